@@ -0,0 +1,23 @@
+package main
+
+import (
+	"log"
+	"log/slog"
+	"os"
+)
+
+// configureLogLevel は環境変数 LOG_LEVEL（debug / info / warn / error、デフォルト: info）から
+// slog のログレベルを設定します。slog の出力は標準の log パッケージと同じ出力先に書き込まれます。
+// log.Printf で出力しているログはレベルに関係なく出力されます。
+func configureLogLevel() {
+	env := os.Getenv("LOG_LEVEL")
+	if env == "" {
+		return
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(env)); err != nil {
+		log.Printf("[WARN] Invalid LOG_LEVEL %q, using default %v", env, slog.LevelInfo)
+		return
+	}
+	slog.SetLogLoggerLevel(level)
+}
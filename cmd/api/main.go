@@ -10,10 +10,8 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 	api "github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/api/handlers"
-	auth "github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/api/middleware"
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database"
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/github"
 	services "github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/services/deck" // 新しいサービスのインポート
@@ -29,6 +27,9 @@ func main() {
 		}
 	}
 
+	// ログレベルを設定（LOG_LEVEL=debug でゲーム開始条件などの詳細ログを出力）
+	configureLogLevel()
+
 	// データベースURLを環境変数から取得
 	databaseURL := os.Getenv("DATABASE_URL")
 	if databaseURL == "" {
@@ -48,75 +49,41 @@ func main() {
 
 	// Deck関連の依存関係の初期化
 	// databaseService.DB を直接リポジトリとサービスに渡す
-	deckRepo := database.NewDeckRepository(databaseService.DB)
-	deckService := services.NewDeckService(databaseService.DB, deckRepo)
+	// 同じデッキでの連戦で対戦開始のたびに配置データを読み込まないよう、短時間キャッシュする
+	deckRepo := database.NewCachedDeckRepository(database.NewDeckRepository(databaseService.DB), database.PlacementCacheTTL, database.PlacementCacheMaxEntries)
+	deckService := services.NewDeckService(databaseService.DB, deckRepo, databaseService)
 
 	// ゲーム結果関連の依存関係の初期化
 	resultRepo := database.NewResultRepository(databaseService.DB)
+	matchRepo := database.NewMatchHistoryRepository(databaseService.DB)
 
-	// テトリスゲームのセッションマネージャーを初期化
-	sessionManager := tetris.NewSessionManager(databaseService, deckRepo, resultRepo)
-	// SessionManager.Run()はNewSessionManager内で既に開始されているため、重複実行を回避
+	// テトリスゲームのセッションマネージャーを初期化（合言葉のハッシュでシャードに分散し、ロック競合を抑える）
+	sessionManager := tetris.NewShardedSessionManager(tetris.DefaultSessionShardCount, databaseService, deckRepo, resultRepo, matchRepo)
+	// 各シャードの Run() は NewSessionManager 内で既に開始されているため、重複実行を回避
 
 	// ハンドラ層の初期化
 	contributionHandler := api.NewContributionHandler(githubService, databaseService)
 	deckSaveHandler := api.NewDeckSaveHandler(deckService) // デッキ保存ハンドラの初期化
 	deckGetHandler := api.NewDeckGetHandler(deckService) // デッキ取得ハンドラの初期化
-	gameHandler := api.NewGameHandler(sessionManager, databaseService) // ゲームハンドラの初期化
+	deckVisibilityHandler := api.NewDeckVisibilityHandler(deckService) // デッキ公開設定ハンドラの初期化
+	gameHandler := api.NewGameHandler(sessionManager, databaseService, deckService) // ゲームハンドラの初期化
 	resultHandler := api.NewResultHandler(resultRepo) // ゲーム結果ハンドラの初期化
 	publicHandler := api.NewPublicHandler(databaseService) // 公開ハンドラの初期化
-	// gorilla/mux ルーターの初期化
-	r := mux.NewRouter()
-
-	// これにより、すべてのリクエストがまずCORSハンドラを通過するようになります。
-	r.Use(auth.CORSHandler())
-
-	// 静的ファイル配信（テスト用）
-	r.HandleFunc("/test_websocket_client.html", func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, "test_websocket_client.html")
+	matchHistoryHandler := api.NewMatchHistoryHandler(matchRepo) // 対戦履歴ハンドラの初期化
+	adminHandler := api.NewAdminHandler(sessionManager, deckRepo) // 管理APIハンドラの初期化
+	// ルーティングの設定（CORSはグローバルに1回だけ適用）
+	r := newRouter(routeHandlers{
+		contribution:   contributionHandler,
+		deckSave:       deckSaveHandler,
+		deckGet:        deckGetHandler,
+		deckVisibility: deckVisibilityHandler,
+		game:           gameHandler,
+		result:         resultHandler,
+		public:         publicHandler,
+		matchHistory:   matchHistoryHandler,
+		admin:          adminHandler,
 	})
 
-	// 認証不要な公開エンドポイント
-	r.HandleFunc("/api/public", api.PublicHandlerFunc).Methods("GET")
-	r.HandleFunc("/api/user/{userID}/display-name", publicHandler.GetUserDisplayNameHandler).Methods("GET", "OPTIONS")
-
-	// データベースから保存済みのGitHub Contributionデータを取得するエンドポイント
-	// GET /api/contributions/{userID}
-	r.HandleFunc("/api/contributions/{userID}", contributionHandler.GetSavedContributionsHandler).Methods("GET", "OPTIONS")
-
-	// GitHubから最新のContributionデータを取得し、データベースを更新するエンドポイント
-	// POST /api/contributions/refresh/{userID} (または PUT)
-	r.HandleFunc("/api/contributions/refresh/{userID}", contributionHandler.GetDailyContributionsAndSaveHandler).Methods("POST")
-
-	// 認証が必要なルートグループを作成
-	protectedRouter := r.PathPrefix("/api/protected").Subrouter()
-	protectedRouter.Use(auth.AuthMiddleware)
-	protectedRouter.Use(auth.CORSHandler()) // CORSミドルウェアを追加
-
-	// 認証済みユーザーのみが自身のデッキを保存できるようにします
-	protectedRouter.Handle("/deck/save", deckSaveHandler).Methods("POST", "OPTIONS")
-	// 認証済みユーザーのデッキを取得できるようにします
-	protectedRouter.Handle("/deck/{userID}", deckGetHandler).Methods("GET", "OPTIONS")
-
-	// テトリスゲーム関連のルート
-	// 認証が必要なゲームルート
-	gameRouter := r.PathPrefix("/api/game").Subrouter()
-	gameRouter.Use(auth.AuthMiddleware)
-	gameRouter.Use(auth.CORSHandler())
-
-	// 合言葉ベースのマッチング・状態取得
-	gameRouter.HandleFunc("/room/passcode/{passcode}/join", gameHandler.JoinRoomByPasscode).Methods("POST", "OPTIONS")
-	gameRouter.HandleFunc("/room/passcode/{passcode}/status", gameHandler.GetRoomStatus).Methods("GET", "OPTIONS")
-	gameRouter.HandleFunc("/room/passcode/{passcode}/delete", gameHandler.DeleteSession).Methods("DELETE", "OPTIONS")
-
-	// WebSocket接続（合言葉ベース）
-	r.HandleFunc("/api/game/ws/{passcode}", gameHandler.HandleWebSocketConnection)
-
-	// ゲーム結果関連のエンドポイント
-	r.HandleFunc("/api/results", resultHandler.GetTopResults).Methods("GET", "OPTIONS")
-	r.HandleFunc("/api/results", resultHandler.PostScore).Methods("POST", "OPTIONS")
-	r.HandleFunc("/api/results/user/{user_id}", resultHandler.GetUserResult).Methods("GET", "OPTIONS")
-
 	// ポート番号の設定
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	api "github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/api/handlers"
+	auth "github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/api/middleware"
+)
+
+// routeHandlers はルーティングに登録するハンドラをまとめたものです。
+type routeHandlers struct {
+	contribution   *api.ContributionHandler
+	deckSave       *api.DeckSaveHandler
+	deckGet        *api.DeckGetHandler
+	deckVisibility *api.DeckVisibilityHandler
+	game           *api.GameHandler
+	result         *api.ResultHandler
+	public         *api.PublicHandler
+	matchHistory   *api.MatchHistoryHandler
+	admin          *api.AdminHandler
+}
+
+// newRouter はAPIのルーティングを設定した gorilla/mux ルーターを返します。
+// CORSはルーター全体で1回だけ適用し、サブルーターでは再適用しません。
+// プリフライト（OPTIONS）はCORSミドルウェアが認証より先に応答するため、各ルートは OPTIONS も受け付けます。
+func newRouter(h routeHandlers) *mux.Router {
+	r := mux.NewRouter()
+
+	// これにより、すべてのリクエストがまずCORSハンドラを通過するようになります。
+	r.Use(auth.CORSHandler())
+	// Accept-Encoding: gzip のクライアントには大きめのレスポンスを圧縮して返します（WebSocketは除外）
+	r.Use(auth.GzipHandler())
+	// Accept-Language からエラーメッセージの言語（日本語・英語）を決めます
+	r.Use(auth.LanguageHandler())
+
+	// 静的ファイル配信（テスト用）
+	r.HandleFunc("/test_websocket_client.html", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "test_websocket_client.html")
+	})
+
+	// 認証不要な公開エンドポイント
+	r.HandleFunc("/api/public", api.PublicHandlerFunc).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/user/{userID}/display-name", h.public.GetUserDisplayNameHandler).Methods("GET", "OPTIONS")
+	// 対戦成績（勝敗数・勝率・直近のフォーム）はプロフィール表示用に認証なしで取得できます
+	r.HandleFunc("/api/user/{userID}/record", h.matchHistory.GetUserRecord).Methods("GET", "OPTIONS")
+
+	// データベースから保存済みのGitHub Contributionデータを取得するエンドポイント
+	// GET /api/contributions/{userID}
+	// v2 と同じく認証は任意で、非公開の草は本人のトークンがある場合だけ返します。
+	r.Handle("/api/contributions/{userID}", auth.OptionalAuthMiddleware(http.HandlerFunc(h.contribution.GetSavedContributionsHandler))).Methods("GET", "OPTIONS")
+	// GET /api/v2/contributions/{userID}
+	// 日別データに加えて最終取得日時（fetched_at）と鮮度（stale）を返します。旧形式（配列直返し）は非推奨です。
+	// 認証は任意で、トークンがあれば検証して本人が自分の非公開の草を取得できるようにします。
+	r.Handle("/api/v2/contributions/{userID}", auth.OptionalAuthMiddleware(http.HandlerFunc(h.contribution.GetSavedContributionsV2Handler))).Methods("GET", "OPTIONS")
+
+	// DBを介さずGitHubユーザー名を直接指定してContributionデータを取得するエンドポイント（デモ表示用、DB保存なし）
+	// GET /api/contributions/github/{username}
+	// 無認証で利用できるが、キャッシュを通したうえで1ユーザー名あたりの頻度を制限します。
+	// ユーザー名を変えながらの連打でキャッシュを素通りさせないよう、クライアントのIPアドレスごとにも制限します。
+	r.HandleFunc("/api/contributions/github/{username}", h.contribution.GetGitHubUserContributionsHandler).Methods("GET", "OPTIONS")
+
+	// GitHubから最新のContributionデータを取得し、データベースを更新するエンドポイント
+	// POST /api/contributions/refresh/{userID} (または PUT)
+	// GitHub APIのコストが発生するため認証必須とし、本人のデータのみ更新できます。
+	r.Handle("/api/contributions/refresh/{userID}", auth.AuthMiddleware(http.HandlerFunc(h.contribution.GetDailyContributionsAndSaveHandler))).Methods("POST", "OPTIONS")
+
+	// 認証が必要なルートグループを作成（CORSは親ルーターで適用済み）
+	protectedRouter := r.PathPrefix("/api/protected").Subrouter()
+	protectedRouter.Use(auth.AuthMiddleware)
+
+	// 認証済みユーザーのみが自身のデッキを保存できるようにします
+	protectedRouter.Handle("/deck/save", h.deckSave).Methods("POST", "OPTIONS")
+	// 認証済みユーザーが自身のデッキの公開/非公開を切り替えられるようにします
+	protectedRouter.Handle("/deck/visibility", h.deckVisibility).Methods("PUT", "OPTIONS")
+	// デッキを取得できるようにします（他人のデッキは公開デッキの概要のみ）
+	protectedRouter.Handle("/deck/{userID}", h.deckGet).Methods("GET", "OPTIONS")
+	// 認証済みユーザーが対戦相手に自分の草（日別レベル）を公開するかどうかを切り替えられるようにします
+	protectedRouter.HandleFunc("/contributions/visibility", h.contribution.SetContributionVisibilityHandler).Methods("PUT", "OPTIONS")
+
+	// テトリスゲーム関連のルート
+	// 認証が必要なゲームルート（CORSは親ルーターで適用済み）
+	gameRouter := r.PathPrefix("/api/game").Subrouter()
+	gameRouter.Use(auth.AuthMiddleware)
+
+	// 合言葉ベースのマッチング・状態取得
+	gameRouter.HandleFunc("/room/create", h.game.CreateRoom).Methods("POST", "OPTIONS")
+	gameRouter.HandleFunc("/room/passcode/{passcode}/join", h.game.JoinRoomByPasscode).Methods("POST", "OPTIONS")
+	gameRouter.HandleFunc("/room/passcode/{passcode}/status", h.game.GetRoomStatus).Methods("GET", "OPTIONS")
+	gameRouter.HandleFunc("/room/passcode/{passcode}/delete", h.game.DeleteSession).Methods("DELETE", "OPTIONS")
+	gameRouter.HandleFunc("/room/passcode/{passcode}/cancel", h.game.CancelRoom).Methods("POST", "OPTIONS")
+	// 開発環境限定: 両プレイヤーのボードと主要な状態をASCIIで返します（本番では登録しないため404）
+	if api.DebugEndpointsEnabled() {
+		gameRouter.HandleFunc("/debug/{passcode}", h.game.DebugBoard).Methods("GET", "OPTIONS")
+	}
+
+	// 運用確認用の管理API（環境変数 ADMIN_TOKEN の管理者トークンが必要、未設定時は無効）
+	adminRouter := r.PathPrefix("/api/admin").Subrouter()
+	adminRouter.Use(auth.AdminHandler())
+	adminRouter.HandleFunc("/connections", h.admin.GetConnections).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/stats", h.admin.GetStats).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/sessions/{passcode}", h.admin.DeleteSession).Methods("DELETE", "OPTIONS")
+	adminRouter.HandleFunc("/sessions/{passcode}/timeline", h.admin.GetSessionTimeline).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/sessions/{passcode}/players/{userID}/reset-board", h.admin.ResetPlayerBoard).Methods("POST", "OPTIONS")
+
+	// テトリミノ・ブロックの色の対応表（クライアントの起動時に取得するため認証不要）
+	r.HandleFunc("/api/game/tetromino-colors", api.TetrominoColorsHandler).Methods("GET", "OPTIONS")
+
+	// WebSocket接続（合言葉ベース）
+	r.HandleFunc("/api/game/ws/{passcode}", h.game.HandleWebSocketConnection)
+
+	// ゲーム結果関連のエンドポイント
+	r.HandleFunc("/api/results", h.result.GetTopResults).Methods("GET", "OPTIONS")
+	// スコアはセッション終了時にサーバー側で保存するため、クライアントからの直接投稿（POST /api/results）は受け付けません
+	r.HandleFunc("/api/results/user/{userID}", h.result.GetUserResult).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/results/user/{userID}/history", h.result.GetUserResultHistory).Methods("GET", "OPTIONS")
+
+	return r
+}
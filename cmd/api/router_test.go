@@ -0,0 +1,297 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+
+	api "github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/api/handlers"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database/databasetest"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
+)
+
+// newTestRouter は依存サービスなしのハンドラでルーターを作成します（プリフライトはハンドラまで到達しないため）。
+func newTestRouter() http.Handler {
+	return newTestRouterWithContribution(api.NewContributionHandler(nil, nil))
+}
+
+// newTestRouterWithContribution は Contribution のハンドラだけを差し替えたルーターを作成します。
+func newTestRouterWithContribution(contribution *api.ContributionHandler) http.Handler {
+	return newRouter(routeHandlers{
+		contribution:   contribution,
+		deckSave:       api.NewDeckSaveHandler(nil),
+		deckGet:        api.NewDeckGetHandler(nil),
+		deckVisibility: api.NewDeckVisibilityHandler(nil),
+		game:           api.NewGameHandler(nil, nil, nil),
+		result:         api.NewResultHandler(nil),
+		public:         api.NewPublicHandler(nil),
+		matchHistory:   api.NewMatchHistoryHandler(nil),
+		admin:          api.NewAdminHandler(nil, nil),
+	})
+}
+
+// TestPreflight はグローバル・認証付きサブルーターの各ルートでプリフライトが認証なしで200を返し、
+// CORSヘッダーが二重に付与されないことをテストします。
+func TestPreflight(t *testing.T) {
+	router := newTestRouter()
+	tests := []struct {
+		path   string
+		method string
+	}{
+		{"/api/public", http.MethodGet},
+		{"/api/user/user-1/record", http.MethodGet},
+		{"/api/contributions/user-1", http.MethodGet},
+		{"/api/v2/contributions/user-1", http.MethodGet},
+		{"/api/contributions/refresh/user-1", http.MethodPost},
+		{"/api/contributions/github/octocat", http.MethodGet},
+		{"/api/protected/deck/save", http.MethodPost},
+		{"/api/protected/deck/visibility", http.MethodPut},
+		{"/api/protected/deck/user-1", http.MethodGet},
+		{"/api/protected/contributions/visibility", http.MethodPut},
+		{"/api/game/room/create", http.MethodPost},
+		{"/api/game/room/passcode/abc/join", http.MethodPost},
+		{"/api/game/room/passcode/abc/delete", http.MethodDelete},
+		{"/api/results", http.MethodGet},
+		{"/api/results/user/user-1/history", http.MethodGet},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodOptions, tt.path, nil)
+		req.Header.Set("Origin", "http://localhost:3000")
+		req.Header.Set("Access-Control-Request-Method", tt.method)
+		req.Header.Set("Access-Control-Request-Headers", "authorization,content-type")
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code, tt.path)
+		assert.Equal(t, []string{"http://localhost:3000"}, rec.Header().Values("Access-Control-Allow-Origin"), tt.path)
+		assert.Len(t, rec.Header().Values("Access-Control-Allow-Methods"), 1, tt.path)
+	}
+}
+
+// TestPreflight_DisallowedOrigin は許可されていないオリジンのプリフライトにCORSヘッダーが付かないことをテストします。
+func TestPreflight_DisallowedOrigin(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+	req := httptest.NewRequest(http.MethodOptions, "/api/protected/deck/save", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	rec := httptest.NewRecorder()
+
+	newTestRouter().ServeHTTP(rec, req)
+
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+}
+
+// TestGitHubUserContributions_RateLimited は無認証のGitHubユーザー名指定の取得が、
+// 形式不正を400で弾き、1クライアントあたりの上限を超えると対象のユーザー名によらず429を返すことをテストします。
+func TestGitHubUserContributions_RateLimited(t *testing.T) {
+	// トークン未設定ならGitHub APIを呼ばずに500で返るため、頻度制限だけを確認できる
+	t.Setenv("GITHUB_TOKEN", "")
+	router := newTestRouter()
+
+	get := func(path, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusBadRequest, get("/api/contributions/github/-invalid-", "192.0.2.1:1000").Code)
+
+	for i := 0; i < api.GitHubLookupRateLimit; i++ {
+		assert.Equal(t, http.StatusInternalServerError, get(fmt.Sprintf("/api/contributions/github/user-%d", i), "192.0.2.1:1000").Code)
+	}
+	rec := get("/api/contributions/github/someone-else", "192.0.2.1:2000")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code, "ユーザー名を変えても同じクライアントとして制限されるはず")
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusInternalServerError, get("/api/contributions/github/octocat", "192.0.2.2:1000").Code, "別のクライアントは制限されないはず")
+}
+
+// TestGitHubUserContributions_RateLimitedPerUsername は同じGitHubユーザー名への取得が、
+// クライアントを変えても1ユーザー名あたりの上限（大文字・小文字を区別しない）を超えると429になることをテストします。
+func TestGitHubUserContributions_RateLimitedPerUsername(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	router := newTestRouter()
+
+	get := func(path string, client int) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = fmt.Sprintf("198.51.100.%d:1000", client)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < api.GitHubUsernameLookupRateLimit; i++ {
+		assert.Equal(t, http.StatusInternalServerError, get("/api/contributions/github/octocat", i).Code)
+	}
+	rec := get("/api/contributions/github/OctoCat", 200)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code, "大文字・小文字を変えても同じユーザー名として制限されるはず")
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusInternalServerError, get("/api/contributions/github/someone-else", 200).Code, "別のユーザー名は制限されないはず")
+}
+
+// TestAdminConnections_RequiresAdminToken は管理APIが ADMIN_TOKEN 未設定時は存在しない扱い（404）になり、
+// トークンが無い・一致しない場合はハンドラに到達せずに弾かれることをテストします。
+func TestAdminConnections_RequiresAdminToken(t *testing.T) {
+	get := func(router http.Handler, authorization string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/connections", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Setenv("ADMIN_TOKEN", "")
+	assert.Equal(t, http.StatusNotFound, get(newTestRouter(), "Bearer anything"))
+
+	t.Setenv("ADMIN_TOKEN", "admin-secret")
+	router := newTestRouter()
+	assert.Equal(t, http.StatusUnauthorized, get(router, ""))
+	assert.Equal(t, http.StatusForbidden, get(router, "Bearer wrong-secret"))
+}
+
+// TestTetrominoColors は色の対応表が認証なしで取得でき、空・お邪魔ブロックを含むことをテストします。
+func TestTetrominoColors(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/game/tetromino-colors", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var palette tetris.ColorPalette
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &palette))
+	assert.Len(t, palette.Pieces, 7)
+	assert.Equal(t, tetris.ColorEntry{Type: int(tetris.TypeT), Name: "T", Color: tetris.ColorT}, palette.Pieces[tetris.TypeT])
+	assert.Equal(t, tetris.ColorEntry{Type: int(tetris.BlockEmpty), Name: "empty", Color: tetris.ColorEmpty}, palette.Blocks[tetris.BlockEmpty])
+	assert.Equal(t, tetris.ColorEntry{Type: int(tetris.BlockGarbage), Name: "garbage", Color: tetris.ColorGarbage}, palette.Blocks[tetris.BlockGarbage])
+}
+
+// TestPostResults_Removed はクライアントからのスコアの直接投稿を受け付けないことをテストします。
+func TestPostResults_Removed(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/results", strings.NewReader(`{"score":999999}`))
+	newTestRouter().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+// TestErrorMessage_AcceptLanguage はエラーメッセージが Accept-Language に応じて日本語・英語で返り、
+// 未対応の言語では日本語になることをテストします（エラーコードは言語によらず同じ）。
+func TestErrorMessage_AcceptLanguage(t *testing.T) {
+	t.Setenv("BYPASS_AUTH", "")
+	router := newTestRouter()
+
+	messageFor := func(acceptLanguage string) (string, string) {
+		req := httptest.NewRequest(http.MethodPost, "/api/protected/deck/save", nil)
+		req.Header.Set("Accept-Language", acceptLanguage)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		var body api.ErrorResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, api.CodeUnauthorized, body.Error.Code)
+		return body.Error.Message, rec.Header().Get("Content-Language")
+	}
+
+	message, lang := messageFor("en-US,en;q=0.9")
+	assert.Equal(t, "Authorization header is required", message, "英語では認証ミドルウェアの詳しいメッセージのはず")
+	assert.Equal(t, "en", lang)
+
+	message, lang = messageFor("ja,en;q=0.5")
+	assert.Equal(t, "認証が必要です。ログインし直してください", message)
+	assert.Equal(t, "ja", lang)
+
+	message, _ = messageFor("fr-FR")
+	assert.Equal(t, "認証が必要です。ログインし直してください", message, "未対応の言語は日本語のはず")
+}
+
+// TestDebugBoard_DisabledInProduction は APP_ENV=development かつ BYPASS_AUTH=true の場合だけデバッグエンドポイントを登録し、
+// 本番環境や設定漏れ（未設定）では登録しないことをテストします。
+// 登録の有無は GET 以外のメソッドへの応答で判定します（登録済みなら405、未登録なら404）。
+func TestDebugBoard_DisabledInProduction(t *testing.T) {
+	post := func() int {
+		rec := httptest.NewRecorder()
+		newTestRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/game/debug/room", nil))
+		return rec.Code
+	}
+
+	for _, env := range []struct{ appEnv, bypassAuth string }{
+		{"production", "true"},
+		{"", "true"},
+		{"development", ""},
+	} {
+		t.Setenv("APP_ENV", env.appEnv)
+		t.Setenv("BYPASS_AUTH", env.bypassAuth)
+		assert.Equal(t, http.StatusNotFound, post(), "登録されないはず: %+v", env)
+	}
+
+	t.Setenv("APP_ENV", "development")
+	t.Setenv("BYPASS_AUTH", "true")
+	assert.Equal(t, http.StatusMethodNotAllowed, post(), "開発環境では登録されるはず")
+}
+
+// signTestToken は SUPABASE_JWT_SECRET で署名した、sub に userID を持つJWTを返します。
+func signTestToken(t *testing.T, secret, userID string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": userID}).SignedString([]byte(secret))
+	assert.NoError(t, err)
+	return token
+}
+
+// TestSavedContributionsV2_Visibility は v2 の保存済みデータ取得が認証を任意とし、
+// 非公開の草はトークンで本人と確認できた場合だけ返し、他人・未認証には403、不正なトークンには401を返すことをテストします。
+func TestSavedContributionsV2_Visibility(t *testing.T) {
+	const secret = "test-jwt-secret"
+	t.Setenv("BYPASS_AUTH", "")
+	t.Setenv("SUPABASE_JWT_SECRET", secret)
+
+	get := func(public bool, authorization string) int {
+		router := newTestRouterWithContribution(api.NewContributionHandlerWithReader(nil, &databasetest.ContributionReader{Public: public}))
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/contributions/user-1", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, get(true, ""), "公開ユーザーは未認証でも取得できるはず")
+	assert.Equal(t, http.StatusForbidden, get(false, ""))
+	assert.Equal(t, http.StatusForbidden, get(false, "Bearer "+signTestToken(t, secret, "user-2")))
+	assert.Equal(t, http.StatusOK, get(false, "Bearer "+signTestToken(t, secret, "user-1")), "本人は非公開でも取得できるはず")
+	assert.Equal(t, http.StatusUnauthorized, get(false, "Bearer "+signTestToken(t, "other-secret", "user-1")))
+}
+
+// TestSavedContributionsV1_Visibility は非推奨の v1 でも非公開の草を他人・未認証に返さず、本人には返すことをテストします。
+func TestSavedContributionsV1_Visibility(t *testing.T) {
+	const secret = "test-jwt-secret"
+	t.Setenv("BYPASS_AUTH", "")
+	t.Setenv("SUPABASE_JWT_SECRET", secret)
+
+	get := func(authorization string) int {
+		router := newTestRouterWithContribution(api.NewContributionHandlerWithReader(nil, &databasetest.ContributionReader{Public: false}))
+		req := httptest.NewRequest(http.MethodGet, "/api/contributions/user-1", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, get(""))
+	assert.Equal(t, http.StatusForbidden, get("Bearer "+signTestToken(t, secret, "user-2")))
+	assert.Equal(t, http.StatusOK, get("Bearer "+signTestToken(t, secret, "user-1")))
+}
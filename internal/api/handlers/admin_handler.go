@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/services/tetris"
+)
+
+// AdminHandler は運用確認用の管理APIのハンドラーを管理する構造体です。
+// ルーターで管理者トークンのミドルウェア（middleware.AdminHandler）の内側に登録します。
+type AdminHandler struct {
+	sessionManager tetris.SessionService
+	placementCache *database.CachedDeckRepository // デッキ配置データのキャッシュ（nil の場合は統計を返さない）
+}
+
+// NewAdminHandler は新しいAdminHandlerインスタンスを作成します。
+func NewAdminHandler(sm tetris.SessionService, placementCache *database.CachedDeckRepository) *AdminHandler {
+	return &AdminHandler{sessionManager: sm, placementCache: placementCache}
+}
+
+// maskUserID はユーザーIDの先頭・末尾4文字だけを残し、間を * で伏せます。
+// 問い合わせのユーザーと照合できる程度に残しつつ、ログやスクリーンショットにIDがそのまま残らないようにします。
+func maskUserID(userID string) string {
+	if len(userID) <= 8 {
+		return strings.Repeat("*", len(userID))
+	}
+	return userID[:4] + strings.Repeat("*", len(userID)-8) + userID[len(userID)-4:]
+}
+
+// GetConnections は接続中のユーザーとアクティブセッションの一覧を返すハンドラーです。
+// GET /api/admin/connections?unmask=true
+// ユーザーIDはデフォルトで部分的にマスクし、unmask=true を指定した場合のみそのまま返します。
+func (h *AdminHandler) GetConnections(w http.ResponseWriter, r *http.Request) {
+	connections := h.sessionManager.ListConnections()
+	if r.URL.Query().Get("unmask") != "true" {
+		for i := range connections {
+			connections[i].UserID = maskUserID(connections[i].UserID)
+		}
+	}
+	sessions := h.sessionManager.ListSessions()
+
+	WriteJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"connected_clients": len(connections),
+		"active_sessions":   len(sessions),
+		"connections":       connections,
+		"sessions":          sessions,
+	})
+}
+
+// GetStats はセッションの稼働状況とデッキ配置キャッシュのヒット率を返すハンドラーです。
+// GET /api/admin/stats
+func (h *AdminHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	response := map[string]interface{}{
+		"sessions": h.sessionManager.Stats(),
+	}
+	if h.placementCache != nil {
+		response["placement_cache"] = h.placementCache.Stats()
+	}
+	WriteJSONResponse(w, http.StatusOK, response)
+}
+
+// ResetPlayerBoard はセッションの指定プレイヤーのボードを空にするハンドラーです（デバッグ・特殊モード用）。
+// POST /api/admin/sessions/{passcode}/players/{userID}/reset-board
+// ボディの {"reset_piece": true, "reset_score": true} で現在のピース・スコアも消せます（ボディは省略可能で、省略時はどちらも保持）。
+func (h *AdminHandler) ResetPlayerBoard(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	passcode, userID := vars["passcode"], vars["userID"]
+
+	var opts tetris.BoardResetOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+		RespondError(w, http.StatusBadRequest, CodeInvalidBody, "リクエストボディの解析に失敗しました")
+		return
+	}
+
+	if err := h.sessionManager.ResetPlayerBoard(passcode, userID, opts); err != nil {
+		log.Printf("[AdminHandler] Failed to reset board of %s in passcode %s: %v", maskUserID(userID), passcode, err)
+		switch {
+		case errors.Is(err, tetris.ErrSessionNotFound):
+			RespondError(w, http.StatusNotFound, CodeSessionNotFound, tetris.ErrSessionNotFound.Error())
+		case errors.Is(err, tetris.ErrPlayerNotFound):
+			RespondError(w, http.StatusNotFound, CodeUserNotFound, tetris.ErrPlayerNotFound.Error())
+		case errors.Is(err, tetris.ErrNotPlaying):
+			RespondError(w, http.StatusConflict, CodeRoomInProgress, "終了済みのセッションのボードはリセットできません")
+		default:
+			RespondError(w, http.StatusInternalServerError, CodeInternalError, "ボードのリセットに失敗しました")
+		}
+		return
+	}
+
+	WriteJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"passcode":    passcode,
+		"reset_piece": opts.ResetPiece,
+		"reset_score": opts.ResetScore,
+	})
+}
+
+// DeleteSession は合言葉のセッションを削除するハンドラーです。
+// DELETE /api/admin/sessions/{passcode}
+// 参加者による削除と異なり、対戦中のセッションはどちらの負けにもせず中止（cancelled）として終了させます。
+func (h *AdminHandler) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	passcode := mux.Vars(r)["passcode"]
+	if _, ok := h.sessionManager.GetGameSession(passcode); !ok {
+		RespondError(w, http.StatusNotFound, CodeSessionNotFound, tetris.ErrSessionNotFound.Error())
+		return
+	}
+
+	if err := h.sessionManager.DeleteSession(passcode); err != nil {
+		log.Printf("[AdminHandler] Failed to delete session %s: %v", passcode, err)
+		// 確認から削除までの間に終了・掃除で消えた場合も見つからない扱いにする
+		if errors.Is(err, tetris.ErrSessionNotFound) {
+			RespondError(w, http.StatusNotFound, CodeSessionNotFound, tetris.ErrSessionNotFound.Error())
+			return
+		}
+		RespondError(w, http.StatusInternalServerError, CodeInternalError, "セッションの削除に失敗しました")
+		return
+	}
+	log.Printf("[AdminHandler] Deleted session %s", passcode)
+	WriteJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"passcode": passcode,
+		"deleted":  true,
+	})
+}
+
+// GetSessionTimeline はセッションの状態遷移の記録（タイムライン）を返すハンドラーです。
+// GET /api/admin/sessions/{passcode}/timeline
+// 記録は新しいものから MaxStateTransitions 件まで保持し、dropped は上限を超えて捨てた件数です。
+func (h *AdminHandler) GetSessionTimeline(w http.ResponseWriter, r *http.Request) {
+	passcode := mux.Vars(r)["passcode"]
+	session, ok := h.sessionManager.GetGameSession(passcode)
+	if !ok {
+		RespondError(w, http.StatusNotFound, CodeSessionNotFound, tetris.ErrSessionNotFound.Error())
+		return
+	}
+
+	transitions, dropped := session.Timeline()
+	WriteJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"passcode":    passcode,
+		"transitions": transitions,
+		"dropped":     dropped,
+	})
+}
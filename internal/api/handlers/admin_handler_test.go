@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/services/tetris"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAdminSessionService は固定の接続・セッション一覧を返すテスト用SessionServiceです。
+type fakeAdminSessionService struct {
+	tetris.SessionService
+	resetOpts tetris.BoardResetOptions // 最後にボードのリセットで受け取った指定
+	session   *tetris.GameSession      // 合言葉 "room" で返すセッション
+}
+
+func (f *fakeAdminSessionService) ListConnections() []tetris.ConnectionInfo {
+	return []tetris.ConnectionInfo{{UserID: "0123456789abcdef", RoomID: "room", State: tetris.ConnectionStateConnected}}
+}
+
+func (f *fakeAdminSessionService) ListSessions() []tetris.SessionSummary {
+	return []tetris.SessionSummary{{Passcode: "room", Status: "waiting", Players: 1, ConnectedPlayers: 1}}
+}
+
+func (f *fakeAdminSessionService) GetGameSession(passcode string) (*tetris.GameSession, bool) {
+	if passcode != "room" || f.session == nil {
+		return nil, false
+	}
+	return f.session, true
+}
+
+func (f *fakeAdminSessionService) ResetPlayerBoard(passcode, userID string, opts tetris.BoardResetOptions) error {
+	if passcode != "room" {
+		return fmt.Errorf("passcode %s: %w", passcode, tetris.ErrSessionNotFound)
+	}
+	if userID != "player1" {
+		return fmt.Errorf("user %s: %w", userID, tetris.ErrPlayerNotFound)
+	}
+	f.resetOpts = opts
+	return nil
+}
+
+// DeleteSession は確認の後に終了・掃除でセッションが消えた場合を再現するため、常に ErrSessionNotFound を返します。
+func (f *fakeAdminSessionService) DeleteSession(passcode string) error {
+	return fmt.Errorf("passcode %s: %w", passcode, tetris.ErrSessionNotFound)
+}
+
+// TestGetConnections_MasksUserID はユーザーIDがデフォルトで部分マスクされ、unmask=true の場合のみそのまま返ることをテストします。
+func TestGetConnections_MasksUserID(t *testing.T) {
+	handler := NewAdminHandler(&fakeAdminSessionService{}, nil)
+
+	get := func(url string) map[string]json.RawMessage {
+		rec := httptest.NewRecorder()
+		handler.GetConnections(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var body map[string]json.RawMessage
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return body
+	}
+	userIDOf := func(body map[string]json.RawMessage) string {
+		var connections []tetris.ConnectionInfo
+		assert.NoError(t, json.Unmarshal(body["connections"], &connections))
+		return connections[0].UserID
+	}
+
+	body := get("/api/admin/connections")
+	assert.Equal(t, "0123********cdef", userIDOf(body))
+	assert.JSONEq(t, `[{"passcode":"room","status":"waiting","players":1,"connected_players":1}]`, string(body["sessions"]))
+
+	assert.Equal(t, "0123456789abcdef", userIDOf(get("/api/admin/connections?unmask=true")))
+}
+
+// TestResetPlayerBoard はボディの指定がそのまま渡され（省略時はピース・スコアを保持）、
+// セッション・プレイヤーが見つからない場合は404を返すことをテストします。
+func TestResetPlayerBoard(t *testing.T) {
+	sm := &fakeAdminSessionService{}
+	router := mux.NewRouter()
+	router.HandleFunc("/api/admin/sessions/{passcode}/players/{userID}/reset-board", NewAdminHandler(sm, nil).ResetPlayerBoard)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, post("/api/admin/sessions/room/players/player1/reset-board", "").Code)
+	assert.Equal(t, tetris.BoardResetOptions{}, sm.resetOpts)
+
+	assert.Equal(t, http.StatusOK, post("/api/admin/sessions/room/players/player1/reset-board", `{"reset_piece":true,"reset_score":true}`).Code)
+	assert.Equal(t, tetris.BoardResetOptions{ResetPiece: true, ResetScore: true}, sm.resetOpts)
+
+	assert.Equal(t, http.StatusBadRequest, post("/api/admin/sessions/room/players/player1/reset-board", `{`).Code)
+
+	rec := post("/api/admin/sessions/missing/players/player1/reset-board", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), string(CodeSessionNotFound))
+
+	rec = post("/api/admin/sessions/room/players/stranger/reset-board", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), string(CodeUserNotFound))
+}
+
+// TestGetSessionTimeline はセッションの状態遷移の記録を返し、セッションが無い場合は404を返すことをテストします。
+func TestGetSessionTimeline(t *testing.T) {
+	session, err := tetris.NewGameSession("room", "player1", nil, nil)
+	assert.NoError(t, err)
+	router := mux.NewRouter()
+	router.HandleFunc("/api/admin/sessions/{passcode}/timeline", NewAdminHandler(&fakeAdminSessionService{session: session}, nil).GetSessionTimeline)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/sessions/room/timeline", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Passcode    string                   `json:"passcode"`
+		Transitions []tetris.StateTransition `json:"transitions"`
+		Dropped     int                      `json:"dropped"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "room", body.Passcode)
+	if assert.Len(t, body.Transitions, 1) {
+		assert.Equal(t, "waiting", body.Transitions[0].To)
+		assert.Equal(t, "room_created", body.Transitions[0].Detail)
+	}
+	assert.Equal(t, 0, body.Dropped)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/sessions/missing/timeline", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), string(CodeSessionNotFound))
+}
+
+// TestDeleteSession_RemovedConcurrently は存在を確認した後の削除までにセッションが消えていた場合も、500ではなく404を返すことをテストします。
+func TestDeleteSession_RemovedConcurrently(t *testing.T) {
+	session, err := tetris.NewGameSession("room", "player1", nil, nil)
+	assert.NoError(t, err)
+	router := mux.NewRouter()
+	router.HandleFunc("/api/admin/sessions/{passcode}", NewAdminHandler(&fakeAdminSessionService{session: session}, nil).DeleteSession)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/admin/sessions/room", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), string(CodeSessionNotFound))
+}
@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"log"
@@ -12,117 +17,382 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database"
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/github"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
 )
 
+// SkippedContributionsHeader は保存時に不正値としてスキップした貢献データの件数を返すレスポンスヘッダーです。
+const SkippedContributionsHeader = "X-Skipped-Contributions"
+
+// TotalContributionsHeader はGitHubから取得した期間内の合計貢献数を返すレスポンスヘッダーです。
+const TotalContributionsHeader = "X-Total-Contributions"
+
+// GitHubLookupRateLimit と GitHubLookupRateWindow は、GitHubユーザー名を直接指定した取得で
+// 1クライアント（IPアドレス）あたり許可するリクエスト数とその期間です。
+// GitHubUsernameLookupRateLimit は同じ期間に1ユーザー名あたり許可するリクエスト数（クライアントをまたいだ合計）です。
+const (
+	GitHubLookupRateLimit         = 10
+	GitHubUsernameLookupRateLimit = 30
+	GitHubLookupRateWindow        = time.Minute
+)
+
+// ContributionSaveTimeout は再取得した貢献データの保存にかける時間の上限です。
+const ContributionSaveTimeout = 10 * time.Second
+
+// githubUsernamePattern はGitHubユーザー名の形式（英数字とハイフン、先頭・末尾以外のハイフン、最大39文字）です。
+var githubUsernamePattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9]|-[A-Za-z0-9]){0,38}$`)
+
+// SavedContributionReader は保存済みの貢献データと草の公開設定の取得です（*database.DatabaseService が実装します）。
+type SavedContributionReader interface {
+	GetSavedContributions(ctx context.Context, userID string) ([]models.DailyContribution, *time.Time, error)
+	GetContributionVisibility(ctx context.Context, userID string) (bool, error)
+}
+
 // ContributionHandler handles HTTP requests related to GitHub contributions.
 type ContributionHandler struct {
 	GitHubService   *github.GitHubService
 	DatabaseService *database.DatabaseService
+
+	savedReader     SavedContributionReader // 保存済みデータと公開設定の取得元（DatabaseService が nil の場合は nil）
+	lookupLimiter   *keyRateLimiter         // クライアントごとの直接取得の頻度制限
+	usernameLimiter *keyRateLimiter         // 対象のGitHubユーザー名ごとの直接取得の頻度制限
+	refreshGuard    *refreshGuard           // 再取得（取得＋保存）の重複実行防止
 }
 
 // NewContributionHandler creates a new instance of ContributionHandler.
 func NewContributionHandler(ghService *github.GitHubService, dbService *database.DatabaseService) *ContributionHandler {
-	return &ContributionHandler{
+	h := &ContributionHandler{
 		GitHubService:   ghService,
 		DatabaseService: dbService,
+		lookupLimiter:   newKeyRateLimiter(GitHubLookupRateLimit, GitHubLookupRateWindow),
+		usernameLimiter: newKeyRateLimiter(GitHubUsernameLookupRateLimit, GitHubLookupRateWindow),
+		refreshGuard:    newRefreshGuard(RefreshIdempotencyTTL),
+	}
+	if dbService != nil {
+		h.savedReader = dbService
 	}
+	return h
+}
+
+// NewContributionHandlerWithReader は保存済みデータの取得元を指定して ContributionHandler を作成します。
+// ルーター経由のテストで、データベースの代わりに保存済みデータと公開設定を差し替えるために使います。
+func NewContributionHandlerWithReader(ghService *github.GitHubService, reader SavedContributionReader) *ContributionHandler {
+	h := NewContributionHandler(ghService, nil)
+	h.savedReader = reader
+	return h
 }
 
 // GetDailyContributionsAndSaveHandler fetches a user's daily contributions from GitHub and saves them to the database.
 // POST /api/contributions/refresh/{userID} (推奨されるエンドポイント)
 // 現在の GET /api/contributions/{userID} の機能をこちらに移動
+// 認証必須で、URLのuserIDが認証済みユーザーと一致しない場合は403を返します。
 func (h *ContributionHandler) GetDailyContributionsAndSaveHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	userID := vars["userID"]
 
 	if userID == "" {
-		http.Error(w, "ユーザーIDが指定されていません。", http.StatusBadRequest)
+		RespondError(w, http.StatusBadRequest, CodeBadRequest, "ユーザーIDが指定されていません。")
 		return
 	}
 
-	// NOTE: 本来は認証ミドルウェアからuserIDを取得し、それを認証済みユーザーのIDとして使用します。
-	// 例えば、userID := r.Context().Value("userID").(string) のように。
-	// ここはデバッグ/テスト用なので、DBに存在するユーザーのUUIDをハードコードしてください。
-	// 例: userID = "f47ac10b-58cc-4372-a567-0e02b2c3d4e5"
+	// 共通のGitHubトークンのレート制限を他人に消費させないよう、本人のデータのみ更新を許可する
+	authenticatedUserID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		RespondError(w, http.StatusUnauthorized, CodeUnauthorized, "未認証: ユーザーIDが見つかりません")
+		return
+	}
+	if authenticatedUserID != userID {
+		log.Printf("ユーザー %s が他のユーザー %s の貢献データ更新を試みました", authenticatedUserID, userID)
+		RespondError(w, http.StatusForbidden, CodeForbidden, "他のユーザーの貢献データは更新できません")
+		return
+	}
 
 	githubToken := os.Getenv("GITHUB_TOKEN")
 	if githubToken == "" {
 		log.Println("警告: GITHUB_TOKEN 環境変数が設定されていません。")
-		http.Error(w, "サーバーサイドにGitHub Personal Access Tokenが設定されていません。", http.StatusInternalServerError)
+		RespondError(w, http.StatusInternalServerError, CodeServerConfigError, "サーバーサイドにGitHub Personal Access Tokenが設定されていません。")
 		return
 	}
 
 	// データベースサービスを使って、userID (UUID) からGitHubユーザー名を取得
-	githubUsername, err := h.DatabaseService.GetGitHubUsernameByUserID(userID)
+	githubUsername, err := h.DatabaseService.GetGitHubUsernameByUserID(r.Context(), userID)
 	if err != nil {
 		log.Printf("GetGitHubUsernameByUserID エラー: %v", err)
-		http.Error(w, fmt.Sprintf("ユーザーID '%s' に対応するGitHubユーザー名が見つからないか、データベースエラーが発生しました: %v", userID, err), http.StatusInternalServerError)
+		if errors.Is(err, database.ErrUserNotFound) {
+			RespondError(w, http.StatusNotFound, CodeUserNotFound, fmt.Sprintf("ユーザーID '%s' に対応するGitHubユーザー名が見つかりません", userID))
+			return
+		}
+		RespondError(w, http.StatusInternalServerError, CodeInternalError, fmt.Sprintf("ユーザーID '%s' のGitHubユーザー名の取得中にデータベースエラーが発生しました: %v", userID, err))
+		return
+	}
+
+	// 日付境界はJST固定（サーバーのTZに依存させない）
+	startDate, endDate := models.ContributionPeriod(time.Now(), models.ContributionWeeks*models.ContributionGridDays) // 8週間 = 56日分
+
+	// タイムアウト後のリトライや連打で取得・保存が重複しないよう、ユーザーID・GitHubユーザー名・期間の冪等キーでまとめる
+	key := refreshIdempotencyKey(userID, githubUsername, startDate, endDate)
+	result, shared, err := h.refreshGuard.Do(r.Context(), key, func() (refreshResult, error) {
+		return h.fetchAndSaveContributions(userID, githubUsername, githubToken, startDate, endDate)
+	})
+	if err != nil {
+		log.Printf("貢献データの再取得に失敗しました: %v", err)
+		switch {
+		case errors.Is(err, errContributionSave):
+			// DBのエラー内容はクライアントに返さず、ログにだけ残す
+			RespondError(w, http.StatusInternalServerError, CodeInternalError, "貢献データのデータベース保存に失敗しました")
+		case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+			RespondError(w, http.StatusServiceUnavailable, CodeRefreshTimeout, "進行中の貢献データ再取得の完了を待てませんでした")
+		default:
+			respondGitHubError(w, err, githubUsername)
+		}
+		return
+	}
+	if shared {
+		log.Printf("ユーザー %s (GitHub: %s) の貢献データ再取得は重複リクエストのため、進行中・完了済みの結果を返しました", userID, githubUsername)
+	}
+	dailyContributions, skipped := result.contributions, result.skipped
+
+	// レスポンスボディ（配列）の形は変えず、不正値としてスキップした件数と合計貢献数はヘッダーで返す
+	w.Header().Set(SkippedContributionsHeader, strconv.Itoa(skipped))
+	w.Header().Set(TotalContributionsHeader, strconv.Itoa(result.totalContributions))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(dailyContributions); err != nil {
+		log.Printf("レスポンスのJSONエンコードに失敗しました: %v", err)
+		RespondError(w, http.StatusInternalServerError, CodeInternalError, "レスポンスのJSONエンコードに失敗しました")
+	}
+}
+
+// GetSavedContributionsV2Handler は保存済みの貢献データを、GitHubから取得した日時と一緒に返すハンドラーです。
+// GET /api/v2/contributions/{userID}
+// レスポンスは {"fetched_at":..., "stale":..., "contributions":[...]} の形で、
+// クライアントは stale（取得から models.ContributionStaleAfter 以上経過）を見て再取得を促せます。
+// 草を非公開に設定しているユーザーのデータは本人以外には返さず 403 Forbidden とします。
+// v1 と同じく、If-None-Match が内容のETagと一致する場合は 304 Not Modified を返します。
+func (h *ContributionHandler) GetSavedContributionsV2Handler(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["userID"]
+	if userID == "" {
+		RespondError(w, http.StatusBadRequest, CodeBadRequest, "ユーザーIDが指定されていません。")
 		return
 	}
 
-	endDate := time.Now()
-	startDate := endDate.AddDate(0, 0, -8*7+1) // 8週間 = 56日前
+	if h.savedReader == nil {
+		RespondError(w, http.StatusInternalServerError, CodeServerConfigError, "DatabaseServiceが初期化されていません。")
+		return
+	}
 
-	// 取得したgithubUsernameを使ってGitHub APIを呼び出す
-	dailyContributions, err := h.GitHubService.GetDailyContributions(githubUsername, githubToken, startDate, endDate)
+	if !h.authorizeSavedContributions(w, r, userID) {
+		return
+	}
+
+	contributions, fetchedAt, err := h.savedReader.GetSavedContributions(r.Context(), userID)
+	if err != nil {
+		log.Printf("保存済み貢献データの取得に失敗しました: %v", err)
+		RespondError(w, http.StatusInternalServerError, CodeInternalError, "保存済み貢献データの取得に失敗しました")
+		return
+	}
+
+	// stale が切り替わった場合も内容が変わるため、ETagは内容から計算すれば一貫する
+	WriteJSONResponseWithETag(w, r, models.NewSavedContributions(contributions, fetchedAt, time.Now()))
+}
+
+// authorizeSavedContributions は草の公開設定を確認し、userID の保存済みデータを返してよいかを判定します。
+// 非公開のユーザーのデータは本人（認証済みのリクエスト）以外には 403 Forbidden とし、
+// 返せない場合はエラーレスポンスを書き込んで false を返します。
+func (h *ContributionHandler) authorizeSavedContributions(w http.ResponseWriter, r *http.Request, userID string) bool {
+	public, err := h.savedReader.GetContributionVisibility(r.Context(), userID)
 	if err != nil {
-		fmt.Printf("GitHub貢献データの取得に失敗しました: %v\n", err)
-		http.Error(w, fmt.Sprintf("GitHub貢献データの取得に失敗しました: %v", err), http.StatusInternalServerError)
+		if errors.Is(err, database.ErrUserNotFound) {
+			RespondError(w, http.StatusNotFound, CodeUserNotFound, "ユーザーが見つかりません。")
+			return false
+		}
+		log.Printf("草の公開設定の取得に失敗しました: %v", err)
+		RespondError(w, http.StatusInternalServerError, CodeInternalError, "草の公開設定の取得に失敗しました")
+		return false
+	}
+	if requesterID, _ := GetUserIDFromContext(r.Context()); !public && requesterID != userID {
+		RespondError(w, http.StatusForbidden, CodeForbidden, "このユーザーは草を公開していません。")
+		return false
+	}
+	return true
+}
+
+// SetContributionVisibilityHandler は対戦相手に自分の草（日別レベル）を公開するかどうかを設定するハンドラーです。
+// PUT /api/protected/contributions/visibility （ボディ: {"isPublic": bool}）
+// 認証済みユーザー自身の設定のみを変更でき、次に参加する対戦から反映されます。
+func (h *ContributionHandler) SetContributionVisibilityHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		RespondError(w, http.StatusUnauthorized, CodeUnauthorized, "未認証: ユーザーIDが見つかりません")
 		return
 	}
 
-	// 取得したデータをデータベースに保存
-	if h.DatabaseService != nil {
-		err = h.DatabaseService.SaveContributions(userID, dailyContributions)
-		if err != nil {
-			fmt.Printf("貢献データのデータベース保存に失敗しました: %v\n", err)
-			http.Error(w, fmt.Sprintf("貢献データのデータベース保存に失敗しました: %v", err), http.StatusInternalServerError)
+	var req models.ContributionVisibilityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, http.StatusBadRequest, CodeInvalidBody, "不正なリクエスト: 無効なリクエストボディです")
+		return
+	}
+
+	if h.DatabaseService == nil {
+		RespondError(w, http.StatusInternalServerError, CodeServerConfigError, "DatabaseServiceが初期化されていません。")
+		return
+	}
+
+	if err := h.DatabaseService.SetContributionVisibility(r.Context(), userID, req.IsPublic); err != nil {
+		log.Printf("ユーザー %s の草の公開設定の更新に失敗しました: %v", userID, err)
+		if errors.Is(err, database.ErrUserNotFound) {
+			RespondError(w, http.StatusNotFound, CodeUserNotFound, "ユーザーが見つかりません")
 			return
 		}
-		fmt.Printf("ユーザー %s (GitHub: %s) の貢献データをデータベースに保存しました。\n", userID, githubUsername)
-	} else {
-		fmt.Println("警告: DatabaseServiceが初期化されていません。貢献データはデータベースに保存されません。")
+		RespondError(w, http.StatusInternalServerError, CodeInternalError, "草の公開設定の更新に失敗しました")
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(dailyContributions); err != nil {
-		fmt.Printf("レスポンスのJSONエンコードに失敗しました: %v\n", err)
-		http.Error(w, "レスポンスのJSONエンコードに失敗しました", http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":  "草の公開設定を更新しました",
+		"isPublic": req.IsPublic,
+	})
+}
+
+// respondGitHubError はGitHubからの貢献データ取得のエラーを種別ごとのHTTPレスポンスに変換します。
+// レート制限は Retry-After 付きの429、トークンの不備はサーバーの設定ミスとして500、ユーザー不在は404を返します。
+func respondGitHubError(w http.ResponseWriter, err error, githubUsername string) {
+	var rateLimitErr *github.RateLimitError
+	switch {
+	case errors.Is(err, github.ErrUserNotFound):
+		RespondError(w, http.StatusNotFound, CodeUserNotFound, fmt.Sprintf("GitHubユーザー '%s' が見つかりません", githubUsername))
+	case errors.Is(err, github.ErrRateLimited):
+		retryAfter := github.DefaultRateLimitRetryAfter
+		if errors.As(err, &rateLimitErr) {
+			retryAfter = rateLimitErr.RetryAfter
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+		RespondError(w, http.StatusTooManyRequests, CodeRateLimited, "GitHub APIのレート制限に達しました。しばらく待ってから再試行してください。")
+	case errors.Is(err, github.ErrBadCredentials):
+		RespondError(w, http.StatusInternalServerError, CodeServerConfigError, "サーバーに設定されたGitHubのトークンが無効か、権限が不足しています。")
+	default:
+		RespondError(w, http.StatusInternalServerError, CodeGitHubAPIError, fmt.Sprintf("GitHub貢献データの取得に失敗しました: %v", err))
 	}
 }
 
+// errContributionSave は再取得した貢献データのデータベース保存に失敗したことを表します。
+var errContributionSave = errors.New("貢献データのデータベース保存に失敗しました")
+
+// fetchAndSaveContributions はGitHubから期間内の貢献データを取得し、データベースに保存します。
+// 保存は日付単位のupsertなので、同じ入力で何度実行しても同じ結果になります。
+// 呼び出し元のリクエストが切断されても保存を完了させるため、リクエストのコンテキストは使いません。
+func (h *ContributionHandler) fetchAndSaveContributions(userID, githubUsername, githubToken string, startDate, endDate time.Time) (refreshResult, error) {
+	calendar, err := h.GitHubService.GetContributionCalendar(githubUsername, githubToken, startDate, endDate)
+	if err != nil {
+		return refreshResult{}, err
+	}
+
+	result := refreshResult{contributions: calendar.Days, totalContributions: calendar.TotalContributions}
+	if len(calendar.Days) == 0 {
+		// GitHubから空のカレンダーが返った場合は既存データを消さないよう保存をスキップする
+		log.Printf("ユーザー %s (GitHub: %s) の貢献データが空のため、データベースの更新をスキップしました", userID, githubUsername)
+		return result, nil
+	}
+	if h.DatabaseService == nil {
+		log.Println("警告: DatabaseServiceが初期化されていません。貢献データはデータベースに保存されません。")
+		return result, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ContributionSaveTimeout)
+	defer cancel()
+	result.contributions, result.skipped, err = h.DatabaseService.SaveContributions(ctx, userID, calendar.Days)
+	if err != nil {
+		return refreshResult{}, fmt.Errorf("%w: %v", errContributionSave, err)
+	}
+	log.Printf("ユーザー %s (GitHub: %s) の貢献データをデータベースに保存しました。", userID, githubUsername)
+	return result, nil
+}
+
 // GetSavedContributionsHandler fetches saved daily contributions from the database.
 // GET /api/contributions/{userID}
+// レスポンスには内容から計算したETagを付け、If-None-Match が一致する場合は 304 Not Modified を返します。
+// 日別データの配列をそのまま返す旧形式です。取得日時を含む GET /api/v2/contributions/{userID} への移行を促すため、
+// Deprecation ヘッダーと後継バージョンへの Link ヘッダーを付けます。
+// v2 と同じく、草を非公開に設定しているユーザーのデータは本人以外には返さず 403 Forbidden とします。
 func (h *ContributionHandler) GetSavedContributionsHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	userID := vars["userID"]
 
 	if userID == "" {
-		http.Error(w, "ユーザーIDが指定されていません。", http.StatusBadRequest)
+		RespondError(w, http.StatusBadRequest, CodeBadRequest, "ユーザーIDが指定されていません。")
 		return
 	}
 
-	// NOTE: 本来は認証ミドルウェアからuserIDを取得し、それを認証済みユーザーのIDとして使用します。
-	// 例: userID := r.Context().Value("userID").(string) のように。
-	// ここはデバッグ/テスト用なので、DBに存在するユーザーのUUIDをハードコードしてください。
-	// 例: userID = "f47ac10b-58cc-4372-a567-0e02b2c3d4e5"
+	if h.savedReader == nil {
+		RespondError(w, http.StatusInternalServerError, CodeServerConfigError, "DatabaseServiceが初期化されていません。")
+		return
+	}
 
-	if h.DatabaseService == nil {
-		http.Error(w, "DatabaseServiceが初期化されていません。", http.StatusInternalServerError)
+	// 非推奨の v1 からも非公開設定を迂回できないよう、v2 と同じく本人以外には返さない
+	if !h.authorizeSavedContributions(w, r, userID) {
 		return
 	}
 
-	// データベースから保存済みの貢献データを取得
-	dailyContributions, err := h.DatabaseService.GetContributionsByUserID(userID)
+	// データベースから保存済みの貢献データを取得（取得日時は v1 では返さない）
+	dailyContributions, _, err := h.savedReader.GetSavedContributions(r.Context(), userID)
 	if err != nil {
 		fmt.Printf("保存済み貢献データの取得に失敗しました: %v\n", err)
-		http.Error(w, fmt.Sprintf("保存済み貢献データの取得に失敗しました: %v", err), http.StatusInternalServerError)
+		RespondError(w, http.StatusInternalServerError, CodeInternalError, fmt.Sprintf("保存済み貢献データの取得に失敗しました: %v", err))
+		return
+	}
+
+	w.Header().Set("Deprecation", "true")
+	w.Header().Set("Link", fmt.Sprintf("</api/v2/contributions/%s>; rel=\"successor-version\"", userID))
+	// 変わっていないデータを再転送しないよう、内容のETagが一致すれば 304 を返す
+	WriteJSONResponseWithETag(w, r, dailyContributions)
+}
+
+// GetGitHubUserContributionsHandler fetches contributions directly by GitHub username without touching the database.
+// GET /api/contributions/github/{username}
+// アカウント未登録の人の草をデモ表示するためのエンドポイントで、取得結果はDBに保存しません。
+// 無認証で叩けるため、GitHub APIの消費を抑えるよう必ずキャッシュを通し、頻度も制限します。
+// 制限は対象のユーザー名ごと（大文字・小文字を区別しない）と、ユーザー名を変えながらの連打でキャッシュを素通りさせないための
+// クライアントのIPアドレスごとの2段階です。
+func (h *ContributionHandler) GetGitHubUserContributionsHandler(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+	if !githubUsernamePattern.MatchString(username) {
+		RespondError(w, http.StatusBadRequest, CodeBadRequest, "GitHubユーザー名の形式が正しくありません。")
+		return
+	}
+
+	if ok, retryAfter := h.lookupLimiter.Allow(clientIP(r)); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+		RespondError(w, http.StatusTooManyRequests, CodeRateLimited, "GitHubユーザーの貢献データ取得のリクエストが多すぎます。しばらく待ってから再試行してください。")
+		return
+	}
+	// GitHubのユーザー名は大文字・小文字を区別しないため、表記を変えて同じユーザーの制限を回避させない
+	if ok, retryAfter := h.usernameLimiter.Allow(strings.ToLower(username)); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+		RespondError(w, http.StatusTooManyRequests, CodeRateLimited, "このGitHubユーザーの貢献データ取得のリクエストが多すぎます。しばらく待ってから再試行してください。")
 		return
 	}
 
+	githubToken := os.Getenv("GITHUB_TOKEN")
+	if githubToken == "" {
+		log.Println("警告: GITHUB_TOKEN 環境変数が設定されていません。")
+		RespondError(w, http.StatusInternalServerError, CodeServerConfigError, "サーバーサイドにGitHub Personal Access Tokenが設定されていません。")
+		return
+	}
+
+	// 期間は保存済みデータの更新と同じ8週間（日付境界はJST固定）
+	startDate, endDate := models.ContributionPeriod(time.Now(), models.ContributionWeeks*models.ContributionGridDays)
+
+	calendar, err := h.GitHubService.GetContributionCalendarCached(username, githubToken, startDate, endDate)
+	if err != nil {
+		log.Printf("GitHubユーザー %s の貢献データ取得に失敗しました: %v", username, err)
+		respondGitHubError(w, err, username)
+		return
+	}
+
+	// レスポンスの形は保存済みデータの取得（GET /api/contributions/{userID}）と揃え、合計貢献数はヘッダーで返す
+	w.Header().Set(TotalContributionsHeader, strconv.Itoa(calendar.TotalContributions))
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(dailyContributions); err != nil {
-		fmt.Printf("レスポンスのJSONエンコードに失敗しました: %v\n", err)
-		http.Error(w, "レスポンスのJSONエンコードに失敗しました", http.StatusInternalServerError)
+	if err := json.NewEncoder(w).Encode(calendar.Days); err != nil {
+		log.Printf("レスポンスのJSONエンコードに失敗しました: %v", err)
+		RespondError(w, http.StatusInternalServerError, CodeInternalError, "レスポンスのJSONエンコードに失敗しました")
 	}
 }
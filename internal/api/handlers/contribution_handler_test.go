@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database/databasetest"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/github"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRespondGitHubError はGitHubのエラー種別ごとにステータスコード・エラーコードを返し分けることをテストします。
+func TestRespondGitHubError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantStatus     int
+		wantCode       ErrorCode
+		wantRetryAfter string
+	}{
+		{"ユーザー不在", fmt.Errorf("%w: nobody", github.ErrUserNotFound), http.StatusNotFound, CodeUserNotFound, ""},
+		{"レート制限", &github.RateLimitError{RetryAfter: 42 * time.Second}, http.StatusTooManyRequests, CodeRateLimited, "42"},
+		{"トークンの不備", fmt.Errorf("%w: Bad credentials", github.ErrBadCredentials), http.StatusInternalServerError, CodeServerConfigError, ""},
+		{"未知のエラー", errors.New("boom"), http.StatusInternalServerError, CodeGitHubAPIError, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			respondGitHubError(rec, tt.err, "octocat")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), string(tt.wantCode))
+			assert.Equal(t, tt.wantRetryAfter, rec.Header().Get("Retry-After"))
+		})
+	}
+}
+
+// TestGetSavedContributionsV2Handler は公開設定に応じて保存済みデータを返し、非公開の草を未認証のリクエストに返さないことをテストします。
+// 本人による取得はトークンの検証を含めてルーター経由でテストします（cmd/api の TestSavedContributionsV2_Visibility）。
+func TestGetSavedContributionsV2Handler(t *testing.T) {
+	fetchedAt := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name       string
+		reader     *databasetest.ContributionReader
+		wantStatus int
+		wantCode   ErrorCode
+	}{
+		{"公開ユーザー", &databasetest.ContributionReader{Public: true}, http.StatusOK, ""},
+		{"非公開ユーザーを未認証で取得", &databasetest.ContributionReader{Public: false}, http.StatusForbidden, CodeForbidden},
+		{"ユーザー不在", &databasetest.ContributionReader{VisibilityErr: fmt.Errorf("%w: user-1", database.ErrUserNotFound)}, http.StatusNotFound, CodeUserNotFound},
+		{"公開設定の取得失敗", &databasetest.ContributionReader{VisibilityErr: errors.New("boom")}, http.StatusInternalServerError, CodeInternalError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.reader.Contributions = []models.DailyContribution{{Date: "2025-01-01", Count: 3}}
+			tt.reader.FetchedAt = &fetchedAt
+			h := &ContributionHandler{savedReader: tt.reader}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v2/contributions/user-1", nil)
+			req = mux.SetURLVars(req, map[string]string{"userID": "user-1"})
+			rec := httptest.NewRecorder()
+			h.GetSavedContributionsV2Handler(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantCode != "" {
+				assert.Contains(t, rec.Body.String(), string(tt.wantCode))
+				return
+			}
+			var body models.SavedContributions
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.False(t, body.Stale)
+			assert.Len(t, body.Contributions, 1)
+			assert.NotEmpty(t, rec.Header().Get("ETag"))
+			assert.Empty(t, rec.Header().Get("Deprecation"))
+		})
+	}
+}
+
+// TestGetSavedContributionsV2Handler_NoDatabase はDBが未設定の場合に設定エラーを返すことをテストします。
+func TestGetSavedContributionsV2Handler_NoDatabase(t *testing.T) {
+	h := NewContributionHandler(nil, nil)
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v2/contributions/user-1", nil), map[string]string{"userID": "user-1"})
+	rec := httptest.NewRecorder()
+	h.GetSavedContributionsV2Handler(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, rec.Body.String(), string(CodeServerConfigError))
+}
+
+// TestGetSavedContributionsHandler_Private は非推奨の v1 でも、非公開の草を本人以外に返さないことをテストします。
+func TestGetSavedContributionsHandler_Private(t *testing.T) {
+	reader := &databasetest.ContributionReader{Public: false, Contributions: []models.DailyContribution{{Date: "2025-01-01", Count: 3}}}
+	h := &ContributionHandler{savedReader: reader}
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/contributions/user-1", nil), map[string]string{"userID": "user-1"})
+	rec := httptest.NewRecorder()
+	h.GetSavedContributionsHandler(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), string(CodeForbidden))
+	assert.NotContains(t, rec.Body.String(), "2025-01-01")
+
+	reader.Public = true
+	rec = httptest.NewRecorder()
+	h.GetSavedContributionsHandler(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get("Deprecation"))
+	assert.Contains(t, rec.Body.String(), "2025-01-01")
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// DebugEndpointsEnabled は開発用のデバッグエンドポイントを有効にするかどうかを返します。
+// 設定漏れで本番に公開されないよう、APP_ENV=development かつ BYPASS_AUTH=true が明示された場合だけ有効にします（未設定は無効）。
+// 無効の場合、ルーターは登録せず、ハンドラーも404を返します。
+func DebugEndpointsEnabled() bool {
+	return os.Getenv("APP_ENV") == "development" && os.Getenv("BYPASS_AUTH") == "true"
+}
+
+// DebugBoard はセッションの両プレイヤーのボードと主要な状態をASCIIで返すハンドラーです（開発環境限定）。
+// GET /api/game/debug/{passcode}
+// ブラウザやcurlでサーバー側のゲーム状態を確認するためのもので、レスポンスは text/plain です。
+// ユーザーIDは部分的にマスクし、デッキやスコアマップなどの情報は含めません。
+func (h *GameHandler) DebugBoard(w http.ResponseWriter, r *http.Request) {
+	if !DebugEndpointsEnabled() {
+		RespondError(w, http.StatusNotFound, CodeNotFound, "見つかりません")
+		return
+	}
+
+	passcode := mux.Vars(r)["passcode"]
+	view, ok := h.sessionManager.DebugView(passcode)
+	if !ok {
+		RespondError(w, http.StatusNotFound, CodeSessionNotFound, "指定された合言葉のセッションは見つかりませんでした")
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "passcode: %s\nstatus: %s\nremaining: %v\n", passcode, view.Status, view.RemainingTime.Round(time.Second))
+	for i, player := range view.Players {
+		fmt.Fprintf(&b, "\n[player%d] %s\n", i+1, maskUserID(player.UserID))
+		fmt.Fprintf(&b, "score: %d  level: %d  lines: %d  pending_garbage: %d  game_over: %v\n",
+			player.Score, player.Level, player.LinesCleared, player.PendingGarbage, player.IsGameOver)
+		b.WriteString(player.Board.String())
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(b.String()))
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/services/tetris"
+	"github.com/stretchr/testify/assert"
+)
+
+// DebugView はテスト用のセッションの状態をそのままデバッグ表示用の状態にします。
+func (f *fakeAdminSessionService) DebugView(passcode string) (tetris.DebugView, bool) {
+	session, ok := f.GetGameSession(passcode)
+	if !ok {
+		return tetris.DebugView{}, false
+	}
+	view := tetris.DebugView{Status: session.Status}
+	for _, player := range []*tetris.PlayerGameState{session.Player1, session.Player2} {
+		view.Players = append(view.Players, tetris.DebugPlayerView{UserID: player.UserID, Score: player.Score, Board: player.Board})
+	}
+	return view, true
+}
+
+// TestDebugBoard は開発環境（APP_ENV=development かつ BYPASS_AUTH=true）で両プレイヤーのASCIIボードと状態を text/plain で返し、
+// それ以外（本番・設定漏れ）では404になることをテストします。
+func TestDebugBoard(t *testing.T) {
+	session, err := tetris.NewGameSession("room", "player1-0123456789", &models.Deck{ID: "deck-1"}, nil)
+	assert.NoError(t, err)
+	session.SetPlayer2("player2-0123456789", &models.Deck{ID: "deck-2"}, nil)
+	router := mux.NewRouter()
+	router.HandleFunc("/api/game/debug/{passcode}", NewGameHandler(&fakeAdminSessionService{session: session}, nil, nil).DebugBoard)
+
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		return rec
+	}
+
+	t.Setenv("APP_ENV", "development")
+	t.Setenv("BYPASS_AUTH", "true")
+	rec := get("/api/game/debug/room")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+	body := rec.Body.String()
+	assert.Contains(t, body, "status: waiting")
+	assert.Contains(t, body, "[player1] play**********6789")
+	assert.Contains(t, body, "[player2] play**********6789")
+	assert.NotContains(t, body, "player1-0123456789", "ユーザーIDはマスクするはず")
+	assert.Equal(t, 2, strings.Count(body, "----------\n"), "2人分のボードがあるはず")
+
+	assert.Equal(t, http.StatusNotFound, get("/api/game/debug/missing").Code)
+
+	t.Setenv("APP_ENV", "production")
+	assert.Equal(t, http.StatusNotFound, get("/api/game/debug/room").Code)
+	t.Setenv("APP_ENV", "")
+	assert.Equal(t, http.StatusNotFound, get("/api/game/debug/room").Code, "APP_ENV 未設定では無効のはず")
+	t.Setenv("APP_ENV", "development")
+	t.Setenv("BYPASS_AUTH", "")
+	assert.Equal(t, http.StatusNotFound, get("/api/game/debug/room").Code, "BYPASS_AUTH が無ければ無効のはず")
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 
@@ -24,7 +25,7 @@ func NewDeckGetHandler(s services.DeckService) *DeckGetHandler {
 func (h *DeckGetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// GETメソッドのみを受け入れます
 	if r.Method != http.MethodGet {
-		http.Error(w, "許可されていないメソッド", http.StatusMethodNotAllowed)
+		RespondError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "許可されていないメソッド")
 		return
 	}
 
@@ -32,7 +33,7 @@ func (h *DeckGetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	requestedUserID := vars["userID"] // URLから取得したユーザーID
 	if requestedUserID == "" {
-		http.Error(w, "ユーザーIDが指定されていません。", http.StatusBadRequest)
+		RespondError(w, http.StatusBadRequest, CodeBadRequest, "ユーザーIDが指定されていません。")
 		return
 	}
 	log.Printf("リクエストされたユーザーID (URL): %s", requestedUserID)
@@ -41,29 +42,28 @@ func (h *DeckGetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	authenticatedUserID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
 		log.Println("エラー: デッキ取得ハンドラで認証済みユーザーIDがコンテキストに見つかりませんでした。")
-		http.Error(w, "未認証: ユーザーIDが見つかりません", http.StatusUnauthorized)
+		RespondError(w, http.StatusUnauthorized, CodeUnauthorized, "未認証: ユーザーIDが見つかりません")
 		return
 	}
 	log.Printf("認証済みユーザーID (JWT): %s", authenticatedUserID)
 
-	// セキュリティ検証: リクエストされたユーザーIDと認証済みユーザーIDが一致するか確認します。
-	if requestedUserID != authenticatedUserID {
-		log.Printf("認可エラー: リクエストユーザーID %s は認証済みユーザーID %s と一致しません。", requestedUserID, authenticatedUserID)
-		http.Error(w, "認可されていない操作: 他のユーザーのデッキにはアクセスできません", http.StatusForbidden)
-		return
-	}
-
 	// デッキと配置のビジネスロジックを実行します
-	deckWithPlacements, err := h.DeckService.GetDeckWithPlacementsByUserID(authenticatedUserID)
+	// 可視性の判定（所有者は全量、他人は公開デッキの概要のみ）はサービス層で行います
+	deckWithPlacements, err := h.DeckService.GetDeckWithPlacementsByUserID(r.Context(), requestedUserID, authenticatedUserID)
 	if err != nil {
-		log.Printf("ユーザー %s のデッキ取得に失敗しました: %v", authenticatedUserID, err)
-		http.Error(w, "内部サーバーエラー: デッキ情報の取得に失敗しました", http.StatusInternalServerError)
+		if errors.Is(err, services.ErrDeckForbidden) {
+			log.Printf("認可エラー: ユーザー %s がユーザー %s の非公開デッキにアクセスしようとしました。", authenticatedUserID, requestedUserID)
+			RespondError(w, http.StatusForbidden, CodeForbidden, "認可されていない操作: このデッキは非公開です")
+			return
+		}
+		log.Printf("ユーザー %s のデッキ取得に失敗しました: %v", requestedUserID, err)
+		RespondError(w, http.StatusInternalServerError, CodeInternalError, "内部サーバーエラー: デッキ情報の取得に失敗しました")
 		return
 	}
 
 	if deckWithPlacements == nil || deckWithPlacements.Deck == nil {
 		// デッキが存在しない場合、404 Not Found を返す
-		http.Error(w, "デッキが見つかりませんでした", http.StatusNotFound)
+		RespondError(w, http.StatusNotFound, CodeDeckNotFound, "デッキが見つかりませんでした")
 		return
 	}
 
@@ -71,7 +71,7 @@ func (h *DeckGetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(deckWithPlacements); err != nil {
 		log.Printf("レスポンスのJSONエンコードに失敗しました: %v", err)
-		http.Error(w, "内部サーバーエラー", http.StatusInternalServerError)
+		RespondError(w, http.StatusInternalServerError, CodeInternalError, "内部サーバーエラー")
 	}
-	log.Printf("ユーザー %s のデッキが正常に取得され、返されました。", authenticatedUserID)
+	log.Printf("ユーザー %s のデッキが正常に取得され、ユーザー %s に返されました。", requestedUserID, authenticatedUserID)
 }
\ No newline at end of file
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/api/middleware"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	services "github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/services/deck"
+	"github.com/stretchr/testify/assert"
+)
+
+func (f *fakeDeckService) GetDeckWithPlacementsByUserID(ctx context.Context, userID, requesterID string) (*models.DeckWithPlacements, error) {
+	f.requesters = append(f.requesters, requesterID)
+	return f.withPlacements, f.getErr
+}
+
+// TestDeckGetHandler_Visibility は認証済みユーザーIDをサービスに渡して可視性を判定させ、
+// 非公開デッキは403、デッキが無い場合は404を返すことをテストします。
+func TestDeckGetHandler_Visibility(t *testing.T) {
+	tests := []struct {
+		name       string
+		service    *fakeDeckService
+		wantStatus int
+	}{
+		{
+			name:       "公開デッキ・自分のデッキは取得できる",
+			service:    &fakeDeckService{withPlacements: &models.DeckWithPlacements{Deck: &models.Deck{ID: "deck-1", UserID: "owner", IsPublic: true}}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "他人の非公開デッキは403",
+			service:    &fakeDeckService{getErr: fmt.Errorf("ユーザーID 'owner' のデッキ: %w", services.ErrDeckForbidden)},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "デッキが無い場合は404",
+			service:    &fakeDeckService{},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/protected/deck/owner", nil)
+			req = mux.SetURLVars(req, map[string]string{"userID": "owner"})
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey{}, "viewer"))
+			rec := httptest.NewRecorder()
+
+			NewDeckGetHandler(tt.service).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, []string{"viewer"}, tt.service.requesters)
+		})
+	}
+}
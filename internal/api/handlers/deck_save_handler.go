@@ -2,14 +2,19 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/api/middleware"         // プロジェクトのルートパスに合わせて修正
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database"
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"                 // プロジェクトのルートパスに合わせて修正
 	services "github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/services/deck" // プロジェクトのルートパスに合わせて修正
 )
 
+// MaxDeckSaveBodyBytes はデッキ保存リクエストのボディサイズ上限です（64KB）。
+const MaxDeckSaveBodyBytes = 64 << 10
+
 // DeckSaveHandler はデッキ保存APIのエンドポイントを処理します。
 type DeckSaveHandler struct {
 	DeckService services.DeckService
@@ -25,7 +30,7 @@ func NewDeckSaveHandler(s services.DeckService) *DeckSaveHandler {
 func (h *DeckSaveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// POSTメソッドのみを受け入れます
 	if r.Method != http.MethodPost {
-		http.Error(w, "許可されていないメソッド", http.StatusMethodNotAllowed)
+		RespondError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "許可されていないメソッド")
 		return
 	}
 
@@ -33,18 +38,25 @@ func (h *DeckSaveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
 		log.Println("エラー: デッキ保存ハンドラでユーザーIDがコンテキストに見つかりませんでした。認証ミドルウェアが正しく動作していることを確認してください。")
-		http.Error(w, "未認証: ユーザーIDが見つかりません", http.StatusUnauthorized)
+		RespondError(w, http.StatusUnauthorized, CodeUnauthorized, "未認証: ユーザーIDが見つかりません")
 		return
 	}
 	log.Printf("認証済みユーザーID: %s がデッキ保存リクエストを送信しました。", userID)
 
 
-	// リクエストボディをパースします
+	// リクエストボディをパースします（巨大なボディでメモリを消費しないようサイズを制限）
+	r.Body = http.MaxBytesReader(w, r.Body, MaxDeckSaveBodyBytes)
 	var req models.DeckSaveRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			log.Printf("リクエストボディがサイズ上限 (%d bytes) を超えています", maxBytesErr.Limit)
+			RespondError(w, http.StatusRequestEntityTooLarge, CodePayloadTooLarge, "リクエストボディが大きすぎます")
+			return
+		}
 		log.Printf("リクエストボディのパースに失敗しました: %v", err)
-		http.Error(w, "不正なリクエスト: 無効なリクエストボディです", http.StatusBadRequest)
+		RespondError(w, http.StatusBadRequest, CodeInvalidBody, "不正なリクエスト: 無効なリクエストボディです")
 		return
 	}
 
@@ -52,20 +64,36 @@ func (h *DeckSaveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// クライアントから送られてくるuserIDはあくまで参考とし、JWTから取得した認証済みuserIDを信頼すべきです。
 	if req.UserID != userID {
 		log.Printf("不正なデッキ保存試行: リクエストユーザーID %s vs 認証済みユーザーID %s", req.UserID, userID)
-		http.Error(w, "未認証: ユーザーIDが一致しません", http.StatusUnauthorized)
+		RespondError(w, http.StatusUnauthorized, CodeUnauthorized, "未認証: ユーザーIDが一致しません")
 		return
 	}
 
 	// デッキ保存のビジネスロジックを実行します
-	err = h.DeckService.SaveDeck(userID, req.Tetriminos)
+	deck, created, err := h.DeckService.SaveDeck(r.Context(), userID, req.Tetriminos, req.Version)
 	if err != nil {
 		log.Printf("ユーザー %s のデッキ保存に失敗しました: %v", userID, err)
-		// エラーの種類に応じて適切なHTTPステータスを返すように改善可能
-		http.Error(w, "内部サーバーエラー: デッキの保存に失敗しました", http.StatusInternalServerError)
+		if errors.Is(err, services.ErrInvalidDeck) {
+			RespondError(w, http.StatusBadRequest, CodeInvalidDeck, "不正なリクエスト: "+err.Error())
+			return
+		}
+		if errors.Is(err, database.ErrDeckVersionConflict) {
+			RespondError(w, http.StatusConflict, CodeDeckVersionConflict, "他の端末で更新されています。デッキを再読み込みしてください")
+			return
+		}
+		RespondError(w, http.StatusInternalServerError, CodeInternalError, "内部サーバーエラー: デッキの保存に失敗しました")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "デッキが正常に保存されました"})
+	json.NewEncoder(w).Encode(models.DeckSaveResponse{
+		Message:     "デッキが正常に保存されました",
+		DeckID:      deck.ID,
+		TotalScore:  deck.TotalScore,
+		Version:     deck.Version,
+		UpdatedAt:   deck.UpdatedAt,
+		PeriodStart: deck.PeriodStart,
+		PeriodEnd:   deck.PeriodEnd,
+		Created:     created,
+	})
 }
\ No newline at end of file
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/api/middleware"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	services "github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/services/deck"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDeckService は SaveDeck・デッキ取得の結果を固定で返すテスト用DeckServiceです。
+// テストで使わないメソッドは埋め込んだインターフェース（nil）に委譲されます。
+type fakeDeckService struct {
+	services.DeckService
+	deck    *models.Deck
+	created bool
+
+	withPlacements *models.DeckWithPlacements // GetDeckWithPlacementsByUserID の結果
+	getErr         error                      // GetDeckWithPlacementsByUserID のエラー
+	requesters     []string                   // GetDeckWithPlacementsByUserID に渡された認証済みユーザーID
+}
+
+func (f *fakeDeckService) SaveDeck(ctx context.Context, userID string, tetriminos []models.TetriminoPlacementRequest, expectedVersion *int) (*models.Deck, bool, error) {
+	return f.deck, f.created, nil
+}
+
+// TestDeckSaveHandler_ReturnsSavedDeck は保存後のデッキIDとtotal_score、新規作成フラグをレスポンスで返すことをテストします。
+func TestDeckSaveHandler_ReturnsSavedDeck(t *testing.T) {
+	updatedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	handler := NewDeckSaveHandler(&fakeDeckService{
+		deck:    &models.Deck{ID: "deck-1", UserID: "user-1", TotalScore: 350, Version: 1, UpdatedAt: updatedAt},
+		created: true,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/protected/deck/save", strings.NewReader(`{"userId":"user-1","tetriminos":[]}`))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey{}, "user-1"))
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp models.DeckSaveResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "deck-1", resp.DeckID)
+	assert.Equal(t, 350, resp.TotalScore)
+	assert.Equal(t, 1, resp.Version)
+	assert.True(t, resp.UpdatedAt.Equal(updatedAt))
+	assert.True(t, resp.Created)
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/api/middleware"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	services "github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/services/deck"
+)
+
+// DeckVisibilityHandler はデッキの公開/非公開切り替えAPIのエンドポイントを処理します。
+type DeckVisibilityHandler struct {
+	DeckService services.DeckService
+}
+
+// NewDeckVisibilityHandler はDeckVisibilityHandlerの新しいインスタンスを作成します。
+func NewDeckVisibilityHandler(s services.DeckService) *DeckVisibilityHandler {
+	return &DeckVisibilityHandler{DeckService: s}
+}
+
+// ServeHTTP は http.Handler インターフェースを実装します。
+// 認証済みユーザー自身のデッキの公開設定のみを変更できます。
+func (h *DeckVisibilityHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// PUTメソッドのみを受け入れます
+	if r.Method != http.MethodPut {
+		RespondError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "許可されていないメソッド")
+		return
+	}
+
+	// ContextからユーザーIDを取得します (AuthMiddlewareが設定されている前提)
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		log.Println("エラー: デッキ公開設定ハンドラでユーザーIDがコンテキストに見つかりませんでした。")
+		RespondError(w, http.StatusUnauthorized, CodeUnauthorized, "未認証: ユーザーIDが見つかりません")
+		return
+	}
+
+	var req models.DeckVisibilityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("リクエストボディのパースに失敗しました: %v", err)
+		RespondError(w, http.StatusBadRequest, CodeInvalidBody, "不正なリクエスト: 無効なリクエストボディです")
+		return
+	}
+
+	if err := h.DeckService.SetDeckVisibility(r.Context(), userID, req.IsPublic); err != nil {
+		log.Printf("ユーザー %s のデッキ公開設定の更新に失敗しました: %v", userID, err)
+		if errors.Is(err, database.ErrDeckNotFound) {
+			RespondError(w, http.StatusNotFound, CodeDeckNotFound, "デッキが見つかりませんでした")
+			return
+		}
+		RespondError(w, http.StatusInternalServerError, CodeInternalError, "内部サーバーエラー: デッキの公開設定の更新に失敗しました")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":  "デッキの公開設定を更新しました",
+		"isPublic": req.IsPublic,
+	})
+}
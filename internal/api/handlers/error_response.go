@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/api/i18n"
+)
+
+// ErrorCode はフロントエンドが分岐に使うマシンリーダブルなエラーコードです。
+// メッセージ文字列は変更される可能性があるため、クライアントはこのコードで判定してください。
+type ErrorCode string
+
+const (
+	CodeBadRequest          ErrorCode = "BAD_REQUEST"           // リクエストの形式・パラメータが不正
+	CodeInvalidBody         ErrorCode = "INVALID_BODY"          // リクエストボディのパースに失敗
+	CodePayloadTooLarge     ErrorCode = "PAYLOAD_TOO_LARGE"     // リクエストボディが大きすぎる
+	CodeUnauthorized        ErrorCode = "UNAUTHORIZED"          // 認証情報がない・無効
+	CodeForbidden           ErrorCode = "FORBIDDEN"             // 認可されていない操作
+	CodeMethodNotAllowed    ErrorCode = "METHOD_NOT_ALLOWED"    // 許可されていないHTTPメソッド
+	CodeNotFound            ErrorCode = "NOT_FOUND"             // リソースが見つからない
+	CodeUserNotFound        ErrorCode = "USER_NOT_FOUND"        // ユーザーが見つからない
+	CodeDeckNotFound        ErrorCode = "DECK_NOT_FOUND"        // デッキが見つからない
+	CodeDeckNotSelected     ErrorCode = "DECK_NOT_SELECTED"     // デッキIDが未指定・不正（デッキ選択画面に戻して選び直す）
+	CodeInvalidDeck         ErrorCode = "INVALID_DECK"          // デッキの内容が不正
+	CodeDeckVersionConflict ErrorCode = "DECK_VERSION_CONFLICT" // デッキが他の端末で更新済み（再読み込みが必要）
+	CodeDeckLoadFailed      ErrorCode = "DECK_LOAD_FAILED"      // デッキの配置データを読み込めずゲームを準備できない
+	CodeSessionNotFound     ErrorCode = "SESSION_NOT_FOUND"     // ゲームセッションが見つからない
+	CodeSessionClosing      ErrorCode = "SESSION_CLOSING"       // セッションが終了処理中（再試行可能）
+	CodeRoomNotCancellable  ErrorCode = "ROOM_NOT_CANCELLABLE"  // 対戦相手が参加済みでルームを解散できない
+	CodeInvalidPasscode     ErrorCode = "INVALID_PASSCODE"      // 合言葉の形式が不正
+	CodeInvalidRules        ErrorCode = "INVALID_RULES"         // ルーム作成時のルール設定が不正
+	CodeRoomInProgress      ErrorCode = "ROOM_IN_PROGRESS"      // ルームが既にゲーム中または終了済み
+	CodeRoomFull            ErrorCode = "ROOM_FULL"             // ルームが満室
+	CodeOwnRoom             ErrorCode = "OWN_ROOM"              // 自分が作成したルームには参加できない
+	CodeAlreadyPlaying      ErrorCode = "ALREADY_PLAYING"       // 既に他のルームでプレイ中
+	CodeUserSessionLimit    ErrorCode = "USER_SESSION_LIMIT"    // ユーザーが参加中のルーム数が上限に達している
+	CodeMatchingFailed      ErrorCode = "MATCHING_FAILED"       // 合言葉でのマッチングに失敗
+	CodeServerBusy          ErrorCode = "SERVER_BUSY"           // セッション数・接続数が上限に達している（Retry-After 後に再試行）
+	CodePasscodeUnavailable ErrorCode = "PASSCODE_UNAVAILABLE"  // 空いている合言葉を生成できなかった（Retry-After 後に再試行）
+	CodeGitHubAPIError      ErrorCode = "GITHUB_API_ERROR"      // GitHub APIの呼び出しに失敗
+	CodeRefreshTimeout      ErrorCode = "REFRESH_TIMEOUT"       // 進行中の貢献データ再取得の完了を待てなかった（再試行可能）
+	CodeRateLimited         ErrorCode = "RATE_LIMITED"          // リクエスト頻度の上限を超えた（Retry-After 後に再試行）
+	CodeServerConfigError   ErrorCode = "SERVER_CONFIG_ERROR"   // サーバー側の設定不備
+	CodeInternalError       ErrorCode = "INTERNAL_ERROR"        // 予期せぬサーバーエラー
+)
+
+// ErrorBody はエラーレスポンスの中身です。
+type ErrorBody struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+// ErrorResponse は全ハンドラで共通のエラーレスポンス形式です。
+// 例: {"error":{"code":"DECK_NOT_FOUND","message":"デッキが見つかりませんでした"}}
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// RespondError は統一形式のJSONエラーレスポンスを書き込みます。
+// メッセージはクライアントの言語（middleware.LanguageHandler が Accept-Language から決めたもの）に合わせ、
+// 日本語の場合は message をそのまま、英語の場合はエラーコードのメッセージカタログ（i18n）の文言を使います。
+//
+// Parameters:
+//   w       : レスポンスライター
+//   status  : HTTPステータスコード
+//   code    : マシンリーダブルなエラーコード
+//   message : 人間向けのエラーメッセージ（日本語）
+func RespondError(w http.ResponseWriter, status int, code ErrorCode, message string) {
+	message = i18n.Localize(i18n.ResponseLanguage(w), string(code), message, i18n.Japanese)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(ErrorResponse{Error: ErrorBody{Code: code, Message: message}}); err != nil {
+		log.Printf("エラーレスポンスのJSONエンコードに失敗しました: %v", err)
+	}
+}
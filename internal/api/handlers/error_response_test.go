@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/api/i18n"
+	"github.com/stretchr/testify/assert"
+)
+
+// allErrorCodes は定義済みの全エラーコードです。コードを追加したらこことメッセージカタログにも追加してください。
+var allErrorCodes = []ErrorCode{
+	CodeBadRequest, CodeInvalidBody, CodePayloadTooLarge, CodeUnauthorized, CodeForbidden, CodeMethodNotAllowed,
+	CodeNotFound, CodeUserNotFound, CodeDeckNotFound, CodeDeckNotSelected, CodeInvalidDeck, CodeDeckVersionConflict, CodeDeckLoadFailed,
+	CodeSessionNotFound, CodeSessionClosing, CodeRoomNotCancellable, CodeInvalidPasscode, CodeInvalidRules,
+	CodeRoomInProgress, CodeRoomFull, CodeOwnRoom, CodeAlreadyPlaying, CodeUserSessionLimit, CodeMatchingFailed, CodeServerBusy, CodePasscodeUnavailable,
+	CodeGitHubAPIError, CodeRateLimited, CodeServerConfigError, CodeInternalError,
+}
+
+// TestRespondError_Language は英語のクライアントには全エラーコードでカタログの英語の文言を返し、
+// 日本語（デフォルト）では呼び出し側の詳しいメッセージをそのまま返すことをテストします。
+func TestRespondError_Language(t *testing.T) {
+	for _, code := range allErrorCodes {
+		rec := httptest.NewRecorder()
+		rec.Header().Set(i18n.LanguageHeader, string(i18n.English))
+		RespondError(rec, http.StatusBadRequest, code, "詳しいメッセージ")
+
+		var body ErrorResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, code, body.Error.Code)
+		assert.NotEqual(t, "詳しいメッセージ", body.Error.Message, "%s の英語の文言がカタログにありません", code)
+	}
+
+	rec := httptest.NewRecorder()
+	RespondError(rec, http.StatusNotFound, CodeDeckNotFound, "指定されたデッキが見つかりません")
+	var body ErrorResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "指定されたデッキが見つかりません", body.Error.Message)
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// contentETag はレスポンスボディの内容から弱いETag（W/"..."）を計算します。
+// 同じ内容なら常に同じ値になるため、保存日時だけが変わった再取得でも 304 を返せます。
+// gzip圧縮の有無でバイト列が変わっても同じ内容として扱えるよう、弱いETagにしています。
+func contentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches は If-None-Match ヘッダーにETagが含まれるかどうかを弱い比較（W/ の有無を無視）で判定します。
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteJSONResponseWithETag は WriteJSONResponse と同じJSONレスポンスに内容から計算したETagを付けて書き込みます。
+// クライアントの If-None-Match が一致する場合はボディを送らず 304 Not Modified を返します。
+// Cache-Control: no-cache で、キャッシュを使う前に毎回ETagで再検証させます。
+func WriteJSONResponseWithETag(w http.ResponseWriter, r *http.Request, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("レスポンスのJSONエンコードに失敗しました: %v", err)
+		RespondError(w, http.StatusInternalServerError, CodeInternalError, "レスポンスのJSONエンコードに失敗しました")
+		return
+	}
+
+	etag := contentETag(body)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(append(body, '\n')) // json.Encoder と同じく末尾に改行を付ける
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWriteJSONResponseWithETag は同じ内容には同じETagを返し、If-None-Match が一致すれば 304 でボディを省くことをテストします。
+func TestWriteJSONResponseWithETag(t *testing.T) {
+	data := []map[string]interface{}{{"date": "2025-06-01", "count": 3}}
+
+	write := func(ifNoneMatch string, data interface{}) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/contributions/user-1", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		WriteJSONResponseWithETag(rec, req, data)
+		return rec
+	}
+
+	first := write("", data)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.JSONEq(t, `[{"date":"2025-06-01","count":3}]`, first.Body.String())
+	etag := first.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, etag, write("", data).Header().Get("ETag"), "同じ内容なら同じETagのはず")
+
+	notModified := write(etag, data)
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Empty(t, notModified.Body.String())
+	assert.Equal(t, http.StatusNotModified, write(`"other", `+etag[2:], data).Code, "リスト内の強いETag表記でも一致するはず")
+
+	changed := write(etag, []map[string]interface{}{{"date": "2025-06-01", "count": 4}})
+	assert.Equal(t, http.StatusOK, changed.Code, "内容が変われば全量を返すはず")
+	assert.NotEqual(t, etag, changed.Header().Get("ETag"))
+}
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"   // Added for os.Getenv
@@ -13,41 +15,57 @@ import (
 	"github.com/gorilla/mux"       // gorilla/muxをインポート
 	"github.com/gorilla/websocket" // WebSocketライブラリ
 
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/api/middleware"
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database"
+	tetrisModel "github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
+	services "github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/services/deck"
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/services/tetris" // SessionManager をインポート
 )
 
 // upgrader はHTTP接続をWebSocketプロトコルにアップグレードするための設定です。
-// CheckOrigin はクロスオリジンリクエストを許可するかどうかを制御します。
-// 開発中は true で良いですが、本番環境では適切な Origin チェックを行うべきです。
+// CheckOrigin はCORSと同じ許可リストでOriginを検証し、他サイトからの接続（CSWSH）を拒否します。
+// ハンドラはUpgrade前にオリジンを確認済みですが、Upgrader を直接使う経路のために残しています。
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  4096,  // 読み取りバッファを4KBに増加
 	WriteBufferSize: 4096,  // 書き込みバッファを4KBに増加
-	CheckOrigin: func(r *http.Request) bool {
-		// すべてのOriginからの接続を許可 (開発用)
-		// 本番環境では、フロントエンドのドメインなどを厳密にチェックしてください。
-		// 例: return r.Header.Get("Origin") == "https://yourfrontend.com"
+	CheckOrigin:     checkWebSocketOrigin,
+	Error:           respondUpgradeError,
+}
+
+// checkWebSocketOrigin はWebSocket接続元のOriginが許可されているかを判定します。
+// Originヘッダーはブラウザが必ず付与するため、ヘッダーのない非ブラウザクライアントは許可します。
+func checkWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
 		return true
-	},
+	}
+	if !middleware.IsOriginAllowed(origin) {
+		log.Printf("[GameHandler] Rejected WebSocket connection from disallowed origin: %s", origin)
+		return false
+	}
+	return true
 }
 
 // GameHandler はゲーム関連のHTTPリクエスト（部屋作成、参加、WebSocket接続）を処理します。
 type GameHandler struct {
-	sessionManager *tetris.SessionManager // ゲームセッションの管理サービス
+	sessionManager tetris.SessionService // ゲームセッションの管理サービス
 	dbService      *database.DatabaseService // データベースサービス
+	deckService    services.DeckService          // デッキ未作成ユーザーのデフォルトデッキ生成用
 }
 
 // NewGameHandler は新しい GameHandler インスタンスを作成します。
 //
 // Parameters:
-//   sm : セッションマネージャーへのポインタ
+//   sm : セッションマネージャー（単一またはシャード分割）
 //   db : データベースサービスへのポインタ
+//   ds : デッキサービス
 // Returns:
 //   *GameHandler: 新しく作成された GameHandler のポインタ
-func NewGameHandler(sm *tetris.SessionManager, db *database.DatabaseService) *GameHandler {
+func NewGameHandler(sm tetris.SessionService, db *database.DatabaseService, ds services.DeckService) *GameHandler {
 	return &GameHandler{
 		sessionManager: sm,
 		dbService:      db,
+		deckService:    ds,
 	}
 }
 
@@ -60,13 +78,6 @@ func ExtractUserIDFromContext(r *http.Request) (string, error) {
 	return userID, nil
 }
 
-// WriteErrorResponse はエラーレスポンスをJSON形式で書き込みます。
-func WriteErrorResponse(w http.ResponseWriter, statusCode int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
-}
-
 // WriteJSONResponse はJSONレスポンスを書き込みます。
 func WriteJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -74,27 +85,80 @@ func WriteJSONResponse(w http.ResponseWriter, statusCode int, data interface{})
 	json.NewEncoder(w).Encode(data)
 }
 
+// TetrominoColorsHandler はテトリミノとボードのブロックの色の対応表を返すハンドラーです。
+// GET /api/game/tetromino-colors
+// 色の割り当てはサーバーの定義（tetris.Palette）を唯一の定義とし、クライアントは起動時に一度取得して描画に使います。
+func TetrominoColorsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	WriteJSONResponse(w, http.StatusOK, tetrisModel.Palette())
+}
+
 // GetRoomStatus は特定の合言葉のセッションの現在の状態を返すハンドラーです。（デバッグやセッション一覧表示用）
+// 対戦相手の非公開情報（次のピースやスコアマップ）は filteredStatus で隠して返します。
+// レスポンスの rules にはルーム作成時に固定されたルール（制限時間・ホールド・攻撃・シードモードなど）が含まれ、
+// 参加前にルールを確認してデッキ選択などのUIを出し分けるのに使えます。
+// ?timeline=true を指定すると、状態遷移の記録（「なぜゲームが始まらなかったか」などの調査用）も timeline で返します。
+// 記録には対戦の経過が含まれるため、timeline を返すのはルームの参加者だけで、観戦者には指定があっても含めません。
 func (h *GameHandler) GetRoomStatus(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	passcode := vars["passcode"] // 合言葉をURLパラメータから取得
 	if passcode == "" {
-		WriteErrorResponse(w, http.StatusBadRequest, "合言葉が必要です")
+		RespondError(w, http.StatusBadRequest, CodeBadRequest, "合言葉が必要です")
+		return
+	}
+
+	userID, err := ExtractUserIDFromContext(r)
+	if err != nil {
+		RespondError(w, http.StatusUnauthorized, CodeUnauthorized, "認証情報が必要です")
 		return
 	}
 
 	session, ok := h.sessionManager.GetGameSession(passcode)
 	if !ok {
-		WriteErrorResponse(w, http.StatusNotFound, "指定された合言葉のセッションは見つかりませんでした")
+		RespondError(w, http.StatusNotFound, CodeSessionNotFound, "指定された合言葉のセッションは見つかりませんでした")
 		return
 	}
 
-	WriteJSONResponse(w, http.StatusOK, session)
+	status := filteredStatus(session, userID)
+	if r.URL.Query().Get("timeline") == "true" && isRoomParticipant(status, userID) {
+		status.Timeline, _ = session.Timeline()
+	}
+	WriteJSONResponse(w, http.StatusOK, status)
+}
+
+// filteredStatus はリクエストしたユーザーに応じて公開範囲を絞ったセッション状態を返します。
+// 自分のプレイヤー状態は全量、対戦相手は制限版（ボードの埋まり状態とスコアのみ）を返し、
+// どちらのプレイヤーでもない観戦者には両者の制限版を返します。
+//
+// Parameters:
+//   session     : 対象のゲームセッション
+//   requesterID : リクエストしたユーザーのID
+// Returns:
+//   *tetris.LightweightGameState: フィルタ済みのセッション状態
+func filteredStatus(session *tetris.GameSession, requesterID string) *tetris.LightweightGameState {
+	status := session.LightweightSnapshot()
+	if status.Player1 != nil && status.Player1.UserID != requesterID {
+		status.Player1 = status.Player1.PublicView()
+	}
+	if status.Player2 != nil && status.Player2.UserID != requesterID {
+		status.Player2 = status.Player2.PublicView()
+	}
+	return status
+}
+
+// isRoomParticipant は userID がセッション状態のどちらかのプレイヤーかどうかを返します。
+func isRoomParticipant(status *tetris.LightweightGameState, userID string) bool {
+	return (status.Player1 != nil && status.Player1.UserID == userID) ||
+		(status.Player2 != nil && status.Player2.UserID == userID)
 }
 
 // HandleWebSocketConnection はHTTP接続をWebSocketプロトコルにアップグレードし、
 // その後、WebSocketメッセージの送受信をセッションマネージャーに引き渡します。
 // このエンドポイントには合言葉が含まれます。
+//
+// 接続確立の失敗は段階ごとに次の形で返します。
+//   Upgrade前（合言葉・オリジン・ルームの確認、ハンドシェイク） : HTTPのエラーレスポンス（ErrorResponse）
+//   Upgrade後（認証・クライアント登録）                         : {"type":"auth_error","stage":...,"reason":...}（WSAuthError）の後にクローズフレーム
 func (h *GameHandler) HandleWebSocketConnection(w http.ResponseWriter, r *http.Request) {
 	log.Printf("[GameHandler] WebSocket connection attempt for path: %s", r.URL.Path)
 	
@@ -105,7 +169,13 @@ func (h *GameHandler) HandleWebSocketConnection(w http.ResponseWriter, r *http.R
 	
 	if passcode == "" {
 		log.Printf("[GameHandler] Missing passcode in WebSocket connection")
-		WriteErrorResponse(w, http.StatusBadRequest, "WebSocket接続には合言葉が必要です")
+		RespondError(w, http.StatusBadRequest, CodeBadRequest, "WebSocket接続には合言葉が必要です")
+		return
+	}
+
+	// Upgrade後はHTTPステータスを返せないため、オリジンとルームの確認はUpgradeの前に済ませる
+	if !checkWebSocketOrigin(r) {
+		RespondError(w, http.StatusForbidden, CodeForbidden, "許可されていないオリジンからのWebSocket接続です")
 		return
 	}
 
@@ -113,7 +183,7 @@ func (h *GameHandler) HandleWebSocketConnection(w http.ResponseWriter, r *http.R
 	session, exists := h.sessionManager.GetGameSession(passcode)
 	if !exists {
 		log.Printf("[GameHandler] Passcode %s does not exist", passcode)
-		WriteErrorResponse(w, http.StatusNotFound, "指定された合言葉のセッションは存在しません")
+		RespondError(w, http.StatusNotFound, CodeSessionNotFound, "指定された合言葉のセッションは存在しません")
 		return
 	}
 	log.Printf("[GameHandler] Passcode %s exists, status: %s", passcode, session.Status)
@@ -124,23 +194,30 @@ func (h *GameHandler) HandleWebSocketConnection(w http.ResponseWriter, r *http.R
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("[GameHandler] Failed to upgrade to websocket for passcode %s: %v", passcode, err)
-		return // アップグレード失敗時はエラーログのみ
+		return // エラーレスポンスは upgrader.Error（respondUpgradeError）が返している
 	}
 	// defer conn.Close() // ここでは閉じない。SessionManagerが管理するため。
 
 	log.Printf("[GameHandler] WebSocket upgraded successfully for passcode %s.", passcode)
 
-	// 認証メッセージを待つ
-	conn.SetReadDeadline(time.Now().Add(10 * time.Second)) // 10秒のタイムアウト
-	log.Printf("[GameHandler] Waiting for auth message from client...")
+	// 認証メッセージを待つ。認証前の接続は読み取りサイズを絞り、期限は認証フェーズ全体で1回だけ設定する（読み取りごとに延長しない）
+	conn.SetReadLimit(wsAuthReadLimit)
+	conn.SetReadDeadline(time.Now().Add(wsAuthTimeout))
+	log.Printf("[GameHandler] Waiting for auth message from client (timeout: %v)...", wsAuthTimeout)
 	
 	var userID string
+	var protocolVersion int
 	authReceived := false
 	
 	// 認証メッセージを待つ
 	for !authReceived {
 		_, message, err := conn.ReadMessage()
 		if err != nil {
+			if isTimeoutError(err) {
+				log.Printf("[GameHandler] Auth timeout for passcode %s after %v", passcode, wsAuthTimeout)
+				rejectAuth(conn, WSStageAuth, WSAuthReasonTimeout, "auth timeout")
+				return
+			}
 			log.Printf("[GameHandler] Failed to read auth message: %v", err)
 			conn.Close()
 			return
@@ -149,20 +226,29 @@ func (h *GameHandler) HandleWebSocketConnection(w http.ResponseWriter, r *http.R
 		log.Printf("[GameHandler] Received message: %s", string(message))
 		
 		var authMsg struct {
-			Type   string `json:"type"`
-			Token  string `json:"token"`
-			UserID string `json:"user_id"`
+			Type    string `json:"type"`
+			Version int    `json:"v"` // クライアントが対応するプロトコルバージョン（省略時はバージョニング導入前のクライアント）
+			Token   string `json:"token"`
+			UserID  string `json:"user_id"`
 		}
 		
 		if err := json.Unmarshal(message, &authMsg); err != nil {
 			log.Printf("[GameHandler] Failed to parse auth message: %v", err)
-			conn.Close()
+			rejectAuth(conn, WSStageAuth, WSAuthReasonInvalidMessage, "Invalid auth message")
 			return
 		}
 		
 		log.Printf("[GameHandler] Parsed auth message - Type: %s, Token length: %d", authMsg.Type, len(authMsg.Token))
 		
 		if authMsg.Type == "auth" {
+			// トークンの検証より先にプロトコルバージョンを確認する（対応できないクライアントはトークンが正しくても遊べない）
+			protocolVersion, err = tetris.NegotiateProtocolVersion(authMsg.Version)
+			if err != nil {
+				log.Printf("[GameHandler] Rejecting client for passcode %s: %v", passcode, err)
+				rejectAuth(conn, WSStageAuth, WSAuthReasonUnsupportedVersion, fmt.Sprintf("Unsupported protocol version %d", authMsg.Version))
+				return
+			}
+
 			// JWTトークンの検証（auth_middleware.goと同じロジック）
 			// 環境変数でBYPASS_AUTHが有効な場合、またはトークンがBYPASS_AUTHの場合
 			if os.Getenv("BYPASS_AUTH") == "true" || authMsg.Token == "BYPASS_AUTH" {
@@ -209,8 +295,7 @@ func (h *GameHandler) HandleWebSocketConnection(w http.ResponseWriter, r *http.R
 				jwtSecret := os.Getenv("SUPABASE_JWT_SECRET")
 				if jwtSecret == "" {
 					log.Println("Error: SUPABASE_JWT_SECRET environment variable is not set.")
-					conn.WriteJSON(map[string]string{"error": "Server configuration error: JWT secret missing"})
-					conn.Close()
+					rejectAuth(conn, WSStageAuth, WSAuthReasonServerConfig, "Server configuration error: JWT secret missing")
 					return
 				}
 
@@ -232,15 +317,13 @@ func (h *GameHandler) HandleWebSocketConnection(w http.ResponseWriter, r *http.R
 
 				if err != nil {
 					log.Printf("WebSocket Auth Error: JWT parse error: %v", err)
-					conn.WriteJSON(map[string]string{"error": "Invalid token"})
-					conn.Close()
+					rejectAuth(conn, WSStageAuth, WSAuthReasonInvalidToken, "Invalid token")
 					return
 				}
 
 				if !parsedToken.Valid {
 					log.Printf("WebSocket Auth Error: Invalid token")
-					conn.WriteJSON(map[string]string{"error": "Invalid token"})
-					conn.Close()
+					rejectAuth(conn, WSStageAuth, WSAuthReasonInvalidToken, "Invalid token")
 					return
 				}
 
@@ -248,8 +331,7 @@ func (h *GameHandler) HandleWebSocketConnection(w http.ResponseWriter, r *http.R
 				claims, ok := parsedToken.Claims.(jwt.MapClaims)
 				if !ok {
 					log.Printf("WebSocket Auth Error: Invalid token claims")
-					conn.WriteJSON(map[string]string{"error": "Invalid token claims"})
-					conn.Close()
+					rejectAuth(conn, WSStageAuth, WSAuthReasonInvalidToken, "Invalid token claims")
 					return
 				}
 
@@ -257,8 +339,7 @@ func (h *GameHandler) HandleWebSocketConnection(w http.ResponseWriter, r *http.R
 				userID, ok = claims["sub"].(string)
 				if !ok {
 					log.Printf("WebSocket Auth Error: JWT claims missing 'sub' (userID) or wrong type: %v", claims["sub"])
-					conn.WriteJSON(map[string]string{"error": "Invalid token: missing user ID"})
-					conn.Close()
+					rejectAuth(conn, WSStageAuth, WSAuthReasonInvalidToken, "Invalid token: missing user ID")
 					return
 				}
 				
@@ -268,23 +349,27 @@ func (h *GameHandler) HandleWebSocketConnection(w http.ResponseWriter, r *http.R
 			authReceived = true
 			// 認証成功レスポンスを送信
 			log.Printf("[GameHandler] Sending auth success response to client")
-			conn.WriteJSON(map[string]string{"type": "auth_success", "message": "Authentication successful"})
+			conn.WriteJSON(map[string]interface{}{"type": "auth_success", "v": protocolVersion, "message": "Authentication successful"})
 		} else {
 			log.Printf("[GameHandler] Unexpected message type: %s", authMsg.Type)
-			conn.WriteJSON(map[string]string{"error": "Expected auth message"})
-			conn.Close()
+			rejectAuth(conn, WSStageAuth, WSAuthReasonInvalidMessage, "Expected auth message")
 			return
 		}
 	}
 
-	// タイムアウトを解除
+	// 認証フェーズの期限を解除する（以降の期限・読み取りサイズは SessionManager が設定する）
 	conn.SetReadDeadline(time.Time{})
 	log.Printf("[GameHandler] Auth completed, registering client %s to passcode %s", userID, passcode)
 
 	// SessionManager に新しいWebSocket接続を登録
-	err = h.sessionManager.RegisterClient(passcode, userID, conn)
+	err = h.sessionManager.RegisterClient(passcode, userID, conn, protocolVersion)
 	if err != nil {
 		log.Printf("[GameHandler] Failed to register client %s to passcode %s: %v", userID, passcode, err)
+		if errors.Is(err, tetris.ErrServerBusy) {
+			// アップグレード済みのためHTTPステータスは返せないので、503相当の理由をメッセージとクローズフレームで伝える
+			rejectAuth(conn, WSStageRegister, WSAuthReasonServerBusy, "Server is busy")
+			return
+		}
 		conn.Close() // 登録失敗時はコネクションを閉じる
 		return
 	}
@@ -309,7 +394,7 @@ func (h *GameHandler) JoinRoomByPasscode(w http.ResponseWriter, r *http.Request)
 	userID, err := ExtractUserIDFromContext(r)
 	if err != nil {
 		log.Printf("[GameHandler] Failed to extract user ID for passcode join: %v", err)
-		WriteErrorResponse(w, http.StatusUnauthorized, "認証情報が必要です")
+		RespondError(w, http.StatusUnauthorized, CodeUnauthorized, "認証情報が必要です")
 		return
 	}
 	log.Printf("[GameHandler] User ID extracted for passcode join: %s", userID)
@@ -318,34 +403,37 @@ func (h *GameHandler) JoinRoomByPasscode(w http.ResponseWriter, r *http.Request)
 	passcode := vars["passcode"] // 合言葉をURLパラメータから取得
 	if passcode == "" {
 		log.Printf("[GameHandler] Missing passcode in join request")
-		WriteErrorResponse(w, http.StatusBadRequest, "合言葉が必要です")
+		RespondError(w, http.StatusBadRequest, CodeBadRequest, "合言葉が必要です")
 		return
 	}
 	log.Printf("[GameHandler] Passcode for join: %s", passcode)
 
-	// リクエストボディからプレイヤーのデッキIDを取得
-	var req struct {
-		DeckID string `json:"deck_id"`
-	}
+	// リクエストボディからプレイヤーのデッキIDとルール設定を取得
+	// ルールは指定された項目だけを通常ルールに上書きする（ルームを新しく作成する場合のみ適用）
+	rules := tetris.DefaultGameRules()
+	req := struct {
+		DeckID string            `json:"deck_id"`
+		Rules  *tetris.GameRules `json:"rules,omitempty"`
+	}{Rules: &rules}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("[GameHandler] Failed to parse passcode join request body: %v", err)
-		WriteErrorResponse(w, http.StatusBadRequest, "リクエストボディの解析に失敗しました")
+		RespondError(w, http.StatusBadRequest, CodeInvalidBody, "リクエストボディの解析に失敗しました")
 		return
 	}
-	if req.DeckID == "" {
-		log.Printf("[GameHandler] Missing deck_id in passcode join request")
-		WriteErrorResponse(w, http.StatusBadRequest, "デッキIDが必要です")
+	deckID, ok := h.resolveDeckID(w, r, userID, req.DeckID)
+	if !ok {
 		return
 	}
+	req.DeckID = deckID
 	log.Printf("[GameHandler] Request parsed for passcode join, deck_id: %s", req.DeckID)
 
 	log.Printf("[GameHandler] Calling sessionManager.JoinRoomByPasscode for user %s, passcode %s, deck %s", userID, passcode, req.DeckID)
 	
 	// セッションマネージャーに合言葉でのマッチングを依頼
-	sessionID, isNewSession, err := h.sessionManager.JoinRoomByPasscode(passcode, userID, req.DeckID)
+	sessionID, isNewSession, err := h.sessionManager.JoinRoomWithRules(passcode, userID, req.DeckID, rules)
 	if err != nil {
 		log.Printf("[GameHandler] User %s failed to join passcode %s: %v", userID, passcode, err)
-		WriteErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("合言葉でのマッチングに失敗しました: %v", err))
+		respondRoomError(w, err)
 		return
 	}
 
@@ -368,37 +456,202 @@ func (h *GameHandler) JoinRoomByPasscode(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// resolveDeckID はルームの作成・参加に使うデッキIDを決めます。
+// デッキ未作成の新規ユーザーでも対戦できるよう、デッキIDの指定が無い場合は自分のデッキ（無ければデフォルトデッキ）を使います。
+// 指定されたデッキIDがUUID形式でない場合や、デッキIDを決められなかった場合はエラーレスポンスを書き込み、false を返します。
+func (h *GameHandler) resolveDeckID(w http.ResponseWriter, r *http.Request, userID, deckID string) (string, bool) {
+	if deckID != "" {
+		if err := tetris.ValidateDeckID(deckID); err != nil {
+			log.Printf("[GameHandler] Invalid deck_id in room request from user %s: %v", userID, err)
+			RespondError(w, http.StatusBadRequest, CodeDeckNotSelected, tetris.ErrDeckNotSelected.Error())
+			return "", false
+		}
+		return deckID, true
+	}
+	if h.deckService == nil {
+		log.Printf("[GameHandler] Missing deck_id in room request")
+		RespondError(w, http.StatusBadRequest, CodeDeckNotSelected, tetris.ErrDeckNotSelected.Error())
+		return "", false
+	}
+	userDeck, err := h.deckService.EnsureDefaultDeck(r.Context(), userID)
+	if err != nil {
+		log.Printf("[GameHandler] Failed to ensure default deck for user %s: %v", userID, err)
+		RespondError(w, http.StatusInternalServerError, CodeInternalError, "デッキの準備に失敗しました")
+		return "", false
+	}
+	return userDeck.ID, true
+}
+
+// respondRoomError はルームの作成・参加で発生したエラーを対応するステータスコードとエラーコードで返します。
+func respondRoomError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, tetris.ErrDeckNotSelected):
+		RespondError(w, http.StatusBadRequest, CodeDeckNotSelected, tetris.ErrDeckNotSelected.Error())
+	case errors.Is(err, database.ErrDeckNotFound):
+		RespondError(w, http.StatusNotFound, CodeDeckNotFound, "指定されたデッキが見つかりません")
+	case errors.Is(err, database.ErrInvalidDeckID):
+		RespondError(w, http.StatusBadRequest, CodeBadRequest, "デッキIDの形式が不正です")
+	case errors.Is(err, database.ErrDeckNotOwned):
+		RespondError(w, http.StatusForbidden, CodeForbidden, "自分のデッキ以外は使用できません")
+	case errors.Is(err, tetris.ErrDeckLoadFailed):
+		RespondError(w, http.StatusInternalServerError, CodeDeckLoadFailed, tetris.ErrDeckLoadFailed.Error())
+	case errors.Is(err, tetris.ErrSessionClosing):
+		// 終了処理は数秒で完了するため、クライアントに再試行を促す
+		w.Header().Set("Retry-After", "3")
+		RespondError(w, http.StatusConflict, CodeSessionClosing, tetris.ErrSessionClosing.Error())
+	case errors.Is(err, tetris.ErrServerBusy):
+		w.Header().Set("Retry-After", "30")
+		RespondError(w, http.StatusServiceUnavailable, CodeServerBusy, tetris.ErrServerBusy.Error())
+	case errors.Is(err, tetris.ErrInvalidGameRules):
+		RespondError(w, http.StatusBadRequest, CodeInvalidRules, err.Error())
+	case errors.Is(err, tetris.ErrInvalidPasscode):
+		RespondError(w, http.StatusUnprocessableEntity, CodeInvalidPasscode, tetris.ErrInvalidPasscode.Error())
+	case errors.Is(err, tetris.ErrRoomInProgress):
+		RespondError(w, http.StatusConflict, CodeRoomInProgress, tetris.ErrRoomInProgress.Error())
+	case errors.Is(err, tetris.ErrRoomFull):
+		RespondError(w, http.StatusConflict, CodeRoomFull, tetris.ErrRoomFull.Error())
+	case errors.Is(err, tetris.ErrOwnRoom):
+		RespondError(w, http.StatusBadRequest, CodeOwnRoom, tetris.ErrOwnRoom.Error())
+	case errors.Is(err, tetris.ErrAlreadyPlaying):
+		RespondError(w, http.StatusConflict, CodeAlreadyPlaying, tetris.ErrAlreadyPlaying.Error())
+	case errors.Is(err, tetris.ErrUserSessionLimit):
+		RespondError(w, http.StatusConflict, CodeUserSessionLimit, tetris.ErrUserSessionLimit.Error())
+	case errors.Is(err, tetris.ErrInvalidPasscodeStyle):
+		RespondError(w, http.StatusBadRequest, CodeBadRequest, tetris.ErrInvalidPasscodeStyle.Error())
+	case errors.Is(err, tetris.ErrPasscodeUnavailable):
+		w.Header().Set("Retry-After", "3")
+		RespondError(w, http.StatusServiceUnavailable, CodePasscodeUnavailable, tetris.ErrPasscodeUnavailable.Error())
+	default:
+		RespondError(w, http.StatusInternalServerError, CodeMatchingFailed, fmt.Sprintf("合言葉でのマッチングに失敗しました: %v", err))
+	}
+}
+
+// CreateRoom はサーバーで生成した合言葉でルームを作成するHTTPハンドラーです。
+// リクエストボディでデッキID・合言葉の形式（"digits": 4桁の数字 / "words": 発音しやすい文字列）・ルールを指定できます。
+// 生成した合言葉はレスポンスの passcode で返すので、作成者はそれを相手に伝えて JoinRoomByPasscode で参加してもらいます。
+func (h *GameHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
+	userID, err := ExtractUserIDFromContext(r)
+	if err != nil {
+		log.Printf("[GameHandler] Failed to extract user ID for room creation: %v", err)
+		RespondError(w, http.StatusUnauthorized, CodeUnauthorized, "認証情報が必要です")
+		return
+	}
+
+	rules := tetris.DefaultGameRules()
+	req := struct {
+		DeckID        string            `json:"deck_id"`
+		PasscodeStyle string            `json:"passcode_style,omitempty"`
+		Rules         *tetris.GameRules `json:"rules,omitempty"`
+	}{PasscodeStyle: tetris.DefaultPasscodeStyle, Rules: &rules}
+	// ボディは省略可能（デフォルトデッキ・デフォルトの形式・通常ルールで作成する）
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		log.Printf("[GameHandler] Failed to parse room creation request body: %v", err)
+		RespondError(w, http.StatusBadRequest, CodeInvalidBody, "リクエストボディの解析に失敗しました")
+		return
+	}
+	deckID, ok := h.resolveDeckID(w, r, userID, req.DeckID)
+	if !ok {
+		return
+	}
+
+	passcode, err := h.sessionManager.CreateRoomWithGeneratedPasscode(userID, deckID, req.PasscodeStyle, rules)
+	if err != nil {
+		log.Printf("[GameHandler] User %s failed to create room: %v", userID, err)
+		respondRoomError(w, err)
+		return
+	}
+
+	log.Printf("[GameHandler] User %s created new session with generated passcode %s", userID, passcode)
+	WriteJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"success":        true,
+		"message":        fmt.Sprintf("合言葉「%s」でルームを作成しました。相手の参加をお待ちください。", passcode),
+		"passcode":       passcode,
+		"session_id":     passcode,
+		"is_new_session": true,
+		"user_id":        userID,
+	})
+}
+
 // DeleteSession は指定された合言葉のセッションを削除するハンドラーです。
+// DELETE /api/game/room/passcode/{passcode}/delete
+// 削除できるのはセッションの参加者（作成者・対戦相手）だけで、それ以外のユーザーは403です（管理者は管理APIから削除します）。
+// 対戦中のセッションを削除した場合は削除した側の棄権となり、相手の勝ちとして結果を記録します。
 func (h *GameHandler) DeleteSession(w http.ResponseWriter, r *http.Request) {
 	log.Printf("[GameHandler] DeleteSession called")
-	
+
+	userID, err := ExtractUserIDFromContext(r)
+	if err != nil {
+		log.Printf("[GameHandler] Failed to extract user ID for session delete: %v", err)
+		RespondError(w, http.StatusUnauthorized, CodeUnauthorized, "認証情報が必要です")
+		return
+	}
+
 	vars := mux.Vars(r)
 	passcode := vars["passcode"] // 合言葉をURLパラメータから取得
 	if passcode == "" {
-		WriteErrorResponse(w, http.StatusBadRequest, "合言葉が必要です")
+		RespondError(w, http.StatusBadRequest, CodeBadRequest, "合言葉が必要です")
 		return
 	}
-	log.Printf("[GameHandler] Deleting session with passcode: %s", passcode)
-
-	// セッションの存在を確認
-	_, exists := h.sessionManager.GetGameSession(passcode)
-	if !exists {
-		WriteErrorResponse(w, http.StatusNotFound, "指定された合言葉のセッションは見つかりませんでした")
+	log.Printf("[GameHandler] User %s deleting session with passcode: %s", userID, passcode)
+
+	if err := h.sessionManager.DeleteSessionByPlayer(passcode, userID); err != nil {
+		log.Printf("[GameHandler] Failed to delete session %s by user %s: %v", passcode, userID, err)
+		switch {
+		case errors.Is(err, tetris.ErrSessionNotFound):
+			RespondError(w, http.StatusNotFound, CodeSessionNotFound, "指定された合言葉のセッションは見つかりませんでした")
+		case errors.Is(err, tetris.ErrNotParticipant):
+			RespondError(w, http.StatusForbidden, CodeForbidden, tetris.ErrNotParticipant.Error())
+		default:
+			RespondError(w, http.StatusInternalServerError, CodeInternalError, fmt.Sprintf("セッションの削除に失敗しました: %v", err))
+		}
 		return
 	}
 
-	// セッションを削除
-	err := h.sessionManager.DeleteSession(passcode)
+	log.Printf("[GameHandler] Successfully deleted session: %s", passcode)
+	WriteJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("セッション「%s」を削除しました", passcode),
+	})
+}
+
+// CancelRoom はマッチング待機中のルームを作成者が解散するハンドラーです。
+// POST /api/game/room/passcode/{passcode}/cancel
+func (h *GameHandler) CancelRoom(w http.ResponseWriter, r *http.Request) {
+	userID, err := ExtractUserIDFromContext(r)
 	if err != nil {
-		log.Printf("[GameHandler] Failed to delete session %s: %v", passcode, err)
-		WriteErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("セッションの削除に失敗しました: %v", err))
+		log.Printf("[GameHandler] Failed to extract user ID for room cancel: %v", err)
+		RespondError(w, http.StatusUnauthorized, CodeUnauthorized, "認証情報が必要です")
 		return
 	}
 
-	log.Printf("[GameHandler] Successfully deleted session: %s", passcode)
+	passcode := mux.Vars(r)["passcode"]
+	if passcode == "" {
+		RespondError(w, http.StatusBadRequest, CodeBadRequest, "合言葉が必要です")
+		return
+	}
+
+	if err := h.sessionManager.CancelRoom(passcode, userID); err != nil {
+		log.Printf("[GameHandler] Failed to cancel room %s by user %s: %v", passcode, userID, err)
+		switch {
+		case errors.Is(err, tetris.ErrSessionNotFound):
+			RespondError(w, http.StatusNotFound, CodeSessionNotFound, "指定された合言葉のセッションは見つかりませんでした")
+		case errors.Is(err, tetris.ErrNotRoomOwner):
+			RespondError(w, http.StatusForbidden, CodeForbidden, "ルームの作成者のみが解散できます")
+		case errors.Is(err, tetris.ErrRoomNotCancellable):
+			RespondError(w, http.StatusConflict, CodeRoomNotCancellable, "対戦相手が参加済みのため、ルームを解散できません")
+		case errors.Is(err, tetris.ErrSessionClosing):
+			w.Header().Set("Retry-After", "3")
+			RespondError(w, http.StatusConflict, CodeSessionClosing, tetris.ErrSessionClosing.Error())
+		default:
+			RespondError(w, http.StatusInternalServerError, CodeInternalError, "ルームの解散に失敗しました")
+		}
+		return
+	}
+
+	log.Printf("[GameHandler] Room %s cancelled by user %s", passcode, userID)
 	WriteJSONResponse(w, http.StatusOK, map[string]interface{}{
 		"success": true,
-		"message": fmt.Sprintf("セッション「%s」を削除しました", passcode),
+		"message": fmt.Sprintf("ルーム「%s」を解散しました", passcode),
 	})
 }
 
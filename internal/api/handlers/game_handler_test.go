@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/api/middleware"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/services/tetris"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestJoinRoomByPasscode_DeckNotSelected はデッキIDが未指定・不正な場合に、セッションマネージャーを呼ばずに
+// 400 DECK_NOT_SELECTED を返すことをテストします（fakeSessionService は参加の操作を実装していないため、呼ばれるとパニックになります）。
+func TestJoinRoomByPasscode_DeckNotSelected(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/game/room/passcode/{passcode}/join", NewGameHandler(&fakeSessionService{}, nil, nil).JoinRoomByPasscode)
+
+	for _, body := range []string{`{}`, `{"deck_id":""}`, `{"deck_id":"not-a-uuid"}`} {
+		req := httptest.NewRequest(http.MethodPost, "/api/game/room/passcode/room/join", strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey{}, "user-1"))
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+		var resp ErrorResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, CodeDeckNotSelected, resp.Error.Code, body)
+		assert.Equal(t, "デッキを選択してください", resp.Error.Message, body)
+	}
+}
+
+// deleteSessionService は DeleteSessionByPlayer の呼び出しを記録し、err を返すテスト用SessionServiceです。
+type deleteSessionService struct {
+	fakeSessionService
+	err      error
+	calledBy string
+}
+
+func (f *deleteSessionService) DeleteSessionByPlayer(passcode, userID string) error {
+	f.calledBy = userID
+	return f.err
+}
+
+// TestDeleteSession_ParticipantsOnly は認証済みユーザーのIDで削除を要求し、参加者以外の場合は403を返すことをテストします。
+func TestDeleteSession_ParticipantsOnly(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{"参加者", nil, http.StatusOK},
+		{"参加者以外", fmt.Errorf("wrapped: %w", tetris.ErrNotParticipant), http.StatusForbidden},
+		{"セッションが無い", fmt.Errorf("wrapped: %w", tetris.ErrSessionNotFound), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := &deleteSessionService{err: tt.err}
+			router := mux.NewRouter()
+			router.HandleFunc("/api/game/room/passcode/{passcode}/delete", NewGameHandler(sm, nil, nil).DeleteSession)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/game/room/passcode/room/delete", nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey{}, "user-1"))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Equal(t, "user-1", sm.calledBy)
+		})
+	}
+}
+
+// TestGetRoomStatus_TimelineParticipantsOnly は timeline=true の指定で参加者には状態遷移の記録を返し、
+// 参加者以外には指定があっても返さないことをテストします。
+func TestGetRoomStatus_TimelineParticipantsOnly(t *testing.T) {
+	session, err := tetris.NewGameSession("room", "player1", nil, nil)
+	assert.NoError(t, err)
+	router := mux.NewRouter()
+	router.HandleFunc("/api/game/room/passcode/{passcode}/status", NewGameHandler(&fakeSessionService{sessions: map[string]*tetris.GameSession{"room": session}}, nil, nil).GetRoomStatus)
+
+	get := func(userID string) tetris.LightweightGameState {
+		req := httptest.NewRequest(http.MethodGet, "/api/game/room/passcode/room/status?timeline=true", nil)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey{}, userID))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var status tetris.LightweightGameState
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+		return status
+	}
+
+	assert.NotEmpty(t, get("player1").Timeline, "参加者には状態遷移の記録を返すはず")
+	assert.Empty(t, get("stranger").Timeline, "参加者以外には状態遷移の記録を返さないはず")
+}
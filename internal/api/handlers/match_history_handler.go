@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+)
+
+const (
+	// DefaultRecentFormLength は戦績APIで返す直近のフォームの試合数のデフォルト値です。
+	DefaultRecentFormLength = 5
+	// MaxRecentFormLength は戦績APIで指定できる直近のフォームの試合数の上限です。
+	MaxRecentFormLength = 20
+)
+
+// MatchHistoryHandler は対戦履歴関連のハンドラーを管理する構造体です。
+type MatchHistoryHandler struct {
+	matchRepo database.MatchHistoryRepository
+}
+
+// NewMatchHistoryHandler は新しいMatchHistoryHandlerインスタンスを作成します。
+func NewMatchHistoryHandler(matchRepo database.MatchHistoryRepository) *MatchHistoryHandler {
+	return &MatchHistoryHandler{
+		matchRepo: matchRepo,
+	}
+}
+
+// GetUserRecord はユーザーの対戦成績（勝敗数・勝率・直近のフォーム）を返すハンドラーです。
+// GET /api/user/{userID}/record?form=5
+// form で直近のフォームに含める試合数を指定できます（1〜MaxRecentFormLength、デフォルト5）。
+func (h *MatchHistoryHandler) GetUserRecord(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["userID"]
+	if _, err := uuid.Parse(userID); err != nil {
+		RespondError(w, http.StatusBadRequest, CodeBadRequest, "ユーザーIDの形式が正しくありません")
+		return
+	}
+
+	formLength := DefaultRecentFormLength
+	if formStr := r.URL.Query().Get("form"); formStr != "" {
+		if parsed, err := strconv.Atoi(formStr); err == nil && parsed > 0 && parsed <= MaxRecentFormLength {
+			formLength = parsed
+		}
+	}
+
+	wins, losses, draws, err := h.matchRepo.GetUserRecord(r.Context(), userID)
+	if err != nil {
+		log.Printf("戦績取得エラー: %v", err)
+		RespondError(w, http.StatusInternalServerError, CodeInternalError, "戦績の取得に失敗しました")
+		return
+	}
+
+	form, err := h.matchRepo.GetRecentForm(r.Context(), userID, formLength)
+	if err != nil {
+		log.Printf("直近の戦績取得エラー: %v", err)
+		RespondError(w, http.StatusInternalServerError, CodeInternalError, "戦績の取得に失敗しました")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewUserRecord(userID, wins, losses, draws, form))
+}
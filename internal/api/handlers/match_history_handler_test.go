@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database/databasetest"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func getUserRecord(t *testing.T, repo database.MatchHistoryRepository, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	router := mux.NewRouter()
+	router.HandleFunc("/api/user/{userID}/record", NewMatchHistoryHandler(repo).GetUserRecord)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+// TestGetUserRecord は勝敗数から対戦数・勝率を計算し、直近のフォームを返すことをテストします。
+func TestGetUserRecord(t *testing.T) {
+	const userID = "3f1c2a9e-5b7d-4c1e-9a2b-8d6f4e3c2b1a"
+	repo := &databasetest.MatchHistoryRepository{Wins: 2, Losses: 1, Draws: 0, Form: "WWL"}
+
+	rec := getUserRecord(t, repo, "/api/user/"+userID+"/record?form=3")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var record models.UserRecord
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &record))
+	assert.Equal(t, models.UserRecord{
+		UserID: userID, Wins: 2, Losses: 1, Draws: 0, Matches: 3, WinRate: 0.667, RecentForm: "WWL",
+	}, record)
+	assert.Equal(t, 3, repo.FormLimit)
+}
+
+// TestGetUserRecord_NoMatches は対戦数0のユーザーには全て0を返し、不正な form 指定はデフォルトになることをテストします。
+func TestGetUserRecord_NoMatches(t *testing.T) {
+	repo := &databasetest.MatchHistoryRepository{}
+
+	rec := getUserRecord(t, repo, "/api/user/3f1c2a9e-5b7d-4c1e-9a2b-8d6f4e3c2b1a/record?form=999")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var record models.UserRecord
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &record))
+	assert.Equal(t, 0, record.Matches)
+	assert.Equal(t, 0.0, record.WinRate)
+	assert.Equal(t, "", record.RecentForm)
+	assert.Equal(t, DefaultRecentFormLength, repo.FormLimit)
+}
+
+// TestGetUserRecord_InvalidUserID はUUID形式でないユーザーIDを400で弾くことをテストします。
+func TestGetUserRecord_InvalidUserID(t *testing.T) {
+	rec := getUserRecord(t, &databasetest.MatchHistoryRepository{}, "/api/user/not-a-uuid/record")
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
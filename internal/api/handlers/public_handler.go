@@ -35,11 +35,11 @@ func (h *PublicHandler) GetUserDisplayNameHandler(w http.ResponseWriter, r *http
 	userID := vars["userID"]
 
 	if userID == "" {
-		http.Error(w, "ユーザーIDが指定されていません", http.StatusBadRequest)
+		RespondError(w, http.StatusBadRequest, CodeBadRequest, "ユーザーIDが指定されていません")
 		return
 	}
 
-	displayName := h.DatabaseService.GetUserDisplayNameByUserID(userID)
+	displayName := h.DatabaseService.GetUserDisplayNameByUserID(r.Context(), userID)
 	
 	response := map[string]string{
 		"userID":      userID,
@@ -49,6 +49,6 @@ func (h *PublicHandler) GetUserDisplayNameHandler(w http.ResponseWriter, r *http
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		log.Printf("GetUserDisplayNameHandler: JSONエンコードエラー: %v", err)
-		http.Error(w, "レスポンスの生成に失敗しました", http.StatusInternalServerError)
+		RespondError(w, http.StatusInternalServerError, CodeInternalError, "レスポンスの生成に失敗しました")
 	}
 }
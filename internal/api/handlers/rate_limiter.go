@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxRateLimiterKeys はレートリミッターが同時に保持するキー数の上限です。
+// 上限に達した場合は期限切れのウィンドウを削除し、それでも空かなければ最も古いウィンドウを削除します。
+const MaxRateLimiterKeys = 10000
+
+// keyRateLimiter はキー（クライアントのIPアドレスなど）ごとに、一定時間内のリクエスト数を制限する固定ウィンドウ方式のレートリミッターです。
+// 無認証で叩けるエンドポイントで、同じクライアントからの連打を抑えるために使います。
+type keyRateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	windows map[string]*rateWindow
+	now     func() time.Time // テストで時刻を差し替えるための関数
+}
+
+// rateWindow はキーごとの現在のウィンドウの開始時刻とリクエスト数です。
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// newKeyRateLimiter は window ごとに limit 回までリクエストを許可するレートリミッターを作成します。
+func newKeyRateLimiter(limit int, window time.Duration) *keyRateLimiter {
+	return &keyRateLimiter{
+		limit:   limit,
+		window:  window,
+		windows: make(map[string]*rateWindow),
+		now:     time.Now,
+	}
+}
+
+// Allow はキーのリクエストを許可するかどうかを返します。
+// 拒否した場合は、次のウィンドウが始まるまでの待ち時間も返します。
+func (l *keyRateLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.window {
+		// 期限切れのウィンドウが溜まり続けないよう、新しいウィンドウを作るついでに掃除する
+		l.pruneLocked(now)
+		if !ok && len(l.windows) >= MaxRateLimiterKeys {
+			l.evictOldestLocked()
+		}
+		l.windows[key] = &rateWindow{start: now, count: 1}
+		return true, 0
+	}
+	if w.count >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
+
+// pruneLocked は期限切れのウィンドウを削除します。l.mu を保持した状態で呼び出してください。
+func (l *keyRateLimiter) pruneLocked(now time.Time) {
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= l.window {
+			delete(l.windows, key)
+		}
+	}
+}
+
+// evictOldestLocked は開始時刻が最も古いウィンドウを1件削除します。l.mu を保持した状態で呼び出してください。
+func (l *keyRateLimiter) evictOldestLocked() {
+	var oldestKey string
+	var oldest time.Time
+	for key, w := range l.windows {
+		if oldestKey == "" || w.start.Before(oldest) {
+			oldestKey, oldest = key, w.start
+		}
+	}
+	delete(l.windows, oldestKey)
+}
+
+// clientIP はレート制限のキーに使うクライアントのIPアドレスを返します。
+// TRUST_PROXY_HEADERS=true の場合（Render などのリバースプロキシの内側で動かす場合）は、
+// プロキシが末尾に追加した X-Forwarded-For の最後のアドレスを使います。
+// 先頭側のアドレスはクライアントが自由に書けるため、制限の回避に使われないよう参照しません。
+func clientIP(r *http.Request) string {
+	if os.Getenv("TRUST_PROXY_HEADERS") == "true" {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			parts := strings.Split(forwarded, ",")
+			if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
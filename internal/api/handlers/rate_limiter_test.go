@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestKeyRateLimiter_EvictsOldestKey はキー数が上限に達した場合、最も古いウィンドウを削除して新しいキーを受け付けることをテストします。
+func TestKeyRateLimiter_EvictsOldestKey(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newKeyRateLimiter(1, time.Hour)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < MaxRateLimiterKeys; i++ {
+		now = now.Add(time.Millisecond)
+		l.Allow(fmt.Sprintf("client-%d", i))
+	}
+	assert.Len(t, l.windows, MaxRateLimiterKeys)
+
+	allowed, _ := l.Allow("new-client")
+	assert.True(t, allowed)
+	assert.Len(t, l.windows, MaxRateLimiterKeys, "上限を超えて保持しない")
+	_, kept := l.windows["client-0"]
+	assert.False(t, kept, "最も古いキーが削除される")
+}
+
+// TestClientIP はプロキシのヘッダーを信頼する設定の場合だけ、X-Forwarded-For の最後のアドレスを使うことをテストします。
+func TestClientIP(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:54321"
+	r.Header.Set("X-Forwarded-For", "1.1.1.1, 203.0.113.7")
+
+	t.Setenv("TRUST_PROXY_HEADERS", "")
+	assert.Equal(t, "10.0.0.1", clientIP(r))
+
+	t.Setenv("TRUST_PROXY_HEADERS", "true")
+	assert.Equal(t, "203.0.113.7", clientIP(r), "クライアントが書ける先頭のアドレスは使わない")
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+)
+
+// RefreshIdempotencyTTL は完了したContribution再取得の結果を、同じ冪等キーのリクエストに使い回す期間です。
+// タイムアウト後のリトライや再取得ボタンの連打で、GitHub APIの呼び出しと保存が繰り返されないようにします。
+const RefreshIdempotencyTTL = 30 * time.Second
+
+// RefreshWaitTimeout は進行中の同じ再取得の完了を待つ時間の上限です。
+// GitHub APIの呼び出し（github.DefaultHTTPTimeout）と保存が詰まっても、後続のリクエストがハングしないようにします。
+const RefreshWaitTimeout = 30 * time.Second
+
+// errRefreshWaitTimeout は進行中の再取得の完了待ちが RefreshWaitTimeout を超えたことを表します。
+// context.DeadlineExceeded としても判定できます。
+var errRefreshWaitTimeout = fmt.Errorf("進行中の貢献データ再取得の完了待ちがタイムアウトしました: %w", context.DeadlineExceeded)
+
+// refreshResult はContribution再取得（GitHubからの取得と保存）の結果です。
+type refreshResult struct {
+	contributions      []models.DailyContribution // 保存した日別データ
+	skipped            int                        // 不正値としてスキップした件数
+	totalContributions int                        // GitHubが返した期間内の合計貢献数
+}
+
+// refreshCall は冪等キーごとの進行中または完了済みの再取得です。
+type refreshCall struct {
+	done       chan struct{} // 完了時にクローズされる
+	result     refreshResult
+	err        error
+	finishedAt time.Time
+}
+
+// refreshGuard は同じ冪等キーの再取得を短時間に重複して実行しないためのガードです。
+// 同じキーの処理が進行中なら完了を待って結果を共有し、RefreshIdempotencyTTL 以内に完了していればその結果を返します。
+// 失敗した結果は使い回さず、次のリクエストで再実行します。
+type refreshGuard struct {
+	mu          sync.Mutex
+	ttl         time.Duration
+	waitTimeout time.Duration // 進行中の処理の完了を待つ時間の上限
+	calls       map[string]*refreshCall
+	now         func() time.Time // テストで時刻を差し替えるための関数
+}
+
+// newRefreshGuard は完了した結果を ttl の間使い回す refreshGuard を作成します。
+func newRefreshGuard(ttl time.Duration) *refreshGuard {
+	return &refreshGuard{
+		ttl:         ttl,
+		waitTimeout: RefreshWaitTimeout,
+		calls:       make(map[string]*refreshCall),
+		now:         time.Now,
+	}
+}
+
+// refreshIdempotencyKey は認証済みのユーザーID・GitHubユーザー名（大文字小文字を区別しない）と取得期間から冪等キーを作成します。
+// 結果は userID の行として保存されるため、同じGitHubユーザー名を登録した別のユーザーの結果を共有しないようユーザーIDも含めます。
+func refreshIdempotencyKey(userID, githubUsername string, start, end time.Time) string {
+	sum := sha256.Sum256([]byte(userID + "|" + strings.ToLower(githubUsername) + "|" + start.Format(time.RFC3339) + "|" + end.Format(time.RFC3339)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Do は冪等キーの再取得を実行するか、進行中・完了済みの同じキーの結果を返します。
+// 進行中の処理を待っている間に ctx が終了した場合は ctx のエラーを、waitTimeout を超えた場合は
+// errRefreshWaitTimeout を返します（どちらの場合も処理自体は継続します）。
+//
+// Parameters:
+//   ctx : 待機を打ち切るためのコンテキスト
+//   key : refreshIdempotencyKey で作成した冪等キー
+//   fn  : 実際の取得・保存処理
+// Returns:
+//   refreshResult: 再取得の結果
+//   bool: 他のリクエストの結果を共有したかどうか
+//   error: 再取得に失敗した場合
+func (g *refreshGuard) Do(ctx context.Context, key string, fn func() (refreshResult, error)) (refreshResult, bool, error) {
+	g.mu.Lock()
+	now := g.now()
+	if call, ok := g.calls[key]; ok {
+		select {
+		case <-call.done:
+			// 完了済み: 成功した結果が新しければ使い回す（失敗・期限切れは下で再実行）
+			if call.err == nil && now.Sub(call.finishedAt) < g.ttl {
+				g.mu.Unlock()
+				return call.result, true, nil
+			}
+		default:
+			// 進行中: 完了を待って結果を共有する
+			g.mu.Unlock()
+			timer := time.NewTimer(g.waitTimeout)
+			defer timer.Stop()
+			select {
+			case <-call.done:
+				return call.result, true, call.err
+			case <-ctx.Done():
+				return refreshResult{}, true, ctx.Err()
+			case <-timer.C:
+				return refreshResult{}, true, errRefreshWaitTimeout
+			}
+		}
+	}
+	g.pruneLocked(now)
+	call := &refreshCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	result, err := fn()
+
+	g.mu.Lock()
+	call.result, call.err, call.finishedAt = result, err, g.now()
+	if err != nil && g.calls[key] == call {
+		delete(g.calls, key)
+	}
+	close(call.done)
+	g.mu.Unlock()
+	return result, false, err
+}
+
+// pruneLocked は使い回し期間を過ぎた完了済みの結果を削除します。g.mu を保持した状態で呼び出してください。
+func (g *refreshGuard) pruneLocked(now time.Time) {
+	for key, call := range g.calls {
+		select {
+		case <-call.done:
+			if now.Sub(call.finishedAt) >= g.ttl {
+				delete(g.calls, key)
+			}
+		default:
+		}
+	}
+}
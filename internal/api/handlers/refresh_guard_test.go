@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRefreshGuard_SharesInFlightAndRecentResults は同じ冪等キーの再取得が、進行中なら完了を待って結果を共有し、
+// 完了後も使い回し期間内は再実行されないことをテストします。
+func TestRefreshGuard_SharesInFlightAndRecentResults(t *testing.T) {
+	guard := newRefreshGuard(RefreshIdempotencyTTL)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	guard.now = func() time.Time { return now }
+	key := refreshIdempotencyKey("user-1", "OctoCat", now, now.Add(time.Hour))
+	assert.Equal(t, key, refreshIdempotencyKey("user-1", "octocat", now, now.Add(time.Hour)), "ユーザー名の大文字小文字は区別しないはず")
+	assert.NotEqual(t, key, refreshIdempotencyKey("user-2", "octocat", now, now.Add(time.Hour)), "別のユーザーの再取得とは共有しないはず")
+
+	calls := 0
+	release := make(chan struct{})
+	fn := func() (refreshResult, error) {
+		calls++
+		<-release
+		return refreshResult{contributions: []models.DailyContribution{{Date: "2026-03-01", Count: 3}}, totalContributions: 3}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]refreshResult, 2)
+	shared := make([]bool, 2)
+	started := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		close(started)
+		results[0], shared[0], _ = guard.Do(context.Background(), key, fn)
+	}()
+	<-started
+	// 1件目が進行中として登録されるまで待ってから2件目を投げる
+	assert.Eventually(t, func() bool {
+		guard.mu.Lock()
+		defer guard.mu.Unlock()
+		return guard.calls[key] != nil
+	}, time.Second, time.Millisecond)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[1], shared[1], _ = guard.Do(context.Background(), key, fn)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, 1, calls, "進行中の同じキーは待って結果を共有するはず")
+	assert.Equal(t, results[0], results[1])
+	assert.ElementsMatch(t, []bool{false, true}, shared)
+
+	_, replayed, err := guard.Do(context.Background(), key, fn)
+	assert.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, 1, calls, "使い回し期間内は再実行しないはず")
+
+	now = now.Add(RefreshIdempotencyTTL)
+	_, replayed, _ = guard.Do(context.Background(), key, fn) // release はクローズ済みなのでブロックしない
+	assert.False(t, replayed)
+	assert.Equal(t, 2, calls, "使い回し期間を過ぎたら再実行するはず")
+}
+
+// TestRefreshGuard_DoesNotCacheErrors は失敗した再取得の結果を使い回さず、次のリクエストで再実行することをテストします。
+func TestRefreshGuard_DoesNotCacheErrors(t *testing.T) {
+	guard := newRefreshGuard(RefreshIdempotencyTTL)
+	calls := 0
+	fn := func() (refreshResult, error) {
+		calls++
+		if calls == 1 {
+			return refreshResult{}, errors.New("github unavailable")
+		}
+		return refreshResult{totalContributions: 1}, nil
+	}
+
+	_, _, err := guard.Do(context.Background(), "key", fn)
+	assert.Error(t, err)
+	result, replayed, err := guard.Do(context.Background(), "key", fn)
+	assert.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Equal(t, 1, result.totalContributions)
+	assert.Equal(t, 2, calls)
+}
+
+// TestRefreshGuard_WaitTimeout は進行中の再取得が終わらない場合、後続のリクエストが waitTimeout で待機を打ち切り、
+// 処理自体は継続して結果が記録されることをテストします。
+func TestRefreshGuard_WaitTimeout(t *testing.T) {
+	guard := newRefreshGuard(RefreshIdempotencyTTL)
+	guard.waitTimeout = 10 * time.Millisecond
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		guard.Do(context.Background(), "key", func() (refreshResult, error) {
+			<-release
+			return refreshResult{totalContributions: 5}, nil
+		})
+	}()
+	assert.Eventually(t, func() bool {
+		guard.mu.Lock()
+		defer guard.mu.Unlock()
+		return guard.calls["key"] != nil
+	}, time.Second, time.Millisecond)
+
+	_, shared, err := guard.Do(context.Background(), "key", func() (refreshResult, error) {
+		t.Fatal("進行中の同じキーを重複して実行してはいけない")
+		return refreshResult{}, nil
+	})
+	assert.True(t, shared)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	<-done
+	result, replayed, err := guard.Do(context.Background(), "key", nil)
+	assert.NoError(t, err)
+	assert.True(t, replayed, "待機を打ち切っても処理は継続し、結果は使い回されるはず")
+	assert.Equal(t, 5, result.totalContributions)
+}
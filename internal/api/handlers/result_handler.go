@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/gorilla/mux"
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database"
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
 )
@@ -23,10 +24,11 @@ func NewResultHandler(resultRepo database.ResultRepository) *ResultHandler {
 }
 
 // GetTopResults は上位ランキングを取得するハンドラーです。
-// GET /api/results?limit=50
+// mode（solo / versus）を指定するとその種別だけのランキングを返します（省略時はすべての種別）。
+// GET /api/results?limit=50&mode=versus
 func (h *ResultHandler) GetTopResults(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		RespondError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -39,10 +41,16 @@ func (h *ResultHandler) GetTopResults(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	results, err := h.resultRepo.GetTopResults(limit)
+	mode, ok := models.ParseGameMode(r.URL.Query().Get("mode"))
+	if !ok {
+		RespondError(w, http.StatusBadRequest, CodeBadRequest, "modeは solo または versus を指定してください")
+		return
+	}
+
+	results, err := h.resultRepo.GetTopResults(r.Context(), limit, mode)
 	if err != nil {
 		log.Printf("ゲーム結果取得エラー: %v", err)
-		http.Error(w, "ゲーム結果取得に失敗しました", http.StatusInternalServerError)
+		RespondError(w, http.StatusInternalServerError, CodeInternalError, "ゲーム結果取得に失敗しました")
 		return
 	}
 
@@ -53,80 +61,112 @@ func (h *ResultHandler) GetTopResults(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// PostScore はスコアを保存するハンドラーです。
-// POST /api/results
-func (h *ResultHandler) PostScore(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// GetUserResult は指定したユーザーのランキングを取得するハンドラーです。
+// mode（solo / versus）を指定するとその種別のランキングでの最高スコアと順位を返します（省略時はすべての種別）。
+// GET /api/results/user/{userID}?mode=versus
+func (h *ResultHandler) GetUserResult(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		RespondError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	var req models.ResultRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "無効なリクエストボディです", http.StatusBadRequest)
+	// パスパラメータからuserIDを取得（末尾スラッシュやエスケープの扱いはルーターに任せる）
+	userID := mux.Vars(r)["userID"]
+	if userID == "" {
+		RespondError(w, http.StatusBadRequest, CodeBadRequest, "userIDが指定されていません")
 		return
 	}
 
-	// バリデーション
-	if req.UserID == "" {
-		http.Error(w, "user_idは必須です", http.StatusBadRequest)
+	mode, ok := models.ParseGameMode(r.URL.Query().Get("mode"))
+	if !ok {
+		RespondError(w, http.StatusBadRequest, CodeBadRequest, "modeは solo または versus を指定してください")
 		return
 	}
-	if req.Score < 0 {
-		http.Error(w, "スコアは0以上である必要があります", http.StatusBadRequest)
+
+	userResult, err := h.resultRepo.GetUserRanking(r.Context(), userID, mode)
+	if err != nil {
+		log.Printf("ユーザー結果取得エラー: %v", err)
+		RespondError(w, http.StatusInternalServerError, CodeInternalError, "ユーザー結果取得に失敗しました")
 		return
 	}
 
-	// スコアを保存
-	result, err := h.resultRepo.CreateResult(nil, req.UserID, req.Score)
-	if err != nil {
-		log.Printf("スコア保存エラー: %v", err)
-		http.Error(w, "スコア保存に失敗しました", http.StatusInternalServerError)
+	if userResult == nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"result":  nil,
+			"message": "ユーザーのスコアが見つかりません",
+		})
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"result":  result,
+		"result":  userResult,
 	})
 }
 
-// GetUserResult は指定したユーザーのランキングを取得するハンドラーです。
-// GET /api/results/user/{user_id}
-func (h *ResultHandler) GetUserResult(w http.ResponseWriter, r *http.Request) {
+// GetUserResultHistory は指定したユーザーのスコア履歴を新しい順に取得するハンドラーです。
+// 各エントリには記録時点で自己ベストを更新したかどうかのフラグが付きます。
+// GET /api/results/user/{userID}/history?limit=20&offset=0
+func (h *ResultHandler) GetUserResultHistory(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		RespondError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	// URLからuser_idを抽出（パスパラメータ）
-	userID := r.URL.Path[len("/api/results/user/"):]
+	userID := mux.Vars(r)["userID"]
 	if userID == "" {
-		http.Error(w, "user_idが指定されていません", http.StatusBadRequest)
+		RespondError(w, http.StatusBadRequest, CodeBadRequest, "userIDが指定されていません")
 		return
 	}
 
-	userResult, err := h.resultRepo.GetUserRanking(userID)
+	// limit（デフォルト20、最大100）とoffset（デフォルト0）を取得
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 100 {
+			limit = parsedLimit
+		}
+	}
+	offset := 0
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
+	}
+
+	// 次ページの有無を判定するため1件多く取得する
+	results, err := h.resultRepo.GetUserResultsPage(r.Context(), userID, limit+1, offset)
 	if err != nil {
-		log.Printf("ユーザー結果取得エラー: %v", err)
-		http.Error(w, "ユーザー結果取得に失敗しました", http.StatusInternalServerError)
+		log.Printf("ユーザーのスコア履歴取得エラー: %v", err)
+		RespondError(w, http.StatusInternalServerError, CodeInternalError, "スコア履歴の取得に失敗しました")
 		return
 	}
+	hasMore := len(results) > limit
+	if hasMore {
+		results = results[:limit]
+	}
 
-	if userResult == nil {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"result":  nil,
-			"message": "ユーザーのスコアが見つかりません",
-		})
-		return
+	// ページより前のリザルトの最高スコアを起点に自己ベスト更新を判定する
+	var previousBest *int
+	if len(results) > 0 {
+		previousBest, err = h.resultRepo.GetUserBestScoreBefore(r.Context(), userID, results[len(results)-1])
+		if err != nil {
+			log.Printf("ユーザーの過去の最高スコア取得エラー: %v", err)
+			RespondError(w, http.StatusInternalServerError, CodeInternalError, "スコア履歴の取得に失敗しました")
+			return
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"result":  userResult,
+		"results": models.MarkPersonalBests(results, previousBest),
+		"pagination": map[string]interface{}{
+			"limit":    limit,
+			"offset":   offset,
+			"has_more": hasMore,
+		},
 	})
-} 
\ No newline at end of file
+}
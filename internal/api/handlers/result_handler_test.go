@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database/databasetest"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGetTopResults_FiltersByMode は mode クエリで種別を絞り込み、省略時はすべての種別、不正な値は400になることをテストします。
+func TestGetTopResults_FiltersByMode(t *testing.T) {
+	repo := &databasetest.ResultRepository{}
+	handler := NewResultHandler(repo)
+
+	for _, query := range []string{"?mode=solo", "?mode=versus", ""} {
+		rec := httptest.NewRecorder()
+		handler.GetTopResults(rec, httptest.NewRequest(http.MethodGet, "/api/results"+query, nil))
+		assert.Equal(t, http.StatusOK, rec.Code, query)
+	}
+	assert.Equal(t, []models.GameMode{models.GameModeSolo, models.GameModeVersus, ""}, repo.TopModes)
+
+	rec := httptest.NewRecorder()
+	handler.GetTopResults(rec, httptest.NewRequest(http.MethodGet, "/api/results?mode=ranked", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Len(t, repo.TopModes, 3)
+}
+
+// TestGetUserResult_FiltersByMode はユーザーのランキングも mode クエリで種別を絞り込み、不正な値は400になることをテストします。
+func TestGetUserResult_FiltersByMode(t *testing.T) {
+	repo := &databasetest.ResultRepository{}
+	handler := NewResultHandler(repo)
+
+	for _, query := range []string{"?mode=solo", "?mode=versus", ""} {
+		rec := httptest.NewRecorder()
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/results/user/user-1"+query, nil), map[string]string{"userID": "user-1"})
+		handler.GetUserResult(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code, query)
+	}
+	assert.Equal(t, []models.GameMode{models.GameModeSolo, models.GameModeVersus, ""}, repo.RankingModes)
+
+	rec := httptest.NewRecorder()
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/results/user/user-1?mode=ranked", nil), map[string]string{"userID": "user-1"})
+	handler.GetUserResult(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Len(t, repo.RankingModes, 3)
+}
+
+// TestGetUserResult_UsesRouteVars はユーザーIDをルートパラメータ userID から取得し、
+// 末尾スラッシュ付きのパスをユーザーIDの一部として扱わないことをテストします。
+func TestGetUserResult_UsesRouteVars(t *testing.T) {
+	repo := &databasetest.ResultRepository{}
+	router := mux.NewRouter()
+	router.HandleFunc("/api/results/user/{userID}", NewResultHandler(repo).GetUserResult)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/results/user/user-1", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/results/user/user-1/", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, []string{"user-1"}, repo.RankingUsers)
+}
+
+// TestGetUserResultHistory_Pagination はスコア履歴の limit・offset の既定値と範囲外の値の扱い、
+// 次ページの有無、ページより前の最高スコアを起点にした自己ベストの判定をテストします。
+func TestGetUserResultHistory_Pagination(t *testing.T) {
+	history := make([]models.Result, 25)
+	for i := range history {
+		history[i] = models.Result{ID: int64(25 - i), UserID: "user-1", Score: 100 * (25 - i)} // 新しいほど高スコア
+	}
+	best := 2000
+
+	tests := []struct {
+		name        string
+		query       string
+		wantPage    [2]int // リポジトリに渡す limit（1件多く取得する）と offset
+		wantCount   int
+		wantHasMore bool
+		wantBefore  []int64 // 過去の最高スコアを問い合わせたリザルトのID
+	}{
+		{name: "既定値は20件目まで", query: "", wantPage: [2]int{21, 0}, wantCount: 20, wantHasMore: true, wantBefore: []int64{6}},
+		{name: "最後のページは次ページ無し", query: "?limit=10&offset=20", wantPage: [2]int{11, 20}, wantCount: 5, wantBefore: []int64{1}},
+		{name: "ちょうど最後まで取得した場合も次ページ無し", query: "?limit=25", wantPage: [2]int{26, 0}, wantCount: 25, wantBefore: []int64{1}},
+		{name: "範囲外のlimitは既定値", query: "?limit=101", wantPage: [2]int{21, 0}, wantCount: 20, wantHasMore: true, wantBefore: []int64{6}},
+		{name: "0のlimitは既定値", query: "?limit=0", wantPage: [2]int{21, 0}, wantCount: 20, wantHasMore: true, wantBefore: []int64{6}},
+		{name: "負のoffsetは0", query: "?limit=5&offset=-1", wantPage: [2]int{6, 0}, wantCount: 5, wantHasMore: true, wantBefore: []int64{21}},
+		{name: "数値でないoffsetは0", query: "?limit=5&offset=abc", wantPage: [2]int{6, 0}, wantCount: 5, wantHasMore: true, wantBefore: []int64{21}},
+		{name: "範囲外のoffsetは空で問い合わせない", query: "?offset=30", wantPage: [2]int{21, 30}, wantCount: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &databasetest.ResultRepository{History: history, BestBefore: &best}
+			req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/results/user/user-1/history"+tt.query, nil), map[string]string{"userID": "user-1"})
+			rec := httptest.NewRecorder()
+
+			NewResultHandler(repo).GetUserResultHistory(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			var body struct {
+				Results    []models.ResultHistoryEntry `json:"results"`
+				Pagination struct {
+					HasMore bool `json:"has_more"`
+				} `json:"pagination"`
+			}
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.NotNil(t, body.Results, "空でも配列を返すはず")
+			assert.Len(t, body.Results, tt.wantCount)
+			assert.Equal(t, tt.wantHasMore, body.Pagination.HasMore)
+			assert.Equal(t, [][2]int{tt.wantPage}, repo.Pages)
+			assert.Equal(t, tt.wantBefore, repo.BeforeIDs)
+			for _, entry := range body.Results {
+				assert.Equal(t, entry.Score > best, entry.IsPersonalBest, "ID %d", entry.ID)
+			}
+		})
+	}
+}
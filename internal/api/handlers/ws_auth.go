@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/services/tetris"
+)
+
+// DefaultWSAuthTimeout はWebSocket接続後、認証メッセージの受信を待つ時間のデフォルト値です。
+const DefaultWSAuthTimeout = 10 * time.Second
+
+// wsAuthReadLimit は認証前の接続から読み取るメッセージの最大サイズ（バイト）です。
+// 認証メッセージ（JWTを含む）が収まる大きさに抑え、未認証の接続に大きなメッセージを読ませないようにします。
+const wsAuthReadLimit = 4096
+
+// wsAuthTimeout は認証フェーズ全体のタイムアウトです。読み取りごとに延長はせず、接続直後から1回分として数えます。
+// 環境変数 WS_AUTH_TIMEOUT（"10s" などの時間表記）で上書きできます。
+var wsAuthTimeout = loadWSAuthTimeout()
+
+// loadWSAuthTimeout は環境変数 WS_AUTH_TIMEOUT を読み込みます。不正な値の場合はデフォルト値を使います。
+func loadWSAuthTimeout() time.Duration {
+	env := os.Getenv("WS_AUTH_TIMEOUT")
+	if env == "" {
+		return DefaultWSAuthTimeout
+	}
+	timeout, err := time.ParseDuration(env)
+	if err != nil || timeout <= 0 {
+		log.Printf("[WARN] Invalid WS_AUTH_TIMEOUT %q, using default %v", env, DefaultWSAuthTimeout)
+		return DefaultWSAuthTimeout
+	}
+	return timeout
+}
+
+// isTimeoutError は読み取りエラーがReadDeadline超過によるものかどうかを返します。
+func isTimeoutError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// WebSocket接続の確立で失敗した段階です（WSAuthError.Stage）。
+// Upgrade前の失敗（合言葉・オリジン・ルームの確認）はWebSocketではなくHTTPのエラーレスポンス（ErrorResponse）で返します。
+const (
+	WSStageAuth     = "auth"     // Upgrade後、認証メッセージの受信・検証で失敗した
+	WSStageRegister = "register" // 認証後、セッションへのクライアント登録で失敗した
+)
+
+// WebSocket接続の確立に失敗した理由です（WSAuthError.Reason）。クライアントはこの値で再試行の方法を判断します。
+const (
+	WSAuthReasonTimeout        = "auth_timeout"        // 期限内に認証メッセージが届かなかった（そのまま再接続してよい）
+	WSAuthReasonInvalidMessage = "invalid_message"     // 認証メッセージの形式・種類が不正（クライアントの修正が必要）
+	WSAuthReasonInvalidToken   = "invalid_token"       // トークンが無効・期限切れ（トークンを更新してから再接続する）
+	WSAuthReasonServerConfig   = "server_config_error" // サーバー側の設定不備（再試行しても解決しない）
+	WSAuthReasonServerBusy     = "server_busy"         // 接続数が上限に達している（時間をおいて再接続する）
+
+	WSAuthReasonUnsupportedVersion = "unsupported_version" // 申告したプロトコルバージョンに対応していない（supported_versions のバージョンで再接続する）
+)
+
+// WSAuthError はUpgrade後の接続確立で失敗した場合にクライアントへ送るメッセージです。
+// 例: {"type":"auth_error","v":1,"stage":"auth","reason":"invalid_token","message":"Invalid token","retryable":false,"error":"Invalid token"}
+type WSAuthError struct {
+	Type      string `json:"type"`      // 常に "auth_error"
+	Version   int    `json:"v"`         // プロトコルバージョン（tetris.ProtocolVersion）
+	Stage     string `json:"stage"`     // 失敗した段階（WSStage* 定数）
+	Reason    string `json:"reason"`    // 失敗の理由（WSAuthReason* 定数）
+	Message   string `json:"message"`   // 人が読むための説明（変更される可能性があるため分岐には reason を使う）
+	Retryable bool   `json:"retryable"` // 同じ内容のまま再接続して成功する可能性があるか
+	Error     string `json:"error"`     // 従来の {"error": message} 形式のクライアント向け（message と同じ）
+
+	SupportedVersions []int `json:"supported_versions,omitempty"` // サーバーが対応しているプロトコルバージョン（unsupported_version の場合のみ）
+}
+
+// newWSAuthError は段階と理由から WSAuthError を作成します。
+func newWSAuthError(stage, reason, message string) WSAuthError {
+	authErr := WSAuthError{
+		Type:      "auth_error",
+		Version:   tetris.ProtocolVersion,
+		Stage:     stage,
+		Reason:    reason,
+		Message:   message,
+		Retryable: reason == WSAuthReasonTimeout || reason == WSAuthReasonServerBusy,
+		Error:     message,
+	}
+	if reason == WSAuthReasonUnsupportedVersion {
+		authErr.SupportedVersions = tetris.SupportedProtocolVersions
+	}
+	return authErr
+}
+
+// closeCode はエラーの理由に対応するWebSocketのクローズコードを返します。
+func (e WSAuthError) closeCode() int {
+	switch e.Reason {
+	case WSAuthReasonServerBusy:
+		return websocket.CloseTryAgainLater
+	case WSAuthReasonServerConfig:
+		return websocket.CloseInternalServerErr
+	default:
+		return websocket.ClosePolicyViolation
+	}
+}
+
+// rejectAuth は接続確立のエラーを WSAuthError としてクライアントに送り、理由を載せたクローズフレームを送って接続を閉じます。
+// 読み取りのタイムアウト後も書き込みはできるため、送信にも短い期限を設けて相手が受信しない場合に詰まらないようにします。
+//
+// Parameters:
+//   conn    : 認証中のWebSocket接続
+//   stage   : 失敗した段階（WSStage* 定数）
+//   reason  : 失敗の理由（WSAuthReason* 定数、クローズフレームの理由にも使う）
+//   message : 人が読むための説明
+func rejectAuth(conn *websocket.Conn, stage, reason, message string) {
+	authErr := newWSAuthError(stage, reason, message)
+	conn.SetWriteDeadline(time.Now().Add(time.Second))
+	conn.WriteJSON(authErr)
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(authErr.closeCode(), reason))
+	conn.Close()
+}
+
+// respondUpgradeError はUpgradeのハンドシェイク自体が不正な場合（ヘッダー不足など）に、統一形式のHTTPエラーを返します。
+// websocket.Upgrader の Error に設定し、Upgrade前の失敗をすべて ErrorResponse で返せるようにします。
+func respondUpgradeError(w http.ResponseWriter, r *http.Request, status int, reason error) {
+	log.Printf("[GameHandler] WebSocket handshake failed for %s: %v", r.URL.Path, reason)
+	code := CodeBadRequest
+	if status == http.StatusForbidden {
+		code = CodeForbidden
+	}
+	RespondError(w, status, code, "WebSocketのハンドシェイクに失敗しました: "+reason.Error())
+}
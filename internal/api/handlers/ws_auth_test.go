@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/services/tetris"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSessionService は指定した合言葉のセッションだけを返すテスト用SessionServiceです。
+type fakeSessionService struct {
+	tetris.SessionService
+	sessions map[string]*tetris.GameSession
+}
+
+func (f *fakeSessionService) GetGameSession(passcode string) (*tetris.GameSession, bool) {
+	session, ok := f.sessions[passcode]
+	return session, ok
+}
+
+// newTestWebSocketServer は合言葉 "room" の待機中セッションだけを持つWebSocketエンドポイントのテストサーバーを作成します。
+func newTestWebSocketServer(t *testing.T) *httptest.Server {
+	sm := &fakeSessionService{sessions: map[string]*tetris.GameSession{"room": {ID: "room", Status: "waiting"}}}
+	router := mux.NewRouter()
+	router.HandleFunc("/api/game/ws/{passcode}", NewGameHandler(sm, nil, nil).HandleWebSocketConnection)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestHandleWebSocketConnection_AuthTimeout は認証メッセージを送らない接続に、
+// WS_AUTH_TIMEOUT 経過後 auth_timeout の auth_error を送って接続を閉じることをテストします。
+func TestHandleWebSocketConnection_AuthTimeout(t *testing.T) {
+	original := wsAuthTimeout
+	wsAuthTimeout = 50 * time.Millisecond
+	defer func() { wsAuthTimeout = original }()
+
+	server := newTestWebSocketServer(t)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/game/ws/room", nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var response WSAuthError
+	assert.NoError(t, conn.ReadJSON(&response))
+	assert.Equal(t, "auth_error", response.Type)
+	assert.Equal(t, WSStageAuth, response.Stage)
+	assert.Equal(t, WSAuthReasonTimeout, response.Reason)
+	assert.True(t, response.Retryable)
+	assert.Equal(t, "auth timeout", response.Error, "従来の error フィールドも残すはず")
+
+	_, _, err = conn.ReadMessage()
+	assert.Error(t, err, "タイムアウト後は接続が閉じられるはず")
+}
+
+// TestHandleWebSocketConnection_InvalidAuthMessage は認証以外のメッセージに invalid_message の auth_error と
+// ポリシー違反のクローズフレームを返すことをテストします。
+func TestHandleWebSocketConnection_InvalidAuthMessage(t *testing.T) {
+	server := newTestWebSocketServer(t)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/game/ws/room", nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	assert.NoError(t, conn.WriteJSON(map[string]string{"type": "input", "action": "left"}))
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var response WSAuthError
+	assert.NoError(t, conn.ReadJSON(&response))
+	assert.Equal(t, WSAuthReasonInvalidMessage, response.Reason)
+	assert.False(t, response.Retryable)
+
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), "got %v", err)
+}
+
+// TestHandleWebSocketConnection_UnsupportedVersion は対応していないプロトコルバージョンを申告した認証メッセージに、
+// トークンを検証せず unsupported_version の auth_error と対応バージョンの一覧を返すことをテストします。
+func TestHandleWebSocketConnection_UnsupportedVersion(t *testing.T) {
+	server := newTestWebSocketServer(t)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/game/ws/room", nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	assert.NoError(t, conn.WriteJSON(map[string]interface{}{"type": "auth", "v": 99, "token": "BYPASS_AUTH"}))
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var response WSAuthError
+	assert.NoError(t, conn.ReadJSON(&response))
+	assert.Equal(t, tetris.ProtocolVersion, response.Version)
+	assert.Equal(t, WSAuthReasonUnsupportedVersion, response.Reason)
+	assert.Equal(t, tetris.SupportedProtocolVersions, response.SupportedVersions)
+	assert.False(t, response.Retryable)
+
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), "got %v", err)
+}
+
+// TestHandleWebSocketConnection_RejectedBeforeUpgrade は存在しないルーム・許可されていないオリジンの接続が、
+// Upgradeせずに統一形式のHTTPエラーで拒否されることをテストします。
+func TestHandleWebSocketConnection_RejectedBeforeUpgrade(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+	server := newTestWebSocketServer(t)
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	tests := []struct {
+		name       string
+		path       string
+		origin     string
+		wantStatus int
+		wantCode   ErrorCode
+	}{
+		{"ルームが存在しない", "/api/game/ws/missing", "", http.StatusNotFound, CodeSessionNotFound},
+		{"許可されていないオリジン", "/api/game/ws/room", "https://evil.example.com", http.StatusForbidden, CodeForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.origin != "" {
+				header.Set("Origin", tt.origin)
+			}
+			conn, resp, err := websocket.DefaultDialer.Dial(wsURL+tt.path, header)
+			if conn != nil {
+				conn.Close()
+			}
+			assert.ErrorIs(t, err, websocket.ErrBadHandshake)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+
+			var body ErrorResponse
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, tt.wantCode, body.Error.Code)
+		})
+	}
+}
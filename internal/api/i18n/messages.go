@@ -0,0 +1,133 @@
+// Package i18n はAPIのエラーメッセージの多言語対応（日本語・英語）を提供します。
+// クライアントの Accept-Language から言語を決め、エラーコードごとのメッセージカタログから文言を選びます。
+package i18n
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Language はエラーメッセージの言語です。
+type Language string
+
+const (
+	Japanese Language = "ja"
+	English  Language = "en"
+
+	// DefaultLanguage は Accept-Language が無い・対応していない言語の場合に使う言語です。
+	DefaultLanguage = Japanese
+)
+
+// LanguageHeader は決定した言語をレスポンスに記録するヘッダーです。
+// ミドルウェアが設定し、エラーレスポンスを書く側がレスポンスライターのラップに関係なく読み取れるようにします。
+const LanguageHeader = "Content-Language"
+
+// ParseAcceptLanguage は Accept-Language ヘッダーから対応している言語を選びます。
+// q値の高い順に対応言語（ja / en、地域指定の ja-JP・en-US なども含む）を探し、見つからなければ DefaultLanguage を返します。
+//
+// Parameters:
+//   header : Accept-Language ヘッダーの値（例: "en-US,en;q=0.9,ja;q=0.8"）
+func ParseAcceptLanguage(header string) Language {
+	best, bestQ := DefaultLanguage, 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		lang := Language(primary)
+		if (lang == Japanese || lang == English) && q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+// ResponseLanguage はミドルウェアがレスポンスに記録した言語を返します（未設定の場合は DefaultLanguage）。
+func ResponseLanguage(w http.ResponseWriter) Language {
+	switch lang := Language(w.Header().Get(LanguageHeader)); lang {
+	case Japanese, English:
+		return lang
+	default:
+		return DefaultLanguage
+	}
+}
+
+// Localize はエラーメッセージをクライアントの言語に合わせます。
+// 呼び出し側のメッセージは状況に応じた詳しい内容なので、その言語（messageLang）と一致する場合はそのまま使い、
+// 一致しない場合はエラーコードのカタログの文言に置き換えます（カタログに無いコードは元のメッセージのまま）。
+//
+// Parameters:
+//   lang        : クライアントの言語
+//   code        : エラーコード（例: "DECK_NOT_FOUND"）
+//   message     : 呼び出し側のメッセージ
+//   messageLang : message の言語
+func Localize(lang Language, code, message string, messageLang Language) string {
+	if lang == messageLang && message != "" {
+		return message
+	}
+	if localized, ok := catalog[code]; ok {
+		if lang == English {
+			return localized.en
+		}
+		return localized.ja
+	}
+	return message
+}
+
+// localizedMessage はエラーコード1つ分の各言語の文言です。
+type localizedMessage struct {
+	ja string
+	en string
+}
+
+// catalog はエラーコード → 各言語の文言のメッセージカタログです。
+// エラーコードは handlers.ErrorCode の定数と揃えてください（認証系のコードはミドルウェアも使います）。
+var catalog = map[string]localizedMessage{
+	// 共通・リクエスト
+	"BAD_REQUEST":        {"リクエストの形式またはパラメータが不正です", "The request is malformed or has invalid parameters."},
+	"INVALID_BODY":       {"リクエストボディを解析できませんでした", "The request body could not be parsed."},
+	"PAYLOAD_TOO_LARGE":  {"リクエストボディが大きすぎます", "The request body is too large."},
+	"METHOD_NOT_ALLOWED": {"許可されていないメソッドです", "This HTTP method is not allowed."},
+	"NOT_FOUND":          {"リソースが見つかりません", "The resource was not found."},
+	"RATE_LIMITED":       {"リクエストが多すぎます。しばらくしてから再試行してください", "Too many requests. Please retry later."},
+	"INTERNAL_ERROR":     {"サーバー内部でエラーが発生しました", "An internal server error occurred."},
+
+	// 認証系
+	"UNAUTHORIZED":        {"認証が必要です。ログインし直してください", "Authentication is required. Please sign in again."},
+	"FORBIDDEN":           {"この操作を行う権限がありません", "You are not allowed to perform this operation."},
+	"USER_NOT_FOUND":      {"ユーザーが見つかりません", "The user was not found."},
+	"SERVER_CONFIG_ERROR": {"サーバーの設定に問題があります", "The server is misconfigured."},
+
+	// デッキ系
+	"DECK_NOT_FOUND":        {"デッキが見つかりません", "The deck was not found."},
+	"DECK_NOT_SELECTED":     {"デッキを選択してください", "Please select a deck."},
+	"INVALID_DECK":          {"デッキの内容が不正です", "The deck contents are invalid."},
+	"DECK_VERSION_CONFLICT": {"他の端末でデッキが更新されています。再読み込みしてください", "The deck was updated on another device. Please reload it."},
+	"DECK_LOAD_FAILED":      {"デッキを読み込めなかったため、ゲームを準備できません", "The game could not be prepared because the deck failed to load."},
+
+	// ゲーム系
+	"SESSION_NOT_FOUND":    {"ゲームセッションが見つかりません", "The game session was not found."},
+	"SESSION_CLOSING":      {"セッションの終了処理中です。しばらくしてから再試行してください", "The session is closing. Please retry shortly."},
+	"ROOM_NOT_CANCELLABLE": {"対戦相手が参加済みのため、ルームを解散できません", "The room cannot be cancelled because an opponent has already joined."},
+	"INVALID_PASSCODE":     {"合言葉の形式が不正です", "The passcode format is invalid."},
+	"INVALID_RULES":        {"ルールの設定が不正です", "The game rules are invalid."},
+	"ROOM_IN_PROGRESS":     {"ルームは既にゲーム中または終了済みです", "The room is already in a game or has finished."},
+	"ROOM_FULL":            {"ルームが満室です", "The room is full."},
+	"OWN_ROOM":             {"自分が作成したルームには参加できません", "You cannot join a room you created."},
+	"ALREADY_PLAYING":      {"既に他のルームでプレイ中です", "You are already playing in another room."},
+	"USER_SESSION_LIMIT":   {"同時に参加できるルーム数の上限に達しています", "You have reached the maximum number of rooms you can be in at once."},
+	"MATCHING_FAILED":      {"マッチングに失敗しました", "Matching failed."},
+	"SERVER_BUSY":          {"サーバーが混み合っています。しばらくしてから再試行してください", "The server is busy. Please retry later."},
+	"PASSCODE_UNAVAILABLE": {"空いている合言葉を用意できませんでした。しばらくしてから再試行してください", "No passcode is available right now. Please retry later."},
+
+	// Contribution系
+	"GITHUB_API_ERROR": {"GitHub APIの呼び出しに失敗しました", "The GitHub API request failed."},
+	"REFRESH_TIMEOUT":  {"貢献データの再取得が完了しませんでした。しばらくしてから再試行してください", "The contribution refresh did not finish in time. Please retry shortly."},
+}
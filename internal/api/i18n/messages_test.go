@@ -0,0 +1,40 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseAcceptLanguage はq値の高い対応言語を選び、対応言語が無い場合は日本語にフォールバックすることをテストします。
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Language
+	}{
+		{"", Japanese},
+		{"en", English},
+		{"en-US,en;q=0.9", English},
+		{"ja-JP", Japanese},
+		{"fr-FR,en;q=0.8,ja;q=0.9", Japanese},
+		{"fr,en;q=0.5", English},
+		{"de,fr", Japanese},
+		{"EN-gb", English},
+		{"en;q=0,ja;q=0", Japanese},
+		{"en;q=abc", Japanese},
+		{"*", Japanese},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ParseAcceptLanguage(tt.header), "Accept-Language: %q", tt.header)
+	}
+}
+
+// TestLocalize は呼び出し側のメッセージと同じ言語ならそのまま使い、異なる言語ならカタログの文言に置き換えることをテストします。
+func TestLocalize(t *testing.T) {
+	assert.Equal(t, "デッキIDが必要です", Localize(Japanese, "BAD_REQUEST", "デッキIDが必要です", Japanese))
+	assert.Equal(t, "The request is malformed or has invalid parameters.", Localize(English, "BAD_REQUEST", "デッキIDが必要です", Japanese))
+	assert.Equal(t, "認証が必要です。ログインし直してください", Localize(Japanese, "UNAUTHORIZED", "Invalid token", English))
+	assert.Equal(t, "デッキが見つかりません", Localize(Japanese, "DECK_NOT_FOUND", "", Japanese), "メッセージが空ならカタログの文言のはず")
+	assert.Equal(t, "想定外", Localize(English, "UNKNOWN_CODE", "想定外", Japanese), "カタログに無いコードは元のメッセージのはず")
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// AdminHandler は環境変数 ADMIN_TOKEN の管理者トークンで保護するミドルウェアを返します。
+// リクエストは Authorization: Bearer <ADMIN_TOKEN> で認証し、トークンの比較は定数時間で行います。
+// ADMIN_TOKEN が未設定の場合は管理APIを無効とし、エンドポイントの存在を明かさないよう 404 を返します。
+func AdminHandler() func(http.Handler) http.Handler {
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if adminToken == "" {
+				writeJSONError(w, http.StatusNotFound, "NOT_FOUND", "Not found")
+				return
+			}
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				writeJSONError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Admin token is required")
+				return
+			}
+			if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+				writeJSONError(w, http.StatusForbidden, "FORBIDDEN", "Invalid admin token")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
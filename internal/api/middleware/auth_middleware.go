@@ -10,6 +10,7 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/api/i18n"
 )
 
 type UserIDKey struct{}
@@ -20,11 +21,16 @@ func GetUserIDFromContext(ctx context.Context) (string, bool) {
 	return userID, ok
 }
 
-// writeJSONError writes a JSON error response
-func writeJSONError(w http.ResponseWriter, statusCode int, message string) {
+// writeJSONError writes a JSON error response.
+// The shape matches handlers.RespondError: {"error":{"code":"...","message":"..."}}
+// The message is written in English; clients that prefer Japanese (see LanguageHandler) get the catalog message for the code.
+func writeJSONError(w http.ResponseWriter, statusCode int, code string, message string) {
+	message = i18n.Localize(i18n.ResponseLanguage(w), code, message, i18n.English)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
+	json.NewEncoder(w).Encode(map[string]map[string]string{
+		"error": {"code": code, "message": message},
+	})
 }
 
 // AuthMiddleware is a middleware function that checks for a valid JWT token.
@@ -45,7 +51,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		authHeader := r.Header.Get("Authorization")
 		log.Printf("AuthMiddleware Debug: Authorization header: %s", authHeader)
 		if authHeader == "" {
-			writeJSONError(w, http.StatusUnauthorized, "Authorization header is required")
+			writeJSONError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authorization header is required")
 			return
 		}
 
@@ -54,7 +60,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			tokenString = authHeader[7:]
 			log.Printf("AuthMiddleware Debug: Extracted token: %s", tokenString)
 		} else {
-			writeJSONError(w, http.StatusUnauthorized, "Invalid Authorization header format. Must be 'Bearer <token>'")
+			writeJSONError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid Authorization header format. Must be 'Bearer <token>'")
 			return
 		}
 
@@ -63,7 +69,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		log.Printf("AuthMiddleware Debug: JWT Secret length: %d", len(jwtSecret))
 		if jwtSecret == "" {
 			log.Println("Error: SUPABASE_JWT_SECRET environment variable is not set.")
-			writeJSONError(w, http.StatusInternalServerError, "Server configuration error: JWT secret missing")
+			writeJSONError(w, http.StatusInternalServerError, "SERVER_CONFIG_ERROR", "Server configuration error: JWT secret missing")
 			return
 		}
 
@@ -79,13 +85,13 @@ func AuthMiddleware(next http.Handler) http.Handler {
 
 		if err != nil {
 			log.Printf("AuthMiddleware Error: JWT parse error: %v", err)
-			writeJSONError(w, http.StatusUnauthorized, "Invalid token")
+			writeJSONError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
 			return
 		}
 
 		if !token.Valid {
 			log.Printf("AuthMiddleware Error: Invalid token")
-			writeJSONError(w, http.StatusUnauthorized, "Invalid token")
+			writeJSONError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
 			return
 		}
 
@@ -93,7 +99,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
 			log.Printf("AuthMiddleware Error: Invalid token claims")
-			writeJSONError(w, http.StatusUnauthorized, "Invalid token claims")
+			writeJSONError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token claims")
 			return
 		}
 
@@ -101,7 +107,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		userID, ok := claims["sub"].(string)
 		if !ok {
 			log.Printf("AuthMiddleware Error: JWT claims missing 'sub' (userID) or wrong type: %v", claims["sub"])
-			writeJSONError(w, http.StatusUnauthorized, "Invalid token: missing user ID")
+			writeJSONError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token: missing user ID")
 			return
 		}
 
@@ -110,4 +116,19 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		ctx := context.WithValue(r.Context(), UserIDKey{}, userID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
-}
\ No newline at end of file
+}
+
+// OptionalAuthMiddleware は認証を任意とするミドルウェアです。
+// Authorization ヘッダーがある場合は AuthMiddleware と同じくJWTを検証してユーザーIDをContextに設定し（不正なトークンは401）、
+// ヘッダーが無いリクエストは未認証のまま次のハンドラに渡します。
+// 公開データだが本人にだけ追加の情報を返すエンドポイントで使います。
+func OptionalAuthMiddleware(next http.Handler) http.Handler {
+	authenticated := AuthMiddleware(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		authenticated.ServeHTTP(w, r)
+	})
+}
@@ -2,17 +2,62 @@ package middleware
 
 import (
 	"net/http"
+	"net/url"
+	"os"
+	"strings"
 
 	"github.com/rs/cors"
 )
 
+// defaultAllowedOrigins は CORS_ALLOWED_ORIGINS が未設定の場合に許可するフロントエンドのオリジンです。
+var defaultAllowedOrigins = []string{"http://localhost:3000", "https://gitris-frontend-deploy.vercel.app"}
+
+// AllowedOrigins は許可するオリジンの一覧を返します。
+// 環境変数 CORS_ALLOWED_ORIGINS（カンマ区切り）が設定されていればそれを使い、なければデフォルトを使います。
+func AllowedOrigins() []string {
+	env := os.Getenv("CORS_ALLOWED_ORIGINS")
+	if env == "" {
+		return defaultAllowedOrigins
+	}
+	var origins []string
+	for _, origin := range strings.Split(env, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// IsOriginAllowed は指定されたオリジンからのリクエストを許可するかどうかを判定します。
+// CORSとWebSocketのオリジンチェックで共有し、許可リストの二重管理を避けます。
+// 本番環境（APP_ENV=production）では許可リストに一致するオリジンのみ、
+// それ以外の環境では加えて localhost / 127.0.0.1 の任意ポートを許可します。
+func IsOriginAllowed(origin string) bool {
+	for _, allowed := range AllowedOrigins() {
+		if origin == allowed {
+			return true
+		}
+	}
+	if os.Getenv("APP_ENV") == "production" {
+		return false
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	return host == "localhost" || host == "127.0.0.1"
+}
+
 // CORSHandler はCORS設定を適用するミドルウェアを返します。
 func CORSHandler() func(http.Handler) http.Handler {
 	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:3000", "https://gitris-frontend-deploy.vercel.app"}, // フロントエンドのオリジン
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
-		AllowCredentials: true,
+		AllowOriginFunc:      IsOriginAllowed, // フロントエンドのオリジン（WebSocketのオリジンチェックと共通）
+		AllowedMethods:       []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:       []string{"Content-Type", "Authorization", "If-None-Match"},
+		ExposedHeaders:       []string{"X-Skipped-Contributions", "X-Total-Contributions", "Retry-After", "ETag"}, // フロントエンドから読めるようにするレスポンスヘッダー
+		AllowCredentials:     true,
+		OptionsSuccessStatus: http.StatusOK, // プリフライトは200で返す
 	})
 	return c.Handler
-}
\ No newline at end of file
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// GzipMinSize はgzip圧縮を行うレスポンスボディの最小サイズ（バイト）です。
+// 小さなレスポンスは圧縮してもほとんど縮まず、CPUコストの方が大きくなるため圧縮しません。
+const GzipMinSize = 1024
+
+// GzipHandler はクライアントが Accept-Encoding: gzip を送ってきた場合に、
+// GzipMinSize 以上のレスポンスをgzip圧縮するミドルウェアを返します。
+// WebSocketのアップグレードリクエストには適用しません。
+// 環境変数 GZIP_ENABLED=false で圧縮を無効にできます（デフォルトは有効）。
+func GzipHandler() func(http.Handler) http.Handler {
+	enabled := os.Getenv("GZIP_ENABLED") != "false"
+	return func(next http.Handler) http.Handler {
+		if !enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isWebSocketUpgrade(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// 圧縮するかどうかに関わらず Accept-Encoding によってレスポンスが変わり得るため、
+			// キャッシュが非圧縮のレスポンスを gzip 対応のクライアントに（またはその逆に）返さないよう常に付ける
+			addVary(w.Header(), "Accept-Encoding")
+			if !acceptsGzip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			gw := &gzipResponseWriter{ResponseWriter: w, status: http.StatusOK}
+			defer gw.finish()
+			next.ServeHTTP(gw, r)
+		})
+	}
+}
+
+// isWebSocketUpgrade はリクエストがWebSocketへのアップグレード要求かどうかを判定します。
+// アップグレード時はレスポンスライターをHijackするため、ラップしてはいけません。
+func isWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// addVary はレスポンスの Vary ヘッダーに field を追加します。既に含まれている場合は追加しません。
+func addVary(h http.Header, field string) {
+	for _, value := range h.Values("Vary") {
+		for _, existing := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(existing), field) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", field)
+}
+
+// acceptsGzip はクライアントがgzipエンコーディングを受け付けるかどうかを判定します。
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		if strings.TrimSpace(strings.SplitN(enc, ";", 2)[0]) == "gzip" {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter はレスポンスボディを GzipMinSize までバッファし、
+// 超えた時点でgzip圧縮に切り替える http.ResponseWriter です。
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	status      int          // ハンドラが指定したステータスコード（送信は圧縮要否の判定後）
+	buf         bytes.Buffer // 圧縮要否が決まるまでのボディのバッファ
+	gz          *gzip.Writer // 圧縮開始後のライター
+	passthrough bool         // 既にContent-Encodingが設定されている場合などにそのまま書き出すか
+	wroteHeader bool         // 元のライターにヘッダーを送信済みか
+}
+
+// WriteHeader はステータスコードを記録します。実際の送信は圧縮要否が決まるまで遅らせます。
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+// Write はボディをバッファし、GzipMinSize に達したらgzip圧縮を開始します。
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if w.gz != nil {
+		return w.gz.Write(p)
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(p)
+	}
+	if w.Header().Get("Content-Encoding") != "" {
+		// ハンドラ側で既にエンコード済みのレスポンスは二重に圧縮しない
+		w.passthrough = true
+		w.flushHeader()
+		if _, err := w.ResponseWriter.Write(w.buf.Bytes()); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf.Write(p)
+	if w.buf.Len() < GzipMinSize {
+		return len(p), nil
+	}
+
+	// サイズが閾値を超えたのでgzip圧縮に切り替える
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Del("Content-Length")
+	w.flushHeader()
+	w.gz = gzip.NewWriter(w.ResponseWriter)
+	if _, err := w.gz.Write(w.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	w.buf.Reset()
+	return len(p), nil
+}
+
+// flushHeader は記録しておいたステータスコードで元のライターにヘッダーを送信します。
+func (w *gzipResponseWriter) flushHeader() {
+	if !w.wroteHeader {
+		w.ResponseWriter.WriteHeader(w.status)
+		w.wroteHeader = true
+	}
+}
+
+// finish はgzipストリームを閉じるか、閾値未満だったボディを非圧縮のまま書き出します。
+func (w *gzipResponseWriter) finish() {
+	if w.gz != nil {
+		w.gz.Close()
+		return
+	}
+	if w.passthrough {
+		return
+	}
+	w.flushHeader()
+	if w.buf.Len() > 0 {
+		w.ResponseWriter.Write(w.buf.Bytes())
+	}
+}
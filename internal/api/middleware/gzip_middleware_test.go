@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGzipHandler は GzipMinSize 以上のレスポンスだけを圧縮し、圧縮の有無に関わらず
+// Vary: Accept-Encoding を1つだけ付けることをテストします。
+func TestGzipHandler(t *testing.T) {
+	large := strings.Repeat("a", GzipMinSize+1)
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		body           string
+		wantGzip       bool
+	}{
+		{name: "gzip対応で閾値以上なら圧縮する", acceptEncoding: "gzip, deflate", body: large, wantGzip: true},
+		{name: "gzip対応でも閾値未満なら圧縮しない", acceptEncoding: "gzip", body: "small"},
+		{name: "gzip非対応なら圧縮しない", acceptEncoding: "", body: large},
+		{name: "q値付きのgzipも対応とみなす", acceptEncoding: "br;q=1.0, gzip;q=0.8", body: large, wantGzip: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := GzipHandler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("Vary", "Origin")
+				w.WriteHeader(http.StatusCreated)
+				io.WriteString(w, tt.body)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/api/results", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.ElementsMatch(t, []string{"Accept-Encoding", "Origin"}, rec.Header().Values("Vary"))
+			body := rec.Body.String()
+			if tt.wantGzip {
+				assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+				gz, err := gzip.NewReader(rec.Body)
+				assert.NoError(t, err)
+				decoded, err := io.ReadAll(gz)
+				assert.NoError(t, err)
+				body = string(decoded)
+			} else {
+				assert.Empty(t, rec.Header().Get("Content-Encoding"))
+			}
+			assert.Equal(t, tt.body, body)
+		})
+	}
+}
+
+// TestGzipHandler_WebSocketUpgrade はWebSocketのアップグレード要求をラップせず、Vary も付けないことをテストします。
+func TestGzipHandler_WebSocketUpgrade(t *testing.T) {
+	var wrapped bool
+	handler := GzipHandler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, wrapped = w.(*gzipResponseWriter)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/ws/game", nil)
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	assert.False(t, wrapped)
+	assert.Empty(t, rec.Header().Values("Vary"))
+}
+
+// TestAddVary は Vary に同じフィールドを重複して追加しないことをテストします。
+func TestAddVary(t *testing.T) {
+	h := http.Header{}
+	h.Add("Vary", "Origin, accept-encoding")
+
+	addVary(h, "Accept-Encoding")
+	addVary(h, "Accept-Language")
+	addVary(h, "Accept-Language")
+
+	assert.Equal(t, []string{"Origin, accept-encoding", "Accept-Language"}, h.Values("Vary"))
+}
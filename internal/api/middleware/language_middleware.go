@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/api/i18n"
+)
+
+// LanguageHandler は Accept-Language からエラーメッセージの言語（日本語・英語）を決め、
+// Content-Language ヘッダーとしてレスポンスに記録するミドルウェアを返します。
+// エラーレスポンスを書く側（handlers.RespondError や認証ミドルウェア）はこのヘッダーを見て文言を選びます。
+// 対応していない言語・ヘッダーなしの場合は日本語です。
+// レスポンスが Accept-Language によって変わるため、キャッシュが言語を取り違えないよう Vary: Accept-Language を付けます。
+func LanguageHandler() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(i18n.LanguageHeader, string(i18n.ParseAcceptLanguage(r.Header.Get("Accept-Language"))))
+			addVary(w.Header(), "Accept-Language")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/api/i18n"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLanguageHandler は Accept-Language から決めた言語を Content-Language に記録し、
+// gzip と組み合わせても Vary に Accept-Language と Accept-Encoding を1つずつ付けることをテストします。
+func TestLanguageHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		want           i18n.Language
+	}{
+		{name: "英語を優先するクライアント", acceptLanguage: "en-US,en;q=0.9,ja;q=0.8", want: i18n.English},
+		{name: "日本語を優先するクライアント", acceptLanguage: "ja,en;q=0.5", want: i18n.Japanese},
+		{name: "ヘッダーなしは日本語", want: i18n.Japanese},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := LanguageHandler()(GzipHandler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			})))
+			req := httptest.NewRequest(http.MethodGet, "/api/results", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			req.Header.Set("Accept-Encoding", "gzip")
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, string(tt.want), rec.Header().Get(i18n.LanguageHeader))
+			assert.ElementsMatch(t, []string{"Accept-Language", "Accept-Encoding"}, rec.Header().Values("Vary"))
+		})
+	}
+}
@@ -1,11 +1,15 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"os"
 	"time"
 
+	"github.com/google/uuid"
 	_ "github.com/lib/pq" // PostgreSQLドライバー
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
 )
@@ -16,6 +20,41 @@ import (
 // 	ContributionCount int
 // }
 
+// ErrUserNotFound is returned when no user exists for the given user ID.
+var ErrUserNotFound = errors.New("ユーザーが見つかりません")
+
+// ErrDeckNotFound is returned when no deck exists for the given deck ID.
+var ErrDeckNotFound = errors.New("デッキが見つかりません")
+
+// ErrInvalidDeckID is returned when the deck ID is not a valid UUID.
+var ErrInvalidDeckID = errors.New("デッキIDの形式が不正です")
+
+// ErrDeckNotOwned is returned when the deck belongs to a different user than the player who wants to use it.
+var ErrDeckNotOwned = errors.New("指定されたデッキはこのユーザーのものではありません")
+
+// ErrDeckVersionConflict is returned when the deck was updated by another client since it was loaded.
+var ErrDeckVersionConflict = errors.New("他の端末でデッキが更新されています")
+
+// ErrInvalidDeckPeriod is returned when a deck's contribution period is half-set, malformed, or does not end after it starts.
+var ErrInvalidDeckPeriod = errors.New("デッキのContribution期間が不正です")
+
+// isProduction reports whether the server is running with APP_ENV=production.
+// テスト用ダミーデッキなどの開発向けフォールバックは本番では無効にします。
+func isProduction() bool {
+	return os.Getenv("APP_ENV") == "production"
+}
+
+// newTestDeck は開発環境で存在しないデッキIDが指定された場合に使うテスト用デッキを生成します。
+func newTestDeck(deckID string) *models.Deck {
+	return &models.Deck{
+		ID:         deckID,
+		UserID:     "test-user",
+		TotalScore: 1000,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+}
+
 // DatabaseService provides methods for interacting with the database.
 type DatabaseService struct {
 	DB *sql.DB
@@ -31,7 +70,10 @@ func NewDatabaseService(databaseURL string) (*DatabaseService, error) {
 	}
 
 	// データベース接続の確認 (Ping)
-	err = db.Ping()
+	// 接続先に到達できない場合に起動が止まり続けないよう、タイムアウトを設定する
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err = db.PingContext(ctx)
 	if err != nil {
 		log.Printf("DatabaseService Error: db.Pingに失敗しました: %v", err)
 		log.Printf("DatabaseService Error: データベース接続エラーの詳細: %s", err.Error())
@@ -43,14 +85,14 @@ func NewDatabaseService(databaseURL string) (*DatabaseService, error) {
 }
 
 // GetGitHubUsernameByUserID fetches the GitHub username for a given user ID (UUID).
-func (s *DatabaseService) GetGitHubUsernameByUserID(userID string) (string, error) {
+func (s *DatabaseService) GetGitHubUsernameByUserID(ctx context.Context, userID string) (string, error) {
 	var githubUsername string
 	// users テーブルから userID に紐づく user_name を取得するクエリ
 	query := `SELECT user_name FROM users WHERE id = $1`
-	err := s.DB.QueryRow(query, userID).Scan(&githubUsername)
+	err := s.DB.QueryRowContext(ctx, query, userID).Scan(&githubUsername)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return "", fmt.Errorf("ユーザーID %s に紐づくGitHubユーザー名が見つかりません。", userID)
+			return "", fmt.Errorf("%w: ユーザーID %s に紐づくGitHubユーザー名が見つかりません。", ErrUserNotFound, userID)
 		}
 		return "", fmt.Errorf("GitHubユーザー名の取得に失敗しました: %w", err)
 	}
@@ -59,13 +101,13 @@ func (s *DatabaseService) GetGitHubUsernameByUserID(userID string) (string, erro
 }
 
 // GetContributionsByUserID retrieves all contributions for a specific user from the database.
-func (s *DatabaseService) GetContributionsByUserID(userID string) ([]models.DailyContribution, error) {
+func (s *DatabaseService) GetContributionsByUserID(ctx context.Context, userID string) ([]models.DailyContribution, error) {
 	log.Printf("DatabaseService Info: ユーザーID %s の保存済み貢献データを取得中...", userID)
 	var contributions []models.DailyContribution
 	query := `SELECT date, contribution_count FROM contribution_data WHERE user_id = $1 ORDER BY date ASC`
 
 	log.Printf("DatabaseService Debug: クエリを実行します: %s", query)
-	rows, err := s.DB.Query(query, userID)
+	rows, err := s.DB.QueryContext(ctx, query, userID)
 	if err != nil {
 		log.Printf("DatabaseService Error: クエリ実行エラー: %v", err)
 		return nil, fmt.Errorf("保存済み貢献データの取得に失敗しました: %w", err)
@@ -81,7 +123,7 @@ func (s *DatabaseService) GetContributionsByUserID(userID string) ([]models.Dail
 			return nil, fmt.Errorf("保存済み貢献データのスキャンに失敗しました: %w", err)
 		}
 		contributions = append(contributions, models.DailyContribution{
-			Date:  date.Format("2006-01-02"),
+			Date:  date.Format(models.ContributionDateLayout),
 			Count: count,
 		})
 	}
@@ -95,47 +137,168 @@ func (s *DatabaseService) GetContributionsByUserID(userID string) ([]models.Dail
 	return contributions, nil
 }
 
-// SaveContributions saves a slice of daily contributions for a given user.
-// It first deletes existing contributions for the user and then inserts the new ones.
-func (s *DatabaseService) SaveContributions(userID string, contributions []models.DailyContribution) error {
-	tx, err := s.DB.Begin()
+// GetContributionVisibility は対戦相手に草（日別レベル）を公開するかどうかの設定を取得します。
+// 未設定のユーザーは公開（users.contributions_public のデフォルト値）です。
+func (s *DatabaseService) GetContributionVisibility(ctx context.Context, userID string) (bool, error) {
+	var public bool
+	err := s.DB.QueryRowContext(ctx, `SELECT contributions_public FROM users WHERE id = $1`, userID).Scan(&public)
 	if err != nil {
-		return fmt.Errorf("トランザクションの開始に失敗しました: %w", err)
+		if err == sql.ErrNoRows {
+			return false, fmt.Errorf("%w: ユーザーID %s", ErrUserNotFound, userID)
+		}
+		return false, fmt.Errorf("草の公開設定の取得に失敗しました: %w", err)
 	}
-	defer tx.Rollback()
+	return public, nil
+}
 
-	// 既存のデータを削除
-	_, err = tx.Exec("DELETE FROM contribution_data WHERE user_id = $1", userID)
+// SetContributionVisibility は対戦相手に草（日別レベル）を公開するかどうかを設定します。
+// 設定は次に参加する対戦から反映されます。
+func (s *DatabaseService) SetContributionVisibility(ctx context.Context, userID string, public bool) error {
+	result, err := s.DB.ExecContext(ctx, `UPDATE users SET contributions_public = $1 WHERE id = $2`, public, userID)
 	if err != nil {
-		return fmt.Errorf("既存の貢献データの削除に失敗しました: %w", err)
+		return fmt.Errorf("草の公開設定の更新に失敗しました: %w", err)
 	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: ユーザーID %s", ErrUserNotFound, userID)
+	}
+	return nil
+}
 
-	// 新しいデータを挿入
-	stmt, err := tx.Prepare(`
-		INSERT INTO contribution_data (user_id, date, contribution_count)
-		VALUES ($1, $2, $3)
-	`)
+// GetSavedContributions は保存済みの貢献データと、それを最後にGitHubから取得した日時を取得します。
+// 取得日時は保存時に記録した fetched_at の最新値で、データが無い場合は nil です。
+func (s *DatabaseService) GetSavedContributions(ctx context.Context, userID string) ([]models.DailyContribution, *time.Time, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT date, contribution_count, fetched_at FROM contribution_data WHERE user_id = $1 ORDER BY date ASC`, userID)
 	if err != nil {
-		return fmt.Errorf("INSERT文の準備に失敗しました: %w", err)
+		return nil, nil, fmt.Errorf("保存済み貢献データの取得に失敗しました: %w", err)
 	}
-	defer stmt.Close()
+	defer rows.Close()
+
+	var contributions []models.DailyContribution
+	var latest *time.Time
+	for rows.Next() {
+		var date, fetchedAt time.Time
+		var count int
+		if err := rows.Scan(&date, &count, &fetchedAt); err != nil {
+			return nil, nil, fmt.Errorf("保存済み貢献データのスキャンに失敗しました: %w", err)
+		}
+		contributions = append(contributions, models.DailyContribution{
+			Date:  date.Format(models.ContributionDateLayout),
+			Count: count,
+		})
+		if latest == nil || fetchedAt.After(*latest) {
+			latest = &fetchedAt
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("保存済み貢献データのイテレーション中にエラーが発生しました: %w", err)
+	}
+	return contributions, latest, nil
+}
 
+// validateContributions は保存前の貢献データを検証し、有効なエントリだけを返します。
+// 負のcount、パースできない日付、未来（JSTで今日より後）の日付のエントリはスキップしてログに残します。
+//
+// Parameters:
+//   contributions : 検証する貢献データ
+//   now           : 未来日判定の基準時刻
+// Returns:
+//   []models.DailyContribution: 有効なエントリ
+//   int: スキップしたエントリ数
+func validateContributions(contributions []models.DailyContribution, now time.Time) ([]models.DailyContribution, int) {
+	today := now.In(models.JST).Format(models.ContributionDateLayout)
+	valid := make([]models.DailyContribution, 0, len(contributions))
+	skipped := 0
 	for _, c := range contributions {
-		date, err := time.Parse("2006-01-02", c.Date)
+		if c.Count < 0 {
+			log.Printf("DatabaseService Warning: 負の貢献数のためスキップします: date=%s count=%d", c.Date, c.Count)
+			skipped++
+			continue
+		}
+		if _, err := time.ParseInLocation(models.ContributionDateLayout, c.Date, models.JST); err != nil {
+			log.Printf("DatabaseService Warning: 日付の形式が不正なためスキップします: date=%q", c.Date)
+			skipped++
+			continue
+		}
+		// "YYYY-MM-DD" 形式同士なので文字列比較で日付の前後を判定できる
+		if c.Date > today {
+			log.Printf("DatabaseService Warning: 未来の日付のためスキップします: date=%s (today=%s)", c.Date, today)
+			skipped++
+			continue
+		}
+		valid = append(valid, c)
+	}
+	return valid, skipped
+}
+
+// SaveContributions saves a slice of daily contributions for a given user.
+// 日付単位のupsertで保存し、保存した期間の外にある古いデータを削除します。
+// 全削除してから挿入する方式と違い、途中でデータが消える窓が無く、同じ入力なら何度実行しても同じ結果になります（冪等）。
+// 不正なエントリは validateContributions でスキップされ、保存したエントリとスキップ件数を返します。
+func (s *DatabaseService) SaveContributions(ctx context.Context, userID string, contributions []models.DailyContribution) ([]models.DailyContribution, int, error) {
+	contributions, skipped := validateContributions(contributions, time.Now())
+	if skipped > 0 {
+		log.Printf("DatabaseService Warning: ユーザーID %s の貢献データのうち %d 件を不正な値としてスキップしました", userID, skipped)
+	}
+
+	// 日付はJSTの暦日として解釈する（GitHubクエリ・レスポンスの日付境界と揃える）
+	dates := make([]time.Time, len(contributions))
+	for i, c := range contributions {
+		date, err := time.ParseInLocation(models.ContributionDateLayout, c.Date, models.JST)
 		if err != nil {
-			return fmt.Errorf("日付のパースに失敗しました: %w", err)
+			return nil, skipped, fmt.Errorf("日付のパースに失敗しました: %w", err)
+		}
+		dates[i] = date
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, skipped, fmt.Errorf("トランザクションの開始に失敗しました: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 新しいデータをupsert（(user_id, date) の一意制約が必要。README のスキーマ変更を参照）
+	// fetched_at には今回GitHubから取得した日時を記録し、保存済みデータの鮮度の判定に使う
+	fetchedAt := time.Now()
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO contribution_data (user_id, date, contribution_count, fetched_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, date) DO UPDATE SET contribution_count = EXCLUDED.contribution_count, fetched_at = EXCLUDED.fetched_at
+	`)
+	if err != nil {
+		return nil, skipped, fmt.Errorf("INSERT文の準備に失敗しました: %w", err)
+	}
+	defer stmt.Close()
+
+	var first, last time.Time
+	for i, c := range contributions {
+		date := dates[i]
+		if first.IsZero() || date.Before(first) {
+			first = date
 		}
-		_, err = stmt.Exec(userID, date, c.Count)
+		if last.IsZero() || date.After(last) {
+			last = date
+		}
+		_, err = stmt.ExecContext(ctx, userID, date, c.Count, fetchedAt)
 		if err != nil {
-			return fmt.Errorf("貢献データの挿入に失敗しました: %w", err)
+			return nil, skipped, fmt.Errorf("貢献データの挿入に失敗しました: %w", err)
 		}
 	}
 
+	// 保存した期間より古い（または新しい）データを削除し、保存結果を今回の期間だけにそろえる
+	if len(contributions) > 0 {
+		_, err = tx.ExecContext(ctx, "DELETE FROM contribution_data WHERE user_id = $1 AND (date < $2 OR date > $3)", userID, first, last)
+	} else {
+		_, err = tx.ExecContext(ctx, "DELETE FROM contribution_data WHERE user_id = $1", userID)
+	}
+	if err != nil {
+		return nil, skipped, fmt.Errorf("期間外の貢献データの削除に失敗しました: %w", err)
+	}
+
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("トランザクションのコミットに失敗しました: %w", err)
+		return nil, skipped, fmt.Errorf("トランザクションのコミットに失敗しました: %w", err)
 	}
 
-	return nil
+	return contributions, skipped, nil
 }
 
 // min helper function for logging
@@ -147,65 +310,94 @@ func min(a, b int) int {
 }
 
 // GetDeckByID は指定されたIDのデッキをデータベースから取得します。
+// 本番環境（APP_ENV=production）ではUUID形式チェックと実在確認を厳格に行い、
+// それ以外の環境では不正・存在しないIDに対してテスト用デッキを返します。
+//
+// Parameters:
+//   ctx    : キャンセル・タイムアウトでクエリを打ち切るためのコンテキスト
+//   deckID : 取得するデッキのUUID
+// Returns:
+//   *models.Deck: 取得したデッキのポインタ
+//   error : UUID形式でない場合は ErrInvalidDeckID、存在しない場合は ErrDeckNotFound（本番のみ）
+func (s *DatabaseService) GetDeckByID(ctx context.Context, deckID string) (*models.Deck, error) {
+	deck, _, err := s.getDeck(ctx, deckID)
+	return deck, err
+}
+
+// GetDeckByIDAndUser はゲームで使うデッキを取得し、そのデッキが userID のものかを検証します。
+// 他人のデッキIDを指定して強いデッキを借用できないよう、所有者が一致しない場合は ErrDeckNotOwned を返します。
+// 開発環境のテスト用デッキ（test-deck-id など）は誰でも使えるよう、userID の所有として返します。
 //
 // Parameters:
+//   ctx    : キャンセル・タイムアウトでクエリを打ち切るためのコンテキスト
 //   deckID : 取得するデッキのUUID
+//   userID : デッキを使うプレイヤーのユーザーID
 // Returns:
 //   *models.Deck: 取得したデッキのポインタ
-//   error : エラーが発生した場合
-func (s *DatabaseService) GetDeckByID(deckID string) (*models.Deck, error) {
+//   error : GetDeckByID のエラーに加え、所有者が異なる場合は ErrDeckNotOwned
+func (s *DatabaseService) GetDeckByIDAndUser(ctx context.Context, deckID, userID string) (*models.Deck, error) {
+	deck, isTestDeck, err := s.getDeck(ctx, deckID)
+	if err != nil {
+		return nil, err
+	}
+	return checkDeckOwner(deck, userID, isTestDeck)
+}
+
+// checkDeckOwner はデッキの所有者が userID であることを確認します。
+// テスト用デッキ（開発環境でのみ生成される）は所有者を userID に置き換えて返します。
+func checkDeckOwner(deck *models.Deck, userID string, isTestDeck bool) (*models.Deck, error) {
+	if isTestDeck {
+		deck.UserID = userID
+		return deck, nil
+	}
+	if deck.UserID != userID {
+		return nil, fmt.Errorf("デッキID %s, ユーザー %s: %w", deck.ID, userID, ErrDeckNotOwned)
+	}
+	return deck, nil
+}
+
+// getDeck はデッキを取得し、開発環境向けのテスト用デッキを生成して返したかどうかも返します。
+func (s *DatabaseService) getDeck(ctx context.Context, deckID string) (*models.Deck, bool, error) {
 	log.Printf("DatabaseService Info: デッキID %s のデッキデータを取得中...", deckID)
 	
-	// UUID形式でない場合はテスト用デッキを返す
-	if deckID == "test-deck-id" || len(deckID) != 36 {
+	if _, err := uuid.Parse(deckID); err != nil || deckID == "test-deck-id" {
+		if isProduction() {
+			return nil, false, fmt.Errorf("デッキID %s: %w", deckID, ErrInvalidDeckID)
+		}
+		// 開発環境: UUID形式でない場合はテスト用デッキを返す
 		log.Printf("DatabaseService Info: テスト用デッキID %s のため、テスト用デッキを生成します", deckID)
-		return &models.Deck{
-			ID:         deckID,
-			UserID:     "test-user",
-			TotalScore: 1000,
-			CreatedAt:  time.Now(),
-			UpdatedAt:  time.Now(),
-		}, nil
+		return newTestDeck(deckID), true, nil
 	}
 	
 	var deck models.Deck
-	query := `SELECT id, user_id, total_score, created_at, updated_at FROM decks WHERE id = $1`
+	query := `SELECT ` + deckColumns + ` FROM decks WHERE id = $1`
 	
-	err := s.DB.QueryRow(query, deckID).Scan(
-		&deck.ID,
-		&deck.UserID,
-		&deck.TotalScore,
-		&deck.CreatedAt,
-		&deck.UpdatedAt,
-	)
+	err := scanDeck(s.DB.QueryRowContext(ctx, query, deckID), &deck)
 	
 	if err != nil {
 		if err == sql.ErrNoRows {
-			// テスト用: デッキが存在しない場合は仮のデッキを返す
+			if isProduction() {
+				return nil, false, fmt.Errorf("デッキID %s: %w", deckID, ErrDeckNotFound)
+			}
+			// 開発環境: デッキが存在しない場合は仮のデッキを返す
 			log.Printf("DatabaseService Info: デッキID %s が見つからないため、テスト用デッキを生成します", deckID)
-			return &models.Deck{
-				ID:         deckID,
-				UserID:     "test-user",
-				TotalScore: 1000,
-				CreatedAt:  time.Now(),
-				UpdatedAt:  time.Now(),
-			}, nil
+			return newTestDeck(deckID), true, nil
 		}
 		log.Printf("DatabaseService Error: デッキ取得エラー: %v", err)
-		return nil, fmt.Errorf("デッキの取得に失敗しました: %w", err)
+		return nil, false, fmt.Errorf("デッキの取得に失敗しました: %w", err)
 	}
 	
 	log.Printf("DatabaseService Info: デッキID %s のデッキデータを正常に取得しました", deckID)
-	return &deck, nil
+	return &deck, false, nil
 }
 
 // GetUserDisplayNameByUserID fetches the display name (user_name) for a given user ID (UUID).
 // If the user doesn't exist or user_name is empty, returns "ゲスト".
-func (s *DatabaseService) GetUserDisplayNameByUserID(userID string) string {
+func (s *DatabaseService) GetUserDisplayNameByUserID(ctx context.Context, userID string) string {
 	var userName sql.NullString
 	// users テーブルから userID に紐づく user_name を取得するクエリ
 	query := `SELECT user_name FROM users WHERE id = $1`
-	err := s.DB.QueryRow(query, userID).Scan(&userName)
+	err := s.DB.QueryRowContext(ctx, query, userID).Scan(&userName)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("DatabaseService Info: ユーザーID %s が見つからないため、「ゲスト」を返します", userID)
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCheckDeckOwner は他人のデッキを ErrDeckNotOwned で拒否し、テスト用デッキは使うプレイヤーの所有として返すことをテストします。
+func TestCheckDeckOwner(t *testing.T) {
+	deck, err := checkDeckOwner(&models.Deck{ID: "deck-1", UserID: "user-1"}, "user-1", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "deck-1", deck.ID)
+
+	deck, err = checkDeckOwner(&models.Deck{ID: "deck-1", UserID: "user-1"}, "user-2", false)
+	assert.ErrorIs(t, err, ErrDeckNotOwned)
+	assert.Nil(t, deck)
+
+	deck, err = checkDeckOwner(newTestDeck("test-deck-id"), "user-2", true)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-2", deck.UserID)
+}
+
+// TestGetDeckByIDAndUser_TestDeck はテスト用デッキIDが開発環境でのみ使え、本番では拒否されることをテストします。
+func TestGetDeckByIDAndUser_TestDeck(t *testing.T) {
+	service := &DatabaseService{} // テスト用デッキIDではDBに問い合わせない
+
+	t.Setenv("APP_ENV", "development")
+	deck, err := service.GetDeckByIDAndUser(context.Background(), "test-deck-id", "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", deck.UserID)
+
+	t.Setenv("APP_ENV", "production")
+	_, err = service.GetDeckByIDAndUser(context.Background(), "test-deck-id", "user-1")
+	assert.ErrorIs(t, err, ErrInvalidDeckID)
+}
+
+// TestValidateContributions は負の貢献数・不正な日付・JSTで今日より後の日付のエントリをスキップし、
+// 残りのエントリを元の順序のまま返すことをテストします。
+func TestValidateContributions(t *testing.T) {
+	now := time.Date(2024, 1, 1, 15, 30, 0, 0, time.UTC) // JSTでは 2024-01-02 00:30
+	tests := []struct {
+		name        string
+		input       []models.DailyContribution
+		want        []models.DailyContribution
+		wantSkipped int
+	}{
+		{name: "空", input: nil, want: []models.DailyContribution{}},
+		{
+			name:  "有効なエントリはそのまま",
+			input: []models.DailyContribution{{Date: "2023-12-31", Count: 0}, {Date: "2024-01-01", Count: 5}},
+			want:  []models.DailyContribution{{Date: "2023-12-31", Count: 0}, {Date: "2024-01-01", Count: 5}},
+		},
+		{
+			name:        "負の貢献数はスキップ",
+			input:       []models.DailyContribution{{Date: "2024-01-01", Count: -1}, {Date: "2023-12-31", Count: 2}},
+			want:        []models.DailyContribution{{Date: "2023-12-31", Count: 2}},
+			wantSkipped: 1,
+		},
+		{
+			name:        "不正な日付はスキップ",
+			input:       []models.DailyContribution{{Date: "2024/01/01", Count: 1}, {Date: "2024-02-30", Count: 1}, {Date: "", Count: 1}},
+			want:        []models.DailyContribution{},
+			wantSkipped: 3,
+		},
+		{
+			name:        "JSTの今日までは有効で、明日以降はスキップ",
+			input:       []models.DailyContribution{{Date: "2024-01-02", Count: 1}, {Date: "2024-01-03", Count: 1}},
+			want:        []models.DailyContribution{{Date: "2024-01-02", Count: 1}},
+			wantSkipped: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, skipped := validateContributions(tt.input, now)
+
+			assert.Equal(t, tt.want, valid)
+			assert.Equal(t, tt.wantSkipped, skipped)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Package databasetest はデータベースのリポジトリを置き換えるテスト用の実装です。
+// ハンドラー・セッション管理・ルーターのテストで同じ偽物を共有するために使います。
+package databasetest
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+)
+
+// ResultRepository は保存されたスコアと、ランキング・履歴の取得に渡された引数を記録するテスト用ResultRepositoryです。
+// 取得系は設定した固定値を返します。テストで使わないメソッドは埋め込んだインターフェース（nil）に委譲されます。
+type ResultRepository struct {
+	database.ResultRepository
+
+	// CreateResult で保存された内容（ユーザーIDごとの最後の保存）
+	Saved   map[string]int
+	Modes   map[string]models.GameMode
+	Flagged map[string]bool // 異常検知で印を付けて保存したかどうか
+
+	History    []models.Result // GetUserResultsPage が返すユーザーのリザルト（created_at DESC）
+	BestBefore *int            // GetUserBestScoreBefore が返す過去の最高スコア
+
+	TopModes     []models.GameMode // GetTopResults に渡された種別
+	RankingUsers []string          // GetUserRanking に渡されたユーザーID
+	RankingModes []models.GameMode // GetUserRanking に渡された種別
+	Pages        [][2]int          // GetUserResultsPage に渡された limit と offset
+	BeforeIDs    []int64           // GetUserBestScoreBefore に渡されたリザルトのID
+}
+
+func (f *ResultRepository) CreateResult(ctx context.Context, tx *sql.Tx, userID string, score int, mode models.GameMode, flagged bool, key string) (*models.Result, error) {
+	if f.Saved == nil {
+		f.Saved = make(map[string]int)
+		f.Modes = make(map[string]models.GameMode)
+		f.Flagged = make(map[string]bool)
+	}
+	f.Saved[userID] = score
+	f.Modes[userID] = mode
+	f.Flagged[userID] = flagged
+	return &models.Result{ID: int64(len(f.Saved)), UserID: userID, Score: score, GameMode: mode, Flagged: flagged}, nil
+}
+
+func (f *ResultRepository) GetTopResults(ctx context.Context, limit int, mode models.GameMode) ([]models.ResultResponse, error) {
+	f.TopModes = append(f.TopModes, mode)
+	return []models.ResultResponse{}, nil
+}
+
+func (f *ResultRepository) GetUserRanking(ctx context.Context, userID string, mode models.GameMode) (*models.ResultResponse, error) {
+	f.RankingUsers = append(f.RankingUsers, userID)
+	f.RankingModes = append(f.RankingModes, mode)
+	return nil, nil
+}
+
+func (f *ResultRepository) GetUserResultsPage(ctx context.Context, userID string, limit, offset int) ([]models.Result, error) {
+	f.Pages = append(f.Pages, [2]int{limit, offset})
+	if offset >= len(f.History) {
+		return []models.Result{}, nil
+	}
+	end := offset + limit
+	if end > len(f.History) {
+		end = len(f.History)
+	}
+	return f.History[offset:end], nil
+}
+
+func (f *ResultRepository) GetUserBestScoreBefore(ctx context.Context, userID string, before models.Result) (*int, error) {
+	f.BeforeIDs = append(f.BeforeIDs, before.ID)
+	return f.BestBefore, nil
+}
+
+// MatchHistoryRepository は記録された対戦履歴を保持し、固定の戦績を返すテスト用MatchHistoryRepositoryです。
+type MatchHistoryRepository struct {
+	Matches []*models.MatchHistory // CreateMatchHistory で記録された対戦履歴
+
+	Wins, Losses, Draws int    // GetUserRecord が返す勝敗数
+	Form                string // GetRecentForm が返す直近のフォーム（limit で切り詰める）
+	FormLimit           int    // GetRecentForm に渡された limit
+}
+
+func (f *MatchHistoryRepository) CreateMatchHistory(ctx context.Context, tx *sql.Tx, match *models.MatchHistory) error {
+	f.Matches = append(f.Matches, match)
+	match.ID = int64(len(f.Matches))
+	return nil
+}
+
+func (f *MatchHistoryRepository) GetUserRecord(ctx context.Context, userID string) (int, int, int, error) {
+	return f.Wins, f.Losses, f.Draws, nil
+}
+
+func (f *MatchHistoryRepository) GetRecentForm(ctx context.Context, userID string, limit int) (string, error) {
+	f.FormLimit = limit
+	if len(f.Form) > limit {
+		return f.Form[:limit], nil
+	}
+	return f.Form, nil
+}
+
+// ContributionReader は固定の保存済み貢献データと草の公開設定を返すテスト用の取得元です
+// （handlers.SavedContributionReader を満たします）。
+type ContributionReader struct {
+	Public        bool
+	VisibilityErr error
+	Contributions []models.DailyContribution
+	FetchedAt     *time.Time
+}
+
+func (f *ContributionReader) GetSavedContributions(ctx context.Context, userID string) ([]models.DailyContribution, *time.Time, error) {
+	return f.Contributions, f.FetchedAt, nil
+}
+
+func (f *ContributionReader) GetContributionVisibility(ctx context.Context, userID string) (bool, error) {
+	return f.Public, f.VisibilityErr
+}
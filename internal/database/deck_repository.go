@@ -1,11 +1,12 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
-
-	// "log"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,12 +15,16 @@ import (
 
 // DeckRepository はデッキ関連のデータベース操作を定義するインターフェースです。
 type DeckRepository interface {
-	GetDeckByUserID(tx *sql.Tx, userID string) (*models.Deck, error)
-	CreateDeck(tx *sql.Tx, userID string, initialTotalScore int) (*models.Deck, error)
-	UpdateDeckTotalScore(tx *sql.Tx, deckID string, totalScore int) error
-	DeleteTetriminoPlacementsByDeckID(tx *sql.Tx, deckID string) error
-	BulkInsertTetriminoPlacements(tx *sql.Tx, deckID string, placements []models.TetriminoPlacementRequest) error
-	GetTetriminoPlacementsByDeckID(tx *sql.Tx, deckID string) ([]models.TetriminoPlacement, error)
+	GetDeckByUserID(ctx context.Context, tx *sql.Tx, userID string) (*models.Deck, error)
+	CreateDeck(ctx context.Context, tx *sql.Tx, userID string, initialTotalScore int) (*models.Deck, error)
+	CreateDeckIfAbsent(ctx context.Context, tx *sql.Tx, userID string, initialTotalScore int) (*models.Deck, bool, error)
+	UpdateDeckTotalScore(ctx context.Context, tx *sql.Tx, deckID string, totalScore int) error
+	UpdateDeckTotalScoreWithVersion(ctx context.Context, tx *sql.Tx, deckID string, totalScore int, periodStart, periodEnd string, expectedVersion *int) (*models.Deck, error)
+	UpdateDeckVisibility(ctx context.Context, tx *sql.Tx, deckID string, isPublic bool) error
+	DeleteTetriminoPlacementsByDeckID(ctx context.Context, tx *sql.Tx, deckID string) error
+	BulkInsertTetriminoPlacements(ctx context.Context, tx *sql.Tx, deckID string, placements []models.TetriminoPlacementRequest) error
+	GetTetriminoPlacementsByDeckID(ctx context.Context, tx *sql.Tx, deckID string) ([]models.TetriminoPlacement, error)
+	GetDeckWithPlacementsByUserID(ctx context.Context, userID string) (*models.DeckWithPlacements, error)
 }
 
 // deckRepositoryImpl はDeckRepositoryインターフェースの実装です。
@@ -32,18 +37,86 @@ func NewDeckRepository(db *sql.DB) DeckRepository {
 	return &deckRepositoryImpl{db: db}
 }
 
-// GetDeckByUserID は指定されたユーザーIDのデッキを取得します。
-func (r *deckRepositoryImpl) GetDeckByUserID(tx *sql.Tx, userID string) (*models.Deck, error) {
-	deck := &models.Deck{}
-	// NOTE: トランザクションがnilの場合も考慮 (Read-only操作のため)
-	var row *sql.Row
+// executor は書き込みの実行先のトランザクションを返します。tx が nil の場合は ErrNilTx を返します。
+func (r *deckRepositoryImpl) executor(tx *sql.Tx) (dbExecutor, error) {
+	if tx == nil {
+		return nil, ErrNilTx
+	}
+	return tx, nil
+}
+
+// reader は読み取り専用のクエリの実行先を返します。tx が nil の場合はトランザクション外の r.db を使います。
+func (r *deckRepositoryImpl) reader(tx *sql.Tx) dbExecutor {
 	if tx != nil {
-		row = tx.QueryRow("SELECT id, user_id, total_score, created_at, updated_at FROM decks WHERE user_id = $1", userID)
-	} else {
-		row = r.db.QueryRow("SELECT id, user_id, total_score, created_at, updated_at FROM decks WHERE user_id = $1", userID)
+		return tx
+	}
+	return r.db
+}
+
+// deckColumns はデッキ1件を scanDeck で読み込むためのSELECT・RETURNINGの列です。
+const deckColumns = "id, user_id, total_score, is_public, version, period_start, period_end, created_at, updated_at"
+
+// rowScanner は *sql.Row と *sql.Rows に共通の Scan です。
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanDeck は deckColumns の順に並んだ行をデッキとして読み込みます。
+// 期間（period_start / period_end）が NULL の場合は空文字列のままにします。
+func scanDeck(row rowScanner, deck *models.Deck, extra ...interface{}) error {
+	var periodStart, periodEnd sql.NullTime
+	dest := append([]interface{}{&deck.ID, &deck.UserID, &deck.TotalScore, &deck.IsPublic, &deck.Version, &periodStart, &periodEnd, &deck.CreatedAt, &deck.UpdatedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	deck.PeriodStart = formatNullDate(periodStart)
+	deck.PeriodEnd = formatNullDate(periodEnd)
+	return nil
+}
+
+// formatNullDate はDATE列の値を YYYY-MM-DD 形式にします（NULL の場合は空文字列）。
+func formatNullDate(date sql.NullTime) string {
+	if !date.Valid {
+		return ""
 	}
+	return date.Time.Format(models.ContributionDateLayout)
+}
 
-	err := row.Scan(&deck.ID, &deck.UserID, &deck.TotalScore, &deck.CreatedAt, &deck.UpdatedAt)
+// checkDeckPeriod はデッキに保存するContribution期間を検証します。
+// 期間なし（両方とも空文字列）か、YYYY-MM-DD 形式で最終日が初日より後の期間だけを受け付け、
+// 片方だけの指定・逆転した期間・長さ0の期間は ErrInvalidDeckPeriod をラップしたエラーにします。
+func checkDeckPeriod(periodStart, periodEnd string) error {
+	if periodStart == "" && periodEnd == "" {
+		return nil
+	}
+	start, err := time.Parse(models.ContributionDateLayout, periodStart)
+	if err != nil {
+		return fmt.Errorf("%w: 初日 %q", ErrInvalidDeckPeriod, periodStart)
+	}
+	end, err := time.Parse(models.ContributionDateLayout, periodEnd)
+	if err != nil {
+		return fmt.Errorf("%w: 最終日 %q", ErrInvalidDeckPeriod, periodEnd)
+	}
+	if !end.After(start) {
+		return fmt.Errorf("%w: 最終日 %s が初日 %s より後ではありません", ErrInvalidDeckPeriod, periodEnd, periodStart)
+	}
+	return nil
+}
+
+// nullableDate は YYYY-MM-DD 形式の日付をDATE列に渡す値にします（空文字列は NULL）。
+func nullableDate(date string) interface{} {
+	if date == "" {
+		return nil
+	}
+	return date
+}
+
+// GetDeckByUserID は指定されたユーザーIDのデッキを取得します。
+func (r *deckRepositoryImpl) GetDeckByUserID(ctx context.Context, tx *sql.Tx, userID string) (*models.Deck, error) {
+	deck := &models.Deck{}
+	row := r.reader(tx).QueryRowContext(ctx, "SELECT "+deckColumns+" FROM decks WHERE user_id = $1", userID)
+
+	err := scanDeck(row, deck)
 	if err == sql.ErrNoRows {
 		return nil, nil // デッキが存在しない場合はnilを返す
 	}
@@ -54,11 +127,15 @@ func (r *deckRepositoryImpl) GetDeckByUserID(tx *sql.Tx, userID string) (*models
 }
 
 // CreateDeck は新しいデッキを作成します。
-func (r *deckRepositoryImpl) CreateDeck(tx *sql.Tx, userID string, initialTotalScore int) (*models.Deck, error) {
+func (r *deckRepositoryImpl) CreateDeck(ctx context.Context, tx *sql.Tx, userID string, initialTotalScore int) (*models.Deck, error) {
+	exec, err := r.executor(tx)
+	if err != nil {
+		return nil, err
+	}
 	newDeckID := uuid.New().String()
 	now := time.Now()
-	_, err := tx.Exec(
-		"INSERT INTO decks (id, user_id, total_score, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)",
+	_, err = exec.ExecContext(ctx, 
+		"INSERT INTO decks (id, user_id, total_score, is_public, version, created_at, updated_at) VALUES ($1, $2, $3, FALSE, 0, $4, $5)",
 		newDeckID, userID, initialTotalScore, now, now,
 	)
 	if err != nil {
@@ -73,76 +150,215 @@ func (r *deckRepositoryImpl) CreateDeck(tx *sql.Tx, userID string, initialTotalS
 	}, nil
 }
 
+// CreateDeckIfAbsent はユーザーのデッキが無い場合だけ新しいデッキを作成します。
+// decks.user_id の一意制約と ON CONFLICT DO NOTHING で、同時に呼ばれても1ユーザー1デッキのままにします。
+// 既にデッキがある（他のリクエストが先に作成した）場合は、そのデッキと false を返します。
+func (r *deckRepositoryImpl) CreateDeckIfAbsent(ctx context.Context, tx *sql.Tx, userID string, initialTotalScore int) (*models.Deck, bool, error) {
+	exec, err := r.executor(tx)
+	if err != nil {
+		return nil, false, err
+	}
+	deck := &models.Deck{}
+	now := time.Now()
+	row := exec.QueryRowContext(ctx,
+		"INSERT INTO decks (id, user_id, total_score, is_public, version, created_at, updated_at) VALUES ($1, $2, $3, FALSE, 0, $4, $5) "+
+			"ON CONFLICT (user_id) DO NOTHING RETURNING "+deckColumns,
+		uuid.New().String(), userID, initialTotalScore, now, now,
+	)
+	err = scanDeck(row, deck)
+	if err == sql.ErrNoRows {
+		existing, err := r.GetDeckByUserID(ctx, tx, userID)
+		if err != nil {
+			return nil, false, err
+		}
+		if existing == nil {
+			return nil, false, fmt.Errorf("ユーザーID %s のデッキの作成が競合しましたが、既存のデッキが見つかりません", userID)
+		}
+		return existing, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("新しいデッキの挿入に失敗しました: %w", err)
+	}
+	return deck, true, nil
+}
+
 // UpdateDeckTotalScore は指定されたデッキのtotal_scoreを更新します。
-func (r *deckRepositoryImpl) UpdateDeckTotalScore(tx *sql.Tx, deckID string, totalScore int) error {
-	_, err := tx.Exec("UPDATE decks SET total_score = $1, updated_at = NOW() WHERE id = $2", totalScore, deckID)
+func (r *deckRepositoryImpl) UpdateDeckTotalScore(ctx context.Context, tx *sql.Tx, deckID string, totalScore int) error {
+	exec, err := r.executor(tx)
+	if err != nil {
+		return err
+	}
+	_, err = exec.ExecContext(ctx, "UPDATE decks SET total_score = $1, updated_at = NOW() WHERE id = $2", totalScore, deckID)
 	if err != nil {
 		return fmt.Errorf("デッキの合計スコアの更新に失敗しました: %w", err)
 	}
 	return nil
 }
 
+// UpdateDeckTotalScoreWithVersion は楽観ロック付きでデッキのtotal_scoreとContribution期間を更新し、versionをインクリメントします。
+// expectedVersion が現在のversionと一致しない場合は ErrDeckVersionConflict を返します。
+// expectedVersion が nil の場合はバージョンチェックを行わずに更新します。
+// 期間は checkDeckPeriod で検証し、不正な場合は更新せずに ErrInvalidDeckPeriod を返します。
+//
+// Parameters:
+//   ctx             : キャンセル・タイムアウトでクエリを打ち切るためのコンテキスト
+//   tx              : 更新に使うトランザクション
+//   deckID          : 更新するデッキのID
+//   totalScore      : 新しい合計スコア
+//   periodStart     : Contribution期間の初日（YYYY-MM-DD、配置が無い場合は空文字列で NULL にする）
+//   periodEnd       : Contribution期間の最終日（YYYY-MM-DD、同上）
+//   expectedVersion : クライアントが読み込んだ時点のバージョン
+//
+// Returns:
+//   *models.Deck: 更新後のデッキ（バージョン・期間・updated_at を含む）
+func (r *deckRepositoryImpl) UpdateDeckTotalScoreWithVersion(ctx context.Context, tx *sql.Tx, deckID string, totalScore int, periodStart, periodEnd string, expectedVersion *int) (*models.Deck, error) {
+	if err := checkDeckPeriod(periodStart, periodEnd); err != nil {
+		return nil, err
+	}
+	exec, err := r.executor(tx)
+	if err != nil {
+		return nil, err
+	}
+	const returning = " RETURNING " + deckColumns
+	var row *sql.Row
+	if expectedVersion != nil {
+		row = exec.QueryRowContext(ctx, 
+			"UPDATE decks SET total_score = $1, period_start = $2, period_end = $3, version = version + 1, updated_at = NOW() WHERE id = $4 AND version = $5"+returning,
+			totalScore, nullableDate(periodStart), nullableDate(periodEnd), deckID, *expectedVersion,
+		)
+	} else {
+		row = exec.QueryRowContext(ctx, 
+			"UPDATE decks SET total_score = $1, period_start = $2, period_end = $3, version = version + 1, updated_at = NOW() WHERE id = $4"+returning,
+			totalScore, nullableDate(periodStart), nullableDate(periodEnd), deckID,
+		)
+	}
+
+	deck := &models.Deck{}
+	err = scanDeck(row, deck)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("デッキ %s: %w", deckID, ErrDeckVersionConflict) // 更新行が0件 = バージョン不一致
+	}
+	if err != nil {
+		return nil, fmt.Errorf("デッキの合計スコアの更新に失敗しました: %w", err)
+	}
+	return deck, nil
+}
+
+// UpdateDeckVisibility は指定されたデッキの公開/非公開フラグを更新します。
+func (r *deckRepositoryImpl) UpdateDeckVisibility(ctx context.Context, tx *sql.Tx, deckID string, isPublic bool) error {
+	exec, err := r.executor(tx)
+	if err != nil {
+		return err
+	}
+	query := "UPDATE decks SET is_public = $1, updated_at = NOW() WHERE id = $2"
+	_, err = exec.ExecContext(ctx, query, isPublic, deckID)
+	if err != nil {
+		return fmt.Errorf("デッキの公開設定の更新に失敗しました: %w", err)
+	}
+	return nil
+}
+
 // DeleteTetriminoPlacementsByDeckID は指定されたデッキIDの全てのテトリミノ配置を削除します。
-func (r *deckRepositoryImpl) DeleteTetriminoPlacementsByDeckID(tx *sql.Tx, deckID string) error {
-	_, err := tx.Exec("DELETE FROM tetrimino_placements WHERE deck_id = $1", deckID)
+func (r *deckRepositoryImpl) DeleteTetriminoPlacementsByDeckID(ctx context.Context, tx *sql.Tx, deckID string) error {
+	exec, err := r.executor(tx)
+	if err != nil {
+		return err
+	}
+	_, err = exec.ExecContext(ctx, "DELETE FROM tetrimino_placements WHERE deck_id = $1", deckID)
 	if err != nil {
 		return fmt.Errorf("既存のテトリミノ配置の削除に失敗しました: %w", err)
 	}
 	return nil
 }
 
+// placementInsertColumns は tetrimino_placements に1行挿入するごとのプレースホルダ数です（created_at は NOW()）。
+const placementInsertColumns = 7
+
+// maxPlacementRowsPerInsert は1文のINSERTで挿入する配置の最大行数です。
+// PostgreSQLの1文あたりのプレースホルダ数の上限（65535）を超えないようにバッチを分けます。
+const maxPlacementRowsPerInsert = 65535 / placementInsertColumns
+
 // BulkInsertTetriminoPlacements は複数のテトリミノ配置を一度に挿入します。
-func (r *deckRepositoryImpl) BulkInsertTetriminoPlacements(tx *sql.Tx, deckID string, placements []models.TetriminoPlacementRequest) error {
+// 行ごとの往復を避けるため、複数行の VALUES を持つ1文のINSERTでまとめて挿入します（プレースホルダ数の上限でバッチ分割）。
+// tx のトランザクション内で実行します（nil の場合は ErrNilTx）。
+func (r *deckRepositoryImpl) BulkInsertTetriminoPlacements(ctx context.Context, tx *sql.Tx, deckID string, placements []models.TetriminoPlacementRequest) error {
 	if len(placements) == 0 {
 		return nil // 挿入するデータがない場合は何もしない
 	}
 
-	stmt, err := tx.Prepare(
-		`INSERT INTO tetrimino_placements (id, deck_id, tetrimino_type, rotation, start_date, positions, score_potential, created_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())`)
+	exec, err := r.executor(tx)
+	if err != nil {
+		return err
+	}
+	started := time.Now()
+	batches, err := insertTetriminoPlacements(ctx, exec, deckID, placements, maxPlacementRowsPerInsert)
 	if err != nil {
-		return fmt.Errorf("一括挿入のためのプリペアードステートメントの準備に失敗しました: %w", err)
+		return err
 	}
-	defer stmt.Close()
+	log.Printf("DeckRepository Info: デッキID %s のテトリミノ配置 %d 件を %d 回のINSERTで挿入しました (%v)", deckID, len(placements), batches, time.Since(started))
+	return nil
+}
 
+// insertTetriminoPlacements は配置を rowsPerBatch 行ずつ複数行の VALUES のINSERTで挿入し、実行したINSERTの回数を返します。
+// 日付・座標が不正な配置がある場合は、何も挿入せずにエラーを返します。
+//
+// Parameters:
+//   exec         : クエリの実行先（*sql.DB または *sql.Tx）
+//   deckID       : 配置を挿入するデッキのID
+//   placements   : 挿入する配置
+//   rowsPerBatch : 1文で挿入する最大行数
+func insertTetriminoPlacements(ctx context.Context, exec dbExecutor, deckID string, placements []models.TetriminoPlacementRequest, rowsPerBatch int) (int, error) {
+	args := make([]interface{}, 0, len(placements)*placementInsertColumns)
 	for _, p := range placements {
 		parsedDate, err := time.Parse("2006-01-02", p.StartDate)
 		if err != nil {
-			return fmt.Errorf("開始日付 '%s' のパースに失敗しました: %w", p.StartDate, err)
+			return 0, fmt.Errorf("開始日付 '%s' のパースに失敗しました: %w", p.StartDate, err)
 		}
 
 		positionsJSON, err := json.Marshal(p.Positions)
 		if err != nil {
-			return fmt.Errorf("テトリミノタイプ '%s' のポジションのマーシャルに失敗しました: %w", p.Type, err)
+			return 0, fmt.Errorf("テトリミノタイプ '%s' のポジションのマーシャルに失敗しました: %w", p.Type, err)
 		}
 
-		_, err = stmt.Exec(
-			uuid.New().String(), deckID, p.Type, p.Rotation, parsedDate, positionsJSON, p.ScorePotential,
-		)
-		if err != nil {
-			return fmt.Errorf("テトリミノ配置の挿入に失敗しました: %w", err)
+		args = append(args, uuid.New().String(), deckID, p.Type, p.Rotation, parsedDate, positionsJSON, p.ScorePotential)
+	}
+
+	batches := 0
+	for start := 0; start < len(placements); start += rowsPerBatch {
+		end := start + rowsPerBatch
+		if end > len(placements) {
+			end = len(placements)
+		}
+		query := placementInsertQuery(end - start)
+		if _, err := exec.ExecContext(ctx, query, args[start*placementInsertColumns:end*placementInsertColumns]...); err != nil {
+			return batches, fmt.Errorf("テトリミノ配置の挿入に失敗しました: %w", err)
 		}
+		batches++
 	}
-	return nil
+	return batches, nil
+}
+
+// placementInsertQuery は rows 行分の VALUES を持つ tetrimino_placements へのINSERT文を返します。
+func placementInsertQuery(rows int) string {
+	var b strings.Builder
+	b.WriteString(`INSERT INTO tetrimino_placements (id, deck_id, tetrimino_type, rotation, start_date, positions, score_potential, created_at) VALUES `)
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		n := i * placementInsertColumns
+		fmt.Fprintf(&b, "($%d, $%d, $%d, $%d, $%d, $%d, $%d, NOW())", n+1, n+2, n+3, n+4, n+5, n+6, n+7)
+	}
+	return b.String()
 }
 
 // GetTetriminoPlacementsByDeckID は指定されたデッキIDの全てのテトリミノ配置を取得します。
-func (r *deckRepositoryImpl) GetTetriminoPlacementsByDeckID(tx *sql.Tx, deckID string) ([]models.TetriminoPlacement, error) {
+func (r *deckRepositoryImpl) GetTetriminoPlacementsByDeckID(ctx context.Context, tx *sql.Tx, deckID string) ([]models.TetriminoPlacement, error) {
 	placements := []models.TetriminoPlacement{}
 
-	// NOTE: トランザクションがnilの場合も考慮 (Read-only操作のため)
-	var rows *sql.Rows
-	var err error
-	if tx != nil {
-		rows, err = tx.Query(
-			`SELECT id, deck_id, tetrimino_type, rotation, start_date, positions, score_potential, created_at
-			 FROM tetrimino_placements WHERE deck_id = $1`, deckID)
-	} else {
-		rows, err = r.db.Query(
-			`SELECT id, deck_id, tetrimino_type, rotation, start_date, positions, score_potential, created_at
-			 FROM tetrimino_placements WHERE deck_id = $1`, deckID)
-	}
-
+	rows, err := r.reader(tx).QueryContext(ctx, 
+		`SELECT id, deck_id, tetrimino_type, rotation, start_date, positions, score_potential, created_at
+		 FROM tetrimino_placements WHERE deck_id = $1`, deckID)
 	if err != nil {
 		return nil, fmt.Errorf("テトリミノ配置のクエリに失敗しました: %w", err)
 	}
@@ -171,4 +387,64 @@ func (r *deckRepositoryImpl) GetTetriminoPlacementsByDeckID(tx *sql.Tx, deckID s
 	}
 
 	return placements, nil
-}
\ No newline at end of file
+}
+
+// GetDeckWithPlacementsByUserID は指定されたユーザーIDのデッキとテトリミノ配置を1回のクエリで取得します。
+// decks と tetrimino_placements をLEFT JOINするため、配置が0件でもデッキ情報は返ります。
+// デッキが存在しない場合は nil を返します。
+func (r *deckRepositoryImpl) GetDeckWithPlacementsByUserID(ctx context.Context, userID string) (*models.DeckWithPlacements, error) {
+	rows, err := r.db.QueryContext(ctx, 
+		`SELECT d.id, d.user_id, d.total_score, d.is_public, d.version, d.period_start, d.period_end, d.created_at, d.updated_at,
+		        p.id, p.tetrimino_type, p.rotation, p.start_date, p.positions, p.score_potential
+		 FROM decks d
+		 LEFT JOIN tetrimino_placements p ON p.deck_id = d.id
+		 WHERE d.user_id = $1
+		 ORDER BY p.created_at ASC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("デッキと配置のクエリに失敗しました: %w", err)
+	}
+	defer rows.Close()
+
+	var result *models.DeckWithPlacements
+	for rows.Next() {
+		var deck models.Deck
+		var (
+			placementID    sql.NullString
+			tetriminoType  sql.NullString
+			rotation       sql.NullInt64
+			startDate      sql.NullTime
+			positions      []byte
+			scorePotential sql.NullInt64
+		)
+		err := scanDeck(rows, &deck, &placementID, &tetriminoType, &rotation, &startDate, &positions, &scorePotential)
+		if err != nil {
+			return nil, fmt.Errorf("デッキと配置のスキャンに失敗しました: %w", err)
+		}
+
+		if result == nil {
+			result = &models.DeckWithPlacements{
+				Deck:       &deck,
+				Placements: []models.TetriminoPlacementAPI{},
+			}
+		}
+
+		// LEFT JOINで配置が存在しない行はスキップ
+		if !placementID.Valid {
+			continue
+		}
+		result.Placements = append(result.Placements, models.TetriminoPlacementAPI{
+			ID:             placementID.String,
+			TetriminoType:  tetriminoType.String,
+			Rotation:       int(rotation.Int64),
+			StartDate:      startDate.Time.Format("2006-01-02"), // YYYY-MM-DD 形式にフォーマット
+			Positions:      json.RawMessage(positions),         // JSONBをそのまま渡す
+			ScorePotential: int(scorePotential.Int64),
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("デッキと配置の行イテレーション中にエラーが発生しました: %w", err)
+	}
+
+	return result, nil // デッキが存在しない場合はnil
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingExecutor は ExecContext に渡されたクエリと引数を記録する dbExecutor です。
+type recordingExecutor struct {
+	dbExecutor // ExecContext 以外は使わない
+	queries    []string
+	args       [][]interface{}
+	failAt     int // 1始まりで、この回数目の ExecContext を失敗させる（0なら失敗しない）
+}
+
+func (e *recordingExecutor) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	e.queries = append(e.queries, query)
+	e.args = append(e.args, args)
+	if len(e.queries) == e.failAt {
+		return nil, errors.New("boom")
+	}
+	return nil, nil
+}
+
+func testPlacements(n int) []models.TetriminoPlacementRequest {
+	placements := make([]models.TetriminoPlacementRequest, n)
+	for i := range placements {
+		placements[i] = models.TetriminoPlacementRequest{
+			Type:           "T",
+			Rotation:       i % 4,
+			StartDate:      fmt.Sprintf("2024-01-%02d", i%28+1),
+			Positions:      []models.Position{{X: i, Y: 0, Score: 100}},
+			ScorePotential: i * 10,
+		}
+	}
+	return placements
+}
+
+// TestInsertTetriminoPlacements は複数行の VALUES でまとめて挿入し、各行の値が従来の1行ずつの挿入と同じになることをテストします。
+func TestInsertTetriminoPlacements(t *testing.T) {
+	placements := testPlacements(5)
+	exec := &recordingExecutor{}
+
+	batches, err := insertTetriminoPlacements(context.Background(), exec, "deck-1", placements, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, batches)
+	require.Len(t, exec.queries, 3, "2行・2行・1行に分割されるはず")
+	assert.Equal(t, 2, strings.Count(exec.queries[0], "NOW()"))
+	assert.Equal(t, 1, strings.Count(exec.queries[2], "NOW()"))
+	assert.True(t, strings.HasSuffix(exec.queries[0], "($8, $9, $10, $11, $12, $13, $14, NOW())"), exec.queries[0])
+
+	var rows [][]interface{}
+	for _, args := range exec.args {
+		for i := 0; i < len(args); i += placementInsertColumns {
+			rows = append(rows, args[i:i+placementInsertColumns])
+		}
+	}
+	require.Len(t, rows, len(placements))
+	ids := map[interface{}]bool{}
+	for i, p := range placements {
+		date, _ := time.Parse("2006-01-02", p.StartDate)
+		row := rows[i]
+		ids[row[0]] = true
+		assert.Equal(t, []interface{}{"deck-1", p.Type, p.Rotation, date, []byte(fmt.Sprintf(`[{"x":%d,"y":0,"score":100}]`, i)), p.ScorePotential}, row[1:])
+	}
+	assert.Len(t, ids, len(placements), "IDは行ごとに異なるはず")
+}
+
+// TestInsertTetriminoPlacements_PlaceholderLimit は上限の行数ちょうどでは1文、超えると分割されることをテストします。
+func TestInsertTetriminoPlacements_PlaceholderLimit(t *testing.T) {
+	assert.LessOrEqual(t, maxPlacementRowsPerInsert*placementInsertColumns, 65535)
+
+	exec := &recordingExecutor{}
+	batches, err := insertTetriminoPlacements(context.Background(), exec, "deck-1", testPlacements(maxPlacementRowsPerInsert+1), maxPlacementRowsPerInsert)
+	require.NoError(t, err)
+	assert.Equal(t, 2, batches)
+	assert.Len(t, exec.args[0], maxPlacementRowsPerInsert*placementInsertColumns)
+	assert.Len(t, exec.args[1], placementInsertColumns)
+}
+
+// TestInsertTetriminoPlacements_Errors は不正な日付では何も挿入せず、挿入の失敗はエラーとして返すことをテストします。
+func TestInsertTetriminoPlacements_Errors(t *testing.T) {
+	placements := testPlacements(3)
+	placements[2].StartDate = "not-a-date"
+	exec := &recordingExecutor{}
+	_, err := insertTetriminoPlacements(context.Background(), exec, "deck-1", placements, 2)
+	assert.Error(t, err)
+	assert.Empty(t, exec.queries, "不正な配置があれば1件も挿入しないはず")
+
+	exec = &recordingExecutor{failAt: 2}
+	batches, err := insertTetriminoPlacements(context.Background(), exec, "deck-1", testPlacements(3), 2)
+	assert.Error(t, err)
+	assert.Equal(t, 1, batches)
+}
+
+// TestDeckRepository_WritesRequireTx は書き込みのメソッドがトランザクション無しで呼ばれた場合、
+// r.db にフォールバックせず ErrNilTx を返すことをテストします。
+func TestDeckRepository_WritesRequireTx(t *testing.T) {
+	repo := &deckRepositoryImpl{} // r.db に触れれば nil のためパニックする
+	ctx := context.Background()
+	version := 0
+
+	_, err := repo.CreateDeck(ctx, nil, "user-1", 0)
+	assert.ErrorIs(t, err, ErrNilTx)
+	_, _, err = repo.CreateDeckIfAbsent(ctx, nil, "user-1", 0)
+	assert.ErrorIs(t, err, ErrNilTx)
+	assert.ErrorIs(t, repo.UpdateDeckTotalScore(ctx, nil, "deck-1", 0), ErrNilTx)
+	_, err = repo.UpdateDeckTotalScoreWithVersion(ctx, nil, "deck-1", 0, "", "", &version)
+	assert.ErrorIs(t, err, ErrNilTx)
+	assert.ErrorIs(t, repo.UpdateDeckVisibility(ctx, nil, "deck-1", true), ErrNilTx)
+	assert.ErrorIs(t, repo.DeleteTetriminoPlacementsByDeckID(ctx, nil, "deck-1"), ErrNilTx)
+	assert.ErrorIs(t, repo.BulkInsertTetriminoPlacements(ctx, nil, "deck-1", []models.TetriminoPlacementRequest{{Type: "O"}}), ErrNilTx)
+}
+
+// TestCheckDeckPeriod は期間なしか、最終日が初日より後の期間だけを受け付けることをテストします。
+func TestCheckDeckPeriod(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   string
+		end     string
+		wantErr bool
+	}{
+		{"期間なし", "", "", false},
+		{"正しい期間", "2026-03-01", "2026-04-25", false},
+		{"初日だけ", "2026-03-01", "", true},
+		{"最終日だけ", "", "2026-04-25", true},
+		{"逆転した期間", "2026-04-25", "2026-03-01", true},
+		{"長さ0の期間", "2026-03-01", "2026-03-01", true},
+		{"形式が不正", "03/01", "2026-04-25", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDeckPeriod(tt.start, tt.end)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidDeckPeriod)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
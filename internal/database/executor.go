@@ -0,0 +1,27 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ErrNilTx は書き込みを行うリポジトリのメソッドにトランザクションが渡されなかった場合のエラーです。
+// 書き込みがトランザクション外で暗黙に実行されないよう、r.db にはフォールバックしません。
+var ErrNilTx = errors.New("書き込みにはトランザクションが必要です")
+
+// dbExecutor は *sql.DB と *sql.Tx の共通インターフェースです。
+// リポジトリのメソッドはトランザクションの有無に関わらずこのインターフェース経由でクエリを実行します。
+// リクエストのキャンセルやタイムアウトでクエリを打ち切れるよう、コンテキスト付きのメソッドのみを公開します。
+type dbExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// 両方の型が dbExecutor を満たしていることをコンパイル時に保証します。
+var (
+	_ dbExecutor = (*sql.DB)(nil)
+	_ dbExecutor = (*sql.Tx)(nil)
+)
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+)
+
+// MatchHistoryRepository は対戦履歴関連のデータベース操作を定義するインターフェースです。
+type MatchHistoryRepository interface {
+	// CreateMatchHistory は対戦履歴レコードを作成し、採番されたIDを match.ID に設定します
+	CreateMatchHistory(ctx context.Context, tx *sql.Tx, match *models.MatchHistory) error
+	// GetUserRecord はユーザーの勝ち・負け・引き分けの数を集計します
+	GetUserRecord(ctx context.Context, userID string) (wins, losses, draws int, err error)
+	// GetRecentForm はユーザーの直近 limit 戦の結果を新しい順に W/L/D の文字列で返します
+	GetRecentForm(ctx context.Context, userID string, limit int) (string, error)
+}
+
+// matchHistoryRepositoryImpl はMatchHistoryRepositoryインターフェースの実装です。
+type matchHistoryRepositoryImpl struct {
+	db *sql.DB
+}
+
+// NewMatchHistoryRepository はMatchHistoryRepositoryの新しいインスタンスを作成します。
+func NewMatchHistoryRepository(db *sql.DB) MatchHistoryRepository {
+	return &matchHistoryRepositoryImpl{db: db}
+}
+
+// CreateMatchHistory は対戦履歴レコードを作成し、採番されたIDを match.ID に設定します。
+func (r *matchHistoryRepositoryImpl) CreateMatchHistory(ctx context.Context, tx *sql.Tx, match *models.MatchHistory) error {
+	query := `
+		INSERT INTO match_histories
+			(passcode, player1_id, player2_id, player1_score, player2_score, winner_id, end_reason, started_at, ended_at,
+			 player1_apm, player2_apm, player1_pps, player2_pps)
+		VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5, NULLIF($6, '')::uuid, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id
+	`
+	args := []interface{}{
+		match.Passcode, match.Player1ID, match.Player2ID, match.Player1Score, match.Player2Score,
+		match.WinnerID, match.EndReason, match.StartedAt, match.EndedAt,
+		match.Player1APM, match.Player2APM, match.Player1PPS, match.Player2PPS,
+	}
+
+	var row *sql.Row
+	if tx != nil {
+		row = tx.QueryRowContext(ctx, query, args...)
+	} else {
+		row = r.db.QueryRowContext(ctx, query, args...)
+	}
+	if err := row.Scan(&match.ID); err != nil {
+		return fmt.Errorf("対戦履歴レコードの作成に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// GetUserRecord はユーザーの勝ち・負け・引き分けの数を1回のクエリで集計します。
+// 対戦相手のいない履歴（player2_id が NULL）は対戦として数えません。対戦数0なら全て0を返します。
+func (r *matchHistoryRepositoryImpl) GetUserRecord(ctx context.Context, userID string) (wins, losses, draws int, err error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE winner_id = $1::uuid),
+			COUNT(*) FILTER (WHERE winner_id IS NOT NULL AND winner_id <> $1::uuid),
+			COUNT(*) FILTER (WHERE winner_id IS NULL)
+		FROM match_histories
+		WHERE (player1_id = $1::uuid OR player2_id = $1::uuid) AND player2_id IS NOT NULL
+	`
+	if err = r.db.QueryRowContext(ctx, query, userID).Scan(&wins, &losses, &draws); err != nil {
+		return 0, 0, 0, fmt.Errorf("ユーザー %s の戦績の集計に失敗しました: %w", userID, err)
+	}
+	return wins, losses, draws, nil
+}
+
+// GetRecentForm はユーザーの直近 limit 戦の結果を新しい順に W（勝ち）/L（負け）/D（引き分け）の文字列で返します。
+func (r *matchHistoryRepositoryImpl) GetRecentForm(ctx context.Context, userID string, limit int) (string, error) {
+	query := `
+		SELECT COALESCE(string_agg(result, '' ORDER BY ended_at DESC), '')
+		FROM (
+			SELECT
+				CASE
+					WHEN winner_id IS NULL THEN 'D'
+					WHEN winner_id = $1::uuid THEN 'W'
+					ELSE 'L'
+				END AS result,
+				ended_at
+			FROM match_histories
+			WHERE (player1_id = $1::uuid OR player2_id = $1::uuid) AND player2_id IS NOT NULL
+			ORDER BY ended_at DESC
+			LIMIT $2
+		) recent
+	`
+	var form string
+	if err := r.db.QueryRowContext(ctx, query, userID, limit).Scan(&form); err != nil {
+		return "", fmt.Errorf("ユーザー %s の直近の戦績の取得に失敗しました: %w", userID, err)
+	}
+	return form, nil
+}
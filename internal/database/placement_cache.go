@@ -0,0 +1,191 @@
+package database
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+)
+
+// テトリミノ配置キャッシュの設定です。
+// 同じデッキで連戦する場合の対戦開始時のDBアクセスを減らすため、配置データをデッキIDごとに短時間保持します。
+const (
+	PlacementCacheTTL        = 5 * time.Minute // キャッシュした配置データの有効期間
+	PlacementCacheMaxEntries = 256             // 保持するデッキ数の上限（超えた場合は最も使われていないものから捨てる）
+)
+
+// PlacementCacheInvalidator は配置データのキャッシュを無効化できるリポジトリです。
+// デッキの保存をコミットした後に呼び出し、更新前の配置データがキャッシュに残らないようにします。
+type PlacementCacheInvalidator interface {
+	InvalidatePlacements(deckID string)
+}
+
+// PlacementCacheStats は配置キャッシュのヒット率などの集計です。
+type PlacementCacheStats struct {
+	Entries int     `json:"entries"`  // 現在キャッシュしているデッキ数
+	Hits    int64   `json:"hits"`     // キャッシュから返した回数の累計
+	Misses  int64   `json:"misses"`   // DBから読み込んだ回数の累計（期限切れを含む）
+	HitRate float64 `json:"hit_rate"` // ヒット率（0〜1、取得が無い場合は0）
+}
+
+// placementCacheEntry はLRUリストの要素です。
+type placementCacheEntry struct {
+	deckID     string
+	placements []models.TetriminoPlacement
+	expiresAt  time.Time
+}
+
+// CachedDeckRepository は GetTetriminoPlacementsByDeckID の結果をデッキIDごとにキャッシュする DeckRepository のラッパーです。
+// トランザクション内の読み込み（tx != nil）はキャッシュを使いません。
+// 配置データを書き換えるメソッドの呼び出しと InvalidatePlacements で該当デッキのキャッシュを捨てます。
+type CachedDeckRepository struct {
+	DeckRepository
+
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time // テストで時刻を差し替えるため
+
+	mu         sync.Mutex
+	lru        *list.List               // 先頭ほど最近使われたエントリ
+	entries    map[string]*list.Element // deckID → lru の要素
+	generation uint64                   // 無効化のたびに増やす世代（読み込み中に無効化された古いデータをキャッシュしないため）
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// 配置データを書き換えたらキャッシュを無効化できることをコンパイル時に保証します。
+var _ PlacementCacheInvalidator = (*CachedDeckRepository)(nil)
+
+// NewCachedDeckRepository は repo の配置データの取得をキャッシュする CachedDeckRepository を作成します。
+//
+// Parameters:
+//   repo       : ラップするデッキリポジトリ
+//   ttl        : キャッシュの有効期間（0以下の場合は PlacementCacheTTL）
+//   maxEntries : 保持するデッキ数の上限（0以下の場合は PlacementCacheMaxEntries）
+func NewCachedDeckRepository(repo DeckRepository, ttl time.Duration, maxEntries int) *CachedDeckRepository {
+	if ttl <= 0 {
+		ttl = PlacementCacheTTL
+	}
+	if maxEntries <= 0 {
+		maxEntries = PlacementCacheMaxEntries
+	}
+	return &CachedDeckRepository{
+		DeckRepository: repo,
+		ttl:            ttl,
+		maxEntries:     maxEntries,
+		now:            time.Now,
+		lru:            list.New(),
+		entries:        make(map[string]*list.Element),
+	}
+}
+
+// GetTetriminoPlacementsByDeckID は有効期限内のキャッシュがあればそれを返し、無ければDBから読み込んでキャッシュします。
+// 呼び出し側が結果を書き換えてもキャッシュに影響しないよう、返すスライスはコピーです。
+func (c *CachedDeckRepository) GetTetriminoPlacementsByDeckID(ctx context.Context, tx *sql.Tx, deckID string) ([]models.TetriminoPlacement, error) {
+	if tx != nil {
+		return c.DeckRepository.GetTetriminoPlacementsByDeckID(ctx, tx, deckID)
+	}
+
+	placements, generation, ok := c.lookup(deckID)
+	if ok {
+		c.hits.Add(1)
+		return placements, nil
+	}
+	c.misses.Add(1)
+
+	placements, err := c.DeckRepository.GetTetriminoPlacementsByDeckID(ctx, nil, deckID)
+	if err != nil {
+		return nil, err
+	}
+	c.store(deckID, placements, generation)
+	return append([]models.TetriminoPlacement(nil), placements...), nil
+}
+
+// DeleteTetriminoPlacementsByDeckID は配置データを削除し、該当デッキのキャッシュを無効化します。
+func (c *CachedDeckRepository) DeleteTetriminoPlacementsByDeckID(ctx context.Context, tx *sql.Tx, deckID string) error {
+	c.InvalidatePlacements(deckID)
+	return c.DeckRepository.DeleteTetriminoPlacementsByDeckID(ctx, tx, deckID)
+}
+
+// BulkInsertTetriminoPlacements は配置データを挿入し、該当デッキのキャッシュを無効化します。
+func (c *CachedDeckRepository) BulkInsertTetriminoPlacements(ctx context.Context, tx *sql.Tx, deckID string, placements []models.TetriminoPlacementRequest) error {
+	c.InvalidatePlacements(deckID)
+	return c.DeckRepository.BulkInsertTetriminoPlacements(ctx, tx, deckID, placements)
+}
+
+// InvalidatePlacements は指定したデッキの配置データのキャッシュを捨てます。
+// トランザクション内の書き込み時点で捨てても、コミット前に別の読み込みが古いデータをキャッシュし直す可能性があるため、
+// デッキの保存をコミットした後にも呼び出します。
+func (c *CachedDeckRepository) InvalidatePlacements(deckID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	if elem, ok := c.entries[deckID]; ok {
+		c.lru.Remove(elem)
+		delete(c.entries, deckID)
+	}
+}
+
+// Stats はキャッシュのエントリ数とヒット率を返します。
+func (c *CachedDeckRepository) Stats() PlacementCacheStats {
+	c.mu.Lock()
+	entries := c.lru.Len()
+	c.mu.Unlock()
+
+	stats := PlacementCacheStats{Entries: entries, Hits: c.hits.Load(), Misses: c.misses.Load()}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// lookup は有効期限内のキャッシュのコピーを返します。期限切れのエントリはここで捨てます。
+// キャッシュが無い場合は、DBから読み込んだ結果を store に渡すときのために現在の世代を返します。
+func (c *CachedDeckRepository) lookup(deckID string) ([]models.TetriminoPlacement, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[deckID]
+	if !ok {
+		return nil, c.generation, false
+	}
+	entry := elem.Value.(*placementCacheEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.lru.Remove(elem)
+		delete(c.entries, deckID)
+		return nil, c.generation, false
+	}
+	c.lru.MoveToFront(elem)
+	return append([]models.TetriminoPlacement(nil), entry.placements...), c.generation, true
+}
+
+// store は配置データをキャッシュし、上限を超えた場合は最も使われていないエントリを捨てます。
+// DBから読み込んでいる間に無効化があった場合（世代が変わった場合）は、古いデータの可能性があるためキャッシュしません。
+func (c *CachedDeckRepository) store(deckID string, placements []models.TetriminoPlacement, generation uint64) {
+	entry := &placementCacheEntry{
+		deckID:     deckID,
+		placements: append([]models.TetriminoPlacement(nil), placements...),
+		expiresAt:  c.now().Add(c.ttl),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		return
+	}
+	if elem, ok := c.entries[deckID]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[deckID] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*placementCacheEntry).deckID)
+	}
+}
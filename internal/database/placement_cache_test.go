@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingDeckRepository は配置データの読み込み回数を数えるテスト用DeckRepositoryです。
+type countingDeckRepository struct {
+	DeckRepository
+	reads map[string]int
+}
+
+func (f *countingDeckRepository) GetTetriminoPlacementsByDeckID(ctx context.Context, tx *sql.Tx, deckID string) ([]models.TetriminoPlacement, error) {
+	f.reads[deckID]++
+	return []models.TetriminoPlacement{{DeckID: deckID, ScorePotential: f.reads[deckID]}}, nil
+}
+
+func (f *countingDeckRepository) DeleteTetriminoPlacementsByDeckID(ctx context.Context, tx *sql.Tx, deckID string) error {
+	return nil
+}
+
+// TestCachedDeckRepository はキャッシュのヒット・TTL切れ・無効化・サイズ上限による追い出しと、ヒット率の集計をテストします。
+func TestCachedDeckRepository(t *testing.T) {
+	repo := &countingDeckRepository{reads: make(map[string]int)}
+	cache := NewCachedDeckRepository(repo, time.Minute, 2)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	get := func(deckID string) int {
+		placements, err := cache.GetTetriminoPlacementsByDeckID(ctx, nil, deckID)
+		assert.NoError(t, err)
+		return placements[0].ScorePotential
+	}
+
+	assert.Equal(t, 1, get("deck-a"))
+	assert.Equal(t, 1, get("deck-a"), "2回目はキャッシュから返るはず")
+	assert.Equal(t, 1, repo.reads["deck-a"])
+
+	// 配置データの削除（デッキの保存）で無効化される
+	assert.NoError(t, cache.DeleteTetriminoPlacementsByDeckID(ctx, nil, "deck-a"))
+	assert.Equal(t, 2, get("deck-a"))
+
+	// TTLが切れたら読み込み直す
+	now = now.Add(time.Minute)
+	assert.Equal(t, 3, get("deck-a"))
+
+	// 上限（2件）を超えると最も使われていないデッキから追い出される
+	get("deck-b")
+	get("deck-a")
+	get("deck-c")
+	assert.Equal(t, 3, get("deck-a"), "最近使ったデッキは残るはず")
+	assert.Equal(t, 2, get("deck-b"), "最も使われていないデッキは追い出されるはず")
+
+	stats := cache.Stats()
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, int64(3), stats.Hits)
+	assert.Equal(t, int64(6), stats.Misses)
+	assert.InDelta(t, 3.0/9.0, stats.HitRate, 1e-9)
+}
+
+// TestCachedDeckRepository_InvalidatedWhileLoading は読み込み中に無効化された場合、古いかもしれない結果をキャッシュしないことをテストします。
+func TestCachedDeckRepository_InvalidatedWhileLoading(t *testing.T) {
+	cache := NewCachedDeckRepository(&countingDeckRepository{reads: make(map[string]int)}, time.Minute, 2)
+
+	_, generation, ok := cache.lookup("deck-a")
+	assert.False(t, ok)
+	cache.InvalidatePlacements("deck-a") // 読み込み中にデッキが保存された
+	cache.store("deck-a", []models.TetriminoPlacement{{DeckID: "deck-a"}}, generation)
+
+	assert.Equal(t, 0, cache.Stats().Entries)
+}
@@ -1,26 +1,38 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
 )
 
 // ResultRepository はゲーム結果関連のデータベース操作を定義するインターフェースです。
 type ResultRepository interface {
-	// CreateResult は新しいゲーム結果レコードを作成します
-	CreateResult(tx *sql.Tx, userID string, score int) (*models.Result, error)
+	// CreateResult は新しいゲーム結果レコードを作成します（ユーザーが存在しない場合は ErrUserNotFound）。
+	// mode はソロ・対戦の種別、flagged はスコアが異常検知に引っかかったかどうかで、ランキングには載せたまま印を付けます。
+	// key は保存の冪等キーで、同じキーで保存済みの場合は新しく作成せずに既存のレコードを返します（空の場合は重複を確認しません）
+	CreateResult(ctx context.Context, tx *sql.Tx, userID string, score int, mode models.GameMode, flagged bool, key string) (*models.Result, error)
 	
-	// GetTopResults は上位N件の結果を取得します（ランキング用）
-	GetTopResults(limit int) ([]models.ResultResponse, error)
+	// GetTopResults は上位N件の結果を取得します（ランキング用、mode が空の場合はすべての種別）
+	GetTopResults(ctx context.Context, limit int, mode models.GameMode) ([]models.ResultResponse, error)
 	
-	// GetUserBestScore は指定したユーザーの最高スコアを取得します
-	GetUserBestScore(userID string) (*models.Result, error)
+	// GetUserBestScore は指定したユーザーの最高スコアを取得します（mode が空の場合はすべての種別）
+	GetUserBestScore(ctx context.Context, userID string, mode models.GameMode) (*models.Result, error)
 	
-	// GetUserRanking は指定したユーザーの現在のランキング順位を取得します
-	GetUserRanking(userID string) (*models.ResultResponse, error)
+	// GetUserRanking は指定したユーザーの現在のランキング順位を取得します（mode が空の場合はすべての種別のランキング）
+	GetUserRanking(ctx context.Context, userID string, mode models.GameMode) (*models.ResultResponse, error)
+
+	// GetUserResultsPage は指定したユーザーの結果を created_at DESC で offset 件目からN件取得します
+	GetUserResultsPage(ctx context.Context, userID string, limit, offset int) ([]models.Result, error)
+
+	// GetUserBestScoreBefore は指定したリザルトより前に記録されたユーザーの最高スコアを取得します
+	GetUserBestScoreBefore(ctx context.Context, userID string, before models.Result) (*int, error)
 }
 
 // resultRepositoryImpl はResultRepositoryインターフェースの実装です。
@@ -33,50 +45,116 @@ func NewResultRepository(db *sql.DB) ResultRepository {
 	return &resultRepositoryImpl{db: db}
 }
 
+// foreignKeyViolation は外部キー制約違反を表すPostgreSQLのエラーコードです。
+const foreignKeyViolation = "23503"
+
 // CreateResult は新しいゲーム結果レコードを作成します。
-func (r *resultRepositoryImpl) CreateResult(tx *sql.Tx, userID string, score int) (*models.Result, error) {
+// 保存前に users テーブルにユーザーが存在するか確認し、存在しない場合は ErrUserNotFound を返します
+// （外部キー制約のDBエラーより原因を特定しやすくするため）。
+// 存在チェックとINSERTは1つのトランザクションで行い、チェックしたユーザー行を FOR SHARE でロックして
+// コミットまでの間に削除されるレースを防ぎます。tx が nil の場合はこのメソッド内でトランザクションを開始・コミットします。
+// key（冪等キー）が保存済みの場合は ON CONFLICT で挿入せず、既存のレコードを返します。
+// コミットは成功したのにエラーが返った保存を再試行しても、同じスコアが二重に記録されません。
+func (r *resultRepositoryImpl) CreateResult(ctx context.Context, tx *sql.Tx, userID string, score int, mode models.GameMode, flagged bool, key string) (*models.Result, error) {
+	// users.id はUUIDのため、ゲスト・テスト用のIDなどUUIDでないものはクエリを投げずに弾く
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, fmt.Errorf("%w: ユーザーID %q はUUID形式ではありません", ErrUserNotFound, userID)
+	}
+	if mode == "" {
+		mode = models.DefaultGameMode
+	}
+
+	if tx != nil {
+		return r.createResultTx(ctx, tx, userID, score, mode, flagged, key)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("トランザクションの開始に失敗しました: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := r.createResultTx(ctx, tx, userID, score, mode, flagged, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("トランザクションのコミットに失敗しました: %w", err)
+	}
+	return result, nil
+}
+
+// createResultTx はトランザクション内でユーザーの存在を確認し、ゲーム結果レコードを作成します。
+func (r *resultRepositoryImpl) createResultTx(ctx context.Context, tx *sql.Tx, userID string, score int, mode models.GameMode, flagged bool, key string) (*models.Result, error) {
+	var exists int
+	err := tx.QueryRowContext(ctx, "SELECT 1 FROM users WHERE id = $1 FOR SHARE", userID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: ユーザーID %s", ErrUserNotFound, userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ユーザーの存在確認に失敗しました: %w", err)
+	}
+
 	now := time.Now()
 	var id int64
-	
-	// トランザクションの有無を確認して適切にクエリを実行
-	var row *sql.Row
-	if tx != nil {
-		row = tx.QueryRow(
-			"INSERT INTO results (user_id, score, created_at) VALUES ($1, $2, $3) RETURNING id",
-			userID, score, now,
-		)
-	} else {
-		row = r.db.QueryRow(
-			"INSERT INTO results (user_id, score, created_at) VALUES ($1, $2, $3) RETURNING id",
-			userID, score, now,
-		)
+	// 冪等キーが空の場合は NULL として保存する（NULL同士はユニーク制約で衝突しない）
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO results (user_id, score, game_mode, flagged, created_at, idempotency_key)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+		ON CONFLICT (idempotency_key) DO NOTHING
+		RETURNING id`,
+		userID, score, string(mode), flagged, now, key,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		// 同じ冪等キーで保存済み（前回の保存はコミットされていた）
+		return r.getResultByKey(ctx, tx, key)
 	}
-	
-	err := row.Scan(&id)
 	if err != nil {
+		// 行ロックで防げないケースでも、外部キー制約違反は同じエラーとして扱う
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			return nil, fmt.Errorf("%w: ユーザーID %s", ErrUserNotFound, userID)
+		}
 		return nil, fmt.Errorf("ゲーム結果レコードの作成に失敗しました: %w", err)
 	}
-	
+
 	return &models.Result{
 		ID:        id,
 		UserID:    userID,
 		Score:     score,
+		GameMode:  mode,
+		Flagged:   flagged,
 		CreatedAt: now,
 	}, nil
 }
 
+// getResultByKey はトランザクション内で冪等キーが一致するゲーム結果レコードを取得します。
+func (r *resultRepositoryImpl) getResultByKey(ctx context.Context, tx *sql.Tx, key string) (*models.Result, error) {
+	var result models.Result
+	err := tx.QueryRowContext(ctx,
+		"SELECT id, user_id, score, game_mode, flagged, created_at FROM results WHERE idempotency_key = $1",
+		key,
+	).Scan(&result.ID, &result.UserID, &result.Score, &result.GameMode, &result.Flagged, &result.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("保存済みのゲーム結果レコードの取得に失敗しました: %w", err)
+	}
+	return &result, nil
+}
+
 // GetTopResults は上位N件の結果を取得します（ランキング用）。
-func (r *resultRepositoryImpl) GetTopResults(limit int) ([]models.ResultResponse, error) {
+// mode を指定した場合はその種別の結果だけで順位を付けます（空の場合はすべての種別）。
+func (r *resultRepositoryImpl) GetTopResults(ctx context.Context, limit int, mode models.GameMode) ([]models.ResultResponse, error) {
 	query := `
 		SELECT 
-			id, user_id, score, created_at,
+			id, user_id, score, game_mode, flagged, created_at,
 			ROW_NUMBER() OVER (ORDER BY score DESC, created_at ASC) as rank
 		FROM results 
+		WHERE $2 = '' OR game_mode = $2
 		ORDER BY score DESC, created_at ASC
 		LIMIT $1
 	`
 	
-	rows, err := r.db.Query(query, limit)
+	rows, err := r.db.QueryContext(ctx, query, limit, string(mode))
 	if err != nil {
 		return nil, fmt.Errorf("ゲーム結果取得に失敗しました: %w", err)
 	}
@@ -85,7 +163,7 @@ func (r *resultRepositoryImpl) GetTopResults(limit int) ([]models.ResultResponse
 	var results []models.ResultResponse
 	for rows.Next() {
 		var result models.ResultResponse
-		err := rows.Scan(&result.ID, &result.UserID, &result.Score, &result.CreatedAt, &result.Rank)
+		err := rows.Scan(&result.ID, &result.UserID, &result.Score, &result.GameMode, &result.Flagged, &result.CreatedAt, &result.Rank)
 		if err != nil {
 			return nil, fmt.Errorf("ゲーム結果データのスキャンに失敗しました: %w", err)
 		}
@@ -100,19 +178,20 @@ func (r *resultRepositoryImpl) GetTopResults(limit int) ([]models.ResultResponse
 }
 
 // GetUserBestScore は指定したユーザーの最高スコアを取得します。
-func (r *resultRepositoryImpl) GetUserBestScore(userID string) (*models.Result, error) {
+// mode を指定した場合はその種別の結果だけから選びます（空の場合はすべての種別）。
+func (r *resultRepositoryImpl) GetUserBestScore(ctx context.Context, userID string, mode models.GameMode) (*models.Result, error) {
 	query := `
-		SELECT id, user_id, score, created_at
+		SELECT id, user_id, score, game_mode, flagged, created_at
 		FROM results 
-		WHERE user_id = $1 
+		WHERE user_id = $1 AND ($2 = '' OR game_mode = $2)
 		ORDER BY score DESC, created_at ASC
 		LIMIT 1
 	`
 	
-	row := r.db.QueryRow(query, userID)
+	row := r.db.QueryRowContext(ctx, query, userID, string(mode))
 	
 	var result models.Result
-	err := row.Scan(&result.ID, &result.UserID, &result.Score, &result.CreatedAt)
+	err := row.Scan(&result.ID, &result.UserID, &result.Score, &result.GameMode, &result.Flagged, &result.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil // ユーザーのスコアが存在しない場合はnilを返す
 	}
@@ -124,9 +203,10 @@ func (r *resultRepositoryImpl) GetUserBestScore(userID string) (*models.Result,
 }
 
 // GetUserRanking は指定したユーザーの現在のランキング順位を取得します。
-func (r *resultRepositoryImpl) GetUserRanking(userID string) (*models.ResultResponse, error) {
+// mode を指定した場合は GetTopResults と同じくその種別の結果だけで順位を付けます（空の場合はすべての種別）。
+func (r *resultRepositoryImpl) GetUserRanking(ctx context.Context, userID string, mode models.GameMode) (*models.ResultResponse, error) {
 	// ユーザーの最高スコアを先に取得
-	bestScore, err := r.GetUserBestScore(userID)
+	bestScore, err := r.GetUserBestScore(ctx, userID, mode)
 	if err != nil {
 		return nil, err
 	}
@@ -138,11 +218,11 @@ func (r *resultRepositoryImpl) GetUserRanking(userID string) (*models.ResultResp
 	query := `
 		SELECT COUNT(*) + 1 as rank
 		FROM results 
-		WHERE score > $1 OR (score = $1 AND created_at < $2)
+		WHERE ($3 = '' OR game_mode = $3) AND (score > $1 OR (score = $1 AND created_at < $2))
 	`
 	
 	var rank int
-	err = r.db.QueryRow(query, bestScore.Score, bestScore.CreatedAt).Scan(&rank)
+	err = r.db.QueryRowContext(ctx, query, bestScore.Score, bestScore.CreatedAt, string(mode)).Scan(&rank)
 	if err != nil {
 		return nil, fmt.Errorf("ユーザーランキング順位の計算に失敗しました: %w", err)
 	}
@@ -151,7 +231,62 @@ func (r *resultRepositoryImpl) GetUserRanking(userID string) (*models.ResultResp
 		ID:        bestScore.ID,
 		UserID:    bestScore.UserID,
 		Score:     bestScore.Score,
+		GameMode:  bestScore.GameMode,
+		Flagged:   bestScore.Flagged,
 		CreatedAt: bestScore.CreatedAt,
 		Rank:      rank,
 	}, nil
-} 
\ No newline at end of file
+}
+
+// GetUserResultsPage は指定したユーザーの結果を created_at DESC で offset 件目からN件取得します。
+// 同時刻のリザルトはIDの降順で並べ、ページ間で順序がぶれないようにします。
+func (r *resultRepositoryImpl) GetUserResultsPage(ctx context.Context, userID string, limit, offset int) ([]models.Result, error) {
+	query := `
+		SELECT id, user_id, score, game_mode, flagged, created_at
+		FROM results
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("ユーザーのゲーム結果履歴取得に失敗しました: %w", err)
+	}
+	defer rows.Close()
+
+	results := []models.Result{}
+	for rows.Next() {
+		var result models.Result
+		if err := rows.Scan(&result.ID, &result.UserID, &result.Score, &result.GameMode, &result.Flagged, &result.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ゲーム結果データのスキャンに失敗しました: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ユーザーのゲーム結果履歴取得中にエラーが発生しました: %w", err)
+	}
+
+	return results, nil
+}
+
+// GetUserBestScoreBefore は指定したリザルトより前に記録されたユーザーの最高スコアを取得します。
+// それ以前のリザルトが存在しない場合は nil を返します。
+func (r *resultRepositoryImpl) GetUserBestScoreBefore(ctx context.Context, userID string, before models.Result) (*int, error) {
+	query := `
+		SELECT MAX(score)
+		FROM results
+		WHERE user_id = $1 AND (created_at < $2 OR (created_at = $2 AND id < $3))
+	`
+
+	var best sql.NullInt64
+	if err := r.db.QueryRowContext(ctx, query, userID, before.CreatedAt, before.ID).Scan(&best); err != nil {
+		return nil, fmt.Errorf("ユーザーの過去の最高スコア取得に失敗しました: %w", err)
+	}
+	if !best.Valid {
+		return nil, nil
+	}
+	score := int(best.Int64)
+	return &score, nil
+}
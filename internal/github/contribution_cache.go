@@ -0,0 +1,130 @@
+package github
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+)
+
+// DefaultContributionCacheTTL はGitHubから取得したContributionカレンダーをキャッシュする期間です。
+// 草は1日単位でしか変わらないため、短時間に同じユーザーを何度取得してもGitHub APIを消費しないようにします。
+const DefaultContributionCacheTTL = 10 * time.Minute
+
+// DefaultContributionCacheMaxEntries はキャッシュに保持するカレンダー数の上限です。
+// 無認証のエンドポイントから任意のユーザー名を指定できるため、件数を制限してメモリを使い切らないようにします。
+const DefaultContributionCacheMaxEntries = 1000
+
+// contributionCacheEntry はキャッシュされたカレンダーと有効期限です。
+type contributionCacheEntry struct {
+	calendar  *models.ContributionCalendar
+	expiresAt time.Time
+}
+
+// ContributionCache はGitHubユーザー名と期間ごとにContributionカレンダーを保持するTTL付きのインメモリキャッシュです。
+// 保持する件数が上限に達した場合は、期限切れのエントリを削除し、それでも空かなければ有効期限が最も近い（最も古い）エントリを削除します。
+type ContributionCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int // 保持するエントリ数の上限（0で無制限）
+	entries    map[string]contributionCacheEntry
+	now        func() time.Time // テストで時刻を差し替えるための関数
+}
+
+// NewContributionCache は ttl の間、最大 DefaultContributionCacheMaxEntries 件のカレンダーを保持するキャッシュを作成します。
+func NewContributionCache(ttl time.Duration) *ContributionCache {
+	return NewContributionCacheWithLimit(ttl, DefaultContributionCacheMaxEntries)
+}
+
+// NewContributionCacheWithLimit は ttl の間、最大 maxEntries 件（0で無制限）のカレンダーを保持するキャッシュを作成します。
+func NewContributionCacheWithLimit(ttl time.Duration, maxEntries int) *ContributionCache {
+	return &ContributionCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]contributionCacheEntry),
+		now:        time.Now,
+	}
+}
+
+// contributionCacheKey はユーザー名（GitHubでは大文字小文字を区別しない）と期間からキャッシュキーを作ります。
+func contributionCacheKey(username string, startDate, endDate time.Time) string {
+	return strings.ToLower(username) + "|" +
+		startDate.In(models.JST).Format(models.ContributionDateLayout) + "|" +
+		endDate.In(models.JST).Format(models.ContributionDateLayout)
+}
+
+// Get はキャッシュされた有効なカレンダーを返します。期限切れのエントリは削除します。
+func (c *ContributionCache) Get(key string) (*models.ContributionCalendar, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.calendar, true
+}
+
+// Set はカレンダーをキャッシュに保存します。上限に達している場合は先に古いエントリを削除します。
+func (c *ContributionCache) Set(key string, calendar *models.ContributionCalendar) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if _, exists := c.entries[key]; !exists && c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		c.evictLocked(now)
+	}
+	c.entries[key] = contributionCacheEntry{calendar: calendar, expiresAt: now.Add(c.ttl)}
+}
+
+// Len はキャッシュに保持しているエントリ数（期限切れで未削除のものを含む）を返します。
+func (c *ContributionCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// evictLocked は新しいエントリを入れる空きを作ります。
+// 期限切れのエントリをすべて削除し、それでも上限に達している場合は有効期限が最も近いエントリを1件削除します。
+// TTLは一律なので、有効期限が最も近いエントリは最も古く保存されたエントリです。c.mu を保持した状態で呼び出してください。
+func (c *ContributionCache) evictLocked(now time.Time) {
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	if len(c.entries) < c.maxEntries {
+		return
+	}
+
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if oldestKey == "" || entry.expiresAt.Before(oldest) {
+			oldestKey, oldest = key, entry.expiresAt
+		}
+	}
+	delete(c.entries, oldestKey)
+}
+
+// GetContributionCalendarCached はキャッシュを通してContributionカレンダーを取得します。
+// キャッシュにない場合のみGitHub APIを呼び出し、成功した結果をキャッシュします。
+// 返り値のカレンダーはキャッシュと共有されるため、呼び出し側で変更しないでください。
+func (s *GitHubService) GetContributionCalendarCached(username, token string, startDate, endDate time.Time) (*models.ContributionCalendar, error) {
+	key := contributionCacheKey(username, startDate, endDate)
+	if calendar, ok := s.cache.Get(key); ok {
+		return calendar, nil
+	}
+
+	calendar, err := s.GetContributionCalendar(username, token, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Set(key, calendar)
+	return calendar, nil
+}
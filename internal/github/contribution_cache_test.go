@@ -0,0 +1,88 @@
+package github
+
+import (
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestContributionCache は時刻を *now で差し替えたキャッシュを作成します。
+func newTestContributionCache(ttl time.Duration, maxEntries int, now *time.Time) *ContributionCache {
+	c := NewContributionCacheWithLimit(ttl, maxEntries)
+	c.now = func() time.Time { return *now }
+	return c
+}
+
+// TestContributionCache_HitAndExpiry はTTLの間はキャッシュを返し、期限が切れたエントリは削除することをテストします。
+func TestContributionCache_HitAndExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := newTestContributionCache(time.Minute, 10, &now)
+	calendar := &models.ContributionCalendar{TotalContributions: 3}
+
+	c.Set("octocat", calendar)
+	got, ok := c.Get("octocat")
+	assert.True(t, ok)
+	assert.Same(t, calendar, got)
+
+	now = now.Add(time.Minute)
+	_, ok = c.Get("octocat")
+	assert.False(t, ok, "TTLが経過したエントリは返さない")
+	assert.Equal(t, 0, c.Len(), "期限切れのエントリは削除される")
+}
+
+// TestContributionCache_Eviction は上限に達した場合、期限切れのエントリ、次に最も古いエントリから削除することをテストします。
+func TestContributionCache_Eviction(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := newTestContributionCache(time.Minute, 2, &now)
+
+	c.Set("a", &models.ContributionCalendar{})
+	now = now.Add(10 * time.Second)
+	c.Set("b", &models.ContributionCalendar{})
+	now = now.Add(10 * time.Second)
+	c.Set("c", &models.ContributionCalendar{})
+
+	assert.Equal(t, 2, c.Len())
+	_, ok := c.Get("a")
+	assert.False(t, ok, "最も古いエントリが削除される")
+	_, ok = c.Get("b")
+	assert.True(t, ok)
+
+	// 既存のキーの更新では削除しない
+	now = now.Add(5 * time.Second)
+	c.Set("b", &models.ContributionCalendar{})
+	assert.Equal(t, 2, c.Len())
+
+	// 期限切れのエントリがあれば、有効なエントリは残す
+	now = now.Add(57 * time.Second) // 更新した b は有効、c は期限切れ
+	c.Set("d", &models.ContributionCalendar{})
+	_, ok = c.Get("b")
+	assert.True(t, ok)
+	_, ok = c.Get("d")
+	assert.True(t, ok)
+	assert.Equal(t, 2, c.Len())
+}
+
+// TestGetContributionCalendarCached はキャッシュにある間はGitHub APIを呼び出さないことをテストします。
+func TestGetContributionCalendarCached(t *testing.T) {
+	var calls int32
+	s := newTestGitHubService(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		io.WriteString(w, `{"data":{"user":{"contributionsCollection":{"contributionCalendar":{
+			"totalContributions":2,"weeks":[{"contributionDays":[{"date":"2024-01-01","contributionCount":2}]}]
+		}}}}}`)
+	})
+
+	first, err := s.GetContributionCalendarCached("octocat", "", testStart, testEnd)
+	assert.NoError(t, err)
+	second, err := s.GetContributionCalendarCached("OctoCat", "", testStart, testEnd)
+	assert.NoError(t, err)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "大文字小文字が違っても同じキャッシュを使う")
+	assert.Same(t, first, second)
+	assert.Equal(t, 2, second.TotalContributions)
+}
@@ -0,0 +1,113 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+)
+
+// DefaultRangeRequestInterval は GetContributionsForRange で分割したリクエストの間に空ける時間のデフォルト値です。
+// GitHubのセカンダリレート制限（短時間の連続リクエスト）に引っかからないよう、1リクエストずつ間を空けます。
+const DefaultRangeRequestInterval = 1 * time.Second
+
+// ErrPartialContributions は分割リクエストの途中で失敗し、取得済みの期間の貢献データだけを返した場合のエラーです。
+// 失敗の原因（ErrRateLimited など）も errors.Is で判定できます。
+var ErrPartialContributions = errors.New("一部の期間の貢献データを取得できませんでした")
+
+// RangeOptions は GetContributionsForRangeWithOptions の挙動の設定です。
+type RangeOptions struct {
+	Interval     time.Duration // 分割したリクエストの間に空ける時間（0で空けない）
+	AllowPartial bool          // 途中で失敗した場合に、取得済みの分を ErrPartialContributions とともに返すか（false の場合は全体を失敗とする）
+}
+
+// DefaultRangeOptions は GetContributionsForRange が使う設定です（途中で失敗した場合は全体を失敗とします）。
+func DefaultRangeOptions() RangeOptions {
+	return RangeOptions{Interval: DefaultRangeRequestInterval}
+}
+
+// GetContributionsForRange は1年を超える期間の貢献データを取得します。
+// GitHub APIは1リクエストで1年までしか取得できないため、1年ごとに分割してリクエストし、日付順にマージして返します。
+// 途中で失敗した場合は全体を失敗とします（取得済みの分が必要な場合は GetContributionsForRangeWithOptions を使います）。
+func (s *GitHubService) GetContributionsForRange(username, token string, from, to time.Time) ([]models.DailyContribution, error) {
+	return s.GetContributionsForRangeWithOptions(username, token, from, to, DefaultRangeOptions())
+}
+
+// GetContributionsForRangeWithOptions は期間を1年ごとに分割して貢献データを取得し、日付順にマージして返します。
+// 分割の境界や GitHub のカレンダーの週区切りで同じ日付が複数の区間に含まれても、1日1件になるようにマージします。
+//
+// Parameters:
+//   username : GitHubのユーザー名
+//   token    : GitHub Personal Access Token
+//   from     : 期間の開始（ContributionPeriod と同じく初日の0時を想定）
+//   to       : 期間の終了（最終日の23:59:59を想定）
+//   opts     : リクエストの間隔と、途中で失敗した場合の扱い
+// Returns:
+//   []models.DailyContribution: 日付の昇順の貢献データ（AllowPartial で途中で失敗した場合は取得済みの分）
+//   error: 取得に失敗した場合（AllowPartial の場合は ErrPartialContributions と原因のエラーをラップ）
+func (s *GitHubService) GetContributionsForRangeWithOptions(username, token string, from, to time.Time, opts RangeOptions) ([]models.DailyContribution, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("期間の終了 %s が開始 %s より前です", to.Format(time.RFC3339), from.Format(time.RFC3339))
+	}
+
+	chunks := splitContributionRange(from, to)
+	log.Printf("GitHubService: ユーザー '%s' の貢献データを %d 回に分けて取得します。期間: %s から %s", username, len(chunks), from.Format(models.ContributionDateLayout), to.Format(models.ContributionDateLayout))
+
+	var fetched [][]models.DailyContribution
+	for i, chunk := range chunks {
+		if i > 0 && opts.Interval > 0 {
+			time.Sleep(opts.Interval)
+		}
+		days, err := s.GetDailyContributions(username, token, chunk[0], chunk[1])
+		if err != nil {
+			if !opts.AllowPartial || len(fetched) == 0 {
+				return nil, err
+			}
+			log.Printf("GitHubService Warn: %d/%d 回目の取得に失敗したため、取得済みの分を返します: %v", i+1, len(chunks), err)
+			return mergeContributions(chunks[:len(fetched)], fetched), fmt.Errorf("%w: %s から %s: %w", ErrPartialContributions,
+				chunk[0].In(models.JST).Format(models.ContributionDateLayout), to.In(models.JST).Format(models.ContributionDateLayout), err)
+		}
+		fetched = append(fetched, days)
+	}
+	return mergeContributions(chunks, fetched), nil
+}
+
+// splitContributionRange は期間を GitHub API が1リクエストで受け付ける1年以内の区間に分割します。
+// 各区間は前の区間の終了の1秒後から始まり、区間同士は重なりません。
+func splitContributionRange(from, to time.Time) [][2]time.Time {
+	var chunks [][2]time.Time
+	for start := from; !start.After(to); {
+		end := start.AddDate(1, 0, 0).Add(-time.Second)
+		if end.After(to) {
+			end = to
+		}
+		chunks = append(chunks, [2]time.Time{start, end})
+		start = end.Add(time.Second)
+	}
+	return chunks
+}
+
+// mergeContributions は分割して取得した貢献データを日付の昇順にマージします。
+// GitHub のカレンダーは週単位で区間の外の日付も返すため、各区間の結果からはその区間（JSTの日付）に含まれる日付だけを使います。
+// 区間同士は重ならないので、同じ日付が複数回含まれることはありません。
+func mergeContributions(chunks [][2]time.Time, fetched [][]models.DailyContribution) []models.DailyContribution {
+	var merged []models.DailyContribution
+	seen := make(map[string]bool)
+	for i, days := range fetched {
+		first := chunks[i][0].In(models.JST).Format(models.ContributionDateLayout)
+		last := chunks[i][1].In(models.JST).Format(models.ContributionDateLayout)
+		for _, day := range days {
+			// YYYY-MM-DD 形式なので文字列の比較で日付の前後を判定できる
+			if day.Date < first || day.Date > last || seen[day.Date] {
+				continue
+			}
+			seen[day.Date] = true
+			merged = append(merged, day)
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Date < merged[j].Date })
+	return merged
+}
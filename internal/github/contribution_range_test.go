@@ -0,0 +1,134 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// rangeTestServer は分割リクエストを記録し、リクエストされた期間の日付（前後1日ずつはみ出したもの）を返すテスト用のハンドラーです。
+// failAt 回目（1始まり、0で失敗しない）のリクエストはレート制限で失敗させます。
+type rangeTestServer struct {
+	mu       sync.Mutex
+	requests []Variables
+	failAt   int
+}
+
+func (rs *rangeTestServer) handle(w http.ResponseWriter, r *http.Request) {
+	var body GraphQLQuery
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rs.mu.Lock()
+	rs.requests = append(rs.requests, body.Variables)
+	n := len(rs.requests)
+	rs.mu.Unlock()
+
+	if n == rs.failAt {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+
+	from, _ := time.Parse(time.RFC3339, body.Variables.From)
+	to, _ := time.Parse(time.RFC3339, body.Variables.To)
+	// GitHubのカレンダーは週単位で返るため、区間の前後の日付が重複して返るケースを再現する
+	var days []string
+	for d := from.In(models.JST).AddDate(0, 0, -1); !d.After(to.In(models.JST).AddDate(0, 0, 1)); d = d.AddDate(0, 0, 1) {
+		days = append(days, fmt.Sprintf(`{"date":"%s","contributionCount":%d}`, d.Format(models.ContributionDateLayout), n))
+	}
+	fmt.Fprintf(w, `{"data":{"user":{"contributionsCollection":{"contributionCalendar":{"totalContributions":0,"weeks":[{"contributionDays":[%s]}]}}}}}`, strings.Join(days, ","))
+}
+
+// rangeTestPeriod は2022-01-01〜2024-06-30（JST）の2年半の期間です。1年ごとに3回に分割されます。
+var (
+	rangeTestFrom = time.Date(2022, 1, 1, 0, 0, 0, 0, models.JST)
+	rangeTestTo   = time.Date(2024, 6, 30, 23, 59, 59, 0, models.JST)
+)
+
+// TestSplitContributionRange は期間を重ならない1年以内の区間に分割することをテストします。
+func TestSplitContributionRange(t *testing.T) {
+	chunks := splitContributionRange(rangeTestFrom, rangeTestTo)
+
+	assert.Equal(t, [][2]time.Time{
+		{rangeTestFrom, time.Date(2022, 12, 31, 23, 59, 59, 0, models.JST)},
+		{time.Date(2023, 1, 1, 0, 0, 0, 0, models.JST), time.Date(2023, 12, 31, 23, 59, 59, 0, models.JST)},
+		{time.Date(2024, 1, 1, 0, 0, 0, 0, models.JST), rangeTestTo},
+	}, chunks)
+
+	single := splitContributionRange(rangeTestFrom, rangeTestFrom.AddDate(0, 0, 7))
+	assert.Len(t, single, 1, "1年以内の期間は分割しないはず")
+}
+
+// TestGetContributionsForRange_MergesChunks は複数の区間に分割してリクエストし、重複の無い日付順の結果にマージすることをテストします。
+func TestGetContributionsForRange_MergesChunks(t *testing.T) {
+	rs := &rangeTestServer{}
+	s := newTestGitHubService(t, rs.handle)
+
+	days, err := s.GetContributionsForRangeWithOptions("octocat", "", rangeTestFrom, rangeTestTo, RangeOptions{})
+
+	assert.NoError(t, err)
+	assert.Len(t, rs.requests, 3)
+	wantDays := int(rangeTestTo.Sub(rangeTestFrom).Hours()/24) + 1
+	assert.Len(t, days, wantDays, "境界の日付が重複せず、期間外の日付も含まないはず")
+	assert.Equal(t, "2022-01-01", days[0].Date)
+	assert.Equal(t, "2024-06-30", days[len(days)-1].Date)
+	for i := 1; i < len(days); i++ {
+		assert.Less(t, days[i-1].Date, days[i].Date)
+	}
+
+	byDate := make(map[string]int)
+	for _, day := range days {
+		byDate[day.Date] = day.Count
+	}
+	assert.Equal(t, 1, byDate["2022-12-31"])
+	assert.Equal(t, 2, byDate["2023-01-01"])
+	assert.Equal(t, 3, byDate["2024-01-01"])
+}
+
+// TestGetContributionsForRange_Failure は途中で失敗した場合、AllowPartial に応じて全体を失敗にするか、
+// 取得済みの分を ErrPartialContributions とともに返すことをテストします。
+func TestGetContributionsForRange_Failure(t *testing.T) {
+	t.Run("全体を失敗にする", func(t *testing.T) {
+		rs := &rangeTestServer{failAt: 2}
+		s := newTestGitHubService(t, rs.handle)
+
+		days, err := s.GetContributionsForRangeWithOptions("octocat", "", rangeTestFrom, rangeTestTo, RangeOptions{})
+
+		assert.ErrorIs(t, err, ErrRateLimited)
+		assert.NotErrorIs(t, err, ErrPartialContributions)
+		assert.Nil(t, days)
+		assert.Len(t, rs.requests, 2, "失敗した後はリクエストしないはず")
+	})
+
+	t.Run("取得済みの分を返す", func(t *testing.T) {
+		rs := &rangeTestServer{failAt: 2}
+		s := newTestGitHubService(t, rs.handle)
+
+		days, err := s.GetContributionsForRangeWithOptions("octocat", "", rangeTestFrom, rangeTestTo, RangeOptions{AllowPartial: true})
+
+		assert.ErrorIs(t, err, ErrPartialContributions)
+		assert.ErrorIs(t, err, ErrRateLimited)
+		assert.Len(t, days, 365)
+		assert.Equal(t, "2022-12-31", days[len(days)-1].Date)
+	})
+
+	t.Run("最初の区間で失敗した場合は部分的な結果にしない", func(t *testing.T) {
+		rs := &rangeTestServer{failAt: 1}
+		s := newTestGitHubService(t, rs.handle)
+
+		days, err := s.GetContributionsForRangeWithOptions("octocat", "", rangeTestFrom, rangeTestTo, RangeOptions{AllowPartial: true})
+
+		assert.ErrorIs(t, err, ErrRateLimited)
+		assert.NotErrorIs(t, err, ErrPartialContributions)
+		assert.Nil(t, days)
+	})
+}
@@ -0,0 +1,136 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUserNotFound is returned when the GitHub user does not exist (user: null / NOT_FOUND error).
+	ErrUserNotFound = errors.New("GitHubユーザーが見つかりません")
+	// ErrRateLimited はGitHub APIのレート制限（プライマリ・セカンダリ）に達した場合のエラーです。
+	// 再試行までの待ち時間が分かる場合は *RateLimitError として返します。
+	ErrRateLimited = errors.New("GitHub APIのレート制限に達しました")
+	// ErrBadCredentials はトークンが無効・期限切れ、または必要なスコープが無い場合のエラーです（サーバーの設定ミス）。
+	ErrBadCredentials = errors.New("GitHubのトークンが無効か、権限が不足しています")
+	// ErrGitHubAPI は上記のいずれにも当てはまらないGitHub APIのエラーです。
+	ErrGitHubAPI = errors.New("GitHub APIがエラーを返しました")
+)
+
+// DefaultRateLimitRetryAfter はレート制限の応答に待ち時間の手がかりが無い場合に返す再試行までの時間です。
+const DefaultRateLimitRetryAfter = 60 * time.Second
+
+// RateLimitError はレート制限に達した場合のエラーで、再試行までの待ち時間を持ちます。
+// errors.Is(err, ErrRateLimited) で判定できます。
+type RateLimitError struct {
+	RetryAfter time.Duration // 再試行できるまでの時間（Retry-After または X-RateLimit-Reset から計算）
+	Message    string        // GitHubが返したメッセージ
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%v (%v後に再試行できます): %s", ErrRateLimited, e.RetryAfter, e.Message)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// GraphQLError はGitHub GraphQL APIのレスポンスの errors 配列の要素です。
+// type は "NOT_FOUND"・"RATE_LIMITED"・"FORBIDDEN"・"INSUFFICIENT_SCOPES" などです。
+type GraphQLError struct {
+	Type      string `json:"type"`
+	Message   string `json:"message"`
+	Locations []struct {
+		Line   int `json:"line"`
+		Column int `json:"column"`
+	} `json:"locations"`
+	Path []interface{} `json:"path"`
+}
+
+// isRateLimitMessage はメッセージがレート制限（セカンダリレート制限を含む）を表すかどうかを返します。
+func isRateLimitMessage(message string) bool {
+	return strings.Contains(strings.ToLower(message), "rate limit")
+}
+
+// rateLimitRetryAfter はレスポンスヘッダーから再試行までの時間を求めます。
+// Retry-After（秒）を優先し、無ければ X-RateLimit-Reset（Unix秒）までの時間、どちらも無ければ DefaultRateLimitRetryAfter です。
+func rateLimitRetryAfter(header http.Header, now time.Time) time.Duration {
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		if wait := time.Unix(reset, 0).Sub(now); wait > 0 {
+			return wait.Round(time.Second)
+		}
+	}
+	return DefaultRateLimitRetryAfter
+}
+
+// classifyHTTPError は200以外のHTTPレスポンスをセンチネルエラーに対応付けます。
+// GitHubのRESTと同じ {"message": "...", "documentation_url": "..."} 形式のボディを解析します。
+//
+// Parameters:
+//   statusCode : HTTPステータスコード
+//   header     : レスポンスヘッダー（レート制限の残数・リセット時刻の判定に使う）
+//   body       : レスポンスボディ
+// Returns:
+//   error: 401 は ErrBadCredentials、レート制限の403・429は *RateLimitError、それ以外は ErrGitHubAPI
+func classifyHTTPError(statusCode int, header http.Header, body []byte) error {
+	var errBody struct {
+		Message string `json:"message"`
+	}
+	message := string(body)
+	if json.Unmarshal(body, &errBody) == nil && errBody.Message != "" {
+		message = errBody.Message
+	}
+
+	switch {
+	case statusCode == http.StatusUnauthorized:
+		return fmt.Errorf("%w (ステータス: %d): %s", ErrBadCredentials, statusCode, message)
+	case statusCode == http.StatusTooManyRequests,
+		statusCode == http.StatusForbidden && (header.Get("X-RateLimit-Remaining") == "0" || header.Get("Retry-After") != "" || isRateLimitMessage(message)):
+		return &RateLimitError{RetryAfter: rateLimitRetryAfter(header, time.Now()), Message: message}
+	default:
+		return fmt.Errorf("%w (ステータス: %d): %s", ErrGitHubAPI, statusCode, message)
+	}
+}
+
+// classifyGraphQLErrors はGraphQLの errors 配列をセンチネルエラーに対応付けます。
+// 複数のエラーがある場合は、再試行の判断に影響の大きい順（レート制限 → 認証 → ユーザー不在）に優先します。
+//
+// Parameters:
+//   errs     : レスポンスの errors 配列（1件以上）
+//   header   : レスポンスヘッダー（レート制限の待ち時間の計算に使う）
+//   username : 取得対象のGitHubユーザー名（エラーメッセージ用）
+func classifyGraphQLErrors(errs []GraphQLError, header http.Header, username string) error {
+	messages := make([]string, 0, len(errs))
+	var rateLimited, badCredentials, notFound bool
+	for _, e := range errs {
+		messages = append(messages, e.Message)
+		switch {
+		case e.Type == "RATE_LIMITED" || isRateLimitMessage(e.Message):
+			rateLimited = true
+		case e.Type == "INSUFFICIENT_SCOPES" || e.Type == "UNAUTHORIZED":
+			badCredentials = true
+		case e.Type == "NOT_FOUND":
+			notFound = true
+		}
+	}
+	joined := strings.Join(messages, "; ")
+
+	switch {
+	case rateLimited:
+		return &RateLimitError{RetryAfter: rateLimitRetryAfter(header, time.Now()), Message: joined}
+	case badCredentials:
+		return fmt.Errorf("%w: %s", ErrBadCredentials, joined)
+	case notFound:
+		return fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	default:
+		return fmt.Errorf("%w: GraphQLエラー: %s", ErrGitHubAPI, joined)
+	}
+}
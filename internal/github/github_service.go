@@ -7,6 +7,7 @@ import (
 	"io"
 	"log" // log パッケージを追加
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
@@ -22,16 +23,44 @@ type DailyContribution struct {
 type GitHubService struct {
 	githubAPIURL string
 	client       *http.Client
+	cache        *ContributionCache // GetContributionCalendarCached 用のキャッシュ
 }
 
+// DefaultGitHubAPIURL はGitHub GraphQL APIのエンドポイントです。
+const DefaultGitHubAPIURL = "https://api.github.com/graphql"
+
+// DefaultHTTPTimeout はGitHub APIを呼び出すデフォルトのHTTPクライアントのタイムアウトです。
+const DefaultHTTPTimeout = 10 * time.Second
+
 // NewGitHubService creates a new instance of GitHubService.
+// 環境変数 GITHUB_API_URL が設定されていれば、そのエンドポイント（GitHub Enterprise の GraphQL API など）を使います。
 func NewGitHubService() *GitHubService {
+	s := NewGitHubServiceWithClient(&http.Client{Timeout: DefaultHTTPTimeout})
+	if apiURL := os.Getenv("GITHUB_API_URL"); apiURL != "" {
+		s.SetAPIURL(apiURL)
+	}
+	return s
+}
+
+// NewGitHubServiceWithClient は指定したHTTPクライアントでGitHub APIを呼び出す GitHubService を作成します。
+// テストでは httptest.Server を向いたクライアントやモックの RoundTripper を注入できます。
+// client が nil の場合はデフォルトのクライアントを使います。
+func NewGitHubServiceWithClient(client *http.Client) *GitHubService {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultHTTPTimeout}
+	}
 	return &GitHubService{
-		githubAPIURL: "https://api.github.com/graphql",
-		client:       &http.Client{Timeout: 10 * time.Second},
+		githubAPIURL: DefaultGitHubAPIURL,
+		client:       client,
+		cache:        NewContributionCache(DefaultContributionCacheTTL),
 	}
 }
 
+// SetAPIURL はGraphQL APIのエンドポイントを変更します（GitHub Enterprise やテスト用サーバー向け）。
+func (s *GitHubService) SetAPIURL(apiURL string) {
+	s.githubAPIURL = apiURL
+}
+
 // GraphQLQuery represents the structure of the GraphQL request body.
 type GraphQLQuery struct {
 	Query     string    `json:"query"`
@@ -51,6 +80,7 @@ type GitHubGraphQLResponse struct {
 		User *struct { // user が null になる可能性があるのでポインタにする
 			ContributionsCollection *struct { // contributionsCollection が null になる可能性があるのでポインタにする
 				ContributionCalendar *struct { // contributionCalendar が null になる可能性があるのでポインタにする
+					TotalContributions int      `json:"totalContributions"`
 					Weeks []struct {
 						ContributionDays []struct {
 							Date            string `json:"date"`
@@ -61,18 +91,23 @@ type GitHubGraphQLResponse struct {
 			} `json:"contributionsCollection"`
 		} `json:"user"`
 	} `json:"data"`
-	Errors []struct {
-		Message   string `json:"message"`
-		Locations []struct {
-			Line   int `json:"line"`
-			Column int `json:"column"`
-		} `json:"locations"`
-		Path []interface{} `json:"path"`
-	} `json:"errors"`
+	Errors []GraphQLError `json:"errors"`
 }
 
 // GetDailyContributions fetches daily contribution data for a given GitHub user.
+// カレンダーが null や空の weeks の場合は nil ではなく空スライスを返すため、
+// 呼び出し側は len(result) == 0 で「データなし」を区別できます。
 func (s *GitHubService) GetDailyContributions(username, token string, startDate, endDate time.Time) ([]models.DailyContribution, error) {
+	calendar, err := s.GetContributionCalendar(username, token, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	return calendar.Days, nil
+}
+
+// GetContributionCalendar fetches the contribution calendar (daily data and total) for a given GitHub user.
+// カレンダーが null の場合も Days が空スライスのカレンダーを返します。
+func (s *GitHubService) GetContributionCalendar(username, token string, startDate, endDate time.Time) (*models.ContributionCalendar, error) {
 	log.Printf("GitHubService: ユーザー '%s' の貢献データを取得開始。期間: %s から %s", username, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
 
 	// GraphQLクエリの定義: 日ごとのContribution数を取得するためのクエリ
@@ -81,6 +116,7 @@ func (s *GitHubService) GetDailyContributions(username, token string, startDate,
 			user(login: $name) {
 				contributionsCollection(from: $from, to: $to) {
 					contributionCalendar {
+						totalContributions
 						weeks {
 							contributionDays {
 								date
@@ -93,11 +129,11 @@ func (s *GitHubService) GetDailyContributions(username, token string, startDate,
 		}
 	`
 
-	// 変数の準備
+	// 変数の準備（JSTのオフセット付きで渡し、GitHub側の日付境界をJSTに揃える）
 	variables := Variables{
 		Name: username,
-		From: startDate.Format(time.RFC3339), // ISO 8601フォーマットに変換
-		To:   endDate.Format(time.RFC3339),   // ISO 8601フォーマットに変換
+		From: startDate.In(models.JST).Format(time.RFC3339), // ISO 8601フォーマットに変換
+		To:   endDate.In(models.JST).Format(time.RFC3339),   // ISO 8601フォーマットに変換
 	}
 
 	// GraphQLリクエストボディの構築
@@ -147,9 +183,9 @@ func (s *GitHubService) GetDailyContributions(username, token string, startDate,
 	log.Printf("GitHubService Debug: HTTPステータスコード: %d", resp.StatusCode)
 	log.Printf("GitHubService Debug: 生レスポンスボディ: %s", string(body))
 
-	// エラーレスポンスの確認
+	// エラーレスポンスの確認（認証失敗・レート制限は呼び出し側が errors.Is で区別できるセンチネルエラーにする）
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub APIからエラーレスポンスが返されました (ステータス: %d): %s", resp.StatusCode, string(body))
+		return nil, classifyHTTPError(resp.StatusCode, resp.Header, body)
 	}
 
 	// JSONレスポンスのパース
@@ -167,26 +203,30 @@ func (s *GitHubService) GetDailyContributions(username, token string, startDate,
 		log.Println("GitHubService Debug: パース後データ: User, ContributionsCollection, または ContributionCalendarがnullです。")
 	}
 
-	// GraphQLエラーがある場合は表示
+	// GraphQLエラーがある場合は種別（レート制限・認証失敗・ユーザー不在など）を判定して返す
 	if len(githubResp.Errors) > 0 {
-		errMsg := "GraphQLエラー:\n"
-		for _, e := range githubResp.Errors {
-			errMsg += fmt.Sprintf("- %s\n", e.Message)
-		}
-		log.Printf("GitHubService Error: %s", errMsg)
-		return nil, fmt.Errorf("%s",errMsg)
+		err := classifyGraphQLErrors(githubResp.Errors, resp.Header, username)
+		log.Printf("GitHubService Error: %v", err)
+		return nil, err
+	}
+
+	// ユーザー自体が存在しない場合 (user: null)
+	if githubResp.Data.User == nil {
+		log.Printf("GitHubService Info: GitHubユーザーが存在しません。username: %s", username)
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, username)
 	}
 
 	// データが取得できたか確認
-	if githubResp.Data.User == nil || githubResp.Data.User.ContributionsCollection == nil || githubResp.Data.User.ContributionsCollection.ContributionCalendar == nil {
+	if githubResp.Data.User.ContributionsCollection == nil || githubResp.Data.User.ContributionsCollection.ContributionCalendar == nil {
 		log.Printf("GitHubService Info: ユーザーの貢献データが見つからないか、クエリの結果が空です。username: %s", username)
-		return []models.DailyContribution{}, nil // 空のスライスを返す
+		return &models.ContributionCalendar{Days: []models.DailyContribution{}}, nil // 空のスライスを返す
 	}
 
 
-	// 取得したContributionデータをDailyContributionスライスに変換
-	var dailyContributions []models.DailyContribution
-	for _, week := range githubResp.Data.User.ContributionsCollection.ContributionCalendar.Weeks {
+	// 取得したContributionデータをDailyContributionスライスに変換（空の weeks でも JSON で null にならないよう空スライスで初期化）
+	calendar := githubResp.Data.User.ContributionsCollection.ContributionCalendar
+	dailyContributions := make([]models.DailyContribution, 0)
+	for _, week := range calendar.Weeks {
 		for _, day := range week.ContributionDays {
 			dailyContributions = append(dailyContributions, models.DailyContribution{
 				Date:  day.Date,
@@ -195,6 +235,9 @@ func (s *GitHubService) GetDailyContributions(username, token string, startDate,
 		}
 	}
 
-	log.Printf("GitHubService Info: ユーザー '%s' の貢献データ %d 日分を取得しました。", username, len(dailyContributions))
-	return dailyContributions, nil
+	log.Printf("GitHubService Info: ユーザー '%s' の貢献データ %d 日分を取得しました。(合計: %d)", username, len(dailyContributions), calendar.TotalContributions)
+	return &models.ContributionCalendar{
+		TotalContributions: calendar.TotalContributions,
+		Days:               dailyContributions,
+	}, nil
 }
@@ -0,0 +1,199 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestGitHubService は handler を返す httptest.Server に向けた GitHubService を作成します。
+func newTestGitHubService(t *testing.T, handler http.HandlerFunc) *GitHubService {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	s := NewGitHubServiceWithClient(server.Client())
+	s.SetAPIURL(server.URL)
+	return s
+}
+
+var (
+	testStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	testEnd   = time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)
+)
+
+// TestGetDailyContributions_Success は正常なレスポンスを日ごとの貢献データに変換し、トークンをヘッダーに付けることをテストします。
+func TestGetDailyContributions_Success(t *testing.T) {
+	s := newTestGitHubService(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		body, _ := io.ReadAll(r.Body)
+		assert.Contains(t, string(body), `"name":"octocat"`)
+
+		io.WriteString(w, `{"data":{"user":{"contributionsCollection":{"contributionCalendar":{
+			"totalContributions":5,"colors":["#ebedf0"],
+			"weeks":[{"contributionDays":[{"date":"2024-01-01","contributionCount":2},{"date":"2024-01-02","contributionCount":3}]}]
+		}}}}}`)
+	})
+
+	days, err := s.GetDailyContributions("octocat", "test-token", testStart, testEnd)
+
+	assert.NoError(t, err)
+	assert.Len(t, days, 2)
+	assert.Equal(t, "2024-01-02", days[1].Date)
+	assert.Equal(t, 3, days[1].Count)
+}
+
+// TestGetDailyContributions_SendsJSTRange は期間をJSTのオフセット付きで送り、GitHub側の日付境界をJSTに揃えることをテストします。
+func TestGetDailyContributions_SendsJSTRange(t *testing.T) {
+	var query GraphQLQuery
+	s := newTestGitHubService(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&query))
+		io.WriteString(w, `{"data":{"user":{"contributionsCollection":{"contributionCalendar":{"totalContributions":0,"weeks":[]}}}}}`)
+	})
+	start, end := models.ContributionPeriod(time.Date(2024, 1, 1, 15, 30, 0, 0, time.UTC), 7)
+
+	_, err := s.GetDailyContributions("octocat", "", start, end)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "2023-12-27T00:00:00+09:00", query.Variables.From)
+	assert.Equal(t, "2024-01-02T23:59:59+09:00", query.Variables.To)
+}
+
+// TestGetContributionCalendar は期間内の合計貢献数と、週をまたいだ日別データを1つの配列にまとめて返すことをテストします。
+func TestGetContributionCalendar(t *testing.T) {
+	s := newTestGitHubService(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Contains(t, string(body), "totalContributions")
+
+		io.WriteString(w, `{"data":{"user":{"contributionsCollection":{"contributionCalendar":{
+			"totalContributions":7,
+			"weeks":[
+				{"contributionDays":[{"date":"2023-12-31","contributionCount":1}]},
+				{"contributionDays":[{"date":"2024-01-01","contributionCount":0},{"date":"2024-01-02","contributionCount":6}]}
+			]
+		}}}}}`)
+	})
+
+	calendar, err := s.GetContributionCalendar("octocat", "", testStart, testEnd)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7, calendar.TotalContributions)
+	assert.Equal(t, []models.DailyContribution{
+		{Date: "2023-12-31", Count: 1},
+		{Date: "2024-01-01", Count: 0},
+		{Date: "2024-01-02", Count: 6},
+	}, calendar.Days)
+}
+
+// TestGetContributionCalendar_Empty はカレンダーが null・weeks が空の場合に、合計0と空の日別データを返すことをテストします。
+func TestGetContributionCalendar_Empty(t *testing.T) {
+	for name, response := range map[string]string{
+		"カレンダーがnull": `{"data":{"user":{"contributionsCollection":{"contributionCalendar":null}}}}`,
+		"weeksが空":    `{"data":{"user":{"contributionsCollection":{"contributionCalendar":{"totalContributions":0,"weeks":[]}}}}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			s := newTestGitHubService(t, func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, response)
+			})
+
+			calendar, err := s.GetContributionCalendar("octocat", "", testStart, testEnd)
+
+			assert.NoError(t, err)
+			assert.Equal(t, 0, calendar.TotalContributions)
+			assert.NotNil(t, calendar.Days, "JSONで null にならないよう空スライスのはず")
+			assert.Empty(t, calendar.Days)
+		})
+	}
+}
+
+// TestGetDailyContributions_ErrorClassification はHTTP・GraphQLのエラー応答を種別ごとのセンチネルエラーに対応付け、
+// 未知のエラーは ErrGitHubAPI にフォールバックすることをテストします。
+func TestGetDailyContributions_ErrorClassification(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		header  map[string]string
+		body    string
+		wantErr error
+	}{
+		{"トークンが無効（401）", http.StatusUnauthorized, nil, `{"message":"Bad credentials"}`, ErrBadCredentials},
+		{"プライマリレート制限（403）", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0"}, `{"message":"API rate limit exceeded"}`, ErrRateLimited},
+		{"セカンダリレート制限（429）", http.StatusTooManyRequests, nil, `{"message":"You have exceeded a secondary rate limit."}`, ErrRateLimited},
+		{"レート制限以外の403", http.StatusForbidden, nil, `{"message":"Resource not accessible"}`, ErrGitHubAPI},
+		{"GitHubの障害（502）", http.StatusBadGateway, nil, `<html>Bad Gateway</html>`, ErrGitHubAPI},
+		{"GraphQLのレート制限", http.StatusOK, nil, `{"data":null,"errors":[{"type":"RATE_LIMITED","message":"API rate limit exceeded for user ID 1."}]}`, ErrRateLimited},
+		{"GraphQLのスコープ不足", http.StatusOK, nil, `{"data":null,"errors":[{"type":"INSUFFICIENT_SCOPES","message":"Your token has not been granted the required scopes."}]}`, ErrBadCredentials},
+		{"GraphQLの未知のエラー", http.StatusOK, nil, `{"data":null,"errors":[{"type":"SOMETHING_NEW","message":"boom"}]}`, ErrGitHubAPI},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestGitHubService(t, func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.header {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			})
+
+			_, err := s.GetDailyContributions("octocat", "", testStart, testEnd)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			for _, other := range []error{ErrBadCredentials, ErrRateLimited, ErrUserNotFound, ErrGitHubAPI} {
+				if other != tt.wantErr {
+					assert.NotErrorIs(t, err, other)
+				}
+			}
+		})
+	}
+}
+
+// TestGetDailyContributions_RateLimitRetryAfter はレート制限の再試行までの時間を Retry-After・X-RateLimit-Reset から求めることをテストします。
+func TestGetDailyContributions_RateLimitRetryAfter(t *testing.T) {
+	reset := time.Now().Add(90 * time.Second).Unix()
+	tests := []struct {
+		name   string
+		header map[string]string
+		min    time.Duration
+		max    time.Duration
+	}{
+		{"Retry-After を優先", map[string]string{"Retry-After": "30", "X-RateLimit-Reset": strconv.FormatInt(reset, 10)}, 30 * time.Second, 30 * time.Second},
+		{"リセット時刻まで", map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(reset, 10)}, 85 * time.Second, 91 * time.Second},
+		{"手がかりなし", nil, DefaultRateLimitRetryAfter, DefaultRateLimitRetryAfter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestGitHubService(t, func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.header {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(http.StatusTooManyRequests)
+				io.WriteString(w, `{"message":"API rate limit exceeded"}`)
+			})
+
+			_, err := s.GetDailyContributions("octocat", "", testStart, testEnd)
+
+			var rateLimitErr *RateLimitError
+			if assert.ErrorAs(t, err, &rateLimitErr) {
+				assert.GreaterOrEqual(t, rateLimitErr.RetryAfter, tt.min)
+				assert.LessOrEqual(t, rateLimitErr.RetryAfter, tt.max)
+			}
+		})
+	}
+}
+
+// TestGetDailyContributions_UserNotFound は存在しないユーザー（NOT_FOUND エラー）で ErrUserNotFound を返すことをテストします。
+func TestGetDailyContributions_UserNotFound(t *testing.T) {
+	s := newTestGitHubService(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{"user":null},"errors":[{"type":"NOT_FOUND","message":"Could not resolve to a User with the login of 'nobody'."}]}`)
+	})
+
+	_, err := s.GetDailyContributions("nobody", "", testStart, testEnd)
+
+	assert.True(t, errors.Is(err, ErrUserNotFound), "NOT_FOUND は ErrUserNotFound になるはず: %v", err)
+}
@@ -1,5 +1,39 @@
 package models
 
+import "time"
+
+// ContributionDateLayout は草データの日付文字列（"YYYY-MM-DD"）のフォーマットです。
+const ContributionDateLayout = "2006-01-02"
+
+// ContributionWeeks は取得・保存する草データの期間（週数）です。
+// デッキを配置する草グリッドの列数もこの週数に揃えます（行数は曜日の7）。
+const ContributionWeeks = 8
+
+// ContributionGridDays は草グリッドの行数（曜日の数）です。
+const ContributionGridDays = 7
+
+// JST は草データの日付境界に使う日本標準時（Asia/Tokyo）です。
+// サーバーのTZ設定に依存しないよう、タイムゾーンデータが読み込めない環境では固定オフセット(+09:00)を使います。
+var JST = loadJST()
+
+func loadJST() *time.Location {
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		return time.FixedZone("JST", 9*60*60)
+	}
+	return loc
+}
+
+// ContributionPeriod は now を含むJSTの日付を最終日として、days 日分の期間を返します。
+// 開始は初日の0時ちょうど、終了は最終日の23:59:59（いずれもJST）です。
+func ContributionPeriod(now time.Time, days int) (time.Time, time.Time) {
+	today := now.In(JST)
+	startOfToday := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, JST)
+	start := startOfToday.AddDate(0, 0, -(days - 1))
+	end := startOfToday.AddDate(0, 0, 1).Add(-time.Second)
+	return start, end
+}
+
 type Contribution struct {
 	ID        string `json:"id"`
 	UserID    string `json:"user_id"`
@@ -16,7 +50,44 @@ type ContributionResponse struct {
 	UpdatedAt string `json:"updated_at"`
 }
 
+// ContributionVisibilityRequest は草の公開設定APIへのリクエストボディです。
+// 公開にすると、対戦相手の画面に自分の草の日別レベル（0-4、生の貢献数は含まない）が表示されます。
+type ContributionVisibilityRequest struct {
+	IsPublic bool `json:"isPublic"`
+}
+
 type DailyContribution struct {
 	Date  string `json:"date"`
 	Count int    `json:"count"`
-} 
\ No newline at end of file
+} 
+
+// ContributionStaleAfter は保存済みの草データを古いとみなすまでの時間です。
+// SavedContributions.Stale が true の場合、クライアントは再取得（refresh）を促します。
+const ContributionStaleAfter = 24 * time.Hour
+
+// SavedContributions は保存済みの草データと、それをGitHubから取得した日時です。
+// GET /api/v2/contributions/{userID} のレスポンスです。
+type SavedContributions struct {
+	FetchedAt     *time.Time          `json:"fetched_at"`    // 最後にGitHubから取得した日時（未取得の場合は null）
+	Stale         bool                `json:"stale"`         // 取得から ContributionStaleAfter 以上経過している（または未取得）かどうか
+	Contributions []DailyContribution `json:"contributions"` // 日別データ（日付の昇順）
+}
+
+// NewSavedContributions は日別データと取得日時から SavedContributions を作成し、now 時点で古いかどうかを判定します。
+func NewSavedContributions(contributions []DailyContribution, fetchedAt *time.Time, now time.Time) *SavedContributions {
+	if contributions == nil {
+		contributions = []DailyContribution{}
+	}
+	return &SavedContributions{
+		FetchedAt:     fetchedAt,
+		Stale:         fetchedAt == nil || now.Sub(*fetchedAt) >= ContributionStaleAfter,
+		Contributions: contributions,
+	}
+}
+
+// ContributionCalendar はGitHubのcontributionCalendarの取得結果です。
+// 日別データに加えて、期間内の合計貢献数を含みます。
+type ContributionCalendar struct {
+	TotalContributions int                 `json:"total_contributions"` // 期間内の合計貢献数
+	Days               []DailyContribution `json:"days"`
+}
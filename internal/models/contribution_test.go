@@ -0,0 +1,86 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewSavedContributions は取得日時から古いかどうかを判定し、未取得の場合は古いとみなすことをテストします。
+func TestNewSavedContributions(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, JST)
+	at := func(d time.Duration) *time.Time {
+		fetchedAt := now.Add(-d)
+		return &fetchedAt
+	}
+	tests := []struct {
+		name      string
+		fetchedAt *time.Time
+		wantStale bool
+	}{
+		{"未取得", nil, true},
+		{"取得直後", at(0), false},
+		{"境界の直前", at(ContributionStaleAfter - time.Second), false},
+		{"ちょうど境界", at(ContributionStaleAfter), true},
+		{"境界を過ぎた", at(ContributionStaleAfter + time.Hour), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := NewSavedContributions([]DailyContribution{{Date: "2025-06-01", Count: 1}}, tt.fetchedAt, now)
+			assert.Equal(t, tt.wantStale, saved.Stale)
+			assert.Equal(t, tt.fetchedAt, saved.FetchedAt)
+			assert.Len(t, saved.Contributions, 1)
+		})
+	}
+}
+
+// TestNewSavedContributions_NilContributions は日別データが無い場合に null ではなく空配列を返すことをテストします。
+func TestNewSavedContributions_NilContributions(t *testing.T) {
+	saved := NewSavedContributions(nil, nil, time.Now())
+	assert.NotNil(t, saved.Contributions)
+	assert.Empty(t, saved.Contributions)
+}
+
+// TestContributionPeriod は now のJSTの日付を最終日とした days 日分の期間を、サーバーのタイムゾーンに関わらず
+// 初日の0時から最終日の23:59:59（JST）で返すことをテストします。
+func TestContributionPeriod(t *testing.T) {
+	tests := []struct {
+		name      string
+		now       time.Time
+		days      int
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			name:      "UTCではまだ前日でもJSTの日付で区切る",
+			now:       time.Date(2024, 1, 1, 15, 30, 0, 0, time.UTC), // JSTでは 2024-01-02 00:30
+			days:      1,
+			wantStart: time.Date(2024, 1, 2, 0, 0, 0, 0, JST),
+			wantEnd:   time.Date(2024, 1, 2, 23, 59, 59, 0, JST),
+		},
+		{
+			name:      "JSTの23:59は同じ日",
+			now:       time.Date(2024, 1, 1, 14, 59, 0, 0, time.UTC), // JSTでは 2024-01-01 23:59
+			days:      1,
+			wantStart: time.Date(2024, 1, 1, 0, 0, 0, 0, JST),
+			wantEnd:   time.Date(2024, 1, 1, 23, 59, 59, 0, JST),
+		},
+		{
+			name:      "8週間は今日を含む56日分",
+			now:       time.Date(2024, 3, 1, 12, 0, 0, 0, JST),
+			days:      56,
+			wantStart: time.Date(2024, 1, 6, 0, 0, 0, 0, JST),
+			wantEnd:   time.Date(2024, 3, 1, 23, 59, 59, 0, JST),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := ContributionPeriod(tt.now, tt.days)
+
+			assert.True(t, tt.wantStart.Equal(start), "start: %v", start)
+			assert.True(t, tt.wantEnd.Equal(end), "end: %v", end)
+			assert.Equal(t, tt.days, int(end.Sub(start)/(24*time.Hour))+1)
+		})
+	}
+}
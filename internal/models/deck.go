@@ -10,6 +10,10 @@ type Deck struct {
     ID          string    `json:"id"`
     UserID      string    `json:"userId"`      // ユーザーごとに1つのデッキを保証
     TotalScore  int       `json:"totalScore"`  // このデッキに含まれる全ブロックの合計ポテンシャルスコア
+    IsPublic    bool      `json:"isPublic"`    // 他のユーザーにデッキの概要を公開するかどうか
+    Version     int       `json:"version"`     // 楽観ロック用のバージョン（保存のたびにインクリメント）
+    PeriodStart string    `json:"periodStart,omitempty"` // デッキが対象とするContribution期間の初日（YYYY-MM-DD、草グリッドの左上のマス）
+    PeriodEnd   string    `json:"periodEnd,omitempty"`   // Contribution期間の最終日（YYYY-MM-DD）。配置が無いデッキ・期間の導入前に保存されたデッキでは省略
     CreatedAt   time.Time `json:"createdAt"`
     UpdatedAt   time.Time `json:"updatedAt"`
 }
@@ -20,14 +24,33 @@ type DeckWithPlacements struct {
 	Placements []TetriminoPlacementAPI `json:"placements"` // APIレスポンス用の配置情報
 }
 
+// DeckSaveResponse はデッキ保存APIの成功レスポンスです。
+// 新規作成されたデッキのIDをクライアントがすぐにゲーム参加で使えるよう、保存後のデッキ情報を返します。
+type DeckSaveResponse struct {
+	Message     string    `json:"message"`
+	DeckID      string    `json:"deckId"`
+	TotalScore  int       `json:"totalScore"`            // サーバーで計算した合計ポテンシャルスコア
+	Version     int       `json:"version"`               // 保存後のバージョン（次回保存時に送り返す）
+	UpdatedAt   time.Time `json:"updatedAt"`
+	PeriodStart string    `json:"periodStart,omitempty"` // 配置から決めたContribution期間の初日（配置が無い場合は省略）
+	PeriodEnd   string    `json:"periodEnd,omitempty"`   // Contribution期間の最終日
+	Created     bool      `json:"created"`               // 新規作成した場合はtrue、既存デッキを更新した場合はfalse
+}
+
+// DeckVisibilityRequest はデッキの公開/非公開切り替えAPIへのリクエストボディです。
+type DeckVisibilityRequest struct {
+	IsPublic bool `json:"isPublic"`
+}
+
 // TetriminoPlacementAPI はAPIレスポンスで返すためのテトリミノ配置情報です。
 // PositionsはJSONBデータとしてそのまま返すためjson.RawMessageを使用します。
+// 所有者以外に公開デッキを返す場合は ID・StartDate・Positions を空にした概要のみを返します。
 type TetriminoPlacementAPI struct {
-	ID           string          `json:"id"`
+	ID           string          `json:"id,omitempty"`
 	TetriminoType string          `json:"type"`
 	Rotation     int             `json:"rotation"`
-	StartDate    string          `json:"startDate"` // YYYY-MM-DD 形式で文字列として返す
-	Positions    json.RawMessage `json:"positions"` // DBから取得したJSONBをそのまま出力
+	StartDate    string          `json:"startDate,omitempty"` // YYYY-MM-DD 形式で文字列として返す
+	Positions    json.RawMessage `json:"positions,omitempty"` // DBから取得したJSONBをそのまま出力
 	ScorePotential int             `json:"scorePotential"`
 	// CreatedAt は必要に応じて含める
 }
\ No newline at end of file
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// MatchHistory はmatch_historiesテーブルのレコードに対応する構造体です。
+// 1回の対戦の参加者・スコア・勝者・終了理由を記録します。
+type MatchHistory struct {
+	ID           int64     `json:"id"`
+	Passcode     string    `json:"passcode"`   // 対戦した部屋の合言葉
+	Player1ID    string    `json:"player1_id"` // UUID
+	Player2ID    string    `json:"player2_id"` // UUID
+	Player1Score int       `json:"player1_score"`
+	Player2Score int       `json:"player2_score"`
+	Player1APM   int       `json:"player1_apm"` // 最終APM（1分あたりの操作数）
+	Player2APM   int       `json:"player2_apm"`
+	Player1PPS   float64   `json:"player1_pps"` // 最終PPS（1秒あたりの設置ピース数）
+	Player2PPS   float64   `json:"player2_pps"`
+	WinnerID     string    `json:"winner_id"`  // 引き分けの場合は空文字
+	EndReason    string    `json:"end_reason"` // "time_up", "game_over", "opponent_disconnected", "cancelled" など
+	StartedAt    time.Time `json:"started_at"`
+	EndedAt      time.Time `json:"ended_at"`
+}
+
+// UserRecord はユーザーの対戦成績（勝敗数・勝率・直近のフォーム）です。
+type UserRecord struct {
+	UserID     string  `json:"user_id"`
+	Wins       int     `json:"wins"`
+	Losses     int     `json:"losses"`
+	Draws      int     `json:"draws"`
+	Matches    int     `json:"matches"`     // 対戦数（勝ち・負け・引き分けの合計）
+	WinRate    float64 `json:"win_rate"`    // 勝率（0〜1、小数第3位で四捨五入。対戦数0なら0）
+	RecentForm string  `json:"recent_form"` // 直近の結果を新しい順に並べた W/L/D の文字列（例: "WWLWL"）
+}
+
+// NewUserRecord は勝敗数から対戦数と勝率を計算した UserRecord を作成します。
+func NewUserRecord(userID string, wins, losses, draws int, recentForm string) UserRecord {
+	record := UserRecord{
+		UserID:     userID,
+		Wins:       wins,
+		Losses:     losses,
+		Draws:      draws,
+		Matches:    wins + losses + draws,
+		RecentForm: recentForm,
+	}
+	if record.Matches > 0 {
+		record.WinRate = math.Round(float64(wins)/float64(record.Matches)*1000) / 1000
+	}
+	return record
+}
@@ -4,11 +4,36 @@ import (
 	"time"
 )
 
+// GameMode はゲーム結果の種別です。ランキングを種別ごとに分けて取得するために使います。
+type GameMode string
+
+const (
+	GameModeSolo   GameMode = "solo"   // ソロプレイ（廃止した POST /api/results でクライアントから送られていたスコア）
+	GameModeVersus GameMode = "versus" // 対戦（セッション終了時にサーバー側で保存した最終スコア）
+
+	// DefaultGameMode は game_mode カラム追加前の既存データの種別です。
+	// それまでのスコアはほぼすべてセッション終了時に保存した対戦のスコアのため、対戦として扱います。
+	DefaultGameMode = GameModeVersus
+)
+
+// ParseGameMode はクエリパラメータなどの文字列をゲーム結果の種別に変換します。
+// 空文字列は種別を指定しない（すべての種別）として ok=true で空の GameMode を返します。
+func ParseGameMode(s string) (GameMode, bool) {
+	switch mode := GameMode(s); mode {
+	case "", GameModeSolo, GameModeVersus:
+		return mode, true
+	default:
+		return "", false
+	}
+}
+
 // Result はresultsテーブルのレコードに対応する構造体です。
 type Result struct {
 	ID        int64     `json:"id"`
 	UserID    string    `json:"user_id"`    // UUID
 	Score     int       `json:"score"`
+	GameMode  GameMode  `json:"game_mode"`  // solo / versus
+	Flagged   bool      `json:"flagged"`    // スコアがライン数・経過時間に対して不自然（チートの疑い）。ランキングには載せたまま印を付ける
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -17,12 +42,37 @@ type ResultResponse struct {
 	ID        int64     `json:"id"`
 	UserID    string    `json:"user_id"`
 	Score     int       `json:"score"`
+	GameMode  GameMode  `json:"game_mode"`
+	Flagged   bool      `json:"flagged"` // 異常検知で印を付けたスコアかどうか
 	CreatedAt time.Time `json:"created_at"`
 	Rank      int       `json:"rank"` // ランキング順位
 }
 
-// ResultRequest はリザルト保存リクエスト用の構造体です。
-type ResultRequest struct {
-	UserID string `json:"user_id"`
-	Score  int    `json:"score"`
-} 
\ No newline at end of file
+// ResultHistoryEntry はスコア履歴APIのエントリです。
+// IsPersonalBest はそのリザルトが記録された時点で自己ベストを更新したかどうかを表します。
+type ResultHistoryEntry struct {
+	Result
+	IsPersonalBest bool `json:"is_personal_best"`
+}
+
+// MarkPersonalBests は created_at DESC で並んだリザルトを古い順に走査し、自己ベストを更新したエントリにフラグを付けます。
+// 返り値は入力と同じ created_at DESC の順序です。
+//
+// Parameters:
+//   results      : created_at DESC で並んだユーザーのリザルト
+//   previousBest : results より前の最高スコア（それ以前のリザルトがない場合は nil）
+func MarkPersonalBests(results []Result, previousBest *int) []ResultHistoryEntry {
+	entries := make([]ResultHistoryEntry, len(results))
+	best := -1
+	if previousBest != nil {
+		best = *previousBest
+	}
+	for i := len(results) - 1; i >= 0; i-- {
+		entries[i] = ResultHistoryEntry{Result: results[i]}
+		if results[i].Score > best {
+			entries[i].IsPersonalBest = true
+			best = results[i].Score
+		}
+	}
+	return entries
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMarkPersonalBests は古い順に走査して自己ベストを更新したリザルトにだけフラグを付け、
+// 入力と同じ新しい順で返すことをテストします。
+func TestMarkPersonalBests(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	tests := []struct {
+		name         string
+		scores       []int // created_at DESC の順
+		previousBest *int
+		want         []bool
+	}{
+		{name: "リザルトが無い場合は空", scores: nil, want: []bool{}},
+		{name: "過去のリザルトが無ければ最も古いリザルトは自己ベスト", scores: []int{300, 500, 100}, want: []bool{false, true, true}},
+		{name: "スコア0でも最初のリザルトは自己ベスト", scores: []int{0}, want: []bool{true}},
+		{name: "過去の最高スコアを超えたものだけが自己ベスト", scores: []int{900, 700, 400}, previousBest: intPtr(600), want: []bool{true, true, false}},
+		{name: "同点は更新とみなさない", scores: []int{600, 600}, previousBest: intPtr(600), want: []bool{false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := make([]Result, len(tt.scores))
+			for i, score := range tt.scores {
+				results[i] = Result{ID: int64(len(tt.scores) - i), Score: score}
+			}
+
+			entries := MarkPersonalBests(results, tt.previousBest)
+
+			got := make([]bool, len(entries))
+			for i, entry := range entries {
+				assert.Equal(t, results[i], entry.Result, "入力と同じ順序のはず")
+				got[i] = entry.IsPersonalBest
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -37,4 +37,7 @@ type TetriminoPlacementRequest struct {
 type DeckSaveRequest struct {
 	UserID    string                      `json:"userId"`    // 認証されたユーザーのID。フロントエンドから渡されるが、バックエンドで検証済みIDを優先
 	Tetriminos []TetriminoPlacementRequest `json:"tetriminos"`
+	// Version はクライアントがデッキを読み込んだ時点のバージョンです。
+	// 現在のバージョンと一致しない場合は他の端末で更新済みとして保存を拒否します（省略時はチェックしない）。
+	Version   *int                        `json:"version,omitempty"`
 }
\ No newline at end of file
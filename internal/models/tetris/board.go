@@ -3,12 +3,14 @@ package tetris
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 )
 
 const (
-	BoardWidth  = 10 // テトリスボードの幅
-	BoardHeight = 20 // テトリスボードの高さ（表示部分）
-	// BoardHiddenHeight = 4 // ピースが生成される見えない領域など、必要に応じて追加
+	BoardWidth        = 10                              // テトリスボードの幅
+	BoardHeight       = 20                              // テトリスボードの高さ（表示部分）
+	BoardHiddenHeight = 2                               // 表示部分の上にある隠し行（ピースのスポーン領域）
+	BoardTotalHeight  = BoardHeight + BoardHiddenHeight // 隠し行を含むボード全体の高さ
 )
 
 // BlockType はボード上のブロックの種類を表します。
@@ -31,7 +33,11 @@ const (
 // Board はテトリスのゲームボードを表す2次元配列です。
 // 各要素はBlockTypeで、その位置にどの種類のブロックがあるかを示します。
 // Board[y][x] でアクセスします。yは行、xは列です。
-type Board [BoardHeight][BoardWidth]BlockType
+// 先頭の BoardHiddenHeight 行は隠し行で、y = BoardHiddenHeight が表示部分の最上段になります。
+type Board [BoardHeight + BoardHiddenHeight][BoardWidth]BlockType
+
+// VisibleBoard はクライアントに送信する表示部分（隠し行を除いた20行）のボードです。
+type VisibleBoard [BoardHeight][BoardWidth]BlockType
 
 // NewBoard は新しい空のボードを初期化して返します。
 // Goの配列はデフォルトでゼロ値（BlockEmpty）で初期化されるため、特別な初期化は不要です。
@@ -40,6 +46,46 @@ func NewBoard() Board {
 	return board
 }
 
+// Visible は隠し行を除いた表示部分のボードを返します。
+func (b *Board) Visible() VisibleBoard {
+	var visible VisibleBoard
+	copy(visible[:], b[BoardHiddenHeight:])
+	return visible
+}
+
+// blockRunes はデバッグ表示（Board.String）で使う各ブロックの文字です。
+var blockRunes = map[BlockType]byte{
+	BlockEmpty: '.', BlockI: 'I', BlockO: 'O', BlockT: 'T', BlockS: 'S', BlockZ: 'Z', BlockJ: 'J', BlockL: 'L',
+	BlockFilled: '#', BlockGarbage: 'X',
+}
+
+// String はボードをデバッグ用のASCIIで表します（1行1段、空きは '.'、テトリミノは種類の文字、お邪魔ブロックは 'X'）。
+// 隠し行は表示部分の上に出力し、境目に "----------" の行を挟みます。
+func (b Board) String() string {
+	var sb strings.Builder
+	for y := 0; y < BoardTotalHeight; y++ {
+		if y == BoardHiddenHeight {
+			sb.WriteString(strings.Repeat("-", BoardWidth))
+			sb.WriteByte('\n')
+		}
+		for x := 0; x < BoardWidth; x++ {
+			r, ok := blockRunes[b[y][x]]
+			if !ok {
+				r = '?'
+			}
+			sb.WriteByte(r)
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// ToVisibleY はボード内部のY座標（隠し行込み）を表示部分のY座標に変換します。
+// 隠し行内の座標は負の値になります。
+func ToVisibleY(y int) int {
+	return y - BoardHiddenHeight
+}
+
 // HasCollision は指定されたピースが現在のボード上の位置 (p.X, p.Y) とオフセット (dx, dy) で
 // 壁や既存のブロックと衝突するかどうかを判定します。
 //
@@ -57,14 +103,13 @@ func (b *Board) HasCollision(p *Piece, dx, dy int) bool {
 		y := p.Y + block[1] + dy
 
 		// ボードの境界との衝突判定
-		if x < 0 || x >= BoardWidth || y >= BoardHeight {
+		if x < 0 || x >= BoardWidth || y >= BoardTotalHeight {
 			return true // 左右の壁、または下部との衝突
 		}
-		// 上部（見えない領域）への衝突は通常ゲームオーバー判定で扱うため、ここでは y < 0 は許可
-		// ただし、y < 0 の位置にあるブロックに対しては、既存のブロックとの衝突は発生しない
+		// 隠し行よりさらに上（y < 0）へのはみ出しは許可し、既存のブロックとの衝突も発生しない
 
 		// 既存のブロックとの衝突判定
-		// y座標がボードの範囲内（0 <= y < BoardHeight）かつ、そのマスが空でない場合
+		// y座標がボードの範囲内（0 <= y < BoardTotalHeight）かつ、そのマスが空でない場合
 		if y >= 0 && b[y][x] != BlockEmpty {
 			return true // 既存のブロックとの衝突
 		}
@@ -72,6 +117,50 @@ func (b *Board) HasCollision(p *Piece, dx, dy int) bool {
 	return false
 }
 
+// IsOccupied は指定されたボード座標（隠し行込みの内部座標）が埋まっているかどうかを返します。
+// ボードの範囲外（左右の壁・床・隠し行より上）はすべて壁として埋まり扱いにします。
+// 範囲内では空セルのみ false を返し、ピース由来のブロックもお邪魔ブロックも同様に埋まり扱いです。
+// T-Spinの四隅判定やゴーストピース、ロックディレイの接地判定で共通して使います。
+//
+// Parameters:
+//   x : 判定するX座標
+//   y : 判定するY座標（隠し行込み）
+func (b *Board) IsOccupied(x, y int) bool {
+	if x < 0 || x >= BoardWidth || y < 0 || y >= BoardTotalHeight {
+		return true
+	}
+	return b[y][x] != BlockEmpty
+}
+
+// CountOccupiedCorners は (cx, cy) を中心とする斜め四隅のうち、埋まっているマスの数を返します。
+// T-Spin判定では Tミノの中心（ブロック相対座標 {1, 1}）を渡し、3つ以上埋まっていればT-Spinとみなします。
+//
+// Parameters:
+//   cx : 中心のX座標
+//   cy : 中心のY座標（隠し行込み）
+func (b *Board) CountOccupiedCorners(cx, cy int) int {
+	count := 0
+	for _, corner := range [4][2]int{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}} {
+		if b.IsOccupied(cx+corner[0], cy+corner[1]) {
+			count++
+		}
+	}
+	return count
+}
+
+// IsEmpty はボード（隠し行を含む）にブロックが1つも無いかどうかを返します。
+// ラインクリア後に空になっていればパーフェクトクリアです。
+func (b *Board) IsEmpty() bool {
+	for y := 0; y < BoardTotalHeight; y++ {
+		for x := 0; x < BoardWidth; x++ {
+			if b[y][x] != BlockEmpty {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // MergePiece は落下したピースをボードに固定します。
 // ピースのブロックのタイプでボードのマスを埋めます。
 //
@@ -82,31 +171,40 @@ func (b *Board) MergePiece(p *Piece) {
 		x := p.X + block[0]
 		y := p.Y + block[1]
 
-		// ボードの有効な範囲内でのみマージ
-		if x >= 0 && x < BoardWidth && y >= 0 && y < BoardHeight {
-			b[y][x] = BlockType(p.Type + 1) // PieceType (0-6) を BlockType (1-7) に変換
+		// ボードの有効な範囲内（隠し行を含む）でのみマージ
+		if x >= 0 && x < BoardWidth && y >= 0 && y < BoardTotalHeight {
+			b[y][x] = PieceBlockType(p.Type) // PieceType (0-6) を BlockType (1-7) に変換
 		}
 	}
 }
 
 // ClearLines は揃ったラインをクリアし、上のブロックを落とします。
-// この関数は、クリアされたライン数と、そのラインクリアによって獲得したスコアを返します。
+// この関数は、クリアされたライン数と、そのラインクリアによって獲得したスコア、クリアされた行を返します。
+// スコアマップは「ボード上の現在位置のスコア」を表すため、残った行のスコアもブロックと一緒に下へずらし、
+// 消えた行のスコアは取り除きます（上端に空いた行にはスコアが無くなります）。
 //
 // Parameters:
 //   contributionScores : 各ボードマス（日付）に対応するContributionスコアのマップ（または2次元配列）
-//                        key: "y_x" (例: "0_0"), value: score (Contribution量)
+//                        key: "y_x" (例: "0_0"、yは表示部分の座標), value: score (Contribution量)
+//                        ライン消去があった場合はその場で書き換えられます
 // Returns:
 //   int: クリアされたライン数
 //   int: ラインクリアによって獲得した合計スコア
-func (b *Board) ClearLines(contributionScores map[string]int) (int, int) {
+//   []int: クリアされた行のY座標（隠し行込みのボード内部の座標、クリア前の位置、昇順）。クリアが無い場合は nil
+func (b *Board) ClearLines(contributionScores map[string]int) (int, int, []int) {
 	clearedLines := 0
 	totalScore := 0
+	var clearedRows []int
 	newBoard := NewBoard() // 新しいボードを作成し、クリア後の状態を構築
+	var sourceRows [BoardTotalHeight]int // 新しいボードの各行がクリア前のどの行から来たか（-1は空いた行）
+	for y := range sourceRows {
+		sourceRows[y] = -1
+	}
 
-	destY := BoardHeight - 1 // 新しいボードにブロックをコピーする際の最も下の行
+	destY := BoardTotalHeight - 1 // 新しいボードにブロックをコピーする際の最も下の行
 
-	// ボードの最下部から上に向かって各行をチェック
-	for y := BoardHeight - 1; y >= 0; y-- {
+	// ボードの最下部から上に向かって各行（隠し行を含む）をチェック
+	for y := BoardTotalHeight - 1; y >= 0; y-- {
 		// 最初にライン満了をチェック（軽量化）
 		isLineFull := true
 		for x := 0; x < BoardWidth; x++ {
@@ -121,7 +219,7 @@ func (b *Board) ClearLines(contributionScores map[string]int) (int, int) {
 		if isLineFull {
 			for x := 0; x < BoardWidth; x++ {
 				// スコア計算の最適化（文字列生成軽量化）
-				scoreKey := fmt.Sprintf("%d_%d", y, x) // y_x の形式でスコアを検索
+				scoreKey := fmt.Sprintf("%d_%d", ToVisibleY(y), x) // 表示座標の y_x の形式でスコアを検索
 				if score, ok := contributionScores[scoreKey]; ok {
 					lineScore += score
 				} else {
@@ -133,41 +231,107 @@ func (b *Board) ClearLines(contributionScores map[string]int) (int, int) {
 		if isLineFull {
 			clearedLines++
 			totalScore += lineScore // 揃ったラインのスコアを加算
+			clearedRows = append([]int{y}, clearedRows...) // 下から走査しているので先頭に追加して昇順にする
 		} else {
 			// 揃っていないラインは新しいボードのdestYにコピー
 			for x := 0; x < BoardWidth; x++ {
 				newBoard[destY][x] = b[y][x]
 			}
+			sourceRows[destY] = y
 			destY-- // 次のラインは一つ上にコピーされる
 		}
 	}
 	*b = newBoard // 現在のボードを更新されたボードに置き換える
-	return clearedLines, totalScore
+	if clearedLines > 0 {
+		shiftContributionScores(contributionScores, &sourceRows)
+	}
+	return clearedLines, totalScore, clearedRows
 }
 
+// shiftContributionScores はライン消去後の行の移動に合わせてスコアマップを書き換えます。
+// スコアマップは表示部分の座標だけを持つため、隠し行から表示部分に下りてきた行や上端に空いた行のスコアは削除します。
+//
+// Parameters:
+//   scores     : 書き換えるスコアマップ（key: "y_x"、yは表示部分の座標）
+//   sourceRows : 消去後の各行（ボード内部の座標）が消去前のどの行から来たか（-1は空いた行）
+func shiftContributionScores(scores map[string]int, sourceRows *[BoardTotalHeight]int) {
+	if scores == nil {
+		return
+	}
+	// 上書きで移動元を壊さないよう、移動後のスコアを先に集めてから書き戻す
+	shifted := make(map[string]int, len(scores))
+	for destY := BoardHiddenHeight; destY < BoardTotalHeight; destY++ {
+		srcY := sourceRows[destY]
+		if srcY < BoardHiddenHeight {
+			continue
+		}
+		for x := 0; x < BoardWidth; x++ {
+			if score, ok := scores[fmt.Sprintf("%d_%d", ToVisibleY(srcY), x)]; ok {
+				shifted[fmt.Sprintf("%d_%d", ToVisibleY(destY), x)] = score
+			}
+		}
+	}
+	for y := 0; y < BoardHeight; y++ {
+		for x := 0; x < BoardWidth; x++ {
+			delete(scores, fmt.Sprintf("%d_%d", y, x))
+		}
+	}
+	for key, score := range shifted {
+		scores[key] = score
+	}
+}
+
+// GarbageOverflow はお邪魔ラインのせり上げで、既存のブロックがどこまで押し上げられたかを表します。
+type GarbageOverflow int
+
+const (
+	GarbageOverflowNone    GarbageOverflow = iota // すべてのブロックが表示部分に収まっている
+	GarbageOverflowVisible                        // 表示部分の上端を超え、隠し行に押し上げられたブロックがある
+	GarbageOverflowHidden                         // 隠し行の上端も超え、ボードの外に押し出されたブロックがある
+)
+
 // AddGarbageLines は指定された数のお邪魔ブロックのラインをボードの最下部に追加します。
 // これにより、ボード上の既存のブロックは上にシフトされます。
+// シフトで表示部分の上端・隠し行の上端を超えたブロックがあれば、その位置を返します（せり上げによるトップアウトの判定用）。
 //
 // Parameters:
 //   count : 追加するお邪魔ラインの数
-func (b *Board) AddGarbageLines(count int) {
+// Returns:
+//   GarbageOverflow: 既存のブロックが超えた最も上の境界（超えていなければ GarbageOverflowNone）
+func (b *Board) AddGarbageLines(count int) GarbageOverflow {
 	if count <= 0 {
-		return
+		return GarbageOverflowNone
 	}
-	if count >= BoardHeight { // ボード全体を覆う場合
+	if count >= BoardTotalHeight { // ボード全体を覆う場合
+		overflow := GarbageOverflowNone
+		if !b.IsEmpty() {
+			overflow = GarbageOverflowHidden
+		}
 		*b = NewBoard() // 全てクリア
-		return
+		return overflow
+	}
+
+	// シフト前に、ボードの外（上から count 行）と隠し行（その下の BoardHiddenHeight 行）に押し上げられるブロックを確認
+	overflow := GarbageOverflowNone
+	for y := 0; y < count+BoardHiddenHeight && y < BoardTotalHeight; y++ {
+		if !b.rowIsEmpty(y) {
+			if y < count {
+				overflow = GarbageOverflowHidden
+				break
+			}
+			overflow = GarbageOverflowVisible
+		}
 	}
 
 	// 既存のブロックを上にシフト
-	for y := 0; y < BoardHeight-count; y++ {
+	for y := 0; y < BoardTotalHeight-count; y++ {
 		for x := 0; x < BoardWidth; x++ {
 			b[y][x] = b[y+count][x]
 		}
 	}
 
 	// 最下部にお邪魔ブロックのラインを追加
-	for y := BoardHeight - count; y < BoardHeight; y++ {
+	for y := BoardTotalHeight - count; y < BoardTotalHeight; y++ {
 		// ランダムな位置に一つ穴を開ける（テトリスの一般的なお邪魔ブロックの動作）
 		holeX := rand.Intn(BoardWidth) // TODO: 適切な乱数生成器を使用する
 
@@ -179,4 +343,15 @@ func (b *Board) AddGarbageLines(count int) {
 			}
 		}
 	}
+	return overflow
+}
+
+// rowIsEmpty は y 行目にブロックが無いかどうかを返します。
+func (b *Board) rowIsEmpty(y int) bool {
+	for x := 0; x < BoardWidth; x++ {
+		if b[y][x] != BlockEmpty {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,162 @@
+package tetris
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestIsOccupied は範囲外・隠し行・各種ブロックで IsOccupied が一貫した結果を返すことをテストします。
+func TestIsOccupied(t *testing.T) {
+	board := NewBoard()
+	board[BoardTotalHeight-1][0] = BlockGarbage
+	board[BoardTotalHeight-1][1] = BlockT
+	board[0][5] = BlockI // 隠し行
+
+	tests := []struct {
+		name string
+		x, y int
+		want bool
+	}{
+		{"空セル", 4, 10, false},
+		{"隠し行の空セル", 4, 0, false},
+		{"隠し行のブロック", 5, 0, true},
+		{"お邪魔ブロック", 0, BoardTotalHeight - 1, true},
+		{"ピース由来のブロック", 1, BoardTotalHeight - 1, true},
+		{"左の壁", -1, 10, true},
+		{"右の壁", BoardWidth, 10, true},
+		{"床", 4, BoardTotalHeight, true},
+		{"隠し行より上", 4, -1, true},
+		{"右下の角", BoardWidth - 1, BoardTotalHeight - 1, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, board.IsOccupied(tt.x, tt.y), tt.name)
+	}
+}
+
+// TestCountOccupiedCorners はT-Spin判定用の四隅カウントが壁とブロックの両方を数えることをテストします。
+func TestCountOccupiedCorners(t *testing.T) {
+	board := NewBoard()
+	assert.Equal(t, 0, board.CountOccupiedCorners(4, 10), "空のボード中央では四隅とも空")
+
+	// 床際の中心は下の2隅が床として埋まり扱い
+	assert.Equal(t, 2, board.CountOccupiedCorners(4, BoardTotalHeight-1))
+
+	board[9][3] = BlockGarbage
+	board[11][5] = BlockL
+	board[11][3] = BlockJ
+	assert.Equal(t, 3, board.CountOccupiedCorners(4, 10))
+
+	// 左の壁際は左側の2隅が壁として埋まり扱い
+	assert.Equal(t, 2, board.CountOccupiedCorners(0, 5))
+}
+
+// TestIsEmpty はパーフェクトクリア判定用に、隠し行を含めてブロックが無いことを判定できるかをテストします。
+func TestIsEmpty(t *testing.T) {
+	board := NewBoard()
+	assert.True(t, board.IsEmpty())
+
+	board[0][0] = BlockT // 隠し行のブロックも数える
+	assert.False(t, board.IsEmpty())
+}
+
+// TestClearLines は揃った行だけが消え、消えた行がクリア前のボード内部の座標で昇順に返されることをテストします。
+func TestClearLines(t *testing.T) {
+	board := NewBoard()
+	bottom := BoardTotalHeight - 1
+	for x := 0; x < BoardWidth; x++ {
+		board[bottom][x] = BlockI
+		board[bottom-2][x] = BlockI
+	}
+	board[bottom-1][0] = BlockT // 揃っていない行は1行分だけ下に落ちる
+
+	cleared, score, rows := board.ClearLines(map[string]int{})
+
+	assert.Equal(t, 2, cleared)
+	assert.Equal(t, 2*BoardWidth*10, score, "スコアマップに無いマスは仮のスコア10で数える")
+	assert.Equal(t, []int{bottom - 2, bottom}, rows)
+	assert.Equal(t, BlockT, board[bottom][0])
+
+	_, _, rows = board.ClearLines(map[string]int{})
+	assert.Nil(t, rows)
+}
+
+// TestClearLines_ShiftsContributionScores は残った行のスコアがブロックと一緒に下へずれ、
+// 消えた行と上端に空いた行のスコアが取り除かれることをテストします。
+func TestClearLines_ShiftsContributionScores(t *testing.T) {
+	board := NewBoard()
+	bottom := BoardTotalHeight - 1
+	for x := 0; x < BoardWidth; x++ {
+		board[bottom-1][x] = BlockI
+	}
+	board[bottom][0] = BlockT
+	board[bottom-2][3] = BlockJ
+
+	visibleBottom := ToVisibleY(bottom)
+	scores := map[string]int{
+		fmt.Sprintf("%d_0", visibleBottom):   500, // 揃っていない最下段はそのまま
+		fmt.Sprintf("%d_0", visibleBottom-1): 400, // 消える行
+		fmt.Sprintf("%d_3", visibleBottom-2): 300, // 1行下に落ちる
+		"0_5":                                200, // 最上段も1行下に落ち、最上段は空く
+	}
+
+	cleared, score, _ := board.ClearLines(scores)
+
+	assert.Equal(t, 1, cleared)
+	assert.Equal(t, 400+(BoardWidth-1)*10, score, "消える行のスコアは消去前の位置で数える")
+	assert.Equal(t, BlockJ, board[bottom-1][3])
+	assert.Equal(t, map[string]int{
+		fmt.Sprintf("%d_0", visibleBottom):   500,
+		fmt.Sprintf("%d_3", visibleBottom-1): 300,
+		"1_5":                                200,
+	}, scores)
+}
+
+// TestBoardString は隠し行と表示部分を区切って、ブロックの種類ごとの文字でボードを出力することをテストします。
+func TestBoardString(t *testing.T) {
+	board := NewBoard()
+	board[0][0] = BlockT
+	board[BoardTotalHeight-1][0] = BlockI
+	board[BoardTotalHeight-1][1] = BlockGarbage
+
+	lines := strings.Split(strings.TrimSuffix(board.String(), "\n"), "\n")
+	assert.Len(t, lines, BoardTotalHeight+1)
+	assert.Equal(t, "T.........", lines[0])
+	assert.Equal(t, "----------", lines[BoardHiddenHeight])
+	assert.Equal(t, "..........", lines[BoardHiddenHeight+1])
+	assert.Equal(t, "IX........", lines[len(lines)-1])
+}
+
+// TestAddGarbageLines_Overflow はせり上げで既存のブロックが表示部分の上端・隠し行の上端を超えたかを判定できることをテストします。
+func TestAddGarbageLines_Overflow(t *testing.T) {
+	tests := []struct {
+		name   string
+		blockY int // 既存のブロックを置く行（-1 で置かない）
+		count  int
+		want   GarbageOverflow
+	}{
+		{"空のボード", -1, 3, GarbageOverflowNone},
+		{"表示部分に収まる", BoardHiddenHeight + 1, 1, GarbageOverflowNone},
+		{"表示部分の最上段から隠し行へ", BoardHiddenHeight, 1, GarbageOverflowVisible},
+		{"表示部分から隠し行の最上段へ", BoardHiddenHeight, BoardHiddenHeight, GarbageOverflowVisible},
+		{"表示部分からボードの外へ", BoardHiddenHeight, BoardHiddenHeight + 1, GarbageOverflowHidden},
+		{"隠し行の最上段からボードの外へ", 0, 1, GarbageOverflowHidden},
+		{"ボード全体を覆う", BoardTotalHeight - 1, BoardTotalHeight, GarbageOverflowHidden},
+		{"追加しない", 0, 0, GarbageOverflowNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board := NewBoard()
+			if tt.blockY >= 0 {
+				board[tt.blockY][4] = BlockT
+			}
+
+			assert.Equal(t, tt.want, board.AddGarbageLines(tt.count))
+			if tt.blockY >= 0 && tt.blockY-tt.count >= 0 {
+				assert.Equal(t, BlockT, board[tt.blockY-tt.count][4], "既存のブロックは count 行上にシフトされるはず")
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package tetris
+
+// ブロック・テトリミノの表示色です。クライアントは GET /api/game/tetromino-colors で取得し、
+// サーバーの定義に合わせてボードを描画します（色の割り当てはここが唯一の定義です）。
+const (
+	ColorEmpty   = "transparent" // 空のマス（背景をそのまま見せる）
+	ColorI       = "#00F0F0"     // シアン
+	ColorO       = "#F0F000"     // 黄色
+	ColorT       = "#A000F0"     // 紫
+	ColorS       = "#00F000"     // 緑
+	ColorZ       = "#F00000"     // 赤
+	ColorJ       = "#0000F0"     // 青
+	ColorL       = "#F0A000"     // オレンジ
+	ColorFilled  = "#C0C0C0"     // 固定ブロック（相手向けの制限版ボードなど、種類を伏せたブロック）
+	ColorGarbage = "#808080"     // お邪魔ブロック
+)
+
+// blockColors は BlockType ごとの表示色です。
+var blockColors = map[BlockType]string{
+	BlockEmpty:   ColorEmpty,
+	BlockI:       ColorI,
+	BlockO:       ColorO,
+	BlockT:       ColorT,
+	BlockS:       ColorS,
+	BlockZ:       ColorZ,
+	BlockJ:       ColorJ,
+	BlockL:       ColorL,
+	BlockFilled:  ColorFilled,
+	BlockGarbage: ColorGarbage,
+}
+
+// blockNames は BlockType ごとの名前です（テトリミノ由来のブロックはテトリミノの種類名）。
+var blockNames = map[BlockType]string{
+	BlockEmpty:   "empty",
+	BlockI:       "I",
+	BlockO:       "O",
+	BlockT:       "T",
+	BlockS:       "S",
+	BlockZ:       "Z",
+	BlockJ:       "J",
+	BlockL:       "L",
+	BlockFilled:  "filled",
+	BlockGarbage: "garbage",
+}
+
+// BlockColor は BlockType の表示色を返します。未定義の値の場合は空文字列を返します。
+func BlockColor(b BlockType) string {
+	return blockColors[b]
+}
+
+// PieceBlockType はテトリミノの種類を、ボードに固定されたときのブロックの種類に変換します。
+func PieceBlockType(t PieceType) BlockType {
+	return BlockType(t + 1)
+}
+
+// PieceColor はテトリミノの種類の表示色を返します（ボードに固定されたブロックと同じ色）。
+func PieceColor(t PieceType) string {
+	return BlockColor(PieceBlockType(t))
+}
+
+// ColorEntry は色の対応表の1項目です。
+type ColorEntry struct {
+	Type  int    `json:"type"`  // PieceType または BlockType の値
+	Name  string `json:"name"`  // 種類の名前（"I"、"garbage" など）
+	Color string `json:"color"` // HEXカラーコード、または CSS の色名
+}
+
+// ColorPalette はテトリミノとボードのブロックの色の対応表です。
+type ColorPalette struct {
+	Pieces []ColorEntry `json:"pieces"` // PieceType（0-6）ごとの色
+	Blocks []ColorEntry `json:"blocks"` // BlockType（0-9）ごとの色（空・お邪魔ブロックを含む）
+}
+
+// Palette は全テトリミノ・全ブロックの色の対応表を、種類の値の昇順で返します。
+func Palette() ColorPalette {
+	palette := ColorPalette{}
+	for t := TypeI; t <= TypeL; t++ {
+		palette.Pieces = append(palette.Pieces, ColorEntry{Type: int(t), Name: PieceTypeToString(t), Color: PieceColor(t)})
+	}
+	for b := BlockEmpty; b <= BlockGarbage; b++ {
+		palette.Blocks = append(palette.Blocks, ColorEntry{Type: int(b), Name: blockNames[b], Color: BlockColor(b)})
+	}
+	return palette
+}
@@ -0,0 +1,142 @@
+package tetris
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+)
+
+// 草（GitHubのContribution）からブロックのスコアを決めるための設定です。
+const (
+	DefaultContributionScore  = 100  // 草が無い日のブロックのスコア
+	ContributionScorePerCount = 50   // 貢献数1あたりに加算するスコア
+	MaxContributionBlockScore = 1000 // 1ブロックのスコア上限（極端に草が多い日でもバランスを崩さないため）
+)
+
+// MaxContributionLevel は草のレベルの最大値です（GitHubの草の色段階と同じ 0-4 の5段階）。
+// ゲームの草レベルとデッキプレビューの強度レベルの両方で使います。
+const MaxContributionLevel = 4
+
+// DeckPlacementPiece はデッキから読み込んだテトリミノ配置情報を表します。
+// ゲーム（ボードのスコア）とデッキ（保存時のスコア算出・プレビュー）の両方で使います。
+type DeckPlacementPiece struct {
+	Type      PieceType         `json:"type"`
+	Rotation  int               `json:"rotation"`
+	StartDate time.Time         `json:"start_date"` // 配置の左上（最小x・最小y）のマスに対応する草の日付
+	Blocks    []models.Position `json:"blocks"`     // 各ブロックのスコア情報を含む
+}
+
+// DeckPlacementsFromRecords はDBのテトリミノ配置レコードをDeckPlacementPieceに変換します。
+// 不明なテトリミノタイプや座標JSONのデコードに失敗したレコードはスキップします。
+func DeckPlacementsFromRecords(placements []models.TetriminoPlacement) []DeckPlacementPiece {
+	pieces := []DeckPlacementPiece{}
+	for _, placement := range placements {
+		pieceType, ok := StringToPieceType(placement.TetriminoType)
+		if !ok {
+			continue // 不明なテトリミノタイプをスキップ
+		}
+
+		// JSONからPositionスライスをデコード
+		var positions []models.Position
+		if err := json.Unmarshal(placement.Positions, &positions); err != nil {
+			continue // デコードに失敗した場合はスキップ
+		}
+
+		pieces = append(pieces, DeckPlacementPiece{
+			Type:      pieceType,
+			Rotation:  placement.Rotation,
+			StartDate: placement.StartDate,
+			Blocks:    positions,
+		})
+	}
+	return pieces
+}
+
+// ScoresFromDeckPlacements はデッキ配置の各ブロックのスコアを "y_x" キーのマップにまとめます。
+// ブロックの座標は草グリッドの座標（x: 週、y: 曜日）で、ボードやプレビューでも同じ座標のマスにそのまま対応させます。
+// width x height の範囲外のブロックは無視し、配置のないセルはマップに含めません。
+//
+// Parameters:
+//   pieces : デッキのテトリミノ配置
+//   width  : グリッドの幅
+//   height : グリッドの高さ
+func ScoresFromDeckPlacements(pieces []DeckPlacementPiece, width, height int) map[string]int {
+	scores := make(map[string]int)
+	for _, deckPiece := range pieces {
+		for _, block := range deckPiece.Blocks {
+			if block.X >= 0 && block.X < width && block.Y >= 0 && block.Y < height {
+				scores[strconv.Itoa(block.Y)+"_"+strconv.Itoa(block.X)] = block.Score
+			}
+		}
+	}
+	return scores
+}
+
+// ContributionBlockScore はその日の貢献数からブロックのスコアを計算します。
+// 草が無い日（0以下）は DefaultContributionScore を返します。
+func ContributionBlockScore(count int) int {
+	if count <= 0 {
+		return DefaultContributionScore
+	}
+	score := DefaultContributionScore + count*ContributionScorePerCount
+	if score > MaxContributionBlockScore {
+		return MaxContributionBlockScore
+	}
+	return score
+}
+
+// PlacementBlockDates はデッキ配置の各ブロックが覆う草の日付（"YYYY-MM-DD"）を Blocks と同じ順で返します。
+// 草グリッドはxが週、yが曜日なので、配置の左上（最小x・最小y）のマスを StartDate として
+// 1列右に進むごとに7日、1行下に進むごとに1日ずれます。
+// StartDate が未設定の配置は日付を決められないため nil を返します。
+func PlacementBlockDates(piece DeckPlacementPiece) []string {
+	if piece.StartDate.IsZero() || len(piece.Blocks) == 0 {
+		return nil
+	}
+
+	minX, minY := piece.Blocks[0].X, piece.Blocks[0].Y
+	for _, block := range piece.Blocks[1:] {
+		if block.X < minX {
+			minX = block.X
+		}
+		if block.Y < minY {
+			minY = block.Y
+		}
+	}
+
+	dates := make([]string, len(piece.Blocks))
+	for i, block := range piece.Blocks {
+		offset := (block.X-minX)*7 + (block.Y - minY)
+		dates[i] = piece.StartDate.AddDate(0, 0, offset).Format(models.ContributionDateLayout)
+	}
+	return dates
+}
+
+// ScoreDeckPlacementsByContributions はデッキ配置の各ブロックのスコアを、そのブロックが覆う日の貢献数から決め直します。
+// 「いつの草の上にどのテトリミノを置いたか」でスコアが変わるようにするための処理で、
+// 草が無い日は DefaultContributionScore にフォールバックします。
+// StartDate が未設定の配置は保存済みのスコアをそのまま使います。元のスライスは変更しません。
+//
+// Parameters:
+//   pieces : デッキのテトリミノ配置
+//   counts : 日付（"YYYY-MM-DD"）-> 貢献数 のマップ
+func ScoreDeckPlacementsByContributions(pieces []DeckPlacementPiece, counts map[string]int) []DeckPlacementPiece {
+	scored := make([]DeckPlacementPiece, len(pieces))
+	for i, piece := range pieces {
+		scored[i] = piece
+		dates := PlacementBlockDates(piece)
+		if dates == nil {
+			continue
+		}
+
+		blocks := make([]models.Position, len(piece.Blocks))
+		for j, block := range piece.Blocks {
+			block.Score = ContributionBlockScore(counts[dates[j]])
+			blocks[j] = block
+		}
+		scored[i].Blocks = blocks
+	}
+	return scored
+}
@@ -0,0 +1,89 @@
+package tetris
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDeckPlacementsFromRecords はDBの配置レコードを変換し、不明なタイプや壊れた座標のレコードをスキップすることをテストします。
+func TestDeckPlacementsFromRecords(t *testing.T) {
+	positions, _ := json.Marshal([]models.Position{{X: 1, Y: 2, Score: 30}})
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	pieces := DeckPlacementsFromRecords([]models.TetriminoPlacement{
+		{TetriminoType: "T", Rotation: 90, StartDate: start, Positions: positions},
+		{TetriminoType: "X", Positions: positions},
+		{TetriminoType: "O", Positions: []byte("not json")},
+	})
+
+	assert.Equal(t, []DeckPlacementPiece{
+		{Type: TypeT, Rotation: 90, StartDate: start, Blocks: []models.Position{{X: 1, Y: 2, Score: 30}}},
+	}, pieces)
+}
+
+// TestScoresFromDeckPlacements はデッキ配置のスコアが範囲内のセルだけマップに入ることをテストします。
+func TestScoresFromDeckPlacements(t *testing.T) {
+	pieces := []DeckPlacementPiece{
+		{Type: TypeO, Blocks: []models.Position{
+			{X: 0, Y: 0, Score: 10},
+			{X: 7, Y: 6, Score: 40},
+			{X: 8, Y: 0, Score: 99}, // 範囲外
+		}},
+	}
+
+	scores := ScoresFromDeckPlacements(pieces, 8, 7)
+	assert.Equal(t, map[string]int{"0_0": 10, "6_7": 40}, scores)
+}
+
+// TestPlacementBlockDates は配置の左上を基準日として、週（x）と曜日（y）から各ブロックの日付を計算することをテストします。
+func TestPlacementBlockDates(t *testing.T) {
+	piece := DeckPlacementPiece{
+		Type:      TypeO,
+		StartDate: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		Blocks: []models.Position{
+			{X: 4, Y: 2}, {X: 5, Y: 2},
+			{X: 4, Y: 3}, {X: 5, Y: 3},
+		},
+	}
+
+	dates := PlacementBlockDates(piece)
+
+	assert.Equal(t, []string{"2026-03-01", "2026-03-08", "2026-03-02", "2026-03-09"}, dates)
+	assert.Nil(t, PlacementBlockDates(DeckPlacementPiece{Blocks: piece.Blocks}), "StartDate が無い配置は日付を決められないはず")
+}
+
+// TestScoreDeckPlacementsByContributions は草の数でブロックのスコアが決まり、草が無い日はデフォルトになることをテストします。
+func TestScoreDeckPlacementsByContributions(t *testing.T) {
+	pieces := []DeckPlacementPiece{
+		{
+			Type:      TypeI,
+			StartDate: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+			Blocks: []models.Position{
+				{X: 0, Y: 0, Score: 7}, {X: 0, Y: 1, Score: 7}, {X: 0, Y: 2, Score: 7}, {X: 0, Y: 3, Score: 7},
+			},
+		},
+		{
+			// StartDate の無い古い配置は保存済みのスコアを使う
+			Type:   TypeT,
+			Blocks: []models.Position{{X: 2, Y: 0, Score: 42}},
+		},
+	}
+	counts := map[string]int{
+		"2026-03-01": 1,
+		"2026-03-02": 4,
+		"2026-03-04": 100,
+	}
+
+	scored := ScoreDeckPlacementsByContributions(pieces, counts)
+
+	assert.Equal(t, DefaultContributionScore+ContributionScorePerCount, scored[0].Blocks[0].Score)
+	assert.Equal(t, DefaultContributionScore+4*ContributionScorePerCount, scored[0].Blocks[1].Score)
+	assert.Equal(t, DefaultContributionScore, scored[0].Blocks[2].Score, "草が無い日はデフォルトスコアのはず")
+	assert.Equal(t, MaxContributionBlockScore, scored[0].Blocks[3].Score, "上限でクランプされるはず")
+	assert.Equal(t, 42, scored[1].Blocks[0].Score)
+	assert.Equal(t, 7, pieces[0].Blocks[0].Score, "元の配置は変更しないはず")
+}
@@ -31,14 +31,15 @@ type Piece struct {
 // pieceShapes は各PieceTypeの各回転状態におけるブロックの相対座標を定義します。
 // [PieceType][RotationIndex][BlockIndex][Coordinate (x or y)]
 // 座標はテトリミノの基準点からの相対値です。
+// 回転状態の形状はSRSの座標表（JLSTZ は3x3、I は4x4の枠）と一致します（TestPieceShapesIntegrity で検証）。
 // Super Rotation System (SRS) に完全に準拠するためには、
 // キックテーブル（回転時の壁蹴りルール）も考慮する必要があります。
 var pieceShapes = map[PieceType][][][2]int{
 	TypeI: { // I-ミノ (長方形の中心が回転軸に近い)
 		{{0, 1}, {1, 1}, {2, 1}, {3, 1}}, // 0度 (横)
 		{{2, 0}, {2, 1}, {2, 2}, {2, 3}}, // 90度 (縦)
-		{{0, 2}, {1, 2}, {2, 2}, {3, 2}}, // 180度 (横) - SRSでは異なる場合もある
-		{{1, 0}, {1, 1}, {1, 2}, {1, 3}}, // 270度 (縦) - SRSでは異なる場合もある
+		{{0, 2}, {1, 2}, {2, 2}, {3, 2}}, // 180度 (横)
+		{{1, 0}, {1, 1}, {1, 2}, {1, 3}}, // 270度 (縦)
 	},
 	TypeO: { // O-ミノ (正方形、回転しない)
 		{{0, 0}, {1, 0}, {0, 1}, {1, 1}}, // 全ての回転で同じ
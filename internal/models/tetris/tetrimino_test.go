@@ -0,0 +1,111 @@
+package tetris
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// srsShapes はSRS（Super Rotation System）の各回転状態の基準となる座標表です。
+// JLSTZ は3x3、I は4x4の枠の左上を原点とし、y は下向きです（Oミノは回転しないため含めません）。
+var srsShapes = map[PieceType][4][][2]int{
+	TypeI: {
+		{{0, 1}, {1, 1}, {2, 1}, {3, 1}},
+		{{2, 0}, {2, 1}, {2, 2}, {2, 3}},
+		{{0, 2}, {1, 2}, {2, 2}, {3, 2}},
+		{{1, 0}, {1, 1}, {1, 2}, {1, 3}},
+	},
+	TypeT: {
+		{{1, 0}, {0, 1}, {1, 1}, {2, 1}},
+		{{1, 0}, {1, 1}, {2, 1}, {1, 2}},
+		{{0, 1}, {1, 1}, {2, 1}, {1, 2}},
+		{{1, 0}, {0, 1}, {1, 1}, {1, 2}},
+	},
+	TypeS: {
+		{{1, 0}, {2, 0}, {0, 1}, {1, 1}},
+		{{1, 0}, {1, 1}, {2, 1}, {2, 2}},
+		{{1, 1}, {2, 1}, {0, 2}, {1, 2}},
+		{{0, 0}, {0, 1}, {1, 1}, {1, 2}},
+	},
+	TypeZ: {
+		{{0, 0}, {1, 0}, {1, 1}, {2, 1}},
+		{{2, 0}, {1, 1}, {2, 1}, {1, 2}},
+		{{0, 1}, {1, 1}, {1, 2}, {2, 2}},
+		{{1, 0}, {0, 1}, {1, 1}, {0, 2}},
+	},
+	TypeJ: {
+		{{0, 0}, {0, 1}, {1, 1}, {2, 1}},
+		{{1, 0}, {2, 0}, {1, 1}, {1, 2}},
+		{{0, 1}, {1, 1}, {2, 1}, {2, 2}},
+		{{1, 0}, {1, 1}, {0, 2}, {1, 2}},
+	},
+	TypeL: {
+		{{2, 0}, {0, 1}, {1, 1}, {2, 1}},
+		{{1, 0}, {1, 1}, {1, 2}, {2, 2}},
+		{{0, 1}, {1, 1}, {2, 1}, {0, 2}},
+		{{0, 0}, {1, 0}, {1, 1}, {1, 2}},
+	},
+}
+
+// sortedBlocks はブロックの並び順に依存せず比較できるよう、座標を (y, x) の昇順に並べたコピーを返します。
+func sortedBlocks(blocks [][2]int) [][2]int {
+	sorted := append([][2]int(nil), blocks...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i][1] != sorted[j][1] {
+			return sorted[i][1] < sorted[j][1]
+		}
+		return sorted[i][0] < sorted[j][0]
+	})
+	return sorted
+}
+
+// rotateClockwise はブロックを size x size の枠の中で時計回りに90度回転させます。
+func rotateClockwise(blocks [][2]int, size int) [][2]int {
+	rotated := make([][2]int, len(blocks))
+	for i, b := range blocks {
+		rotated[i] = [2]int{size - 1 - b[1], b[0]}
+	}
+	return rotated
+}
+
+// TestPieceShapesIntegrity は全PieceTypeの全回転状態について、4つの重複しないブロックで構成され、
+// SRSの座標表と一致し、隣り合う回転状態が枠の中心まわりの90度回転になっていることをテストします。
+// Oミノはどの回転角度でも形状が変わらないことを確認します。
+func TestPieceShapesIntegrity(t *testing.T) {
+	assert.Len(t, pieceShapes, 7, "7種類すべてのテトリミノの形状が定義されているはず")
+
+	for pieceType := TypeI; pieceType <= TypeL; pieceType++ {
+		t.Run(PieceTypeToString(pieceType), func(t *testing.T) {
+			piece := &Piece{Type: pieceType}
+			for rotation := 0; rotation < 360; rotation += 90 {
+				blocks := piece.GetBlocksAtRotation(rotation)
+				assert.Len(t, blocks, 4, "回転 %d のブロック数", rotation)
+				seen := make(map[[2]int]bool)
+				for _, b := range blocks {
+					assert.False(t, seen[b], "回転 %d で座標 %v が重複している", rotation, b)
+					seen[b] = true
+				}
+			}
+
+			if pieceType == TypeO {
+				for rotation := 90; rotation < 360; rotation += 90 {
+					assert.Equal(t, piece.GetBlocksAtRotation(0), piece.GetBlocksAtRotation(rotation), "Oミノは回転で形状が変わらないはず")
+				}
+				return
+			}
+
+			size := 3
+			if pieceType == TypeI {
+				size = 4
+			}
+			for i := 0; i < 4; i++ {
+				blocks := piece.GetBlocksAtRotation(i * 90)
+				assert.Equal(t, sortedBlocks(srsShapes[pieceType][i]), sortedBlocks(blocks), "回転 %d がSRSの座標表と一致するはず", i*90)
+
+				next := piece.GetBlocksAtRotation((i + 1) % 4 * 90)
+				assert.Equal(t, sortedBlocks(next), sortedBlocks(rotateClockwise(blocks, size)), "回転 %d から時計回りに90度回した形状のはず", i*90)
+			}
+		})
+	}
+}
@@ -1,41 +1,112 @@
 package services
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"time"
 
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database" // プロジェクトのルートパスに合わせて修正
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"   // modelsパッケージをインポート
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
 	// プロジェクトのルートパスに合わせて修正
 )
 
+const (
+	// MaxTetriminosPerDeck は1デッキに含められるテトリミノ数の上限です（草グリッド8週間×7日 = 56マス分）。
+	MaxTetriminosPerDeck = 56
+	// MaxPositionsPerTetrimino は1テトリミノあたりのブロック数の上限です。
+	MaxPositionsPerTetrimino = 4
+	// PreviewGridWeeks はデッキプレビューの列数（草グリッドの週数）です。
+	PreviewGridWeeks = models.ContributionWeeks
+	// PreviewGridDays はデッキプレビューの行数（草グリッドの曜日数）です。
+	PreviewGridDays = models.ContributionGridDays
+	// MaxPreviewLevel はデッキプレビューの強度レベルの最大値です（ゲームの草レベルと同じ 0-4 の5段階）。
+	MaxPreviewLevel = tetris.MaxContributionLevel
+	// DefaultDeckBlockScore はデフォルトデッキの各ブロックのスコアです（チュートリアル用に控えめな均一値）。
+	DefaultDeckBlockScore = 10
+)
+
+// ErrInvalidDeck はデッキの内容がバリデーションに失敗した場合のエラーです。
+var ErrInvalidDeck = errors.New("デッキの内容が不正です")
+
+// ErrDeckForbidden は所有者以外が非公開デッキにアクセスした場合のエラーです。
+var ErrDeckForbidden = errors.New("このデッキは非公開です")
+
+// ContributionSource はデッキ保存時のスコア算出に使う、ユーザーの保存済み草データの取得元です。
+// *database.DatabaseService が実装しています。
+type ContributionSource interface {
+	GetContributionsByUserID(ctx context.Context, userID string) ([]models.DailyContribution, error)
+}
+
 // DeckService はデッキ関連のビジネスロジックを定義するインターフェースです。
 type DeckService interface {
-	SaveDeck(userID string, tetriminos []models.TetriminoPlacementRequest) error
-	GetDeckWithPlacementsByUserID(userID string) (*models.DeckWithPlacements, error)
+	SaveDeck(ctx context.Context, userID string, tetriminos []models.TetriminoPlacementRequest, expectedVersion *int) (*models.Deck, bool, error)
+	GetDeckWithPlacementsByUserID(ctx context.Context, userID, requesterID string) (*models.DeckWithPlacements, error)
+	SetDeckVisibility(ctx context.Context, userID string, isPublic bool) error
+	GetDeckPreview(ctx context.Context, userID, deckID string) ([][]int, error)
+	EnsureDefaultDeck(ctx context.Context, userID string) (*models.Deck, error)
 }
 
 // deckServiceImpl はDeckServiceインターフェースの実装です。
 type deckServiceImpl struct {
-	db          *sql.DB
-	deckRepo    database.DeckRepository
+	db            *sql.DB
+	deckRepo      database.DeckRepository
+	contributions ContributionSource // 配置のスコア算出に使う草データの取得元
 }
 
 // NewDeckService はDeckServiceの新しいインスタンスを作成します。
-func NewDeckService(db *sql.DB, deckRepo database.DeckRepository) DeckService {
+func NewDeckService(db *sql.DB, deckRepo database.DeckRepository, contributions ContributionSource) DeckService {
 	return &deckServiceImpl{
-		db:          db,
-		deckRepo:    deckRepo,
+		db:            db,
+		deckRepo:      deckRepo,
+		contributions: contributions,
 	}
 }
 
 // SaveDeck はユーザーのデッキデータを保存するビジネスロジックを実行します。
-// 既存のデッキ配置を削除し、新しい配置を挿入し、デッキの合計スコアを更新します。
-func (s *deckServiceImpl) SaveDeck(userID string, tetriminos []models.TetriminoPlacementRequest) error {
-	tx, err := s.db.Begin()
+// 既存のデッキ配置を削除し、新しい配置を挿入し、デッキの合計スコアとContribution期間を更新します。
+// 各ブロックのスコアと score_potential はクライアントの申告を使わず、ブロックが覆う日のユーザーの草データから算出します。
+// 期間は配置の start_date から決め（deckPeriod）、期間外の日付を覆う配置があれば ErrInvalidDeck を返します。
+// 複数端末での上書きを防ぐため、expectedVersion が現在のバージョンと異なる場合は
+// database.ErrDeckVersionConflict を返します（nil の場合はチェックしません）。
+//
+// Parameters:
+//   ctx             : キャンセル・タイムアウトでクエリを打ち切るためのコンテキスト
+//   userID          : デッキを保存するユーザーのID
+//   tetriminos      : 保存するテトリミノ配置
+//   expectedVersion : クライアントがデッキを読み込んだ時点のバージョン
+//
+// Returns:
+//   *models.Deck: 保存後のデッキ（ID・total_score・バージョン・updated_at を含む）
+//   bool: デッキを新規作成したかどうか（true: 作成、false: 既存デッキを更新）
+//   error: エラーが発生した場合
+func (s *deckServiceImpl) SaveDeck(ctx context.Context, userID string, tetriminos []models.TetriminoPlacementRequest, expectedVersion *int) (*models.Deck, bool, error) {
+	// DBに触る前にリクエスト内容を検証します
+	if err := validateDeck(tetriminos); err != nil {
+		return nil, false, err
+	}
+
+	// クライアント申告のスコアは信頼せず、保存済みの草データから算出し直します
+	counts, err := s.contributionCounts(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("トランザクションの開始に失敗しました: %w", err)
+		return nil, false, err
+	}
+	tetriminos, err = scorePlacementsByContributions(tetriminos, counts)
+	if err != nil {
+		return nil, false, err
+	}
+	periodStart, periodEnd, err := deckPeriod(tetriminos, models.ContributionWeeks)
+	if err != nil {
+		return nil, false, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("トランザクションの開始に失敗しました: %w", err)
 	}
 	defer func() {
 		if r := recover(); r != nil { // パニック発生時にリカバリー
@@ -47,93 +118,415 @@ func (s *deckServiceImpl) SaveDeck(userID string, tetriminos []models.TetriminoP
 	}()
 
 	// ユーザーの既存のデッキを取得または新規作成します
-	deck, err := s.deckRepo.GetDeckByUserID(tx, userID)
+	deck, err := s.deckRepo.GetDeckByUserID(ctx, tx, userID)
 	if err != nil {
-		return fmt.Errorf("デッキの取得に失敗しました: %w", err)
+		return nil, false, fmt.Errorf("デッキの取得に失敗しました: %w", err)
 	}
 
 	var deckID string
-	if deck == nil {
+	created := deck == nil
+	if created {
 		// デッキが存在しない場合、新規作成します
-		newDeck, err := s.deckRepo.CreateDeck(tx, userID, 0) // total_scoreは後で更新
+		newDeck, err := s.deckRepo.CreateDeck(ctx, tx, userID, 0) // total_scoreは後で更新
 		if err != nil {
-			return fmt.Errorf("新しいデッキの作成に失敗しました: %w", err)
+			return nil, false, fmt.Errorf("新しいデッキの作成に失敗しました: %w", err)
 		}
 		deckID = newDeck.ID
+		expectedVersion = &newDeck.Version // 新規作成したデッキはこのトランザクション内のバージョンを基準にする
 		log.Printf("ユーザー %s の新しいデッキが作成されました: %s", userID, deckID)
 	} else {
 		deckID = deck.ID
 	}
 
+	// 配置を書き換える前に、楽観ロック付きでtotal_scoreとversionを更新します。
+	// UPDATEで行ロックを取るため、同時に保存した端末のうち後から来た方はバージョン不一致で失敗します。
+	newTotalScore := 0
+	for _, t := range tetriminos {
+		newTotalScore += t.ScorePotential
+	}
+	savedDeck, err := s.deckRepo.UpdateDeckTotalScoreWithVersion(ctx, tx, deckID, newTotalScore, periodStart, periodEnd, expectedVersion)
+	if err != nil {
+		return nil, false, err
+	}
+	log.Printf("デッキ %s のtotal_scoreが %d に更新されました (version %d)。", deckID, newTotalScore, savedDeck.Version)
+
 	// 該当ユーザーの既存のtetrimino_placementsレコードを全て削除します
-	err = s.deckRepo.DeleteTetriminoPlacementsByDeckID(tx, deckID)
+	err = s.deckRepo.DeleteTetriminoPlacementsByDeckID(ctx, tx, deckID)
 	if err != nil {
-		return fmt.Errorf("既存のテトリミノ配置の削除に失敗しました: %w", err)
+		return nil, false, fmt.Errorf("既存のテトリミノ配置の削除に失敗しました: %w", err)
 	}
 	log.Printf("デッキ %s の既存のテトリミノ配置が削除されました。", deckID)
 
 	// 受け取ったtetriminos配列の各要素をtetrimino_placementsテーブルに新規レコードとして挿入します
-	err = s.deckRepo.BulkInsertTetriminoPlacements(tx, deckID, tetriminos)
+	err = s.deckRepo.BulkInsertTetriminoPlacements(ctx, tx, deckID, tetriminos)
 	if err != nil {
-		return fmt.Errorf("テトリミノ配置の挿入に失敗しました: %w", err)
+		return nil, false, fmt.Errorf("テトリミノ配置の挿入に失敗しました: %w", err)
 	}
 	log.Printf("デッキ %s に %d 個のテトリミノ配置が挿入されました。", deckID, len(tetriminos))
 
-	// decksテーブルのtotal_scoreを更新します
-	newTotalScore := 0
-	for _, t := range tetriminos {
-		newTotalScore += t.ScorePotential
-	}
-	err = s.deckRepo.UpdateDeckTotalScore(tx, deckID, newTotalScore)
-	if err != nil {
-		return fmt.Errorf("デッキの合計スコアの更新に失敗しました: %w", err)
-	}
-	log.Printf("デッキ %s のtotal_scoreが %d に更新されました。", deckID, newTotalScore)
-
 	// トランザクションをコミットします
 	err = tx.Commit()
 	if err != nil {
-		return fmt.Errorf("トランザクションのコミットに失敗しました: %w", err)
+		return nil, false, fmt.Errorf("トランザクションのコミットに失敗しました: %w", err)
+	}
+	// 配置データをキャッシュしているリポジトリなら、コミット後の内容を次の対戦から使うよう無効化する
+	if cache, ok := s.deckRepo.(database.PlacementCacheInvalidator); ok {
+		cache.InvalidatePlacements(deckID)
 	}
 
 	log.Println("デッキが正常に保存されました。")
+	return savedDeck, created, nil
+}
+
+// validateDeck はデッキ保存リクエストのテトリミノ配置を検証します。
+// 悪意あるペイロードで大量のINSERTが走らないよう、テトリミノ数とブロック数の上限をチェックし、
+// ゲームバランスのため通常モードのテトリミノの種類ごと・合計の枚数の上限（DeckPieceLimits）もチェックします。
+func validateDeck(tetriminos []models.TetriminoPlacementRequest) error {
+	if len(tetriminos) > MaxTetriminosPerDeck {
+		return fmt.Errorf("%w: テトリミノ数 %d が上限 %d を超えています", ErrInvalidDeck, len(tetriminos), MaxTetriminosPerDeck)
+	}
+	for i, t := range tetriminos {
+		if len(t.Positions) > MaxPositionsPerTetrimino {
+			return fmt.Errorf("%w: %d 番目のテトリミノ (%s) のブロック数 %d が上限 %d を超えています", ErrInvalidDeck, i, t.Type, len(t.Positions), MaxPositionsPerTetrimino)
+		}
+	}
+	if err := checkPieceLimits(tetriminos, DeckPieceLimitsFor(DeckModeStandard)); err != nil {
+		return err
+	}
+	// 草データの取得期間（週数）がそのまま草グリッドの列数になります
+	return checkPlacementOverlap(tetriminos, models.ContributionWeeks)
+}
+
+// checkPlacementOverlap は全テトリミノの占有セルを草グリッド（ContributionGridDays 行 × weeks 列）に展開し、
+// 範囲外のブロックや、同じセルを複数のテトリミノ（または同じテトリミノの複数ブロック）が占有していないかを検証します。
+// 問題があれば、どのテトリミノかを示すエラー（ErrInvalidDeck をラップ）を返します。
+//
+// Parameters:
+//   tetriminos : 検証するテトリミノ配置（Positions の x が週、y が曜日）
+//   weeks      : 草グリッドの列数（デッキ保存時点のContribution取得期間の週数）
+func checkPlacementOverlap(tetriminos []models.TetriminoPlacementRequest, weeks int) error {
+	occupied := make(map[models.Position]int) // セル座標 -> 占有しているテトリミノの番号
+	for i, t := range tetriminos {
+		for _, p := range t.Positions {
+			if p.X < 0 || p.X >= weeks || p.Y < 0 || p.Y >= models.ContributionGridDays {
+				return fmt.Errorf("%w: %d 番目のテトリミノ (%s) のブロック (%d, %d) が草グリッド (%d×%d) の範囲外です", ErrInvalidDeck, i, t.Type, p.X, p.Y, weeks, models.ContributionGridDays)
+			}
+			cell := models.Position{X: p.X, Y: p.Y}
+			if other, ok := occupied[cell]; ok {
+				return fmt.Errorf("%w: %d 番目のテトリミノ (%s) のブロック (%d, %d) が %d 番目のテトリミノと重なっています", ErrInvalidDeck, i, t.Type, p.X, p.Y, other)
+			}
+			occupied[cell] = i
+		}
+	}
 	return nil
 }
 
+// deckPeriod はテトリミノ配置の start_date から、デッキが対象とするContribution期間（weeks 週分）を決めます。
+// 各配置の左上のマスの日付と位置から草グリッドの左上（x=0, y=0）の日付を逆算し、最も早いものを期間の初日とします。
+// 配置のずれなどで期間外の日付を覆うブロックがあれば、どのテトリミノかを示すエラー（ErrInvalidDeck をラップ）を返します。
+// 期間は必ず最終日が初日より後になるよう、weeks は1以上である必要があります。
+//
+// Parameters:
+//   tetriminos : 検証済みのテトリミノ配置（StartDate は配置の左上のマスの日付）
+//   weeks      : 草グリッドの列数（期間の週数）
+// Returns:
+//   string: 期間の初日（YYYY-MM-DD、ブロックを持つ配置が無い場合は空文字列）
+//   string: 期間の最終日（同上）
+//   error: weeks が1未満の場合、日付の形式が不正な場合、期間外の日付を覆う配置がある場合
+func deckPeriod(tetriminos []models.TetriminoPlacementRequest, weeks int) (string, string, error) {
+	if weeks < 1 {
+		return "", "", fmt.Errorf("%w: 期間の週数 %d は1以上である必要があります", ErrInvalidDeck, weeks)
+	}
+	pieces := make([]tetris.DeckPlacementPiece, len(tetriminos)) // ブロックが無い配置はゼロ値のまま（日付を求めない）
+	var start time.Time
+	for i, t := range tetriminos {
+		if len(t.Positions) == 0 {
+			continue
+		}
+		startDate, err := time.ParseInLocation(models.ContributionDateLayout, t.StartDate, models.JST)
+		if err != nil {
+			return "", "", fmt.Errorf("%w: %d 番目のテトリミノ (%s) の開始日 %q の形式が正しくありません", ErrInvalidDeck, i, t.Type, t.StartDate)
+		}
+		minX, minY := t.Positions[0].X, t.Positions[0].Y
+		for _, p := range t.Positions[1:] {
+			minX = min(minX, p.X)
+			minY = min(minY, p.Y)
+		}
+		if origin := startDate.AddDate(0, 0, -(minX*models.ContributionGridDays + minY)); start.IsZero() || origin.Before(start) {
+			start = origin
+		}
+		pieces[i] = tetris.DeckPlacementPiece{StartDate: startDate, Blocks: t.Positions}
+	}
+	if start.IsZero() {
+		return "", "", nil
+	}
+
+	periodStart := start.Format(models.ContributionDateLayout)
+	periodEnd := start.AddDate(0, 0, weeks*models.ContributionGridDays-1).Format(models.ContributionDateLayout)
+	for i, piece := range pieces {
+		for j, date := range tetris.PlacementBlockDates(piece) {
+			// YYYY-MM-DD 形式なので文字列の比較で日付の前後を判定できる
+			if date < periodStart || date > periodEnd {
+				block := piece.Blocks[j]
+				return "", "", fmt.Errorf("%w: %d 番目のテトリミノのブロック (%d, %d) の日付 %s がデッキの期間 %s〜%s の範囲外です", ErrInvalidDeck, i, block.X, block.Y, date, periodStart, periodEnd)
+			}
+		}
+	}
+	return periodStart, periodEnd, nil
+}
+
+// contributionCounts はユーザーの保存済み草データを 日付（"YYYY-MM-DD"）-> 貢献数 のマップで返します。
+func (s *deckServiceImpl) contributionCounts(ctx context.Context, userID string) (map[string]int, error) {
+	counts := make(map[string]int)
+	if s.contributions == nil {
+		return counts, nil
+	}
+	contributions, err := s.contributions.GetContributionsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("スコア算出用の草データの取得に失敗しました: %w", err)
+	}
+	for _, c := range contributions {
+		counts[c.Date] = c.Count
+	}
+	return counts, nil
+}
+
+// scorePlacementsByContributions は各テトリミノのブロックのスコアを、ブロックが覆う日の貢献数から算出し、
+// その合計を ScorePotential に設定した配置を返します。算出はゲーム開始時の tetris.ScoreDeckPlacementsByContributions と共通で、
+// 草が無い日のブロックは tetris.DefaultContributionScore になります。元のスライスは変更しません。
+//
+// Parameters:
+//   tetriminos : 保存するテトリミノ配置（StartDate は配置の左上のマスの日付）
+//   counts     : 日付（"YYYY-MM-DD"）-> 貢献数 のマップ
+func scorePlacementsByContributions(tetriminos []models.TetriminoPlacementRequest, counts map[string]int) ([]models.TetriminoPlacementRequest, error) {
+	scored := make([]models.TetriminoPlacementRequest, len(tetriminos))
+	for i, t := range tetriminos {
+		startDate, err := time.ParseInLocation(models.ContributionDateLayout, t.StartDate, models.JST)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %d 番目のテトリミノ (%s) の開始日 %q の形式が正しくありません", ErrInvalidDeck, i, t.Type, t.StartDate)
+		}
+		piece := tetris.ScoreDeckPlacementsByContributions([]tetris.DeckPlacementPiece{{StartDate: startDate, Blocks: t.Positions}}, counts)[0]
+		t.Positions = piece.Blocks
+		t.ScorePotential = 0
+		for _, p := range t.Positions {
+			t.ScorePotential += p.Score
+		}
+		scored[i] = t
+	}
+	return scored, nil
+}
+
 // GetDeckWithPlacementsByUserID は指定されたユーザーIDのデッキとそのテトリミノ配置情報を取得します。
-func (s *deckServiceImpl) GetDeckWithPlacementsByUserID(userID string) (*models.DeckWithPlacements, error) {
-	// 読み取り専用操作なのでトランザクションは必須ではないが、一貫性のために使用することも可能
-	// 今回はシンプルにtx=nilでリポジトリメソッドを呼び出す（直接dbを使う）
-	deck, err := s.deckRepo.GetDeckByUserID(nil, userID) // tx=nilで呼び出す
+// リポジトリのJOINクエリに委譲し、DBへのラウンドトリップを1回に抑えます。
+// 所有者には全フィールドを返し、他のユーザーには公開デッキの概要（スコアポテンシャルと配置の種類・回転）のみを返します。
+// 他のユーザーが非公開デッキにアクセスした場合は ErrDeckForbidden を返します。
+//
+// Parameters:
+//   ctx         : キャンセル・タイムアウトでクエリを打ち切るためのコンテキスト
+//   userID      : 取得するデッキの所有者のユーザーID
+//   requesterID : リクエストした認証済みユーザーのID
+func (s *deckServiceImpl) GetDeckWithPlacementsByUserID(ctx context.Context, userID, requesterID string) (*models.DeckWithPlacements, error) {
+	deckWithPlacements, err := s.deckRepo.GetDeckWithPlacementsByUserID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("ユーザーID '%s' のデッキ取得に失敗しました: %w", userID, err)
 	}
+	if deckWithPlacements == nil || deckWithPlacements.Deck == nil {
+		return nil, nil // デッキが存在しない場合はnil
+	}
+	if userID == requesterID {
+		return deckWithPlacements, nil // 所有者は全フィールド
+	}
+	if !deckWithPlacements.Deck.IsPublic {
+		return nil, fmt.Errorf("ユーザーID '%s' のデッキ: %w", userID, ErrDeckForbidden)
+	}
+	return publicDeckSummary(deckWithPlacements), nil
+}
+
+// publicDeckSummary は他のユーザー向けに、配置の正確な位置や日付を除いたデッキの概要を返します。
+func publicDeckSummary(d *models.DeckWithPlacements) *models.DeckWithPlacements {
+	summary := &models.DeckWithPlacements{
+		Deck: &models.Deck{
+			ID:         d.Deck.ID,
+			UserID:     d.Deck.UserID,
+			TotalScore: d.Deck.TotalScore,
+			IsPublic:   d.Deck.IsPublic,
+			UpdatedAt:  d.Deck.UpdatedAt,
+		},
+		Placements: make([]models.TetriminoPlacementAPI, 0, len(d.Placements)),
+	}
+	for _, p := range d.Placements {
+		summary.Placements = append(summary.Placements, models.TetriminoPlacementAPI{
+			TetriminoType:  p.TetriminoType,
+			Rotation:       p.Rotation,
+			ScorePotential: p.ScorePotential,
+		})
+	}
+	return summary
+}
+
+// SetDeckVisibility はユーザーのデッキの公開/非公開を切り替えます。
+// デッキが存在しない場合は database.ErrDeckNotFound を返します。
+func (s *deckServiceImpl) SetDeckVisibility(ctx context.Context, userID string, isPublic bool) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("トランザクションの開始に失敗しました: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	deck, err := s.deckRepo.GetDeckByUserID(ctx, tx, userID)
+	if err != nil {
+		return fmt.Errorf("ユーザーID '%s' のデッキ取得に失敗しました: %w", userID, err)
+	}
 	if deck == nil {
-		return nil, nil // デッキが存在しない
+		return fmt.Errorf("ユーザーID '%s': %w", userID, database.ErrDeckNotFound)
 	}
+	if err = s.deckRepo.UpdateDeckVisibility(ctx, tx, deck.ID, isPublic); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("トランザクションのコミットに失敗しました: %w", err)
+	}
+	log.Printf("デッキ %s の公開設定が %v に更新されました。", deck.ID, isPublic)
+	return nil
+}
 
-	placements, err := s.deckRepo.GetTetriminoPlacementsByDeckID(nil, deck.ID) // tx=nilで呼び出す
+// GetDeckPreview はデッキ一覧のサムネイル描画用に、テトリミノ配置を草グリッド座標にマッピングした強度配列を返します。
+// 返り値は [曜日][週] の PreviewGridDays x PreviewGridWeeks の2次元配列で、各セルはスコアレベル 0-4 です。
+// レベルはデッキ内の最大スコアを基準に4段階に分け、配置のない空セルは0になります。
+// 画像そのものは生成せず、クライアントがSVGやCanvasで描画することを想定しています。
+//
+// Parameters:
+//   ctx    : キャンセル・タイムアウトでクエリを打ち切るためのコンテキスト
+//   userID : デッキ所有者のユーザーID
+//   deckID : プレビューを生成するデッキのID
+func (s *deckServiceImpl) GetDeckPreview(ctx context.Context, userID, deckID string) ([][]int, error) {
+	deck, err := s.deckRepo.GetDeckByUserID(ctx, nil, userID)
 	if err != nil {
-		return nil, fmt.Errorf("デッキID '%s' のテトリミノ配置取得に失敗しました: %w", deck.ID, err)
+		return nil, fmt.Errorf("ユーザーID '%s' のデッキ取得に失敗しました: %w", userID, err)
+	}
+	if deck == nil || deck.ID != deckID {
+		return nil, fmt.Errorf("ユーザーID '%s' のデッキ '%s': %w", userID, deckID, database.ErrDeckNotFound)
 	}
 
-	// APIレスポンス用のPlacementsを作成
-	apiPlacements := make([]models.TetriminoPlacementAPI, len(placements))
-	for i, p := range placements {
-		apiPlacements[i] = models.TetriminoPlacementAPI{
-			ID:            p.ID,
-			TetriminoType: p.TetriminoType,
-			Rotation:      p.Rotation,
-			StartDate:     p.StartDate.Format("2006-01-02"), // YYYY-MM-DD 形式にフォーマット
-			Positions:     p.Positions,                       // json.RawMessage をそのまま渡す
-			ScorePotential: p.ScorePotential,
+	placements, err := s.deckRepo.GetTetriminoPlacementsByDeckID(ctx, nil, deckID)
+	if err != nil {
+		return nil, fmt.Errorf("デッキ '%s' の配置取得に失敗しました: %w", deckID, err)
+	}
+
+	scores := tetris.ScoresFromDeckPlacements(tetris.DeckPlacementsFromRecords(placements), PreviewGridWeeks, PreviewGridDays)
+	return previewLevels(scores), nil
+}
+
+// previewLevels は "y_x" キーのスコアマップを強度レベルの2次元配列に変換します。
+func previewLevels(scores map[string]int) [][]int {
+	maxScore := 0
+	for _, score := range scores {
+		if score > maxScore {
+			maxScore = score
 		}
 	}
 
-	deckWithPlacements := &models.DeckWithPlacements{
-		Deck:       deck,
-		Placements: apiPlacements,
+	grid := make([][]int, PreviewGridDays)
+	for y := range grid {
+		grid[y] = make([]int, PreviewGridWeeks)
+		if maxScore == 0 {
+			continue
+		}
+		for x := range grid[y] {
+			score := scores[strconv.Itoa(y)+"_"+strconv.Itoa(x)]
+			if score <= 0 {
+				continue
+			}
+			// 1以上のスコアは最低でもレベル1になるよう切り上げます
+			grid[y][x] = (score*MaxPreviewLevel + maxScore - 1) / maxScore
+		}
+	}
+	return grid
+}
+
+// EnsureDefaultDeck はユーザーのデッキを返します。デッキが無い場合はデフォルトデッキを作成して返します。
+// デフォルトデッキはチュートリアル用に均一スコアのテトリミノを1つだけ配置した最小構成で、
+// SaveDeck と同じく配置から決めたContribution期間を保存します。ユーザーは後から SaveDeck で上書き保存できます。
+//
+// Parameters:
+//   ctx    : キャンセル・タイムアウトでクエリを打ち切るためのコンテキスト
+//   userID : デッキを用意するユーザーのID
+func (s *deckServiceImpl) EnsureDefaultDeck(ctx context.Context, userID string) (deck *models.Deck, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("トランザクションの開始に失敗しました: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	deck, err = s.deckRepo.GetDeckByUserID(ctx, tx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("デッキの取得に失敗しました: %w", err)
+	}
+	if deck != nil {
+		if err = tx.Commit(); err != nil {
+			return nil, fmt.Errorf("トランザクションのコミットに失敗しました: %w", err)
+		}
+		return deck, nil // 既存のデッキはそのまま使う
 	}
 
-	return deckWithPlacements, nil
-}
\ No newline at end of file
+	placements := defaultDeckPlacements(time.Now())
+	totalScore := 0
+	for _, p := range placements {
+		totalScore += p.ScorePotential
+	}
+	periodStart, periodEnd, err := deckPeriod(placements, models.ContributionWeeks)
+	if err != nil {
+		return nil, fmt.Errorf("デフォルトデッキの期間の決定に失敗しました: %w", err)
+	}
+
+	// 同じユーザーの初回アクセスが同時に来ても2つ目のデッキを作らないよう、一意制約で作成を1回にする
+	deck, created, err := s.deckRepo.CreateDeckIfAbsent(ctx, tx, userID, totalScore)
+	if err != nil {
+		return nil, fmt.Errorf("デフォルトデッキの作成に失敗しました: %w", err)
+	}
+	if !created {
+		if err = tx.Commit(); err != nil {
+			return nil, fmt.Errorf("トランザクションのコミットに失敗しました: %w", err)
+		}
+		return deck, nil // 他のリクエストが先に作成したデッキを使う
+	}
+	if err = s.deckRepo.BulkInsertTetriminoPlacements(ctx, tx, deck.ID, placements); err != nil {
+		return nil, fmt.Errorf("デフォルトデッキの配置の挿入に失敗しました: %w", err)
+	}
+	// 作成したデッキに期間を記録する（作成はこのトランザクション内なので、作成時のバージョンを基準にする）
+	if deck, err = s.deckRepo.UpdateDeckTotalScoreWithVersion(ctx, tx, deck.ID, totalScore, periodStart, periodEnd, &deck.Version); err != nil {
+		return nil, fmt.Errorf("デフォルトデッキの期間の保存に失敗しました: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("トランザクションのコミットに失敗しました: %w", err)
+	}
+
+	log.Printf("ユーザー %s のデフォルトデッキを作成しました: %s", userID, deck.ID)
+	return deck, nil
+}
+
+// defaultDeckPlacements はデフォルトデッキの配置を返します。
+// 草グリッドの原点（X0, Y0: 最初の週の先頭の日）から2×2の範囲にOミノを1つ置き、全ブロックを DefaultDeckBlockScore の均一スコアにします。
+func defaultDeckPlacements(now time.Time) []models.TetriminoPlacementRequest {
+	positions := []models.Position{
+		{X: 0, Y: 0, Score: DefaultDeckBlockScore},
+		{X: 1, Y: 0, Score: DefaultDeckBlockScore},
+		{X: 0, Y: 1, Score: DefaultDeckBlockScore},
+		{X: 1, Y: 1, Score: DefaultDeckBlockScore},
+	}
+	return []models.TetriminoPlacementRequest{{
+		Type:           "O",
+		Rotation:       0,
+		StartDate:      now.In(models.JST).Format(models.ContributionDateLayout),
+		Positions:      positions,
+		ScorePotential: DefaultDeckBlockScore * len(positions),
+	}}
+}
@@ -0,0 +1,498 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
+	"github.com/stretchr/testify/assert"
+)
+
+// txOnlyDriver はトランザクションの開始・コミット・ロールバックだけを受け付けるテスト用のSQLドライバです。
+// クエリはすべてフェイクのリポジトリが処理するため、実際のSQLは実行しません。
+type txOnlyDriver struct{}
+
+type txOnlyConn struct{}
+
+func (txOnlyDriver) Open(string) (driver.Conn, error)  { return txOnlyConn{}, nil }
+func (txOnlyConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (txOnlyConn) Close() error                        { return nil }
+func (txOnlyConn) Begin() (driver.Tx, error)           { return txOnlyConn{}, nil }
+func (txOnlyConn) Commit() error                       { return nil }
+func (txOnlyConn) Rollback() error                     { return nil }
+
+func init() {
+	sql.Register("txonly", txOnlyDriver{})
+}
+
+// fakeDeckRepository はメモリ上でデッキを保持するテスト用DeckRepositoryです。
+// テストで使わないメソッドは埋め込んだインターフェース（nil）に委譲されます。
+type fakeDeckRepository struct {
+	database.DeckRepository
+	deck       *models.Deck
+	placements []models.TetriminoPlacementRequest
+}
+
+func (f *fakeDeckRepository) GetDeckByUserID(ctx context.Context, tx *sql.Tx, userID string) (*models.Deck, error) {
+	if f.deck == nil || f.deck.UserID != userID {
+		return nil, nil
+	}
+	copied := *f.deck
+	return &copied, nil
+}
+
+func (f *fakeDeckRepository) CreateDeck(ctx context.Context, tx *sql.Tx, userID string, initialTotalScore int) (*models.Deck, error) {
+	now := time.Now()
+	f.deck = &models.Deck{ID: "deck-new", UserID: userID, TotalScore: initialTotalScore, CreatedAt: now, UpdatedAt: now}
+	copied := *f.deck
+	return &copied, nil
+}
+
+func (f *fakeDeckRepository) CreateDeckIfAbsent(ctx context.Context, tx *sql.Tx, userID string, initialTotalScore int) (*models.Deck, bool, error) {
+	if existing, _ := f.GetDeckByUserID(ctx, tx, userID); existing != nil {
+		return existing, false, nil
+	}
+	deck, err := f.CreateDeck(ctx, tx, userID, initialTotalScore)
+	return deck, true, err
+}
+
+func (f *fakeDeckRepository) UpdateDeckTotalScoreWithVersion(ctx context.Context, tx *sql.Tx, deckID string, totalScore int, periodStart, periodEnd string, expectedVersion *int) (*models.Deck, error) {
+	if expectedVersion != nil && *expectedVersion != f.deck.Version {
+		return nil, database.ErrDeckVersionConflict
+	}
+	f.deck.TotalScore = totalScore
+	f.deck.PeriodStart, f.deck.PeriodEnd = periodStart, periodEnd
+	f.deck.Version++
+	f.deck.UpdatedAt = time.Now()
+	copied := *f.deck
+	return &copied, nil
+}
+
+func (f *fakeDeckRepository) DeleteTetriminoPlacementsByDeckID(ctx context.Context, tx *sql.Tx, deckID string) error {
+	f.placements = nil
+	return nil
+}
+
+func (f *fakeDeckRepository) BulkInsertTetriminoPlacements(ctx context.Context, tx *sql.Tx, deckID string, placements []models.TetriminoPlacementRequest) error {
+	f.placements = placements
+	return nil
+}
+
+func (f *fakeDeckRepository) UpdateDeckVisibility(ctx context.Context, tx *sql.Tx, deckID string, isPublic bool) error {
+	f.deck.IsPublic = isPublic
+	return nil
+}
+
+func (f *fakeDeckRepository) GetDeckWithPlacementsByUserID(ctx context.Context, userID string) (*models.DeckWithPlacements, error) {
+	deck, _ := f.GetDeckByUserID(ctx, nil, userID)
+	if deck == nil {
+		return nil, nil
+	}
+	result := &models.DeckWithPlacements{Deck: deck}
+	for i, p := range f.placements {
+		positions, _ := json.Marshal(p.Positions)
+		result.Placements = append(result.Placements, models.TetriminoPlacementAPI{
+			ID:             "placement-" + strconv.Itoa(i),
+			TetriminoType:  p.Type,
+			Rotation:       p.Rotation,
+			StartDate:      p.StartDate,
+			Positions:      positions,
+			ScorePotential: p.ScorePotential,
+		})
+	}
+	return result, nil
+}
+
+// fakeContributionSource は固定の草データを返すテスト用ContributionSourceです。
+type fakeContributionSource struct {
+	contributions []models.DailyContribution
+}
+
+func (f *fakeContributionSource) GetContributionsByUserID(ctx context.Context, userID string) ([]models.DailyContribution, error) {
+	return f.contributions, nil
+}
+
+func newTestDeckService(t *testing.T, repo database.DeckRepository) DeckService {
+	db, err := sql.Open("txonly", "")
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewDeckService(db, repo, &fakeContributionSource{
+		contributions: []models.DailyContribution{{Date: "2026-03-01", Count: 2}, {Date: "2026-03-02", Count: 30}},
+	})
+}
+
+// TestSaveDeck_ReturnsSavedDeck は保存後のデッキ（ID・total_score・バージョン）と新規作成フラグを返すことをテストします。
+func TestSaveDeck_ReturnsSavedDeck(t *testing.T) {
+	repo := &fakeDeckRepository{}
+	service := newTestDeckService(t, repo)
+	tetriminos := []models.TetriminoPlacementRequest{
+		{Type: "O", StartDate: "2026-03-01", ScorePotential: 120, Positions: []models.Position{{X: 0, Y: 0, Score: 30}}},
+		{Type: "I", StartDate: "2026-03-08", ScorePotential: 80, Positions: []models.Position{{X: 1, Y: 0, Score: 20}}},
+	}
+
+	deck, created, err := service.SaveDeck(context.Background(), "user-1", tetriminos, nil)
+
+	assert.NoError(t, err)
+	assert.True(t, created, "デッキが無い場合は新規作成のはず")
+	assert.Equal(t, "deck-new", deck.ID)
+	assert.Equal(t, 300, deck.TotalScore, "total_score は草データから算出したスコアの合計のはず")
+	assert.Equal(t, 1, deck.Version)
+	assert.False(t, deck.UpdatedAt.IsZero())
+	assert.Equal(t, "2026-03-01", deck.PeriodStart, "草グリッドの左上 (0, 0) が 03-01 のはず")
+	assert.Equal(t, "2026-04-25", deck.PeriodEnd, "8週間の最終日のはず")
+
+	// 2回目は既存デッキの更新
+	version := deck.Version
+	deck, created, err = service.SaveDeck(context.Background(), "user-1", tetriminos[:1], &version)
+
+	assert.NoError(t, err)
+	assert.False(t, created, "既存デッキは更新のはず")
+	assert.Equal(t, "deck-new", deck.ID)
+	assert.Equal(t, 200, deck.TotalScore)
+	assert.Equal(t, 2, deck.Version)
+}
+
+// TestScorePlacementsByContributions はブロックのスコアと score_potential がクライアントの申告ではなく、
+// ブロックが覆う日の貢献数から算出されることをテストします。
+func TestScorePlacementsByContributions(t *testing.T) {
+	counts := map[string]int{"2026-03-01": 2, "2026-03-09": 100}
+	tetriminos := []models.TetriminoPlacementRequest{{
+		Type:           "O",
+		StartDate:      "2026-03-01",
+		ScorePotential: 99999, // 改ざんされた申告値
+		Positions:      []models.Position{{X: 3, Y: 2, Score: 9999}, {X: 4, Y: 2}, {X: 3, Y: 3}, {X: 4, Y: 3}},
+	}}
+
+	scored, err := scorePlacementsByContributions(tetriminos, counts)
+
+	assert.NoError(t, err)
+	// 左上 (3, 2) が 03-01、右に1列で7日後、下に1行で1日後
+	assert.Equal(t, []int{200, tetris.DefaultContributionScore, tetris.DefaultContributionScore, tetris.MaxContributionBlockScore},
+		[]int{scored[0].Positions[0].Score, scored[0].Positions[1].Score, scored[0].Positions[2].Score, scored[0].Positions[3].Score})
+	assert.Equal(t, 200+2*tetris.DefaultContributionScore+tetris.MaxContributionBlockScore, scored[0].ScorePotential)
+	assert.Equal(t, 9999, tetriminos[0].Positions[0].Score, "元の配置は変更しないはず")
+
+	_, err = scorePlacementsByContributions([]models.TetriminoPlacementRequest{{Type: "I", StartDate: "03/01"}}, counts)
+	assert.ErrorIs(t, err, ErrInvalidDeck)
+}
+
+// TestPreviewLevels はスコアマップが最大スコアに対する割合で 0-MaxPreviewLevel の強度レベルに変換されることをテストします。
+func TestPreviewLevels(t *testing.T) {
+	tests := []struct {
+		name   string
+		scores map[string]int
+		want   map[[2]int]int // {y, x} -> 期待するレベル（指定の無いセルは0）
+	}{
+		{name: "スコアが無い場合はすべて0", scores: map[string]int{}},
+		{name: "スコアがすべて0以下の場合はすべて0", scores: map[string]int{"0_0": 0, "1_1": -100}},
+		{
+			name:   "最大スコアのセルは最大レベル",
+			scores: map[string]int{"3_10": 500},
+			want:   map[[2]int]int{{3, 10}: MaxPreviewLevel},
+		},
+		{
+			name:   "最大スコアに対する割合を切り上げる",
+			scores: map[string]int{"0_0": 1000, "0_1": 750, "0_2": 500, "0_3": 260, "0_4": 1},
+			want:   map[[2]int]int{{0, 0}: 4, {0, 1}: 3, {0, 2}: 2, {0, 3}: 2, {0, 4}: 1},
+		},
+		{
+			name:   "負のスコアは0",
+			scores: map[string]int{"2_2": 100, "2_3": -50},
+			want:   map[[2]int]int{{2, 2}: MaxPreviewLevel},
+		},
+		{
+			name:   "グリッド外のキーは無視する",
+			scores: map[string]int{"6_52": 100, "7_0": 1000, "0_53": 1000, "x_y": 1000},
+			want:   map[[2]int]int{{6, 52}: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grid := previewLevels(tt.scores)
+
+			assert.Len(t, grid, PreviewGridDays)
+			for y, row := range grid {
+				assert.Len(t, row, PreviewGridWeeks)
+				for x, level := range row {
+					assert.Equal(t, tt.want[[2]int{y, x}], level, "セル (%d, %d)", x, y)
+				}
+			}
+		})
+	}
+}
+
+// TestDeckPeriod は配置の start_date と位置から草グリッドの左上の日付を逆算して期間を決め、
+// 期間外の日付を覆う配置を拒否することをテストします。
+func TestDeckPeriod(t *testing.T) {
+	tests := []struct {
+		name      string
+		pieces    []models.TetriminoPlacementRequest
+		wantStart string
+		wantEnd   string
+		wantErr   string
+	}{
+		{"配置なし", nil, "", "", ""},
+		{"左上から逆算", []models.TetriminoPlacementRequest{
+			{Type: "O", StartDate: "2026-03-10", Positions: []models.Position{{X: 1, Y: 2}, {X: 2, Y: 2}, {X: 1, Y: 3}, {X: 2, Y: 3}}},
+		}, "2026-03-01", "2026-04-25", ""},
+		{"揃った複数の配置", []models.TetriminoPlacementRequest{
+			{Type: "I", StartDate: "2026-03-01", Positions: []models.Position{{X: 0, Y: 0}}},
+			{Type: "I", StartDate: "2026-04-25", Positions: []models.Position{{X: 7, Y: 6}}},
+		}, "2026-03-01", "2026-04-25", ""},
+		{"最終日を越える配置", []models.TetriminoPlacementRequest{
+			{Type: "I", StartDate: "2026-03-01", Positions: []models.Position{{X: 0, Y: 0}}},
+			{Type: "I", StartDate: "2026-04-26", Positions: []models.Position{{X: 7, Y: 6}}},
+		}, "", "", "1 番目のテトリミノのブロック (7, 6) の日付 2026-04-26 がデッキの期間 2026-03-01〜2026-04-25 の範囲外です"},
+		{"日付の形式が不正", []models.TetriminoPlacementRequest{
+			{Type: "I", StartDate: "03/01", Positions: []models.Position{{X: 0, Y: 0}}},
+		}, "", "", "開始日"},
+	}
+
+	t.Run("週数が0", func(t *testing.T) {
+		_, _, err := deckPeriod(tests[1].pieces, 0)
+		assert.ErrorIs(t, err, ErrInvalidDeck)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := deckPeriod(tt.pieces, models.ContributionWeeks)
+			if tt.wantErr != "" {
+				assert.ErrorIs(t, err, ErrInvalidDeck)
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStart, start)
+			assert.Equal(t, tt.wantEnd, end)
+		})
+	}
+}
+
+// TestSaveDeck_VersionConflict はバージョン不一致の場合にデッキを返さずエラーになることをテストします。
+func TestSaveDeck_VersionConflict(t *testing.T) {
+	repo := &fakeDeckRepository{deck: &models.Deck{ID: "deck-1", UserID: "user-1", Version: 3}}
+	service := newTestDeckService(t, repo)
+	stale := 2
+
+	deck, created, err := service.SaveDeck(context.Background(), "user-1", nil, &stale)
+
+	assert.True(t, errors.Is(err, database.ErrDeckVersionConflict))
+	assert.Nil(t, deck)
+	assert.False(t, created)
+}
+
+// TestCheckPlacementOverlap は草グリッドの範囲外のブロックや、テトリミノ同士の重なりを
+// どのテトリミノかを示すエラーで拒否することをテストします。
+func TestCheckPlacementOverlap(t *testing.T) {
+	square := func(x, y int) []models.Position {
+		return []models.Position{{X: x, Y: y}, {X: x + 1, Y: y}, {X: x, Y: y + 1}, {X: x + 1, Y: y + 1}}
+	}
+	tests := []struct {
+		name    string
+		pieces  []models.TetriminoPlacementRequest
+		wantErr string
+	}{
+		{"隣接は許可", []models.TetriminoPlacementRequest{{Type: "O", Positions: square(0, 0)}, {Type: "O", Positions: square(2, 0)}}, ""},
+		{"右端の週", []models.TetriminoPlacementRequest{{Type: "O", Positions: square(6, 5)}}, ""},
+		{"重なり", []models.TetriminoPlacementRequest{{Type: "O", Positions: square(0, 0)}, {Type: "O", Positions: square(1, 1)}}, "1 番目のテトリミノ (O) のブロック (1, 1) が 0 番目のテトリミノと重なっています"},
+		{"週の範囲外", []models.TetriminoPlacementRequest{{Type: "O", Positions: square(7, 0)}}, "0 番目のテトリミノ (O) のブロック (8, 0) が草グリッド (8×7) の範囲外です"},
+		{"曜日の範囲外", []models.TetriminoPlacementRequest{{Type: "O", Positions: square(0, 6)}}, "範囲外です"},
+		{"負の座標", []models.TetriminoPlacementRequest{{Type: "O", Positions: square(-1, 0)}}, "範囲外です"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPlacementOverlap(tt.pieces, models.ContributionWeeks)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalidDeck)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+// TestCheckPieceLimits は種類ごと・合計の枚数の上限を超えたデッキを、超過しているすべての種類と超過数を含むエラーで拒否することをテストします。
+func TestCheckPieceLimits(t *testing.T) {
+	pieces := func(types ...string) []models.TetriminoPlacementRequest {
+		placements := make([]models.TetriminoPlacementRequest, len(types))
+		for i, pieceType := range types {
+			placements[i] = models.TetriminoPlacementRequest{Type: pieceType}
+		}
+		return placements
+	}
+	repeat := func(pieceType string, n int) []string {
+		types := make([]string, n)
+		for i := range types {
+			types[i] = pieceType
+		}
+		return types
+	}
+	limits := DeckPieceLimits{MaxPerType: map[string]int{"I": 2}, DefaultMaxPerType: 3, MaxTotal: 6}
+
+	tests := []struct {
+		name    string
+		types   []string
+		wantErr string
+	}{
+		{"上限ちょうど", append(repeat("I", 2), repeat("O", 3)...), ""},
+		{"種類別の上限を超過", append(repeat("O", 3), repeat("I", 3)...), "I が 3 個（上限 2、1 個超過）"},
+		{"既定の上限を超過", repeat("T", 5), "T が 5 個（上限 3、2 個超過）"},
+		{"超過した種類をすべて含む", append(repeat("L", 4), repeat("I", 3)...), "I が 3 個（上限 2、1 個超過）、L が 4 個（上限 3、1 個超過）、合計が 7 個（上限 6、1 個超過）"},
+		{"合計の上限を超過", []string{"I", "O", "T", "S", "Z", "J", "L"}, "合計が 7 個（上限 6、1 個超過）"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPieceLimits(pieces(tt.types...), limits)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalidDeck)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	standard := DeckPieceLimitsFor(DeckModeStandard)
+	assert.Equal(t, 14, standard.MaxTotal, "草グリッド56マスを4ブロックで埋めきれる枚数のはず")
+	assert.Equal(t, standard, DeckPieceLimitsFor("unknown"), "未知のモードは通常モードの上限のはず")
+}
+
+// racingDeckRepository は存在確認の後、作成までの間に他のリクエストがデッキを作成した状況を再現するテスト用DeckRepositoryです。
+type racingDeckRepository struct {
+	*fakeDeckRepository
+	winner *models.Deck
+}
+
+func (r *racingDeckRepository) CreateDeckIfAbsent(ctx context.Context, tx *sql.Tx, userID string, initialTotalScore int) (*models.Deck, bool, error) {
+	r.deck = r.winner
+	return r.fakeDeckRepository.CreateDeckIfAbsent(ctx, tx, userID, initialTotalScore)
+}
+
+// TestEnsureDefaultDeck はデッキが無い場合だけデフォルトデッキを作成し、既存のデッキはそのまま返すことをテストします。
+func TestEnsureDefaultDeck(t *testing.T) {
+	repo := &fakeDeckRepository{}
+	service := newTestDeckService(t, repo)
+
+	deck, err := service.EnsureDefaultDeck(context.Background(), "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "deck-new", deck.ID)
+	assert.Equal(t, 4*DefaultDeckBlockScore, deck.TotalScore)
+	assert.Len(t, repo.placements, 1)
+	start, end, err := deckPeriod(repo.placements, models.ContributionWeeks)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, deck.PeriodStart, "デフォルトデッキにも期間を保存するはず")
+	assert.Equal(t, start, deck.PeriodStart)
+	assert.Equal(t, end, deck.PeriodEnd)
+
+	repo.placements = nil
+	again, err := service.EnsureDefaultDeck(context.Background(), "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, deck.ID, again.ID)
+	assert.Nil(t, repo.placements, "既存のデッキには配置を挿入しないはず")
+}
+
+// TestEnsureDefaultDeck_ConcurrentCreation は他のリクエストが先にデッキを作成した場合、そのデッキを返して配置を挿入しないことをテストします。
+func TestEnsureDefaultDeck_ConcurrentCreation(t *testing.T) {
+	winner := &models.Deck{ID: "deck-winner", UserID: "user-1", TotalScore: 40}
+	repo := &racingDeckRepository{fakeDeckRepository: &fakeDeckRepository{}, winner: winner}
+	service := newTestDeckService(t, repo)
+
+	deck, err := service.EnsureDefaultDeck(context.Background(), "user-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "deck-winner", deck.ID)
+	assert.Nil(t, repo.placements, "先に作成したリクエストが配置を挿入するはず")
+}
+
+// TestDefaultDeckPlacements はデフォルトデッキが草グリッドの原点から2×2のOミノ1つで、均一スコアになることをテストします。
+func TestDefaultDeckPlacements(t *testing.T) {
+	now := time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC) // JSTでは 03-02
+
+	placements := defaultDeckPlacements(now)
+
+	assert.Len(t, placements, 1)
+	placement := placements[0]
+	assert.Equal(t, "O", placement.Type)
+	assert.Equal(t, "2026-03-02", placement.StartDate, "開始日はJSTの日付のはず")
+	assert.ElementsMatch(t, []models.Position{
+		{X: 0, Y: 0, Score: DefaultDeckBlockScore},
+		{X: 1, Y: 0, Score: DefaultDeckBlockScore},
+		{X: 0, Y: 1, Score: DefaultDeckBlockScore},
+		{X: 1, Y: 1, Score: DefaultDeckBlockScore},
+	}, placement.Positions)
+	assert.Equal(t, 4*DefaultDeckBlockScore, placement.ScorePotential)
+}
+
+// TestGetDeckWithPlacementsByUserID_Visibility は所有者には非公開でも全フィールドを返し、他のユーザーには
+// 公開デッキの概要（配置の位置・日付を除く）だけを返して、非公開デッキは ErrDeckForbidden にすることをテストします。
+func TestGetDeckWithPlacementsByUserID_Visibility(t *testing.T) {
+	newRepo := func(isPublic bool) *fakeDeckRepository {
+		return &fakeDeckRepository{
+			deck: &models.Deck{ID: "deck-1", UserID: "owner", TotalScore: 400, IsPublic: isPublic, PeriodStart: "2026-03-01"},
+			placements: []models.TetriminoPlacementRequest{{
+				Type: "O", Rotation: 0, StartDate: "2026-03-01", ScorePotential: 400,
+				Positions: []models.Position{{X: 0, Y: 0, Score: 100}, {X: 1, Y: 0, Score: 100}, {X: 0, Y: 1, Score: 100}, {X: 1, Y: 1, Score: 100}},
+			}},
+		}
+	}
+
+	t.Run("所有者は非公開でも全フィールド", func(t *testing.T) {
+		got, err := newTestDeckService(t, newRepo(false)).GetDeckWithPlacementsByUserID(context.Background(), "owner", "owner")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "2026-03-01", got.Deck.PeriodStart)
+		assert.Equal(t, "2026-03-01", got.Placements[0].StartDate)
+		assert.NotEmpty(t, got.Placements[0].Positions)
+	})
+
+	t.Run("他のユーザーは公開デッキの概要だけ", func(t *testing.T) {
+		got, err := newTestDeckService(t, newRepo(true)).GetDeckWithPlacementsByUserID(context.Background(), "owner", "viewer")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 400, got.Deck.TotalScore)
+		assert.True(t, got.Deck.IsPublic)
+		assert.Empty(t, got.Deck.PeriodStart, "概要に期間は含めないはず")
+		assert.Equal(t, []models.TetriminoPlacementAPI{{TetriminoType: "O", Rotation: 0, ScorePotential: 400}}, got.Placements)
+	})
+
+	t.Run("他のユーザーは非公開デッキを取得できない", func(t *testing.T) {
+		got, err := newTestDeckService(t, newRepo(false)).GetDeckWithPlacementsByUserID(context.Background(), "owner", "viewer")
+
+		assert.ErrorIs(t, err, ErrDeckForbidden)
+		assert.Nil(t, got)
+	})
+
+	t.Run("デッキが無い場合はnil", func(t *testing.T) {
+		got, err := newTestDeckService(t, &fakeDeckRepository{}).GetDeckWithPlacementsByUserID(context.Background(), "owner", "viewer")
+
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+	})
+}
+
+// TestSetDeckVisibility はユーザー自身のデッキの公開設定を切り替え、デッキが無い場合は ErrDeckNotFound を返すことをテストします。
+func TestSetDeckVisibility(t *testing.T) {
+	repo := &fakeDeckRepository{deck: &models.Deck{ID: "deck-1", UserID: "owner"}}
+	s := newTestDeckService(t, repo)
+
+	assert.NoError(t, s.SetDeckVisibility(context.Background(), "owner", true))
+	assert.True(t, repo.deck.IsPublic)
+	assert.NoError(t, s.SetDeckVisibility(context.Background(), "owner", false))
+	assert.False(t, repo.deck.IsPublic)
+
+	err := s.SetDeckVisibility(context.Background(), "someone-else", true)
+	assert.ErrorIs(t, err, database.ErrDeckNotFound)
+	assert.False(t, repo.deck.IsPublic, "他のユーザーのデッキは変更しないはず")
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
+)
+
+// DeckModeStandard は通常のデッキのモードです。現在のデッキ保存はすべてこのモードの上限で検証します。
+const DeckModeStandard = "standard"
+
+// DeckPieceLimits はデッキに含められるテトリミノの枚数の上限です。
+// 特定の種類だけを大量に配置したデッキが有利になりすぎないよう、種類ごとの枚数と合計の枚数を制限します。
+type DeckPieceLimits struct {
+	MaxPerType        map[string]int // 種類（"I", "O" など）ごとの上限。指定の無い種類は DefaultMaxPerType
+	DefaultMaxPerType int            // MaxPerType に無い種類の上限
+	MaxTotal          int            // 全種類の合計の上限
+}
+
+// deckPieceLimitsByMode はモードごとのテトリミノの枚数の上限です。
+// 通常モードの合計は草グリッド（8週間×7日 = 56マス）を4ブロックのテトリミノで埋めきれる14枚、
+// 種類ごとはその約1/4の4枚とし、少なくとも4種類を組み合わせないとグリッドを埋められないようにしています。
+var deckPieceLimitsByMode = map[string]DeckPieceLimits{
+	DeckModeStandard: {
+		DefaultMaxPerType: 4,
+		MaxTotal:          models.ContributionWeeks * models.ContributionGridDays / MaxPositionsPerTetrimino,
+	},
+}
+
+// DeckPieceLimitsFor はモードのテトリミノの枚数の上限を返します。未知のモードは通常モードの上限です。
+func DeckPieceLimitsFor(mode string) DeckPieceLimits {
+	if limits, ok := deckPieceLimitsByMode[mode]; ok {
+		return limits
+	}
+	return deckPieceLimitsByMode[DeckModeStandard]
+}
+
+// maxFor は種類 pieceType の上限を返します。
+func (l DeckPieceLimits) maxFor(pieceType string) int {
+	if limit, ok := l.MaxPerType[pieceType]; ok {
+		return limit
+	}
+	return l.DefaultMaxPerType
+}
+
+// checkPieceLimits はテトリミノの種類ごとの枚数と合計の枚数が上限以内かを検証します。
+// 上限を超えた場合は、クライアントがまとめて修正できるよう、超過しているすべての種類と超過数を含むエラー（ErrInvalidDeck をラップ）を返します。
+//
+// Parameters:
+//   tetriminos : 検証するテトリミノ配置
+//   limits     : 枚数の上限（DeckPieceLimitsFor で取得したもの）
+func checkPieceLimits(tetriminos []models.TetriminoPlacementRequest, limits DeckPieceLimits) error {
+	counts := make(map[string]int)
+	for _, t := range tetriminos {
+		counts[t.Type]++
+	}
+
+	var violations []string
+	for _, pieceType := range sortedPieceTypes(counts) {
+		if count, limit := counts[pieceType], limits.maxFor(pieceType); count > limit {
+			violations = append(violations, fmt.Sprintf("%s が %d 個（上限 %d、%d 個超過）", pieceType, count, limit, count-limit))
+		}
+	}
+	if total := len(tetriminos); total > limits.MaxTotal {
+		violations = append(violations, fmt.Sprintf("合計が %d 個（上限 %d、%d 個超過）", total, limits.MaxTotal, total-limits.MaxTotal))
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("%w: テトリミノの枚数が上限を超えています: %s", ErrInvalidDeck, strings.Join(violations, "、"))
+	}
+	return nil
+}
+
+// sortedPieceTypes は種類をテトリミノの順（I, O, T, S, Z, J, L）に並べて返します。未知の種類は末尾に文字列順で並べます。
+func sortedPieceTypes(counts map[string]int) []string {
+	types := make([]string, 0, len(counts))
+	for pieceType := range counts {
+		types = append(types, pieceType)
+	}
+	order := func(pieceType string) int {
+		if p, ok := tetris.StringToPieceType(pieceType); ok {
+			return int(p)
+		}
+		return int(tetris.TypeL) + 1
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if oi, oj := order(types[i]), order(types[j]); oi != oj {
+			return oi < oj
+		}
+		return types[i] < types[j]
+	})
+	return types
+}
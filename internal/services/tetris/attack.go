@@ -0,0 +1,106 @@
+package tetris
+
+// ライン消去の種類です。CalculateAttack で相手に送るお邪魔ライン数を決めるために使います。
+const (
+	ClearNone         = iota // ラインを消していない
+	ClearSingle              // 1ライン消去
+	ClearDouble              // 2ライン消去
+	ClearTriple              // 3ライン消去
+	ClearTetris              // 4ライン消去
+	ClearTSpinSingle         // T-Spinで1ライン消去
+	ClearTSpinDouble         // T-Spinで2ライン消去
+	ClearTSpinTriple         // T-Spinで3ライン消去
+	ClearPerfectClear        // 消去後に盤面が空になった（ライン数・T-Spinを問わない）
+)
+
+// attackTable はライン消去の種類ごとの基本攻撃力（相手に送るお邪魔ライン数）です。攻撃力の定義はここが唯一の定義です。
+var attackTable = map[int]int{
+	ClearNone:         0,
+	ClearSingle:       0,
+	ClearDouble:       1,
+	ClearTriple:       2,
+	ClearTetris:       4,
+	ClearTSpinSingle:  2,
+	ClearTSpinDouble:  4,
+	ClearTSpinTriple:  6,
+	ClearPerfectClear: 10,
+}
+
+// comboAttackBonus は連続消去（コンボ）による追加攻撃力です。添字は連続消去数 - 1 で、
+// 1回目の消去（添字0）はボーナスなし、表の長さを超えるコンボは最後の値で頭打ちになります。
+var comboAttackBonus = []int{0, 1, 1, 2, 2, 3, 3, 4, 4, 4, 5}
+
+// BackToBackAttackBonus は難しい消去（テトリス・ラインを消したT-Spin）を連続で決めたときの追加攻撃力です。
+const BackToBackAttackBonus = 1
+
+// isDifficultClear はBack-to-Backの対象になる消去（テトリス・ラインを消したT-Spin）かどうかを返します。
+// パーフェクトクリアはB2Bの連続を途切れさせませんが、それ自体はB2Bボーナスの対象にしません。
+func isDifficultClear(clearType int) bool {
+	switch clearType {
+	case ClearTetris, ClearTSpinSingle, ClearTSpinDouble, ClearTSpinTriple:
+		return true
+	default:
+		return false
+	}
+}
+
+// classifyClear は消去したライン数・T-Spinかどうか・パーフェクトクリアかどうかからライン消去の種類を判定します。
+// パーフェクトクリアを最優先し、次にT-Spin、最後にライン数で判定します。
+//
+// Parameters:
+//   clearedLines : 同時に消去したライン数
+//   tSpin        : 固定したピースがT-Spinだったか
+//   perfectClear : 消去後に盤面が空になったか
+func classifyClear(clearedLines int, tSpin, perfectClear bool) int {
+	if clearedLines <= 0 {
+		return ClearNone
+	}
+	if perfectClear {
+		return ClearPerfectClear
+	}
+	if tSpin {
+		switch clearedLines {
+		case 1:
+			return ClearTSpinSingle
+		case 2:
+			return ClearTSpinDouble
+		default:
+			return ClearTSpinTriple
+		}
+	}
+	switch clearedLines {
+	case 1:
+		return ClearSingle
+	case 2:
+		return ClearDouble
+	case 3:
+		return ClearTriple
+	default:
+		return ClearTetris
+	}
+}
+
+// CalculateAttack はライン消去で相手に送るお邪魔ライン数（攻撃力）を計算します。
+// 基本攻撃力（attackTable）にコンボボーナス（comboAttackBonus）と、難しい消去を連続で決めた場合の
+// B2Bボーナス（BackToBackAttackBonus）を加えます。ラインを消していない場合は常に0です。
+// 受信側の予告中のお邪魔ラインとの相殺は handlePieceLock で行います。
+//
+// Parameters:
+//   clearType : ライン消去の種類（Clear* 定数）
+//   combo     : 今回を含めた連続消去数（1回目の消去は1）
+//   b2b       : 直前の消去も難しい消去（テトリス・ラインを消したT-Spin）だったか
+// Returns:
+//   int: 相手に送るお邪魔ライン数
+func CalculateAttack(clearType, combo int, b2b bool) int {
+	if clearType == ClearNone {
+		return 0
+	}
+	attack := attackTable[clearType]
+	if combo > 1 {
+		attack += comboAttackBonus[min(combo, len(comboAttackBonus))-1]
+	}
+	if b2b && isDifficultClear(clearType) {
+		attack += BackToBackAttackBonus
+	}
+	return attack
+}
@@ -0,0 +1,111 @@
+package tetris
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCalculateAttack は消去の種類・コンボ・B2Bの組み合わせごとの攻撃力をテストします。
+func TestCalculateAttack(t *testing.T) {
+	tests := []struct {
+		clearType int
+		combo     int
+		b2b       bool
+		want      int
+	}{
+		// 基本攻撃力
+		{ClearNone, 0, false, 0},
+		{ClearSingle, 1, false, 0},
+		{ClearDouble, 1, false, 1},
+		{ClearTriple, 1, false, 2},
+		{ClearTetris, 1, false, 4},
+		{ClearTSpinSingle, 1, false, 2},
+		{ClearTSpinDouble, 1, false, 4},
+		{ClearTSpinTriple, 1, false, 6},
+		{ClearPerfectClear, 1, false, 10},
+
+		// コンボボーナス（2コンボ目から、11コンボ以上は頭打ち）
+		{ClearSingle, 2, false, 1},
+		{ClearSingle, 3, false, 1},
+		{ClearSingle, 4, false, 2},
+		{ClearDouble, 6, false, 4},
+		{ClearTriple, 8, false, 6},
+		{ClearSingle, 11, false, 5},
+		{ClearSingle, 50, false, 5},
+		{ClearPerfectClear, 3, false, 11},
+
+		// B2Bボーナスはテトリス・T-Spinのみ
+		{ClearTetris, 1, true, 5},
+		{ClearTSpinSingle, 1, true, 3},
+		{ClearTSpinDouble, 1, true, 5},
+		{ClearTSpinTriple, 1, true, 7},
+		{ClearSingle, 1, true, 0},
+		{ClearDouble, 1, true, 1},
+		{ClearTriple, 1, true, 2},
+		{ClearPerfectClear, 1, true, 10},
+
+		// コンボとB2Bの両方
+		{ClearTetris, 4, true, 7},
+		{ClearTSpinDouble, 2, true, 6},
+
+		// ラインを消していなければコンボ・B2Bがあっても0
+		{ClearNone, 5, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("type=%d/combo=%d/b2b=%v", tt.clearType, tt.combo, tt.b2b), func(t *testing.T) {
+			assert.Equal(t, tt.want, CalculateAttack(tt.clearType, tt.combo, tt.b2b))
+		})
+	}
+}
+
+// TestClassifyClear はライン数・T-Spin・パーフェクトクリアから消去の種類を判定できることをテストします。
+func TestClassifyClear(t *testing.T) {
+	tests := []struct {
+		lines        int
+		tSpin        bool
+		perfectClear bool
+		want         int
+	}{
+		{0, false, false, ClearNone},
+		{0, true, false, ClearNone},
+		{1, false, false, ClearSingle},
+		{2, false, false, ClearDouble},
+		{3, false, false, ClearTriple},
+		{4, false, false, ClearTetris},
+		{1, true, false, ClearTSpinSingle},
+		{2, true, false, ClearTSpinDouble},
+		{3, true, false, ClearTSpinTriple},
+		{2, false, true, ClearPerfectClear},
+		{4, false, true, ClearPerfectClear},
+		{1, true, true, ClearPerfectClear},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, classifyClear(tt.lines, tt.tSpin, tt.perfectClear), "lines=%d tSpin=%v pc=%v", tt.lines, tt.tSpin, tt.perfectClear)
+	}
+}
+
+// TestHandlePieceLock_AttackOffsetsPendingGarbage はT-Spinシングルの攻撃（2ライン）で予告が相殺され、
+// 残りが相手への送信分になることをテストします。
+func TestHandlePieceLock_AttackOffsetsPendingGarbage(t *testing.T) {
+	state := NewPlayerGameState("test-user", &models.Deck{ID: "mock-deck-id"})
+	bottom := tetris.BoardTotalHeight - 1
+	for x := 0; x < tetris.BoardWidth; x++ {
+		if x != 1 {
+			state.Board[bottom][x] = tetris.BlockL
+		}
+	}
+	state.Board[bottom-2][0] = tetris.BlockL
+	state.CurrentPiece = &tetris.Piece{Type: tetris.TypeT, X: 0, Y: bottom - 2, Rotation: 180}
+	state.lastMoveWasRotation = true
+	state.ReceiveGarbage(1)
+
+	ApplyPlayerInput(state, ActionHardDrop)
+
+	assert.Equal(t, 0, state.PendingGarbage(), "予告は攻撃で相殺されるはず")
+	assert.Equal(t, 1, state.outgoingGarbage, "相殺しきれなかった攻撃は送信分になるはず")
+	assert.True(t, state.BackToBack, "ラインを消したT-SpinはB2Bの対象のはず")
+}
@@ -0,0 +1,102 @@
+package tetris
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
+)
+
+// ErrPlayerNotFound は指定されたユーザーがセッションのプレイヤーでない場合のエラーです。
+var ErrPlayerNotFound = errors.New("指定されたユーザーはこのセッションのプレイヤーではありません")
+
+// BoardResetOptions はボードのリセットで、ボード以外に何を消すかの指定です。
+// ゼロ値では現在のピースとスコアを保持し、ボードと連続消去の状態だけをリセットします。
+type BoardResetOptions struct {
+	ResetPiece bool `json:"reset_piece"` // 現在のピースを捨てて次のピースを出す（ホールドは保持）
+	ResetScore bool `json:"reset_score"` // スコアを初期値（ハンディキャップのボーナスのみ）に戻す
+}
+
+// ResetBoard はボードを空にし、ボードに紐づく状態（草のスコア・連続消去・B2B・せり上げ待ちのお邪魔ライン）をリセットします。
+// デバッグや特殊モードの管理操作、テストでの状態操作に使います。ゲームオーバーの状態は変更しません。
+// セッションのプレイヤーに対しては、ゲーム状態ロック（gameMu）を保持した状態で呼び出してください。
+//
+// Parameters:
+//   opts : 現在のピース・スコアもリセットするかどうか
+func (s *PlayerGameState) ResetBoard(opts BoardResetOptions) {
+	s.Board = tetris.NewBoard()
+	s.ContributionScores = make(map[string]int)
+	s.ConsecutiveClears = 0
+	s.BackToBack = false
+	s.pendingGarbage = 0
+
+	if opts.ResetScore {
+		s.Score = s.handicap.ScoreBonus
+	}
+	if opts.ResetPiece && !s.IsGameOver {
+		s.SpawnNewPiece()
+	} else {
+		// 空のボードに置き直しても位置は変わらないが、ピースのスコア表示は盤面に合わせて更新する
+		s.updateCurrentPieceScores()
+	}
+}
+
+// playerOf は userID のプレイヤーの状態を返します（プレイヤーでない場合は nil）。
+func (gs *GameSession) playerOf(userID string) *PlayerGameState {
+	for _, player := range []*PlayerGameState{gs.Player1, gs.Player2} {
+		if player != nil && player.UserID == userID {
+			return player
+		}
+	}
+	return nil
+}
+
+// ResetPlayerBoard は管理操作として、セッションの指定プレイヤーのボードをリセットします。
+// 認可は呼び出し側（管理APIの管理者トークン）で行ってください。
+// リセット後の盤面がすぐ表示に反映されるよう、接続中のプレイヤーには完全な状態スナップショット（resync）を送ります。
+//
+// Parameters:
+//   passcode : セッションの合言葉
+//   userID   : ボードをリセットするプレイヤーのユーザーID
+//   opts     : 現在のピース・スコアもリセットするかどうか
+// Returns:
+//   error: セッションが無い場合（ErrSessionNotFound）、終了済みの場合（ErrNotPlaying）、プレイヤーでない場合（ErrPlayerNotFound）
+func (sm *SessionManager) ResetPlayerBoard(passcode, userID string, opts BoardResetOptions) error {
+	sm.mu.RLock()
+	session, ok := sm.sessions[passcode]
+	if !ok {
+		sm.mu.RUnlock()
+		return fmt.Errorf("passcode %s: %w", passcode, ErrSessionNotFound)
+	}
+	if session.Status == "finished" {
+		sm.mu.RUnlock()
+		return fmt.Errorf("passcode %s: %w", passcode, ErrNotPlaying)
+	}
+
+	var playerIDs []string
+	session.gameMu.Lock()
+	player := session.playerOf(userID)
+	if player != nil {
+		player.ResetBoard(opts)
+		for _, p := range []*PlayerGameState{session.Player1, session.Player2} {
+			if p != nil {
+				playerIDs = append(playerIDs, p.UserID)
+			}
+		}
+	}
+	session.gameMu.Unlock()
+	sm.mu.RUnlock()
+
+	if player == nil {
+		return fmt.Errorf("user %s: %w", userID, ErrPlayerNotFound)
+	}
+	log.Printf("[SessionManager] Board reset for %s in passcode %s (reset_piece=%v, reset_score=%v)", userID, passcode, opts.ResetPiece, opts.ResetScore)
+
+	for _, id := range playerIDs {
+		if err := sm.SendFullResync(id, passcode); err != nil && !errors.Is(err, ErrClientNotConnected) {
+			log.Printf("[SessionManager] Failed to send resync after board reset to %s: %v", id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package tetris
+
+import (
+	"testing"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestResetBoard はボードと連続消去・B2B・せり上げ待ちがリセットされ、
+// 指定に応じて現在のピースとスコアを保持・リセットすることをテストします。
+func TestResetBoard(t *testing.T) {
+	setup := func() *PlayerGameState {
+		state := NewPlayerGameState("test-user", &models.Deck{ID: "mock-deck-id"})
+		state.Board[tetris.BoardTotalHeight-1][0] = tetris.BlockGarbage
+		state.ContributionScores["19_0"] = 100
+		state.ConsecutiveClears = 3
+		state.BackToBack = true
+		state.pendingGarbage = 4
+		state.Score = 1200
+		return state
+	}
+
+	t.Run("ピースとスコアを保持", func(t *testing.T) {
+		state := setup()
+		current := state.CurrentPiece
+
+		state.ResetBoard(BoardResetOptions{})
+
+		assert.True(t, state.Board.IsEmpty())
+		assert.Empty(t, state.ContributionScores)
+		assert.Equal(t, 0, state.ConsecutiveClears)
+		assert.False(t, state.BackToBack)
+		assert.Equal(t, 0, state.PendingGarbage())
+		assert.Same(t, current, state.CurrentPiece)
+		assert.Equal(t, 1200, state.Score)
+	})
+
+	t.Run("ピースとスコアもリセット", func(t *testing.T) {
+		state := setup()
+		state.handicap = PlayerHandicap{ScoreBonus: 100, FallIntervalPercent: 120}
+		current, next := state.CurrentPiece, state.NextPiece
+
+		state.ResetBoard(BoardResetOptions{ResetPiece: true, ResetScore: true})
+
+		assert.NotSame(t, current, state.CurrentPiece, "現在のピースは捨てられるはず")
+		assert.Same(t, next, state.CurrentPiece, "次のピースが出るはず")
+		assert.Equal(t, 100, state.Score, "ハンディキャップのボーナスは残るはず")
+	})
+}
+
+// TestResetPlayerBoard は存在しないセッション・プレイヤー、終了済みのセッションがエラーになり、
+// 指定したプレイヤーのボードだけがリセットされることをテストします。
+func TestResetPlayerBoard(t *testing.T) {
+	sm := newTestSessionManager()
+	session := newPlayingSession(t, "reset-room")
+	session.Player1.Board[tetris.BoardTotalHeight-1][0] = tetris.BlockGarbage
+	session.Player2.Board[tetris.BoardTotalHeight-1][0] = tetris.BlockGarbage
+	sm.sessions["reset-room"] = session
+
+	assert.ErrorIs(t, sm.ResetPlayerBoard("missing", "player1", BoardResetOptions{}), ErrSessionNotFound)
+	assert.ErrorIs(t, sm.ResetPlayerBoard("reset-room", "stranger", BoardResetOptions{}), ErrPlayerNotFound)
+
+	assert.NoError(t, sm.ResetPlayerBoard("reset-room", "player1", BoardResetOptions{}))
+	assert.True(t, session.Player1.Board.IsEmpty())
+	assert.False(t, session.Player2.Board.IsEmpty(), "相手のボードはそのままのはず")
+
+	session.Status = "finished"
+	assert.ErrorIs(t, sm.ResetPlayerBoard("reset-room", "player2", BoardResetOptions{}), ErrNotPlaying)
+}
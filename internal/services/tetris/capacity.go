@@ -0,0 +1,108 @@
+package tetris
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"sync/atomic"
+)
+
+// ErrServerBusy は同時に保持できるセッション数・接続数の上限に達しているため、新規のルーム作成・接続を拒否した場合のエラーです。
+var ErrServerBusy = errors.New("サーバーが混雑しています。しばらくしてから再度お試しください")
+
+const (
+	// DefaultMaxSessions は同時に保持できるセッション数のデフォルトの上限です。
+	DefaultMaxSessions = 1000
+	// DefaultMaxClients は同時に接続できるWebSocketクライアント数のデフォルトの上限です（1セッション2人分）。
+	DefaultMaxClients = 2 * DefaultMaxSessions
+)
+
+// loadCapacityLimit は環境変数から上限値を読み込みます（0で無制限）。不正な値の場合はデフォルト値を使います。
+func loadCapacityLimit(name string, defaultValue int) int {
+	env := os.Getenv(name)
+	if env == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(env)
+	if err != nil || n < 0 {
+		log.Printf("[WARN] Invalid %s %q, using default %d", name, env, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+// loadCapacityLimits は環境変数 MAX_SESSIONS・MAX_CLIENTS からセッション数・接続数の上限を読み込みます。
+func loadCapacityLimits() (maxSessions, maxClients int) {
+	return loadCapacityLimit("MAX_SESSIONS", DefaultMaxSessions), loadCapacityLimit("MAX_CLIENTS", DefaultMaxClients)
+}
+
+// capacityUsage は上限の判定に使うセッション数・接続数です。
+// ShardedSessionManager では全シャードで共有し、シャードの偏りに関係なく全体の合計で上限を判定します。
+type capacityUsage struct {
+	sessions atomic.Int64
+	clients  atomic.Int64
+}
+
+// tryReserve は counter を1つ増やします。上限（0で無制限）に達している場合は増やさずに false を返します。
+func tryReserve(counter *atomic.Int64, limit int) bool {
+	for {
+		n := counter.Load()
+		if limit > 0 && n >= int64(limit) {
+			return false
+		}
+		if counter.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+// syncCapacityLocked はこのマネージャーのセッション数・接続数の増減を capacity に反映します。
+// sm.sessions・sm.clients を変更した後に、sm.mu を保持した状態で呼び出してください。
+func (sm *SessionManager) syncCapacityLocked() {
+	sm.capacity.sessions.Add(int64(len(sm.sessions) - sm.countedSessions))
+	sm.capacity.clients.Add(int64(len(sm.clients) - sm.countedClients))
+	sm.countedSessions, sm.countedClients = len(sm.sessions), len(sm.clients)
+}
+
+// activeSessionsLocked は上限の判定に使うセッション数（ShardedSessionManager では全シャードの合計）を返します。
+// sm.mu を保持した状態で呼び出してください（読み取りロックでも構いません）。
+func (sm *SessionManager) activeSessionsLocked() int {
+	return int(sm.capacity.sessions.Load()) + len(sm.sessions) - sm.countedSessions
+}
+
+// reserveSessionLocked は新しいセッションの分を capacity に予約し、上限に達している場合は false を返します。
+// 予約に成功した場合、呼び出し側は続けて sm.sessions にセッションを追加してください。
+// sm.mu を保持した状態で呼び出してください。
+func (sm *SessionManager) reserveSessionLocked() bool {
+	sm.syncCapacityLocked()
+	if !tryReserve(&sm.capacity.sessions, sm.maxSessions) {
+		return false
+	}
+	sm.countedSessions++
+	return true
+}
+
+// reserveClientLocked は userID の新しい接続の分を capacity に予約し、上限に達している場合は false を返します。
+// 既に接続中のユーザーの再接続は接続数が増えないため、上限に達していても受け付けます。
+// 予約に成功した場合、呼び出し側は続けて sm.clients に接続を追加してください。
+// sm.mu を保持した状態で呼び出してください。
+func (sm *SessionManager) reserveClientLocked(userID string) bool {
+	if _, exists := sm.clients[userID]; exists {
+		return true
+	}
+	sm.syncCapacityLocked()
+	if !tryReserve(&sm.capacity.clients, sm.maxClients) {
+		return false
+	}
+	sm.countedClients++
+	return true
+}
+
+// usageRatio は上限に対する使用率（0〜1）を返します。上限が無い場合は0です。
+func usageRatio(used, limit int) float64 {
+	if limit <= 0 {
+		return 0
+	}
+	return float64(used) / float64(limit)
+}
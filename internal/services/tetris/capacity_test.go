@@ -0,0 +1,55 @@
+package tetris
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCapacityLimits はセッション数・接続数が上限に達すると新規のルーム作成・接続が ErrServerBusy で拒否され、
+// 使用率が Stats に反映されることをテストします。
+func TestCapacityLimits(t *testing.T) {
+	sm := newTestSessionManager()
+	sm.maxSessions = 1
+	sm.maxClients = 2
+	sm.sessions["busy-room"] = newPlayingSession(t, "busy-room")
+	sm.clients["player1"] = &Client{UserID: "player1", RoomID: "busy-room"}
+
+	_, _, err := sm.JoinRoomByPasscode("new-room", "player3", testDeckID)
+	assert.ErrorIs(t, err, ErrServerBusy)
+	_, exists := sm.sessions["new-room"]
+	assert.False(t, exists)
+
+	stats := sm.Stats()
+	assert.Equal(t, 1.0, stats.SessionUsage)
+	assert.Equal(t, 0.5, stats.ClientUsage)
+
+	sm.clients["player2"] = &Client{UserID: "player2", RoomID: "busy-room"}
+	assert.ErrorIs(t, sm.RegisterClient("busy-room", "player3", nil, ProtocolVersion), ErrServerBusy)
+	assert.True(t, sm.reserveClientLocked("player1"), "接続中のユーザーの再接続は上限に数えないはず")
+}
+
+// TestSharedCapacity は ShardedSessionManager の上限が全シャードの合計で判定され、
+// 1つのシャードにセッションが偏っても全体の上限までは作成できることをテストします。
+func TestSharedCapacity(t *testing.T) {
+	s := newTestShardedSessionManager(2)
+	for _, shard := range s.shards {
+		shard.maxSessions = 2
+	}
+	first, second := s.shards[0], s.shards[1]
+
+	for _, passcode := range []string{"room-a", "room-b"} {
+		assert.True(t, first.reserveSessionLocked(), "全体の上限までは1つのシャードに偏っても作成できるはず")
+		first.sessions[passcode] = newPlayingSession(t, passcode)
+	}
+	assert.False(t, second.reserveSessionLocked(), "全体の上限に達したら他のシャードでも拒否するはず")
+
+	first.removeSessionLocked("room-a")
+	assert.True(t, second.reserveSessionLocked(), "削除した分は他のシャードで使えるはず")
+	second.sessions["room-c"] = newPlayingSession(t, "room-c")
+	assert.Equal(t, int64(2), first.capacity.sessions.Load())
+
+	stats := s.Stats()
+	assert.Equal(t, 2, stats.MaxSessions, "共有している上限はシャード数倍しないはず")
+	assert.Equal(t, 1.0, stats.SessionUsage)
+}
@@ -0,0 +1,78 @@
+package tetris
+
+import "sort"
+
+// 接続中クライアントの状態です（ConnectionInfo.State）。
+const (
+	ConnectionStateConnected     = "connected"     // 通常どおり送受信できる
+	ConnectionStateSlow          = "slow"          // 送信チャネルが詰まり気味（送信失敗が続いている）
+	ConnectionStateDisconnecting = "disconnecting" // 追従できないクライアントとして切断処理中、または送信チャネルを閉じた
+)
+
+// ConnectionInfo は運用確認用の接続中クライアント1件分のサマリです。
+type ConnectionInfo struct {
+	UserID       string `json:"user_id"`       // ユーザーID（管理APIではマスクして返す）
+	RoomID       string `json:"room_id"`       // 参加中のルームの合言葉
+	State        string `json:"state"`         // 接続状態（ConnectionState* 定数）
+	PendingSends int    `json:"pending_sends"` // 送信待ちのメッセージ数
+}
+
+// SessionSummary は運用確認用のアクティブセッション1件分のサマリです。
+type SessionSummary struct {
+	Passcode         string `json:"passcode"`          // 合言葉（セッションID）
+	Status           string `json:"status"`            // "waiting", "playing", "paused", "finished"
+	Players          int    `json:"players"`           // 参加済みのプレイヤー数
+	ConnectedPlayers int    `json:"connected_players"` // WebSocketで接続中のプレイヤー数
+}
+
+// ListConnections は接続中のクライアントの一覧をユーザーID順で返します。
+// clients マップは読み取りロックで走査し、各クライアントの状態はクライアント自身のロックで読み取ります。
+func (sm *SessionManager) ListConnections() []ConnectionInfo {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	connections := make([]ConnectionInfo, 0, len(sm.clients))
+	for _, client := range sm.clients {
+		client.mu.Lock()
+		state := ConnectionStateConnected
+		switch {
+		case client.closed || client.slowDisconnecting:
+			state = ConnectionStateDisconnecting
+		case client.sendFailures > 0:
+			state = ConnectionStateSlow
+		}
+		client.mu.Unlock()
+
+		connections = append(connections, ConnectionInfo{
+			UserID:       client.UserID,
+			RoomID:       client.RoomID,
+			State:        state,
+			PendingSends: len(client.Send),
+		})
+	}
+	sort.Slice(connections, func(i, j int) bool { return connections[i].UserID < connections[j].UserID })
+	return connections
+}
+
+// ListSessions は保持中のセッションの一覧を合言葉順で返します（結果参照のために保持中の終了済みセッションを含む）。
+func (sm *SessionManager) ListSessions() []SessionSummary {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	summaries := make([]SessionSummary, 0, len(sm.sessions))
+	for passcode, session := range sm.sessions {
+		summary := SessionSummary{Passcode: passcode, Status: session.Status}
+		for _, player := range []*PlayerGameState{session.Player1, session.Player2} {
+			if player == nil {
+				continue
+			}
+			summary.Players++
+			if client, ok := sm.clients[player.UserID]; ok && client.RoomID == passcode {
+				summary.ConnectedPlayers++
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Passcode < summaries[j].Passcode })
+	return summaries
+}
@@ -0,0 +1,105 @@
+package tetris
+
+import (
+	"log"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
+)
+
+// ApplyContributions は実際の草データでデッキ配置のスコアを決め直し、ボードと落下中・次のピースのスコアに反映します。
+// 草データが取得できなかった場合（nil）は、デッキに保存されたスコアをそのまま使います。
+//
+// Parameters:
+//   contributions : デッキ所有者の日別Contributionデータ
+func (s *PlayerGameState) ApplyContributions(contributions []models.DailyContribution) {
+	if contributions == nil || len(s.DeckPlacements) == 0 {
+		return
+	}
+
+	counts := make(map[string]int, len(contributions))
+	for _, c := range contributions {
+		counts[c.Date] = c.Count
+	}
+	s.DeckPlacements = tetris.ScoreDeckPlacementsByContributions(s.DeckPlacements, counts)
+	s.buildContributionScoresFromDeck()
+
+	// 生成済みのピースは古いスコアを持っているので差し替える
+	for _, piece := range []*tetris.Piece{s.CurrentPiece, s.NextPiece} {
+		if piece == nil {
+			continue
+		}
+		if fresh := s.getPieceScoreFromDeck(piece.Type); fresh != nil {
+			piece.ScoreData = fresh.ScoreData
+		}
+	}
+	s.updateCurrentPieceScores()
+
+	log.Printf("[GameState] ユーザー %s のデッキスコアに %d 日分の草データを反映しました", s.UserID, len(contributions))
+}
+
+// ContributionLevels は now を含む直近 ContributionWeeks 週分の草を、古い順の日別レベル（0-4）に変換します。
+// 対戦相手に生の貢献数を見せないためのもので、GitHubの草と同じく期間内の最大の貢献数を4等分して
+// 草が無い日を0、最大の日を tetris.MaxContributionLevel とします。草データが1件も無い場合は nil を返します。
+//
+// Parameters:
+//   contributions : 日別Contributionデータ（期間外の日付は無視）
+//   now           : 期間の最終日を決める基準時刻
+func ContributionLevels(contributions []models.DailyContribution, now time.Time) []int {
+	if len(contributions) == 0 {
+		return nil
+	}
+
+	days := models.ContributionWeeks * models.ContributionGridDays
+	start, _ := models.ContributionPeriod(now, days)
+	counts := make(map[string]int, len(contributions))
+	for _, c := range contributions {
+		counts[c.Date] = c.Count
+	}
+
+	dailyCounts := make([]int, days)
+	maxCount := 0
+	for i := range dailyCounts {
+		dailyCounts[i] = counts[start.AddDate(0, 0, i).Format(models.ContributionDateLayout)]
+		if dailyCounts[i] > maxCount {
+			maxCount = dailyCounts[i]
+		}
+	}
+
+	levels := make([]int, days)
+	for i, count := range dailyCounts {
+		if count > 0 {
+			levels[i] = (count*tetris.MaxContributionLevel + maxCount - 1) / maxCount // 切り上げで1以上にする
+		}
+	}
+	return levels
+}
+
+// applyPlayerContributions は保存済みの草データを取得し、プレイヤーのデッキ配置のスコアに反映します。
+// 草データが1件も無い・取得に失敗した場合は、デッキに保存されたスコアのままプレイします。
+// プレイヤーが草を公開する設定の場合は、対戦相手のボード背景用の日別レベルもセッションに保持します。
+func (sm *SessionManager) applyPlayerContributions(state *PlayerGameState) {
+	if state == nil || sm.dbService == nil || sm.dbService.DB == nil {
+		return
+	}
+
+	ctx, cancel := gameDBContext()
+	defer cancel()
+	contributions, err := sm.dbService.GetContributionsByUserID(ctx, state.UserID)
+	if err != nil {
+		log.Printf("[SessionManager] Failed to load contributions for %s, using saved deck scores: %v", state.UserID, err)
+		return
+	}
+	state.ApplyContributions(contributions)
+
+	// 公開設定を確認できない場合は、本人の意図に反して公開しないよう非公開として扱う
+	public, err := sm.dbService.GetContributionVisibility(ctx, state.UserID)
+	if err != nil {
+		log.Printf("[SessionManager] Failed to load contribution visibility for %s, hiding levels: %v", state.UserID, err)
+		return
+	}
+	if public {
+		state.contributionLevels = ContributionLevels(contributions, time.Now())
+	}
+}
@@ -0,0 +1,80 @@
+package tetris
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestApplyContributions は草データがボードのスコアマップと落下中のピースに反映されることをテストします。
+func TestApplyContributions(t *testing.T) {
+	state := NewPlayerGameState("player1", nil)
+	state.DeckPlacements = []tetris.DeckPlacementPiece{{
+		Type:      state.CurrentPiece.Type,
+		StartDate: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		Blocks: []models.Position{
+			{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0}, {X: 3, Y: 0},
+		},
+	}}
+
+	state.ApplyContributions([]models.DailyContribution{{Date: "2026-03-08", Count: 2}})
+
+	assert.Equal(t, tetris.DefaultContributionScore, state.ContributionScores["0_0"])
+	assert.Equal(t, tetris.DefaultContributionScore+2*tetris.ContributionScorePerCount, state.ContributionScores["0_1"])
+	assert.Contains(t, pieceScores(state.CurrentPiece), tetris.DefaultContributionScore+2*tetris.ContributionScorePerCount,
+		"落下中のピースにも新しいスコアが反映されるはず")
+}
+
+func pieceScores(piece *tetris.Piece) []int {
+	scores := []int{}
+	for _, score := range piece.ScoreData {
+		scores = append(scores, score)
+	}
+	return scores
+}
+
+// TestContributionLevels は直近の期間の草を期間内の最大値を基準に0-4のレベルに変換し、期間外の日付を無視することをテストします。
+func TestContributionLevels(t *testing.T) {
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, models.JST)
+	days := models.ContributionWeeks * models.ContributionGridDays
+
+	assert.Nil(t, ContributionLevels(nil, now), "草データが無い場合は nil のはず")
+
+	levels := ContributionLevels([]models.DailyContribution{
+		{Date: "2024-06-30", Count: 20}, // 最終日・最大
+		{Date: "2024-06-29", Count: 1},
+		{Date: "2024-06-28", Count: 10},
+		{Date: "2024-06-27", Count: 15},
+		{Date: "2024-05-06", Count: 5},   // 初日（8週間前）
+		{Date: "2024-05-05", Count: 100}, // 期間外
+	}, now)
+
+	assert.Len(t, levels, days)
+	assert.Equal(t, 4, levels[days-1])
+	assert.Equal(t, 1, levels[days-2], "少しでも草があればレベル1以上のはず")
+	assert.Equal(t, 2, levels[days-3])
+	assert.Equal(t, 3, levels[days-4])
+	assert.Equal(t, 1, levels[0])
+	assert.Equal(t, 0, levels[1], "草が無い日はレベル0のはず")
+}
+
+// TestContributionLevels_OpponentView は草のレベルが対戦相手向けの版にも含まれ、非公開（nil）の場合はJSONから省かれることをテストします。
+func TestContributionLevels_OpponentView(t *testing.T) {
+	session := newPlayingSession(t, "levels")
+	session.Player1.contributionLevels = []int{0, 1, 4}
+
+	view := session.ToLightweight().ViewFor(session.Player2.UserID)
+	assert.Equal(t, []int{0, 1, 4}, view.Player1.ContributionLevels)
+	assert.Nil(t, view.Player1.ContributionScores, "生のスコアマップは相手に送らないはず")
+	assert.Nil(t, view.Player2.ContributionLevels)
+
+	data, err := json.Marshal(view)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"contribution_levels":[0,1,4]`)
+	assert.Equal(t, 1, strings.Count(string(data), "contribution_levels"), "非公開のプレイヤーのレベルはキーごと省くはず")
+}
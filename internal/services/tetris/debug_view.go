@@ -0,0 +1,72 @@
+package tetris
+
+import (
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
+)
+
+// DebugPlayerView はデバッグ表示用のプレイヤー状態です。
+type DebugPlayerView struct {
+	UserID         string
+	Score          int
+	Level          int
+	LinesCleared   int
+	PendingGarbage int
+	IsGameOver     bool
+	Board          tetris.Board // 落下中のピースを書き込んだボードのコピー（隠し行を含む）
+}
+
+// DebugView はデバッグ表示用のセッション状態です。開発環境のデバッグエンドポイントが使います。
+type DebugView struct {
+	Status        string
+	RemainingTime time.Duration // 残り時間（プレイ中・一時停止中のみ、それ以外は0）
+	Players       []DebugPlayerView
+}
+
+// DebugView は合言葉のセッションのデバッグ表示用の状態を返します。セッションが無い場合は false を返します。
+// Status はその書き込み側と同じ sm.mu の下で読み、ボードなどのゲーム状態は gameMu の下でコピーします。
+func (sm *SessionManager) DebugView(passcode string) (DebugView, bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	session, ok := sm.sessions[passcode]
+	if !ok {
+		return DebugView{}, false
+	}
+	return session.debugViewLocked(), true
+}
+
+// debugViewLocked はゲーム状態ロックの下でデバッグ表示用の状態を作成します。
+// ボードはコピーなので、返り値はセッションループと並行して安全に読み出せます。
+// Status を読むため sm.mu を保持した状態で呼び出してください。
+func (gs *GameSession) debugViewLocked() DebugView {
+	view := DebugView{Status: gs.Status}
+	inProgress := gs.isInProgress()
+
+	gs.gameMu.Lock()
+	defer gs.gameMu.Unlock()
+	if inProgress && !gs.StartedAt.IsZero() {
+		if remaining := gs.TimeLimit - gs.elapsedPlayTimeLocked(time.Now()); remaining > 0 {
+			view.RemainingTime = remaining
+		}
+	}
+	for _, player := range []*PlayerGameState{gs.Player1, gs.Player2} {
+		if player == nil {
+			continue
+		}
+		board := player.Board
+		if player.CurrentPiece != nil && !player.IsGameOver {
+			board.MergePiece(player.CurrentPiece)
+		}
+		view.Players = append(view.Players, DebugPlayerView{
+			UserID:         player.UserID,
+			Score:          player.Score,
+			Level:          player.Level,
+			LinesCleared:   player.LinesCleared,
+			PendingGarbage: player.pendingGarbage,
+			IsGameOver:     player.IsGameOver,
+			Board:          board,
+		})
+	}
+	return view
+}
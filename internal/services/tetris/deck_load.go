@@ -0,0 +1,144 @@
+package tetris
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+)
+
+// ErrDeckLoadFailed はデッキの配置データを読み込めず、プレイヤーのゲーム状態を初期化できなかった場合のエラーです。
+var ErrDeckLoadFailed = errors.New("デッキの読み込みに失敗しました")
+
+// ErrDeckNotSelected はルームの作成・参加でデッキIDが指定されていない、またはUUID形式でない場合のエラーです。
+// 開発環境では不正なデッキIDでもテスト用デッキが返るため、デッキの読み込みより前に拒否してデッキ無しでゲームが始まらないようにします。
+// クライアントはデッキ選択画面に戻してデッキを選び直させます。
+var ErrDeckNotSelected = errors.New("デッキを選択してください")
+
+// ValidateDeckID はルームの作成・参加に使うデッキIDが指定されていて、UUID形式であることを確認します。
+// UUID形式でない場合は ErrDeckNotSelected と database.ErrInvalidDeckID の両方をラップしたエラーを返します。
+func ValidateDeckID(deckID string) error {
+	if strings.TrimSpace(deckID) == "" {
+		return ErrDeckNotSelected
+	}
+	if _, err := uuid.Parse(deckID); err != nil {
+		return fmt.Errorf("%w: デッキID %q: %w", ErrDeckNotSelected, deckID, database.ErrInvalidDeckID)
+	}
+	return nil
+}
+
+// EventOpponentJoinFailed は待機中のプレイヤーに、対戦相手の参加（デッキの読み込み・初期化）が失敗したことを通知するイベントの種類です。
+// 待機中のルームはそのまま残るため、相手は参加をやり直せます。
+const EventOpponentJoinFailed = "opponent_join_failed"
+
+// OpponentJoinFailedEvent は対戦相手の参加失敗を通知するイベントメッセージです。
+type OpponentJoinFailedEvent struct {
+	Type    string `json:"type"`
+	Version int    `json:"v"` // プロトコルバージョン（ProtocolVersion）
+	Message string `json:"message"`
+}
+
+// deckPlacementFallback はデッキの配置データを読み込めなかった場合に、ランダムなスコアで代替してゲームを続けるかどうかです。
+// 環境変数 DECK_PLACEMENT_FALLBACK で指定でき、未設定の場合は本番（APP_ENV=production）以外でのみ代替します。
+var deckPlacementFallback = loadDeckPlacementFallback()
+
+// loadDeckPlacementFallback は環境変数 DECK_PLACEMENT_FALLBACK を読み込みます。不正な値の場合はデフォルト値を使います。
+func loadDeckPlacementFallback() bool {
+	defaultValue := os.Getenv("APP_ENV") != "production"
+	env := os.Getenv("DECK_PLACEMENT_FALLBACK")
+	if env == "" {
+		return defaultValue
+	}
+	fallback, err := strconv.ParseBool(env)
+	if err != nil {
+		log.Printf("[WARN] Invalid DECK_PLACEMENT_FALLBACK %q, using default %v", env, defaultValue)
+		return defaultValue
+	}
+	return fallback
+}
+
+// newPlayerStateFromDeck はデッキの配置データからプレイヤーのゲーム状態を作成します。
+// 配置データを読み込めなかった場合、deckPlacementFallback が有効ならランダムなスコアで代替し、
+// 無効なら ErrDeckLoadFailed を返します。
+//
+// Parameters:
+//   userID   : プレイヤーのユーザーID
+//   deck     : プレイヤーが選択したデッキデータ
+//   deckRepo : デッキリポジトリ（テトリミノ配置データを取得するため）
+func newPlayerStateFromDeck(userID string, deck *models.Deck, deckRepo database.DeckRepository) (*PlayerGameState, error) {
+	state, err := NewPlayerGameStateWithDeckPlacements(userID, deck, deckRepo)
+	if err == nil {
+		return state, nil
+	}
+	if !deckPlacementFallback {
+		log.Printf("Failed to create state for player %s with deck placements: %v", userID, err)
+		return nil, fmt.Errorf("%w: %v", ErrDeckLoadFailed, err)
+	}
+	log.Printf("Failed to create state for player %s with deck placements: %v, falling back to random scores", userID, err)
+	return NewPlayerGameState(userID, deck), nil
+}
+
+// loadPlayerState はプレイヤーのデッキを読み込み、保存済みの草データを反映したゲーム状態を作成します。
+// デッキ・配置・草データのDBアクセスを sm.mu の外で済ませるため、ルームの作成・参加でロックを取る前に呼び出します
+// （ロックを保持したままDBを待つと、その間すべてのルームの入力・ブロードキャストが止まるため）。
+//
+// Parameters:
+//   playerID : プレイヤーのユーザーID
+//   deckID   : プレイヤーが使用するデッキのUUID（他人のデッキは使えない）
+// Returns:
+//   *PlayerGameState: 作成したゲーム状態
+//   error: デッキを取得できなかった場合（database.ErrDeckNotOwned など）、配置を読み込めなかった場合（ErrDeckLoadFailed）
+func (sm *SessionManager) loadPlayerState(playerID, deckID string) (*PlayerGameState, error) {
+	ctx, cancel := gameDBContext()
+	deck, err := sm.dbService.GetDeckByIDAndUser(ctx, deckID, playerID)
+	cancel()
+	if err != nil {
+		log.Printf("[SessionManager] Failed to get deck %s for player %s: %v", deckID, playerID, err)
+		return nil, fmt.Errorf("failed to get player deck: %w", err)
+	}
+
+	state, err := newPlayerStateFromDeck(playerID, deck, sm.deckRepo)
+	if err != nil {
+		log.Printf("[SessionManager] Failed to initialize player %s with deck %s: %v", playerID, deckID, err)
+		return nil, fmt.Errorf("failed to initialize player: %w", err)
+	}
+	sm.applyPlayerContributions(state)
+	return state, nil
+}
+
+// joinFailedEvent はプレイヤー2の参加に失敗した原因を、セッションのイベント履歴に残す名前に変換します。
+func joinFailedEvent(err error) string {
+	switch {
+	case errors.Is(err, ErrDeckLoadFailed):
+		return "player2_join_failed: deck_load_failed"
+	case errors.Is(err, database.ErrDeckNotOwned):
+		return "player2_join_failed: deck_not_owned"
+	default:
+		return "player2_join_failed: deck_not_found"
+	}
+}
+
+// notifyOpponentJoinFailedLocked は待機中のプレイヤー1に、対戦相手の参加が失敗したことを通知します。
+// プレイヤー1が接続していない場合は何もしません。呼び出し側で sm.mu のロックを保持している必要があります。
+func (sm *SessionManager) notifyOpponentJoinFailedLocked(session *GameSession) {
+	if session.Player1 == nil {
+		return
+	}
+	client, ok := sm.clients[session.Player1.UserID]
+	if !ok || client.RoomID != session.ID {
+		return
+	}
+	message, err := json.Marshal(OpponentJoinFailedEvent{Type: EventOpponentJoinFailed, Version: ProtocolVersion, Message: "対戦相手の参加に失敗しました"})
+	if err != nil {
+		log.Printf("[SessionManager] Error marshaling %s event for passcode %s: %v", EventOpponentJoinFailed, session.ID, err)
+		return
+	}
+	sendReliable(client, message)
+}
@@ -15,6 +15,9 @@ const (
 	SoftDropMultiplier  = 5                       // ソフトドロップ時の落下速度倍率
 	GameTimeLimit      = 100 * time.Second       // ゲームの制限時間（100秒）
 	LevelUpLines       = 5                       // レベルアップに必要なライン数（5ラインごとにレベルアップ）
+	// MaxLevel はレベルの上限です。到達後はレベル表示・落下速度・スコア倍率のすべてを固定します。
+	// (MaxLevel-1)*LevelUpLines ライン消去で到達し、落下間隔は既に下限の100msに達しています。
+	MaxLevel           = 20
 	// LockDelay           = 500 * time.Millisecond // ピースが着地してから固定されるまでの猶予時間 (オプション)
 )
 
@@ -28,9 +31,12 @@ func GetFallInterval(level int) time.Duration {
 	return interval
 }
 
+// SpawnY はテトリミノの初期Y位置です。隠し行の最上段にスポーンし、落下とともに表示部分へ降りてきます。
+const SpawnY = 0
+
 // spawnPieceAtCenter は指定されたテトリミノタイプの適切な初期位置を返します
 func spawnPieceAtCenter(pieceType tetris.PieceType) (int, int) {
-	y := 1 // 全てのテトリミノの初期Y位置は1
+	y := SpawnY
 	
 	switch pieceType {
 	case tetris.TypeI:
@@ -42,14 +48,37 @@ func spawnPieceAtCenter(pieceType tetris.PieceType) (int, int) {
 	}
 }
 
+// resetPieceToSpawn はピースをテトリミノの種類に応じたスポーン位置・回転0に配置し直します。
+// 新規スポーンとホールドの両方で使い、スポーン位置の決め方を一箇所にまとめます。
+func resetPieceToSpawn(piece *tetris.Piece) {
+	x, y := spawnPieceAtCenter(piece.Type)
+	piece.X = x
+	piece.Y = y
+	piece.Rotation = 0 // 必ず回転をリセット
+}
+
 // ApplyPlayerInput はプレイヤーの入力をゲーム状態に適用します。
+// 操作は1件ずつ適用する必要があるため（ハードドロップの落下〜固定の途中に他の入力や自動落下が割り込まないようにする）、
+// セッションのプレイヤーに対してはゲーム状態ロック（gameMu）を保持した状態で呼び出してください。
 //
 // Parameters:
 //   state : 更新するプレイヤーのゲーム状態のポインタ
-//   action : プレイヤーが実行したアクション（"left", "right", "rotate_left", "rotate_right", "soft_drop", "hard_drop", "hold"）
+//   action : プレイヤーが実行したアクション（Action* 定数。許可リストに無いアクションは無視する）
 // Returns:
 //   bool: ピースが移動・回転・固定されたかどうか（描画更新の判定に使用）
-func ApplyPlayerInput(state *PlayerGameState, action string) bool {
+func ApplyPlayerInput(state *PlayerGameState, action string) (moved bool) {
+	defer func() {
+		if moved {
+			state.actionCount++ // 状態が変わった操作をAPMとして数える
+		}
+	}()
+
+	// 許可リストに無いアクションはswitchに入る前に弾く（未知のアクションはどのcaseにも当たらないため）
+	if !IsAllowedAction(action) {
+		log.Printf("[DEBUG] Ignoring unknown action %q from user %s", action, state.UserID)
+		return false
+	}
+
 	if state.IsGameOver {
 		return false
 	}
@@ -59,41 +88,30 @@ func ApplyPlayerInput(state *PlayerGameState, action string) bool {
 		return false
 	}
 
-	moved := false
-
 	switch action {
-	case "left", "move_left":
+	case ActionLeft, ActionMoveLeft:
 		if !state.Board.HasCollision(state.CurrentPiece, -1, 0) {
 			state.CurrentPiece.X--
+			state.lastMoveWasRotation = false
 			moved = true
 		}
-	case "right", "move_right":
+	case ActionRight, ActionMoveRight:
 		if !state.Board.HasCollision(state.CurrentPiece, 1, 0) {
 			state.CurrentPiece.X++
+			state.lastMoveWasRotation = false
 			moved = true
 		}
-	case "down", "soft_drop":
+	case ActionDown, ActionSoftDrop:
 		// ソフトドロップ（手動でピースを下に落とす）
 		if !state.Board.HasCollision(state.CurrentPiece, 0, 1) {
 			state.CurrentPiece.Y++
 			state.Score += 1 // ソフトドロップで1ポイント加算
+			state.lastMoveWasRotation = false
 			moved = true
 		}
-	case "hard_drop":
-		// ハードドロップ（ピースを一番下まで瞬時に落とす）
-		dropDistance := 0
-		for !state.Board.HasCollision(state.CurrentPiece, 0, dropDistance+1) {
-			dropDistance++
-		}
-		if dropDistance > 0 {
-			state.CurrentPiece.Y += dropDistance
-			state.Score += dropDistance * 2 // ハードドロップで落下距離×2ポイント加算
-			moved = true
-		}
-		// ハードドロップ後はピースを即座に固定
-		state.Board.MergePiece(state.CurrentPiece)
-		handlePieceLock(state)
-	case "rotate_right", "rotate":
+	case ActionHardDrop:
+		moved = hardDrop(state)
+	case ActionRotateRight, ActionRotate:
 		// 右回転（Oピースは回転しない）
 		if state.CurrentPiece.Type == tetris.TypeO {
 			// Oピースは回転しない
@@ -105,10 +123,11 @@ func ApplyPlayerInput(state *PlayerGameState, action string) bool {
 				// 衝突する場合は回転を元に戻す
 				state.CurrentPiece.Rotation = oldRotation
 			} else {
+				state.lastMoveWasRotation = true
 				moved = true
 			}
 		}
-	case "rotate_left":
+	case ActionRotateLeft:
 		// 左回転（Oピースは回転しない）
 		if state.CurrentPiece.Type == tetris.TypeO {
 			// Oピースは回転しない
@@ -120,12 +139,13 @@ func ApplyPlayerInput(state *PlayerGameState, action string) bool {
 				// 衝突する場合は回転を元に戻す
 				state.CurrentPiece.Rotation = oldRotation
 			} else {
+				state.lastMoveWasRotation = true
 				moved = true
 			}
 		}
-	case "hold":
-		// ホールド機能（今回が既に使用済みでなければ実行）
-		if !state.hasUsedHold {
+	case ActionHold:
+		// ホールド機能（ルールで許可されていて、今回が既に使用済みでなければ実行）
+		if !state.holdDisabled && !state.hasUsedHold {
 			state.hasUsedHold = true
 
 			// 現在のピースを一時保存
@@ -151,36 +171,63 @@ func ApplyPlayerInput(state *PlayerGameState, action string) bool {
 				log.Printf("[ERROR] HeldPiece is nil during hold swap for user %s", state.UserID)
 				state.CurrentPiece = state.GetNextPieceFromQueue()
 				state.NextPiece = state.GetNextPieceFromQueue()
-			} else {
-				// テトリミノの種類に応じた適切な初期位置を設定
-				x, y := spawnPieceAtCenter(state.CurrentPiece.Type)
-				state.CurrentPiece.X = x
-				state.CurrentPiece.Y = y
-				state.CurrentPiece.Rotation = 0
 			}
+			resetPieceToSpawn(state.CurrentPiece)
 			
 			// 現在のピースのコピーをホールドピースとして設定
 			state.HeldPiece = currentPieceCopy
 			moved = true
-		}
 
-		// ホールド後のピースが衝突する場合はゲームオーバー
-		if state.CurrentPiece != nil && state.Board.HasCollision(state.CurrentPiece, 0, 0) {
-			log.Printf("[INFO] Game over after hold for user %s - piece collision", state.UserID)
-			state.IsGameOver = true
+			// ホールド後のピースがスポーン位置で衝突する場合はブロックアウトでゲームオーバー
+			if state.Board.HasCollision(state.CurrentPiece, 0, 0) {
+				log.Printf("[INFO] Game over after hold for user %s - piece collision", state.UserID)
+				state.topOut(TopOutBlockOut)
+				state.markPlayEnded(time.Now())
+			}
 		}
 	}
 
-	// スコア更新を軽量化: ハードドロップ以外のみ更新（頻度削減）
-	if moved && state.CurrentPiece != nil && action != "hard_drop" {
-		state.updateCurrentPieceScores()
+	// スコア更新を軽量化: ハードドロップは固定時に更新済み。
+	// 落下・ホールドは必ず更新し、横移動・回転の連打は scoreUpdateInterval で間引く
+	if moved && state.CurrentPiece != nil && action != ActionHardDrop {
+		switch action {
+		case ActionDown, ActionSoftDrop, ActionHold:
+			state.updateCurrentPieceScores()
+		default:
+			state.updateCurrentPieceScoresThrottled(time.Now())
+		}
 	}
 
 	return moved
 }
 
+// hardDrop はピースを一番下まで瞬時に落とし、そのまま固定します。
+// 落下距離の計算から固定・次のピースのスポーンまでを1つの操作として行い、途中で回転などの再配置は行いません。
+// 呼び出し側でゲーム状態ロック（gameMu）を保持している必要があります。
+//
+// Parameters:
+//   state : 更新するプレイヤーのゲーム状態のポインタ
+// Returns:
+//   bool: ピースが1マス以上落下したかどうか
+func hardDrop(state *PlayerGameState) bool {
+	dropDistance := 0
+	for !state.Board.HasCollision(state.CurrentPiece, 0, dropDistance+1) {
+		dropDistance++
+	}
+	state.CurrentPiece.Y += dropDistance
+	state.Score += dropDistance * 2 // ハードドロップで落下距離×2ポイント加算
+	if dropDistance > 0 {
+		state.lastMoveWasRotation = false
+	}
+
+	// ハードドロップ後はピースを即座に固定（ロックディレイの対象外）
+	state.Board.MergePiece(state.CurrentPiece)
+	handlePieceLock(state)
+	return dropDistance > 0
+}
+
 // AutoFall は自動落下処理を行います。
-// GameSessionManagerのメインループから定期的に呼び出されます。
+// セッションのゲームループ（runSessionLoop）から、ゲーム状態ロック（gameMu）を保持した状態で定期的に呼び出されます。
 //
 // Parameters:
 //   state : 更新するプレイヤーのゲーム状態のポインタ
@@ -191,8 +238,8 @@ func AutoFall(state *PlayerGameState) bool {
 		return false
 	}
 
-	// 落下間隔の計算（レベルに基づく）
-	fallInterval := GetFallInterval(state.Level)
+	// 落下間隔の計算（レベルとハンディキャップに基づく）
+	fallInterval := state.fallInterval()
 	
 	// テスト環境では時間チェックをスキップ（無限ループ防止）
 	timePassed := time.Since(state.lastFallTime)
@@ -202,10 +249,10 @@ func AutoFall(state *PlayerGameState) bool {
 			// 落下
 			state.CurrentPiece.Y++
 			state.lastFallTime = time.Now()
+			state.lastMoveWasRotation = false
 			
-			// 自動落下時はスコア更新をスキップ（パフォーマンス優先）
-			// クライアント側で補間されるため問題なし
-			// state.updateCurrentPieceScores()
+			// 落下時は間引かずに更新する（横移動・回転で間引いた分もここで追いつく）
+			state.updateCurrentPieceScores()
 			
 			return true
 		} else {
@@ -225,42 +272,103 @@ func AutoFall(state *PlayerGameState) bool {
 // Parameters:
 //   state : 更新するプレイヤーのゲーム状態のポインタ
 func handlePieceLock(state *PlayerGameState) {
+	// 間引きで古くなっている可能性があるため、固定直前のピース位置で強制的に更新する
+	state.updateCurrentPieceScores()
+
 	// ピースのスコアデータをContributionScoresに反映
 	updateContributionScoresFromPiece(state, state.CurrentPiece)
+	state.piecesPlaced++ // PPS計算用
+
+	// ロックアウト判定: 表示部分に1マスも入らないまま隠し行の中で固定された場合は、ライン消去や次のスポーンを行わずに終了
+	if lockedAboveVisible(state.CurrentPiece) {
+		state.topOut(TopOutLockOut)
+		state.markPlayEnded(time.Now())
+		log.Printf("Player %s Game Over (lock out)! Final Score: %d, Lines Cleared: %d", state.UserID, state.Score, state.LinesCleared)
+		return
+	}
+
+	// 効果音・エフェクト用の出来事の判定に使う固定前の状態
+	tSpin := isTSpin(state)
+	previousLevel := state.Level
+	previousBackToBack := state.BackToBack
 
 	// ラインクリア判定とスコア加算
-	clearedLines, lineClearScore := state.Board.ClearLines(state.ContributionScores)
+	clearedLines, lineClearScore, clearedRows := state.Board.ClearLines(state.ContributionScores)
 	state.LinesCleared += clearedLines
 	state.Score += lineClearScore // ラインクリアによるスコア加算
 
+	perfectClear := clearedLines > 0 && state.Board.IsEmpty()
+	clearType := classifyClear(clearedLines, tSpin, perfectClear)
+	// B2Bの対象かはパーフェクトクリアでもライン数・T-Spinで判定する（テトリスでのパーフェクトクリアはB2Bを続ける）
+	difficultClear := isDifficultClear(classifyClear(clearedLines, tSpin, false))
+
 	if clearedLines > 0 {
 		// コンボやBack-to-Backなどのボーナス計算をここに実装
-		state.Score += CalculateScore(clearedLines, state.Level, state.ConsecutiveClears, state.BackToBack)
+		state.Score += CalculateScore(clearedLines, state.Level, state.ConsecutiveClears, tSpin, perfectClear, state.BackToBack)
 
 		// 連続ラインクリアの更新
 		state.ConsecutiveClears++
-		state.BackToBack = (clearedLines == 4) // テトリス（4ラインクリア）でB2Bをセット
+		state.BackToBack = difficultClear // テトリス・ラインを消したT-SpinでB2Bをセット
 
-		// レベルアップのロジック (5ラインクリアごとにレベルアップ)
-		state.Level = state.LinesCleared/LevelUpLines + 1
+		// レベルアップのロジック (5ラインクリアごとにレベルアップ、MaxLevelで固定)
+		state.Level = calculateLevel(state.LinesCleared)
 
-		// TODO: マルチプレイの場合、お邪魔ブロック送信ロジックを SessionManager に通知
 	} else {
 		// ラインクリアがない場合、連続クリアカウンターをリセット
+		// B2Bは難しくない消去でだけ途切れ、ラインを消さない固定では維持する（テトリス→消さない固定→テトリスもB2B）
 		state.ConsecutiveClears = 0
-		state.BackToBack = false
 	}
 
-	state.SpawnNewPiece() // 次のピースを生成
+	// 消去アニメーション用に、消えた行を表示部分の座標で溜めておく（隠し行内の消去は表示されないため除く）
+	if rows := visibleRows(clearedRows); len(rows) > 0 {
+		state.lastClearedRows = append(state.lastClearedRows, rows)
+	}
+
+	// 送信されるまで出来事を溜めておく（セッションが takeLockEvents で取り出して送信する）
+	state.lastEvents = append(state.lastEvents, lockEvents(
+		clearedLines,
+		tSpin,
+		perfectClear,
+		state.ConsecutiveClears > 1,
+		difficultClear && previousBackToBack,
+		state.Level > previousLevel,
+	)...)
+
+	// 攻撃分で予告中のお邪魔ラインを相殺し、残りを相手への送信分にする
+	attack := CalculateAttack(clearType, state.ConsecutiveClears, previousBackToBack)
+	if offset := min(attack, state.pendingGarbage); offset > 0 {
+		state.pendingGarbage -= offset
+		attack -= offset
+	}
+	state.outgoingGarbage += attack
 
-	// 新しいピースがスポーン位置で既に衝突（ボードの最上部が埋まっている）したらゲームオーバー
+	// 相殺しきれなかった予告分をせり上げる（次のピースのスポーン判定より前に反映）
+	// せり上げで既存のブロックが表示部分の上端を超えた場合はトップアウトとし、次のピースはスポーンしない
+	state.applyPendingGarbage()
+
+	if !state.IsGameOver {
+		state.SpawnNewPiece() // 次のピースを生成（せり上がったボードと衝突する場合はブロックアウト）
+	}
+
+	// せり上げで押し上げられた、または新しいピースがスポーン位置で既に衝突（隠し行まで埋まっている）したらゲームオーバー
 	if state.IsGameOver {
-		log.Printf("Player %s Game Over! Final Score: %d, Lines Cleared: %d", state.UserID, state.Score, state.LinesCleared)
+		state.markPlayEnded(time.Now()) // ゲームオーバー時点のAPM/PPSで固定
+		log.Printf("Player %s Game Over (%s)! Final Score: %d, Lines Cleared: %d", state.UserID, state.topOutKind, state.Score, state.LinesCleared)
 		// TODO: GameSessionManager にゲームオーバーを通知し、セッションを終了する
 		// 例: sessionManager.EndGameSession(state.RoomID)
 	}
 }
 
+// calculateLevel は累計消去ライン数からレベルを計算します。
+// LevelUpLines ラインごとに1上がり、MaxLevel で頭打ちになります（カンスト後はスコア倍率も伸びません）。
+func calculateLevel(linesCleared int) int {
+	level := linesCleared/LevelUpLines + 1
+	if level > MaxLevel {
+		return MaxLevel
+	}
+	return level
+}
+
 // updateContributionScoresFromPiece はピースのスコアデータをPlayerGameStateのContributionScoresに反映します。
 //
 // Parameters:
@@ -278,10 +386,11 @@ func updateContributionScoresFromPiece(state *PlayerGameState, piece *tetris.Pie
 		boardX := piece.X + block[0]
 		boardY := piece.Y + block[1]
 
-		// ボードの有効な範囲内のみ処理
-		if boardX >= 0 && boardX < tetris.BoardWidth && boardY >= 0 && boardY < tetris.BoardHeight {
+		// ボードの表示範囲内のみ処理（スコアマップは表示座標で管理）
+		visibleY := tetris.ToVisibleY(boardY)
+		if boardX >= 0 && boardX < tetris.BoardWidth && visibleY >= 0 && visibleY < tetris.BoardHeight {
 			// 文字列作成の最適化: strconv使用でfmt.Sprintfより高速
-			scoreKey := strconv.Itoa(boardY) + "_" + strconv.Itoa(boardX)
+			scoreKey := strconv.Itoa(visibleY) + "_" + strconv.Itoa(boardX)
 			rotationKey := "rot_" + strconv.Itoa(piece.Rotation) + "_" + strconv.Itoa(block[0]) + "_" + strconv.Itoa(block[1])
 			
 			// スコア存在チェックを効率化
@@ -292,32 +401,51 @@ func updateContributionScoresFromPiece(state *PlayerGameState, piece *tetris.Pie
 	}
 }
 
+// lineClearBaseScores はライン消去の種類ごとの基本点（レベル1のときのボーナススコア）です。
+// T-Spinは同じライン数の通常の消去より高く、パーフェクトクリアの加点は perfectClearBaseScores で別に加えます。
+var lineClearBaseScores = map[int]int{
+	ClearSingle:      100,
+	ClearDouble:      300,
+	ClearTriple:      500,
+	ClearTetris:      800,
+	ClearTSpinSingle: 800,
+	ClearTSpinDouble: 1200,
+	ClearTSpinTriple: 1600,
+}
+
+// perfectClearBaseScores はパーフェクトクリアのときに追加する基本点です。添字は消去したライン数です。
+var perfectClearBaseScores = map[int]int{1: 800, 2: 1200, 3: 1800, 4: 2000}
+
 // CalculateScore はラインクリア数、レベル、コンボなどに基づいて追加スコアを計算します。
 // GITRIS固有の「草の濃さ」によるスコアは Board.ClearLines で加算されるため、
 // ここは一般的なテトリスルールでのボーナススコアを計算する場所です。
+// 仕様は TestCalculateScore で固定しています。変更する場合はテストの期待値も合わせて見直してください。
+//
+//   スコア = (基本点 × レベル + 50 × (consecutiveClears - 1) × レベル [consecutiveClears > 1 のみ]) × 1.5 [B2Bのみ、切り捨て]
+//            + パーフェクトクリアの基本点 × レベル [perfectClear のみ]
+//   基本点 = Single 100 / Double 300 / Triple 500 / Tetris 800 / T-Spin Single 800 / Double 1200 / Triple 1600
+//   パーフェクトクリアの基本点 = 1ライン 800 / 2ライン 1200 / 3ライン 1800 / 4ライン 2000
+//   B2B = backToBack で、今回の消去も難しい消去（isDifficultClear: テトリス・ラインを消したT-Spin）の場合（お邪魔ラインと同じ定義）
+//   0ラインの場合はボーナスを含めて0点
 //
 // Parameters:
 //   clearedLines      : クリアされたライン数 (1-4)
-//   level             : 現在のレベル
-//   consecutiveClears : 連続ラインクリア数
-//   backToBack        : 前回のラインクリアがT-SpinまたはTetrisだったか
+//   level             : 現在のレベル（ライン消去によるレベルアップ前の値）
+//   consecutiveClears : 今回の消去より前に連続してラインを消した回数（handlePieceLock は加算前の値を渡す）
+//   tSpin             : 固定したピースがT-Spinだったか
+//   perfectClear      : 消去後に盤面が空になったか
+//   backToBack        : 前回のラインクリアが難しい消去（テトリス・ラインを消したT-Spin）だったか
 // Returns:
 //   int: 計算されたボーナススコア
-func CalculateScore(clearedLines int, level int, consecutiveClears int, backToBack bool) int {
-	baseScore := 0
-	switch clearedLines {
-	case 1: // Single
-		baseScore = 100
-	case 2: // Double
-		baseScore = 300
-	case 3: // Triple
-		baseScore = 500
-	case 4: // Tetris
-		baseScore = 800
+func CalculateScore(clearedLines, level, consecutiveClears int, tSpin, perfectClear, backToBack bool) int {
+	if clearedLines <= 0 {
+		return 0 // ラインを消していなければコンボ・B2Bのボーナスも無い
 	}
+	// パーフェクトクリアでも基本点とB2Bはライン数・T-Spinで判定する
+	clearType := classifyClear(clearedLines, tSpin, false)
 
 	// レベルボーナス
-	score := baseScore * level
+	score := lineClearBaseScores[clearType] * level
 
 	// コンボボーナス (連続クリア)
 	if consecutiveClears > 1 {
@@ -325,10 +453,13 @@ func CalculateScore(clearedLines int, level int, consecutiveClears int, backToBa
 	}
 
 	// Back-to-Backボーナス (T-SpinやTetris後にすぐT-Spin/Tetris)
-	if backToBack && clearedLines > 0 { // T-SpinとTetrisの場合のみB2Bが適用されるのが一般的
-		score = int(float64(score) * 1.5) // 例: 1.5倍
+	if backToBack && isDifficultClear(clearType) {
+		score = score * 3 / 2
 	}
 
-	// TODO: T-Spin判定やPerfect Clear判定があれば、ここに追加ボーナスを実装
+	// パーフェクトクリアボーナス（B2Bの倍率はかけない）
+	if perfectClear {
+		score += perfectClearBaseScores[min(clearedLines, 4)] * level
+	}
 	return score
 }
@@ -1,7 +1,9 @@
 package tetris
 
 import (
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
@@ -191,7 +193,7 @@ func TestApplyPlayerInput_HardDrop(t *testing.T) {
 	// ボードの最下段にピースが固定されたことを確認
 	hasPieceAtBottom := false
 	for x := 0; x < tetris.BoardWidth; x++ {
-		if state.Board[tetris.BoardHeight-1][x] != tetris.BlockEmpty {
+		if state.Board[tetris.BoardTotalHeight-1][x] != tetris.BlockEmpty {
 			hasPieceAtBottom = true
 			break
 		}
@@ -237,14 +239,14 @@ func TestLineClear(t *testing.T) {
 
 	// ボードの最下段を埋める
 	for x := 0; x < tetris.BoardWidth; x++ {
-		state.Board[tetris.BoardHeight-1][x] = tetris.BlockI
+		state.Board[tetris.BoardTotalHeight-1][x] = tetris.BlockI
 	}
 
 	initialScore := state.Score
 	initialLinesCleared := state.LinesCleared
 
 	// ピースを落下させてラインクリアを発生させる
-	state.CurrentPiece.Y = tetris.BoardHeight - 2
+	state.CurrentPiece.Y = tetris.BoardTotalHeight - 2
 	ApplyPlayerInput(state, "hard_drop")
 
 	// スコアとラインクリア数が増加したことを確認
@@ -264,8 +266,8 @@ func TestGameOver(t *testing.T) {
 		t.Fatal("Initial CurrentPiece is nil, cannot run test.")
 	}
 
-	// ボードを全体的に埋める（最上部まで含む）
-	for y := 0; y < tetris.BoardHeight; y++ {
+	// ボードを全体的に埋める（隠し行まで含む）
+	for y := 0; y < tetris.BoardTotalHeight; y++ {
 		for x := 0; x < tetris.BoardWidth; x++ {
 			state.Board[y][x] = tetris.BlockI
 		}
@@ -274,10 +276,93 @@ func TestGameOver(t *testing.T) {
 	// 新しいピースを生成してゲームオーバーを発生させる
 	state.SpawnNewPiece()
 
-	// ゲームオーバー状態を確認
+	// ゲームオーバー状態を確認（スポーン位置が埋まっているのでブロックアウト）
 	if !state.IsGameOver {
 		t.Error("Expected game over state, but game is still running.")
 	}
+	if state.TopOut() != TopOutBlockOut {
+		t.Errorf("Expected top out %q, but got %q", TopOutBlockOut, state.TopOut())
+	}
+}
+
+// TestLockOut は表示部分に1マスも入らないまま隠し行の中で固定された場合に、
+// ライン消去や次のスポーンを行わずにロックアウトでゲームオーバーになることをテストします。
+func TestLockOut(t *testing.T) {
+	mockDeck := &models.Deck{ID: "mock-deck-id"}
+	state := NewPlayerGameState("test-user", mockDeck)
+
+	// 表示部分を（ラインが揃わないよう右端の列を空けて）埋め、隠し行は空けておく
+	for y := tetris.BoardHiddenHeight; y < tetris.BoardTotalHeight; y++ {
+		for x := 0; x < tetris.BoardWidth-1; x++ {
+			state.Board[y][x] = tetris.BlockFilled
+		}
+	}
+	state.SpawnNewPiece()
+	if state.IsGameOver {
+		t.Fatal("Expected spawn to succeed while hidden rows are empty, but game is over.")
+	}
+
+	ApplyPlayerInput(state, ActionHardDrop)
+
+	if !state.IsGameOver {
+		t.Error("Expected game over after locking in hidden rows, but game is still running.")
+	}
+	if state.TopOut() != TopOutLockOut {
+		t.Errorf("Expected top out %q, but got %q", TopOutLockOut, state.TopOut())
+	}
+}
+
+// TestLockedAboveVisible は一部でも表示部分に入っているピースはロックアウトとみなさないことをテストします。
+func TestLockedAboveVisible(t *testing.T) {
+	tests := []struct {
+		name  string
+		piece *tetris.Piece
+		want  bool
+	}{
+		{"隠し行の中のOミノ", &tetris.Piece{Type: tetris.TypeO, X: 4, Y: 0}, true},
+		{"1段だけ表示部分に入ったOミノ", &tetris.Piece{Type: tetris.TypeO, X: 4, Y: tetris.BoardHiddenHeight - 1}, false},
+		{"表示部分にはみ出す縦のIミノ", &tetris.Piece{Type: tetris.TypeI, X: 4, Y: 0, Rotation: 90}, false},
+		{"ピースなし", nil, false},
+	}
+	for _, tt := range tests {
+		if got := lockedAboveVisible(tt.piece); got != tt.want {
+			t.Errorf("%s: expected %v, but got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+// TestSpawnInHiddenRows は表示部分の最上段まで積み上がっていても、
+// 隠し行が空いていればスポーン直後にゲームオーバーにならないことをテストします。
+func TestSpawnInHiddenRows(t *testing.T) {
+	mockDeck := &models.Deck{ID: "mock-deck-id"}
+	state := NewPlayerGameState("test-user", mockDeck)
+
+	// 表示部分のみを全体的に埋める（隠し行は空のまま）
+	for y := tetris.BoardHiddenHeight; y < tetris.BoardTotalHeight; y++ {
+		for x := 0; x < tetris.BoardWidth; x++ {
+			state.Board[y][x] = tetris.BlockI
+		}
+	}
+
+	state.SpawnNewPiece()
+
+	if state.IsGameOver {
+		t.Error("Expected no game over when hidden rows are empty, but game is over.")
+	}
+	for _, block := range state.CurrentPiece.Blocks() {
+		if y := state.CurrentPiece.Y + block[1]; y >= tetris.BoardHiddenHeight {
+			t.Errorf("Expected piece to spawn in hidden rows, but block is at y=%d", y)
+		}
+	}
+
+	// クライアント送信用の表示ボードには隠し行が含まれない
+	visible := state.Board.Visible()
+	if len(visible) != tetris.BoardHeight {
+		t.Errorf("Expected visible board height %d, but got %d", tetris.BoardHeight, len(visible))
+	}
+	if visible[0][0] != tetris.BlockI {
+		t.Errorf("Expected top visible row to be filled, but got %v", visible[0][0])
+	}
 }
 
 // TestApplyPlayerInput_Hold はホールド機能をテストします。
@@ -337,16 +422,16 @@ func TestApplyPlayerInput_Hold(t *testing.T) {
 	switch state.CurrentPiece.Type {
 	case tetris.TypeI:
 		expectedX = tetris.BoardWidth/2 - 2 // 3
-		expectedY = 1
+		expectedY = SpawnY
 	case tetris.TypeO:
 		expectedX = tetris.BoardWidth/2 - 1 // 4
-		expectedY = 1
+		expectedY = SpawnY
 	case tetris.TypeL:
 		expectedX = tetris.BoardWidth/2 - 1 // 4
-		expectedY = 1
+		expectedY = SpawnY
 	default:
 		expectedX = tetris.BoardWidth/2 - 1 // 4
-		expectedY = 1
+		expectedY = SpawnY
 	}
 	
 	if state.CurrentPiece.X != expectedX || state.CurrentPiece.Y != expectedY {
@@ -363,8 +448,8 @@ func TestApplyPlayerInput_HoldGameOver(t *testing.T) {
 		t.Fatal("Initial CurrentPiece is nil, cannot run test.")
 	}
 
-	// ボードを全体的に埋める（最上部まで含む）
-	for y := 0; y < tetris.BoardHeight; y++ {
+	// ボードを全体的に埋める（隠し行まで含む）
+	for y := 0; y < tetris.BoardTotalHeight; y++ {
 		for x := 0; x < tetris.BoardWidth; x++ {
 			state.Board[y][x] = tetris.BlockFilled
 		}
@@ -380,6 +465,9 @@ func TestApplyPlayerInput_HoldGameOver(t *testing.T) {
 	if !state.IsGameOver {
 		t.Error("Expected game over after hold, but game is still running")
 	}
+	if state.TopOut() != TopOutBlockOut {
+		t.Errorf("Expected top out %q after hold, but got %q", TopOutBlockOut, state.TopOut())
+	}
 }
 
 // `go test -v ./services/tetris/...` コマンドでテストを実行できます。
@@ -391,7 +479,7 @@ func TestUpdateContributionScoresFromPiece(t *testing.T) {
 
 	// テスト用のピースを作成（ScoreDataを含む）
 	// T-ピースの0度回転時の配置: {{1, 0}, {0, 1}, {1, 1}, {2, 1}}
-	// X=5, Y=10に配置した場合の実際のボード座標:
+	// X=5, 表示座標Y=10（内部座標は隠し行分ずらしたY）に配置した場合の表示座標:
 	// (5+1, 10+0) = (6, 10)
 	// (5+0, 10+1) = (5, 11)  
 	// (5+1, 10+1) = (6, 11)
@@ -399,7 +487,7 @@ func TestUpdateContributionScoresFromPiece(t *testing.T) {
 	testPiece := &tetris.Piece{
 		Type:     tetris.TypeT,
 		X:        5,
-		Y:        10,
+		Y:        10 + tetris.BoardHiddenHeight,
 		Rotation: 0,
 		ScoreData: map[string]int{
 			"rot_0_1_0": 100, // ブロック座標 (6, 10)
@@ -573,3 +661,302 @@ func TestApplyPlayerInput_GameOverIgnored(t *testing.T) {
 		t.Error("Expected player to remain in game over state")
 	}
 }
+
+// setupDoubleClear は O-ミノを左端にハードドロップすると2ライン消去になる盤面を用意します。
+// パーフェクトクリアにならないよう、消える2段の上にブロックを1つ残します。
+func setupDoubleClear(state *PlayerGameState) {
+	for y := tetris.BoardTotalHeight - 2; y < tetris.BoardTotalHeight; y++ {
+		for x := 2; x < tetris.BoardWidth; x++ {
+			state.Board[y][x] = tetris.BlockFilled
+		}
+	}
+	state.Board[tetris.BoardTotalHeight-3][tetris.BoardWidth-1] = tetris.BlockFilled
+	state.CurrentPiece = &tetris.Piece{Type: tetris.TypeO, X: 0, Y: SpawnY}
+}
+
+// TestPendingGarbage_RaisedOnLock は予告中のお邪魔ラインがピース固定時にせり上がることをテストします。
+func TestPendingGarbage_RaisedOnLock(t *testing.T) {
+	mockDeck := &models.Deck{ID: "mock-deck-id"}
+	state := NewPlayerGameState("test-user", mockDeck)
+
+	state.ReceiveGarbage(2)
+
+	// 受信直後は盤面に反映されず予告として溜まるだけ
+	if state.PendingGarbage() != 2 {
+		t.Errorf("Expected pending garbage to be 2, but got %d", state.PendingGarbage())
+	}
+	for x := 0; x < tetris.BoardWidth; x++ {
+		if state.Board[tetris.BoardTotalHeight-1][x] != tetris.BlockEmpty {
+			t.Fatal("Expected garbage not to be raised before piece lock")
+		}
+	}
+
+	// ライン消去なしでピースを固定するとせり上がる
+	state.CurrentPiece = &tetris.Piece{Type: tetris.TypeO, X: 0, Y: SpawnY}
+	ApplyPlayerInput(state, "hard_drop")
+
+	if state.PendingGarbage() != 0 {
+		t.Errorf("Expected pending garbage to be 0 after lock, but got %d", state.PendingGarbage())
+	}
+	for y := tetris.BoardTotalHeight - 2; y < tetris.BoardTotalHeight; y++ {
+		garbageCount := 0
+		for x := 0; x < tetris.BoardWidth; x++ {
+			if state.Board[y][x] == tetris.BlockGarbage {
+				garbageCount++
+			}
+		}
+		if garbageCount != tetris.BoardWidth-1 {
+			t.Errorf("Expected row %d to be a garbage line with one hole, but got %d garbage blocks", y, garbageCount)
+		}
+	}
+}
+
+// TestPendingGarbage_TopOut はせり上げで既存のブロックが表示部分の上端を超えるとトップアウトになり、
+// 次のピースをスポーンしないことをテストします。
+func TestPendingGarbage_TopOut(t *testing.T) {
+	mockDeck := &models.Deck{ID: "mock-deck-id"}
+	state := NewPlayerGameState("test-user", mockDeck)
+
+	// 右端の列を表示部分の最上段まで積み上げる（ラインは揃わない）
+	for y := tetris.BoardHiddenHeight; y < tetris.BoardTotalHeight; y++ {
+		state.Board[y][tetris.BoardWidth-1] = tetris.BlockFilled
+	}
+	state.ReceiveGarbage(1)
+	state.CurrentPiece = &tetris.Piece{Type: tetris.TypeO, X: 0, Y: SpawnY}
+	lockedPiece := state.CurrentPiece
+	ApplyPlayerInput(state, ActionHardDrop)
+
+	if !state.IsGameOver {
+		t.Fatal("Expected game over after garbage pushed blocks above the visible area, but game is still running.")
+	}
+	if state.TopOut() != TopOutGarbage {
+		t.Errorf("Expected top out %q, but got %q", TopOutGarbage, state.TopOut())
+	}
+	if state.Board[tetris.BoardHiddenHeight-1][tetris.BoardWidth-1] != tetris.BlockFilled {
+		t.Error("Expected the top block to be pushed into the hidden rows")
+	}
+	if state.CurrentPiece != lockedPiece {
+		t.Error("Expected no new piece to spawn after garbage top out")
+	}
+}
+
+// TestPendingGarbage_OffsetByLineClear はライン消去の攻撃分で予告が相殺されることをテストします。
+func TestPendingGarbage_OffsetByLineClear(t *testing.T) {
+	mockDeck := &models.Deck{ID: "mock-deck-id"}
+
+	// 予告1ラインをダブル（攻撃1）で完全に相殺する
+	state := NewPlayerGameState("test-user", mockDeck)
+	state.ReceiveGarbage(1)
+	setupDoubleClear(state)
+	ApplyPlayerInput(state, "hard_drop")
+
+	if state.LinesCleared != 2 {
+		t.Fatalf("Expected 2 lines cleared, but got %d", state.LinesCleared)
+	}
+	if state.PendingGarbage() != 0 || state.outgoingGarbage != 0 {
+		t.Errorf("Expected garbage to be fully offset, but pending=%d outgoing=%d", state.PendingGarbage(), state.outgoingGarbage)
+	}
+	for x := 0; x < tetris.BoardWidth; x++ {
+		if state.Board[tetris.BoardTotalHeight-1][x] == tetris.BlockGarbage {
+			t.Fatal("Expected no garbage to be raised after full offset")
+		}
+	}
+
+	// 予告がない場合は攻撃分がそのまま相手への送信分になる
+	state = NewPlayerGameState("test-user", mockDeck)
+	setupDoubleClear(state)
+	ApplyPlayerInput(state, "hard_drop")
+
+	if state.outgoingGarbage != 1 {
+		t.Errorf("Expected outgoing garbage to be 1, but got %d", state.outgoingGarbage)
+	}
+}
+
+// TestGameSession_DeliverGarbage は送信分のお邪魔ラインが相手の予告に届くことをテストします。
+func TestGameSession_DeliverGarbage(t *testing.T) {
+	session, err := NewGameSession("test-room-garbage", "player1", &models.Deck{ID: "deck-1"}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	session.SetPlayer2("player2", &models.Deck{ID: "deck-2"}, nil)
+
+	session.Player1.outgoingGarbage = 3
+	session.deliverGarbage()
+
+	if session.Player1.outgoingGarbage != 0 {
+		t.Errorf("Expected sender's outgoing garbage to be reset, but got %d", session.Player1.outgoingGarbage)
+	}
+	if session.Player2.PendingGarbage() != 3 {
+		t.Errorf("Expected opponent's pending garbage to be 3, but got %d", session.Player2.PendingGarbage())
+	}
+	if lightweight := session.ToLightweight(); lightweight.Player2.PendingGarbage != 3 {
+		t.Errorf("Expected lightweight pending garbage to be 3, but got %d", lightweight.Player2.PendingGarbage)
+	}
+}
+
+// TestCalculateLevel はレベル計算の境界値とMaxLevelでの頭打ちをテストします。
+func TestCalculateLevel(t *testing.T) {
+	maxLevelLines := (MaxLevel - 1) * LevelUpLines
+	tests := []struct {
+		lines int
+		want  int
+	}{
+		{0, 1},
+		{LevelUpLines - 1, 1},
+		{LevelUpLines, 2},
+		{maxLevelLines - 1, MaxLevel - 1},
+		{maxLevelLines, MaxLevel},
+		{maxLevelLines + LevelUpLines, MaxLevel},
+		{1000, MaxLevel},
+	}
+	for _, tt := range tests {
+		if got := calculateLevel(tt.lines); got != tt.want {
+			t.Errorf("calculateLevel(%d) = %d, want %d", tt.lines, got, tt.want)
+		}
+	}
+}
+
+// TestApplyPlayerInput_ScoreUpdateThrottled は横移動の連打でCurrentPieceScoresの更新が間引かれ、
+// 間引き間隔の経過後やソフトドロップでは更新されることをテストします。
+func TestApplyPlayerInput_ScoreUpdateThrottled(t *testing.T) {
+	state := NewPlayerGameState("test-user", &models.Deck{ID: "mock-deck-id"})
+	stale := map[string]int{"stale": 1}
+
+	// 直前に更新したばかりなので、横移動ではマップが作り直されない
+	state.CurrentPieceScores = stale
+	state.lastScoreUpdate = time.Now()
+	if !ApplyPlayerInput(state, "move_left") {
+		t.Fatal("Expected piece to move left, but it did not.")
+	}
+	if _, ok := state.CurrentPieceScores["stale"]; !ok {
+		t.Errorf("Expected score update to be throttled, but scores were refreshed: %v", state.CurrentPieceScores)
+	}
+
+	// 間引き間隔が経過していれば更新される
+	state.lastScoreUpdate = time.Now().Add(-scoreUpdateInterval)
+	if !ApplyPlayerInput(state, "move_right") {
+		t.Fatal("Expected piece to move right, but it did not.")
+	}
+	if _, ok := state.CurrentPieceScores["stale"]; ok {
+		t.Error("Expected scores to be refreshed after the throttle interval.")
+	}
+
+	// ソフトドロップ（落下）は間引かずに更新される
+	state.CurrentPieceScores = stale
+	state.lastScoreUpdate = time.Now()
+	if !ApplyPlayerInput(state, "soft_drop") {
+		t.Fatal("Expected piece to soft drop, but it did not.")
+	}
+	if _, ok := state.CurrentPieceScores["stale"]; ok {
+		t.Error("Expected scores to be refreshed on soft drop.")
+	}
+}
+
+// BenchmarkApplyPlayerInput_MoveThrottled は横移動連打時の処理コストを間引きあり/なしで比較します。
+func BenchmarkApplyPlayerInput_MoveThrottled(b *testing.B) {
+	for _, interval := range []time.Duration{0, DefaultScoreUpdateInterval} {
+		b.Run(interval.String(), func(b *testing.B) {
+			original := scoreUpdateInterval
+			scoreUpdateInterval = interval
+			defer func() { scoreUpdateInterval = original }()
+
+			state := NewPlayerGameState("bench-user", &models.Deck{ID: "mock-deck-id"})
+			actions := []string{"move_left", "move_right"}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ApplyPlayerInput(state, actions[i%2])
+			}
+		})
+	}
+}
+
+// TestApplyPlayerInput_HardDropAtomic はハードドロップと並行して他の入力が届いても、
+// セッションのゲーム状態ロック（gameMu）の下で適用していれば落下〜固定の途中に割り込まれず、
+// ピースがちょうど1つ分だけ固定されることをテストします。
+// データ競合の検出には go test -race を使用してください。
+func TestApplyPlayerInput_HardDropAtomic(t *testing.T) {
+	session, err := NewGameSession("atomic-room", "test-user", &models.Deck{ID: "mock-deck-id"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := session.Player1
+	apply := func(action string) {
+		session.gameMu.Lock()
+		defer session.gameMu.Unlock()
+		ApplyPlayerInput(state, action)
+	}
+
+	var wg sync.WaitGroup
+	actions := []string{"rotate_right", "rotate_left", "move_left", "move_right"}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(action string) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				apply(action)
+			}
+		}(actions[i])
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		apply("hard_drop")
+	}()
+	wg.Wait()
+
+	filled := 0
+	for y := range state.Board {
+		for x := range state.Board[y] {
+			if state.Board[y][x] != tetris.BlockEmpty {
+				filled++
+			}
+		}
+	}
+	if filled != 4 {
+		t.Errorf("Expected exactly one piece (4 blocks) to be locked, got %d blocks", filled)
+	}
+}
+
+// TestCalculateAPMAndPPS は操作数と設置ピース数から、プレイ時間あたりのAPM/PPSが計算されることをテストします。
+func TestCalculateAPMAndPPS(t *testing.T) {
+	state := NewPlayerGameState("test-user", &models.Deck{ID: "mock-deck-id"})
+	if apm := state.CalculateAPM(); apm != 0 {
+		t.Errorf("Expected APM 0 before start, got %d", apm)
+	}
+
+	start := time.Now().Add(-30 * time.Second)
+	state.markPlayStarted(start)
+	ApplyPlayerInput(state, "move_left")
+	ApplyPlayerInput(state, "hard_drop")
+	state.markPlayEnded(start.Add(30 * time.Second))
+
+	// 30秒で2操作・1ピース → APM 4, PPS 0.03
+	if apm := state.CalculateAPM(); apm != 4 {
+		t.Errorf("Expected APM 4, got %d", apm)
+	}
+	if pps := state.CalculatePPS(); pps != 0.03 {
+		t.Errorf("Expected PPS 0.03, got %v", pps)
+	}
+}
+
+// TestApplyPlayerInput_UnknownAction は許可リストに無いアクションが状態を変えずに無視されることをテストします。
+func TestApplyPlayerInput_UnknownAction(t *testing.T) {
+	state := NewPlayerGameState("test-user", &models.Deck{ID: "mock-deck-id"})
+	beforeX, beforeY, beforeRotation := state.CurrentPiece.X, state.CurrentPiece.Y, state.CurrentPiece.Rotation
+
+	for _, action := range []string{"teleport", "", "HARD_DROP"} {
+		if IsAllowedAction(action) {
+			t.Errorf("Expected %q not to be an allowed action", action)
+		}
+		if ApplyPlayerInput(state, action) {
+			t.Errorf("Expected unknown action %q to be ignored, but it was applied.", action)
+		}
+	}
+	if state.CurrentPiece.X != beforeX || state.CurrentPiece.Y != beforeY || state.CurrentPiece.Rotation != beforeRotation {
+		t.Errorf("Expected CurrentPiece to be unchanged, got (%d, %d, %d) (was (%d, %d, %d))",
+			state.CurrentPiece.X, state.CurrentPiece.Y, state.CurrentPiece.Rotation, beforeX, beforeY, beforeRotation)
+	}
+	if !IsAllowedAction(ActionHardDrop) {
+		t.Error("Expected hard_drop to be an allowed action")
+	}
+}
@@ -1,12 +1,11 @@
 package tetris
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
-	"math/rand"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database"
@@ -14,13 +13,6 @@ import (
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
 )
 
-// DeckPlacementPiece はデッキから読み込んだテトリミノ配置情報を表します。
-type DeckPlacementPiece struct {
-	Type     tetris.PieceType `json:"type"`
-	Rotation int              `json:"rotation"`
-	Blocks   []models.Position `json:"blocks"` // 各ブロックのスコア情報を含む
-}
-
 // PlayerGameState は単一プレイヤーのテトリスゲーム状態です。
 // これはゲームセッション内で個々のプレイヤーの進行を管理するために使われます。
 type PlayerGameState struct {
@@ -33,19 +25,36 @@ type PlayerGameState struct {
 	LinesCleared  int                `json:"lines_cleared"`  // クリアしたライン数
 	Level         int                `json:"level"`          // 現在のレベル
 	IsGameOver    bool               `json:"is_game_over"`   // ゲームオーバー状態かどうか
+	topOutKind    string             `json:"-"`              // トップアウトの種類（TopOut* 定数、トップアウトしていない場合は空）
 	Deck          *models.Deck       `json:"deck"`           // このゲームで使用するデッキデータ
 	pieceQueue    []tetris.PieceType `json:"-"`              // 次のピースを管理するためのキュー (7-bag systemなど) - JSONシリアライズから除外
-	randGenerator *rand.Rand         `json:"-"`              // ピース生成用の乱数ジェネレータ - JSONシリアライズから除外
+	randGenerator *seededRand        `json:"-"`              // ピース生成用の乱数ジェネレータ - JSONシリアライズから除外（MarshalSnapshot ではシードと消費回数を保存）
+	pieceRand     *seededRand        `json:"-"`              // 7-bagのシャッフル専用の乱数ジェネレータ（共通シードのルールのみ、nil なら randGenerator を使う）
 	lastFallTime  time.Time          `json:"-"`              // 最後の自動落下またはハードドロップの時間 - JSONシリアライズから除外
 	ContributionScores map[string]int `json:"contribution_scores"` // GitHub草のContributionスコアをボード上の位置に紐付けるマップ
 	// 例: "y_x": score, "0_0": 100, "0_1": 200
 	CurrentPieceScores map[string]int `json:"current_piece_scores"` // 現在のピースの各ブロックのスコア情報をボード座標で送信
 	// 例: "y_x": score, "5_3": 250 (現在のピースの該当ブロックのスコア)
-	DeckPlacements []DeckPlacementPiece `json:"-"` // デッキから読み込んだテトリミノ配置情報 - JSONシリアライズから除外
+	DeckPlacements []tetris.DeckPlacementPiece `json:"-"` // デッキから読み込んだテトリミノ配置情報 - JSONシリアライズから除外
+	contributionLevels []int `json:"-"` // 対戦相手に公開する草の日別レベル（0-4、参加時に取得、非公開設定の場合は nil）
 	ConsecutiveClears int            `json:"consecutive_clears"` // 連続ラインクリア数 (コンボボーナス用)
-	BackToBack        bool           `json:"back_to_back"`       // T-Spin, Perfect Clear 後のラインクリアでボーナス
+	BackToBack        bool           `json:"back_to_back"`       // 直前の消去がテトリス・ラインを消したT-Spinだったか（次の難しい消去でボーナス）
 	hasUsedHold       bool           `json:"-"`                  // 現在のピースでホールドが使用済みかどうか - JSONシリアライズから除外
+	holdDisabled      bool           `json:"-"`                  // ルールでホールドが禁止されているかどうか（GameRules.AllowHold の反映）
+	handicap          PlayerHandicap `json:"-"`                  // 適用中のハンディキャップ（GameRules.Handicap の反映、弱い側のみ）
+	lastMoveWasRotation bool         `json:"-"`                  // 現在のピースの最後の移動が回転だったか（T-Spin判定用）
+	lastEvents        []string       `json:"-"`                  // ピース固定時に発生し、まだ送信していない出来事（LockEvent* 定数）
+	lastClearedRows   [][]int        `json:"-"`                  // ライン消去ごとの消えた行（表示部分のY座標）で、まだ送信していないもの
+	pendingGarbage    int            `json:"-"`                  // 受信済みでまだせり上げていないお邪魔ライン数（予告）
+	outgoingGarbage   int            `json:"-"`                  // 相殺後に相手へ送るお邪魔ライン数（セッションが配送する）
+	inputRate         inputRateTracker `json:"-"`                // 操作頻度のサニティチェック用カウンター（gameMu の下で更新）
+	lastScoreUpdate   time.Time      `json:"-"`                  // 最後にCurrentPieceScoresを更新した時刻（間引き判定用）
 	mu                sync.RWMutex   `json:"-"`                  // CurrentPieceScoresの並行アクセス保護用
+	actionCount       int            `json:"-"`                  // プレイ開始後に状態が変わった操作の数（APM計算用）
+	piecesPlaced      int            `json:"-"`                  // プレイ開始後に固定したピースの数（PPS計算用）
+	playStartedAt     time.Time      `json:"-"`                  // APM/PPS計測の開始時刻（ゲーム開始時刻）
+	playEndedAt       time.Time      `json:"-"`                  // APM/PPS計測の終了時刻（ゲームオーバーまたはセッション終了時刻）
+	disconnected      bool           `json:"-"`                  // WebSocketの接続後に切断し、まだ再接続していないか（SessionManager.mu で保護、ユーザーごとのセッション数の集計用）
 }
 
 // NewPlayerGameState は新しいプレイヤーのゲーム状態を初期化して返します（ランダムスコア版）。
@@ -58,8 +67,7 @@ type PlayerGameState struct {
 func NewPlayerGameState(userID string, deck *models.Deck) *PlayerGameState {
 	// 乱数生成器のシードを現在時刻で初期化
 	seed := time.Now().UnixNano()
-	source := rand.NewSource(seed)
-	r := rand.New(source)
+	r := newSeededRand(seed)
 
 	state := &PlayerGameState{
 		UserID:        userID,
@@ -73,7 +81,7 @@ func NewPlayerGameState(userID string, deck *models.Deck) *PlayerGameState {
 		lastFallTime:  time.Now(),
 		ContributionScores: make(map[string]int),
 		CurrentPieceScores: make(map[string]int),
-		DeckPlacements: []DeckPlacementPiece{},
+		DeckPlacements: []tetris.DeckPlacementPiece{},
 	}
 
 	// 仮でボード全体にランダムなスコアを設定
@@ -101,8 +109,7 @@ func NewPlayerGameState(userID string, deck *models.Deck) *PlayerGameState {
 func NewPlayerGameStateWithDeckPlacements(userID string, deck *models.Deck, deckRepo database.DeckRepository) (*PlayerGameState, error) {
 	// 乱数生成器のシードを現在時刻で初期化
 	seed := time.Now().UnixNano()
-	source := rand.NewSource(seed)
-	r := rand.New(source)
+	r := newSeededRand(seed)
 
 	state := &PlayerGameState{
 		UserID:        userID,
@@ -116,36 +123,19 @@ func NewPlayerGameStateWithDeckPlacements(userID string, deck *models.Deck, deck
 		lastFallTime:  time.Now(),
 		ContributionScores: make(map[string]int),
 		CurrentPieceScores: make(map[string]int),
-		DeckPlacements: []DeckPlacementPiece{},
+		DeckPlacements: []tetris.DeckPlacementPiece{},
 	}
 
 	// デッキからテトリミノ配置データを取得
 	if deck != nil && deckRepo != nil {
-		placements, err := deckRepo.GetTetriminoPlacementsByDeckID(nil, deck.ID)
+		ctx, cancel := gameDBContext()
+		placements, err := deckRepo.GetTetriminoPlacementsByDeckID(ctx, nil, deck.ID)
+		cancel()
 		if err != nil {
 			return nil, fmt.Errorf("デッキ配置データの取得に失敗しました: %w", err)
 		}
 
-		// テトリミノ配置データをDeckPlacementPieceに変換
-		for _, placement := range placements {
-			pieceType, ok := tetris.StringToPieceType(placement.TetriminoType)
-			if !ok {
-				continue // 不明なテトリミノタイプをスキップ
-			}
-
-			// JSONからPositionスライスをデコード
-			var positions []models.Position
-			if err := json.Unmarshal(placement.Positions, &positions); err != nil {
-				continue // デコードに失敗した場合はスキップ
-			}
-
-			deckPiece := DeckPlacementPiece{
-				Type:     pieceType,
-				Rotation: placement.Rotation,
-				Blocks:   positions,
-			}
-			state.DeckPlacements = append(state.DeckPlacements, deckPiece)
-		}
+		state.DeckPlacements = tetris.DeckPlacementsFromRecords(placements)
 
 		// デッキデータから実際のスコアマップを構築
 		state.buildContributionScoresFromDeck()
@@ -176,17 +166,8 @@ func (s *PlayerGameState) buildContributionScoresFromDeck() {
 	}
 
 	// デッキ配置データからスコアを設定
-	for _, deckPiece := range s.DeckPlacements {
-		for _, block := range deckPiece.Blocks {
-			// デッキ配置のx,y座標をボード座標に変換
-			// TODO: ここでGitHub草座標からテトリスボード座標への変換ロジックが必要
-			// 現在は単純にx,yをそのまま使用（後で調整が必要）
-			if block.X >= 0 && block.X < tetris.BoardWidth && 
-			   block.Y >= 0 && block.Y < tetris.BoardHeight {
-				scoreKey := strconv.Itoa(block.Y) + "_" + strconv.Itoa(block.X)
-				s.ContributionScores[scoreKey] = block.Score
-			}
-		}
+	for key, score := range tetris.ScoresFromDeckPlacements(s.DeckPlacements, tetris.BoardWidth, tetris.BoardHeight) {
+		s.ContributionScores[key] = score
 	}
 }
 
@@ -205,8 +186,12 @@ func (s *PlayerGameState) generatePieceQueue() {
 		hasLastPiece = true
 	}
 	
-	// バッグをシャッフル
-	s.randGenerator.Shuffle(len(bag), func(i, j int) {
+	// バッグをシャッフル（共通シードのルールでは、デッキのスコア選択に影響されない専用の乱数を使う）
+	bagRand := s.randGenerator
+	if s.pieceRand != nil {
+		bagRand = s.pieceRand
+	}
+	bagRand.Shuffle(len(bag), func(i, j int) {
 		bag[i], bag[j] = bag[j], bag[i]
 	})
 	
@@ -214,7 +199,7 @@ func (s *PlayerGameState) generatePieceQueue() {
 	if hasLastPiece && len(bag) > 1 && bag[0] == lastPieceType {
 		// 最初のピースと2番目以降のどれかを交換
 		// ランダムな位置（1から最後まで）を選んで交換
-		swapIndex := s.randGenerator.Intn(len(bag)-1) + 1
+		swapIndex := bagRand.Intn(len(bag)-1) + 1
 		bag[0], bag[swapIndex] = bag[swapIndex], bag[0]
 		
 		log.Printf("[PieceQueue] 連続防止: 前のピース %d と重複していたため、位置 %d と交換しました", lastPieceType, swapIndex)
@@ -225,6 +210,17 @@ func (s *PlayerGameState) generatePieceQueue() {
 	// log.Printf("[PieceQueue] 新しいバッグを生成: %v (キュー長: %d)", bag, len(s.pieceQueue))
 }
 
+// reseedPieceQueue はピース順を seed の乱数で最初から作り直し、現在・次のピースを出し直します。
+// 同じ seed のプレイヤー同士は同じ順番でピースが出ます。プレイ開始前にのみ呼び出してください。
+func (s *PlayerGameState) reseedPieceQueue(seed int64) {
+	s.pieceRand = newSeededRand(seed)
+	s.pieceQueue = nil
+	s.CurrentPiece = nil
+	s.NextPiece = nil
+	s.generatePieceQueue()
+	s.SpawnNewPiece()
+}
+
 // GetNextPieceFromQueue はキューから次のピースを取得し、必要であれば新しいバッグを生成します。
 // 7-bagシステムを最優先し、デッキデータからはスコア情報のみを使用します。
 //
@@ -268,7 +264,7 @@ func (s *PlayerGameState) getPieceScoreFromDeck(pieceType tetris.PieceType) *tet
 	}
 
 	// 指定されたピースタイプのデッキデータを探す
-	var selectedDeckPiece *DeckPlacementPiece
+	var selectedDeckPiece *tetris.DeckPlacementPiece
 	for _, deckPiece := range s.DeckPlacements {
 		if deckPiece.Type == pieceType {
 			selectedDeckPiece = &deckPiece
@@ -412,48 +408,77 @@ func (s *PlayerGameState) SpawnNewPiece() {
 	}
 	s.NextPiece = s.GetNextPieceFromQueue()
 
-	// 初期位置設定（ボードの中央上部、すべてのブロックが表示範囲内）
-	// テトリミノの種類に応じた適切な初期位置を設定
-	x, y := spawnPieceAtCenter(s.CurrentPiece.Type)
-	s.CurrentPiece.X = x
-	s.CurrentPiece.Y = y
-	s.CurrentPiece.Rotation = 0 // 必ず回転をリセット
+	// 初期位置設定（ボードの中央上部の隠し行）
+	resetPieceToSpawn(s.CurrentPiece)
 
 	// ホールドフラグをリセット（新しいピースなのでホールド可能）
 	s.hasUsedHold = false
+	s.lastMoveWasRotation = false
 
 	// 現在のピースのスコア情報を更新
 	s.updateCurrentPieceScores()
 
-	// ゲームオーバー判定: 新しいピースがスポーン位置で既に衝突している場合
-	// これは通常、ボードの最上部にブロックが積み上がってしまった状態を指します。
+	// ブロックアウト判定: 新しいピースがスポーン位置で既に衝突している場合
+	// これは通常、隠し行までブロックが積み上がってしまった状態を指します（ロックアウトは固定時に handlePieceLock が判定）。
 	if s.Board.HasCollision(s.CurrentPiece, 0, 0) {
-		s.IsGameOver = true
+		s.topOut(TopOutBlockOut)
+	}
+}
+
+// ReceiveGarbage は相手から送られたお邪魔ラインを予告として溜めます。
+// 実際のせり上げは自分がピースを固定したタイミング（handlePieceLock）で行われます。
+func (s *PlayerGameState) ReceiveGarbage(lines int) {
+	if lines <= 0 || s.IsGameOver {
+		return
 	}
+	s.pendingGarbage += lines
+}
+
+// PendingGarbage はせり上げ待ちのお邪魔ライン数を返します。
+func (s *PlayerGameState) PendingGarbage() int {
+	return s.pendingGarbage
 }
 
 // GameSession は2人のプレイヤーのゲーム状態とセッション情報を含みます。
 // これはマルチプレイヤー対戦のためのトップレベルのゲーム状態です。
+//
+// セッションのライフサイクルは 作成 → "waiting" → "playing" → "finished" → 削除 の順に遷移します。
+// プレイ中は "playing" ⇔ "paused"（一時停止）を行き来でき、一時停止していた時間は制限時間に数えません。
+// 終了処理や削除が始まったセッションには isDeleting が立ち、以降の参加・開始は受け付けません。
 type GameSession struct {
 	ID        string `json:"id"`        // セッションID (UUID)
 	Player1   *PlayerGameState `json:"player1"` // プレイヤー1のゲーム状態
 	Player2   *PlayerGameState `json:"player2"` // プレイヤー2のゲーム状態
-	Status    string           `json:"status"`  // "waiting", "playing", "finished"
+	Status    string           `json:"status"`  // "waiting", "playing", "paused", "finished"
 	StartedAt time.Time        `json:"started_at"` // ゲーム開始日時
 	EndedAt   time.Time        `json:"ended_at"`   // ゲーム終了日時
 	TimeLimit time.Duration    `json:"time_limit"` // ゲームの制限時間
+	EndReason string           `json:"end_reason,omitempty"` // 終了理由（EndReason* 定数、終了時に設定）
+	WinnerID  string           `json:"winner_id,omitempty"`  // 勝者のユーザーID（引き分けの場合は空）
+	GameRules                  `json:"rules"`                // ルーム作成時に指定されたルール（AllowHold などをセッションから直接参照できるよう埋め込む）
 
 	// Internal communication channels for the session manager (JSONシリアライズから除外)
 	InputCh  chan PlayerInputEvent `json:"-"` // クライアントからのプレイヤー操作入力を受け取るチャネル
 	OutputCh chan GameStateEvent   `json:"-"` // ゲーム状態の更新をブロードキャストするためのチャネル
 	GameLoopDone chan struct{}     `json:"-"` // ゲームループの終了を通知するチャネル
+
+	gameMu       sync.Mutex `json:"-"` // 入力適用・自動落下・シリアライズを直列化するためのロック（セッションループとRunの競合防止）
+	stopLoopOnce sync.Once  `json:"-"` // GameLoopDone を一度だけ閉じるためのOnce
+	isDeleting   bool       `json:"-"` // 終了処理・削除中フラグ（SessionManager.mu で保護）
+	pieceSeed    int64      `json:"-"` // 共通シードのルールでピース順に使うシード（SetRules が設定）
+	resultsSaved bool       `json:"-"` // スコア・対戦履歴を保存済みか（SessionManager.mu で保護、終了処理が重なっても一度だけ保存する）
+	flagged      atomic.Bool `json:"-"` // 異常な操作列を検知したセッションのフラグ（checkInputRate が設定）
+	pause        pauseState `json:"-"` // 一時停止の回数・時間（RequestPause/RequestResume が更新）
+	timeline     sessionTimeline `json:"-"` // 状態遷移の記録（不具合調査用、setStatus・recordEvent が追記）
 }
 
 // PlayerInputEvent はクライアントからの操作入力を表す構造体です。
 // WebSocketを通じてサーバーに送信されます。
 type PlayerInputEvent struct {
-	UserID string `json:"user_id"` // 操作を行ったプレイヤーのID
-	Action string `json:"action"`  // "move_left", "move_right", "rotate", "hard_drop", "hold" など
+	UserID  string `json:"user_id"`        // 操作を行ったプレイヤーのID
+	Action  string `json:"action"`         // "move_left", "move_right", "rotate", "hard_drop", "hold" など
+	Type    string `json:"type,omitempty"` // 操作以外の制御メッセージの種類（"request_resync" など。操作入力では空）
+	Version int    `json:"v,omitempty"`    // メッセージのプロトコルバージョン（省略時は接続のバージョン）
 }
 
 // GameStateEvent はゲーム状態の更新を通知するイベントです。
@@ -474,23 +499,27 @@ type GameStateEvent struct {
 //   *GameSession: 初期化されたゲームセッションのポインタ
 //   error: エラーが発生した場合
 func NewGameSession(roomID, player1ID string, player1Deck *models.Deck, deckRepo database.DeckRepository) (*GameSession, error) {
-	// プレイヤー1のゲーム状態を作成（デッキデータを使用）
-	player1State, err := NewPlayerGameStateWithDeckPlacements(player1ID, player1Deck, deckRepo)
+	// プレイヤー1のゲーム状態を作成（デッキデータを使用、読み込み失敗時の代替は deckPlacementFallback に従う）
+	player1State, err := newPlayerStateFromDeck(player1ID, player1Deck, deckRepo)
 	if err != nil {
-		// エラーが発生した場合は従来の方法でフォールバック
-		log.Printf("Failed to create player1 state with deck placements: %v, falling back to random scores", err)
-		player1State = NewPlayerGameState(player1ID, player1Deck)
+		return nil, err
 	}
+	return newGameSessionWithPlayer(roomID, player1State), nil
+}
 
-	return &GameSession{
+// newGameSessionWithPlayer は読み込み済みのプレイヤー1のゲーム状態で、待機中の新しいゲームセッションを作成します。
+func newGameSessionWithPlayer(roomID string, player1State *PlayerGameState) *GameSession {
+	session := &GameSession{
 		ID:           roomID,
 		Player1:      player1State,
-		Status:       "waiting",
 		TimeLimit:    GameTimeLimit,
+		GameRules:    DefaultGameRules(),
 		InputCh:      make(chan PlayerInputEvent, 100),
 		OutputCh:     make(chan GameStateEvent, 100),
 		GameLoopDone: make(chan struct{}),
-	}, nil
+	}
+	session.setStatus("waiting", "room_created")
+	return session
 }
 
 // SetPlayer2 はセッションに2人目のプレイヤーを設定します。
@@ -499,32 +528,55 @@ func NewGameSession(roomID, player1ID string, player1Deck *models.Deck, deckRepo
 //   player2ID   : プレイヤー2のユーザーID
 //   player2Deck : プレイヤー2が使用するデッキデータ
 //   deckRepo    : デッキリポジトリ（テトリミノ配置データ取得用）
-func (gs *GameSession) SetPlayer2(player2ID string, player2Deck *models.Deck, deckRepo database.DeckRepository) {
-	// プレイヤー2のゲーム状態を作成（デッキデータを使用）
-	player2State, err := NewPlayerGameStateWithDeckPlacements(player2ID, player2Deck, deckRepo)
+// Returns:
+//   error: デッキを読み込めなかった場合（ErrDeckLoadFailed）。この場合プレイヤー2は設定されません
+func (gs *GameSession) SetPlayer2(player2ID string, player2Deck *models.Deck, deckRepo database.DeckRepository) error {
+	// プレイヤー2のゲーム状態を作成（デッキデータを使用、読み込み失敗時の代替は deckPlacementFallback に従う）
+	player2State, err := newPlayerStateFromDeck(player2ID, player2Deck, deckRepo)
 	if err != nil {
-		// エラーが発生した場合は従来の方法でフォールバック
-		log.Printf("Failed to create player2 state with deck placements: %v, falling back to random scores", err)
-		player2State = NewPlayerGameState(player2ID, player2Deck)
+		return err
 	}
+	gs.setPlayer2State(player2State)
+	return nil
+}
+
+// setPlayer2State は読み込み済みのゲーム状態をプレイヤー2として設定し、ルームのルールとハンディキャップを反映します。
+func (gs *GameSession) setPlayer2State(player2State *PlayerGameState) {
+	gs.applyRulesToPlayer(player2State)
 	gs.Player2 = player2State
+	gs.applyHandicap()
+}
+
+// StopGameLoop はセッション専用のゲームループに終了を通知します。
+// 終了・削除・シャットダウンの複数経路から呼ばれるため、何度呼んでも安全です。
+func (gs *GameSession) StopGameLoop() {
+	gs.stopLoopOnce.Do(func() {
+		close(gs.GameLoopDone)
+	})
 }
 
-// IsTimeUp はゲームの制限時間が経過したかどうかを判定します。
+// IsTimeUp はゲームの制限時間が経過したかどうかを判定します。一時停止していた時間は経過時間に含めません。
+// 一時停止の状態を参照するため、sm.mu または gameMu を保持した状態で呼び出してください。
 func (gs *GameSession) IsTimeUp() bool {
 	if gs.Status != "playing" {
 		return false
 	}
-	return time.Since(gs.StartedAt) >= gs.TimeLimit
+	return gs.elapsedPlayTimeLocked(time.Now()) >= gs.TimeLimit
 }
 
 // ToLightweight はGameSessionから軽量な構造体に変換します。
 func (gs *GameSession) ToLightweight() *LightweightGameState {
 	// 残り時間を計算
+	// クライアントが滑らかにカウントダウンできるよう、サーバー時刻と終了予定時刻もミリ秒で渡す
+	now := time.Now()
 	remainingTime := 0
-	if gs.Status == "playing" && !gs.StartedAt.IsZero() {
-		elapsed := time.Since(gs.StartedAt)
-		remaining := gs.TimeLimit - elapsed
+	var endsAtMs int64
+	// 一時停止していた時間の分だけ終了予定時刻を後ろにずらす。一時停止中は終了予定時刻が決まらないため送らない
+	if gs.isInProgress() && !gs.StartedAt.IsZero() {
+		remaining := gs.TimeLimit - gs.elapsedPlayTimeLocked(now)
+		if gs.Status == "playing" {
+			endsAtMs = gs.StartedAt.Add(gs.TimeLimit + gs.pause.pausedDuration).UnixMilli()
+		}
 		if remaining > 0 {
 			remainingTime = int(remaining.Seconds())
 		}
@@ -532,48 +584,147 @@ func (gs *GameSession) ToLightweight() *LightweightGameState {
 
 	lightweight := &LightweightGameState{
 		ID:            gs.ID,
+		Version:       ProtocolVersion,
 		Status:        gs.Status,
 		StartedAt:     gs.StartedAt,
 		EndedAt:       gs.EndedAt,
 		TimeLimit:     int(gs.TimeLimit.Seconds()),
 		RemainingTime: remainingTime,
+		ServerTimeMs:  now.UnixMilli(),
+		EndsAtMs:      endsAtMs,
+		EndReason:     gs.EndReason,
+		WinnerID:      gs.WinnerID,
+		Rules:         gs.GameRules,
 	}
 	
 	if gs.Player1 != nil {
 		lightweight.Player1 = &LightweightPlayerState{
 			UserID:             gs.Player1.UserID,
-			Board:              gs.Player1.Board,
-			CurrentPiece:       gs.Player1.CurrentPiece,
+			Board:              gs.Player1.Board.Visible(),
+			CurrentPiece:       toVisiblePiece(gs.Player1.CurrentPiece),
 			NextPiece:          gs.Player1.NextPiece,
 			HeldPiece:          gs.Player1.HeldPiece,
 			Score:              gs.Player1.Score,
 			LinesCleared:       gs.Player1.LinesCleared,
 			Level:              gs.Player1.Level,
+			IsMaxLevel:         gs.Player1.Level >= MaxLevel,
 			IsGameOver:         gs.Player1.IsGameOver,
+			TopOut:             gs.Player1.topOutKind,
+			Handicap:           gs.Player1.handicapView(),
+			PendingGarbage:     gs.Player1.pendingGarbage,
+			APM:                gs.Player1.CalculateAPM(),
+			PPS:                gs.Player1.CalculatePPS(),
 			ContributionScores: gs.Player1.ContributionScores,
 			CurrentPieceScores: gs.Player1.CurrentPieceScores,
+			ContributionLevels: gs.Player1.contributionLevels,
 		}
 	}
 	
 	if gs.Player2 != nil {
 		lightweight.Player2 = &LightweightPlayerState{
 			UserID:             gs.Player2.UserID,
-			Board:              gs.Player2.Board,
-			CurrentPiece:       gs.Player2.CurrentPiece,
+			Board:              gs.Player2.Board.Visible(),
+			CurrentPiece:       toVisiblePiece(gs.Player2.CurrentPiece),
 			NextPiece:          gs.Player2.NextPiece,
 			HeldPiece:          gs.Player2.HeldPiece,
 			Score:              gs.Player2.Score,
 			LinesCleared:       gs.Player2.LinesCleared,
 			Level:              gs.Player2.Level,
+			IsMaxLevel:         gs.Player2.Level >= MaxLevel,
 			IsGameOver:         gs.Player2.IsGameOver,
+			TopOut:             gs.Player2.topOutKind,
+			Handicap:           gs.Player2.handicapView(),
+			PendingGarbage:     gs.Player2.pendingGarbage,
+			APM:                gs.Player2.CalculateAPM(),
+			PPS:                gs.Player2.CalculatePPS(),
 			ContributionScores: gs.Player2.ContributionScores,
 			CurrentPieceScores: gs.Player2.CurrentPieceScores,
+			ContributionLevels: gs.Player2.contributionLevels,
+		}
+	}
+
+	// nextプレビューを表示しないルールでは NextPiece も送らない
+	if gs.NextPreviewCount == 0 {
+		for _, ps := range []*LightweightPlayerState{lightweight.Player1, lightweight.Player2} {
+			if ps != nil {
+				ps.NextPiece = nil
+			}
 		}
 	}
 	
 	return lightweight
 }
 
+// deliverGarbage は各プレイヤーが相殺後に送り出したお邪魔ラインを相手の予告に届けます。
+// 攻撃なし（AllowGarbage=false）のルールでは何も届けません。
+// ゲーム状態ロック（gameMu）を保持した状態で呼び出してください。
+func (gs *GameSession) deliverGarbage() {
+	if gs.Player1 == nil || gs.Player2 == nil {
+		return
+	}
+	if !gs.AllowGarbage {
+		// 攻撃なしのルームでは送信分を捨てる（相殺の対象になる予告も溜まらない）
+		gs.Player1.outgoingGarbage = 0
+		gs.Player2.outgoingGarbage = 0
+		return
+	}
+	if lines := gs.Player1.outgoingGarbage; lines > 0 {
+		gs.Player1.outgoingGarbage = 0
+		gs.Player2.ReceiveGarbage(lines)
+	}
+	if lines := gs.Player2.outgoingGarbage; lines > 0 {
+		gs.Player2.outgoingGarbage = 0
+		gs.Player1.ReceiveGarbage(lines)
+	}
+}
+
+// LightweightSnapshot はゲーム状態ロックの下で軽量状態のスナップショットを作成します。
+// マップやピースはコピーされるため、返り値はセッションループと並行して安全に読み出せます。
+func (gs *GameSession) LightweightSnapshot() *LightweightGameState {
+	gs.gameMu.Lock()
+	defer gs.gameMu.Unlock()
+
+	snapshot := gs.ToLightweight()
+	for _, ps := range []*LightweightPlayerState{snapshot.Player1, snapshot.Player2} {
+		if ps == nil {
+			continue
+		}
+		ps.NextPiece = clonePiece(ps.NextPiece)
+		ps.HeldPiece = clonePiece(ps.HeldPiece)
+		ps.ContributionScores = copyScores(ps.ContributionScores)
+		ps.CurrentPieceScores = copyScores(ps.CurrentPieceScores)
+	}
+	return snapshot
+}
+
+// clonePiece はnilを許容するピースのコピーです。
+func clonePiece(p *tetris.Piece) *tetris.Piece {
+	if p == nil {
+		return nil
+	}
+	return p.Clone()
+}
+
+// copyScores はスコアマップのシャローコピーを返します。
+func copyScores(scores map[string]int) map[string]int {
+	copied := make(map[string]int, len(scores))
+	for k, v := range scores {
+		copied[k] = v
+	}
+	return copied
+}
+
+// toVisiblePiece はピースのY座標を表示部分の座標系に変換したコピーを返します。
+// クライアントは隠し行を持たないため、送信時にのみ変換します。
+func toVisiblePiece(p *tetris.Piece) *tetris.Piece {
+	if p == nil {
+		return nil
+	}
+	visible := p.Clone()
+	visible.Y = tetris.ToVisibleY(p.Y)
+	return visible
+}
+
 // updateCurrentPieceScores は現在のピースのスコア情報をCurrentPieceScoresマップに更新します。
 // これによりクライアント側で落下中のピースも正しい色で表示されます。
 // テトリミノのScoreDataが存在する場合はそれを優先し、ない場合はContributionScoresを使用します。
@@ -585,6 +736,7 @@ func (s *PlayerGameState) updateCurrentPieceScores() {
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.lastScoreUpdate = time.Now()
 
 	// マップ全削除の代わりに、新しいマップを作成（高速化）
 	newScores := make(map[string]int, 4) // テトリミノは最大4ブロック
@@ -593,9 +745,9 @@ func (s *PlayerGameState) updateCurrentPieceScores() {
 	blocks := s.CurrentPiece.Blocks() // 一度だけ取得
 	for _, block := range blocks {
 		boardX := s.CurrentPiece.X + block[0]
-		boardY := s.CurrentPiece.Y + block[1]
+		boardY := tetris.ToVisibleY(s.CurrentPiece.Y + block[1]) // スコアマップは表示座標で管理
 
-		// ボードの有効な範囲内のみ処理
+		// ボードの表示範囲内のみ処理（隠し行にあるブロックは表示されないため除外）
 		if boardX >= 0 && boardX < tetris.BoardWidth && boardY >= 0 && boardY < tetris.BoardHeight {
 			scoreKey := strconv.Itoa(boardY) + "_" + strconv.Itoa(boardX)
 			
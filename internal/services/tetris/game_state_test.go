@@ -1,6 +1,7 @@
 package tetris
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -35,7 +36,7 @@ func TestNewPlayerGameState(t *testing.T) {
 	// ボードの初期化を確認
 	assert.NotNil(t, state.Board)
 	assert.Equal(t, tetris.BoardWidth, len(state.Board[0]))
-	assert.Equal(t, tetris.BoardHeight, len(state.Board))
+	assert.Equal(t, tetris.BoardTotalHeight, len(state.Board))
 
 	// ピースの初期化を確認
 	assert.NotNil(t, state.CurrentPiece)
@@ -178,16 +179,16 @@ func TestSpawnNewPiece(t *testing.T) {
 	switch initialPiece.Type {
 	case tetris.TypeI:
 		expectedX = tetris.BoardWidth/2 - 2 // 3
-		expectedY = 1
+		expectedY = 0
 	case tetris.TypeO:
 		expectedX = tetris.BoardWidth/2 - 1 // 4
-		expectedY = 1
+		expectedY = 0
 	case tetris.TypeL:
 		expectedX = tetris.BoardWidth/2 - 1 // 4
-		expectedY = 1
+		expectedY = 0
 	default:
 		expectedX = tetris.BoardWidth/2 - 1 // 4
-		expectedY = 1
+		expectedY = 0
 	}
 
 	assert.Equal(t, expectedX, initialPiece.X)
@@ -319,4 +320,92 @@ func TestToLightweightRemainingTime(t *testing.T) {
 	session.Status = "waiting"
 	lightweight = session.ToLightweight()
 	assert.Equal(t, 0, lightweight.RemainingTime, "待機中は残り時間が0のはず")
-} 
\ No newline at end of file
+}
+
+// TestToLightweightServerTime はサーバー時刻と終了予定時刻がミリ秒で含まれることをテストします。
+func TestToLightweightServerTime(t *testing.T) {
+	session, err := NewGameSession("test-room-server-time", "player1", &models.Deck{ID: "test-deck-server-time"}, nil)
+	assert.NoError(t, err)
+
+	// 待機中は終了予定時刻を含めない
+	before := time.Now().UnixMilli()
+	lightweight := session.ToLightweight()
+	assert.GreaterOrEqual(t, lightweight.ServerTimeMs, before)
+	assert.Zero(t, lightweight.EndsAtMs, "待機中は終了予定時刻がないはず")
+
+	session.Status = "playing"
+	session.StartedAt = time.Now().Add(-30 * time.Second)
+	lightweight = session.ToLightweight()
+
+	assert.Equal(t, session.StartedAt.Add(session.TimeLimit).UnixMilli(), lightweight.EndsAtMs)
+	remainingMs := lightweight.EndsAtMs - lightweight.ServerTimeMs
+	assert.InDelta(t, (session.TimeLimit - 30*time.Second).Milliseconds(), remainingMs, 100, "終了予定時刻とサーバー時刻の差が残り時間になるはず")
+}
+
+// TestGameSessionJSONExcludesInternalFields はGameSessionの内部チャネル等がJSONに漏れないことをテストします。
+func TestGameSessionJSONExcludesInternalFields(t *testing.T) {
+	session, err := NewGameSession("test-room-json", "player1", &models.Deck{ID: "test-deck-json"}, nil)
+	assert.NoError(t, err)
+
+	// チャネルがシリアライズ対象に含まれているとMarshalはエラーになる
+	data, err := json.Marshal(session)
+	assert.NoError(t, err)
+
+	var fields map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &fields))
+	for _, key := range []string{"InputCh", "OutputCh", "GameLoopDone", "gameMu", "stopLoopOnce"} {
+		assert.NotContains(t, fields, key)
+	}
+}
+
+// TestLightweightPlayerStatePublicView は相手向けの制限版状態で非公開情報が隠されることをテストします。
+func TestLightweightPlayerStatePublicView(t *testing.T) {
+	session, err := NewGameSession("test-room-public", "player1", &models.Deck{ID: "test-deck-public"}, nil)
+	assert.NoError(t, err)
+	session.Player1.Board[tetris.BoardTotalHeight-1][0] = tetris.BlockT
+	session.Player1.Score = 1234
+
+	public := session.LightweightSnapshot().Player1.PublicView()
+
+	assert.Equal(t, "player1", public.UserID)
+	assert.Equal(t, 1234, public.Score)
+	assert.Equal(t, tetris.BlockFilled, public.Board[tetris.BoardHeight-1][0], "ブロックの種類は埋まり状態に変換されるはず")
+	assert.Nil(t, public.CurrentPiece)
+	assert.Nil(t, public.NextPiece)
+	assert.Nil(t, public.HeldPiece)
+	assert.Nil(t, public.ContributionScores)
+	assert.Nil(t, public.CurrentPieceScores)
+}
+
+// TestLightweightGameStateViewFor は自分の状態は全情報のまま、相手の状態から
+// next・ホールドとスコアマップが省かれ、ボードの色は残ることをテストします。
+func TestLightweightGameStateViewFor(t *testing.T) {
+	session := newPlayingSession(t, "view-for")
+	session.Player2.Board[tetris.BoardTotalHeight-1][0] = tetris.BlockT
+	session.Player2.HeldPiece = &tetris.Piece{Type: tetris.TypeO}
+	state := session.LightweightSnapshot()
+
+	view := state.ViewFor("player1")
+
+	assert.Same(t, state.Player1, view.Player1, "自分の状態はそのまま送るはず")
+	assert.Equal(t, tetris.BlockT, view.Player2.Board[tetris.BoardHeight-1][0], "相手のボードの色は残るはず")
+	assert.NotNil(t, view.Player2.CurrentPiece)
+	assert.NotNil(t, state.Player2.NextPiece, "元の状態は変更されないはず")
+
+	data, err := json.Marshal(view)
+	assert.NoError(t, err)
+	var decoded struct {
+		Player1 map[string]json.RawMessage `json:"player1"`
+		Player2 map[string]json.RawMessage `json:"player2"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.NotContains(t, decoded.Player2, "held_piece")
+	for _, key := range []string{"next_piece", "contribution_scores", "current_piece_scores"} {
+		assert.Equal(t, "null", string(decoded.Player2[key]), key)
+	}
+	assert.NotEqual(t, "null", string(decoded.Player1["contribution_scores"]), "自分のスコアマップは送るはず")
+
+	full, err := json.Marshal(state)
+	assert.NoError(t, err)
+	assert.Less(t, len(data), len(full))
+}
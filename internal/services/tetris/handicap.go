@@ -0,0 +1,107 @@
+package tetris
+
+import "time"
+
+// デッキの total_score の差に応じたハンディキャップの設定です（GameRules.Handicap が有効なルームのみ）。
+// total_score が低い側（弱い側）のプレイヤーにだけ、初期スコアのボーナスと自動落下の緩和を与えます。
+//
+//   初期スコアボーナス = min(差 × HandicapScoreBonusPercent / 100, HandicapMaxScoreBonus)
+//   落下間隔の倍率(%)  = 100 + HandicapMaxFallSlowdownPercent × min(差, HandicapFullSlowdownGap) / HandicapFullSlowdownGap
+const (
+	HandicapScoreBonusPercent      = 10   // total_score の差のうち初期スコアに加算する割合（%）
+	HandicapMaxScoreBonus          = 3000 // 初期スコアボーナスの上限
+	HandicapMaxFallSlowdownPercent = 50   // 自動落下間隔を延ばす割合の上限（%、50なら最大1.5倍）
+	HandicapFullSlowdownGap        = 2000 // 落下の緩和が上限に達する total_score の差
+)
+
+// PlayerHandicap はプレイヤーに適用したハンディキャップの量です。
+type PlayerHandicap struct {
+	ScoreBonus          int `json:"score_bonus"`           // 初期スコアに加算したボーナス
+	FallIntervalPercent int `json:"fall_interval_percent"` // 自動落下間隔の倍率（%、100で調整なし）
+}
+
+// calculateHandicap は2つのデッキの total_score から、弱い側に与えるハンディキャップを計算します。
+//
+// Parameters:
+//   weakerTotal   : 弱い側（ハンディを受ける側）のデッキの total_score
+//   strongerTotal : 強い側のデッキの total_score
+// Returns:
+//   PlayerHandicap: 弱い側に与えるハンディキャップ（差が無い・逆転している場合は調整なし）
+func calculateHandicap(weakerTotal, strongerTotal int) PlayerHandicap {
+	gap := strongerTotal - weakerTotal
+	if gap <= 0 {
+		return PlayerHandicap{FallIntervalPercent: 100}
+	}
+
+	bonus := gap * HandicapScoreBonusPercent / 100
+	if bonus > HandicapMaxScoreBonus {
+		bonus = HandicapMaxScoreBonus
+	}
+	slowdownGap := gap
+	if slowdownGap > HandicapFullSlowdownGap {
+		slowdownGap = HandicapFullSlowdownGap
+	}
+	return PlayerHandicap{
+		ScoreBonus:          bonus,
+		FallIntervalPercent: 100 + HandicapMaxFallSlowdownPercent*slowdownGap/HandicapFullSlowdownGap,
+	}
+}
+
+// deckTotalScore はプレイヤーのデッキの total_score を返します（デッキが無い場合は0）。
+func deckTotalScore(state *PlayerGameState) int {
+	if state.Deck == nil {
+		return 0
+	}
+	return state.Deck.TotalScore
+}
+
+// applyHandicap は両プレイヤーが揃っている場合に、ルールに従ってハンディキャップを適用し直します。
+// 以前に適用したボーナスは取り消してから計算し直すため、ルールの設定とプレイヤー2の参加のどちらの順でも同じ結果になります。
+// 初期スコアを書き換えるため、プレイ開始前のみ呼び出してください。
+func (gs *GameSession) applyHandicap() {
+	if gs.Player1 == nil || gs.Player2 == nil {
+		return
+	}
+	for _, player := range []*PlayerGameState{gs.Player1, gs.Player2} {
+		player.Score -= player.handicap.ScoreBonus
+		player.handicap = PlayerHandicap{}
+	}
+	if !gs.Handicap {
+		return
+	}
+
+	weaker, stronger := gs.Player1, gs.Player2
+	if deckTotalScore(weaker) > deckTotalScore(stronger) {
+		weaker, stronger = stronger, weaker
+	}
+	handicap := calculateHandicap(deckTotalScore(weaker), deckTotalScore(stronger))
+	if handicap.ScoreBonus == 0 && handicap.FallIntervalPercent == 100 {
+		return
+	}
+	weaker.handicap = handicap
+	weaker.Score += handicap.ScoreBonus
+}
+
+// earnedScore はハンディキャップで加算した初期スコアを除いた、プレイで得たスコアを返します。
+// ランキングへの保存と異常検知には、ハンディキャップの有無で有利・不利が出ないようこのスコアを使います。
+func (state *PlayerGameState) earnedScore() int {
+	return max(state.Score-state.handicap.ScoreBonus, 0)
+}
+
+// fallInterval はレベルとハンディキャップの落下緩和を反映した自動落下間隔を返します。
+func (state *PlayerGameState) fallInterval() time.Duration {
+	interval := GetFallInterval(state.Level)
+	if state.handicap.FallIntervalPercent > 100 {
+		interval = interval * time.Duration(state.handicap.FallIntervalPercent) / 100
+	}
+	return interval
+}
+
+// handicapView は軽量状態に載せるハンディキャップを返します（適用していない場合は nil）。
+func (state *PlayerGameState) handicapView() *PlayerHandicap {
+	if state.handicap.ScoreBonus == 0 && state.handicap.FallIntervalPercent <= 100 {
+		return nil
+	}
+	handicap := state.handicap
+	return &handicap
+}
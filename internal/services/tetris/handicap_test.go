@@ -0,0 +1,101 @@
+package tetris
+
+import (
+	"testing"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database/databasetest"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCalculateHandicap は total_score の差に応じた初期スコアボーナスと落下間隔の倍率、各上限をテストします。
+func TestCalculateHandicap(t *testing.T) {
+	tests := []struct {
+		name          string
+		weaker        int
+		stronger      int
+		wantBonus     int
+		wantIntervalP int
+	}{
+		{"差なし", 1000, 1000, 0, 100},
+		{"逆転している", 1500, 1000, 0, 100},
+		{"差1000", 1000, 2000, 100, 125},
+		{"落下の緩和が上限", 0, 5000, 500, 150},
+		{"ボーナスが上限", 0, 100000, HandicapMaxScoreBonus, 150},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculateHandicap(tt.weaker, tt.stronger)
+			assert.Equal(t, PlayerHandicap{ScoreBonus: tt.wantBonus, FallIntervalPercent: tt.wantIntervalP}, got)
+		})
+	}
+}
+
+// TestApplyHandicap はハンディ有効のルームで弱い側だけに初期スコアと落下の緩和が適用され、
+// 軽量状態で通知されることをテストします。ルール設定とプレイヤー2の参加の順序に依らないことも確認します。
+func TestApplyHandicap(t *testing.T) {
+	rules := DefaultGameRules()
+	rules.Handicap = true
+
+	t.Run("ルール設定後にプレイヤー2が参加", func(t *testing.T) {
+		session, err := NewGameSession("handicap-1", "player1", &models.Deck{ID: "deck-1", TotalScore: 3000}, nil)
+		assert.NoError(t, err)
+		session.SetRules(rules)
+		assert.NoError(t, session.SetPlayer2("player2", &models.Deck{ID: "deck-2", TotalScore: 2000}, nil))
+
+		assert.Equal(t, 0, session.Player1.Score, "強い側には適用しないはず")
+		assert.Equal(t, 100, session.Player2.Score)
+		assert.Equal(t, GetFallInterval(1), session.Player1.fallInterval())
+		assert.Equal(t, GetFallInterval(1)*125/100, session.Player2.fallInterval())
+
+		state := session.ToLightweight()
+		assert.True(t, state.Rules.Handicap)
+		assert.Nil(t, state.Player1.Handicap)
+		assert.Equal(t, &PlayerHandicap{ScoreBonus: 100, FallIntervalPercent: 125}, state.Player2.Handicap)
+		assert.Equal(t, state.Player2.Handicap, state.ViewFor("player1").Player2.Handicap, "相手にも適用量を公開するはず")
+	})
+
+	t.Run("両プレイヤーが揃った後にルール設定", func(t *testing.T) {
+		session, err := NewGameSession("handicap-2", "player1", &models.Deck{ID: "deck-1", TotalScore: 1000}, nil)
+		assert.NoError(t, err)
+		assert.NoError(t, session.SetPlayer2("player2", &models.Deck{ID: "deck-2", TotalScore: 2000}, nil))
+		session.SetRules(rules)
+		session.SetRules(rules)
+
+		assert.Equal(t, 100, session.Player1.Score, "再適用してもボーナスが二重に加算されないはず")
+		assert.Equal(t, 0, session.Player2.Score)
+	})
+}
+
+// TestApplyHandicap_Disabled はハンディ無効（デフォルト）のルームでは従来どおり調整しないことをテストします。
+func TestApplyHandicap_Disabled(t *testing.T) {
+	session, err := NewGameSession("no-handicap", "player1", &models.Deck{ID: "deck-1", TotalScore: 5000}, nil)
+	assert.NoError(t, err)
+	session.SetRules(DefaultGameRules())
+	assert.NoError(t, session.SetPlayer2("player2", &models.Deck{ID: "deck-2", TotalScore: 0}, nil))
+
+	for _, player := range []*PlayerGameState{session.Player1, session.Player2} {
+		assert.Equal(t, 0, player.Score)
+		assert.Equal(t, GetFallInterval(player.Level), player.fallInterval())
+	}
+	state := session.ToLightweight()
+	assert.Nil(t, state.Player1.Handicap)
+	assert.Nil(t, state.Player2.Handicap)
+}
+
+// TestSaveGameResults_ExcludesHandicapBonus はランキングにハンディキャップの初期スコアボーナスを除いたスコアを保存することをテストします。
+func TestSaveGameResults_ExcludesHandicapBonus(t *testing.T) {
+	sm := newTestSessionManager()
+	session := newPlayingSession(t, "handicap-result")
+	sm.sessions["handicap-result"] = session
+	session.Player1.Score = 700
+	session.Player2.handicap = PlayerHandicap{ScoreBonus: 300, FallIntervalPercent: 125}
+	session.Player2.Score = 300 + 500
+
+	sm.EndGameSession("handicap-result")
+
+	saved := sm.resultRepo.(*databasetest.ResultRepository).Saved
+	assert.Equal(t, 700, saved["player1"])
+	assert.Equal(t, 500, saved["player2"], "ボーナスはプレイで得たスコアではないため保存しないはず")
+	assert.Equal(t, 800, session.Player2.Score, "対戦中・終了時の表示スコアはボーナスを含めたまま")
+}
@@ -0,0 +1,171 @@
+package tetris
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"time"
+)
+
+// アイドル接続の自動クローズのイベントの種類です。
+// どのルームでもプレイしていない接続が、最後の有効なゲーム操作から一定時間経過すると、警告のあとに切断します。
+// Pong や再同期・一時停止の要求は操作として数えません。
+const (
+	EventIdleWarning = "idle_warning" // まもなく切断することの警告（操作すれば切断されない）
+	EventIdleTimeout = "idle_timeout" // アイドルのため切断すること（この後にクローズフレームが届く）
+)
+
+// アイドル接続の自動クローズの設定です。
+const (
+	DefaultWSIdleTimeout = 10 * time.Minute // 切断するまでのアイドル時間のデフォルト値
+	IdleWarningLead      = 30 * time.Second // 切断の何秒前に警告するか（タイムアウトが短い場合はその半分）
+	IdleCheckInterval    = 5 * time.Second  // アイドル接続を確認する間隔
+)
+
+// wsIdleTimeout は実際に使用するアイドル時間のしきい値です。
+// 環境変数 WS_IDLE_TIMEOUT（"10m" などの時間表記、0で切断しない）で上書きできます。
+var wsIdleTimeout = loadWSIdleTimeout()
+
+// loadWSIdleTimeout は環境変数 WS_IDLE_TIMEOUT を読み込みます。不正な値の場合はデフォルト値を使います。
+func loadWSIdleTimeout() time.Duration {
+	env := os.Getenv("WS_IDLE_TIMEOUT")
+	if env == "" {
+		return DefaultWSIdleTimeout
+	}
+	timeout, err := time.ParseDuration(env)
+	if err != nil || timeout < 0 {
+		log.Printf("[WARN] Invalid WS_IDLE_TIMEOUT %q, using default %v", env, DefaultWSIdleTimeout)
+		return DefaultWSIdleTimeout
+	}
+	return timeout
+}
+
+// IdleEvent はアイドル接続の警告・切断を通知するイベントメッセージです。
+// 例: {"type":"idle_warning","v":1,"message":"...","disconnect_in_ms":30000}
+type IdleEvent struct {
+	Type           string `json:"type"`
+	Version        int    `json:"v"` // プロトコルバージョン（ProtocolVersion）
+	Message        string `json:"message"`
+	DisconnectInMs int64  `json:"disconnect_in_ms,omitempty"` // 切断までの残り時間（ミリ秒、idle_warning のみ）
+}
+
+// idleWarningLead はタイムアウト timeout に対して切断の何秒前に警告するかを返します。
+func idleWarningLead(timeout time.Duration) time.Duration {
+	return min(IdleWarningLead, timeout/2)
+}
+
+// touch はクライアントの最後の有効なゲーム操作の時刻を更新し、アイドルの警告を取り消します。
+func (c *Client) touch(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastActivity = now
+	c.idleWarned = false
+}
+
+// idleAction はアイドル接続の確認で、クライアントに対して行う処理です。
+type idleAction int
+
+const (
+	idleNone       idleAction = iota // 何もしない
+	idleWarn                         // 警告を送る
+	idleDisconnect                   // 切断する
+)
+
+// checkIdle は now 時点のアイドル時間から、クライアントに対して行う処理を決めます。
+// 警告は1回だけ送るため、警告を返した時点で印を付けます（touch で取り消されます）。切断も一度だけ返します。
+func (c *Client) checkIdle(now time.Time, timeout time.Duration) (idleAction, time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || c.idleDisconnecting || c.lastActivity.IsZero() {
+		return idleNone, 0
+	}
+	idle := now.Sub(c.lastActivity)
+	if idle >= timeout {
+		c.idleDisconnecting = true
+		return idleDisconnect, 0
+	}
+	if remaining := timeout - idle; remaining <= idleWarningLead(timeout) && !c.idleWarned {
+		c.idleWarned = true
+		return idleWarn, remaining
+	}
+	return idleNone, 0
+}
+
+// runIdleClientLoop はアイドル接続を定期的に確認するバックグラウンドループです。Shutdown で quit が閉じられると終了します。
+func (sm *SessionManager) runIdleClientLoop() {
+	if wsIdleTimeout <= 0 {
+		return
+	}
+	ticker := time.NewTicker(IdleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			sm.checkIdleClients(now, wsIdleTimeout)
+		case <-sm.quit:
+			return
+		}
+	}
+}
+
+// checkIdleClients はアイドル時間が timeout に近づいた接続に警告を送り、超えた接続を切断します。
+// プレイ中（一時停止中を含む）のセッションに参加している接続は、操作が無くても対象外です。
+//
+// Parameters:
+//   now     : 判定の基準時刻
+//   timeout : 切断するまでのアイドル時間
+func (sm *SessionManager) checkIdleClients(now time.Time, timeout time.Duration) {
+	type idleClient struct {
+		client    *Client
+		action    idleAction
+		remaining time.Duration
+	}
+	var targets []idleClient
+
+	sm.mu.RLock()
+	for _, client := range sm.clients {
+		if session, ok := sm.sessions[client.RoomID]; ok && session.isInProgress() {
+			continue
+		}
+		if action, remaining := client.checkIdle(now, timeout); action != idleNone {
+			targets = append(targets, idleClient{client: client, action: action, remaining: remaining})
+		}
+	}
+	sm.mu.RUnlock()
+
+	for _, target := range targets {
+		switch target.action {
+		case idleWarn:
+			log.Printf("[SessionManager] Client %s (passcode %s) is idle, disconnecting in %v", target.client.UserID, target.client.RoomID, target.remaining.Round(time.Second))
+			event, err := json.Marshal(IdleEvent{
+				Type:           EventIdleWarning,
+				Version:        ProtocolVersion,
+				Message:        "操作が無いため、まもなく切断します",
+				DisconnectInMs: target.remaining.Milliseconds(),
+			})
+			if err == nil {
+				target.client.SafeSend(event)
+			}
+		case idleDisconnect:
+			sm.disconnectIdleClient(target.client, timeout)
+		}
+	}
+}
+
+// disconnectIdleClient は切断通知を送ってから、クライアントを登録解除します（Sendチャネルが閉じられ、writePump がクローズフレームを送ります）。
+func (sm *SessionManager) disconnectIdleClient(client *Client, timeout time.Duration) {
+	log.Printf("[SessionManager] Client %s (passcode %s) has been idle for %v, disconnecting", client.UserID, client.RoomID, timeout)
+
+	event, err := json.Marshal(IdleEvent{Type: EventIdleTimeout, Version: ProtocolVersion, Message: "操作が無いため切断しました"})
+	if err == nil {
+		client.SafeSend(event)
+	}
+
+	go func() {
+		select {
+		case sm.unregister <- client:
+		case <-sm.quit:
+		}
+	}()
+}
@@ -0,0 +1,120 @@
+package tetris
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// receiveIdleEvent はクライアントのSendチャネルからアイドルのイベントを1つ取り出します（無ければ空）。
+func receiveIdleEvent(t *testing.T, client *Client) IdleEvent {
+	t.Helper()
+	select {
+	case message := <-client.Send:
+		var event IdleEvent
+		assert.NoError(t, json.Unmarshal(message, &event))
+		return event
+	default:
+		return IdleEvent{}
+	}
+}
+
+// TestCheckIdleClients はプレイ中でない接続に、しきい値の手前で警告を1回だけ送り、しきい値を超えたら切断することをテストします。
+func TestCheckIdleClients(t *testing.T) {
+	sm := newTestSessionManager()
+	start := time.Now()
+	timeout := time.Minute
+	client := &Client{UserID: "player1", RoomID: "lobby", Send: make(chan []byte, 4), lastActivity: start}
+	sm.clients["player1"] = client
+
+	sm.checkIdleClients(start.Add(10*time.Second), timeout)
+	assert.Equal(t, IdleEvent{}, receiveIdleEvent(t, client), "しきい値から遠いうちは何も送らないはず")
+
+	sm.checkIdleClients(start.Add(40*time.Second), timeout)
+	warning := receiveIdleEvent(t, client)
+	assert.Equal(t, EventIdleWarning, warning.Type)
+	assert.Equal(t, int64(20000), warning.DisconnectInMs)
+
+	sm.checkIdleClients(start.Add(45*time.Second), timeout)
+	assert.Equal(t, IdleEvent{}, receiveIdleEvent(t, client), "警告は1回だけのはず")
+
+	sm.checkIdleClients(start.Add(time.Minute), timeout)
+	assert.Equal(t, EventIdleTimeout, receiveIdleEvent(t, client).Type)
+	select {
+	case unregistered := <-sm.unregister:
+		assert.Same(t, client, unregistered)
+	case <-time.After(time.Second):
+		t.Fatal("アイドルの接続は登録解除されるはず")
+	}
+}
+
+// TestCheckIdleClients_ActivityResets は有効なゲーム操作で最後の操作の時刻と警告がリセットされることをテストします。
+func TestCheckIdleClients_ActivityResets(t *testing.T) {
+	sm := newTestSessionManager()
+	start := time.Now()
+	timeout := time.Minute
+	client := &Client{UserID: "player1", RoomID: "lobby", Send: make(chan []byte, 4), lastActivity: start}
+	sm.clients["player1"] = client
+
+	sm.checkIdleClients(start.Add(40*time.Second), timeout)
+	assert.Equal(t, EventIdleWarning, receiveIdleEvent(t, client).Type)
+
+	client.touch(start.Add(50 * time.Second))
+	sm.checkIdleClients(start.Add(time.Minute), timeout)
+	assert.Equal(t, IdleEvent{}, receiveIdleEvent(t, client), "操作があれば切断しないはず")
+
+	sm.checkIdleClients(start.Add(95*time.Second), timeout)
+	assert.Equal(t, EventIdleWarning, receiveIdleEvent(t, client).Type, "操作後は再び警告するはず")
+}
+
+// TestCheckIdleClients_SkipsPlaying はプレイ中のセッションに参加している接続は操作が無くても切断しないことをテストします。
+func TestCheckIdleClients_SkipsPlaying(t *testing.T) {
+	sm := newTestSessionManager()
+	sm.sessions["idle-room"] = newPlayingSession(t, "idle-room")
+	start := time.Now()
+	client := &Client{UserID: "player1", RoomID: "idle-room", Send: make(chan []byte, 4), lastActivity: start}
+	sm.clients["player1"] = client
+
+	sm.checkIdleClients(start.Add(time.Hour), time.Minute)
+	assert.Equal(t, IdleEvent{}, receiveIdleEvent(t, client))
+	assert.Empty(t, sm.unregister)
+}
+
+// TestIdleTimeout_PongIsNotActivity はPongを返し続けるだけの接続は操作として数えず、しきい値を超えたら切断することをテストします。
+func TestIdleTimeout_PongIsNotActivity(t *testing.T) {
+	sm := newTestSessionManager()
+	accepted := make(chan *websocket.Conn, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err == nil {
+			accepted <- conn
+		}
+	}))
+	defer server.Close()
+	peer, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer peer.Close()
+
+	start := time.Now().Add(-time.Hour)
+	client := &Client{UserID: "player1", RoomID: "lobby", Conn: <-accepted, Send: make(chan []byte, 4), lastActivity: start}
+	sm.clients["player1"] = client
+	go sm.readPump(client)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, peer.WriteControl(websocket.PongMessage, nil, time.Now().Add(time.Second)))
+	}
+	// 不正なメッセージの処理を待つことで、先に送ったPongが読み込まれたことを確認する
+	assert.NoError(t, peer.WriteMessage(websocket.TextMessage, []byte("not json")))
+	assert.Eventually(t, func() bool { return sm.malformedMessages.Load() == 1 }, time.Second, 10*time.Millisecond)
+
+	sm.checkIdleClients(time.Now(), time.Minute)
+	assert.Equal(t, EventIdleTimeout, receiveIdleEvent(t, client).Type, "Pongだけでは切断を延長しないはず")
+}
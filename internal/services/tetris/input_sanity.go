@@ -0,0 +1,115 @@
+package tetris
+
+import (
+	"log"
+	"time"
+)
+
+// プレイヤーが送信できる操作アクションです。"left" などの短縮形は旧クライアントとの互換のために残しています。
+const (
+	ActionLeft        = "left"
+	ActionMoveLeft    = "move_left"
+	ActionRight       = "right"
+	ActionMoveRight   = "move_right"
+	ActionDown        = "down"
+	ActionSoftDrop    = "soft_drop"
+	ActionHardDrop    = "hard_drop"
+	ActionRotate      = "rotate"
+	ActionRotateRight = "rotate_right"
+	ActionRotateLeft  = "rotate_left"
+	ActionHold        = "hold"
+)
+
+// InvalidMessageWarningThreshold は同一クライアントからの不正なメッセージ（未知のアクション・パース失敗）の警告しきい値です。
+// 累計がこの値の倍数に達するたびに警告ログを出し、クライアントのバグや不正な操作を検知できるようにします。
+const InvalidMessageWarningThreshold = 20
+
+// allowedActions は ApplyPlayerInput が受け付けるアクションの許可リストです。
+var allowedActions = map[string]struct{}{
+	ActionLeft: {}, ActionMoveLeft: {}, ActionRight: {}, ActionMoveRight: {},
+	ActionDown: {}, ActionSoftDrop: {}, ActionHardDrop: {},
+	ActionRotate: {}, ActionRotateRight: {}, ActionRotateLeft: {},
+	ActionHold: {},
+}
+
+// IsAllowedAction はアクションが許可リストに含まれるかどうかを返します。
+func IsAllowedAction(action string) bool {
+	_, ok := allowedActions[action]
+	return ok
+}
+
+const (
+	// MaxInputsPerTick は1tick（SessionTickInterval）内に同一プレイヤーから受け付ける操作数の目安です。
+	// キーリピートを含めても人間の操作ではまず超えない値で、超えた場合は異常な操作列とみなします。
+	MaxInputsPerTick = 40
+	// SuspiciousInputThreshold は異常な操作列を何tick検知したらセッションをフラグ付けするかのしきい値です。
+	SuspiciousInputThreshold = 3
+)
+
+// inputRateTracker はプレイヤーごとの操作頻度を tick 単位で数えるカウンターです。
+// ゲーム状態と同じく session.gameMu の下で更新します。
+type inputRateTracker struct {
+	windowStart time.Time // 現在のtickの開始時刻
+	count       int       // 現在のtick内の操作数
+	anomalies   int       // 操作数が上限を超えたtickの数
+}
+
+// checkInputRate は操作を1件記録し、1tick内の操作数が異常に多くないかを検査します。
+// 上限を超えたtickはログに記録してカウントし、カウントがしきい値に達したらセッションをフラグ付けします。
+// 現時点では検知のみを行い、入力そのものは破棄しません。
+// session.gameMu を保持した状態で呼び出してください。
+//
+// Parameters:
+//   session : 入力先のゲームセッション
+//   player  : 入力したプレイヤーのゲーム状態
+//   now     : 入力を受け付けた時刻
+func checkInputRate(session *GameSession, player *PlayerGameState, now time.Time) {
+	tracker := &player.inputRate
+	if now.Sub(tracker.windowStart) >= SessionTickInterval {
+		tracker.windowStart = now
+		tracker.count = 0
+	}
+	tracker.count++
+
+	// 上限をちょうど超えた時点で1回だけ数える（同じtick内で何度も数えない）
+	if tracker.count != MaxInputsPerTick+1 {
+		return
+	}
+	tracker.anomalies++
+	log.Printf("[SessionManager] Abnormal input rate from user %s in passcode %s: more than %d inputs within %v (anomalies: %d)",
+		player.UserID, session.ID, MaxInputsPerTick, SessionTickInterval, tracker.anomalies)
+
+	if tracker.anomalies >= SuspiciousInputThreshold && !session.flagged.Swap(true) {
+		log.Printf("[SessionManager] WARNING: Session %s flagged for suspicious input from user %s", session.ID, player.UserID)
+	}
+}
+
+// IsFlagged はセッションが異常な操作列の検知によりフラグ付けされているかを返します。
+func (gs *GameSession) IsFlagged() bool {
+	return gs.flagged.Load()
+}
+
+// recordUnknownAction は許可リストに無いアクションを受け取ったことを記録します。
+// 個々の入力はデバッグログに残し、同一クライアントからの累計がしきい値に達するたびに警告します。
+func (sm *SessionManager) recordUnknownAction(client *Client, action string) {
+	sm.unknownActions.Add(1)
+	log.Printf("[DEBUG] Unknown action %q from user %s in passcode %s", action, client.UserID, client.RoomID)
+	sm.recordInvalidMessage(client)
+}
+
+// recordMalformedMessage はJSONとしてパースできなかったメッセージを記録します。
+func (sm *SessionManager) recordMalformedMessage(client *Client, err error) {
+	sm.malformedMessages.Add(1)
+	log.Printf("[DEBUG] Failed to unmarshal input message from %s: %v", client.UserID, err)
+	sm.recordInvalidMessage(client)
+}
+
+// recordInvalidMessage はクライアントごとの不正なメッセージ数を数え、しきい値の倍数に達したら警告します。
+// readPump のゴルーチンからのみ呼び出されるため、カウンターはロックなしで更新します。
+func (sm *SessionManager) recordInvalidMessage(client *Client) {
+	client.invalidMessages++
+	if client.invalidMessages%InvalidMessageWarningThreshold == 0 {
+		log.Printf("[SessionManager] WARNING: %d invalid messages from user %s in passcode %s (possible client bug or tampering)",
+			client.invalidMessages, client.UserID, client.RoomID)
+	}
+}
@@ -0,0 +1,199 @@
+package tetris
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
+)
+
+// ピース固定時に発生した出来事の種類です。クライアントは効果音やエフェクトの再生に使います。
+// 1回の固定で複数発生しうるため（例: tetris + b2b + level_up）、常に配列で送信します。
+const (
+	LockEventSingle       = "single"        // 1ライン消去
+	LockEventDouble       = "double"        // 2ライン消去
+	LockEventTriple       = "triple"        // 3ライン消去
+	LockEventTetris       = "tetris"        // 4ライン消去
+	LockEventTSpin        = "tspin"         // T-Spin（ライン消去の有無を問わない）
+	LockEventPerfectClear = "perfect_clear" // ライン消去後にボードが空になった
+	LockEventCombo        = "combo"         // 2回以上連続でラインを消去した
+	LockEventBackToBack   = "b2b"           // 難しい消去（テトリス・ラインを消したT-Spin）を連続で決めた
+	LockEventLevelUp      = "level_up"      // レベルが上がった
+)
+
+// EventPieceLock はピース固定時の出来事をクライアントに通知するイベントの種類です。
+const EventPieceLock = "lock_events"
+
+// EventLinesCleared はライン消去で消えた行をクライアントに通知するイベントの種類です。
+// クライアントは消去アニメーションを再生した後、次に届くゲーム状態のボードへ遷移します。
+const EventLinesCleared = "lines_cleared"
+
+// TSpinMinCorners はT-Spinとみなすために埋まっている必要がある、Tミノ中心の斜め四隅の数です。
+const TSpinMinCorners = 3
+
+// PieceLockEvent はピース固定時の出来事を通知するイベントメッセージです。
+// 相手の演出にも使えるよう、ルームの両プレイヤーに送信します。
+type PieceLockEvent struct {
+	Type    string   `json:"type"`
+	Version int      `json:"v"`       // プロトコルバージョン（ProtocolVersion）
+	UserID  string   `json:"user_id"` // ピースを固定したプレイヤーのユーザーID
+	Events  []string `json:"events"`  // LockEvent* 定数の配列（発生順）
+}
+
+// LinesClearedEvent はライン消去で消えた行を通知するイベントメッセージです。
+// 例: {"type":"lines_cleared","user_id":"...","rows":[17,18]}
+type LinesClearedEvent struct {
+	Type    string `json:"type"`
+	Version int    `json:"v"`       // プロトコルバージョン（ProtocolVersion）
+	UserID  string `json:"user_id"` // ラインを消去したプレイヤーのユーザーID
+	Rows    []int  `json:"rows"`    // 消えた行のY座標（表示部分の座標、消去前のボード上の位置、昇順）
+}
+
+// lockNotification はプレイヤー1人分の、送信待ちのピース固定時の通知です。
+type lockNotification struct {
+	events      []string // LockEvent* 定数（発生順）
+	clearedRows [][]int  // ライン消去ごとの消えた行
+}
+
+// visibleRows はボード内部のY座標の行を表示部分の座標に変換し、隠し行内の行を除きます。
+func visibleRows(rows []int) []int {
+	var visible []int
+	for _, y := range rows {
+		if vy := tetris.ToVisibleY(y); vy >= 0 {
+			visible = append(visible, vy)
+		}
+	}
+	return visible
+}
+
+// lineClearEvents は同時に消したライン数に対応する出来事です。
+var lineClearEvents = map[int]string{
+	1: LockEventSingle,
+	2: LockEventDouble,
+	3: LockEventTriple,
+	4: LockEventTetris,
+}
+
+// isTSpin は固定したピースがT-Spinかどうかを判定します。
+// 最後の操作が回転のTミノで、中心（ブロック相対座標 {1, 1}）の斜め四隅のうち TSpinMinCorners 以上が埋まっていればT-Spinです。
+// 呼び出し時点で CurrentPiece は固定したピースを指している必要があります。
+func isTSpin(state *PlayerGameState) bool {
+	piece := state.CurrentPiece
+	if piece == nil || piece.Type != tetris.TypeT || !state.lastMoveWasRotation {
+		return false
+	}
+	return state.Board.CountOccupiedCorners(piece.X+1, piece.Y+1) >= TSpinMinCorners
+}
+
+// lockEvents は1回の固定で発生した出来事を発生順に並べて返します。
+//
+// Parameters:
+//   clearedLines : 同時に消したライン数
+//   tSpin        : T-Spinだったかどうか
+//   perfectClear : ライン消去後にボードが空になったかどうか
+//   combo        : 連続ラインクリアだったかどうか
+//   backToBack   : 難しい消去（テトリス・ラインを消したT-Spin）を連続で決めたかどうか
+//   levelUp      : レベルが上がったかどうか
+func lockEvents(clearedLines int, tSpin, perfectClear, combo, backToBack, levelUp bool) []string {
+	var events []string
+	if tSpin {
+		events = append(events, LockEventTSpin)
+	}
+	if event, ok := lineClearEvents[clearedLines]; ok {
+		events = append(events, event)
+	}
+	if backToBack {
+		events = append(events, LockEventBackToBack)
+	}
+	if combo {
+		events = append(events, LockEventCombo)
+	}
+	if perfectClear {
+		events = append(events, LockEventPerfectClear)
+	}
+	if levelUp {
+		events = append(events, LockEventLevelUp)
+	}
+	return events
+}
+
+// takeLockEvents は送信待ちの出来事を取り出してクリアします。
+// ゲーム状態ロック（gameMu）を保持した状態で呼び出してください。
+func (s *PlayerGameState) takeLockEvents() []string {
+	events := s.lastEvents
+	s.lastEvents = nil
+	return events
+}
+
+// takeSessionLockEvents はセッションの両プレイヤーの送信待ちの出来事と消えた行を取り出します。
+// ゲーム状態ロック（gameMu）を保持した状態で呼び出してください。
+//
+// Returns:
+//   map[string]lockNotification: userID -> 通知（出来事も消えた行も無いプレイヤーは含まない）
+func (gs *GameSession) takeSessionLockEvents() map[string]lockNotification {
+	var pending map[string]lockNotification
+	for _, player := range []*PlayerGameState{gs.Player1, gs.Player2} {
+		if player == nil {
+			continue
+		}
+		notification := lockNotification{events: player.takeLockEvents(), clearedRows: player.lastClearedRows}
+		player.lastClearedRows = nil
+		if len(notification.events) > 0 || len(notification.clearedRows) > 0 {
+			if pending == nil {
+				pending = make(map[string]lockNotification)
+			}
+			pending[player.UserID] = notification
+		}
+	}
+	return pending
+}
+
+// sendLockEvents はピース固定時の出来事と消えた行をルームの接続中のクライアント全員に送信します。
+// 消去アニメーションを先に始められるよう、lines_cleared を lock_events より先に送ります。
+// 演出用の通知なので、送信できなかった場合は取りこぼしを許容します。
+//
+// Parameters:
+//   session : 出来事が発生したセッション
+//   pending : userID -> 通知（takeSessionLockEvents の返り値）
+func (sm *SessionManager) sendLockEvents(session *GameSession, pending map[string]lockNotification) {
+	if len(pending) == 0 {
+		return
+	}
+
+	sm.mu.RLock()
+	var clients []*Client
+	for _, player := range []*PlayerGameState{session.Player1, session.Player2} {
+		if player == nil {
+			continue
+		}
+		if client, ok := sm.clients[player.UserID]; ok && client.RoomID == session.ID {
+			clients = append(clients, client)
+		}
+	}
+	sm.mu.RUnlock()
+
+	for userID, notification := range pending {
+		var messages [][]byte
+		for _, rows := range notification.clearedRows {
+			message, err := json.Marshal(LinesClearedEvent{Type: EventLinesCleared, Version: ProtocolVersion, UserID: userID, Rows: rows})
+			if err != nil {
+				log.Printf("[SessionManager] Failed to marshal cleared rows for %s: %v", userID, err)
+				continue
+			}
+			messages = append(messages, message)
+		}
+		if len(notification.events) > 0 {
+			message, err := json.Marshal(PieceLockEvent{Type: EventPieceLock, Version: ProtocolVersion, UserID: userID, Events: notification.events})
+			if err != nil {
+				log.Printf("[SessionManager] Failed to marshal lock events for %s: %v", userID, err)
+				continue
+			}
+			messages = append(messages, message)
+		}
+		for _, client := range clients {
+			for _, message := range messages {
+				sm.sendOrDisconnect(client, message)
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package tetris
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHandlePieceLock_TetrisEvents はテトリスでパーフェクトクリアした固定で、
+// 同時に発生した出来事がすべて記録され、取り出し後にクリアされることをテストします。
+func TestHandlePieceLock_TetrisEvents(t *testing.T) {
+	state := NewPlayerGameState("test-user", &models.Deck{ID: "mock-deck-id"})
+
+	// 下4段を左端の1列だけ空けて埋め、縦向きのIミノで4ライン同時に消す
+	for y := tetris.BoardTotalHeight - 4; y < tetris.BoardTotalHeight; y++ {
+		for x := 1; x < tetris.BoardWidth; x++ {
+			state.Board[y][x] = tetris.BlockL
+		}
+	}
+	state.CurrentPiece = &tetris.Piece{Type: tetris.TypeI, X: -2, Y: 0, Rotation: 90}
+	state.LinesCleared = 1      // 4ライン消去でレベル2に上がる
+	state.ConsecutiveClears = 1 // 直前の固定でもラインを消している
+	state.BackToBack = true     // 直前もテトリス
+
+	ApplyPlayerInput(state, ActionHardDrop)
+
+	assert.Equal(t, []string{
+		LockEventTetris, LockEventBackToBack, LockEventCombo, LockEventPerfectClear, LockEventLevelUp,
+	}, state.takeLockEvents())
+	assert.Empty(t, state.takeLockEvents(), "取り出した出来事はクリアされるはず")
+}
+
+// TestHandlePieceLock_TSpin は回転で差し込んだTミノの中心の斜め3隅が埋まっていればT-Spinになることをテストします。
+func TestHandlePieceLock_TSpin(t *testing.T) {
+	state := NewPlayerGameState("test-user", &models.Deck{ID: "mock-deck-id"})
+	bottom := tetris.BoardTotalHeight - 1
+
+	// 最下段を x=1 だけ空けて埋め、中心 (1, bottom-1) の左上の隅も埋める
+	for x := 0; x < tetris.BoardWidth; x++ {
+		if x != 1 {
+			state.Board[bottom][x] = tetris.BlockL
+		}
+	}
+	state.Board[bottom-2][0] = tetris.BlockL
+
+	// 下向きのTミノが既に接地している状態で、最後の操作が回転だったとする
+	state.CurrentPiece = &tetris.Piece{Type: tetris.TypeT, X: 0, Y: bottom - 2, Rotation: 180}
+	state.lastMoveWasRotation = true
+
+	ApplyPlayerInput(state, ActionHardDrop)
+
+	assert.Equal(t, []string{LockEventTSpin, LockEventSingle}, state.takeLockEvents())
+	assert.Equal(t, [][]int{{tetris.BoardHeight - 1}}, state.lastClearedRows, "消えた行は表示部分の座標で記録されるはず")
+}
+
+// TestTakeSessionLockEvents は出来事・消えた行のあるプレイヤーの分だけをユーザーIDごとに取り出すことをテストします。
+func TestTakeSessionLockEvents(t *testing.T) {
+	session := newPlayingSession(t, "lock-events")
+	session.Player2.lastEvents = []string{LockEventDouble}
+	session.Player2.lastClearedRows = [][]int{{18, 19}}
+
+	assert.Equal(t, map[string]lockNotification{
+		"player2": {events: []string{LockEventDouble}, clearedRows: [][]int{{18, 19}}},
+	}, session.takeSessionLockEvents())
+	assert.Nil(t, session.takeSessionLockEvents())
+}
+
+// TestRun_LockEventsBeforeState は操作でピースを固定した場合、消えた行・固定時の出来事が
+// 固定後のゲーム状態より先に、この順で本人に届くことをテストします。
+func TestRun_LockEventsBeforeState(t *testing.T) {
+	sm := newTestSessionManager()
+	sm.inputEvents = make(chan PlayerInputEvent, 1)
+	session := newPlayingSession(t, "lock-order")
+	for x := 1; x < tetris.BoardWidth; x++ {
+		session.Player1.Board[tetris.BoardTotalHeight-1][x] = tetris.BlockL
+	}
+	session.Player1.CurrentPiece = &tetris.Piece{Type: tetris.TypeI, X: -2, Y: 0, Rotation: 90}
+	sm.sessions["lock-order"] = session
+	client := &Client{UserID: "player1", RoomID: "lock-order", Send: make(chan []byte, 8)}
+	sm.clients["player1"] = client
+	go sm.Run()
+	defer sm.Shutdown()
+
+	sm.inputEvents <- PlayerInputEvent{UserID: "player1", Action: ActionHardDrop}
+
+	var types []string
+	for len(types) < 3 {
+		select {
+		case message := <-client.Send:
+			var decoded struct {
+				Type string `json:"type"`
+			}
+			assert.NoError(t, json.Unmarshal(message, &decoded))
+			types = append(types, decoded.Type)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out, received %v", types)
+		}
+	}
+	assert.Equal(t, []string{EventLinesCleared, EventPieceLock, ""}, types, "ゲーム状態（type無し）は固定時の通知の後に届くはず")
+}
@@ -0,0 +1,161 @@
+package tetris
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+)
+
+// サーバーで生成する合言葉の形式です。
+const (
+	PasscodeStyleDigits = "digits" // 4桁の数字（例: "0427"）。口頭で伝えやすいが組み合わせが少ない
+	PasscodeStyleWords  = "words"  // 発音しやすいローマ字3音節と2桁の数字（例: "kamiro-27"）
+)
+
+// DefaultPasscodeStyle は形式を指定しなかった場合の合言葉の形式です。
+const DefaultPasscodeStyle = PasscodeStyleWords
+
+// MaxPasscodeGenerateAttempts は既存のセッションと衝突した場合に合言葉を生成し直す回数の上限です。
+const MaxPasscodeGenerateAttempts = 10
+
+var (
+	// ErrInvalidPasscodeStyle は未知の合言葉の形式が指定された場合のエラーです。
+	ErrInvalidPasscodeStyle = errors.New("合言葉の形式が不正です")
+	// ErrPasscodeUnavailable は再生成を繰り返しても空いている合言葉を生成できなかった場合のエラーです。
+	ErrPasscodeUnavailable = errors.New("空いている合言葉を生成できませんでした")
+	// errPasscodeTaken は生成した合言葉のセッションが既に存在することを表します（再生成の合図）。
+	errPasscodeTaken = errors.New("合言葉は使用中です")
+)
+
+// 発音しやすい合言葉に使う文字です。読み間違えやすい文字（l, q, x など）は使いません。
+const (
+	passcodeConsonants = "bdfghkmnprstyz"
+	passcodeVowels     = "aeiou"
+)
+
+// randomIndex は crypto/rand で 0 以上 n 未満の乱数を返します。合言葉を推測されにくくするために使います。
+func randomIndex(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(v.Int64()), nil
+}
+
+// generatePasscode は指定された形式でランダムな合言葉を1つ生成します。既存セッションとの衝突は確認しません。
+func generatePasscode(style string) (string, error) {
+	var b strings.Builder
+	switch style {
+	case PasscodeStyleDigits:
+		for i := 0; i < 4; i++ {
+			d, err := randomIndex(10)
+			if err != nil {
+				return "", err
+			}
+			b.WriteByte(byte('0' + d))
+		}
+	case PasscodeStyleWords:
+		for i := 0; i < 3; i++ {
+			c, err := randomIndex(len(passcodeConsonants))
+			if err != nil {
+				return "", err
+			}
+			v, err := randomIndex(len(passcodeVowels))
+			if err != nil {
+				return "", err
+			}
+			b.WriteByte(passcodeConsonants[c])
+			b.WriteByte(passcodeVowels[v])
+		}
+		n, err := randomIndex(100)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "-%02d", n)
+	default:
+		return "", fmt.Errorf("passcode style %q: %w", style, ErrInvalidPasscodeStyle)
+	}
+	return b.String(), nil
+}
+
+// createWithGeneratedPasscode は合言葉を生成して create に渡し、既存のセッションと衝突した場合（errPasscodeTaken）は
+// MaxPasscodeGenerateAttempts 回まで生成し直します。SessionManager と ShardedSessionManager で共通の再試行処理です。
+//
+// Parameters:
+//   style  : 合言葉の形式（PasscodeStyle* 定数）
+//   create : 生成した合言葉でルームを作成する関数（合言葉が使用中なら errPasscodeTaken を返す）
+// Returns:
+//   string: ルームを作成した合言葉
+//   error: 形式が不正な場合、空きが見つからない場合（ErrPasscodeUnavailable）、ルームの作成に失敗した場合
+func createWithGeneratedPasscode(style string, create func(passcode string) error) (string, error) {
+	for attempt := 1; attempt <= MaxPasscodeGenerateAttempts; attempt++ {
+		passcode, err := generatePasscode(style)
+		if err != nil {
+			return "", err
+		}
+		err = create(passcode)
+		if errors.Is(err, errPasscodeTaken) {
+			log.Printf("[SessionManager] Generated passcode %s is already in use, regenerating (%d/%d)", passcode, attempt, MaxPasscodeGenerateAttempts)
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return passcode, nil
+	}
+	return "", ErrPasscodeUnavailable
+}
+
+// createRoomIfAbsent は合言葉のセッションが存在しない場合に限り、新しいルームを作成します。
+// 結果参照のために保持中の終了済みセッションも使用中として扱います。
+func (sm *SessionManager) createRoomIfAbsent(passcode string, player1 *PlayerGameState, rules GameRules) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if _, exists := sm.sessions[passcode]; exists {
+		return errPasscodeTaken
+	}
+	return sm.createSessionLocked(passcode, player1, rules)
+}
+
+// CreateRoomWithRandomPasscode はサーバーで生成した既存のセッションと衝突しない合言葉で、通常ルールのルームを作成します。
+//
+// Parameters:
+//   userID : ルームを作成するプレイヤー（プレイヤー1）のユーザーID
+//   deckID : プレイヤーが使用するデッキのUUID
+// Returns:
+//   string: 生成した合言葉（セッションID）
+//   error: ルームを作成できなかった場合
+func (sm *SessionManager) CreateRoomWithRandomPasscode(userID, deckID string) (string, error) {
+	return sm.CreateRoomWithGeneratedPasscode(userID, deckID, DefaultPasscodeStyle, DefaultGameRules())
+}
+
+// CreateRoomWithGeneratedPasscode は指定された形式で生成した合言葉で、ルールを指定してルームを作成します。
+// 生成した合言葉が使用中の場合は生成し直します。手動で決めた合言葉での作成（JoinRoomWithRules）と併用できます。
+//
+// Parameters:
+//   userID : ルームを作成するプレイヤー（プレイヤー1）のユーザーID
+//   deckID : プレイヤーが使用するデッキのUUID
+//   style  : 合言葉の形式（PasscodeStyle* 定数）
+//   rules  : ルームのルール
+// Returns:
+//   string: 生成した合言葉（セッションID）
+//   error: ルール・形式・デッキIDが不正な場合、空きが見つからない場合、ルームを作成できなかった場合
+func (sm *SessionManager) CreateRoomWithGeneratedPasscode(userID, deckID, style string, rules GameRules) (string, error) {
+	if err := rules.Validate(); err != nil {
+		return "", err
+	}
+	if err := ValidateDeckID(deckID); err != nil {
+		return "", err
+	}
+	// デッキ・草データのDBアクセスはロックの外で1回だけ済ませ、合言葉の生成し直しでは読み込んだ状態を使い回す
+	player1, err := sm.loadPlayerState(userID, deckID)
+	if err != nil {
+		return "", err
+	}
+	return createWithGeneratedPasscode(style, func(passcode string) error {
+		return sm.createRoomIfAbsent(passcode, player1, rules)
+	})
+}
@@ -0,0 +1,83 @@
+package tetris
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGeneratePasscode は各形式で生成される合言葉の書式と、未知の形式がエラーになることをテストします。
+func TestGeneratePasscode(t *testing.T) {
+	tests := []struct {
+		style   string
+		pattern *regexp.Regexp
+	}{
+		{PasscodeStyleDigits, regexp.MustCompile(`^[0-9]{4}$`)},
+		{PasscodeStyleWords, regexp.MustCompile(`^([bdfghkmnprstyz][aeiou]){3}-[0-9]{2}$`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			for i := 0; i < 50; i++ {
+				passcode, err := generatePasscode(tt.style)
+				assert.NoError(t, err)
+				assert.Regexp(t, tt.pattern, passcode)
+			}
+		})
+	}
+
+	_, err := generatePasscode("emoji")
+	assert.ErrorIs(t, err, ErrInvalidPasscodeStyle)
+}
+
+// TestCreateWithGeneratedPasscode は合言葉が使用中の場合に生成し直し、上限回数を超えると
+// ErrPasscodeUnavailable を返すことをテストします。
+func TestCreateWithGeneratedPasscode(t *testing.T) {
+	t.Run("衝突したら生成し直す", func(t *testing.T) {
+		var tried []string
+		passcode, err := createWithGeneratedPasscode(PasscodeStyleWords, func(p string) error {
+			tried = append(tried, p)
+			if len(tried) < 3 {
+				return errPasscodeTaken
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Len(t, tried, 3)
+		assert.Equal(t, tried[2], passcode, "最後に作成できた合言葉を返すはず")
+	})
+
+	t.Run("空きが見つからない", func(t *testing.T) {
+		attempts := 0
+		_, err := createWithGeneratedPasscode(PasscodeStyleDigits, func(string) error {
+			attempts++
+			return errPasscodeTaken
+		})
+		assert.ErrorIs(t, err, ErrPasscodeUnavailable)
+		assert.Equal(t, MaxPasscodeGenerateAttempts, attempts)
+	})
+
+	t.Run("作成のエラーは再試行しない", func(t *testing.T) {
+		createErr := errors.New("deck load failed")
+		attempts := 0
+		_, err := createWithGeneratedPasscode(PasscodeStyleDigits, func(string) error {
+			attempts++
+			return createErr
+		})
+		assert.ErrorIs(t, err, createErr)
+		assert.Equal(t, 1, attempts)
+	})
+}
+
+// TestCreateRoomIfAbsent_Taken は既存のセッション（終了済みで保持中のものを含む）と同じ合言葉では
+// ルームを作成せず、errPasscodeTaken を返すことをテストします。
+func TestCreateRoomIfAbsent_Taken(t *testing.T) {
+	sm := newTestSessionManager()
+	sm.sessions["1234"] = &GameSession{ID: "1234", Status: "finished"}
+
+	err := sm.createRoomIfAbsent("1234", NewPlayerGameState("player1", &models.Deck{ID: "deck1"}), DefaultGameRules())
+	assert.ErrorIs(t, err, errPasscodeTaken)
+	assert.Equal(t, "finished", sm.sessions["1234"].Status, "既存のセッションはそのままのはず")
+}
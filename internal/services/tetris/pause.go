@@ -0,0 +1,342 @@
+package tetris
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// プレイ中の一時停止・再開を要求するときにクライアントが送るメッセージの種類です。
+// 例: {"type":"request_pause"}
+const (
+	MessageRequestPause  = "request_pause"
+	MessageRequestResume = "request_resume"
+)
+
+// 一時停止に関するイベントの種類です。遷移イベントと同様に取りこぼさない経路（sendReliable）で送信します。
+const (
+	EventPauseRequested = "pause_requested" // 片方のプレイヤーが一時停止を要求した（相手の同意待ち）
+	EventPauseRejected  = "pause_rejected"  // 一時停止・再開の要求を受け付けなかった（要求したプレイヤーにのみ送信）
+	EventGamePaused     = "game_paused"     // playing → paused
+	EventGameResumed    = "game_resumed"    // paused → playing
+)
+
+const (
+	// MaxPausesPerGame は1ゲームで一時停止できる回数の上限です。
+	MaxPausesPerGame = 3
+	// MaxTotalPauseDuration は1ゲームで一時停止できる合計時間の上限です。超えた場合は自動的に再開します。
+	MaxTotalPauseDuration = 60 * time.Second
+	// PauseRequestTTL は相手の同意を待つ一時停止の要求の有効期間です。過ぎた要求は同意の集計に数えません。
+	PauseRequestTTL = 10 * time.Second
+	// PauseMessageMinInterval は同じクライアントからの一時停止・再開の要求を処理する最小間隔です。間隔内の要求は捨てます。
+	PauseMessageMinInterval = 1 * time.Second
+)
+
+var (
+	// ErrNotPlaying はプレイ中でないセッションに一時停止を要求した場合のエラーです。
+	ErrNotPlaying = errors.New("プレイ中のゲームではありません")
+	// ErrNotPaused は一時停止中でないセッションに再開を要求した場合のエラーです。
+	ErrNotPaused = errors.New("ゲームは一時停止していません")
+	// ErrPauseLimitReached は一時停止の回数または合計時間の上限に達している場合のエラーです。
+	ErrPauseLimitReached = errors.New("一時停止の上限に達しています")
+)
+
+// pauseRequiresAgreement は一時停止に両プレイヤーの要求（同意）が必要かどうかです。
+// 環境変数 PAUSE_SINGLE_REQUEST=true の場合は片方のプレイヤーの要求だけで一時停止します。
+var pauseRequiresAgreement = !loadPauseSingleRequest()
+
+// loadPauseSingleRequest は環境変数 PAUSE_SINGLE_REQUEST を読み込みます。不正な値の場合は false（同意が必要）です。
+func loadPauseSingleRequest() bool {
+	env := os.Getenv("PAUSE_SINGLE_REQUEST")
+	if env == "" {
+		return false
+	}
+	single, err := strconv.ParseBool(env)
+	if err != nil {
+		log.Printf("[WARN] Invalid PAUSE_SINGLE_REQUEST %q, using default false", env)
+		return false
+	}
+	return single
+}
+
+// GamePauseEvent は一時停止・再開を通知するイベントメッセージです。
+type GamePauseEvent struct {
+	Type             string `json:"type"`
+	Version          int    `json:"v"`                  // プロトコルバージョン（ProtocolVersion）
+	UserID           string `json:"user_id"`            // 要求したプレイヤーのユーザーID（自動再開の場合は空）
+	PauseCount       int    `json:"pause_count"`        // このゲームで一時停止した回数
+	RemainingPauses  int    `json:"remaining_pauses"`   // 残りの一時停止可能回数
+	RemainingPauseMs int64  `json:"remaining_pause_ms"` // 残りの一時停止可能時間（ミリ秒）
+	Reason           string `json:"reason,omitempty"`   // pause_rejected の理由、自動再開の場合は "pause_time_exhausted"
+}
+
+// pauseState はセッションの一時停止の状態です。
+// 一時停止・再開（Status の遷移）は sm.mu と session.gameMu の両方を保持して変更するため、
+// pausedAt・pausedDuration・pauseCount の参照はどちらか一方のロックの下で行えます。
+// 同意待ちの要求（requests）は gameMu だけで保護します。
+type pauseState struct {
+	pausedAt       time.Time            // 現在の一時停止の開始時刻（一時停止中でなければゼロ値）
+	pausedDuration time.Duration        // 終了済みの一時停止の合計時間
+	pauseCount     int                  // このゲームで一時停止した回数
+	requests       map[string]time.Time // 一時停止を要求したプレイヤーと要求の時刻（同意待ち、PauseRequestTTL で失効）
+}
+
+// addRequestLocked は userID の一時停止の要求を記録し、失効していない要求の数を返します。
+// PauseRequestTTL を過ぎた要求は削除します。gameMu を保持した状態で呼び出してください。
+func (p *pauseState) addRequestLocked(userID string, now time.Time) int {
+	if p.requests == nil {
+		p.requests = make(map[string]time.Time, 2)
+	}
+	for id, requestedAt := range p.requests {
+		if now.Sub(requestedAt) >= PauseRequestTTL {
+			delete(p.requests, id)
+		}
+	}
+	p.requests[userID] = now
+	return len(p.requests)
+}
+
+// pauseLimitReachedLocked は一時停止の回数または合計時間の上限に達しているかを返します。gameMu を保持した状態で呼び出してください。
+func (gs *GameSession) pauseLimitReachedLocked(now time.Time) bool {
+	return gs.pause.pauseCount >= MaxPausesPerGame || gs.totalPausedLocked(now) >= MaxTotalPauseDuration
+}
+
+// totalPausedLocked は現在進行中の一時停止を含めた合計一時停止時間を返します。
+// sm.mu または gameMu を保持した状態で呼び出してください。
+func (gs *GameSession) totalPausedLocked(now time.Time) time.Duration {
+	total := gs.pause.pausedDuration
+	if !gs.pause.pausedAt.IsZero() {
+		total += now.Sub(gs.pause.pausedAt)
+	}
+	return total
+}
+
+// elapsedPlayTimeLocked はゲーム開始からの経過時間のうち、一時停止していた時間を除いたものを返します。
+// sm.mu または gameMu を保持した状態で呼び出してください。
+func (gs *GameSession) elapsedPlayTimeLocked(now time.Time) time.Duration {
+	return now.Sub(gs.StartedAt) - gs.totalPausedLocked(now)
+}
+
+// pauseEventLocked は一時停止の状態から GamePauseEvent を作成します。gameMu を保持した状態で呼び出してください。
+func (gs *GameSession) pauseEventLocked(eventType, userID, reason string, now time.Time) GamePauseEvent {
+	remaining := MaxTotalPauseDuration - gs.totalPausedLocked(now)
+	if remaining < 0 {
+		remaining = 0
+	}
+	remainingPauses := MaxPausesPerGame - gs.pause.pauseCount
+	if remainingPauses < 0 {
+		remainingPauses = 0
+	}
+	return GamePauseEvent{
+		Type:             eventType,
+		Version:          ProtocolVersion,
+		UserID:           userID,
+		PauseCount:       gs.pause.pauseCount,
+		RemainingPauses:  remainingPauses,
+		RemainingPauseMs: remaining.Milliseconds(),
+		Reason:           reason,
+	}
+}
+
+// RequestPause はプレイヤーからの一時停止の要求を処理します。
+// 同意が必要な設定では両プレイヤーが要求した時点で、そうでなければ要求した時点で playing → paused に遷移し、
+// game_paused イベントを両プレイヤーに送信します。相手の同意待ちの場合は pause_requested イベントを送信します。
+// 要求の受け付けは sm.mu の読み取りロックとセッションの gameMu だけで行い、
+// Status を書き換える遷移（1ゲームで最大 MaxPausesPerGame 回）のときだけ sm.mu の書き込みロックを取ります。
+//
+// Parameters:
+//   userID : 一時停止を要求したプレイヤーのユーザーID
+// Returns:
+//   error: セッションが無い・プレイ中でない・上限に達している場合
+func (sm *SessionManager) RequestPause(userID string) error {
+	sm.mu.RLock()
+	session, err := sm.sessionOfPlayerLocked(userID)
+	if err == nil && session.Status != "playing" {
+		err = ErrNotPlaying
+	}
+	if err != nil {
+		sm.mu.RUnlock()
+		return err
+	}
+
+	session.gameMu.Lock()
+	now := time.Now()
+	if session.pauseLimitReachedLocked(now) {
+		session.gameMu.Unlock()
+		sm.mu.RUnlock()
+		return ErrPauseLimitReached
+	}
+	requests := session.pause.addRequestLocked(userID, now)
+	if pauseRequiresAgreement && requests < 2 {
+		event := session.pauseEventLocked(EventPauseRequested, userID, "", now)
+		session.gameMu.Unlock()
+		sm.sendPauseEventLocked(session, event)
+		sm.mu.RUnlock()
+		return nil
+	}
+	session.gameMu.Unlock()
+	sm.mu.RUnlock()
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	// ロックを取り直す間に終了・一時停止されていないかを確認する
+	if current, ok := sm.sessions[session.ID]; !ok || current != session || session.Status != "playing" {
+		return ErrNotPlaying
+	}
+	session.gameMu.Lock()
+	now = time.Now()
+	if session.pauseLimitReachedLocked(now) {
+		session.gameMu.Unlock()
+		return ErrPauseLimitReached
+	}
+	session.pause.pausedAt = now
+	session.pause.pauseCount++
+	session.pause.requests = nil
+	session.setStatus("paused", "pause_requested")
+	event := session.pauseEventLocked(EventGamePaused, userID, "", now)
+	session.gameMu.Unlock()
+
+	log.Printf("[SessionManager] Game paused for passcode %s by %s (%d/%d)", session.ID, userID, event.PauseCount, MaxPausesPerGame)
+	sm.sendPauseEventLocked(session, event)
+	return nil
+}
+
+// RequestResume はプレイヤーからの再開の要求を処理し、paused → playing に遷移します。
+// 再開はどちらのプレイヤーの要求でもすぐに行います。
+// 一時停止中でない場合の拒否は sm.mu の読み取りロックだけで判定し、遷移するときだけ書き込みロックを取ります。
+//
+// Parameters:
+//   userID : 再開を要求したプレイヤーのユーザーID
+// Returns:
+//   error: セッションが無い・一時停止中でない場合
+func (sm *SessionManager) RequestResume(userID string) error {
+	sm.mu.RLock()
+	session, err := sm.sessionOfPlayerLocked(userID)
+	if err == nil && session.Status != "paused" {
+		err = ErrNotPaused
+	}
+	sm.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if current, ok := sm.sessions[session.ID]; !ok || current != session || session.Status != "paused" {
+		return ErrNotPaused
+	}
+	sm.resumeLocked(session, userID, "")
+	return nil
+}
+
+// resumeLocked は一時停止中のセッションを再開し、game_resumed イベントを両プレイヤーに送信します。
+// 呼び出し側で sm.mu のロックを保持している必要があります。
+func (sm *SessionManager) resumeLocked(session *GameSession, userID, reason string) {
+	session.gameMu.Lock()
+	now := time.Now()
+	if !session.pause.pausedAt.IsZero() {
+		session.pause.pausedDuration += now.Sub(session.pause.pausedAt)
+		session.pause.pausedAt = time.Time{}
+	}
+	detail := "resumed"
+	if reason != "" {
+		detail += ": " + reason
+	}
+	session.setStatus("playing", detail)
+	event := session.pauseEventLocked(EventGameResumed, userID, reason, now)
+	session.gameMu.Unlock()
+
+	log.Printf("[SessionManager] Game resumed for passcode %s (remaining pause time: %dms)", session.ID, event.RemainingPauseMs)
+	sm.sendPauseEventLocked(session, event)
+}
+
+// resumeIfPauseExhausted は一時停止の合計時間が上限に達したセッションを自動的に再開します。
+// セッションループから一時停止中に毎tick呼び出されます。
+func (sm *SessionManager) resumeIfPauseExhausted(session *GameSession) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if session.Status != "paused" {
+		return
+	}
+	session.gameMu.Lock()
+	exhausted := session.totalPausedLocked(time.Now()) >= MaxTotalPauseDuration
+	session.gameMu.Unlock()
+	if exhausted {
+		log.Printf("[SessionManager] Pause time exhausted for passcode %s, resuming", session.ID)
+		sm.resumeLocked(session, "", "pause_time_exhausted")
+	}
+}
+
+// sessionOfPlayerLocked は接続中のユーザーが参加しているセッションを返します。
+// 呼び出し側で sm.mu のロックを保持している必要があります。
+func (sm *SessionManager) sessionOfPlayerLocked(userID string) (*GameSession, error) {
+	client, ok := sm.clients[userID]
+	if !ok {
+		return nil, ErrClientNotConnected
+	}
+	session, ok := sm.sessions[client.RoomID]
+	if !ok || !session.hasPlayer(userID) {
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// isInProgress はゲームが進行中（プレイ中または一時停止中）かどうかを返します。
+// 一時停止中も切断時の再接続猶予・再接続時の resync はプレイ中と同じように扱います。
+func (gs *GameSession) isInProgress() bool {
+	return gs.Status == "playing" || gs.Status == "paused"
+}
+
+// hasPlayer は userID がセッションのプレイヤーかどうかを返します。
+func (gs *GameSession) hasPlayer(userID string) bool {
+	return (gs.Player1 != nil && gs.Player1.UserID == userID) || (gs.Player2 != nil && gs.Player2.UserID == userID)
+}
+
+// sendPauseEventLocked は一時停止に関するイベントをセッションの全クライアントに送信します。
+// 呼び出し側で sm.mu のロック（読み取りロック可）を保持している必要があります。
+func (sm *SessionManager) sendPauseEventLocked(session *GameSession, event GamePauseEvent) {
+	message, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("[SessionManager] Error marshaling %s event for passcode %s: %v", event.Type, session.ID, err)
+		return
+	}
+	for _, client := range sm.clients {
+		if client.RoomID == session.ID {
+			sendReliable(client, message)
+		}
+	}
+}
+
+// allowPauseMessage はクライアントの一時停止・再開の要求を処理してよいかを判定し、処理する場合は時刻を記録します。
+// 前回処理してから PauseMessageMinInterval 経っていない要求は捨てます。readPump のゴルーチンからのみ呼び出されるため、ロックなしで更新します。
+func (c *Client) allowPauseMessage(now time.Time) bool {
+	if !c.lastPauseMessage.IsZero() && now.Sub(c.lastPauseMessage) < PauseMessageMinInterval {
+		slog.Debug("[SessionManager] Dropping pause message (too frequent)", "user_id", c.UserID, "passcode", c.RoomID)
+		return false
+	}
+	c.lastPauseMessage = now
+	return true
+}
+
+// handlePauseMessage は一時停止・再開の要求メッセージを処理し、受け付けなかった場合は要求したクライアントに pause_rejected を送ります。
+func (sm *SessionManager) handlePauseMessage(client *Client, messageType string) {
+	var err error
+	if messageType == MessageRequestPause {
+		err = sm.RequestPause(client.UserID)
+	} else {
+		err = sm.RequestResume(client.UserID)
+	}
+	if err == nil {
+		return
+	}
+	log.Printf("[SessionManager] Rejected %s from user %s: %v", messageType, client.UserID, err)
+	message, marshalErr := json.Marshal(GamePauseEvent{Type: EventPauseRejected, Version: ProtocolVersion, UserID: client.UserID, Reason: err.Error()})
+	if marshalErr != nil {
+		return
+	}
+	sendReliable(client, message)
+}
@@ -0,0 +1,123 @@
+package tetris
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newPauseTestSession は2人とも接続中のプレイ中セッションを登録し、各プレイヤーのクライアントを返します。
+func newPauseTestSession(t *testing.T, sm *SessionManager) (*GameSession, *Client, *Client) {
+	session := newPlayingSession(t, "pause-room")
+	sm.sessions["pause-room"] = session
+	c1 := &Client{UserID: "player1", RoomID: "pause-room", Send: make(chan []byte, 8)}
+	c2 := &Client{UserID: "player2", RoomID: "pause-room", Send: make(chan []byte, 8)}
+	sm.clients["player1"] = c1
+	sm.clients["player2"] = c2
+	return session, c1, c2
+}
+
+// lastPauseEvent はクライアントに送られた最後のメッセージを GamePauseEvent として返します。
+func lastPauseEvent(t *testing.T, client *Client) GamePauseEvent {
+	var event GamePauseEvent
+	var message []byte
+	for len(client.Send) > 0 {
+		message = <-client.Send
+	}
+	assert.NoError(t, json.Unmarshal(message, &event))
+	return event
+}
+
+// TestRequestPause_RequiresAgreement は両プレイヤーが要求して初めて一時停止し、再開で playing に戻ることをテストします。
+func TestRequestPause_RequiresAgreement(t *testing.T) {
+	sm := newTestSessionManager()
+	session, c1, c2 := newPauseTestSession(t, sm)
+
+	assert.NoError(t, sm.RequestPause("player1"))
+	assert.Equal(t, "playing", session.Status, "相手の同意があるまで一時停止しないはず")
+	assert.Equal(t, EventPauseRequested, lastPauseEvent(t, c2).Type)
+
+	assert.NoError(t, sm.RequestPause("player2"))
+	assert.Equal(t, "paused", session.Status)
+	paused := lastPauseEvent(t, c1)
+	assert.Equal(t, EventGamePaused, paused.Type)
+	assert.Equal(t, MaxPausesPerGame-1, paused.RemainingPauses)
+
+	assert.ErrorIs(t, sm.RequestPause("player1"), ErrNotPlaying)
+	assert.NoError(t, sm.RequestResume("player1"))
+	assert.Equal(t, "playing", session.Status)
+	assert.Equal(t, EventGameResumed, lastPauseEvent(t, c2).Type)
+	assert.ErrorIs(t, sm.RequestResume("player1"), ErrNotPaused)
+}
+
+// TestPause_ExcludedFromTimeLimit は一時停止していた時間が制限時間・残り時間に数えられないことをテストします。
+func TestPause_ExcludedFromTimeLimit(t *testing.T) {
+	session := newPlayingSession(t, "pause-time")
+	session.StartedAt = time.Now().Add(-session.TimeLimit - 5*time.Second)
+	assert.True(t, session.IsTimeUp())
+
+	session.pause.pausedDuration = 30 * time.Second
+	assert.False(t, session.IsTimeUp(), "一時停止していた時間の分だけ制限時間が延びるはず")
+	state := session.ToLightweight()
+	assert.InDelta(t, 25, state.RemainingTime, 1)
+	assert.Equal(t, session.StartedAt.Add(session.TimeLimit+30*time.Second).UnixMilli(), state.EndsAtMs)
+
+	session.Status = "paused"
+	session.pause.pausedAt = time.Now().Add(-10 * time.Second)
+	state = session.ToLightweight()
+	assert.InDelta(t, 35, state.RemainingTime, 1, "一時停止中は残り時間が減らないはず")
+	assert.Zero(t, state.EndsAtMs, "一時停止中は終了予定時刻を送らないはず")
+}
+
+// TestPause_Limits は一時停止の回数上限で要求が拒否され、合計時間の上限で自動的に再開することをテストします。
+func TestPause_Limits(t *testing.T) {
+	sm := newTestSessionManager()
+	session, c1, _ := newPauseTestSession(t, sm)
+
+	session.pause.pauseCount = MaxPausesPerGame
+	assert.ErrorIs(t, sm.RequestPause("player1"), ErrPauseLimitReached)
+
+	sm.handlePauseMessage(c1, MessageRequestPause)
+	rejected := lastPauseEvent(t, c1)
+	assert.Equal(t, EventPauseRejected, rejected.Type)
+	assert.NotEmpty(t, rejected.Reason)
+
+	session.pause.pauseCount = 0
+	session.Status = "paused"
+	session.pause.pausedAt = time.Now().Add(-MaxTotalPauseDuration)
+	sm.resumeIfPauseExhausted(session)
+	assert.Equal(t, "playing", session.Status, "合計時間の上限に達したら自動的に再開するはず")
+	resumed := lastPauseEvent(t, c1)
+	assert.Equal(t, EventGameResumed, resumed.Type)
+	assert.Equal(t, "pause_time_exhausted", resumed.Reason)
+	assert.ErrorIs(t, sm.RequestPause("player1"), ErrPauseLimitReached)
+}
+
+// TestRequestPause_RequestExpires は PauseRequestTTL を過ぎた同意待ちの要求は同意に数えず、一時停止しないことをテストします。
+func TestRequestPause_RequestExpires(t *testing.T) {
+	sm := newTestSessionManager()
+	session, c1, _ := newPauseTestSession(t, sm)
+
+	assert.NoError(t, sm.RequestPause("player1"))
+	session.pause.requests["player1"] = time.Now().Add(-PauseRequestTTL)
+
+	assert.NoError(t, sm.RequestPause("player2"))
+	assert.Equal(t, "playing", session.Status, "失効した要求は同意に数えないはず")
+	assert.Equal(t, EventPauseRequested, lastPauseEvent(t, c1).Type)
+	assert.NotContains(t, session.pause.requests, "player1", "失効した要求は削除されるはず")
+
+	assert.NoError(t, sm.RequestPause("player1"))
+	assert.Equal(t, "paused", session.Status, "有効期間内に両者が要求すれば一時停止するはず")
+}
+
+// TestAllowPauseMessage は同じクライアントの一時停止・再開の要求を PauseMessageMinInterval に1回だけ処理することをテストします。
+func TestAllowPauseMessage(t *testing.T) {
+	client := &Client{UserID: "player1", RoomID: "pause-room"}
+	now := time.Now()
+
+	assert.True(t, client.allowPauseMessage(now))
+	assert.False(t, client.allowPauseMessage(now.Add(PauseMessageMinInterval/2)), "間隔内の要求は捨てるはず")
+	assert.True(t, client.allowPauseMessage(now.Add(PauseMessageMinInterval)))
+}
@@ -0,0 +1,53 @@
+package tetris
+
+import (
+	"math"
+	"time"
+)
+
+// markPlayStarted はプレイ開始時刻を記録し、APM/PPSの計測を開始します。
+func (s *PlayerGameState) markPlayStarted(t time.Time) {
+	s.playStartedAt = t
+	s.playEndedAt = time.Time{}
+	s.actionCount = 0
+	s.piecesPlaced = 0
+}
+
+// markPlayEnded はプレイ終了時刻を記録し、以降のAPM/PPSを最終値で固定します。既に終了済みの場合は何もしません。
+func (s *PlayerGameState) markPlayEnded(t time.Time) {
+	if s.playStartedAt.IsZero() || !s.playEndedAt.IsZero() {
+		return
+	}
+	s.playEndedAt = t
+}
+
+// playDuration はプレイ開始から（終了済みなら終了時刻、プレイ中なら now までの）経過時間を返します。
+func (s *PlayerGameState) playDuration(now time.Time) time.Duration {
+	if s.playStartedAt.IsZero() {
+		return 0
+	}
+	if !s.playEndedAt.IsZero() {
+		now = s.playEndedAt
+	}
+	return now.Sub(s.playStartedAt)
+}
+
+// CalculateAPM はプレイ開始からの1分あたりの操作数（Actions Per Minute）を返します。
+// 操作数は ApplyPlayerInput で実際に状態が変わった入力の数です。開始前は0を返します。
+func (s *PlayerGameState) CalculateAPM() int {
+	elapsed := s.playDuration(time.Now())
+	if elapsed <= 0 {
+		return 0
+	}
+	return int(float64(s.actionCount) / elapsed.Minutes())
+}
+
+// CalculatePPS はプレイ開始からの1秒あたりの設置ピース数（Pieces Per Second）を小数第2位までで返します。
+// ハードドロップ・自動落下を問わず、ピースがボードに固定された回数を数えます。開始前は0を返します。
+func (s *PlayerGameState) CalculatePPS() float64 {
+	elapsed := s.playDuration(time.Now())
+	if elapsed <= 0 {
+		return 0
+	}
+	return math.Round(float64(s.piecesPlaced)/elapsed.Seconds()*100) / 100
+}
@@ -0,0 +1,48 @@
+package tetris
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ProtocolVersion はサーバーが送るWebSocketメッセージの形式の現在のバージョンです。
+// すべてのサーバー → クライアントのメッセージ（ゲーム状態・イベント・認証の応答）は "v" フィールドにバージョンを持ちます。
+// フィールドの削除や意味の変更など後方互換が壊れる変更をする場合はバージョンを上げ、
+// 古いバージョンのクライアント向けの形式を残したまま SupportedProtocolVersions に追加してください。
+// フィールドの追加だけなら古いクライアントは無視できるため、バージョンは上げません。
+const ProtocolVersion = 1
+
+// SupportedProtocolVersions はサーバーが並行して対応しているプロトコルバージョンです（昇順）。
+var SupportedProtocolVersions = []int{1}
+
+// ErrUnsupportedProtocolVersion はクライアントが申告したプロトコルバージョンにサーバーが対応していない場合のエラーです。
+var ErrUnsupportedProtocolVersion = errors.New("対応していないプロトコルバージョンです")
+
+// NegotiateProtocolVersion は認証メッセージでクライアントが申告したバージョンから、その接続で使うバージョンを決めます。
+// バージョンを申告しない（0）クライアントはバージョニング導入前のクライアントとして、同じ形式の 1 を使います。
+//
+// Parameters:
+//   requested : 認証メッセージの "v"（省略時は0）
+// Returns:
+//   int: 接続で使うプロトコルバージョン
+//   error: 対応していないバージョンの場合は ErrUnsupportedProtocolVersion
+func NegotiateProtocolVersion(requested int) (int, error) {
+	if requested == 0 {
+		return 1, nil
+	}
+	for _, v := range SupportedProtocolVersions {
+		if v == requested {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %d（対応: %v）", ErrUnsupportedProtocolVersion, requested, SupportedProtocolVersions)
+}
+
+// checkMessageVersion はクライアントから受信したメッセージのバージョンが接続のバージョンと一致するかを確認します。
+// "v" を省略したメッセージは接続のバージョンとして扱います。
+func checkMessageVersion(client *Client, version int) error {
+	if version == 0 || version == client.ProtocolVersion {
+		return nil
+	}
+	return fmt.Errorf("%w: メッセージのバージョン %d は接続のバージョン %d と一致しません", ErrUnsupportedProtocolVersion, version, client.ProtocolVersion)
+}
@@ -0,0 +1,52 @@
+package tetris
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNegotiateProtocolVersion は申告したバージョンから接続のバージョンを決め、
+// 省略したクライアントは1、対応していないバージョンはエラーになることをテストします。
+func TestNegotiateProtocolVersion(t *testing.T) {
+	version, err := NegotiateProtocolVersion(0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, version, "バージョンを申告しないクライアントは1として扱うはず")
+
+	version, err = NegotiateProtocolVersion(ProtocolVersion)
+	assert.NoError(t, err)
+	assert.Equal(t, ProtocolVersion, version)
+
+	_, err = NegotiateProtocolVersion(ProtocolVersion + 1)
+	assert.ErrorIs(t, err, ErrUnsupportedProtocolVersion)
+}
+
+// TestCheckMessageVersion は "v" を省略したメッセージと接続と同じバージョンのメッセージだけを受け付けることをテストします。
+func TestCheckMessageVersion(t *testing.T) {
+	client := &Client{UserID: "player1", ProtocolVersion: 1}
+
+	assert.NoError(t, checkMessageVersion(client, 0))
+	assert.NoError(t, checkMessageVersion(client, 1))
+	assert.ErrorIs(t, checkMessageVersion(client, 2), ErrUnsupportedProtocolVersion)
+}
+
+// TestMessagesIncludeVersion はゲーム状態とイベントのJSONがプロトコルバージョンの "v" を持つことをテストします。
+func TestMessagesIncludeVersion(t *testing.T) {
+	session := newPlayingSession(t, "version-room")
+
+	state, err := json.Marshal(session.ToLightweight().ViewFor("player1"))
+	assert.NoError(t, err)
+	end, err := gameEndEventJSON(session)
+	assert.NoError(t, err)
+	resync, err := resyncEventJSON(session, "player1")
+	assert.NoError(t, err)
+
+	for name, message := range map[string][]byte{"state": state, "game_end": end, "resync": resync} {
+		var decoded struct {
+			Version int `json:"v"`
+		}
+		assert.NoError(t, json.Unmarshal(message, &decoded), name)
+		assert.Equal(t, ProtocolVersion, decoded.Version, name)
+	}
+}
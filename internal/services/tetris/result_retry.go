@@ -0,0 +1,209 @@
+package tetris
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+)
+
+// ゲーム結果保存のリトライ設定です。
+// DBの一時的な瞬断でスコアが失われないよう、保存に失敗したリザルトは
+// インメモリのキューに積み、指数バックオフで再保存を試みます。
+const (
+	ResultRetryMaxAttempts  = 5                      // 諦めるまでの再試行回数
+	ResultRetryBaseDelay    = 1 * time.Second        // 1回目の再試行までの待ち時間（以降2倍ずつ増加）
+	ResultRetryMaxDelay     = 30 * time.Second       // 再試行間隔の上限
+	ResultRetryPollInterval = 500 * time.Millisecond // バックグラウンドgoroutineがキューを確認する間隔
+)
+
+// pendingResult は保存に失敗し、再試行待ちのゲーム結果です。
+type pendingResult struct {
+	userID      string
+	score       int
+	flagged     bool // 異常検知で印を付けたスコアかどうか
+	playerName  string
+	key         string    // 保存の冪等キー（コミット済みなのにエラーが返った保存を再試行しても二重に記録しない）
+	attempts    int       // これまでの再試行回数
+	nextAttempt time.Time // 次に再試行する時刻
+}
+
+// resultRetryQueue は保存に失敗したゲーム結果のリトライキューです。ゼロ値で使用できます。
+type resultRetryQueue struct {
+	mu        sync.Mutex
+	items     []*pendingResult
+	succeeded int64 // 再試行で保存できた件数
+	abandoned int64 // 再試行回数の上限に達して諦めた件数
+	closed    bool  // Shutdown でフラッシュ済みか（以降に積まれたものは再試行されないため、その場で諦める）
+}
+
+// resultRetryDelay は attempts 回失敗した後、次の再試行までの待ち時間を返します。
+func resultRetryDelay(attempts int) time.Duration {
+	delay := ResultRetryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= ResultRetryMaxDelay {
+			return ResultRetryMaxDelay
+		}
+	}
+	return delay
+}
+
+// newResultKey はゲーム結果の保存ごとに一意な冪等キーを返します。
+func newResultKey() string {
+	return uuid.NewString()
+}
+
+// enqueueResultRetry は保存に失敗したスコアをリトライキューに積みます。
+// key には最初の保存で使った冪等キーを渡し、再試行でも同じキーで保存します。
+// Shutdown でキューをフラッシュした後は再試行されないため、キューに積まずに諦めます。
+func (sm *SessionManager) enqueueResultRetry(userID string, score int, flagged bool, playerName, key string) {
+	item := &pendingResult{
+		userID:      userID,
+		score:       score,
+		flagged:     flagged,
+		playerName:  playerName,
+		key:         key,
+		nextAttempt: time.Now().Add(resultRetryDelay(1)),
+	}
+
+	q := &sm.resultRetries
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		sm.abandonResult(item, errors.New("リトライキューはシャットダウン済みです"))
+		return
+	}
+	q.items = append(q.items, item)
+	pending := len(q.items)
+	q.mu.Unlock()
+
+	log.Printf("[SessionManager] Queued %s (%s) score %d for retry (pending: %d)", playerName, userID, score, pending)
+}
+
+// runResultRetryLoop はリトライキューを定期的に確認し、期限の来たリザルトを再保存するバックグラウンドループです。
+// Shutdown で quit が閉じられると終了します（残りのフラッシュは Shutdown 側で行います）。
+func (sm *SessionManager) runResultRetryLoop() {
+	ticker := time.NewTicker(ResultRetryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			sm.retryDueResults(now)
+		case <-sm.quit:
+			return
+		}
+	}
+}
+
+// retryDueResults は now の時点で再試行時刻に達したリザルトの保存を1回ずつ試みます。
+// 失敗したものはバックオフを延ばしてキューに戻し、上限回数に達したものはエラーログを出して破棄します。
+func (sm *SessionManager) retryDueResults(now time.Time) {
+	q := &sm.resultRetries
+	q.mu.Lock()
+	var due, waiting []*pendingResult
+	for _, item := range q.items {
+		if now.Before(item.nextAttempt) {
+			waiting = append(waiting, item)
+		} else {
+			due = append(due, item)
+		}
+	}
+	q.items = waiting
+	q.mu.Unlock()
+
+	// DBアクセス中はキューのロックを保持しない
+	var requeue []*pendingResult
+	for _, item := range due {
+		item.attempts++
+		ctx, cancel := gameDBContext()
+		_, err := sm.resultRepo.CreateResult(ctx, nil, item.userID, item.score, models.GameModeVersus, item.flagged, item.key)
+		cancel()
+		if err != nil {
+			// ユーザーが削除された場合など、再試行しても保存できないものはすぐに諦める
+			if item.attempts >= ResultRetryMaxAttempts || errors.Is(err, database.ErrUserNotFound) {
+				sm.abandonResult(item, err)
+				continue
+			}
+			item.nextAttempt = now.Add(resultRetryDelay(item.attempts + 1))
+			log.Printf("[SessionManager] Retry %d/%d failed for %s (%s) score: %v", item.attempts, ResultRetryMaxAttempts, item.playerName, item.userID, err)
+			requeue = append(requeue, item)
+			continue
+		}
+		sm.recordResultRetrySuccess(item)
+	}
+
+	if len(requeue) == 0 {
+		return
+	}
+	q.mu.Lock()
+	closed := q.closed
+	if !closed {
+		q.items = append(q.items, requeue...)
+	}
+	q.mu.Unlock()
+	// 再試行中に Shutdown でフラッシュされた場合は、戻しても再試行されないため諦める
+	if closed {
+		for _, item := range requeue {
+			sm.abandonResult(item, errors.New("リトライキューはシャットダウン済みです"))
+		}
+	}
+}
+
+// flushResultRetries はキューに残っている全リザルトをバックオフを待たずに1回ずつ保存します。
+// サーバー終了時のベストエフォートな処理なので、ここで失敗したものは諦めてエラーログに残します。
+// キューはこの時点で閉じ、以降に積まれようとしたリザルトはその場で諦めます（取り残されて黙って失われないようにするため）。
+func (sm *SessionManager) flushResultRetries() {
+	q := &sm.resultRetries
+	q.mu.Lock()
+	items := q.items
+	q.items = nil
+	q.closed = true
+	q.mu.Unlock()
+
+	if len(items) == 0 {
+		return
+	}
+	log.Printf("[SessionManager] Flushing %d pending game results before shutdown", len(items))
+
+	for _, item := range items {
+		item.attempts++
+		ctx, cancel := gameDBContext()
+		_, err := sm.resultRepo.CreateResult(ctx, nil, item.userID, item.score, models.GameModeVersus, item.flagged, item.key)
+		cancel()
+		if err != nil {
+			sm.abandonResult(item, err)
+			continue
+		}
+		sm.recordResultRetrySuccess(item)
+	}
+}
+
+// recordResultRetrySuccess は再試行での保存成功を記録します。
+func (sm *SessionManager) recordResultRetrySuccess(item *pendingResult) {
+	sm.resultRetries.mu.Lock()
+	sm.resultRetries.succeeded++
+	sm.resultRetries.mu.Unlock()
+	log.Printf("[SessionManager] Successfully saved %s (%s) score %d after %d retries", item.playerName, item.userID, item.score, item.attempts)
+}
+
+// abandonResult はリザルトの保存を諦め、失われたスコアをエラーログとメトリクスに記録します。
+func (sm *SessionManager) abandonResult(item *pendingResult, err error) {
+	sm.resultRetries.mu.Lock()
+	sm.resultRetries.abandoned++
+	sm.resultRetries.mu.Unlock()
+	log.Printf("[SessionManager] ERROR: giving up saving %s (%s) score %d after %d retries: %v", item.playerName, item.userID, item.score, item.attempts, err)
+}
+
+// resultRetryStats はリトライキューの待ち件数・成功件数・破棄件数を返します。
+func (sm *SessionManager) resultRetryStats() (pending int, succeeded, abandoned int64) {
+	q := &sm.resultRetries
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items), q.succeeded, q.abandoned
+}
@@ -0,0 +1,203 @@
+package tetris
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database/databasetest"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyResultRepository は最初の failures 回だけ保存に失敗するテスト用ResultRepositoryです。
+type flakyResultRepository struct {
+	databasetest.ResultRepository
+	failures int
+	calls    int
+	keys     []string        // 呼び出しごとの冪等キー
+	onCall   func(calls int) // 保存のたびに呼ばれるフック（nil可）
+}
+
+func (f *flakyResultRepository) CreateResult(ctx context.Context, tx *sql.Tx, userID string, score int, mode models.GameMode, flagged bool, key string) (*models.Result, error) {
+	f.calls++
+	f.keys = append(f.keys, key)
+	if f.onCall != nil {
+		f.onCall(f.calls)
+	}
+	if f.calls <= f.failures {
+		return nil, errors.New("connection reset")
+	}
+	return f.ResultRepository.CreateResult(ctx, tx, userID, score, mode, flagged, key)
+}
+
+// TestResultRetry_SavesAfterTransientFailure は一時的な保存失敗のあと、再試行でスコアが保存されることをテストします。
+func TestResultRetry_SavesAfterTransientFailure(t *testing.T) {
+	repo := &flakyResultRepository{failures: 2}
+	sm := newTestSessionManager()
+	sm.resultRepo = repo
+
+	err := sm.savePlayerScore("player1", 1200, false, "Player1")
+	assert.Error(t, err, "初回の保存失敗はエラーとして返るはず")
+
+	pending, _, _ := sm.resultRetryStats()
+	assert.Equal(t, 1, pending, "失敗したスコアはリトライキューに積まれるはず")
+
+	// バックオフ前は再試行しない
+	now := time.Now()
+	sm.retryDueResults(now)
+	assert.Equal(t, 1, repo.calls)
+
+	// 1回目の再試行は失敗し、バックオフが延びる
+	now = now.Add(resultRetryDelay(1))
+	sm.retryDueResults(now)
+	assert.Equal(t, 2, repo.calls)
+
+	now = now.Add(resultRetryDelay(2))
+	sm.retryDueResults(now)
+	assert.Equal(t, 1200, repo.Saved["player1"], "2回目の再試行で保存されるはず")
+
+	stats := sm.Stats()
+	assert.Equal(t, 0, stats.ResultRetriesPending)
+	assert.Equal(t, int64(1), stats.ResultRetrySuccesses)
+	assert.Equal(t, int64(0), stats.ResultSaveAbandoned)
+}
+
+// TestResultRetry_KeepsFlag は異常検知で印を付けたスコアが、再試行で保存した場合も印を付けたまま保存されることをテストします。
+func TestResultRetry_KeepsFlag(t *testing.T) {
+	repo := &flakyResultRepository{failures: 1}
+	sm := newTestSessionManager()
+	sm.resultRepo = repo
+
+	sm.savePlayerScore("player1", 9999999, true, "Player1")
+	sm.retryDueResults(time.Now().Add(resultRetryDelay(1)))
+
+	assert.Equal(t, 9999999, repo.Saved["player1"])
+	assert.True(t, repo.Flagged["player1"], "再試行でも異常検知の印を落とさないはず")
+}
+
+// TestResultRetry_AbandonsAfterMaxAttempts は再試行回数の上限に達したリザルトが破棄されることをテストします。
+func TestResultRetry_AbandonsAfterMaxAttempts(t *testing.T) {
+	repo := &flakyResultRepository{failures: 1 + ResultRetryMaxAttempts}
+	sm := newTestSessionManager()
+	sm.resultRepo = repo
+
+	sm.savePlayerScore("player1", 500, false, "Player1")
+
+	now := time.Now()
+	for i := 0; i < ResultRetryMaxAttempts; i++ {
+		now = now.Add(ResultRetryMaxDelay)
+		sm.retryDueResults(now)
+	}
+
+	pending, succeeded, abandoned := sm.resultRetryStats()
+	assert.Equal(t, 0, pending)
+	assert.Equal(t, int64(0), succeeded)
+	assert.Equal(t, int64(1), abandoned)
+	assert.Equal(t, 1+ResultRetryMaxAttempts, repo.calls, "上限を超えて再試行しないはず")
+}
+
+// TestShutdown_FlushesResultRetries はシャットダウン時にバックオフを待たず未保存のリザルトを保存することをテストします。
+func TestShutdown_FlushesResultRetries(t *testing.T) {
+	repo := &flakyResultRepository{failures: 1}
+	sm := newTestSessionManager()
+	sm.resultRepo = repo
+
+	sm.savePlayerScore("player1", 800, false, "Player1")
+	sm.Shutdown()
+
+	assert.Equal(t, 800, repo.Saved["player1"])
+	pending, succeeded, _ := sm.resultRetryStats()
+	assert.Equal(t, 0, pending)
+	assert.Equal(t, int64(1), succeeded)
+}
+
+// TestResultRetry_ReusesIdempotencyKey は再試行で最初の保存と同じ冪等キーを使い、
+// コミット済みの保存を再試行しても二重に記録されないようにすることをテストします。
+func TestResultRetry_ReusesIdempotencyKey(t *testing.T) {
+	repo := &flakyResultRepository{failures: 1}
+	sm := newTestSessionManager()
+	sm.resultRepo = repo
+
+	sm.savePlayerScore("player1", 700, false, "Player1")
+	sm.retryDueResults(time.Now().Add(resultRetryDelay(1)))
+	sm.savePlayerScore("player2", 300, false, "Player2")
+
+	assert.Len(t, repo.keys, 3)
+	assert.NotEmpty(t, repo.keys[0])
+	assert.Equal(t, repo.keys[0], repo.keys[1], "再試行は同じキーで保存するはず")
+	assert.NotEqual(t, repo.keys[0], repo.keys[2], "別の保存は別のキーになるはず")
+}
+
+// TestResultRetry_AbandonsAfterShutdown はシャットダウンでキューをフラッシュした後に保存に失敗したリザルトが、
+// キューに取り残されずにその場で破棄として記録されることをテストします。
+func TestResultRetry_AbandonsAfterShutdown(t *testing.T) {
+	repo := &flakyResultRepository{failures: 1}
+	sm := newTestSessionManager()
+	sm.resultRepo = repo
+	sm.Shutdown()
+
+	sm.savePlayerScore("player1", 400, false, "Player1")
+
+	pending, succeeded, abandoned := sm.resultRetryStats()
+	assert.Equal(t, 0, pending, "シャットダウン後はキューに積まないはず")
+	assert.Equal(t, int64(0), succeeded)
+	assert.Equal(t, int64(1), abandoned)
+}
+
+// TestResultRetry_AbandonsRequeueAfterShutdown は再試行中にシャットダウンでフラッシュされた場合、
+// 再試行に失敗したリザルトをキューに戻さずに破棄することをテストします。
+func TestResultRetry_AbandonsRequeueAfterShutdown(t *testing.T) {
+	repo := &flakyResultRepository{failures: 2}
+	sm := newTestSessionManager()
+	sm.resultRepo = repo
+
+	// 再試行のDBアクセス中に Shutdown がキューをフラッシュした状態を再現する
+	repo.onCall = func(call int) {
+		if call == 2 {
+			sm.flushResultRetries()
+		}
+	}
+
+	sm.savePlayerScore("player1", 400, false, "Player1")
+	sm.retryDueResults(time.Now().Add(resultRetryDelay(1)))
+
+	pending, succeeded, abandoned := sm.resultRetryStats()
+	assert.Equal(t, 0, pending, "フラッシュ後はキューに戻さないはず")
+	assert.Equal(t, int64(0), succeeded)
+	assert.Equal(t, int64(1), abandoned)
+}
+
+// TestResultRetryDelay は再試行間隔が指数的に伸び、上限で頭打ちになることをテストします。
+func TestResultRetryDelay(t *testing.T) {
+	assert.Equal(t, ResultRetryBaseDelay, resultRetryDelay(1))
+	assert.Equal(t, 2*ResultRetryBaseDelay, resultRetryDelay(2))
+	assert.Equal(t, 4*ResultRetryBaseDelay, resultRetryDelay(3))
+	assert.Equal(t, ResultRetryMaxDelay, resultRetryDelay(20))
+}
+
+// missingUserResultRepository は常に ErrUserNotFound を返すテスト用ResultRepositoryです。
+type missingUserResultRepository struct {
+	databasetest.ResultRepository
+}
+
+func (f *missingUserResultRepository) CreateResult(ctx context.Context, tx *sql.Tx, userID string, score int, mode models.GameMode, flagged bool, key string) (*models.Result, error) {
+	return nil, fmt.Errorf("%w: ユーザーID %s", database.ErrUserNotFound, userID)
+}
+
+// TestSavePlayerScore_UserNotFound は存在しないユーザーのスコアが ErrUserNotFound として返り、
+// 再試行しても保存できないためリトライキューに積まれないことをテストします。
+func TestSavePlayerScore_UserNotFound(t *testing.T) {
+	sm := newTestSessionManager()
+	sm.resultRepo = &missingUserResultRepository{}
+
+	err := sm.savePlayerScore("guest-1", 300, false, "Player1")
+	assert.ErrorIs(t, err, database.ErrUserNotFound)
+
+	pending, _, _ := sm.resultRetryStats()
+	assert.Equal(t, 0, pending)
+}
@@ -0,0 +1,108 @@
+package tetris
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ゲーム結果の異常検知のしきい値のデフォルト値です。
+// 偽陽性でまっとうなプレイヤーに印を付けないよう、現行のスコア計算で理論上届く値より十分に大きく取っています。
+//
+//   1ラインあたり: 草スコア上限（tetris.MaxContributionBlockScore × ボード幅 = 10000）+ テトリスのボーナス（800 × レベル × 1.5）に余裕を持たせた値
+//   1秒あたり    : 毎秒2ラインを草スコア上限のブロックで消し続けても届かない値（プロのプレイヤーでも毎秒1ライン程度）
+const (
+	DefaultResultMaxScorePerLine   = 30000            // 消したライン1本あたりのスコアの上限
+	DefaultResultMaxScorePerSecond = 30000            // 経過時間1秒あたりのスコアの上限
+	DefaultResultMaxLinesPerSecond = 5                // 経過時間1秒あたりのライン数の上限
+	DefaultResultScoreAllowance    = 5000             // ライン消去以外（ソフト・ハードドロップ）で得られるスコアとして常に許容する分
+	DefaultResultGracePeriod       = 10 * time.Second // 開始直後に時間あたりの値が跳ねないよう、経過時間に足す猶予
+)
+
+// ResultValidationThresholds はゲーム結果の異常検知のしきい値です。
+// 環境変数 RESULT_MAX_SCORE_PER_LINE・RESULT_MAX_SCORE_PER_SECOND（いずれも1以上の整数）で上書きできます。
+type ResultValidationThresholds struct {
+	MaxScorePerLine   int
+	MaxScorePerSecond int
+	MaxLinesPerSecond int
+	ScoreAllowance    int
+	GracePeriod       time.Duration
+}
+
+// resultValidationThresholds は実際に使用するしきい値です。
+var resultValidationThresholds = loadResultValidationThresholds()
+
+// loadResultValidationThresholds は環境変数からしきい値を読み込みます。不正な値の場合はデフォルト値を使います。
+func loadResultValidationThresholds() ResultValidationThresholds {
+	thresholds := ResultValidationThresholds{
+		MaxScorePerLine:   DefaultResultMaxScorePerLine,
+		MaxScorePerSecond: DefaultResultMaxScorePerSecond,
+		MaxLinesPerSecond: DefaultResultMaxLinesPerSecond,
+		ScoreAllowance:    DefaultResultScoreAllowance,
+		GracePeriod:       DefaultResultGracePeriod,
+	}
+	for name, target := range map[string]*int{
+		"RESULT_MAX_SCORE_PER_LINE":   &thresholds.MaxScorePerLine,
+		"RESULT_MAX_SCORE_PER_SECOND": &thresholds.MaxScorePerSecond,
+	} {
+		env := os.Getenv(name)
+		if env == "" {
+			continue
+		}
+		n, err := strconv.Atoi(env)
+		if err != nil || n < 1 {
+			log.Printf("[WARN] Invalid %s %q, using default %d", name, env, *target)
+			continue
+		}
+		*target = n
+	}
+	return thresholds
+}
+
+// resultAnomalies はスコアとライン数・経過時間の関係が物理的に妥当かを検証し、見つかった異常の説明を返します。
+// ハンディキャップで加算した初期スコアはプレイで得たものではないため、検証の対象から除きます。
+//
+// Parameters:
+//   state      : 終了時のプレイヤーの状態
+//   elapsed    : ゲーム開始から終了までの経過時間（一時停止していた時間を除く）
+//   thresholds : 異常とみなすしきい値
+// Returns:
+//   []string: 異常の説明（妥当な場合は空）
+func resultAnomalies(state *PlayerGameState, elapsed time.Duration, thresholds ResultValidationThresholds) []string {
+	score := state.earnedScore()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	seconds := (elapsed + thresholds.GracePeriod).Seconds()
+
+	var anomalies []string
+	if maxScore := state.LinesCleared*thresholds.MaxScorePerLine + thresholds.ScoreAllowance; score > maxScore {
+		anomalies = append(anomalies, fmt.Sprintf("score %d exceeds %d for %d lines", score, maxScore, state.LinesCleared))
+	}
+	if maxScore := int(seconds*float64(thresholds.MaxScorePerSecond)) + thresholds.ScoreAllowance; score > maxScore {
+		anomalies = append(anomalies, fmt.Sprintf("score %d exceeds %d in %v", score, maxScore, elapsed.Round(time.Second)))
+	}
+	if maxLines := int(seconds * float64(thresholds.MaxLinesPerSecond)); state.LinesCleared > maxLines {
+		anomalies = append(anomalies, fmt.Sprintf("%d lines exceeds %d in %v", state.LinesCleared, maxLines, elapsed.Round(time.Second)))
+	}
+	return anomalies
+}
+
+// validateGameResult はゲーム終了時のスコアがライン数・経過時間に対して妥当かを返します。
+// 妥当でない場合は理由をログに残します。結果は破棄せず、呼び出し側でランキングに flagged の印を付けて保存します。
+//
+// Parameters:
+//   state   : 終了時のプレイヤーの状態
+//   elapsed : ゲーム開始から終了までの経過時間（一時停止していた時間を除く）
+// Returns:
+//   bool: 妥当な場合は true、異常を検出した場合は false
+func validateGameResult(state *PlayerGameState, elapsed time.Duration) bool {
+	anomalies := resultAnomalies(state, elapsed, resultValidationThresholds)
+	if len(anomalies) == 0 {
+		return true
+	}
+	log.Printf("[SessionManager] WARN: flagging suspicious result of %s: %v", state.UserID, anomalies)
+	return false
+}
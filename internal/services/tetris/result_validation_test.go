@@ -0,0 +1,66 @@
+package tetris
+
+import (
+	"testing"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database/databasetest"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestResultAnomalies はライン数・経過時間に対して高すぎるスコアや多すぎるライン数を異常として検出し、
+// ハンディキャップの初期スコアは検証の対象から除くことをテストします。
+func TestResultAnomalies(t *testing.T) {
+	thresholds := ResultValidationThresholds{
+		MaxScorePerLine:   1000,
+		MaxScorePerSecond: 500,
+		MaxLinesPerSecond: 1,
+		ScoreAllowance:    100,
+		GracePeriod:       10 * time.Second,
+	}
+	tests := []struct {
+		name       string
+		score      int
+		lines      int
+		bonus      int
+		elapsed    time.Duration
+		wantIssues int
+	}{
+		{"妥当なスコア", 10000, 10, 0, 100 * time.Second, 0},
+		{"ライン消去なしのドロップ点", 100, 0, 0, 100 * time.Second, 0},
+		{"ライン数に対して高すぎる", 10101, 10, 0, 100 * time.Second, 1},
+		{"経過時間に対して高すぎる", 10000, 10, 0, 5 * time.Second, 1},
+		{"経過時間に対してライン数が多すぎる", 20000, 20, 0, 5 * time.Second, 2},
+		{"ハンディキャップの初期スコアは除く", 12000, 10, 2000, 100 * time.Second, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := NewPlayerGameState("player1", &models.Deck{ID: "deck-1"})
+			state.Score = tt.score
+			state.LinesCleared = tt.lines
+			state.handicap.ScoreBonus = tt.bonus
+
+			assert.Len(t, resultAnomalies(state, tt.elapsed, thresholds), tt.wantIssues)
+		})
+	}
+}
+
+// TestEndGameSession_FlagsSuspiciousResult は異常なスコアもランキングに保存し、flagged の印を付けることをテストします。
+func TestEndGameSession_FlagsSuspiciousResult(t *testing.T) {
+	sm := newTestSessionManager()
+	repo := &databasetest.ResultRepository{}
+	sm.resultRepo = repo
+	session := newPlayingSession(t, "flag-room")
+	session.Player1.Score = 300
+	session.Player2.Score = 9999999 // ライン消去なしでは届かないスコア
+	sm.sessions["flag-room"] = session
+
+	sm.endGameSession("flag-room", EndReasonTimeUp, "player2")
+
+	assert.Equal(t, 9999999, repo.Saved["player2"], "異常なスコアも保存するはず")
+	assert.True(t, repo.Flagged["player2"])
+	assert.False(t, repo.Flagged["player1"])
+	assert.Equal(t, models.GameModeVersus, repo.Modes["player1"], "対戦のスコアは versus として保存するはず")
+}
@@ -0,0 +1,115 @@
+package tetris
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
+)
+
+// EventResync は途中参加・再接続したクライアントや、ズレを検知したクライアントへ送る完全な状態スナップショットの種類です。
+const EventResync = "resync"
+
+// MessageRequestResync はクライアントが完全な状態スナップショットを要求するときに送るメッセージの種類です。
+// 例: {"type":"request_resync"}
+const MessageRequestResync = "request_resync"
+
+// ResyncQueuePreview は resync で返すピースキュー（NextPiece の次以降）の先頭の最大個数です。
+// 実際の個数はルームの GameRules.NextPreviewCount で絞られます。
+const ResyncQueuePreview = 5
+
+// ResyncMinInterval は同じクライアントからの resync 要求に応答する最小間隔です。
+// 完全な状態スナップショットの作成はゲーム状態のロックを取るため、連打されても一定間隔に1回だけ応答します。
+const ResyncMinInterval = 500 * time.Millisecond
+
+// ErrClientNotConnected は指定されたユーザーが接続していない場合のエラーです。
+var ErrClientNotConnected = errors.New("指定されたユーザーは接続していません")
+
+// ResyncEvent はクライアントが内部状態を完全に再構築するためのスナップショットです。
+// 毎秒のゲーム状態に加えて、要求したプレイヤー本人のピースキューとホールド可否を含みます。
+type ResyncEvent struct {
+	Type      string                `json:"type"`
+	Version   int                   `json:"v"`          // プロトコルバージョン（ProtocolVersion）
+	State     *LightweightGameState `json:"state"`      // ゲーム状態（本人はボード・CurrentPiece・NextPiece・HeldPiece を含む全情報、相手は OpponentView）
+	NextQueue []tetris.PieceType    `json:"next_queue"` // NextPiece の次に出るピースの種類（ルールのプレビュー数に応じて最大 ResyncQueuePreview 個）
+	CanHold   bool                  `json:"can_hold"`   // 現在のピースでホールドが使えるかどうか
+}
+
+// resyncEventJSON は userID のプレイヤー向けの resync イベントをゲーム状態ロックの下でシリアライズします。
+func resyncEventJSON(session *GameSession, userID string) ([]byte, error) {
+	session.gameMu.Lock()
+	defer session.gameMu.Unlock()
+
+	event := ResyncEvent{
+		Type:      EventResync,
+		Version:   ProtocolVersion,
+		State:     session.ToLightweight().ViewFor(userID),
+		NextQueue: []tetris.PieceType{},
+	}
+
+	var player *PlayerGameState
+	if session.Player1 != nil && session.Player1.UserID == userID {
+		player = session.Player1
+	} else if session.Player2 != nil && session.Player2.UserID == userID {
+		player = session.Player2
+	}
+	if player != nil {
+		n := len(player.pieceQueue)
+		if limit := session.nextQueuePreview(); n > limit {
+			n = limit
+		}
+		event.NextQueue = append(event.NextQueue, player.pieceQueue[:n]...)
+		event.CanHold = !player.holdDisabled && !player.hasUsedHold && !player.IsGameOver
+	}
+
+	return json.Marshal(event)
+}
+
+// SendFullResync は指定されたユーザーに、合言葉のセッションの完全な状態スナップショットを送信します。
+// 毎秒の状態送信の取りこぼしでズレたクライアントが一度で追いつけるよう、取りこぼさない経路（sendReliable）で送ります。
+//
+// Parameters:
+//   userID   : 送信先のユーザーID
+//   passcode : セッションの合言葉
+func (sm *SessionManager) SendFullResync(userID, passcode string) error {
+	sm.mu.RLock()
+	session, ok := sm.sessions[passcode]
+	client, clientOk := sm.clients[userID]
+	sm.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("passcode %s: %w", passcode, ErrSessionNotFound)
+	}
+	if !clientOk || client.RoomID != passcode {
+		return fmt.Errorf("user %s: %w", userID, ErrClientNotConnected)
+	}
+
+	message, err := resyncEventJSON(session, userID)
+	if err != nil {
+		return fmt.Errorf("resync イベントのシリアライズに失敗しました: %w", err)
+	}
+	sendReliable(client, message)
+	return nil
+}
+
+// handleResyncRequest はクライアントからの resync 要求に応答します。
+func (sm *SessionManager) handleResyncRequest(client *Client) {
+	if err := sm.SendFullResync(client.UserID, client.RoomID); err != nil {
+		log.Printf("[SessionManager] Failed to send resync to %s: %v", client.UserID, err)
+	}
+}
+
+// allowResync はクライアントの resync 要求に応答してよいかを判定し、応答する場合は時刻を記録します。
+// 前回応答してから ResyncMinInterval 経っていない要求は捨てます。readPump のゴルーチンからのみ呼び出されるため、ロックなしで更新します。
+func (c *Client) allowResync(now time.Time) bool {
+	if !c.lastResync.IsZero() && now.Sub(c.lastResync) < ResyncMinInterval {
+		slog.Debug("[SessionManager] Dropping resync request (too frequent)", "user_id", c.UserID, "passcode", c.RoomID)
+		return false
+	}
+	c.lastResync = now
+	return true
+}
@@ -0,0 +1,105 @@
+package tetris
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MaxNextPreviewCount はnextプレビューに表示できるピース数の上限です（NextPiece と resync のキュー先頭分）。
+const MaxNextPreviewCount = ResyncQueuePreview + 1
+
+// ルームのルールで指定できる制限時間（秒）の範囲です。
+const (
+	MinTimeLimitSeconds = 30
+	MaxTimeLimitSeconds = 600
+)
+
+// ピース順（7-bag）の乱数シードの決め方です。
+const (
+	SeedModeRandom = "random" // プレイヤーごとに別々のシード（ピース順は各自で異なる）
+	SeedModeShared = "shared" // ルームで共通のシード（両プレイヤーに同じ順番でピースが出る）
+)
+
+// ErrInvalidGameRules はルーム作成時に指定されたルール設定が不正な場合のエラーです。
+var ErrInvalidGameRules = errors.New("ルール設定が不正です")
+
+// GameRules はルームごとに切り替えられるゲームルールの設定です。
+// ルーム作成時に決まり、参加者全員に同じルールが適用されます。
+// 将来のルールバリエーション（モード）はここに項目を追加して表現します。
+type GameRules struct {
+	AllowHold        bool   `json:"allow_hold"`         // ホールドを使えるかどうか（false の場合 hold 操作は無視される）
+	ShowGhost        bool   `json:"show_ghost"`         // ゴーストピース（落下予測位置）を表示するかどうか（クライアント側で描画）
+	NextPreviewCount int    `json:"next_preview_count"` // nextプレビューに表示するピース数（0でNextPieceも非表示）
+	TimeLimitSeconds int    `json:"time_limit_seconds"` // 制限時間（秒、MinTimeLimitSeconds〜MaxTimeLimitSeconds）
+	AllowGarbage     bool   `json:"allow_garbage"`      // ライン消去の攻撃で相手にお邪魔ラインを送るかどうか
+	SeedMode         string `json:"seed_mode"`          // ピース順の乱数シードの決め方（SeedModeRandom / SeedModeShared）
+	Handicap         bool   `json:"handicap"`           // デッキの total_score の差に応じて弱い側にハンディキャップを与えるかどうか（handicap.go）
+}
+
+// DefaultGameRules は通常モードのルール（従来の挙動）を返します。
+func DefaultGameRules() GameRules {
+	return GameRules{
+		AllowHold:        true,
+		ShowGhost:        true,
+		NextPreviewCount: MaxNextPreviewCount,
+		TimeLimitSeconds: int(GameTimeLimit / time.Second),
+		AllowGarbage:     true,
+		SeedMode:         SeedModeRandom,
+	}
+}
+
+// Validate はルール設定が有効な範囲に収まっているかを検証します。
+func (r GameRules) Validate() error {
+	if r.NextPreviewCount < 0 || r.NextPreviewCount > MaxNextPreviewCount {
+		return fmt.Errorf("next_preview_count は0以上%d以下で指定してください (got %d): %w", MaxNextPreviewCount, r.NextPreviewCount, ErrInvalidGameRules)
+	}
+	if r.TimeLimitSeconds < MinTimeLimitSeconds || r.TimeLimitSeconds > MaxTimeLimitSeconds {
+		return fmt.Errorf("time_limit_seconds は%d以上%d以下で指定してください (got %d): %w", MinTimeLimitSeconds, MaxTimeLimitSeconds, r.TimeLimitSeconds, ErrInvalidGameRules)
+	}
+	if r.SeedMode != SeedModeRandom && r.SeedMode != SeedModeShared {
+		return fmt.Errorf("seed_mode は %q または %q で指定してください (got %q): %w", SeedModeRandom, SeedModeShared, r.SeedMode, ErrInvalidGameRules)
+	}
+	return nil
+}
+
+// SetRules はセッションのルールを設定し、参加済みのプレイヤーの状態に反映します。
+// プレイ開始前（ルーム作成時）に呼び出してください。ルールは以降変更されず、後から参加したプレイヤーにも同じルールが適用されます。
+func (gs *GameSession) SetRules(rules GameRules) {
+	gs.GameRules = rules
+	if rules.TimeLimitSeconds > 0 {
+		gs.TimeLimit = time.Duration(rules.TimeLimitSeconds) * time.Second
+	}
+	if rules.SeedMode == SeedModeShared && gs.pieceSeed == 0 {
+		gs.pieceSeed = time.Now().UnixNano()
+	}
+	gs.applyRulesToPlayer(gs.Player1)
+	gs.applyRulesToPlayer(gs.Player2)
+	gs.applyHandicap()
+}
+
+// applyRulesToPlayer はプレイヤー単位で判定が必要なルールをプレイヤーの状態に反映します。
+// ApplyPlayerInput はセッションを参照しないため、ホールド可否はプレイヤーの状態に持たせます。
+// 共通シードのルールでは、ピース順をルームのシードで最初から作り直します（プレイ開始前のみ呼び出してください）。
+func (gs *GameSession) applyRulesToPlayer(state *PlayerGameState) {
+	if state == nil {
+		return
+	}
+	state.holdDisabled = !gs.AllowHold
+	if gs.SeedMode == SeedModeShared {
+		state.reseedPieceQueue(gs.pieceSeed)
+	}
+}
+
+// nextQueuePreview は resync で返すピースキューの先頭の個数を返します。
+// NextPreviewCount は NextPiece を含む数なので、キューからはその残りを返します。
+func (r GameRules) nextQueuePreview() int {
+	n := r.NextPreviewCount - 1
+	if n < 0 {
+		return 0
+	}
+	if n > ResyncQueuePreview {
+		return ResyncQueuePreview
+	}
+	return n
+}
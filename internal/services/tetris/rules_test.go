@@ -0,0 +1,144 @@
+package tetris
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSetRules_DisallowHold はホールド禁止ルールで hold 操作が無視されることをテストします。
+// ルール設定後に参加したプレイヤー2にも同じルールが適用されることも確認します。
+func TestSetRules_DisallowHold(t *testing.T) {
+	session, err := NewGameSession("no-hold", "player1", &models.Deck{ID: "deck-1"}, nil)
+	assert.NoError(t, err)
+	assert.True(t, session.AllowHold, "デフォルトではホールドが使えるはず")
+
+	rules := DefaultGameRules()
+	rules.AllowHold = false
+	session.SetRules(rules)
+	session.SetPlayer2("player2", &models.Deck{ID: "deck-2"}, nil)
+
+	for _, player := range []*PlayerGameState{session.Player1, session.Player2} {
+		current := player.CurrentPiece
+		moved := ApplyPlayerInput(player, "hold")
+
+		assert.False(t, moved, "ホールド禁止ルールでは hold は無視されるはず")
+		assert.Nil(t, player.HeldPiece)
+		assert.Same(t, current, player.CurrentPiece, "現在のピースは変わらないはず")
+	}
+}
+
+// TestGameRulesValidate はnextプレビュー数の範囲外の値が拒否されることをテストします。
+func TestGameRulesValidate(t *testing.T) {
+	rules := DefaultGameRules()
+	assert.NoError(t, rules.Validate())
+
+	rules.NextPreviewCount = 0
+	assert.NoError(t, rules.Validate())
+
+	rules.NextPreviewCount = MaxNextPreviewCount + 1
+	assert.ErrorIs(t, rules.Validate(), ErrInvalidGameRules)
+
+	rules.NextPreviewCount = -1
+	assert.ErrorIs(t, rules.Validate(), ErrInvalidGameRules)
+}
+
+// TestToLightweightRules は軽量状態にルールが含まれ、プレビュー0のルールでは NextPiece を送らないことをテストします。
+// ルールの制限時間がセッションの制限時間に反映されることも確認します。
+func TestToLightweightRules(t *testing.T) {
+	session := newPlayingSession(t, "rules-room")
+	session.SetRules(GameRules{AllowHold: false, ShowGhost: false, NextPreviewCount: 0, TimeLimitSeconds: 180, SeedMode: SeedModeRandom})
+
+	state := session.ToLightweight()
+
+	data, err := json.Marshal(state)
+	assert.NoError(t, err)
+	var decoded map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, map[string]interface{}{
+		"allow_hold":         false,
+		"show_ghost":         false,
+		"next_preview_count": float64(0),
+		"time_limit_seconds": float64(180),
+		"allow_garbage":      false,
+		"seed_mode":          SeedModeRandom,
+		"handicap":           false,
+	}, decoded["rules"])
+	assert.Equal(t, 180, state.TimeLimit)
+	assert.Nil(t, state.Player1.NextPiece)
+	assert.Nil(t, state.Player2.NextPiece)
+}
+
+// TestResyncRespectsRules は resync のキュー長とホールド可否がルームのルールに従うことをテストします。
+func TestResyncRespectsRules(t *testing.T) {
+	session := newPlayingSession(t, "rules-resync")
+	session.SetRules(GameRules{AllowHold: false, NextPreviewCount: 3})
+
+	data, err := resyncEventJSON(session, "player1")
+	assert.NoError(t, err)
+	var event ResyncEvent
+	assert.NoError(t, json.Unmarshal(data, &event))
+
+	assert.Len(t, event.NextQueue, 2, "NextPiece を含めて3個なので、キューからは2個のはず")
+	assert.False(t, event.CanHold, "ホールド禁止ルールでは can_hold は false のはず")
+}
+
+// TestJoinRoomWithRules_InvalidRules は不正なルールでのルーム作成がセッションを作らずに拒否されることをテストします。
+func TestJoinRoomWithRules_InvalidRules(t *testing.T) {
+	sm := newTestSessionManager()
+
+	_, _, err := sm.JoinRoomWithRules("bad-rules", "player1", "deck-1", GameRules{NextPreviewCount: -1})
+
+	assert.ErrorIs(t, err, ErrInvalidGameRules)
+	_, exists := sm.sessions["bad-rules"]
+	assert.False(t, exists)
+}
+
+// TestGameRulesValidate_TimeLimitAndSeedMode は制限時間の範囲外の値と未知のシードモードが拒否されることをテストします。
+func TestGameRulesValidate_TimeLimitAndSeedMode(t *testing.T) {
+	for _, modify := range []func(*GameRules){
+		func(r *GameRules) { r.TimeLimitSeconds = MinTimeLimitSeconds - 1 },
+		func(r *GameRules) { r.TimeLimitSeconds = MaxTimeLimitSeconds + 1 },
+		func(r *GameRules) { r.SeedMode = "fixed" },
+	} {
+		rules := DefaultGameRules()
+		modify(&rules)
+		assert.ErrorIs(t, rules.Validate(), ErrInvalidGameRules)
+	}
+}
+
+// TestSetRules_SharedSeed は共通シードのルールで、後から参加したプレイヤーにも同じ順番でピースが出ることをテストします。
+func TestSetRules_SharedSeed(t *testing.T) {
+	session, err := NewGameSession("shared-seed", "player1", &models.Deck{ID: "deck-1"}, nil)
+	assert.NoError(t, err)
+	rules := DefaultGameRules()
+	rules.SeedMode = SeedModeShared
+	session.SetRules(rules)
+	session.SetPlayer2("player2", &models.Deck{ID: "deck-2"}, nil)
+
+	sequence := func(state *PlayerGameState) []tetris.PieceType {
+		types := []tetris.PieceType{state.CurrentPiece.Type, state.NextPiece.Type}
+		for i := 0; i < 14; i++ {
+			types = append(types, state.GetNextPieceFromQueue().Type)
+		}
+		return types
+	}
+	assert.Equal(t, sequence(session.Player1), sequence(session.Player2))
+}
+
+// TestDeliverGarbage_Disabled は攻撃なしのルールでお邪魔ラインが相手に届かないことをテストします。
+func TestDeliverGarbage_Disabled(t *testing.T) {
+	session := newPlayingSession(t, "no-garbage")
+	rules := DefaultGameRules()
+	rules.AllowGarbage = false
+	session.SetRules(rules)
+
+	session.Player1.outgoingGarbage = 4
+	session.deliverGarbage()
+
+	assert.Zero(t, session.Player1.outgoingGarbage)
+	assert.Zero(t, session.Player2.PendingGarbage())
+}
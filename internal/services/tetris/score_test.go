@@ -0,0 +1,152 @@
+package tetris
+
+import (
+	"testing"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCalculateScore はライン数・T-Spin・パーフェクトクリア・コンボ・B2B・レベルの組み合わせで CalculateScore の期待スコアを検証します。
+// スコア計算は対戦の公平性の根幹なので、仕様（CalculateScore のコメントの式）を手で計算した期待値で固定します。
+func TestCalculateScore(t *testing.T) {
+	tests := []struct {
+		name         string
+		lines        int
+		level        int
+		combo        int
+		tSpin        bool
+		perfectClear bool
+		b2b          bool
+		want         int
+	}{
+		{"0ラインは0点", 0, 5, 3, false, false, true, 0},
+		{"ラインを消さないT-Spinは0点", 0, 1, 0, true, false, false, 0},
+
+		{"Single レベル1", 1, 1, 0, false, false, false, 100},
+		{"Double レベル1", 2, 1, 0, false, false, false, 300},
+		{"Triple レベル1", 3, 1, 0, false, false, false, 500},
+		{"Tetris レベル1", 4, 1, 0, false, false, false, 800},
+		{"Single レベル2", 1, 2, 0, false, false, false, 200},
+		{"Tetris レベル5", 4, 5, 0, false, false, false, 4000},
+		{"Tetris 最大レベル", 4, MaxLevel, 0, false, false, false, 16000},
+
+		{"2連続目はまだコンボボーナスなし", 1, 1, 1, false, false, false, 100},
+		{"3連続目からコンボボーナス", 1, 1, 2, false, false, false, 150},
+		{"Single 5コンボ", 1, 1, 5, false, false, false, 300},
+		{"Tetris 5コンボ レベル3", 4, 3, 5, false, false, false, 3000},
+
+		{"B2B中の Single は倍率なし", 1, 2, 0, false, false, true, 200},
+		{"B2B中の Triple は倍率なし", 3, 1, 2, false, false, true, 550},
+		{"Tetris B2B", 4, 1, 0, false, false, true, 1200},
+		{"Tetris B2B 5コンボ レベル3", 4, 3, 5, false, false, true, 4500},
+
+		{"T-Spin Single", 1, 1, 0, true, false, false, 800},
+		{"T-Spin Double", 2, 1, 0, true, false, false, 1200},
+		{"T-Spin Triple レベル2", 3, 2, 0, true, false, false, 3200},
+		{"T-Spin Double B2B", 2, 1, 0, true, false, true, 1800},
+		{"T-Spin Single B2B 3コンボ目", 1, 1, 2, true, false, true, 1275},
+
+		{"Single のパーフェクトクリア", 1, 1, 0, false, true, false, 900},
+		{"Double のパーフェクトクリア レベル2", 2, 2, 0, false, true, false, 3000},
+		{"Tetris のパーフェクトクリア", 4, 1, 0, false, true, false, 2800},
+		{"Tetris B2B のパーフェクトクリアは加点にB2B倍率をかけない", 4, 1, 3, false, true, true, 3350},
+		{"T-Spin Double のパーフェクトクリア", 2, 1, 0, true, true, false, 2400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CalculateScore(tt.lines, tt.level, tt.combo, tt.tSpin, tt.perfectClear, tt.b2b))
+		})
+	}
+}
+
+// TestHandlePieceLock_ScoreBonus は handlePieceLock がレベルアップ前のレベル・加算前のコンボ数・直前のB2Bで
+// CalculateScore を呼び、盤面が空になるテトリスにパーフェクトクリアの加点を与えることをテストします。
+func TestHandlePieceLock_ScoreBonus(t *testing.T) {
+	state := NewPlayerGameState("test-user", &models.Deck{ID: "mock-deck-id"})
+	state.ContributionScores = map[string]int{} // 草の濃さのスコアが無いブロックは1マス10点（Board.ClearLines の仮のスコア）
+
+	// 下4段を左端の1列だけ空けて埋め、縦向きのIミノを着地位置まで下ろしておく（ハードドロップの加点を0にする）
+	for y := tetris.BoardTotalHeight - 4; y < tetris.BoardTotalHeight; y++ {
+		for x := 1; x < tetris.BoardWidth; x++ {
+			state.Board[y][x] = tetris.BlockL
+		}
+	}
+	state.CurrentPiece = &tetris.Piece{Type: tetris.TypeI, X: -2, Y: 0, Rotation: 90}
+	for !state.Board.HasCollision(state.CurrentPiece, 0, 1) {
+		state.CurrentPiece.Y++
+	}
+	state.LinesCleared = 4 // 4ライン消去でレベル2に上がるが、ボーナスはレベル1で計算する
+	state.Level = 1
+	state.ConsecutiveClears = 3 // 直前まで3回連続で消している
+	state.BackToBack = true
+	state.Score = 0
+
+	ApplyPlayerInput(state, ActionHardDrop)
+
+	lineClearScore := 4 * tetris.BoardWidth * 10
+	assert.Equal(t, lineClearScore+CalculateScore(4, 1, 3, false, true, true), state.Score)
+	assert.Equal(t, lineClearScore+3350, state.Score, "ボーナスは (800 + 50×2) × 1.5 + 2000 のはず")
+	assert.Equal(t, 2, state.Level)
+	assert.Equal(t, 4, state.ConsecutiveClears)
+	assert.True(t, state.BackToBack)
+}
+
+// setupTetrisReady は下4段を左端の1列だけ空けて埋め、縦向きのIミノを着地位置まで下ろしておきます。
+// 消去後に盤面が空にならないよう（パーフェクトクリアにならないよう）、5段目にも1マス置いておきます。
+func setupTetrisReady(state *PlayerGameState) {
+	state.Board = tetris.NewBoard()
+	for y := tetris.BoardTotalHeight - 4; y < tetris.BoardTotalHeight; y++ {
+		for x := 1; x < tetris.BoardWidth; x++ {
+			state.Board[y][x] = tetris.BlockL
+		}
+	}
+	state.Board[tetris.BoardTotalHeight-5][tetris.BoardWidth-1] = tetris.BlockL
+	state.CurrentPiece = &tetris.Piece{Type: tetris.TypeI, X: -2, Y: 0, Rotation: 90}
+	for !state.Board.HasCollision(state.CurrentPiece, 0, 1) {
+		state.CurrentPiece.Y++
+	}
+}
+
+// TestHandlePieceLock_BackToBackSurvivesNonClearingLock はテトリスの後にラインを消さない固定を挟んでも
+// B2Bが途切れず、次のテトリスでスコアと攻撃力のB2Bボーナスが付くことをテストします。
+func TestHandlePieceLock_BackToBackSurvivesNonClearingLock(t *testing.T) {
+	state := NewPlayerGameState("test-user", &models.Deck{ID: "mock-deck-id"})
+	state.ContributionScores = map[string]int{}
+
+	setupTetrisReady(state)
+	ApplyPlayerInput(state, ActionHardDrop)
+	assert.True(t, state.BackToBack, "テトリスでB2Bがセットされるはず")
+
+	// ラインを消さない固定（空いている右側にOミノを置く）
+	state.CurrentPiece = &tetris.Piece{Type: tetris.TypeO, X: 3, Y: 0}
+	ApplyPlayerInput(state, ActionHardDrop)
+	assert.Equal(t, 0, state.ConsecutiveClears, "ラインを消さない固定でコンボは途切れるはず")
+	assert.True(t, state.BackToBack, "ラインを消さない固定ではB2Bは途切れないはず")
+
+	setupTetrisReady(state)
+	level := state.Level
+	state.Score = 0
+	state.outgoingGarbage = 0
+	ApplyPlayerInput(state, ActionHardDrop)
+
+	lineClearScore := 4 * tetris.BoardWidth * 10
+	assert.Equal(t, lineClearScore+CalculateScore(4, level, 0, false, false, true), state.Score, "2回目のテトリスにB2Bボーナスが付くはず")
+	assert.Equal(t, CalculateAttack(ClearTetris, state.ConsecutiveClears, true), state.outgoingGarbage)
+	assert.Equal(t, CalculateAttack(ClearTetris, 1, false)+BackToBackAttackBonus, state.outgoingGarbage)
+
+	// 難しくない消去ではB2Bが途切れる
+	state.BackToBack = true
+	state.Board = tetris.NewBoard()
+	for x := 1; x < tetris.BoardWidth; x++ {
+		state.Board[tetris.BoardTotalHeight-1][x] = tetris.BlockL
+	}
+	state.Board[tetris.BoardTotalHeight-2][tetris.BoardWidth-1] = tetris.BlockL
+	state.CurrentPiece = &tetris.Piece{Type: tetris.TypeI, X: -2, Y: 0, Rotation: 90}
+	for !state.Board.HasCollision(state.CurrentPiece, 0, 1) {
+		state.CurrentPiece.Y++
+	}
+	ApplyPlayerInput(state, ActionHardDrop)
+	assert.False(t, state.BackToBack, "シングルでB2Bは途切れるはず")
+}
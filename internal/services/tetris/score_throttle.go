@@ -0,0 +1,42 @@
+package tetris
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// DefaultScoreUpdateInterval は操作に伴う CurrentPieceScores 更新の最小間隔のデフォルト値です。
+// 横移動・回転の連打時はこの間隔より短い更新を間引き、落下・ホールド・固定時は常に更新します。
+const DefaultScoreUpdateInterval = 50 * time.Millisecond
+
+// scoreUpdateInterval は実際に使用する間引き間隔です。
+// 環境変数 SCORE_UPDATE_INTERVAL_MS（ミリ秒、0で間引きなし）で上書きできます。
+var scoreUpdateInterval = loadScoreUpdateInterval()
+
+// loadScoreUpdateInterval は環境変数から間引き間隔を読み込みます。不正な値の場合はデフォルト値を使います。
+func loadScoreUpdateInterval() time.Duration {
+	env := os.Getenv("SCORE_UPDATE_INTERVAL_MS")
+	if env == "" {
+		return DefaultScoreUpdateInterval
+	}
+	ms, err := strconv.Atoi(env)
+	if err != nil || ms < 0 {
+		log.Printf("[WARN] Invalid SCORE_UPDATE_INTERVAL_MS %q, using default %v", env, DefaultScoreUpdateInterval)
+		return DefaultScoreUpdateInterval
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// updateCurrentPieceScoresThrottled は直前の更新から scoreUpdateInterval 以上経過している場合のみ
+// CurrentPieceScores を更新します。高頻度の横移動・回転で毎回マップを作り直す負荷を抑えるために使います。
+//
+// Parameters:
+//   now : 操作を適用した時刻
+func (s *PlayerGameState) updateCurrentPieceScoresThrottled(now time.Time) {
+	if now.Sub(s.lastScoreUpdate) < scoreUpdateInterval {
+		return
+	}
+	s.updateCurrentPieceScores()
+}
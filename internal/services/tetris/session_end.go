@@ -0,0 +1,252 @@
+package tetris
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+)
+
+// ReconnectGracePeriod はプレイ中に切断したプレイヤーの再接続を待つ猶予時間です。
+// 猶予内に再接続すればゲームを続行し、猶予切れで初めて相手の切断勝ちが確定します。
+const ReconnectGracePeriod = 10 * time.Second
+
+// ゲームの終了理由です。GameSession.EndReason・game_end イベント・対戦履歴に記録され、
+// クライアントは「時間切れであなたの勝ち」「相手が切断した」などの文言の出し分けに使います。
+// 値は保存済みの対戦履歴とクライアントが参照するため変更しないでください。
+const (
+	EndReasonTimeUp               = "time_up"               // 制限時間切れ
+	EndReasonGameOver             = "game_over"             // どちらか（または両方）のゲームオーバー（トップアウト）
+	EndReasonOpponentDisconnected = "opponent_disconnected" // 相手の切断（再接続猶予切れ）
+	EndReasonCancelled            = "cancelled"             // 対戦中のセッションの削除による中止（勝者なし、ランキングには記録しない）
+	EndReasonForfeit              = "forfeit"               // 対戦中のセッションを参加者が削除した（削除した側の負け、結果は記録する）
+	EndReasonOther                = "other"                 // その他（サーバー都合など）
+)
+
+// EventGameResult は勝敗の確定をクライアントに通知するイベントの種類です。
+const EventGameResult = "game_result"
+
+// GameResultEvent は勝敗の確定を通知するイベントメッセージです。
+type GameResultEvent struct {
+	Type     string            `json:"type"`
+	Version  int               `json:"v"`         // プロトコルバージョン（ProtocolVersion）
+	Reason   string            `json:"reason"`    // 終了理由（EndReason* 定数）
+	WinnerID string            `json:"winner_id"` // 勝者のユーザーID（引き分けの場合は空）
+	Message  string            `json:"message"`
+	TopOuts  map[string]string `json:"top_outs,omitempty"` // トップアウトしたプレイヤーのユーザーID -> 種類（TopOut* 定数、ゲームオーバーでの終了時のみ）
+}
+
+// startDisconnectGrace はプレイ中に切断したプレイヤーの再接続猶予タイマーを開始します。
+// 猶予切れまでに再接続しなかった場合、残ったプレイヤーの切断勝ちでセッションを終了します。
+//
+// Parameters:
+//   passcode : 切断したプレイヤーのセッションの合言葉
+//   userID   : 切断したプレイヤーのユーザーID
+//   session  : 切断時点のセッション（猶予中に置き換わっていないかの確認用）
+func (sm *SessionManager) startDisconnectGrace(passcode, userID string, session *GameSession) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.cancelDisconnectGraceLocked(userID)
+	if sm.disconnectTimers == nil {
+		sm.disconnectTimers = make(map[string]*time.Timer)
+	}
+	sm.disconnectTimers[userID] = time.AfterFunc(ReconnectGracePeriod, func() {
+		sm.handleDisconnectTimeout(passcode, userID, session)
+	})
+}
+
+// cancelDisconnectGraceLocked はユーザーの再接続猶予タイマーがあれば停止します。
+// 呼び出し側で sm.mu のロックを保持している必要があります。
+func (sm *SessionManager) cancelDisconnectGraceLocked(userID string) {
+	if timer, ok := sm.disconnectTimers[userID]; ok {
+		timer.Stop()
+		delete(sm.disconnectTimers, userID)
+		log.Printf("[SessionManager] Cancelled reconnect grace period for user %s", userID)
+	}
+}
+
+// cancelSessionDisconnectGracesLocked はセッションの両プレイヤーの再接続猶予タイマーを停止します。
+// 呼び出し側で sm.mu のロックを保持している必要があります。
+func (sm *SessionManager) cancelSessionDisconnectGracesLocked(session *GameSession) {
+	if session.Player1 != nil {
+		sm.cancelDisconnectGraceLocked(session.Player1.UserID)
+	}
+	if session.Player2 != nil {
+		sm.cancelDisconnectGraceLocked(session.Player2.UserID)
+	}
+}
+
+// handleDisconnectTimeout は再接続猶予が切れたときに呼ばれ、切断したプレイヤーの負けでセッションを終了します。
+// 猶予中に再接続した場合や、セッションが既に終了・置き換えられている場合は何もしません。
+func (sm *SessionManager) handleDisconnectTimeout(passcode, userID string, session *GameSession) {
+	sm.mu.Lock()
+	delete(sm.disconnectTimers, userID)
+	current, ok := sm.sessions[passcode]
+	if !ok || current != session || !session.isInProgress() {
+		sm.mu.Unlock()
+		return
+	}
+	if client, connected := sm.clients[userID]; connected && client.RoomID == passcode {
+		sm.mu.Unlock()
+		return // 猶予中に再接続済み
+	}
+
+	// 残ったプレイヤーが接続中であれば勝者とする（両者とも切断している場合は勝者なし）
+	winnerID := ""
+	if opponent := session.opponentOf(userID); opponent != nil {
+		if client, connected := sm.clients[opponent.UserID]; connected && client.RoomID == passcode {
+			winnerID = opponent.UserID
+		}
+	}
+	sm.mu.Unlock()
+
+	log.Printf("[SessionManager] Reconnect grace period expired for user %s in passcode %s. Winner: %s", userID, passcode, winnerID)
+	sm.endGameSession(passcode, EndReasonOpponentDisconnected, winnerID)
+}
+
+// opponentOf は指定したユーザーの対戦相手のゲーム状態を返します。
+func (gs *GameSession) opponentOf(userID string) *PlayerGameState {
+	if gs.Player1 != nil && gs.Player1.UserID == userID {
+		return gs.Player2
+	}
+	if gs.Player2 != nil && gs.Player2.UserID == userID {
+		return gs.Player1
+	}
+	return nil
+}
+
+// determineEndResult はセッションの状態から終了理由と勝者を判定します。
+// ゲームオーバーの場合は生き残った側、時間切れの場合はスコアの高い側を勝者とし、同点は引き分け（勝者なし）です。
+// スコアとゲームオーバーを参照するため、sm.mu と session.gameMu の両方を保持した状態で呼び出してください。
+func determineEndResult(session *GameSession) (string, string) {
+	p1, p2 := session.Player1, session.Player2
+	if p1 == nil || p2 == nil {
+		return EndReasonOther, ""
+	}
+
+	switch {
+	case p1.IsGameOver && !p2.IsGameOver:
+		return EndReasonGameOver, p2.UserID
+	case p2.IsGameOver && !p1.IsGameOver:
+		return EndReasonGameOver, p1.UserID
+	}
+
+	reason := EndReasonOther
+	if p1.IsGameOver && p2.IsGameOver {
+		reason = EndReasonGameOver
+	} else if session.IsTimeUp() {
+		reason = EndReasonTimeUp
+	}
+	switch {
+	case p1.Score > p2.Score:
+		return reason, p1.UserID
+	case p2.Score > p1.Score:
+		return reason, p2.UserID
+	default:
+		return reason, ""
+	}
+}
+
+// matchHistoryOf は終了したセッションの対戦結果を対戦履歴に記録する形で返します（記録しない場合は nil）。
+// プレイヤーの状態を参照するため、sm.mu と session.gameMu の両方を保持した状態で呼び出してください。
+func (sm *SessionManager) matchHistoryOf(session *GameSession) *models.MatchHistory {
+	if sm.matchRepo == nil || session.Player1 == nil {
+		return nil
+	}
+
+	match := &models.MatchHistory{
+		Passcode:     session.ID,
+		Player1ID:    session.Player1.UserID,
+		Player1Score: session.Player1.Score,
+		Player1APM:   session.Player1.CalculateAPM(),
+		Player1PPS:   session.Player1.CalculatePPS(),
+		WinnerID:     session.WinnerID,
+		EndReason:    session.EndReason,
+		StartedAt:    session.StartedAt,
+		EndedAt:      session.EndedAt,
+	}
+	if session.Player2 != nil {
+		match.Player2ID = session.Player2.UserID
+		match.Player2Score = session.Player2.Score
+		match.Player2APM = session.Player2.CalculateAPM()
+		match.Player2PPS = session.Player2.CalculatePPS()
+	}
+	return match
+}
+
+// saveMatchHistory は matchHistoryOf で作成した対戦結果を対戦履歴に記録します。
+// データベースへの書き込みを行うため、sm.mu を保持せずに呼び出してください。
+func (sm *SessionManager) saveMatchHistory(match *models.MatchHistory) {
+	if match == nil {
+		return
+	}
+
+	ctx, cancel := gameDBContext()
+	defer cancel()
+	if err := sm.matchRepo.CreateMatchHistory(ctx, nil, match); err != nil {
+		log.Printf("[SessionManager] Failed to save match history for session %s: %v", match.Passcode, err)
+		return
+	}
+	log.Printf("[SessionManager] Saved match history for session %s (id: %d, reason: %s)", match.Passcode, match.ID, match.EndReason)
+}
+
+// gameResultMessage は game_result イベントで userID のクライアントに送る文言を返します。
+// 切断・棄権での終了では、勝者には勝ち、それ以外（切断・棄権した本人）には負けの文言を返します。
+func gameResultMessage(session *GameSession, userID string) string {
+	if session.WinnerID == "" {
+		return "引き分けです"
+	}
+	won := userID == session.WinnerID
+	switch session.EndReason {
+	case EndReasonOpponentDisconnected:
+		if won {
+			return "相手が切断したため、あなたの勝ちです"
+		}
+		return "再接続の猶予時間が過ぎたため、あなたの負けです"
+	case EndReasonForfeit:
+		if won {
+			return "相手が対戦を放棄したため、あなたの勝ちです"
+		}
+		return "対戦を放棄したため、あなたの負けです"
+	}
+	return "勝者が確定しました"
+}
+
+// sendGameResult は勝敗の確定を game_result イベントとしてセッションの全クライアントに送信します。
+// 文言は受け取るクライアントが勝者かどうかで変わるため、クライアントごとに組み立てます。
+func (sm *SessionManager) sendGameResult(session *GameSession) {
+	var topOuts map[string]string
+	for _, player := range []*PlayerGameState{session.Player1, session.Player2} {
+		if player != nil && player.topOutKind != "" {
+			if topOuts == nil {
+				topOuts = make(map[string]string, 2)
+			}
+			topOuts[player.UserID] = player.topOutKind
+		}
+	}
+
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	for _, client := range sm.clients {
+		if client.RoomID != session.ID {
+			continue
+		}
+		event, err := json.Marshal(GameResultEvent{
+			Type:     EventGameResult,
+			Version:  ProtocolVersion,
+			Reason:   session.EndReason,
+			WinnerID: session.WinnerID,
+			Message:  gameResultMessage(session, client.UserID),
+			TopOuts:  topOuts,
+		})
+		if err != nil {
+			log.Printf("[SessionManager] Error marshaling game result for passcode %s: %v", session.ID, err)
+			return
+		}
+		if !sm.sendOrDisconnect(client, event) {
+			log.Printf("[SessionManager] Failed to send game result to client %s (channel closed or full)", client.UserID)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package tetris
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// ゲームの状態遷移を通知するイベントの種類です。
+// 毎秒のゲーム状態ブロードキャストとは別に、遷移の瞬間に各クライアントへ一度だけ送信されます。
+const (
+	EventGameStart = "game_start" // waiting → countdown・playing
+	EventGameEnd   = "game_end"   // playing → finished
+)
+
+const (
+	// TransitionEventMaxRetries は遷移イベントの送信に失敗（Sendチャネルが満杯）した場合の再試行回数です。
+	TransitionEventMaxRetries = 20
+	// TransitionEventRetryInterval は遷移イベントの再試行間隔です。
+	TransitionEventRetryInterval = 50 * time.Millisecond
+)
+
+// GameStartEvent はゲーム開始を通知するイベントメッセージです。
+type GameStartEvent struct {
+	Type      string    `json:"type"`
+	Version   int       `json:"v"` // プロトコルバージョン（ProtocolVersion）
+	StartedAt time.Time `json:"started_at"`
+	TimeLimit int       `json:"time_limit"` // 制限時間（秒）
+	Player1ID string    `json:"player1_id"`
+	Player2ID string    `json:"player2_id"`
+}
+
+// GameEndEvent はゲーム終了を通知するイベントメッセージです。
+type GameEndEvent struct {
+	Type    string        `json:"type"`
+	Version int           `json:"v"` // プロトコルバージョン（ProtocolVersion）
+	Result  GameEndResult `json:"result"`
+}
+
+// GameEndResult はゲーム終了時の結果です。
+type GameEndResult struct {
+	Reason   string         `json:"reason"`    // 終了理由（EndReason* 定数）
+	WinnerID string         `json:"winner_id"` // 勝者のユーザーID（引き分けの場合は空）
+	Scores   map[string]int `json:"scores"`    // userID -> 最終スコア
+	EndedAt  time.Time      `json:"ended_at"`
+}
+
+// IsClosed はクライアントのSendチャネルが既に閉じられているかどうかを返します。
+func (c *Client) IsClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// sendReliable は遷移イベントなど取りこぼせないメッセージをクライアントに送信します。
+// まず同期的に1回送信を試み、チャネルが満杯の場合はバックグラウンドで再試行します。
+// クライアントが切断済み（チャネルが閉じられている）の場合は諦めます。
+func sendReliable(client *Client, message []byte) {
+	if client.SafeSend(message) {
+		return
+	}
+	go func() {
+		for attempt := 1; attempt <= TransitionEventMaxRetries; attempt++ {
+			time.Sleep(TransitionEventRetryInterval)
+			if client.IsClosed() {
+				return
+			}
+			if client.SafeSend(message) {
+				return
+			}
+		}
+		log.Printf("[SessionManager] Gave up sending transition event to client %s after %d retries", client.UserID, TransitionEventMaxRetries)
+	}()
+}
+
+// gameStartEventJSON はセッションの game_start イベントをJSONにシリアライズします。
+func gameStartEventJSON(session *GameSession) ([]byte, error) {
+	event := GameStartEvent{
+		Type:      EventGameStart,
+		Version:   ProtocolVersion,
+		StartedAt: session.StartedAt,
+		TimeLimit: int(session.TimeLimit.Seconds()),
+	}
+	if session.Player1 != nil {
+		event.Player1ID = session.Player1.UserID
+	}
+	if session.Player2 != nil {
+		event.Player2ID = session.Player2.UserID
+	}
+	return json.Marshal(event)
+}
+
+// gameEndEventJSON はセッションの game_end イベントをJSONにシリアライズします。
+// スコアの参照でゲーム状態ロック（gameMu）を取るため、sm.mu を保持せずに呼び出してください。
+func gameEndEventJSON(session *GameSession) ([]byte, error) {
+	session.gameMu.Lock()
+	scores := make(map[string]int, 2)
+	for _, player := range []*PlayerGameState{session.Player1, session.Player2} {
+		if player != nil {
+			scores[player.UserID] = player.Score
+		}
+	}
+	session.gameMu.Unlock()
+
+	return json.Marshal(GameEndEvent{
+		Type:    EventGameEnd,
+		Version: ProtocolVersion,
+		Result: GameEndResult{
+			Reason:   session.EndReason,
+			WinnerID: session.WinnerID,
+			Scores:   scores,
+			EndedAt:  session.EndedAt,
+		},
+	})
+}
+
+// transitionEventJSON はセッションの状態 status に対応する遷移イベントを返します。
+// countdown・playing・paused なら game_start、finished なら game_end で、それ以外の状態では nil を返します。
+// game_end の組み立てでゲーム状態ロック（gameMu）を取るため、status は sm.mu の下で読んでおき、
+// sm.mu を解放してから呼び出してください。
+func transitionEventJSON(session *GameSession, status string) ([]byte, error) {
+	switch status {
+	case "countdown", "playing", "paused":
+		return gameStartEventJSON(session)
+	case "finished":
+		return gameEndEventJSON(session)
+	}
+	return nil, nil
+}
+
+// sendTransitionEvent はセッションの現在の状態に対応する遷移イベントをセッションの全クライアントに送信します。
+// 状態と送信先は sm.mu の下で読み、イベントの組み立てと送信は sm.mu を解放してから行います。
+// 呼び出し側で sm.mu のロックを保持していてはいけません。
+func (sm *SessionManager) sendTransitionEvent(session *GameSession) {
+	sm.mu.RLock()
+	status := session.Status
+	var clients []*Client
+	for _, client := range sm.clients {
+		if client.RoomID == session.ID {
+			clients = append(clients, client)
+		}
+	}
+	sm.mu.RUnlock()
+
+	event, err := transitionEventJSON(session, status)
+	if err != nil {
+		log.Printf("[SessionManager] Error marshaling transition event for passcode %s: %v", session.ID, err)
+		return
+	}
+	if event == nil {
+		return
+	}
+	for _, client := range clients {
+		sendReliable(client, event)
+	}
+}
+
+// sendTransitionEventTo は再接続したクライアントに、接続先セッションの現在の状態に対応する遷移イベントを送信します。
+// 遷移の瞬間に切断していたクライアントも、これにより画面遷移を取りこぼしません。
+func (sm *SessionManager) sendTransitionEventTo(client *Client, session *GameSession) {
+	sm.mu.RLock()
+	status := session.Status
+	sm.mu.RUnlock()
+
+	event, err := transitionEventJSON(session, status)
+	if err != nil {
+		log.Printf("[SessionManager] Error marshaling transition event for passcode %s: %v", session.ID, err)
+		return
+	}
+	if event != nil {
+		sendReliable(client, event)
+	}
+}
@@ -0,0 +1,118 @@
+package tetris
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// SessionTickInterval はセッション専用ゲームループの自動落下・ブロードキャスト間隔です。
+const SessionTickInterval = 1000 * time.Millisecond
+
+// runSessionLoop は1つのゲームセッション専用のゲームループです。
+// セッション生成時にゴルーチンとして起動し、そのセッションの自動落下・時間切れ判定・ブロードキャストを
+// 独立したtickerで処理します。これにより他セッションの処理時間に落下間隔が左右されなくなります。
+// GameLoopDone が閉じられるか、SessionManagerがシャットダウンされると終了します。
+//
+// Parameters:
+//   session : ループを回すゲームセッション
+func (sm *SessionManager) runSessionLoop(session *GameSession) {
+	ticker := time.NewTicker(SessionTickInterval)
+	defer ticker.Stop()
+
+	log.Printf("[SessionLoop] Game loop started for passcode %s", session.ID)
+	defer log.Printf("[SessionLoop] Game loop stopped for passcode %s", session.ID)
+
+	for {
+		select {
+		case <-session.GameLoopDone:
+			return
+		case <-sm.quit:
+			return
+		case <-ticker.C:
+			switch sm.tickSession(session) {
+			case tickTimeUp:
+				log.Printf("[SessionLoop] Time limit reached for passcode %s, ending game", session.ID)
+				sm.EndGameSession(session.ID)
+				return
+			case tickBothGameOver:
+				// 両方のプレイヤーがゲームオーバーした場合のみ終了
+				log.Printf("[SessionLoop] Both players are game over, ending session %s", session.ID)
+				select {
+				case <-time.After(2 * time.Second):
+				case <-session.GameLoopDone:
+					return
+				case <-sm.quit:
+					return
+				}
+				sm.EndGameSession(session.ID)
+				return
+			}
+		}
+	}
+}
+
+// tickOutcome はセッションループの1tick（tickSession）の結果です。
+type tickOutcome int
+
+const (
+	tickContinue     tickOutcome = iota // ゲームを続ける（待機中・一時停止中を含む）
+	tickTimeUp                          // 時間切れ
+	tickBothGameOver                    // 両方のプレイヤーがゲームオーバー
+)
+
+// tickSession はセッションループの1tick分（自動落下・お邪魔ブロックの反映・ブロードキャスト）を実行します。
+// 時間切れ・両者ゲームオーバーの場合はそれを返し、セッションの終了は呼び出し側（runSessionLoop）が行います。
+func (sm *SessionManager) tickSession(session *GameSession) tickOutcome {
+	sm.mu.RLock()
+	status := session.Status
+	sm.mu.RUnlock()
+	if status == "paused" {
+		// 一時停止中は自動落下・時間切れ判定を止め、一時停止時間の上限だけを確認する
+		sm.resumeIfPauseExhausted(session)
+		return tickContinue
+	}
+	if status != "playing" {
+		return tickContinue // 待機中はプレイヤーが揃うまで何もしない
+	}
+
+	// 時間制限チェック（100秒、一時停止していた時間を除く）
+	session.gameMu.Lock()
+	timeUp := session.IsTimeUp()
+	session.gameMu.Unlock()
+	if timeUp {
+		return tickTimeUp
+	}
+
+	session.gameMu.Lock()
+	if session.Player1 != nil && !session.Player1.IsGameOver {
+		AutoFall(session.Player1)
+	}
+	if session.Player2 != nil && !session.Player2.IsGameOver {
+		AutoFall(session.Player2)
+	}
+	session.deliverGarbage()
+	bothGameOver := session.Player1 != nil && session.Player2 != nil &&
+		session.Player1.IsGameOver && session.Player2.IsGameOver
+	pendingEvents := session.takeSessionLockEvents()
+	session.gameMu.Unlock()
+
+	sm.sendLockEvents(session, pendingEvents)
+
+	// 自動落下時は常にブロードキャスト（相手の状態更新のタイミング）
+	sm.BroadcastGameState(session.ID)
+
+	if bothGameOver {
+		return tickBothGameOver
+	}
+	return tickContinue
+}
+
+// marshalLightweightFor はセッションの軽量状態を userID のクライアント向けの版（LightweightGameState.ViewFor）で
+// ゲーム状態ロックの下でJSONにシリアライズします。
+// セッションループによる自動落下と同時にマップを読み書きしないようにするためのヘルパーです。
+func marshalLightweightFor(session *GameSession, userID string) ([]byte, error) {
+	session.gameMu.Lock()
+	defer session.gameMu.Unlock()
+	return json.Marshal(session.ToLightweight().ViewFor(userID))
+}
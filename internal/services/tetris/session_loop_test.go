@@ -0,0 +1,247 @@
+package tetris
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+)
+
+// benchSessions は BenchmarkConcurrentSessions で計測する同時対戦数（カンマ区切り）です。
+// 例: go test ./internal/services/tetris -run '^$' -bench ConcurrentSessions -bench.sessions 100,500,1000
+var benchSessions = flag.String("bench.sessions", "10,100,500", "BenchmarkConcurrentSessions で計測する同時対戦数（カンマ区切り）")
+
+// pipeListener は net.Pipe で作ったサーバー側のコネクションを受け付ける net.Listener です。
+// 実際のネットワークを使わずに、WebSocketのハンドシェイクと書き込みを通すために使います。
+type pipeListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{conns: make(chan net.Conn), done: make(chan struct{})}
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr { return pipeAddr{} }
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return "pipe" }
+func (pipeAddr) String() string  { return "pipe" }
+
+// pipeWebSocketServer は net.Pipe 上でWebSocketのサーバー側コネクションを作るダミーサーバーです。
+type pipeWebSocketServer struct {
+	listener *pipeListener
+	server   *http.Server
+	accepted chan *websocket.Conn
+}
+
+func newPipeWebSocketServer() *pipeWebSocketServer {
+	s := &pipeWebSocketServer{listener: newPipeListener(), accepted: make(chan *websocket.Conn)}
+	upgrader := websocket.Upgrader{}
+	s.server = &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		s.accepted <- conn
+	})}
+	go s.server.Serve(s.listener)
+	return s
+}
+
+// connect はダミーのWebSocket接続を作成し、サーバー側のコネクションを返します。
+// クライアント側は受信したメッセージを読み捨て続けます（net.Pipe は同期的なため、読まないと書き込みが詰まる）。
+func (s *pipeWebSocketServer) connect(tb testing.TB) *websocket.Conn {
+	dialer := websocket.Dialer{
+		NetDial: func(network, addr string) (net.Conn, error) {
+			clientConn, serverConn := net.Pipe()
+			s.listener.conns <- serverConn
+			return clientConn, nil
+		},
+	}
+	clientConn, _, err := dialer.Dial("ws://pipe/", nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	go func() {
+		defer clientConn.Close()
+		for {
+			if _, _, err := clientConn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+	return <-s.accepted
+}
+
+func (s *pipeWebSocketServer) Close() {
+	s.server.Close()
+}
+
+// parseBenchSessions は -bench.sessions の値を同時対戦数の一覧に変換します。
+func parseBenchSessions(tb testing.TB) []int {
+	var counts []int
+	for _, field := range strings.Split(*benchSessions, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n <= 0 {
+			tb.Fatalf("invalid -bench.sessions %q", *benchSessions)
+		}
+		counts = append(counts, n)
+	}
+	return counts
+}
+
+// revivePlayers は計測を続けられるよう、ゲームオーバーしたプレイヤーを新しい状態に差し替え、制限時間をリセットします。
+func revivePlayers(session *GameSession) {
+	session.gameMu.Lock()
+	defer session.gameMu.Unlock()
+	for _, player := range []**PlayerGameState{&session.Player1, &session.Player2} {
+		if (*player).IsGameOver {
+			*player = NewPlayerGameState((*player).UserID, &models.Deck{ID: "bench-deck"})
+		}
+	}
+	session.StartedAt = time.Now()
+}
+
+// BenchmarkConcurrentSessions はN個の同時対戦で1tickを処理する時間・ブロードキャストのドロップ率・メモリ使用量を、
+// 単一の SessionManager と ShardedSessionManager で比較して計測します。
+// 各セッションは runSessionLoop と同じくゴルーチンで同時に tickSession を実行し、状態は各マネージャー（シャード）の
+// Run のブロードキャスト処理から net.Pipe 上のダミーWebSocketに実際に書き込まれます。
+// 結果は maxSessions（MAX_SESSIONS）の見積もりに使います。
+//
+// 計測値:
+//   ns/op              : 全セッションの1tick（自動落下から Run がブロードキャストを送り終えるまで）にかかる時間
+//   tick-budget-%      : 1tickの間隔（SessionTickInterval）に対する処理時間の割合。100% を超えるとtickが遅れる
+//   broadcast-drop-%   : broadcast チャネルが満杯で捨てられた状態送信の割合
+//   heap-B/session     : 1セッション（2人分の接続を含む）あたりのヒープ使用量
+func BenchmarkConcurrentSessions(b *testing.B) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	for _, n := range parseBenchSessions(b) {
+		b.Run(fmt.Sprintf("single/sessions=%d", n), func(b *testing.B) {
+			sm := newTestSessionManager()
+			benchmarkConcurrentSessions(b, []*SessionManager{sm}, func(string) *SessionManager { return sm }, n)
+		})
+		b.Run(fmt.Sprintf("sharded/sessions=%d", n), func(b *testing.B) {
+			s := newTestShardedSessionManager(DefaultSessionShardCount)
+			benchmarkConcurrentSessions(b, s.shards, s.shardFor, n)
+		})
+	}
+}
+
+// benchmarkConcurrentSessions は managers の Run を起動し、sessionCount 個の対戦を managerFor で選んだマネージャーに登録して計測します。
+// 単一の SessionManager の場合は managers がその1つだけで、managerFor は常にそれを返します。
+func benchmarkConcurrentSessions(b *testing.B, managers []*SessionManager, managerFor func(passcode string) *SessionManager, sessionCount int) {
+	server := newPipeWebSocketServer()
+	defer server.Close()
+
+	var before runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	for _, sm := range managers {
+		sm.broadcast = make(chan *GameStateEvent, 512) // NewSessionManager と同じバッファサイズ
+		sm.inputEvents = make(chan PlayerInputEvent, 512)
+		go sm.Run()
+		defer sm.Shutdown()
+	}
+
+	sessions := make([]*GameSession, sessionCount)
+	for i := range sessions {
+		passcode := fmt.Sprintf("bench-%d", i)
+		session, err := NewGameSession(passcode, passcode+"-p1", &models.Deck{ID: "bench-deck"}, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		session.SetPlayer2(passcode+"-p2", &models.Deck{ID: "bench-deck"}, nil)
+		session.Status = "playing"
+		session.StartedAt = time.Now()
+		sessions[i] = session
+
+		sm := managerFor(passcode)
+		sm.mu.Lock()
+		sm.sessions[passcode] = session
+		for _, userID := range []string{session.Player1.UserID, session.Player2.UserID} {
+			client := &Client{UserID: userID, RoomID: passcode, Conn: server.connect(b), Send: make(chan []byte, 512)}
+			sm.clients[userID] = client
+			go client.writePump()
+		}
+		sm.mu.Unlock()
+	}
+
+	var after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	broadcastDrops := func() int64 {
+		var drops int64
+		for _, sm := range managers {
+			drops += sm.broadcastDrops.Load()
+		}
+		return drops
+	}
+
+	b.ReportAllocs()
+	dropsBefore := broadcastDrops()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// 実際のtickは1秒間隔でスロットリングに掛からないため、計測でも毎回ブロードキャストさせる
+		for _, sm := range managers {
+			sm.broadcastMu.Lock()
+			sm.lastBroadcast = make(map[string]time.Time, sessionCount)
+			sm.broadcastMu.Unlock()
+		}
+
+		var wg sync.WaitGroup
+		for _, session := range sessions {
+			wg.Add(1)
+			go func(session *GameSession) {
+				defer wg.Done()
+				revivePlayers(session)
+				managerFor(session.ID).tickSession(session)
+			}(session)
+		}
+		wg.Wait()
+		// Run がブロードキャストを送り終えるまでを1tickの処理時間に含める
+		for _, sm := range managers {
+			for len(sm.broadcast) > 0 {
+				runtime.Gosched()
+			}
+		}
+	}
+	b.StopTimer()
+
+	nsPerTick := float64(b.Elapsed().Nanoseconds()) / float64(b.N)
+	b.ReportMetric(nsPerTick/float64(SessionTickInterval.Nanoseconds())*100, "tick-budget-%")
+	b.ReportMetric(float64(broadcastDrops()-dropsBefore)/float64(b.N*sessionCount)*100, "broadcast-drop-%")
+	b.ReportMetric(float64(int64(after.HeapAlloc)-int64(before.HeapAlloc))/float64(sessionCount), "heap-B/session")
+}
@@ -1,20 +1,76 @@
 package tetris
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket" // WebSocketライブラリのインポート
 
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database" // データベースサービスをインポート
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
 	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
 )
 
+// FinishedSessionRetention は終了済みセッションを削除せずに保持する時間です。
+// この間はクライアントが終了画面の描画やリザルト取得のために最終状態を参照できます。
+const FinishedSessionRetention = 30 * time.Second
+
+// GameDBTimeout はゲーム処理内（デッキの読み込み・結果の保存など）で行うDB操作1回あたりのタイムアウトです。
+// HTTPリクエストに紐付かない処理でも、遅いクエリがセッションの進行やDBコネクションを占有し続けないようにします。
+const GameDBTimeout = 5 * time.Second
+
+// gameDBContext はゲーム処理内のDB操作に使う、GameDBTimeout 付きのコンテキストを返します。
+func gameDBContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), GameDBTimeout)
+}
+
+// ErrSessionClosing は参加しようとしたセッションが終了処理中であることを示す再試行可能なエラーです。
+// 終了処理（結果保存と最終状態の送信）が終わると同じ合言葉で新しいルームを作成できるため、少し待って再試行してください。
+var ErrSessionClosing = errors.New("この部屋は終了処理中です、もう一度お試しください")
+
+// ErrSessionNotFound は指定された合言葉のセッションが存在しない場合のエラーです。
+var ErrSessionNotFound = errors.New("指定された合言葉のセッションは見つかりませんでした")
+
+// ErrNotRoomOwner はルームの作成者以外がルームを操作しようとした場合のエラーです。
+var ErrNotRoomOwner = errors.New("ルームの作成者のみが操作できます")
+
+// ErrNotParticipant はセッションの参加者（作成者・対戦相手）以外がセッションを操作しようとした場合のエラーです。
+var ErrNotParticipant = errors.New("このセッションの参加者のみが操作できます")
+
+// ErrRoomNotCancellable は対戦相手が参加済み、またはゲームが開始済みでルームを解散できない場合のエラーです。
+var ErrRoomNotCancellable = errors.New("対戦相手が参加済みのため、ルームを解散できません")
+
+// ErrInvalidPasscode は合言葉が空、または長さが許容範囲外の場合のエラーです。
+var ErrInvalidPasscode = errors.New("合言葉は3文字以上20文字以下で入力してください")
+
+// ErrRoomInProgress は参加しようとしたルームが既にゲーム中または終了済みの場合のエラーです。
+var ErrRoomInProgress = errors.New("このルームは既にゲーム中または終了しています")
+
+// ErrRoomFull は参加しようとしたルームに既に2人のプレイヤーがいる場合のエラーです。
+var ErrRoomFull = errors.New("このルームは既に満室です")
+
+// ErrOwnRoom は自分が作成したルームにプレイヤー2として参加しようとした場合のエラーです。
+var ErrOwnRoom = errors.New("自分が作成したルームには参加できません")
+
+// EventRoomCancelled はルームが作成者によって解散されたことを通知するイベントの種類です。
+const EventRoomCancelled = "room_cancelled"
+
+// RoomEvent はゲーム状態以外にクライアントへ通知するイベントメッセージです。
+// ゲーム状態のJSONと区別できるよう type フィールドを持ちます。
+type RoomEvent struct {
+	Type    string `json:"type"`
+	Version int    `json:"v"` // プロトコルバージョン（ProtocolVersion）
+	Message string `json:"message"`
+}
+
 // Client はWebSocket接続を持つ単一のクライアントを表します。
 type Client struct {
 	UserID string          // このクライアントに紐づくユーザーのID
@@ -22,7 +78,20 @@ type Client struct {
 	Send   chan []byte     // クライアントへメッセージを送信するためのバッファ付きチャネル
 	RoomID string          // このクライアントが現在参加しているルームのID
 	closed bool            // チャネルが閉じられたかどうかのフラグ
-	mu     sync.Mutex      // closedフラグ保護用
+	mu     sync.Mutex      // closedフラグ・送信失敗カウンタ保護用
+
+	sendFailures      int  // チャネル満杯による連続送信失敗回数（送信成功でリセット）
+	slowDisconnecting bool // 追従できないクライアントとして切断処理中かどうか
+
+	invalidMessages  int       // 不正なメッセージ（未知のアクション・パース失敗）の累計（readPump のみが更新）
+	lastResync       time.Time // 最後に resync 要求に応答した時刻（readPump のみが更新）
+	lastPauseMessage time.Time // 最後に一時停止・再開の要求を処理した時刻（readPump のみが更新）
+
+	lastActivity      time.Time // 最後の有効なゲーム操作の時刻（アイドル接続の検出用、mu で保護）
+	idleWarned        bool      // アイドルの警告を送ったかどうか（有効なゲーム操作でリセット）
+	idleDisconnecting bool      // アイドルのため切断処理中かどうか
+
+	ProtocolVersion int // 認証時に決めたプロトコルバージョン（NegotiateProtocolVersion）
 }
 
 // SafeSend は安全にチャネルにメッセージを送信します（closedチェック付き）
@@ -36,8 +105,10 @@ func (c *Client) SafeSend(message []byte) bool {
 	
 	select {
 	case c.Send <- message:
+		c.sendFailures = 0
 		return true // 送信成功
 	default:
+		c.sendFailures++
 		return false // チャネルがフル
 	}
 }
@@ -57,28 +128,113 @@ func (c *Client) SafeClose() {
 // GameSessionの全情報ではなく、クライアントが必要とする最小限の情報のみを含みます。
 type LightweightGameState struct {
 	ID             string                    `json:"id"`
+	Version        int                       `json:"v"`                // プロトコルバージョン（ProtocolVersion）
 	Player1        *LightweightPlayerState   `json:"player1"`
 	Player2        *LightweightPlayerState   `json:"player2"`
 	Status         string                    `json:"status"`
 	StartedAt      time.Time                 `json:"started_at,omitempty"`
 	EndedAt        time.Time                 `json:"ended_at,omitempty"`
 	TimeLimit      int                       `json:"time_limit"`       // 制限時間（秒）
-	RemainingTime  int                       `json:"remaining_time"`   // 残り時間（秒）
+	RemainingTime  int                       `json:"remaining_time"`   // 残り時間（秒、後方互換のため残す）
+	ServerTimeMs   int64                     `json:"server_time_ms"`   // 状態を作成した時点のサーバー時刻（Unixミリ秒、クライアントの時計ズレ補正用）
+	EndsAtMs       int64                     `json:"ends_at_ms,omitempty"` // ゲーム終了予定時刻 StartedAt + TimeLimit + 一時停止時間（Unixミリ秒、プレイ中のみ）
+	EndReason      string                    `json:"end_reason,omitempty"` // 終了理由（終了後のみ）
+	WinnerID       string                    `json:"winner_id,omitempty"`  // 勝者のユーザーID（終了後のみ、引き分けは空）
+	Rules          GameRules                 `json:"rules"`                // ルームのルール（クライアントがホールドUIなどの表示を切り替える、参加前のステータス取得でも返す）
+	Timeline       []StateTransition         `json:"timeline,omitempty"`   // 状態遷移の記録（参加者がステータス取得で timeline=true を指定した場合のみ、WebSocketでは送らない）
 }
 
 // LightweightPlayerState はプレイヤー状態の軽量版です。
 type LightweightPlayerState struct {
 	UserID             string             `json:"user_id"`
-	Board              tetris.Board       `json:"board"`
+	Board              tetris.VisibleBoard `json:"board"` // 隠し行を除いた表示部分のみ
 	CurrentPiece       *tetris.Piece      `json:"current_piece"`
 	NextPiece          *tetris.Piece      `json:"next_piece"`
 	HeldPiece          *tetris.Piece      `json:"held_piece,omitempty"`
 	Score              int                `json:"score"`
 	LinesCleared       int                `json:"lines_cleared"`
 	Level              int                `json:"level"`
+	IsMaxLevel         bool               `json:"is_max_level"` // レベルがカンスト（MaxLevel）に到達したか（到達演出の通知用）
 	IsGameOver         bool               `json:"is_game_over"`
+	TopOut             string             `json:"top_out,omitempty"` // トップアウトの種類（TopOut* 定数、ゲームオーバー時のみ）
+	Handicap           *PlayerHandicap    `json:"handicap,omitempty"` // 適用中のハンディキャップ（ハンディ有効のルームで弱い側のみ、相手にも公開）
+	PendingGarbage     int                `json:"pending_garbage"` // せり上げ待ちのお邪魔ライン数（予告バー表示用）
+	APM                int                `json:"apm"`             // 1分あたりの操作数
+	PPS                float64            `json:"pps"`             // 1秒あたりの設置ピース数
 	ContributionScores map[string]int     `json:"contribution_scores"`
 	CurrentPieceScores map[string]int     `json:"current_piece_scores"`
+	ContributionLevels []int              `json:"contribution_levels,omitempty"` // 草の日別レベル（0-4、古い順に ContributionWeeks 週分、相手のボード背景用。非公開設定の場合は省略）
+}
+
+// OpponentView はWebSocketで対戦相手に送る版のプレイヤー状態を返します。
+// 相手の画面にはボード（ブロックの色付き）と落下中のピース、スコア類が描ければ十分なため、
+// NextPiece・HeldPiece と、ContributionScores・CurrentPieceScores の全マップ（最大200エントリ）を省いて帯域を節約します。
+// 省いたフィールドはJSONでは null（held_piece はキーごと省略）になります。PublicView と異なりブロックの種類は残します。
+// 草のレベル（ContributionLevels）は相手のボード背景の表示に使うため残します（生の貢献数は元から含みません）。
+func (ps *LightweightPlayerState) OpponentView() *LightweightPlayerState {
+	if ps == nil {
+		return nil
+	}
+	return &LightweightPlayerState{
+		UserID:             ps.UserID,
+		Board:              ps.Board,
+		CurrentPiece:       ps.CurrentPiece,
+		Score:              ps.Score,
+		LinesCleared:       ps.LinesCleared,
+		Level:              ps.Level,
+		IsMaxLevel:         ps.IsMaxLevel,
+		IsGameOver:         ps.IsGameOver,
+		TopOut:             ps.TopOut,
+		Handicap:           ps.Handicap,
+		PendingGarbage:     ps.PendingGarbage,
+		APM:                ps.APM,
+		PPS:                ps.PPS,
+		ContributionLevels: ps.ContributionLevels,
+	}
+}
+
+// ViewFor は userID のクライアントに送る版のゲーム状態を返します。
+// 本人のプレイヤー状態は全情報、それ以外のプレイヤーは OpponentView に置き換えます（元の状態は変更しません）。
+func (s *LightweightGameState) ViewFor(userID string) *LightweightGameState {
+	view := *s
+	if view.Player1 != nil && view.Player1.UserID != userID {
+		view.Player1 = view.Player1.OpponentView()
+	}
+	if view.Player2 != nil && view.Player2.UserID != userID {
+		view.Player2 = view.Player2.OpponentView()
+	}
+	return &view
+}
+
+// PublicView は対戦相手や観戦者に見せてよい情報だけを残した制限版のプレイヤー状態を返します。
+// ボードはブロックの埋まり状態のみに変換し、ピース情報やスコアマップは含めません。
+func (ps *LightweightPlayerState) PublicView() *LightweightPlayerState {
+	if ps == nil {
+		return nil
+	}
+	board := ps.Board
+	for y := range board {
+		for x := range board[y] {
+			if board[y][x] != tetris.BlockEmpty {
+				board[y][x] = tetris.BlockFilled
+			}
+		}
+	}
+	return &LightweightPlayerState{
+		UserID:             ps.UserID,
+		Board:              board,
+		Score:              ps.Score,
+		LinesCleared:       ps.LinesCleared,
+		Level:              ps.Level,
+		IsMaxLevel:         ps.IsMaxLevel,
+		IsGameOver:         ps.IsGameOver,
+		TopOut:             ps.TopOut,
+		Handicap:           ps.Handicap,
+		PendingGarbage:     ps.PendingGarbage,
+		APM:                ps.APM,
+		PPS:                ps.PPS,
+		ContributionLevels: ps.ContributionLevels,
+	}
 }
 
 // SessionManager はゲームセッションとWebSocketクライアント接続の全体を管理します。
@@ -95,8 +251,30 @@ type SessionManager struct {
 	dbService   *database.DatabaseService      // データベース操作のためのサービス
 	deckRepo    database.DeckRepository        // デッキリポジトリ（テトリミノ配置データ取得用）
 	resultRepo database.ResultRepository       // ゲーム結果リポジトリ（スコア保存用）
+	matchRepo  database.MatchHistoryRepository // 対戦履歴リポジトリ（勝者・終了理由の記録用）
+	disconnectTimers map[string]*time.Timer    // userID -> 再接続猶予タイマー（sm.mu で保護）
 	lastBroadcast map[string]time.Time          // ルームごとの最後のブロードキャスト時刻
 	broadcastMu   sync.Mutex                    // lastBroadcastマップへのアクセス保護用
+
+	inputDrops           atomic.Int64     // inputEvents チャネルが満杯で捨てた入力の累計
+	broadcastDrops       atomic.Int64     // broadcast チャネルが満杯で捨てたブロードキャストの累計
+	dropMu               sync.Mutex       // 以下の入力ドロップ集計マップの保護用
+	inputDropsByPasscode map[string]int64 // 合言葉ごとの入力ドロップ数
+	inputDropsByUser     map[string]int64 // ユーザーごとの入力ドロップ数
+
+	unknownActions    atomic.Int64 // 許可リストに無いアクションとして弾いた入力の累計
+	malformedMessages atomic.Int64 // JSONとしてパースできなかったメッセージの累計
+
+	resultRetries resultRetryQueue // 保存に失敗したゲーム結果のリトライキュー
+
+	maxSessions int // 同時に保持できるセッション数の上限（0で無制限、環境変数 MAX_SESSIONS）
+	maxClients  int // 同時に接続できるクライアント数の上限（0で無制限、環境変数 MAX_CLIENTS）
+
+	capacity        *capacityUsage // 上限の判定に使うセッション数・接続数（ShardedSessionManager では全シャードで共有）
+	countedSessions int            // capacity に反映済みのこのマネージャーのセッション数
+	countedClients  int            // capacity に反映済みのこのマネージャーの接続数
+
+	maxSessionsPerUser int // 1人のユーザーが同時に参加できるセッション数の上限（0で無制限、環境変数 MAX_SESSIONS_PER_USER）
 }
 
 // NewSessionManager は新しい SessionManager インスタンスを作成し、そのメインイベントループをバックグラウンドで開始します。
@@ -105,9 +283,10 @@ type SessionManager struct {
 //   db : データベースサービスへのポインタ
 //   deckRepo : デッキリポジトリ
 //   resultRepo : ゲーム結果リポジトリ
+//   matchRepo : 対戦履歴リポジトリ
 // Returns:
 //   *SessionManager: 初期化されたセッションマネージャーのポインタ
-func NewSessionManager(db *database.DatabaseService, deckRepo database.DeckRepository, resultRepo database.ResultRepository) *SessionManager {
+func NewSessionManager(db *database.DatabaseService, deckRepo database.DeckRepository, resultRepo database.ResultRepository, matchRepo database.MatchHistoryRepository) *SessionManager {
 	sm := &SessionManager{
 		sessions:    make(map[string]*GameSession),
 		clients:     make(map[string]*Client),
@@ -119,34 +298,62 @@ func NewSessionManager(db *database.DatabaseService, deckRepo database.DeckRepos
 		dbService:  db,
 		deckRepo:   deckRepo,
 		resultRepo: resultRepo,
+		matchRepo:  matchRepo,
+		disconnectTimers: make(map[string]*time.Timer),
 		lastBroadcast: make(map[string]time.Time),
 		broadcastMu: sync.Mutex{},
+		capacity:    &capacityUsage{},
 	}
+	sm.maxSessions, sm.maxClients = loadCapacityLimits()
+	sm.maxSessionsPerUser = loadMaxSessionsPerUser()
 	go sm.Run() // SessionManager のメインイベントループをゴルーチンで開始
+	go sm.runResultRetryLoop() // 保存に失敗したゲーム結果の再試行ループを開始
+	go sm.runIdleClientLoop()  // 操作の無いWebSocket接続を切断するループを開始
 	return sm
 }
 
 // Run は SessionManager のメインイベントループです。
-// このゴルーチンは、クライアントの登録/解除、プレイヤー入力の処理、自動落下タイマーの管理、
-// そしてゲーム状態のブロードキャストといったすべての主要なイベントを処理します。
+// このゴルーチンは、クライアントの登録/解除、プレイヤー入力の処理、
+// そしてゲーム状態のブロードキャストといった主要なイベントを処理します。
+// 自動落下と時間切れ判定はセッションごとのゲームループ（runSessionLoop）が担当します。
 func (sm *SessionManager) Run() {
-	// 自動落下用のタイマー（さらに軽量化）
-	ticker := time.NewTicker(1000 * time.Millisecond) // 1秒間隔で大幅軽量化
-	defer ticker.Stop()
-
 	for {
 		select {
 		case client := <-sm.register:
 			// 新しいクライアントの登録処理
 			sm.mu.Lock()
 			sm.clients[client.UserID] = client
+			sm.syncCapacityLocked()
+			sm.markPlayerConnectionLocked(client.RoomID, client.UserID, true)
+			// 再接続猶予中のプレイヤーが戻ってきた場合は切断負けの確定を取り消す
+			sm.cancelDisconnectGraceLocked(client.UserID)
 			sm.mu.Unlock()
 			log.Printf("[SessionManager] Client registered: %s (Passcode: %s)", client.UserID, client.RoomID)
 
 			// クライアント登録後に最新の状態をブロードキャスト（非同期実行）
-			go func(passcode string) {
-				sm.BroadcastGameState(passcode)
-			}(client.RoomID)
+			// 終了済みセッションへの再接続の場合は、保持中の最終状態を本人にだけ送る
+			// プレイ中・終了済みのセッションへの再接続では、現在の状態に対応する遷移イベントも送り直す
+			// プレイ中の場合はさらに resync で完全な状態スナップショットを送る
+			go func(client *Client) {
+				// 終了済みか・進行中かは setStatus と競合しないよう、同じ sm.mu の読み取りロックの下でまとめて読んでおく
+				sm.mu.RLock()
+				session, ok := sm.sessions[client.RoomID]
+				finished := ok && session.Status == "finished"
+				inProgress := ok && session.isInProgress()
+				sm.mu.RUnlock()
+				if ok {
+					sm.sendTransitionEventTo(client, session)
+				}
+				if finished {
+					sm.BroadcastToSpecificClient(client.UserID, client.RoomID)
+					return
+				}
+				// プレイ中のセッションへの再接続では、ピースキューを含む完全なスナップショットを本人に送る
+				if inProgress {
+					sm.handleResyncRequest(client)
+				}
+				sm.BroadcastGameState(client.RoomID)
+			}(client)
 
 			// クライアント登録後、セッションが開始可能かチェック（非同期実行、少し遅延させてレースコンディション回避）
 			go func(passcode string) {
@@ -163,6 +370,8 @@ func (sm *SessionManager) Run() {
 					// Sendチャネルを安全に閉じる
 					registeredClient.SafeClose()
 					delete(sm.clients, client.UserID)
+					sm.syncCapacityLocked()
+					sm.markPlayerConnectionLocked(client.RoomID, client.UserID, false)
 					log.Printf("[SessionManager] Client unregistered: %s (Passcode: %s)", client.UserID, client.RoomID)
 				} else {
 					log.Printf("[SessionManager] Skipped unregister for user %s (different client instance)", client.UserID)
@@ -172,16 +381,22 @@ func (sm *SessionManager) Run() {
 			}
 			sm.mu.Unlock()
 
-			// プレイヤーがゲーム中に退出した場合、セッションを終了させる
+			// プレイヤーがゲーム中に退出した場合、再接続猶予の経過後にセッションを終了させる
+			// Status は setStatus と競合しないよう sm.mu の下で読んでおく
 			sm.mu.RLock()
 			session, ok := sm.sessions[client.RoomID]
+			var status string
+			if ok {
+				status = session.Status
+			}
+			inProgress := ok && session.isInProgress()
 			sm.mu.RUnlock()
-			if ok && session.Status == "playing" {
-				log.Printf("[SessionManager] Player %s left passcode %s during game. Ending session.", client.UserID, client.RoomID)
-				sm.EndGameSession(client.RoomID)
+			if inProgress {
+				log.Printf("[SessionManager] Player %s left passcode %s during game. Waiting %v for reconnection.", client.UserID, client.RoomID, ReconnectGracePeriod)
+				sm.startDisconnectGrace(client.RoomID, client.UserID, session)
 			} else if ok {
 				// ゲーム中でない場合は、セッション状態を更新してブロードキャスト
-				log.Printf("[SessionManager] Player %s left passcode %s (status: %s)", client.UserID, client.RoomID, session.Status)
+				log.Printf("[SessionManager] Player %s left passcode %s (status: %s)", client.UserID, client.RoomID, status)
 				sm.BroadcastGameState(client.RoomID)
 			}
 
@@ -197,11 +412,13 @@ func (sm *SessionManager) Run() {
 				continue
 			}
 			
+			// Status は一時停止・再開の setStatus と競合しないよう、セッションの参照と同じ sm.mu の下で読む
 			sm.mu.RLock()
 			session, ok := sm.sessions[client.RoomID]
+			playing := ok && session.Status == "playing"
 			sm.mu.RUnlock()
 
-			if !ok || session.Status != "playing" {
+			if !playing {
 				log.Printf("[SessionManager] Received input for non-existent or non-playing passcode %s from user %s", client.RoomID, event.UserID)
 				continue // 存在しないか、プレイ中でない合言葉への入力は無視
 			}
@@ -217,78 +434,49 @@ func (sm *SessionManager) Run() {
 				continue
 			}
 
+			// ゲームロジックを適用し、状態が実際に変更されたか確認
+			// セッションループの自動落下と競合しないよう、ゲームオーバーの判定から適用までゲーム状態ロックの下で行う
+			session.gameMu.Lock()
+			// 上の確認の後に一時停止・終了した場合の操作は無視（Status の変更は gameMu も保持して行われる）
+			if session.Status != "playing" {
+				session.gameMu.Unlock()
+				continue
+			}
 			// ゲームオーバーしたプレイヤーの操作は無視
 			if targetPlayerState.IsGameOver {
+				session.gameMu.Unlock()
 				log.Printf("[SessionManager] Ignoring input from game over player %s", event.UserID)
 				continue
 			}
 
-			// ゲームロジックを適用し、状態が実際に変更されたか確認
-			if ApplyPlayerInput(targetPlayerState, event.Action) {
+			// 1tick内の操作数が物理的にありえない量でないか検査（検知のみ）
+			checkInputRate(session, targetPlayerState, time.Now())
+
+			moved := ApplyPlayerInput(targetPlayerState, event.Action)
+			session.deliverGarbage()
+			isGameOver := targetPlayerState.IsGameOver
+			pendingEvents := session.takeSessionLockEvents()
+			session.gameMu.Unlock()
+
+			// ピース固定時の出来事（効果音用）は状態の間引きと関係なく即座に通知する
+			// 固定後の状態より先に届くよう、状態の送信と同じこのゴルーチンで順に送る（送信はどれもブロックしない）
+			sm.sendLockEvents(session, pendingEvents)
+
+			if moved {
 				// 自分の操作は即座に自分にだけ送信（レスポンシブ感を維持）
-				go func(userID, passcode string) {
-					sm.BroadcastToSpecificClient(userID, passcode)
-				}(event.UserID, session.ID)
+				sm.BroadcastToSpecificClient(event.UserID, session.ID)
 				
 				// 相手への更新は1秒間隔のブロードキャストに任せる（負荷軽減）
-				// （自動落下タイマーでブロードキャストされるため、ここでは相手への送信は不要）
+				// （セッションループの自動落下でブロードキャストされるため、ここでは相手への送信は不要）
 
 				// プレイヤーのゲームが終了したか判定（ゲームオーバーは即座に通知）
-				if targetPlayerState.IsGameOver {
-					// ゲームオーバーは重要なので即座にブロードキャスト
-					go func(passcode string) {
-						sm.BroadcastGameState(passcode)
-					}(session.ID)
+				if isGameOver {
+					// ゲームオーバーは重要なので即座にブロードキャスト（ブロードキャストチャネルに積むだけでブロックしない）
+					sm.BroadcastGameState(session.ID)
 					log.Printf("[SessionManager] Player %s is game over, but game continues for the other player", event.UserID)
 				}
 			}
 
-		case <-ticker.C:
-			// 自動落下処理を全プレイ中セッションで実行（パフォーマンス最適化）
-			sm.mu.RLock()
-			activeSessions := make([]*GameSession, 0) // アクティブセッションのみコピー
-			for _, session := range sm.sessions {
-				if session.Status == "playing" {
-					activeSessions = append(activeSessions, session)
-				}
-			}
-			sm.mu.RUnlock()
-
-			// ロック外で処理を実行（パフォーマンス改善）
-			for _, session := range activeSessions {
-				// 時間制限チェック（100秒）
-				if session.IsTimeUp() {
-					log.Printf("[SessionManager] Time limit reached for passcode %s, ending game", session.ID)
-					sm.EndGameSession(session.ID)
-					continue // 時間切れのセッションは処理をスキップ
-				}
-
-				// プレイヤー1の自動落下
-				if session.Player1 != nil && !session.Player1.IsGameOver {
-					AutoFall(session.Player1)
-				}
-				// プレイヤー2の自動落下
-				if session.Player2 != nil && !session.Player2.IsGameOver {
-					AutoFall(session.Player2)
-				}
-
-				// 自動落下時は常にブロードキャスト（1秒間隔なので相手の状態更新のタイミング）
-				go func(roomID string) {
-					sm.BroadcastGameState(roomID)
-				}(session.ID)
-
-				// ゲームオーバー判定 - 両方のプレイヤーがゲームオーバーした場合のみ終了
-				if session.Player1 != nil && session.Player2 != nil && 
-				   session.Player1.IsGameOver && session.Player2.IsGameOver {
-					// 両プレイヤーがゲームオーバーした場合のみセッション終了
-					log.Printf("[SessionManager] Both players are game over, ending session %s", session.ID)
-					go func(sessionID string) {
-						time.Sleep(2 * time.Second)
-						sm.EndGameSession(sessionID)
-					}(session.ID)
-				}
-			}
-
 		case event := <-sm.broadcast:
 			// ゲーム状態のブロードキャスト処理
 			sm.mu.RLock()
@@ -299,20 +487,18 @@ func (sm *SessionManager) Run() {
 				continue
 			}
 
-			// GameSessionを軽量な構造体に変換してからJSON形式でシリアライズ
-			lightweightState := session.ToLightweight()
-			stateJSON, err := json.Marshal(lightweightState)
-			if err != nil {
-				log.Printf("[SessionManager] Error marshaling lightweight game state for room %s: %v", event.RoomID, err)
-				sm.mu.RUnlock()
-				continue
-			}
-
-			// ルーム内の各クライアントにゲーム状態を送信
+			// GameSessionを軽量な構造体に変換し、ルーム内の各クライアントに応じた版（自分用・相手用）を送信
+			// 複数クライアント分をロック外でシリアライズするため、マップをコピーしたスナップショットを使う
+			state := session.LightweightSnapshot()
 			for _, client := range sm.clients {
 				if client.RoomID == event.RoomID {
+					stateJSON, err := json.Marshal(state.ViewFor(client.UserID))
+					if err != nil {
+						log.Printf("[SessionManager] Error marshaling lightweight game state for room %s: %v", event.RoomID, err)
+						continue
+					}
 					// 安全な送信メソッドを使用
-					if !client.SafeSend(stateJSON) {
+					if !sm.sendOrDisconnect(client, stateJSON) {
 						log.Printf("[SessionManager] Failed to send to client %s (channel closed or full)", client.UserID)
 					}
 				}
@@ -328,99 +514,113 @@ func (sm *SessionManager) Run() {
 }
 
 // CheckAndStartGame はセッションが開始条件を満たしているかチェックし、満たしていればゲームを開始します。
+// 接続のたびに呼ばれるため、各条件の値は debug レベルで出力し、info レベルでは結論（開始した・満たさなかった）だけを1行出力します。
 //
 // Parameters:
 //   passcode : チェックする合言葉
 func (sm *SessionManager) CheckAndStartGame(passcode string) {
-	log.Printf("[SessionManager] CheckAndStartGame called for passcode: %s", passcode)
-	
+	session := sm.startGameIfReady(passcode)
+	if session == nil {
+		return
+	}
+
+	// 状態遷移を game_start イベントで一度だけ確実に通知（イベントの組み立ては sm.mu の外で行う）
+	sm.sendTransitionEvent(session)
+
+	// ゲーム開始をクライアントに通知（非同期実行）
+	go func(passcode string) {
+		sm.BroadcastGameState(passcode)
+	}(passcode)
+}
+
+// startGameIfReady は sm.mu の下で開始条件を確認し、満たしていればセッションを playing にして返します。
+// 開始しなかった場合は nil を返します。開始の通知は呼び出し側が sm.mu を解放してから行います。
+func (sm *SessionManager) startGameIfReady(passcode string) *GameSession {
 	sm.mu.Lock()
-	defer sm.mu.Unlock() // defer で必ずアンロックされるように変更
+	defer sm.mu.Unlock()
 
-	// デバッグ用: 現在のセッション一覧をログ出力
-	sessionCount := len(sm.sessions)
-	log.Printf("[SessionManager] Current session count: %d", sessionCount)
-	
 	session, ok := sm.sessions[passcode]
-	if !ok {
-		log.Printf("[SessionManager] Passcode %s not found in CheckAndStartGame (total sessions: %d)", passcode, sessionCount)
-		// デバッグ用: 存在するセッションパスコードをログ出力
-		var existingPasscodes []string
-		for code := range sm.sessions {
-			existingPasscodes = append(existingPasscodes, code)
+	if !ok || session == nil {
+		// 存在する合言葉の一覧はデバッグ時のみ集める（セッション数に比例するため）
+		if slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+			existingPasscodes := make([]string, 0, len(sm.sessions))
+			for code := range sm.sessions {
+				existingPasscodes = append(existingPasscodes, code)
+			}
+			slog.Debug("[SessionManager] CheckAndStartGame: passcode not found", "passcode", passcode, "existing_passcodes", existingPasscodes)
 		}
-		log.Printf("[SessionManager] Existing passcodes: %v", existingPasscodes)
-		return // セッションが存在しない
+		return nil // セッションが存在しない
 	}
-	
-	// セッションの状態をチェック（削除された可能性を考慮）
-	if session == nil {
-		log.Printf("[SessionManager] Session for passcode %s is nil", passcode)
-		return
+	if session.isDeleting {
+		slog.Debug("[SessionManager] CheckAndStartGame: session is being closed", "passcode", passcode)
+		return nil
 	}
-	
-	log.Printf("[SessionManager] Passcode %s status: %s", passcode, session.Status)
-	
+
 	// 各条件をチェック
 	hasPlayer1 := session.Player1 != nil
 	hasPlayer2 := session.Player2 != nil
-	
-	log.Printf("[SessionManager] Passcode %s - hasPlayer1: %v, hasPlayer2: %v", passcode, hasPlayer1, hasPlayer2)
-	
-	if hasPlayer1 {
-		log.Printf("[SessionManager] Passcode %s - Player1 ID: %s", passcode, session.Player1.UserID)
-	}
-	if hasPlayer2 {
-		log.Printf("[SessionManager] Passcode %s - Player2 ID: %s", passcode, session.Player2.UserID)
-	}
-	
-	// WebSocket接続をチェック
 	var player1Connected, player2Connected bool
 	if hasPlayer1 {
 		player1Connected = sm.clients[session.Player1.UserID] != nil
-		log.Printf("[SessionManager] Passcode %s - Player1 (%s) connected: %v", passcode, session.Player1.UserID, player1Connected)
 	}
 	if hasPlayer2 {
 		player2Connected = sm.clients[session.Player2.UserID] != nil
-		log.Printf("[SessionManager] Passcode %s - Player2 (%s) connected: %v", passcode, session.Player2.UserID, player2Connected)
 	}
-	
 	isWaiting := session.Status == "waiting"
-	log.Printf("[SessionManager] Passcode %s - isWaiting: %v", passcode, isWaiting)
+	slog.Debug("[SessionManager] CheckAndStartGame conditions", "passcode", passcode, "status", session.Status,
+		"has_player1", hasPlayer1, "has_player2", hasPlayer2,
+		"player1_connected", player1Connected, "player2_connected", player2Connected)
 
 	// 2人のプレイヤーが揃っていて、両方がWebSocketに接続済みであればゲーム開始
-	if hasPlayer1 && hasPlayer2 && player1Connected && player2Connected && isWaiting {
-		log.Printf("[SessionManager] All conditions met, starting game for passcode %s", passcode)
-		
-		session.Status = "playing"
-		session.StartedAt = time.Now()
-		log.Printf("[SessionManager] Game session %s started! Players: %s vs %s", passcode, session.Player1.UserID, session.Player2.UserID)
-
-		// ゲーム開始をクライアントに通知（非同期実行）
-		go func(passcode string) {
-			sm.BroadcastGameState(passcode) 
-		}(passcode)
-		return
-	} else {
-		log.Printf("[SessionManager] Game start conditions not met for passcode %s", passcode)
-		log.Printf("[SessionManager] - hasPlayer1: %v, hasPlayer2: %v, player1Connected: %v, player2Connected: %v, isWaiting: %v", 
-			hasPlayer1, hasPlayer2, player1Connected, player2Connected, isWaiting)
+	if !(hasPlayer1 && hasPlayer2 && player1Connected && player2Connected && isWaiting) {
+		if isWaiting {
+			slog.Info("[SessionManager] Game start conditions not met", "passcode", passcode,
+				"players", boolCount(hasPlayer1, hasPlayer2), "connected", boolCount(player1Connected, player2Connected))
+			session.recordEvent(fmt.Sprintf("start_conditions_not_met: players=%d connected=%d",
+				boolCount(hasPlayer1, hasPlayer2), boolCount(player1Connected, player2Connected)))
+		}
+		return nil
+	}
+
+	session.setStatus("playing", "game_started")
+	session.StartedAt = time.Now()
+	session.Player1.markPlayStarted(session.StartedAt)
+	session.Player2.markPlayStarted(session.StartedAt)
+	slog.Info("[SessionManager] Game started", "passcode", passcode, "player1", session.Player1.UserID, "player2", session.Player2.UserID)
+	return session
+}
+
+// boolCount は true の個数を返します（ログに「2人中何人」を出すために使います）。
+func boolCount(values ...bool) int {
+	count := 0
+	for _, v := range values {
+		if v {
+			count++
+		}
 	}
+	return count
 }
 
 // RegisterClient は新しいWebSocketクライアントをSessionManagerに登録します。
+// 接続数が上限に達している場合は ErrServerBusy を返します（既に接続中のユーザーの再接続は除く）。
 //
 // Parameters:
 //   passcode : クライアントが参加する合言葉
 //   userID : クライアントのユーザーID
 //   conn   : WebSocketコネクション
+//   protocolVersion : 認証時に決めたプロトコルバージョン（NegotiateProtocolVersion の結果）
 // Returns:
 //   error: エラーが発生した場合
-func (sm *SessionManager) RegisterClient(passcode, userID string, conn *websocket.Conn) error {
+func (sm *SessionManager) RegisterClient(passcode, userID string, conn *websocket.Conn, protocolVersion int) error {
 	log.Printf("[SessionManager] RegisterClient called for user %s with passcode %s", userID, passcode)
 
 	// 既存の接続があれば状況に応じてクリーンアップ
 	sm.mu.Lock()
+	if !sm.reserveClientLocked(userID) {
+		sm.mu.Unlock()
+		log.Printf("[SessionManager] Rejecting client %s: connected clients reached the limit %d", userID, sm.maxClients)
+		return ErrServerBusy
+	}
 	if existingClient, exists := sm.clients[userID]; exists {
 		// 同一ユーザーの複数接続許可が有効な場合は、既存接続を保持
 		if os.Getenv("ALLOW_SAME_USER_JOIN") == "true" {
@@ -442,6 +642,9 @@ func (sm *SessionManager) RegisterClient(passcode, userID string, conn *websocke
 		Conn:   conn,
 		Send:   make(chan []byte, 512), // バッファサイズをさらに増加
 		RoomID: passcode, // 合言葉をRoomIDフィールドに格納
+
+		ProtocolVersion: protocolVersion,
+		lastActivity:    time.Now(),
 	}
 	
 	// 同一ユーザーの複数接続許可が有効な場合は、常に新しい接続を登録
@@ -459,6 +662,7 @@ func (sm *SessionManager) RegisterClient(passcode, userID string, conn *websocke
 			log.Printf("[SessionManager] Client %s replaced for passcode %s", userID, passcode)
 		}
 	}
+	sm.syncCapacityLocked()
 	sm.mu.Unlock()
 
 	// WebSocket接続の基本設定（パフォーマンス最適化）
@@ -489,7 +693,7 @@ func (sm *SessionManager) readPump(client *Client) {
 		}
 		
 		// クライアントの切断処理（unregisterのみ実行、コネクション切断はwritePumpで処理）
-		log.Printf("[SessionManager] ReadPump ending for user %s from room %s", client.UserID, client.RoomID)
+		slog.Debug("[SessionManager] readPump ending", "user_id", client.UserID, "passcode", client.RoomID)
 		
 		// unregister チャネルが閉じられていない場合のみ送信
 		select {
@@ -529,7 +733,7 @@ func (sm *SessionManager) readPump(client *Client) {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure, websocket.CloseNormalClosure) {
 				log.Printf("[SessionManager] WebSocket unexpected close error for user %s: %v", client.UserID, err)
 			} else if websocket.IsCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure, websocket.CloseNormalClosure) {
-				log.Printf("[SessionManager] WebSocket normal close for user %s: %v", client.UserID, err)
+				slog.Debug("[SessionManager] WebSocket normal close", "user_id", client.UserID, "error", err)
 			} else {
 				log.Printf("[SessionManager] WebSocket read error for user %s: %v", client.UserID, err)
 			}
@@ -539,22 +743,55 @@ func (sm *SessionManager) readPump(client *Client) {
 		
 		// メッセージサイズチェック
 		if len(message) == 0 {
-			log.Printf("[SessionManager] Received empty message from user %s", client.UserID)
+			slog.Debug("[SessionManager] Received empty message", "user_id", client.UserID)
 			continue
 		}
-		
-		// ログ出力を削減（パフォーマンス改善）
-		// log.Printf("[SessionManager] Received message from %s (Room %s): %s", client.UserID, client.RoomID, message)
+
+		// 受信メッセージの内容は操作のたびに出るためデバッグ時のみ出力する
+		slog.Debug("[SessionManager] Received message", "user_id", client.UserID, "passcode", client.RoomID, "message", string(message))
 
 		// 受信したJSONメッセージを PlayerInputEvent 構造体にパース
 		var inputEvent PlayerInputEvent
 		err = json.Unmarshal(message, &inputEvent)
 		if err != nil {
-			log.Printf("[SessionManager] Failed to unmarshal input message from %s: %v, message: %s", client.UserID, err, message)
+			sm.recordMalformedMessage(client, err)
 			continue // パース失敗時はこのメッセージをスキップ
 		}
 		inputEvent.UserID = client.UserID // 受信したメッセージのUserIDを上書き（セキュリティのため）
 
+		// 接続と異なるバージョンの形式のメッセージは解釈を誤るおそれがあるため処理しない
+		if err := checkMessageVersion(client, inputEvent.Version); err != nil {
+			sm.recordMalformedMessage(client, err)
+			continue
+		}
+
+		// 完全な状態スナップショットの要求は入力キューを通さずに応答する
+		// 連打でスナップショットの作成が積み上がらないよう、クライアントごとに ResyncMinInterval に1回だけ応答する
+		if inputEvent.Type == MessageRequestResync {
+			if client.allowResync(time.Now()) {
+				go sm.handleResyncRequest(client)
+			}
+			continue
+		}
+
+		// 一時停止・再開の要求も操作入力とは別に処理する（連打はクライアントごとに PauseMessageMinInterval に1回まで）
+		if inputEvent.Type == MessageRequestPause || inputEvent.Type == MessageRequestResume {
+			if client.allowPauseMessage(time.Now()) {
+				go sm.handlePauseMessage(client, inputEvent.Type)
+			}
+			continue
+		}
+
+		// 許可リストに無いアクションは入力キューに積まずに弾く
+		if !IsAllowedAction(inputEvent.Action) {
+			sm.recordUnknownAction(client, inputEvent.Action)
+			continue
+		}
+
+		// 許可されたゲーム操作だけを、アイドル接続の検出で操作として数える
+		// Pong や再同期・一時停止の要求は操作しないまま接続を維持できてしまうため数えない
+		client.touch(time.Now())
+
 		// プレイヤー入力を SessionManager の inputEvents チャネルに送信
 		// チャネルがブロックされないように非同期で送信
 		select {
@@ -562,6 +799,7 @@ func (sm *SessionManager) readPump(client *Client) {
 			// 正常に送信
 		default:
 			log.Printf("[SessionManager] Input events channel is full, dropping message from user %s", client.UserID)
+			sm.recordInputDrop(client.RoomID, client.UserID)
 		}
 	}
 }
@@ -673,17 +911,16 @@ func (sm *SessionManager) BroadcastToSpecificClient(userID, passcode string) {
 		return
 	}
 
-	// GameSessionを軽量な構造体に変換してからJSON形式でシリアライズ
-	lightweightState := session.ToLightweight()
-	stateJSON, err := json.Marshal(lightweightState)
+	sm.mu.RUnlock()
+
+	// GameSessionを軽量な構造体に変換してからJSON形式でシリアライズ（本人には自分用のフル版）
+	stateJSON, err := marshalLightweightFor(session, userID)
 	if err != nil {
-		sm.mu.RUnlock()
 		return
 	}
-	sm.mu.RUnlock()
 
 	// 指定されたクライアントにのみ送信（安全な送信メソッドを使用）
-	if !client.SafeSend(stateJSON) {
+	if !sm.sendOrDisconnect(client, stateJSON) {
 		log.Printf("[SessionManager] Failed to send to specific client %s (channel closed or full)", userID)
 	}
 }
@@ -710,16 +947,18 @@ func (sm *SessionManager) BroadcastGameState(passcode string) {
 	sm.lastBroadcast[passcode] = now
 	sm.broadcastMu.Unlock()
 	
-	// ログ出力を削減（パフォーマンス改善）
-	// log.Printf("[SessionManager] BroadcastGameState called for passcode: %s", passcode)
 	sm.mu.RLock()
 	session, ok := sm.sessions[passcode]
+	finished := ok && session.Status == "finished"
 	sm.mu.RUnlock()
 	if !ok {
-		log.Printf("[SessionManager] Attempted to broadcast for non-existent passcode: %s", passcode)
+		// セッション削除直後のtickなどで日常的に起きるためデバッグ時のみ出力する
+		slog.Debug("[SessionManager] Broadcast skipped for non-existent passcode", "passcode", passcode)
 		return
 	}
-	// log.Printf("[SessionManager] Session found for passcode %s, status: %s", passcode, session.Status)
+	if finished {
+		return // 終了済み（結果保持中）のセッションにはブロードキャストしない
+	}
 
 	// ゲーム状態更新イベントを SessionManager のブロードキャストチャネルに送信
 	// チャネルがフルの場合は最新の状態のみ保持（負荷軽減）
@@ -728,9 +967,9 @@ func (sm *SessionManager) BroadcastGameState(passcode string) {
 		RoomID: passcode, // 合言葉を使用
 		State:  session, // セッション全体の状態を送信
 	}:
-		// log.Printf("[SessionManager] Broadcast event sent to channel for passcode: %s", passcode)
 	default:
 		log.Printf("[SessionManager] Broadcast channel full, skipping update for passcode: %s", passcode)
+		sm.recordBroadcastDrop()
 	}
 }
 
@@ -739,6 +978,17 @@ func (sm *SessionManager) BroadcastGameState(passcode string) {
 // Parameters:
 //   passcode : 終了する合言葉
 func (sm *SessionManager) EndGameSession(passcode string) {
+	sm.endGameSession(passcode, "", "")
+}
+
+// endGameSession はゲームセッションを終了し、結果の保存・最終状態の送信を行います。
+// reason が空の場合はセッションの状態から終了理由と勝者を判定します。
+//
+// Parameters:
+//   passcode : 終了するセッションの合言葉
+//   reason   : 終了理由（EndReason* 定数、空なら自動判定）
+//   winnerID : reason を指定した場合の勝者のユーザーID
+func (sm *SessionManager) endGameSession(passcode, reason, winnerID string) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
@@ -753,54 +1003,105 @@ func (sm *SessionManager) EndGameSession(passcode string) {
 		return // 既に終了済み
 	}
 
-	session.Status = "finished" // ステータスを「終了済み」に設定
-	session.EndedAt = time.Now() // 終了日時を記録
-	
-	// 終了理由を判定してログ出力
-	if session.IsTimeUp() {
-		log.Printf("[SessionManager] Game session %s ended by TIME LIMIT (100 seconds).", passcode)
-	} else if session.Player1 != nil && session.Player1.IsGameOver {
-		log.Printf("[SessionManager] Game session %s ended by GAME OVER - Player1: %s", passcode, session.Player1.UserID)
-	} else if session.Player2 != nil && session.Player2.IsGameOver {
-		log.Printf("[SessionManager] Game session %s ended by GAME OVER - Player2: %s", passcode, session.Player2.UserID)
-	} else {
-		log.Printf("[SessionManager] Game session %s ended by OTHER REASON.", passcode)
+	// スコアとゲームオーバーは入力・自動落下が gameMu の下で更新するため、勝敗の判定から最終値の固定までを gameMu の下で行う
+	// （sm.mu → gameMu の順で取る。判定中に入力が適用されて僅差の勝者が入れ替わらないようにする）
+	session.gameMu.Lock()
+	// 終了理由と勝者を判定（IsTimeUp は playing 中のみ有効なのでステータス変更前に行う）
+	if reason == "" {
+		reason, winnerID = determineEndResult(session)
 	}
+	session.EndReason = reason
+	session.WinnerID = winnerID
 
-	// ゲーム結果をランキングデータベースに記録する
-	sm.saveGameResultsToRanking(session)
-
-	// クライアントにゲーム終了を通知 (最後の状態をブロードキャスト)
-	// mutexをアンロックしてからブロードキャスト（デッドロック回避）
+	session.setStatus("finished", "ended: "+reason) // ステータスを「終了済み」に設定
+	session.EndedAt = time.Now() // 終了日時を記録
+	for _, player := range []*PlayerGameState{session.Player1, session.Player2} {
+		if player != nil {
+			player.markPlayEnded(session.EndedAt) // APM/PPSを最終値で固定
+		}
+	}
+	// 保存するスコアと対戦履歴はロックの下でコピーし、データベースへの書き込みはロックを手放してから行う
+	results := sm.takeGameResultsLocked(session)
+	session.gameMu.Unlock()
+	session.isDeleting = true    // 削除完了までの間に同じ合言葉で参加されないようにする
+	session.StopGameLoop()       // セッション専用のゲームループを停止
+	sm.cancelSessionDisconnectGracesLocked(session)
+	log.Printf("[SessionManager] Game session %s ended (reason: %s, winner: %s)", passcode, reason, winnerID)
+
+	// クライアントにゲーム終了を通知（最後の状態をスロットリングを通さず直接送信）
+	// mutexをアンロックしてから送信（デッドロック回避）
 	sm.mu.Unlock()
-	sm.BroadcastGameState(passcode)
-	
-	// ゲーム終了の通知をクライアントが受信する時間を確保（3秒待機）
-	log.Printf("[SessionManager] Waiting 3 seconds for clients to receive final game state...")
-	time.Sleep(3 * time.Second)
-	
+	// ゲーム結果をランキングデータベースと対戦履歴に記録する
+	// DBの応答待ちで同じシャードの登録・入力・状態取得を止めないよう、sm.mu を保持せずに行う
+	sm.saveGameResults(results)
+	sm.sendFinalState(session)
+	if reason == EndReasonOpponentDisconnected || reason == EndReasonForfeit {
+		sm.sendGameResult(session)
+	}
+	// 状態遷移を game_end イベントで一度だけ確実に通知（スコアの参照で gameMu を取るため sm.mu の外で行う）
+	sm.sendTransitionEvent(session)
 	sm.mu.Lock()
 
-	// セッションに関連するクライアントのクリーンアップ
-	var clientsToUnregister []*Client
-	for userID, client := range sm.clients {
-		if client.RoomID == passcode {
-			clientsToUnregister = append(clientsToUnregister, client)
-			log.Printf("[SessionManager] Marking client %s for cleanup from ended passcode %s", userID, passcode)
+	// 最終状態の送信が完了したので、結果参照用の保持フェーズに移る
+	// 保持中はブロードキャストを行わず、GetRoomStatus や再接続でのみ最終状態を参照できる
+	session.isDeleting = false
+	time.AfterFunc(FinishedSessionRetention, func() {
+		sm.cleanupFinishedSession(passcode, session)
+	})
+	log.Printf("[SessionManager] Session %s finished; retaining results for %v", passcode, FinishedSessionRetention)
+}
+
+// sendFinalState は終了したセッションの最終状態を、そのセッションの全クライアントに直接送信します。
+// BroadcastGameState のスロットリングで最終状態が取りこぼされないようにするためのものです。
+func (sm *SessionManager) sendFinalState(session *GameSession) {
+	state := session.LightweightSnapshot()
+
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	for _, client := range sm.clients {
+		if client.RoomID == session.ID {
+			stateJSON, err := json.Marshal(state.ViewFor(client.UserID))
+			if err != nil {
+				log.Printf("[SessionManager] Error marshaling final game state for passcode %s: %v", session.ID, err)
+				continue
+			}
+			if !sm.sendOrDisconnect(client, stateJSON) {
+				log.Printf("[SessionManager] Failed to send final state to client %s (channel closed or full)", client.UserID)
+			}
 		}
 	}
+}
 
-	// クライアントの実際のクリーンアップ
-	for _, client := range clientsToUnregister {
-		// Sendチャネルを安全に閉じる
-		client.SafeClose()
-		delete(sm.clients, client.UserID)
-		log.Printf("[SessionManager] Cleaned up client %s from ended passcode %s", client.UserID, passcode)
+// cleanupFinishedSession は保持期間を過ぎた終了済みセッションとそのクライアントを削除します。
+// 保持中に同じ合言葉で新しいセッションが作られていた場合は何もしません。
+func (sm *SessionManager) cleanupFinishedSession(passcode string, session *GameSession) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if current, ok := sm.sessions[passcode]; !ok || current != session {
+		return
 	}
+	sm.removeSessionLocked(passcode)
+	log.Printf("[SessionManager] Cleaned up finished session %s after retention period", passcode)
+}
 
-	// セッションマネージャーのマップからセッションを削除
+// removeSessionLocked はセッションとそのセッションに接続中のクライアントを削除します。
+// 呼び出し側で sm.mu のロックを保持している必要があります。
+func (sm *SessionManager) removeSessionLocked(passcode string) {
+	for userID, client := range sm.clients {
+		if client.RoomID == passcode {
+			// Sendチャネルを安全に閉じる
+			client.SafeClose()
+			delete(sm.clients, userID)
+			log.Printf("[SessionManager] Cleaned up client %s from ended passcode %s", userID, passcode)
+		}
+	}
 	delete(sm.sessions, passcode)
-	log.Printf("[SessionManager] Removed session %s from sessions map", passcode)
+	sm.syncCapacityLocked()
+
+	sm.broadcastMu.Lock()
+	delete(sm.lastBroadcast, passcode)
+	sm.broadcastMu.Unlock()
 }
 
 // GetGameSession は指定された合言葉のゲームセッションを取得します。
@@ -813,15 +1114,30 @@ func (sm *SessionManager) GetGameSession(passcode string) (*GameSession, bool) {
 }
 
 // DeleteSession は指定された合言葉のセッションを削除します。
+// セッションが存在しない場合は ErrSessionNotFound を返します。
 func (sm *SessionManager) DeleteSession(passcode string) error {
+	// 対戦中のセッションは中止として終了させ、game_end イベントと対戦履歴に終了理由を残してから削除する
+	sm.mu.RLock()
+	session, exists := sm.sessions[passcode]
+	inProgress := exists && session.isInProgress()
+	sm.mu.RUnlock()
+	if inProgress {
+		sm.endGameSession(passcode, EndReasonCancelled, "")
+	}
+
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 	
-	session, exists := sm.sessions[passcode]
+	session, exists = sm.sessions[passcode]
 	if !exists {
-		return fmt.Errorf("passcode %s のセッションは見つかりませんでした", passcode)
+		// 終了処理の間に猶予切れの掃除や別の削除で消えた場合もここに来る
+		return fmt.Errorf("passcode %s: %w", passcode, ErrSessionNotFound)
 	}
 	
+	// 削除中としてマークし、セッション専用のゲームループを停止
+	session.isDeleting = true
+	session.StopGameLoop()
+
 	// セッションに接続されているクライアントをすべて切断
 	if session.Player1 != nil {
 		if client, ok := sm.clients[session.Player1.UserID]; ok {
@@ -841,11 +1157,88 @@ func (sm *SessionManager) DeleteSession(passcode string) error {
 	
 	// セッションをマップから削除
 	delete(sm.sessions, passcode)
+	sm.syncCapacityLocked()
 	log.Printf("[SessionManager] Deleted session %s", passcode)
 	
 	return nil
 }
 
+// DeleteSessionByPlayer は参加者の要求でセッションを削除します。
+// 参加者以外（作成者・対戦相手のどちらでもないユーザー）の要求は ErrNotParticipant で拒否します。
+// 対戦中のセッションを削除した場合は削除した側の棄権（EndReasonForfeit）として相手の勝ちで終了させ、
+// ランキングと対戦履歴に結果を記録してから削除します。
+//
+// Parameters:
+//   passcode : 削除するセッションの合言葉
+//   userID   : 削除を要求したユーザーのID
+func (sm *SessionManager) DeleteSessionByPlayer(passcode, userID string) error {
+	sm.mu.RLock()
+	session, exists := sm.sessions[passcode]
+	if !exists {
+		sm.mu.RUnlock()
+		return fmt.Errorf("passcode %s: %w", passcode, ErrSessionNotFound)
+	}
+	if session.playerOf(userID) == nil {
+		sm.mu.RUnlock()
+		return fmt.Errorf("user %s, passcode %s: %w", userID, passcode, ErrNotParticipant)
+	}
+	var winnerID string
+	inProgress := session.isInProgress()
+	if opponent := session.opponentOf(userID); inProgress && opponent != nil {
+		winnerID = opponent.UserID
+	}
+	sm.mu.RUnlock()
+
+	if inProgress {
+		log.Printf("[SessionManager] User %s forfeited session %s by deleting it", userID, passcode)
+		sm.endGameSession(passcode, EndReasonForfeit, winnerID)
+	}
+	return sm.DeleteSession(passcode)
+}
+
+// CancelRoom はマッチング待機中のルームを作成者が解散します。
+// 接続中のクライアントに解散イベントを送ってから切断し、セッションを削除します。
+// 対戦相手が参加済み、または waiting 以外の状態のルームは解散できません。
+//
+// Parameters:
+//   passcode : 解散するルームの合言葉
+//   userID   : 解散を要求したユーザーのID
+func (sm *SessionManager) CancelRoom(passcode, userID string) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	session, exists := sm.sessions[passcode]
+	if !exists {
+		return fmt.Errorf("passcode %s: %w", passcode, ErrSessionNotFound)
+	}
+	if session.isDeleting {
+		return fmt.Errorf("passcode %s: %w", passcode, ErrSessionClosing)
+	}
+	if session.Player1 == nil || session.Player1.UserID != userID {
+		return fmt.Errorf("passcode %s, user %s: %w", passcode, userID, ErrNotRoomOwner)
+	}
+	if session.Status != "waiting" || session.Player2 != nil {
+		return fmt.Errorf("passcode %s (status %s): %w", passcode, session.Status, ErrRoomNotCancellable)
+	}
+
+	session.isDeleting = true
+	session.StopGameLoop()
+
+	// 切断前に解散イベントを送る（Sendチャネルを閉じても送信済みのメッセージは書き出される）
+	event, err := json.Marshal(RoomEvent{Type: EventRoomCancelled, Version: ProtocolVersion, Message: "ルームが解散されました"})
+	if err == nil {
+		for _, client := range sm.clients {
+			if client.RoomID == passcode && !client.SafeSend(event) {
+				log.Printf("[SessionManager] Failed to send room cancelled event to client %s", client.UserID)
+			}
+		}
+	}
+
+	sm.removeSessionLocked(passcode)
+	log.Printf("[SessionManager] Room %s cancelled by owner %s", passcode, userID)
+	return nil
+}
+
 // Shutdown はSessionManagerを安全にシャットダウンします
 func (sm *SessionManager) Shutdown() {
 	log.Printf("[SessionManager] シャットダウン開始...")
@@ -865,42 +1258,104 @@ func (sm *SessionManager) Shutdown() {
 	// クライアントマップをクリア
 	sm.clients = make(map[string]*Client)
 	
-	// セッションマップをクリア
+	// セッションのゲームループを停止してからセッションマップをクリア
+	for _, session := range sm.sessions {
+		session.StopGameLoop()
+	}
 	sm.sessions = make(map[string]*GameSession)
 	sm.mu.Unlock()
+
+	// 未保存のゲーム結果をベストエフォートでフラッシュ
+	sm.flushResultRetries()
 	
 	log.Printf("[SessionManager] シャットダウン完了")
 } 
 
-// saveGameResultsToRanking はゲーム終了時に両プレイヤーのスコアをresultsテーブルに保存します
-func (sm *SessionManager) saveGameResultsToRanking(session *GameSession) {
-	if session == nil {
-		log.Printf("[SessionManager] saveGameResultsToRanking called with nil session")
-		return
+// gameResults は終了したセッションから保存するスコアと対戦履歴です。
+// データベースへの書き込みを sm.mu の外で行うため、終了処理でロックの下にコピーしておきます。
+type gameResults struct {
+	sessionID string
+	scores    []playerScore        // ランキングに保存するスコア（中止された対戦では空）
+	match     *models.MatchHistory // 対戦履歴（記録しない場合は nil）
+}
+
+// playerScore はランキングに保存する1プレイヤー分のスコアです。
+type playerScore struct {
+	userID  string
+	name    string // ログ用のプレイヤー名（Player1/Player2）
+	score   int
+	flagged bool
+}
+
+// takeGameResultsLocked はセッションのスコアと対戦履歴を保存用にコピーし、保存済みの印を付けます。
+// 切断・時間切れ・両者ゲームオーバーなど複数の経路から終了処理が呼ばれても、二重に記録しないようにします。
+// 既に取り出し済みの場合は nil を返します。
+// 印の確認・設定を sm.mu の下で、プレイヤーの状態の参照を gameMu の下で行うため、両方を保持した状態で呼び出してください。
+func (sm *SessionManager) takeGameResultsLocked(session *GameSession) *gameResults {
+	if session.resultsSaved {
+		log.Printf("[SessionManager] Results for session %s are already saved, skipping", session.ID)
+		return nil
 	}
+	session.resultsSaved = true
 
-	log.Printf("[SessionManager] Saving game results for session: %s", session.ID)
+	results := &gameResults{sessionID: session.ID, match: sm.matchHistoryOf(session)}
+	// 中止された対戦は最後までプレイしていないためランキングには記録せず、対戦履歴にだけ中止として残す
+	if session.EndReason != EndReasonCancelled {
+		results.scores = rankingScoresOf(session)
+	}
+	return results
+}
 
-	// プレイヤー1のスコアを保存
-	if session.Player1 != nil {
-		err := sm.savePlayerScore(session.Player1.UserID, session.Player1.Score, "Player1")
-		if err != nil {
-			log.Printf("[SessionManager] Failed to save Player1 score: %v", err)
+// saveGameResults は takeGameResultsLocked でコピーしたスコアを results テーブルに、対戦結果を対戦履歴に保存します。
+// データベースへの書き込みを行うため、sm.mu を保持せずに呼び出してください。
+func (sm *SessionManager) saveGameResults(results *gameResults) {
+	if results == nil {
+		return
+	}
+	if len(results.scores) > 0 {
+		log.Printf("[SessionManager] Saving game results for session: %s", results.sessionID)
+	}
+	for _, p := range results.scores {
+		if err := sm.savePlayerScore(p.userID, p.score, p.flagged, p.name); err != nil {
+			log.Printf("[SessionManager] Failed to save %s score: %v", p.name, err)
 		}
 	}
+	sm.saveMatchHistory(results.match)
+}
 
-	// プレイヤー2のスコアを保存
-	if session.Player2 != nil {
-		err := sm.savePlayerScore(session.Player2.UserID, session.Player2.Score, "Player2")
-		if err != nil {
-			log.Printf("[SessionManager] Failed to save Player2 score: %v", err)
+// rankingScoresOf はゲーム終了時の両プレイヤーのスコアを、ランキングに保存する形で返します。
+// 保存するスコアはハンディキャップの初期スコアボーナスを除いたもの（earnedScore）です。
+// validateGameResult でスコアとライン数・経過時間の整合性を検証し、異常なスコアには flagged の印を付けます。
+// 経過時間とプレイヤーの状態を参照するため、sm.mu と gameMu の両方を保持した状態で呼び出してください。
+func rankingScoresOf(session *GameSession) []playerScore {
+	var elapsed time.Duration
+	if !session.StartedAt.IsZero() {
+		elapsed = session.elapsedPlayTimeLocked(session.EndedAt)
+	}
+
+	var scores []playerScore
+	for _, p := range []struct {
+		state *PlayerGameState
+		name  string
+	}{{session.Player1, "Player1"}, {session.Player2, "Player2"}} {
+		if p.state == nil {
+			continue
 		}
+		// ハンディキャップの初期スコアボーナスはプレイで得たものではないため、ランキングには含めない
+		scores = append(scores, playerScore{
+			userID:  p.state.UserID,
+			name:    p.name,
+			score:   p.state.earnedScore(),
+			flagged: !validateGameResult(p.state, elapsed),
+		})
 	}
+	return scores
 }
 
-// savePlayerScore は個別のプレイヤーのスコアを保存します（result_handlerのロジックを使用）
-func (sm *SessionManager) savePlayerScore(userID string, score int, playerName string) error {
-	// result_handlerと同じバリデーション
+// savePlayerScore は個別のプレイヤーのスコアを保存します。
+// flagged は異常検知でスコアに印を付けるかどうかで、rankingScoresOf が validateGameResult で判定した結果を渡します。
+// クライアントからのスコア投稿（POST /api/results）は廃止したため、results への書き込みはすべてこの経路（と再試行）を通ります。
+func (sm *SessionManager) savePlayerScore(userID string, score int, flagged bool, playerName string) error {
 	if userID == "" {
 		return fmt.Errorf("user_idは必須です")
 	}
@@ -908,10 +1363,20 @@ func (sm *SessionManager) savePlayerScore(userID string, score int, playerName s
 		return fmt.Errorf("スコアは0以上である必要があります")
 	}
 
-	// resultsテーブルに保存
-	result, err := sm.resultRepo.CreateResult(nil, userID, score)
+	// resultsテーブルに保存（再試行でも同じ冪等キーを使い、コミット済みの保存を二重に記録しない）
+	key := newResultKey()
+	ctx, cancel := gameDBContext()
+	defer cancel()
+	result, err := sm.resultRepo.CreateResult(ctx, nil, userID, score, models.GameModeVersus, flagged, key)
+	if errors.Is(err, database.ErrUserNotFound) {
+		// ゲスト・テスト用のIDなど users に存在しないユーザーは再試行しても保存できないため、キューに積まない
+		log.Printf("[SessionManager] Skipping %s score: user %s does not exist in users: %v", playerName, userID, err)
+		return fmt.Errorf("スコア保存に失敗しました: %w", err)
+	}
 	if err != nil {
 		log.Printf("[SessionManager] Failed to save %s (%s) score to results: %v", playerName, userID, err)
+		// DBの一時的な障害でスコアが失われないよう、リトライキューに積んで後で再保存する
+		sm.enqueueResultRetry(userID, score, flagged, playerName, key)
 		return fmt.Errorf("スコア保存に失敗しました: %w", err)
 	}
 
@@ -920,6 +1385,35 @@ func (sm *SessionManager) savePlayerScore(userID string, score int, playerName s
 	return nil
 }
 
+// createSessionLocked は合言葉の新しいセッションをプレイヤー1として作成し、セッション専用のゲームループを起動します。
+// 呼び出し側で sm.mu のロックを保持し、合言葉のセッションが存在しないことを確認している必要があります。
+// プレイヤー1のゲーム状態はロックを取る前に loadPlayerState で読み込んでおきます。
+//
+// Parameters:
+//   passcode : 新しいセッションの合言葉（セッションIDにもなる）
+//   player1  : プレイヤー1のゲーム状態（loadPlayerState で読み込んだもの）
+//   rules    : ルームのルール（検証済みのもの）
+func (sm *SessionManager) createSessionLocked(passcode string, player1 *PlayerGameState, rules GameRules) error {
+	if err := sm.checkUserSessionLimitLocked(player1.UserID, passcode); err != nil {
+		return err
+	}
+	if !sm.reserveSessionLocked() {
+		log.Printf("[SessionManager] Rejecting new session %s: active sessions reached the limit %d", passcode, sm.maxSessions)
+		return ErrServerBusy
+	}
+	log.Printf("[SessionManager] Creating new session for passcode: %s", passcode)
+
+	// 新しいゲームセッションを初期化（IDは合言葉を使用）
+	newSession := newGameSessionWithPlayer(passcode, player1)
+	newSession.SetRules(rules)
+	sm.sessions[passcode] = newSession
+	log.Printf("[SessionManager] Created new game session with passcode: %s for player %s", passcode, player1.UserID)
+
+	// セッション専用のゲームループを起動（プレイ開始まではtickしても何もしない）
+	go sm.runSessionLoop(newSession)
+	return nil
+}
+
 // JoinRoomByPasscode は合言葉を使ってルームに参加します。
 // 合言葉のセッションが存在しない場合は新しく作成し、存在する場合は参加します。
 //
@@ -932,81 +1426,141 @@ func (sm *SessionManager) savePlayerScore(userID string, score int, playerName s
 //   bool: 新しくセッションを作成したかどうか（true: 作成、false: 既存セッションに参加）
 //   error: エラーが発生した場合
 func (sm *SessionManager) JoinRoomByPasscode(passcode, playerID, playerDeckID string) (string, bool, error) {
+	return sm.JoinRoomWithRules(passcode, playerID, playerDeckID, DefaultGameRules())
+}
+
+// JoinRoomWithRules はルールを指定して合言葉のルームに参加します。
+// ルールはセッションを新しく作成した場合のみ適用され、既存のルームに参加した場合はそのルームのルールに従います。
+// セッション数が上限に達している場合、新しいルームの作成は ErrServerBusy で拒否します（既存ルームへの参加は可能）。
+// ユーザーが既に他のルームでプレイ中の場合は ErrAlreadyPlaying、参加中のルーム数が上限（MAX_SESSIONS_PER_USER）に達している場合は
+// ErrUserSessionLimit で、作成・参加のどちらも拒否します。
+// デッキIDが空・UUID形式でない場合は、デッキを読み込まずに ErrDeckNotSelected で拒否します。
+//
+// Parameters:
+//   passcode     : ユーザーが入力した合言葉
+//   playerID     : 参加するプレイヤーのユーザーID
+//   playerDeckID : プレイヤーが使用するデッキのUUID
+//   rules        : ルームを作成する場合に適用するルール
+// Returns:
+//   string: セッションID（合言葉と同じ）
+//   bool: 新しくセッションを作成したかどうか（true: 作成、false: 既存セッションに参加）
+//   error: エラーが発生した場合
+func (sm *SessionManager) JoinRoomWithRules(passcode, playerID, playerDeckID string, rules GameRules) (string, bool, error) {
 	log.Printf("[SessionManager] JoinRoomByPasscode called with passcode: %s, playerID: %s, playerDeckID: %s", passcode, playerID, playerDeckID)
 	
 	// 合言葉のバリデーション
-	if passcode == "" {
-		return "", false, errors.New("合言葉が必要です")
-	}
 	if len(passcode) < 3 || len(passcode) > 20 {
-		return "", false, errors.New("合言葉は3文字以上20文字以下で入力してください")
+		return "", false, fmt.Errorf("passcode %q: %w", passcode, ErrInvalidPasscode)
 	}
-	
+	if err := rules.Validate(); err != nil {
+		return "", false, err
+	}
+	// デッキIDが無い・不正なままテスト用デッキでゲームが始まらないよう、デッキの読み込み前に拒否する
+	if err := ValidateDeckID(playerDeckID); err != nil {
+		log.Printf("[SessionManager] Rejecting join to %s by %s: %v", passcode, playerID, err)
+		return "", false, err
+	}
+
+	// 作成・参加できないことが分かっているリクエストではデッキを読み込まない
+	sm.mu.RLock()
+	err := sm.checkJoinLocked(passcode, playerID)
+	sm.mu.RUnlock()
+	if err != nil {
+		return "", false, err
+	}
+
+	// デッキ・草データのDBアクセスはロックの外で済ませる（作成・参加のどちらでも同じデッキを使う）
+	player, loadErr := sm.loadPlayerState(playerID, playerDeckID)
+
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
+	// 読み込み中に他のリクエストでルームの状態が変わっていることがあるため、ロックを取り直してから判定し直す
+	if err := sm.checkJoinLocked(passcode, playerID); err != nil {
+		return "", false, err
+	}
 	session, exists := sm.sessions[passcode]
+
+	// 結果参照のために保持中の終了済みセッションは、同じ合言葉での新しい対戦に置き換える
+	if exists && session.Status == "finished" {
+		log.Printf("[SessionManager] Replacing retained finished session for passcode: %s", passcode)
+		sm.removeSessionLocked(passcode)
+		exists = false
+	}
 	
 	if !exists {
 		// セッションが存在しない場合、新しく作成（プレイヤー1として）
-		log.Printf("[SessionManager] Creating new session for passcode: %s", passcode)
-		
-		// データベースからプレイヤーのデッキデータをロード
-		playerDeck, err := sm.dbService.GetDeckByID(playerDeckID)
-		if err != nil {
-			log.Printf("[SessionManager] Failed to get player deck %s: %v", playerDeckID, err)
-			return "", false, fmt.Errorf("failed to get player deck: %w", err)
+		if loadErr != nil {
+			return "", false, loadErr
 		}
-		
-		// 新しいゲームセッションを初期化（IDは合言葉を使用）
-		newSession, err := NewGameSession(passcode, playerID, playerDeck, sm.deckRepo)
-		if err != nil {
-			log.Printf("[SessionManager] Failed to create GameSession: %v", err)
-			return "", false, fmt.Errorf("failed to create game session: %w", err)
+		if err := sm.createSessionLocked(passcode, player, rules); err != nil {
+			return "", false, err
 		}
-		sm.sessions[passcode] = newSession
-		log.Printf("[SessionManager] Created new game session with passcode: %s for player %s", passcode, playerID)
-		
 		return passcode, true, nil
-		
-	} else {
-		// セッションが存在する場合、プレイヤー2として参加
-		log.Printf("[SessionManager] Session found for passcode: %s, current status: %s", passcode, session.Status)
-		
-		if session.Status != "waiting" {
-			log.Printf("[SessionManager] Session %s is not waiting (status: %s)", passcode, session.Status)
-			return "", false, errors.New("このルームは既にゲーム中または終了しています")
-		}
-		
-		if session.Player2 != nil {
-			log.Printf("[SessionManager] Session %s already has player2", passcode)
-			return "", false, errors.New("このルームは既に満室です")
+	}
+
+	// セッションが存在する場合、プレイヤー2として参加
+	log.Printf("[SessionManager] Adding player2 to existing session: %s", passcode)
+
+	// デッキの読み込み・初期化に失敗した場合も、待機中のプレイヤー1が待ち続けないよう通知する（ルームは待機中のまま残す）
+	if loadErr != nil {
+		session.recordEvent(joinFailedEvent(loadErr))
+		sm.notifyOpponentJoinFailedLocked(session)
+		return "", false, loadErr
+	}
+	session.setPlayer2State(player)
+	session.recordEvent("player2_joined")
+	log.Printf("[SessionManager] Player %s joined session %s successfully", playerID, passcode)
+
+	return passcode, false, nil
+}
+
+// checkJoinLocked は playerID が合言葉のルームを作成・参加できるかを、デッキを読み込まずに判定します。
+// ルームが無い場合と、結果参照のために保持中の終了済みセッションを置き換える場合は作成として判定します。
+// sm.mu を保持した状態で呼び出してください（読み取りロックでも構いません）。
+func (sm *SessionManager) checkJoinLocked(passcode, playerID string) error {
+	session, exists := sm.sessions[passcode]
+	if !exists || (!session.isDeleting && session.Status == "finished") {
+		// 置き換える終了済みのセッションはセッション数に数えない
+		active := sm.activeSessionsLocked()
+		if exists {
+			active--
 		}
-		
-		// 開発・テスト用: 環境変数でこの制限を無効化可能
-		if os.Getenv("ALLOW_SAME_USER_JOIN") != "true" {
-			if session.Player1 != nil && session.Player1.UserID == playerID {
-				log.Printf("[SessionManager] Player %s cannot join their own room %s", playerID, passcode)
-				return "", false, errors.New("自分が作成したルームには参加できません")
-			}
-		} else {
-			log.Printf("[SessionManager] ALLOW_SAME_USER_JOIN=true: Same user join allowed for testing")
+		if sm.maxSessions > 0 && active >= sm.maxSessions {
+			log.Printf("[SessionManager] Rejecting new session %s: active sessions reached the limit %d", passcode, sm.maxSessions)
+			return ErrServerBusy
 		}
+		return sm.checkUserSessionLimitLocked(playerID, passcode)
+	}
 
-		log.Printf("[SessionManager] Adding player2 to existing session: %s", passcode)
-		
-		// データベースからプレイヤー2のデッキデータをロード
-		playerDeck, err := sm.dbService.GetDeckByID(playerDeckID)
-		if err != nil {
-			log.Printf("[SessionManager] Failed to get player2 deck %s: %v", playerDeckID, err)
-			return "", false, fmt.Errorf("failed to get player2 deck: %w", err)
-		}
+	log.Printf("[SessionManager] Session found for passcode: %s, current status: %s", passcode, session.Status)
 
-		session.SetPlayer2(playerID, playerDeck, sm.deckRepo)
-		log.Printf("[SessionManager] Player %s joined session %s successfully", playerID, passcode)
+	// 終了処理中のセッションには参加させない（削除完了後に再試行すれば新規作成できる）
+	if session.isDeleting {
+		log.Printf("[SessionManager] Session %s is being closed", passcode)
+		return ErrSessionClosing
+	}
 
-		return passcode, false, nil
+	if session.Status != "waiting" {
+		log.Printf("[SessionManager] Session %s is not waiting (status: %s)", passcode, session.Status)
+		return fmt.Errorf("passcode %s (status %s): %w", passcode, session.Status, ErrRoomInProgress)
+	}
+
+	if session.Player2 != nil {
+		log.Printf("[SessionManager] Session %s already has player2", passcode)
+		return fmt.Errorf("passcode %s: %w", passcode, ErrRoomFull)
 	}
+
+	// 開発・テスト用: 環境変数でこの制限を無効化可能
+	if os.Getenv("ALLOW_SAME_USER_JOIN") != "true" {
+		if session.Player1 != nil && session.Player1.UserID == playerID {
+			log.Printf("[SessionManager] Player %s cannot join their own room %s", playerID, passcode)
+			return fmt.Errorf("passcode %s, user %s: %w", passcode, playerID, ErrOwnRoom)
+		}
+	}
+
+	// 既に他のルームでプレイ中・参加中のルーム数が上限に達している場合は参加させない
+	return sm.checkUserSessionLimitLocked(playerID, passcode)
 }
 
 // IsUserConnected は指定されたユーザーIDが現在接続中かどうかを確認します。
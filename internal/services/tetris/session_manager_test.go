@@ -0,0 +1,780 @@
+package tetris
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database/databasetest"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestSessionManager はRunループやDBを起動せずにテスト用のSessionManagerを作成します。
+// testDeckID はルームの作成・参加のテストで使うUUID形式のデッキIDです（デッキの読み込み前に失敗するテスト用）。
+const testDeckID = "6f1c2a4e-8b3d-4c5f-9a7e-1d2b3c4d5e6f"
+
+func newTestSessionManager() *SessionManager {
+	return &SessionManager{
+		sessions:      make(map[string]*GameSession),
+		clients:       make(map[string]*Client),
+		unregister:    make(chan *Client, 10),
+		broadcast:     make(chan *GameStateEvent, 10),
+		quit:          make(chan struct{}),
+		resultRepo:    &databasetest.ResultRepository{},
+		matchRepo:     &databasetest.MatchHistoryRepository{},
+		lastBroadcast: make(map[string]time.Time),
+		capacity:      &capacityUsage{},
+	}
+}
+
+// TestJoinRoomByPasscode_SessionClosing は終了処理中のセッションへの参加が再試行可能なエラーになることをテストします。
+func TestJoinRoomByPasscode_SessionClosing(t *testing.T) {
+	sm := newTestSessionManager()
+	session, err := NewGameSession("closing-room", "player1", &models.Deck{ID: "deck-1"}, nil)
+	assert.NoError(t, err)
+	session.isDeleting = true
+	sm.sessions["closing-room"] = session
+
+	_, _, err = sm.JoinRoomByPasscode("closing-room", "player2", testDeckID)
+
+	assert.True(t, errors.Is(err, ErrSessionClosing), "終了処理中のセッションには ErrSessionClosing を返すはず")
+	assert.Nil(t, session.Player2, "終了処理中のセッションにプレイヤーが追加されてはいけない")
+}
+
+// TestJoinRoom_DeckNotSelected はデッキIDが空・UUID形式でない場合に、デッキを読み込まずに ErrDeckNotSelected で拒否することをテストします。
+func TestJoinRoom_DeckNotSelected(t *testing.T) {
+	sm := newTestSessionManager()
+	sm.sessions["deck-room"] = newWaitingSession(t, "deck-room", "player1")
+
+	for _, deckID := range []string{"", "  ", "test-deck-id", "not-a-uuid"} {
+		_, _, err := sm.JoinRoomByPasscode("deck-room", "player2", deckID)
+		assert.ErrorIs(t, err, ErrDeckNotSelected, "deck_id %q", deckID)
+		_, err = sm.CreateRoomWithGeneratedPasscode("player2", deckID, DefaultPasscodeStyle, DefaultGameRules())
+		assert.ErrorIs(t, err, ErrDeckNotSelected, "deck_id %q", deckID)
+	}
+	assert.Nil(t, sm.sessions["deck-room"].Player2, "デッキが無いプレイヤーは参加させないはず")
+	assert.Len(t, sm.sessions, 1, "デッキが無いままルームを作成しないはず")
+
+	assert.ErrorIs(t, ValidateDeckID("not-a-uuid"), database.ErrInvalidDeckID)
+	assert.NoError(t, ValidateDeckID(testDeckID))
+}
+
+// TestDeleteSession_MarksDeleting はセッション削除時に削除中フラグが立ちゲームループが停止することをテストします。
+func TestDeleteSession_MarksDeleting(t *testing.T) {
+	sm := newTestSessionManager()
+	session, err := NewGameSession("deleted-room", "player1", &models.Deck{ID: "deck-1"}, nil)
+	assert.NoError(t, err)
+	sm.sessions["deleted-room"] = session
+
+	assert.NoError(t, sm.DeleteSession("deleted-room"))
+
+	assert.True(t, session.isDeleting)
+	_, exists := sm.GetGameSession("deleted-room")
+	assert.False(t, exists)
+	select {
+	case <-session.GameLoopDone:
+	default:
+		t.Error("削除されたセッションのゲームループは停止されるはず")
+	}
+}
+
+// TestEndGameSession_RetainsFinishedSession は終了済みセッションが保持期間中は削除されず、
+// 最終状態を参照できることをテストします。
+func TestEndGameSession_RetainsFinishedSession(t *testing.T) {
+	sm := newTestSessionManager()
+	session, err := NewGameSession("finished-room", "player1", &models.Deck{ID: "deck-1"}, nil)
+	assert.NoError(t, err)
+	session.SetPlayer2("player2", &models.Deck{ID: "deck-2"}, nil)
+	session.Status = "playing"
+	session.StartedAt = time.Now()
+	session.Player1.Score = 500
+	sm.sessions["finished-room"] = session
+
+	sm.EndGameSession("finished-room")
+
+	retained, exists := sm.GetGameSession("finished-room")
+	assert.True(t, exists, "終了直後のセッションは保持されるはず")
+	assert.Equal(t, "finished", retained.Status)
+	assert.False(t, retained.isDeleting, "最終状態の送信後は保持フェーズに移るはず")
+	assert.Equal(t, 500, retained.ToLightweight().Player1.Score, "保持中は最終状態を参照できるはず")
+
+	// 保持期間が過ぎるとクリーンアップされる
+	sm.cleanupFinishedSession("finished-room", session)
+	_, exists = sm.GetGameSession("finished-room")
+	assert.False(t, exists, "保持期間後のセッションは削除されるはず")
+}
+
+// TestStats_CountsDrops はチャネル満杯時のドロップが合言葉・ユーザー単位で集計されることをテストします。
+func TestStats_CountsDrops(t *testing.T) {
+	sm := newTestSessionManager()
+
+	sm.recordInputDrop("room-a", "user-1")
+	sm.recordInputDrop("room-a", "user-1")
+	sm.recordInputDrop("room-b", "user-2")
+	sm.recordBroadcastDrop()
+
+	stats := sm.Stats()
+	assert.Equal(t, int64(3), stats.InputDrops)
+	assert.Equal(t, int64(1), stats.BroadcastDrops)
+	assert.Equal(t, int64(2), stats.InputDropsByPasscode["room-a"])
+	assert.Equal(t, int64(1), stats.InputDropsByUser["user-2"])
+}
+
+// TestCancelRoom は待機中のルームを作成者のみが解散でき、対戦相手の参加後は解散できないことをテストします。
+func TestCancelRoom(t *testing.T) {
+	sm := newTestSessionManager()
+	session, err := NewGameSession("waiting-room", "player1", &models.Deck{ID: "deck-1"}, nil)
+	assert.NoError(t, err)
+	sm.sessions["waiting-room"] = session
+	owner := &Client{UserID: "player1", RoomID: "waiting-room", Send: make(chan []byte, 1)}
+	sm.clients["player1"] = owner
+
+	err = sm.CancelRoom("waiting-room", "someone-else")
+	assert.True(t, errors.Is(err, ErrNotRoomOwner), "作成者以外は解散できないはず")
+
+	assert.NoError(t, sm.CancelRoom("waiting-room", "player1"))
+	_, exists := sm.GetGameSession("waiting-room")
+	assert.False(t, exists)
+	msg := <-owner.Send
+	assert.Contains(t, string(msg), EventRoomCancelled, "切断前に解散イベントが送られるはず")
+
+	err = sm.CancelRoom("waiting-room", "player1")
+	assert.True(t, errors.Is(err, ErrSessionNotFound))
+
+	matched, err := NewGameSession("matched-room", "player1", &models.Deck{ID: "deck-1"}, nil)
+	assert.NoError(t, err)
+	matched.SetPlayer2("player2", &models.Deck{ID: "deck-2"}, nil)
+	sm.sessions["matched-room"] = matched
+
+	err = sm.CancelRoom("matched-room", "player1")
+	assert.True(t, errors.Is(err, ErrRoomNotCancellable), "対戦相手の参加後は解散できないはず")
+}
+
+// TestCheckInputRate_FlagsSession は1tick内の異常な操作数がしきい値回数続くとセッションがフラグ付けされることをテストします。
+func TestCheckInputRate_FlagsSession(t *testing.T) {
+	session, err := NewGameSession("rate-room", "player1", &models.Deck{ID: "deck-1"}, nil)
+	assert.NoError(t, err)
+	player := session.Player1
+	start := time.Now()
+
+	// 上限以内の操作ではフラグ付けされない
+	for i := 0; i < MaxInputsPerTick; i++ {
+		checkInputRate(session, player, start)
+	}
+	assert.Equal(t, 0, player.inputRate.anomalies)
+
+	for tick := 0; tick < SuspiciousInputThreshold; tick++ {
+		now := start.Add(time.Duration(tick+1) * SessionTickInterval)
+		for i := 0; i < MaxInputsPerTick*2; i++ {
+			checkInputRate(session, player, now)
+		}
+	}
+
+	assert.Equal(t, SuspiciousInputThreshold, player.inputRate.anomalies, "異常は1tickにつき1回だけ数えるはず")
+	assert.True(t, session.IsFlagged())
+}
+
+// TestRun_InputRateUnderGameLock は Run が入力のゲームオーバー判定と操作頻度の記録を、セッションループの
+// 自動落下と同じゲーム状態ロックの下で行うことをテストします（-race で競合を検出します）。
+func TestRun_InputRateUnderGameLock(t *testing.T) {
+	sm := newTestSessionManager()
+	sm.inputEvents = make(chan PlayerInputEvent, 2*MaxInputsPerTick)
+	session := newPlayingSession(t, "race-room")
+	sm.sessions["race-room"] = session
+	sm.clients["player1"] = &Client{UserID: "player1", RoomID: "race-room", Send: make(chan []byte, 256)}
+	go sm.Run()
+	defer sm.Shutdown()
+
+	// セッションループの自動落下と、ゲーム状態ロックの下での状態の参照を並行して動かす
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			session.gameMu.Lock()
+			AutoFall(session.Player1)
+			_ = session.Player1.inputRate.count
+			session.gameMu.Unlock()
+		}
+	}()
+	for i := 0; i < 2*MaxInputsPerTick; i++ {
+		sm.inputEvents <- PlayerInputEvent{UserID: "player1", Action: "move_left"}
+	}
+	<-done
+
+	assert.Eventually(t, func() bool {
+		session.gameMu.Lock()
+		defer session.gameMu.Unlock()
+		return session.Player1.IsGameOver || session.Player1.inputRate.count > 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+// newPlayingSession はテスト用に2人が参加済みのプレイ中セッションを作成します。
+func newPlayingSession(t *testing.T, passcode string) *GameSession {
+	session, err := NewGameSession(passcode, "player1", &models.Deck{ID: "deck-1"}, nil)
+	assert.NoError(t, err)
+	session.SetPlayer2("player2", &models.Deck{ID: "deck-2"}, nil)
+	session.Status = "playing"
+	session.StartedAt = time.Now()
+	return session
+}
+
+// TestHandleDisconnectTimeout_OpponentWins は再接続猶予切れで残ったプレイヤーの切断勝ちが確定し、
+// game_result イベントの送信・スコアと対戦履歴の保存が行われることをテストします。
+func TestHandleDisconnectTimeout_OpponentWins(t *testing.T) {
+	sm := newTestSessionManager()
+	session := newPlayingSession(t, "dc-room")
+	session.Player2.Score = 300
+	sm.sessions["dc-room"] = session
+	remaining := &Client{UserID: "player2", RoomID: "dc-room", Send: make(chan []byte, 4)}
+	sm.clients["player2"] = remaining
+
+	sm.handleDisconnectTimeout("dc-room", "player1", session)
+
+	assert.Equal(t, "finished", session.Status)
+	assert.Equal(t, EndReasonOpponentDisconnected, session.EndReason)
+	assert.Equal(t, "player2", session.WinnerID)
+	assert.Equal(t, 300, sm.resultRepo.(*databasetest.ResultRepository).Saved["player2"], "切断勝ちのスコアも保存されるはず")
+
+	matches := sm.matchRepo.(*databasetest.MatchHistoryRepository).Matches
+	if assert.Len(t, matches, 1) {
+		assert.Equal(t, EndReasonOpponentDisconnected, matches[0].EndReason)
+		assert.Equal(t, "player2", matches[0].WinnerID)
+	}
+
+	<-remaining.Send // 最終状態
+	var event GameResultEvent
+	assert.NoError(t, json.Unmarshal(<-remaining.Send, &event))
+	assert.Equal(t, EventGameResult, event.Type)
+	assert.Equal(t, EndReasonOpponentDisconnected, event.Reason)
+
+	var endEvent GameEndEvent
+	assert.NoError(t, json.Unmarshal(<-remaining.Send, &endEvent))
+	assert.Equal(t, EventGameEnd, endEvent.Type)
+	assert.Equal(t, "player2", endEvent.Result.WinnerID)
+	assert.Equal(t, 300, endEvent.Result.Scores["player2"])
+}
+
+// TestHandleDisconnectTimeout_Reconnected は猶予中に再接続したプレイヤーのセッションが終了しないことをテストします。
+func TestHandleDisconnectTimeout_Reconnected(t *testing.T) {
+	sm := newTestSessionManager()
+	session := newPlayingSession(t, "rc-room")
+	sm.sessions["rc-room"] = session
+	sm.clients["player1"] = &Client{UserID: "player1", RoomID: "rc-room", Send: make(chan []byte, 4)}
+
+	sm.handleDisconnectTimeout("rc-room", "player1", session)
+
+	assert.Equal(t, "playing", session.Status)
+	assert.Empty(t, sm.matchRepo.(*databasetest.MatchHistoryRepository).Matches)
+}
+
+// TestCheckAndStartGame_SendsGameStart はゲーム開始時に両クライアントへ game_start イベントが送信されることをテストします。
+func TestCheckAndStartGame_SendsGameStart(t *testing.T) {
+	sm := newTestSessionManager()
+	session := newPlayingSession(t, "start-room")
+	session.Status = "waiting"
+	sm.sessions["start-room"] = session
+	for _, userID := range []string{"player1", "player2"} {
+		sm.clients[userID] = &Client{UserID: userID, RoomID: "start-room", Send: make(chan []byte, 4)}
+	}
+
+	sm.CheckAndStartGame("start-room")
+
+	assert.Equal(t, "playing", session.Status)
+	for _, userID := range []string{"player1", "player2"} {
+		var event GameStartEvent
+		assert.NoError(t, json.Unmarshal(<-sm.clients[userID].Send, &event))
+		assert.Equal(t, EventGameStart, event.Type)
+		assert.Equal(t, "player1", event.Player1ID)
+		assert.Equal(t, "player2", event.Player2ID)
+	}
+}
+
+// TestTransitionEventJSON はセッションの状態ごとに対応する遷移イベントの種類をテストします。
+func TestTransitionEventJSON(t *testing.T) {
+	session := newPlayingSession(t, "transition-room")
+	tests := map[string]string{
+		"waiting":   "",
+		"countdown": EventGameStart,
+		"playing":   EventGameStart,
+		"paused":    EventGameStart,
+		"finished":  EventGameEnd,
+	}
+	for status, want := range tests {
+		t.Run(status, func(t *testing.T) {
+			message, err := transitionEventJSON(session, status)
+			assert.NoError(t, err)
+			if want == "" {
+				assert.Nil(t, message)
+				return
+			}
+			var event struct {
+				Type string `json:"type"`
+			}
+			assert.NoError(t, json.Unmarshal(message, &event))
+			assert.Equal(t, want, event.Type)
+		})
+	}
+}
+
+// TestSendReliable_RetriesWhenChannelFull はSendチャネルが満杯でも空き次第イベントが届くことをテストします。
+func TestSendReliable_RetriesWhenChannelFull(t *testing.T) {
+	client := &Client{UserID: "player1", Send: make(chan []byte, 1)}
+	client.Send <- []byte("state")
+
+	sendReliable(client, []byte("event"))
+
+	assert.Equal(t, "state", string(<-client.Send))
+	select {
+	case msg := <-client.Send:
+		assert.Equal(t, "event", string(msg))
+	case <-time.After(TransitionEventRetryInterval * TransitionEventMaxRetries):
+		t.Fatal("遷移イベントが再送されませんでした")
+	}
+}
+
+// TestJoinRoomByPasscode_SentinelErrors は参加できない理由ごとに対応するセンチネルエラーが返ることをテストします。
+func TestJoinRoomByPasscode_SentinelErrors(t *testing.T) {
+	t.Setenv("ALLOW_SAME_USER_JOIN", "")
+	sm := newTestSessionManager()
+
+	playing := newPlayingSession(t, "playing-room")
+	sm.sessions["playing-room"] = playing
+
+	full := newPlayingSession(t, "full-room")
+	full.Status = "waiting"
+	sm.sessions["full-room"] = full
+
+	waiting, err := NewGameSession("waiting-room", "player1", &models.Deck{ID: "deck-1"}, nil)
+	assert.NoError(t, err)
+	sm.sessions["waiting-room"] = waiting
+
+	tests := []struct {
+		passcode string
+		playerID string
+		want     error
+	}{
+		{"ab", "player3", ErrInvalidPasscode},
+		{"playing-room", "player3", ErrRoomInProgress},
+		{"full-room", "player3", ErrRoomFull},
+		{"waiting-room", "player1", ErrOwnRoom},
+	}
+	for _, tt := range tests {
+		_, _, err := sm.JoinRoomByPasscode(tt.passcode, tt.playerID, testDeckID)
+		assert.ErrorIs(t, err, tt.want, tt.passcode)
+	}
+}
+
+// TestSendOrDisconnect_DisconnectsSlowClient は連続して送信に失敗したクライアントに切断通知を送り、登録解除することをテストします。
+func TestSendOrDisconnect_DisconnectsSlowClient(t *testing.T) {
+	sm := newTestSessionManager()
+	client := &Client{UserID: "slow", RoomID: "room", Send: make(chan []byte, 1)}
+	assert.True(t, sm.sendOrDisconnect(client, []byte("state-0")))
+
+	for i := 1; i < slowClientMaxSendFailures; i++ {
+		assert.False(t, sm.sendOrDisconnect(client, []byte("state")))
+	}
+	select {
+	case <-sm.unregister:
+		t.Fatal("しきい値未満では切断しないはず")
+	default:
+	}
+
+	assert.False(t, sm.sendOrDisconnect(client, []byte("state")))
+
+	select {
+	case unregistered := <-sm.unregister:
+		assert.Same(t, client, unregistered)
+	case <-time.After(time.Second):
+		t.Fatal("しきい値に達したクライアントは登録解除されるはず")
+	}
+
+	// 切断処理は一度だけ
+	assert.False(t, sm.sendOrDisconnect(client, []byte("state")))
+	assert.False(t, client.markSlowDisconnect(slowClientMaxSendFailures))
+
+	var event RoomEvent
+	assert.NoError(t, json.Unmarshal(<-client.Send, &event))
+	assert.Equal(t, EventConnectionUnstable, event.Type, "古い状態は捨てて切断通知を送るはず")
+}
+
+// TestSafeSend_ResetsFailuresOnSuccess は送信成功で連続失敗カウンタがリセットされることをテストします。
+func TestSafeSend_ResetsFailuresOnSuccess(t *testing.T) {
+	client := &Client{UserID: "user", Send: make(chan []byte, 1)}
+	client.SafeSend([]byte("a"))
+	client.SafeSend([]byte("b"))
+	client.SafeSend([]byte("c"))
+	assert.Equal(t, 2, client.sendFailures)
+
+	<-client.Send
+	assert.True(t, client.SafeSend([]byte("d")))
+	assert.Equal(t, 0, client.sendFailures)
+}
+
+// TestSendFullResync はピースキューの先頭とホールド可否を含む完全なスナップショットを本人に送ることをテストします。
+func TestSendFullResync(t *testing.T) {
+	sm := newTestSessionManager()
+	session := newPlayingSession(t, "resync-room")
+	sm.sessions["resync-room"] = session
+	client := &Client{UserID: "player1", RoomID: "resync-room", Send: make(chan []byte, 4)}
+	sm.clients["player1"] = client
+
+	assert.NoError(t, sm.SendFullResync("player1", "resync-room"))
+
+	var event ResyncEvent
+	assert.NoError(t, json.Unmarshal(<-client.Send, &event))
+	assert.Equal(t, EventResync, event.Type)
+	assert.Equal(t, session.Player1.pieceQueue[:ResyncQueuePreview], event.NextQueue)
+	assert.True(t, event.CanHold)
+	assert.Equal(t, "playing", event.State.Status)
+	assert.Equal(t, session.Player1.NextPiece.Type, event.State.Player1.NextPiece.Type)
+
+	err := sm.SendFullResync("player2", "resync-room")
+	assert.True(t, errors.Is(err, ErrClientNotConnected), "未接続のユーザーには送れないはず")
+	err = sm.SendFullResync("player1", "missing-room")
+	assert.True(t, errors.Is(err, ErrSessionNotFound))
+}
+
+// TestAllowResync は同じクライアントの resync 要求に ResyncMinInterval に1回だけ応答することをテストします。
+func TestAllowResync(t *testing.T) {
+	client := &Client{UserID: "player1", RoomID: "resync-room"}
+	now := time.Now()
+
+	assert.True(t, client.allowResync(now))
+	assert.False(t, client.allowResync(now.Add(ResyncMinInterval/2)), "間隔内の要求は捨てるはず")
+	assert.False(t, client.allowResync(now.Add(ResyncMinInterval-time.Millisecond)), "捨てた要求で間隔は延びないが、間隔内は捨てるはず")
+	assert.True(t, client.allowResync(now.Add(ResyncMinInterval)))
+	assert.False(t, client.allowResync(now.Add(ResyncMinInterval+time.Millisecond)))
+}
+
+// TestRecordInvalidMessages は未知のアクションとパース失敗のメッセージが集計に反映されることをテストします。
+func TestRecordInvalidMessages(t *testing.T) {
+	sm := newTestSessionManager()
+	client := &Client{UserID: "player1", RoomID: "invalid-room"}
+
+	sm.recordUnknownAction(client, "teleport")
+	sm.recordUnknownAction(client, "")
+	sm.recordMalformedMessage(client, errors.New("unexpected end of JSON input"))
+
+	stats := sm.Stats()
+	assert.Equal(t, int64(2), stats.UnknownActions)
+	assert.Equal(t, int64(1), stats.MalformedMessages)
+	assert.Equal(t, 3, client.invalidMessages, "クライアントごとの不正メッセージ数も数えるはず")
+}
+
+// failingDeckRepository は配置データの取得に常に失敗するテスト用DeckRepositoryです。
+type failingDeckRepository struct {
+	database.DeckRepository
+}
+
+func (f *failingDeckRepository) GetTetriminoPlacementsByDeckID(ctx context.Context, tx *sql.Tx, deckID string) ([]models.TetriminoPlacement, error) {
+	return nil, errors.New("connection reset")
+}
+
+// TestSetPlayer2_DeckLoadFailure はデッキの読み込みに失敗した場合、代替が無効ならプレイヤー2を設定せずに
+// ErrDeckLoadFailed を返し、待機中のプレイヤー1に参加失敗が通知されることをテストします。
+func TestSetPlayer2_DeckLoadFailure(t *testing.T) {
+	original := deckPlacementFallback
+	defer func() { deckPlacementFallback = original }()
+
+	sm := newTestSessionManager()
+	session, err := NewGameSession("deck-fail-room", "player1", &models.Deck{ID: "deck-1"}, nil)
+	assert.NoError(t, err)
+	sm.sessions["deck-fail-room"] = session
+	waiting := &Client{UserID: "player1", RoomID: "deck-fail-room", Send: make(chan []byte, 4)}
+	sm.clients["player1"] = waiting
+
+	deckPlacementFallback = false
+	err = session.SetPlayer2("player2", &models.Deck{ID: "deck-2"}, &failingDeckRepository{})
+	assert.ErrorIs(t, err, ErrDeckLoadFailed)
+	assert.Nil(t, session.Player2, "初期化に失敗したプレイヤーは設定されないはず")
+
+	sm.notifyOpponentJoinFailedLocked(session)
+	var event OpponentJoinFailedEvent
+	assert.NoError(t, json.Unmarshal(<-waiting.Send, &event))
+	assert.Equal(t, EventOpponentJoinFailed, event.Type)
+
+	deckPlacementFallback = true
+	assert.NoError(t, session.SetPlayer2("player2", &models.Deck{ID: "deck-2"}, &failingDeckRepository{}))
+	assert.NotNil(t, session.Player2, "代替が有効ならランダムなスコアで参加できるはず")
+}
+
+// TestJoinFailedEvent はプレイヤー2の参加に失敗した原因が、イベント履歴の名前に変換されることをテストします。
+func TestJoinFailedEvent(t *testing.T) {
+	assert.Equal(t, "player2_join_failed: deck_load_failed", joinFailedEvent(fmt.Errorf("failed to initialize player: %w", ErrDeckLoadFailed)))
+	assert.Equal(t, "player2_join_failed: deck_not_owned", joinFailedEvent(fmt.Errorf("failed to get player deck: %w", database.ErrDeckNotOwned)))
+	assert.Equal(t, "player2_join_failed: deck_not_found", joinFailedEvent(fmt.Errorf("failed to get player deck: %w", database.ErrDeckNotFound)))
+}
+
+// TestCheckJoinLocked はデッキを読み込む前の判定で、参加できないルームを拒否し、
+// 置き換える終了済みのセッションはセッション数の上限に数えないことをテストします。
+func TestCheckJoinLocked(t *testing.T) {
+	sm := newTestSessionManager()
+	sm.maxSessions = 2
+	sm.sessions["playing-room"] = newPlayingSession(t, "playing-room")
+	sm.sessions["finished-room"] = &GameSession{ID: "finished-room", Status: "finished"}
+
+	assert.ErrorIs(t, sm.checkJoinLocked("playing-room", "player3"), ErrRoomInProgress)
+	assert.NoError(t, sm.checkJoinLocked("finished-room", "player3"), "終了済みのセッションは置き換えるので上限に数えないはず")
+	assert.ErrorIs(t, sm.checkJoinLocked("new-room", "player3"), ErrServerBusy)
+}
+
+// countingResultRepository は保存の回数を数えるテスト用ResultRepositoryです。
+type countingResultRepository struct {
+	databasetest.ResultRepository
+	mu    sync.Mutex
+	count int
+}
+
+func (f *countingResultRepository) CreateResult(ctx context.Context, tx *sql.Tx, userID string, score int, mode models.GameMode, flagged bool, key string) (*models.Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.count++
+	return f.ResultRepository.CreateResult(ctx, tx, userID, score, mode, flagged, key)
+}
+
+// TestEndGameSession_SavesResultsOnce は両者ゲームオーバー後の遅延した終了処理と時間切れの即時の終了処理が
+// 同時に走っても、スコアと対戦履歴が一度だけ保存されることをテストします。
+func TestEndGameSession_SavesResultsOnce(t *testing.T) {
+	sm := newTestSessionManager()
+	repo := &countingResultRepository{}
+	sm.resultRepo = repo
+	session := newPlayingSession(t, "once-room")
+	sm.sessions["once-room"] = session
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, end := range []func(){
+		func() { sm.EndGameSession("once-room") },                      // セッションループの両者ゲームオーバー
+		func() { sm.endGameSession("once-room", EndReasonTimeUp, "") }, // 時間切れ
+	} {
+		wg.Add(1)
+		go func(end func()) {
+			defer wg.Done()
+			<-start
+			end()
+		}(end)
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, 2, repo.count, "2人分のスコアが一度ずつ保存されるはず")
+	assert.Len(t, sm.matchRepo.(*databasetest.MatchHistoryRepository).Matches, 1)
+
+	// finished のチェックをすり抜けた場合も保存済みフラグで二重保存しない
+	session.Status = "playing"
+	sm.EndGameSession("once-room")
+	assert.Equal(t, 2, repo.count)
+	assert.Len(t, sm.matchRepo.(*databasetest.MatchHistoryRepository).Matches, 1)
+}
+
+// blockingResultRepository は release が閉じられるまで保存を待たせるテスト用ResultRepositoryです（応答の遅いDBの代わり）。
+type blockingResultRepository struct {
+	databasetest.ResultRepository
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (f *blockingResultRepository) CreateResult(ctx context.Context, tx *sql.Tx, userID string, score int, mode models.GameMode, flagged bool, key string) (*models.Result, error) {
+	f.once.Do(func() { close(f.started) })
+	<-f.release
+	return f.ResultRepository.CreateResult(ctx, tx, userID, score, mode, flagged, key)
+}
+
+// TestEndGameSession_SavesResultsWithoutLock はゲーム結果の保存中に sm.mu を保持せず、
+// DBの応答待ちの間も同じシャードのセッションの参照がブロックされないことをテストします。
+func TestEndGameSession_SavesResultsWithoutLock(t *testing.T) {
+	sm := newTestSessionManager()
+	repo := &blockingResultRepository{started: make(chan struct{}), release: make(chan struct{})}
+	sm.resultRepo = repo
+	sm.sessions["slow-db-room"] = newPlayingSession(t, "slow-db-room")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sm.endGameSession("slow-db-room", EndReasonTimeUp, "")
+	}()
+	<-repo.started
+
+	locked := make(chan struct{})
+	go func() {
+		sm.mu.Lock()
+		sm.mu.Unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("保存の完了を待たずに sm.mu を取れるはず")
+	}
+
+	close(repo.release)
+	<-done
+	assert.Len(t, repo.Saved, 2)
+	assert.Len(t, sm.matchRepo.(*databasetest.MatchHistoryRepository).Matches, 1)
+}
+
+// TestSendGameResult_MessagePerClient は棄権・切断での終了時に、両者が接続していても
+// 勝者にだけ勝ちの文言を送り、棄権・切断した本人には負けの文言を送ることをテストします。
+func TestSendGameResult_MessagePerClient(t *testing.T) {
+	for _, tt := range []struct {
+		reason     string
+		winnerText string
+		loserText  string
+	}{
+		{EndReasonForfeit, "相手が対戦を放棄したため、あなたの勝ちです", "対戦を放棄したため、あなたの負けです"},
+		{EndReasonOpponentDisconnected, "相手が切断したため、あなたの勝ちです", "再接続の猶予時間が過ぎたため、あなたの負けです"},
+	} {
+		t.Run(tt.reason, func(t *testing.T) {
+			sm := newTestSessionManager()
+			session := newPlayingSession(t, "result-room")
+			sm.sessions["result-room"] = session
+			loser := &Client{UserID: "player1", RoomID: "result-room", Send: make(chan []byte, 4)}
+			winner := &Client{UserID: "player2", RoomID: "result-room", Send: make(chan []byte, 4)}
+			sm.clients["player1"] = loser
+			sm.clients["player2"] = winner
+
+			sm.endGameSession("result-room", tt.reason, "player2")
+
+			for _, c := range []struct {
+				client *Client
+				want   string
+			}{{winner, tt.winnerText}, {loser, tt.loserText}} {
+				<-c.client.Send // 最終状態
+				var result GameResultEvent
+				assert.NoError(t, json.Unmarshal(<-c.client.Send, &result))
+				assert.Equal(t, EventGameResult, result.Type)
+				assert.Equal(t, "player2", result.WinnerID)
+				assert.Equal(t, c.want, result.Message, c.client.UserID)
+			}
+		})
+	}
+}
+
+// TestListSessions はセッション一覧に参加済みのプレイヤー数と、そのルームに接続中のプレイヤー数が含まれることをテストします。
+func TestListSessions(t *testing.T) {
+	sm := newTestSessionManager()
+	sm.sessions["b-room"] = &GameSession{ID: "b-room", Status: "playing", Player1: &PlayerGameState{UserID: "u1"}, Player2: &PlayerGameState{UserID: "u2"}}
+	sm.sessions["a-room"] = &GameSession{ID: "a-room", Status: "waiting", Player1: &PlayerGameState{UserID: "u3"}}
+	sm.clients["u1"] = &Client{UserID: "u1", RoomID: "b-room", Send: make(chan []byte, 1)}
+	sm.clients["u3"] = &Client{UserID: "u3", RoomID: "other-room", Send: make(chan []byte, 1)}
+
+	assert.Equal(t, []SessionSummary{
+		{Passcode: "a-room", Status: "waiting", Players: 1, ConnectedPlayers: 0},
+		{Passcode: "b-room", Status: "playing", Players: 2, ConnectedPlayers: 1},
+	}, sm.ListSessions())
+
+	connections := sm.ListConnections()
+	assert.Len(t, connections, 2)
+	assert.Equal(t, ConnectionInfo{UserID: "u1", RoomID: "b-room", State: ConnectionStateConnected}, connections[0])
+}
+
+// TestEndReason_EachPath は時間切れ・トップアウト・中止の各終了経路で、セッション・game_end イベント・対戦履歴に
+// 正しい終了理由が設定されることをテストします（切断は TestHandleDisconnectTimeout_OpponentWins で確認）。
+func TestEndReason_EachPath(t *testing.T) {
+	tests := []struct {
+		name       string
+		end        func(sm *SessionManager, session *GameSession)
+		wantReason string
+		wantWinner string
+		wantScores int // ランキングに保存されるスコアの件数
+	}{
+		{
+			name: "時間切れ",
+			end: func(sm *SessionManager, session *GameSession) {
+				session.StartedAt = time.Now().Add(-session.TimeLimit - time.Second)
+				session.Player1.Score = 500
+				sm.EndGameSession(session.ID)
+			},
+			wantReason: EndReasonTimeUp,
+			wantWinner: "player1",
+			wantScores: 2,
+		},
+		{
+			name: "トップアウト",
+			end: func(sm *SessionManager, session *GameSession) {
+				session.Player2.IsGameOver = true
+				sm.EndGameSession(session.ID)
+			},
+			wantReason: EndReasonGameOver,
+			wantWinner: "player1",
+			wantScores: 2,
+		},
+		{
+			name: "中止",
+			end: func(sm *SessionManager, session *GameSession) {
+				assert.NoError(t, sm.DeleteSession(session.ID))
+			},
+			wantReason: EndReasonCancelled,
+			wantWinner: "",
+			wantScores: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newTestSessionManager()
+			session := newPlayingSession(t, "reason-room")
+			sm.sessions["reason-room"] = session
+			client := &Client{UserID: "player2", RoomID: "reason-room", Send: make(chan []byte, 4)}
+			sm.clients["player2"] = client
+
+			tt.end(sm, session)
+
+			assert.Equal(t, tt.wantReason, session.EndReason)
+			assert.Equal(t, tt.wantWinner, session.WinnerID)
+			assert.Len(t, sm.resultRepo.(*databasetest.ResultRepository).Saved, tt.wantScores)
+			matches := sm.matchRepo.(*databasetest.MatchHistoryRepository).Matches
+			if assert.Len(t, matches, 1) {
+				assert.Equal(t, tt.wantReason, matches[0].EndReason)
+			}
+
+			<-client.Send // 最終状態
+			var endEvent GameEndEvent
+			assert.NoError(t, json.Unmarshal(<-client.Send, &endEvent))
+			assert.Equal(t, EventGameEnd, endEvent.Type)
+			assert.Equal(t, tt.wantReason, endEvent.Result.Reason)
+		})
+	}
+}
+
+// TestDeleteSessionByPlayer は参加者以外の削除を拒否し、対戦中に参加者が削除した場合は
+// 削除した側の棄権として相手の勝ちで結果を記録してから削除することをテストします。
+func TestDeleteSessionByPlayer(t *testing.T) {
+	sm := newTestSessionManager()
+	session := newPlayingSession(t, "forfeit-room")
+	sm.sessions["forfeit-room"] = session
+	client := &Client{UserID: "player2", RoomID: "forfeit-room", Send: make(chan []byte, 4)}
+	sm.clients["player2"] = client
+
+	err := sm.DeleteSessionByPlayer("forfeit-room", "stranger")
+	assert.ErrorIs(t, err, ErrNotParticipant)
+	assert.Contains(t, sm.sessions, "forfeit-room", "参加者以外の要求では削除しない")
+	assert.ErrorIs(t, sm.DeleteSessionByPlayer("missing-room", "player1"), ErrSessionNotFound)
+	assert.ErrorIs(t, sm.DeleteSession("missing-room"), ErrSessionNotFound, "終了処理の間に消えたセッションも見つからない扱いのはず")
+
+	assert.NoError(t, sm.DeleteSessionByPlayer("forfeit-room", "player1"))
+
+	assert.NotContains(t, sm.sessions, "forfeit-room")
+	assert.Equal(t, EndReasonForfeit, session.EndReason)
+	assert.Equal(t, "player2", session.WinnerID)
+	assert.Len(t, sm.resultRepo.(*databasetest.ResultRepository).Saved, 2, "棄権は結果としてランキングに記録する")
+	matches := sm.matchRepo.(*databasetest.MatchHistoryRepository).Matches
+	if assert.Len(t, matches, 1) {
+		assert.Equal(t, EndReasonForfeit, matches[0].EndReason)
+		assert.Equal(t, "player2", matches[0].WinnerID)
+	}
+
+	<-client.Send // 最終状態
+	var result GameResultEvent
+	assert.NoError(t, json.Unmarshal(<-client.Send, &result))
+	assert.Equal(t, EventGameResult, result.Type)
+	assert.Equal(t, EndReasonForfeit, result.Reason)
+	assert.Equal(t, "player2", result.WinnerID)
+}
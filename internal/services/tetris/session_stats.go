@@ -0,0 +1,88 @@
+package tetris
+
+import "log"
+
+// DropWarningThreshold はチャネル満杯によるドロップ数の警告しきい値です。
+// 累計がこの値の倍数に達するたびに警告ログを出し、チャネルバッファサイズ見直しの判断材料にします。
+const DropWarningThreshold = 100
+
+// SessionStats は SessionManager の稼働状況とメッセージドロップの集計です。
+type SessionStats struct {
+	ActiveSessions       int              `json:"active_sessions"`         // 保持中のセッション数
+	ConnectedClients     int              `json:"connected_clients"`       // 接続中のクライアント数
+	InputDrops           int64            `json:"input_drops"`             // 入力チャネル満杯で捨てた入力の累計
+	BroadcastDrops       int64            `json:"broadcast_drops"`         // ブロードキャストチャネル満杯で捨てた更新の累計
+	InputDropsByPasscode map[string]int64 `json:"input_drops_by_passcode"` // 合言葉ごとの入力ドロップ数
+	InputDropsByUser     map[string]int64 `json:"input_drops_by_user"`     // ユーザーごとの入力ドロップ数
+	ResultRetriesPending int              `json:"result_retries_pending"`  // 再保存待ちのゲーム結果数
+	ResultRetrySuccesses int64            `json:"result_retry_successes"`  // 再試行で保存できたゲーム結果の累計
+	ResultSaveAbandoned  int64            `json:"result_save_abandoned"`   // 再試行上限に達して失われたゲーム結果の累計
+	UnknownActions       int64            `json:"unknown_actions"`         // 許可リストに無いアクションとして弾いた入力の累計
+	MalformedMessages    int64            `json:"malformed_messages"`      // JSONとしてパースできなかったメッセージの累計
+	MaxSessions          int              `json:"max_sessions"`            // 保持できるセッション数の上限（0で無制限）
+	MaxClients           int              `json:"max_clients"`             // 接続できるクライアント数の上限（0で無制限）
+	SessionUsage         float64          `json:"session_usage"`           // セッション数の上限に対する使用率（0〜1、オートスケールの判断材料）
+	ClientUsage          float64          `json:"client_usage"`            // 接続数の上限に対する使用率（0〜1）
+}
+
+// recordInputDrop は入力イベントのドロップを記録します。
+// 入力の取りこぼしはゲーム体験に直結するため、合言葉・ユーザー単位でも集計します。
+func (sm *SessionManager) recordInputDrop(passcode, userID string) {
+	total := sm.inputDrops.Add(1)
+
+	sm.dropMu.Lock()
+	if sm.inputDropsByPasscode == nil {
+		sm.inputDropsByPasscode = make(map[string]int64)
+		sm.inputDropsByUser = make(map[string]int64)
+	}
+	sm.inputDropsByPasscode[passcode]++
+	sm.inputDropsByUser[userID]++
+	sm.dropMu.Unlock()
+
+	if total%DropWarningThreshold == 0 {
+		log.Printf("[SessionManager] WARNING: %d input events dropped in total (latest: passcode %s, user %s). Consider increasing the inputEvents buffer.", total, passcode, userID)
+	}
+}
+
+// recordBroadcastDrop はブロードキャストイベントのドロップを記録します。
+func (sm *SessionManager) recordBroadcastDrop() {
+	total := sm.broadcastDrops.Add(1)
+	if total%DropWarningThreshold == 0 {
+		log.Printf("[SessionManager] WARNING: %d broadcast events dropped in total. Consider increasing the broadcast buffer.", total)
+	}
+}
+
+// Stats は現在のセッション数・接続数と上限に対する使用率、メッセージドロップの集計を返します。
+// 返り値のマップはコピーなので、呼び出し側で自由に参照できます。
+func (sm *SessionManager) Stats() SessionStats {
+	sm.mu.RLock()
+	stats := SessionStats{
+		ActiveSessions:   len(sm.sessions),
+		ConnectedClients: len(sm.clients),
+		MaxSessions:      sm.maxSessions,
+		MaxClients:       sm.maxClients,
+	}
+	sm.mu.RUnlock()
+	stats.SessionUsage = usageRatio(stats.ActiveSessions, stats.MaxSessions)
+	stats.ClientUsage = usageRatio(stats.ConnectedClients, stats.MaxClients)
+
+	stats.InputDrops = sm.inputDrops.Load()
+	stats.BroadcastDrops = sm.broadcastDrops.Load()
+	stats.UnknownActions = sm.unknownActions.Load()
+	stats.MalformedMessages = sm.malformedMessages.Load()
+
+	sm.dropMu.Lock()
+	stats.InputDropsByPasscode = make(map[string]int64, len(sm.inputDropsByPasscode))
+	for passcode, count := range sm.inputDropsByPasscode {
+		stats.InputDropsByPasscode[passcode] = count
+	}
+	stats.InputDropsByUser = make(map[string]int64, len(sm.inputDropsByUser))
+	for userID, count := range sm.inputDropsByUser {
+		stats.InputDropsByUser[userID] = count
+	}
+	sm.dropMu.Unlock()
+
+	stats.ResultRetriesPending, stats.ResultRetrySuccesses, stats.ResultSaveAbandoned = sm.resultRetryStats()
+
+	return stats
+}
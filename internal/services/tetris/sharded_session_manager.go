@@ -0,0 +1,296 @@
+package tetris
+
+import (
+	"log"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/database"
+)
+
+// DefaultSessionShardCount は ShardedSessionManager のデフォルトのシャード数です。
+const DefaultSessionShardCount = 16
+
+// FNV-1a（32bit）のパラメータです（hash/fnv と同じ値）。
+const (
+	fnvOffset32 = 2166136261
+	fnvPrime32  = 16777619
+)
+
+// SessionService はHTTP/WebSocketハンドラが利用するセッション管理の操作です。
+// SessionManager（単一）と ShardedSessionManager（シャード分割）の両方が実装します。
+type SessionService interface {
+	JoinRoomByPasscode(passcode, playerID, playerDeckID string) (string, bool, error)
+	JoinRoomWithRules(passcode, playerID, playerDeckID string, rules GameRules) (string, bool, error)
+	CreateRoomWithGeneratedPasscode(userID, deckID, style string, rules GameRules) (string, error)
+	RegisterClient(passcode, userID string, conn *websocket.Conn, protocolVersion int) error
+	GetGameSession(passcode string) (*GameSession, bool)
+	DebugView(passcode string) (DebugView, bool)
+	DeleteSession(passcode string) error
+	DeleteSessionByPlayer(passcode, userID string) error
+	CancelRoom(passcode, userID string) error
+	ResetPlayerBoard(passcode, userID string, opts BoardResetOptions) error
+	IsUserConnected(userID string) bool
+	Stats() SessionStats
+	ListConnections() []ConnectionInfo
+	ListSessions() []SessionSummary
+	Shutdown()
+}
+
+var (
+	_ SessionService = (*SessionManager)(nil)
+	_ SessionService = (*ShardedSessionManager)(nil)
+)
+
+// ShardedSessionManager は合言葉のハッシュでセッションを複数の SessionManager（シャード）に振り分けます。
+// 各シャードは独立したセッション・クライアントのマップ、mutex、イベントループを持つため、
+// セッション数が増えても1つのmutexに待ちが集中しません。
+// 将来の水平スケールでは、同じハッシュでルームを担当ノードに割り当てることを想定しています。
+//
+// ルームに関する操作は合言葉から決まる1つのシャードだけで完結します。
+// クライアントは接続先ルームのシャードに登録されるため、ユーザー単位の問い合わせは全シャードを確認します。
+// ユーザー単位のルール（参加中のルーム数の上限・プレイ中の重複参加・1ユーザー1接続）は、ユーザーIDから決まる
+// ロック（userLockFor）で同じユーザーの作成・参加・接続を直列化したうえで、全シャードを確認して適用します。
+type ShardedSessionManager struct {
+	shards    []*SessionManager
+	userLocks [userLockCount]sync.Mutex // ユーザーIDのハッシュで選ぶ、同じユーザーの作成・参加・接続の直列化用のロック
+
+	maxSessionsPerUser int // 1人のユーザーが同時に参加できるセッション数の上限（全シャード合計、0で無制限）
+}
+
+// userLockCount は ShardedSessionManager がユーザー単位の操作の直列化に使うロックの数です。
+// 別のユーザーが同じロックを共有することはありますが、待ちが発生するだけで正しさには影響しません。
+const userLockCount = 64
+
+// NewShardedSessionManager は shardCount 個のシャードを持つ ShardedSessionManager を作成し、
+// 各シャードのイベントループをバックグラウンドで開始します。
+//
+// Parameters:
+//   shardCount : シャード数（1未満の場合は1）
+//   db         : データベースサービスへのポインタ
+//   deckRepo   : デッキリポジトリ
+//   resultRepo : ゲーム結果リポジトリ
+//   matchRepo  : 対戦履歴リポジトリ
+func NewShardedSessionManager(shardCount int, db *database.DatabaseService, deckRepo database.DeckRepository, resultRepo database.ResultRepository, matchRepo database.MatchHistoryRepository) *ShardedSessionManager {
+	if shardCount < 1 {
+		shardCount = 1
+	}
+	shards := make([]*SessionManager, shardCount)
+	// 上限は全体の値なので、セッション数・接続数を全シャードで共有して合計で判定する
+	// （シャードごとに割り振ると、ハッシュの偏りで1つのシャードが埋まった時点で全体に余裕があっても拒否してしまう）
+	capacity := &capacityUsage{}
+	for i := range shards {
+		shards[i] = NewSessionManager(db, deckRepo, resultRepo, matchRepo)
+		shards[i].capacity = capacity
+		// ユーザーのセッションは複数のシャードにまたがるため、ユーザーごとの上限は全シャードを集計して判定する
+		shards[i].maxSessionsPerUser = 0
+	}
+	return &ShardedSessionManager{shards: shards, maxSessionsPerUser: loadMaxSessionsPerUser()}
+}
+
+// fnv32 は文字列のFNV-1a（32bit）ハッシュを返します。全リクエストで呼ばれるためアロケーションなしで計算します。
+func fnv32(key string) uint32 {
+	h := uint32(fnvOffset32)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= fnvPrime32
+	}
+	return h
+}
+
+// shardFor は合言葉を担当するシャードを返します。
+func (s *ShardedSessionManager) shardFor(passcode string) *SessionManager {
+	return s.shards[fnv32(passcode)%uint32(len(s.shards))]
+}
+
+// userLockFor はユーザー単位の操作を直列化するロックを返します。
+func (s *ShardedSessionManager) userLockFor(userID string) *sync.Mutex {
+	return &s.userLocks[fnv32(userID)%userLockCount]
+}
+
+// JoinRoomByPasscode は合言葉を担当するシャードでルームに参加します。
+func (s *ShardedSessionManager) JoinRoomByPasscode(passcode, playerID, playerDeckID string) (string, bool, error) {
+	return s.JoinRoomWithRules(passcode, playerID, playerDeckID, DefaultGameRules())
+}
+
+// JoinRoomWithRules は合言葉を担当するシャードでルールを指定してルームに参加します。
+// ユーザーごとのセッション数の上限は、参加前に全シャードを集計して確認します。
+func (s *ShardedSessionManager) JoinRoomWithRules(passcode, playerID, playerDeckID string, rules GameRules) (string, bool, error) {
+	lock := s.userLockFor(playerID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := s.checkUserSessionLimit(playerID, passcode); err != nil {
+		return "", false, err
+	}
+	return s.shardFor(passcode).JoinRoomWithRules(passcode, playerID, playerDeckID, rules)
+}
+
+// CreateRoomWithGeneratedPasscode は生成した合言葉を担当するシャードでルームを作成します。
+// 合言葉ごとに担当シャードが変わるため、使用中かどうかの確認と作成はそのシャードの中で行います。
+func (s *ShardedSessionManager) CreateRoomWithGeneratedPasscode(userID, deckID, style string, rules GameRules) (string, error) {
+	if err := rules.Validate(); err != nil {
+		return "", err
+	}
+	if err := ValidateDeckID(deckID); err != nil {
+		return "", err
+	}
+	lock := s.userLockFor(userID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := s.checkUserSessionLimit(userID, ""); err != nil {
+		return "", err
+	}
+	// 担当シャードは合言葉を生成するまで決まらないため、デッキはシャードのロックを取る前にどのシャードからでも読み込める
+	player1, err := s.shards[0].loadPlayerState(userID, deckID)
+	if err != nil {
+		return "", err
+	}
+	return createWithGeneratedPasscode(style, func(passcode string) error {
+		return s.shardFor(passcode).createRoomIfAbsent(passcode, player1, rules)
+	})
+}
+
+// RegisterClient は合言葉を担当するシャードにWebSocketクライアントを登録します。
+// 単一の SessionManager と同じく1ユーザー1接続とするため、他のシャードに残っている同じユーザーの接続は切断します。
+func (s *ShardedSessionManager) RegisterClient(passcode, userID string, conn *websocket.Conn, protocolVersion int) error {
+	lock := s.userLockFor(userID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	target := s.shardFor(passcode)
+	s.disconnectFromOtherShards(userID, target)
+	return target.RegisterClient(passcode, userID, conn, protocolVersion)
+}
+
+// disconnectFromOtherShards は target 以外のシャードに登録されている userID の接続を切断します。
+// ALLOW_SAME_USER_JOIN=true の場合は、単一の SessionManager と同じく既存の接続を残します。
+// 呼び出し側で userLockFor(userID) のロックを保持している必要があります。
+func (s *ShardedSessionManager) disconnectFromOtherShards(userID string, target *SessionManager) {
+	if os.Getenv("ALLOW_SAME_USER_JOIN") == "true" {
+		return
+	}
+	for _, shard := range s.shards {
+		if shard != target && shard.disconnectClient(userID) {
+			log.Printf("[ShardedSessionManager] Replaced connection of user %s on another shard", userID)
+		}
+	}
+}
+
+// GetGameSession は合言葉を担当するシャードからゲームセッションを取得します。
+func (s *ShardedSessionManager) GetGameSession(passcode string) (*GameSession, bool) {
+	return s.shardFor(passcode).GetGameSession(passcode)
+}
+
+// DeleteSession は合言葉を担当するシャードのセッションを削除します。
+func (s *ShardedSessionManager) DeleteSession(passcode string) error {
+	return s.shardFor(passcode).DeleteSession(passcode)
+}
+
+// DebugView は合言葉を担当するシャードでセッションのデバッグ表示用の状態を返します。
+func (s *ShardedSessionManager) DebugView(passcode string) (DebugView, bool) {
+	return s.shardFor(passcode).DebugView(passcode)
+}
+
+// DeleteSessionByPlayer は合言葉を担当するシャードで、参加者の要求によりセッションを削除します。
+func (s *ShardedSessionManager) DeleteSessionByPlayer(passcode, userID string) error {
+	return s.shardFor(passcode).DeleteSessionByPlayer(passcode, userID)
+}
+
+// CancelRoom は合言葉を担当するシャードのルームを解散します。
+func (s *ShardedSessionManager) CancelRoom(passcode, userID string) error {
+	return s.shardFor(passcode).CancelRoom(passcode, userID)
+}
+
+// ResetPlayerBoard は合言葉のシャードでプレイヤーのボードをリセットします。
+func (s *ShardedSessionManager) ResetPlayerBoard(passcode, userID string, opts BoardResetOptions) error {
+	return s.shardFor(passcode).ResetPlayerBoard(passcode, userID, opts)
+}
+
+// checkUserSessionLimit は全シャードのセッションを集計し、userID が passcode のルームを作成・参加できるかを判定します。
+// 集計から作成・参加までの間に同じユーザーの作成・参加が割り込まないよう、呼び出し側で userLockFor(userID) のロックを保持してください。
+func (s *ShardedSessionManager) checkUserSessionLimit(userID, passcode string) error {
+	var total userSessionUsage
+	for _, shard := range s.shards {
+		shard.mu.RLock()
+		usage := shard.userSessionUsageLocked(userID, passcode)
+		shard.mu.RUnlock()
+		total.active += usage.active
+		if usage.playing != "" {
+			total.playing = usage.playing
+		}
+	}
+	return checkUserSessionLimit(userID, passcode, total, s.maxSessionsPerUser)
+}
+
+// IsUserConnected はいずれかのシャードにユーザーが接続しているかどうかを返します。
+func (s *ShardedSessionManager) IsUserConnected(userID string) bool {
+	for _, shard := range s.shards {
+		if shard.IsUserConnected(userID) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListConnections は全シャードの接続中クライアントをまとめてユーザーID順で返します。
+func (s *ShardedSessionManager) ListConnections() []ConnectionInfo {
+	connections := []ConnectionInfo{}
+	for _, shard := range s.shards {
+		connections = append(connections, shard.ListConnections()...)
+	}
+	sort.Slice(connections, func(i, j int) bool { return connections[i].UserID < connections[j].UserID })
+	return connections
+}
+
+// ListSessions は全シャードのセッションをまとめて合言葉順で返します。
+func (s *ShardedSessionManager) ListSessions() []SessionSummary {
+	summaries := []SessionSummary{}
+	for _, shard := range s.shards {
+		summaries = append(summaries, shard.ListSessions()...)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Passcode < summaries[j].Passcode })
+	return summaries
+}
+
+// Stats は全シャードの稼働状況とメッセージドロップの集計を合算して返します。
+func (s *ShardedSessionManager) Stats() SessionStats {
+	total := SessionStats{
+		InputDropsByPasscode: make(map[string]int64),
+		InputDropsByUser:     make(map[string]int64),
+	}
+	for _, shard := range s.shards {
+		stats := shard.Stats()
+		total.ActiveSessions += stats.ActiveSessions
+		total.ConnectedClients += stats.ConnectedClients
+		total.InputDrops += stats.InputDrops
+		total.BroadcastDrops += stats.BroadcastDrops
+		total.ResultRetriesPending += stats.ResultRetriesPending
+		total.ResultRetrySuccesses += stats.ResultRetrySuccesses
+		total.ResultSaveAbandoned += stats.ResultSaveAbandoned
+		total.UnknownActions += stats.UnknownActions
+		total.MalformedMessages += stats.MalformedMessages
+		// 上限は全シャードで共有している全体の値なので合計しない
+		total.MaxSessions = stats.MaxSessions
+		total.MaxClients = stats.MaxClients
+		for passcode, count := range stats.InputDropsByPasscode {
+			total.InputDropsByPasscode[passcode] += count
+		}
+		for userID, count := range stats.InputDropsByUser {
+			total.InputDropsByUser[userID] += count
+		}
+	}
+	total.SessionUsage = usageRatio(total.ActiveSessions, total.MaxSessions)
+	total.ClientUsage = usageRatio(total.ConnectedClients, total.MaxClients)
+	return total
+}
+
+// Shutdown は全シャードをシャットダウンします。
+func (s *ShardedSessionManager) Shutdown() {
+	for _, shard := range s.shards {
+		shard.Shutdown()
+	}
+}
@@ -0,0 +1,162 @@
+package tetris
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestShardedSessionManager はRunループやDBを起動せずにテスト用の ShardedSessionManager を作成します。
+func newTestShardedSessionManager(shardCount int) *ShardedSessionManager {
+	shards := make([]*SessionManager, shardCount)
+	capacity := &capacityUsage{}
+	for i := range shards {
+		shards[i] = newTestSessionManager()
+		shards[i].capacity = capacity
+	}
+	return &ShardedSessionManager{shards: shards}
+}
+
+// addFullRoom はテスト用に満室（待機中・2人参加済み）のルームを担当シャードに追加します。
+func addFullRoom(t testing.TB, sm SessionService, passcode string) {
+	session, err := NewGameSession(passcode, "player1", &models.Deck{ID: "deck-1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.SetPlayer2("player2", &models.Deck{ID: "deck-2"}, nil)
+	switch m := sm.(type) {
+	case *ShardedSessionManager:
+		m.shardFor(passcode).sessions[passcode] = session
+	case *SessionManager:
+		m.sessions[passcode] = session
+	}
+}
+
+// TestShardedSessionManager_RoutesByPasscode は合言葉ごとに同じシャードへ振り分けられ、集計が全シャードの合算になることをテストします。
+func TestShardedSessionManager_RoutesByPasscode(t *testing.T) {
+	sm := newTestShardedSessionManager(4)
+	for i := 0; i < 20; i++ {
+		addFullRoom(t, sm, fmt.Sprintf("room-%d", i))
+	}
+
+	for i := 0; i < 20; i++ {
+		passcode := fmt.Sprintf("room-%d", i)
+		assert.Same(t, sm.shardFor(passcode), sm.shardFor(passcode))
+		session, ok := sm.GetGameSession(passcode)
+		assert.True(t, ok, passcode)
+		assert.Equal(t, passcode, session.ID)
+
+		_, _, err := sm.JoinRoomByPasscode(passcode, "player3", testDeckID)
+		assert.ErrorIs(t, err, ErrRoomFull)
+	}
+
+	used := 0
+	for _, shard := range sm.shards {
+		if len(shard.sessions) > 0 {
+			used++
+		}
+	}
+	assert.Greater(t, used, 1, "セッションが複数のシャードに分散されるはず")
+	assert.Equal(t, 20, sm.Stats().ActiveSessions)
+
+	sm.shardFor("room-0").clients["player1"] = &Client{UserID: "player1", RoomID: "room-0"}
+	assert.True(t, sm.IsUserConnected("player1"))
+	assert.False(t, sm.IsUserConnected("player3"))
+}
+
+// passcodesOnDifferentShards はテスト用に、担当シャードが異なる2つの合言葉を返します。
+func passcodesOnDifferentShards(t *testing.T, sm *ShardedSessionManager) (string, string) {
+	for i := 1; i < 100; i++ {
+		other := fmt.Sprintf("room-%d", i)
+		if sm.shardFor(other) != sm.shardFor("room-0") {
+			return "room-0", other
+		}
+	}
+	t.Fatal("担当シャードが異なる合言葉が見つかりません")
+	return "", ""
+}
+
+// TestShardedSessionManager_UserRulesAcrossShards はユーザー単位のルールが、別のシャードのルームにまたがっても適用されることをテストします。
+func TestShardedSessionManager_UserRulesAcrossShards(t *testing.T) {
+	t.Setenv("ALLOW_SAME_USER_JOIN", "")
+	sm := newTestShardedSessionManager(4)
+	roomA, roomB := passcodesOnDifferentShards(t, sm)
+	shardA, shardB := sm.shardFor(roomA), sm.shardFor(roomB)
+
+	// 別のシャードでプレイ中のユーザーは参加できない
+	addFullRoom(t, sm, roomA)
+	shardA.sessions[roomA].Status = "playing"
+	_, _, err := sm.JoinRoomByPasscode(roomB, "player1", testDeckID)
+	assert.ErrorIs(t, err, ErrAlreadyPlaying)
+
+	// 別のシャードのルームに接続し直すと、古い接続は切断される
+	client := &Client{UserID: "player1", RoomID: roomA, Send: make(chan []byte, 1)}
+	shardA.clients["player1"] = client
+	sm.disconnectFromOtherShards("player1", shardB)
+	assert.NotContains(t, shardA.clients, "player1")
+	assert.True(t, client.closed, "古い接続の送信チャネルは閉じられるはず")
+	assert.True(t, shardA.sessions[roomA].Player1.disconnected)
+	assert.False(t, sm.IsUserConnected("player1"))
+
+	// 同じシャード（接続先のルーム）の接続はシャード自身が置き換えるので残す
+	shardB.clients["player1"] = &Client{UserID: "player1", RoomID: roomB, Send: make(chan []byte, 1)}
+	sm.disconnectFromOtherShards("player1", shardB)
+	assert.Contains(t, shardB.clients, "player1")
+}
+
+// TestShardedSessionManager_AllowSameUserJoin は ALLOW_SAME_USER_JOIN=true の場合、別のシャードの接続を残すことをテストします。
+func TestShardedSessionManager_AllowSameUserJoin(t *testing.T) {
+	t.Setenv("ALLOW_SAME_USER_JOIN", "true")
+	sm := newTestShardedSessionManager(4)
+	roomA, roomB := passcodesOnDifferentShards(t, sm)
+
+	sm.shardFor(roomA).clients["player1"] = &Client{UserID: "player1", RoomID: roomA, Send: make(chan []byte, 1)}
+	sm.disconnectFromOtherShards("player1", sm.shardFor(roomB))
+	assert.True(t, sm.IsUserConnected("player1"))
+}
+
+// BenchmarkSessionManager_Contention は多数のセッションに並行してアクセスしたときのロック競合を
+// 単一の SessionManager と ShardedSessionManager で比較します。
+// 参照（GetGameSession）と書き込みロックを取る参加（満室エラー）を 4:1 で混ぜて実行します。
+func BenchmarkSessionManager_Contention(b *testing.B) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	const sessionCount = 1000
+	managers := []struct {
+		name string
+		sm   SessionService
+	}{
+		{"single", newTestSessionManager()},
+		{"sharded", newTestShardedSessionManager(DefaultSessionShardCount)},
+	}
+	for _, m := range managers {
+		passcodes := make([]string, sessionCount)
+		for i := range passcodes {
+			passcodes[i] = fmt.Sprintf("room-%d", i)
+			addFullRoom(b, m.sm, passcodes[i])
+		}
+
+		var worker atomic.Int64
+		b.Run(m.name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				i := int(worker.Add(1)) * 7919 // ゴルーチンごとに異なるセッションから開始する
+				for pb.Next() {
+					passcode := passcodes[i%sessionCount]
+					if i%5 == 0 {
+						m.sm.JoinRoomByPasscode(passcode, "player3", testDeckID)
+					} else {
+						m.sm.GetGameSession(passcode)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package tetris
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strconv"
+)
+
+// EventConnectionUnstable は送信が追従できないクライアントをサーバーから切断することを通知するイベントの種類です。
+// クライアントはこのイベントを受け取ったら再接続フローに入ることを想定しています。
+const EventConnectionUnstable = "connection_unstable"
+
+// DefaultSlowClientMaxSendFailures は「追従できない」とみなして切断するまでの連続送信失敗回数のデフォルト値です。
+const DefaultSlowClientMaxSendFailures = 10
+
+// slowClientMaxSendFailures は実際に使用する切断しきい値です。
+// 環境変数 SLOW_CLIENT_MAX_SEND_FAILURES（1以上、0で切断しない）で上書きできます。
+var slowClientMaxSendFailures = loadSlowClientMaxSendFailures()
+
+// loadSlowClientMaxSendFailures は環境変数から切断しきい値を読み込みます。不正な値の場合はデフォルト値を使います。
+func loadSlowClientMaxSendFailures() int {
+	env := os.Getenv("SLOW_CLIENT_MAX_SEND_FAILURES")
+	if env == "" {
+		return DefaultSlowClientMaxSendFailures
+	}
+	n, err := strconv.Atoi(env)
+	if err != nil || n < 0 {
+		log.Printf("[WARN] Invalid SLOW_CLIENT_MAX_SEND_FAILURES %q, using default %d", env, DefaultSlowClientMaxSendFailures)
+		return DefaultSlowClientMaxSendFailures
+	}
+	return n
+}
+
+// sendOrDisconnect はクライアントにメッセージを送信し、連続失敗回数がしきい値に達したクライアントを切断します。
+// 遅いクライアントはSendチャネルが詰まったまま古い状態を受け取れずにズレ続けるため、
+// 一度切断して再接続（Register時の状態再送）で追いつかせます。
+// sm.mu を保持したまま呼ばれることがあるため、登録解除は非同期で行います。
+//
+// Returns:
+//   bool: 送信に成功したかどうか
+func (sm *SessionManager) sendOrDisconnect(client *Client, message []byte) bool {
+	if client.SafeSend(message) {
+		return true
+	}
+	if client.markSlowDisconnect(slowClientMaxSendFailures) {
+		sm.disconnectSlowClient(client)
+	}
+	return false
+}
+
+// markSlowDisconnect は連続送信失敗回数が maxFailures に達していれば切断処理中として印を付けます。
+// 切断は一度だけ行うため、既に印が付いている場合や閉じられている場合は false を返します。
+func (c *Client) markSlowDisconnect(maxFailures int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if maxFailures <= 0 || c.closed || c.slowDisconnecting || c.sendFailures < maxFailures {
+		return false
+	}
+	c.slowDisconnecting = true
+	return true
+}
+
+// replaceQueued はSendチャネルに溜まった未送信メッセージを捨て、message だけを積み直します。
+// 溜まっているのは古いゲーム状態なので、切断通知を確実に届けることを優先します。
+func (c *Client) replaceQueued(message []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+drain:
+	for {
+		select {
+		case <-c.Send:
+		default:
+			break drain
+		}
+	}
+	select {
+	case c.Send <- message:
+	default:
+	}
+}
+
+// disconnectSlowClient は切断通知を送ってから、クライアントを登録解除します。
+// 登録解除後の処理（ゲーム中なら再接続猶予）は通常の切断と同じ経路に乗ります。
+func (sm *SessionManager) disconnectSlowClient(client *Client) {
+	log.Printf("[SessionManager] Client %s cannot keep up (%d consecutive send failures), disconnecting", client.UserID, slowClientMaxSendFailures)
+
+	event, err := json.Marshal(RoomEvent{Type: EventConnectionUnstable, Version: ProtocolVersion, Message: "接続が不安定なため切断します"})
+	if err == nil {
+		client.replaceQueued(event)
+	}
+
+	go func() {
+		select {
+		case sm.unregister <- client:
+		case <-sm.quit:
+		}
+	}()
+}
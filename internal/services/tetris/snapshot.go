@@ -0,0 +1,274 @@
+package tetris
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
+)
+
+// PlayerSnapshotVersion はプレイヤー状態のスナップショットの形式のバージョンです。
+// 形式を変えた場合は上げてください。異なるバージョンのスナップショットは復元しません。
+const PlayerSnapshotVersion = 1
+
+// maxSnapshotDraws は復元時に進める乱数の消費回数の上限です（壊れたスナップショットで復元が終わらなくなるのを防ぐ）。
+const maxSnapshotDraws = 1 << 24
+
+// ErrInvalidSnapshot はスナップショットの形式・バージョンが不正で復元できない場合のエラーです。
+var ErrInvalidSnapshot = errors.New("スナップショットが不正です")
+
+// countingSource は乱数の消費回数を数える乱数ソースです。
+// math/rand の内部状態は取り出せないため、シードと消費回数を記録しておき、復元時は同じシードから同じ回数だけ進めます。
+type countingSource struct {
+	src   rand.Source64
+	seed  int64
+	draws uint64
+}
+
+func (s *countingSource) Int63() int64 {
+	s.draws++
+	return s.src.Int63()
+}
+
+func (s *countingSource) Uint64() uint64 {
+	s.draws++
+	return s.src.Uint64()
+}
+
+func (s *countingSource) Seed(seed int64) {
+	s.src.Seed(seed)
+	s.seed = seed
+	s.draws = 0
+}
+
+// seededRand はシードと消費回数からいつでも同じ状態を作り直せる乱数ジェネレータです。
+type seededRand struct {
+	*rand.Rand
+	source *countingSource
+}
+
+// newSeededRand は seed で初期化した乱数ジェネレータを返します。
+func newSeededRand(seed int64) *seededRand {
+	source := &countingSource{src: rand.NewSource(seed).(rand.Source64), seed: seed}
+	return &seededRand{Rand: rand.New(source), source: source}
+}
+
+// rngState は乱数ジェネレータのシードと消費回数です。
+type rngState struct {
+	Seed  int64  `json:"seed"`
+	Draws uint64 `json:"draws"`
+}
+
+// state は現在のシードと消費回数を返します。
+func (r *seededRand) state() rngState {
+	return rngState{Seed: r.source.seed, Draws: r.source.draws}
+}
+
+// restoreSeededRand はシードから消費回数だけ進めて、スナップショット時点と同じ乱数ジェネレータを作り直します。
+func restoreSeededRand(state rngState) (*seededRand, error) {
+	if state.Draws > maxSnapshotDraws {
+		return nil, fmt.Errorf("乱数の消費回数 %d が上限 %d を超えています: %w", state.Draws, maxSnapshotDraws, ErrInvalidSnapshot)
+	}
+	r := newSeededRand(state.Seed)
+	for i := uint64(0); i < state.Draws; i++ {
+		r.source.Int63()
+	}
+	return r, nil
+}
+
+// pieceSnapshot はスナップショット用のテトリミノです。
+// ブロードキャストでは送らない ScoreData（各ブロックのスコア）も含めます。
+type pieceSnapshot struct {
+	Type      tetris.PieceType `json:"type"`
+	X         int              `json:"x"`
+	Y         int              `json:"y"`
+	Rotation  int              `json:"rotation"`
+	ScoreData map[string]int   `json:"score_data,omitempty"`
+}
+
+func snapshotPiece(p *tetris.Piece) *pieceSnapshot {
+	if p == nil {
+		return nil
+	}
+	return &pieceSnapshot{Type: p.Type, X: p.X, Y: p.Y, Rotation: p.Rotation, ScoreData: p.ScoreData}
+}
+
+func (p *pieceSnapshot) piece() *tetris.Piece {
+	if p == nil {
+		return nil
+	}
+	scoreData := p.ScoreData
+	if scoreData == nil {
+		scoreData = make(map[string]int)
+	}
+	return &tetris.Piece{Type: p.Type, X: p.X, Y: p.Y, Rotation: p.Rotation, ScoreData: scoreData}
+}
+
+// playerSnapshot はセッションの永続化・リプレイ用のプレイヤー状態です。
+// 毎秒ブロードキャストする軽量版（LightweightPlayerState）とは別に、以降のゲーム進行を完全に再現するための
+// 非公開の状態（ピースキュー・乱数の状態・ホールドの使用状況・お邪魔ラインの予告など）を含みます。
+type playerSnapshot struct {
+	Version             int                  `json:"version"`
+	UserID              string               `json:"user_id"`
+	Board               tetris.Board         `json:"board"`
+	CurrentPiece        *pieceSnapshot       `json:"current_piece"`
+	NextPiece           *pieceSnapshot       `json:"next_piece"`
+	HeldPiece           *pieceSnapshot       `json:"held_piece"`
+	Score               int                  `json:"score"`
+	LinesCleared        int                  `json:"lines_cleared"`
+	Level               int                  `json:"level"`
+	IsGameOver          bool                 `json:"is_game_over"`
+	TopOut              string               `json:"top_out,omitempty"`
+	Deck                *models.Deck         `json:"deck"`
+	ContributionScores  map[string]int       `json:"contribution_scores"`
+	CurrentPieceScores  map[string]int       `json:"current_piece_scores"`
+	DeckPlacements      []tetris.DeckPlacementPiece `json:"deck_placements"`
+	ConsecutiveClears   int                  `json:"consecutive_clears"`
+	BackToBack          bool                 `json:"back_to_back"`
+	PieceQueue          []tetris.PieceType   `json:"piece_queue"`          // 7-bagのキュー全体（NextPiece の次以降）
+	Rand                rngState             `json:"rand"`                 // ピース生成用の乱数の状態
+	PieceRand           *rngState            `json:"piece_rand,omitempty"` // 共通シードのルールの7-bag専用の乱数の状態
+	HasUsedHold         bool                 `json:"has_used_hold"`
+	HoldDisabled        bool                 `json:"hold_disabled"`
+	Handicap            PlayerHandicap       `json:"handicap"`
+	LastMoveWasRotation bool                 `json:"last_move_was_rotation"`
+	PendingGarbage      int                  `json:"pending_garbage"`
+}
+
+// MarshalSnapshot はプレイヤーの状態を、セッションの永続化・リプレイ用にシリアライズします。
+// ピースキュー全体と乱数のシード・消費回数を含むため、RestoreSnapshot で復元すると以降のピース順が完全に一致します。
+// 操作の適用と並行して呼び出さないでください（ゲームループの開始前か、gameMu を保持した状態で呼び出します）。
+//
+// Returns:
+//   []byte: スナップショットのJSON
+//   error: シリアライズに失敗した場合
+func (s *PlayerGameState) MarshalSnapshot() ([]byte, error) {
+	s.mu.RLock()
+	currentPieceScores := make(map[string]int, len(s.CurrentPieceScores))
+	for key, score := range s.CurrentPieceScores {
+		currentPieceScores[key] = score
+	}
+	s.mu.RUnlock()
+
+	snapshot := playerSnapshot{
+		Version:             PlayerSnapshotVersion,
+		UserID:              s.UserID,
+		Board:               s.Board,
+		CurrentPiece:        snapshotPiece(s.CurrentPiece),
+		NextPiece:           snapshotPiece(s.NextPiece),
+		HeldPiece:           snapshotPiece(s.HeldPiece),
+		Score:               s.Score,
+		LinesCleared:        s.LinesCleared,
+		Level:               s.Level,
+		IsGameOver:          s.IsGameOver,
+		TopOut:              s.topOutKind,
+		Deck:                s.Deck,
+		ContributionScores:  s.ContributionScores,
+		CurrentPieceScores:  currentPieceScores,
+		DeckPlacements:      s.DeckPlacements,
+		ConsecutiveClears:   s.ConsecutiveClears,
+		BackToBack:          s.BackToBack,
+		PieceQueue:          s.pieceQueue,
+		Rand:                s.randGenerator.state(),
+		HasUsedHold:         s.hasUsedHold,
+		HoldDisabled:        s.holdDisabled,
+		Handicap:            s.handicap,
+		LastMoveWasRotation: s.lastMoveWasRotation,
+		PendingGarbage:      s.pendingGarbage,
+	}
+	if s.pieceRand != nil {
+		pieceRand := s.pieceRand.state()
+		snapshot.PieceRand = &pieceRand
+	}
+	return json.Marshal(snapshot)
+}
+
+// RestoreSnapshot は MarshalSnapshot で作成したスナップショットからプレイヤーの状態を復元します。
+// 復元に失敗した場合（形式・バージョンが不正な場合）は状態を変更しません。
+// APM/PPSの計測や操作頻度のカウンターは復元せず、自動落下の基準時刻は現在時刻になります。
+//
+// Parameters:
+//   data : MarshalSnapshot が返したJSON
+func (s *PlayerGameState) RestoreSnapshot(data []byte) error {
+	var snapshot playerSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	if snapshot.Version != PlayerSnapshotVersion {
+		return fmt.Errorf("バージョン %d には対応していません（対応: %d）: %w", snapshot.Version, PlayerSnapshotVersion, ErrInvalidSnapshot)
+	}
+	if snapshot.UserID == "" {
+		return fmt.Errorf("user_id がありません: %w", ErrInvalidSnapshot)
+	}
+	pieceTypes := append([]tetris.PieceType{}, snapshot.PieceQueue...)
+	for _, p := range []*pieceSnapshot{snapshot.CurrentPiece, snapshot.NextPiece, snapshot.HeldPiece} {
+		if p != nil {
+			pieceTypes = append(pieceTypes, p.Type)
+		}
+	}
+	for _, pieceType := range pieceTypes {
+		if pieceType < tetris.TypeI || pieceType > tetris.TypeL {
+			return fmt.Errorf("不明なピースの種類 %d があります: %w", pieceType, ErrInvalidSnapshot)
+		}
+	}
+
+	randGenerator, err := restoreSeededRand(snapshot.Rand)
+	if err != nil {
+		return err
+	}
+	var pieceRand *seededRand
+	if snapshot.PieceRand != nil {
+		if pieceRand, err = restoreSeededRand(*snapshot.PieceRand); err != nil {
+			return err
+		}
+	}
+
+	contributionScores := snapshot.ContributionScores
+	if contributionScores == nil {
+		contributionScores = make(map[string]int)
+	}
+	currentPieceScores := snapshot.CurrentPieceScores
+	if currentPieceScores == nil {
+		currentPieceScores = make(map[string]int)
+	}
+	deckPlacements := snapshot.DeckPlacements
+	if deckPlacements == nil {
+		deckPlacements = []tetris.DeckPlacementPiece{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.UserID = snapshot.UserID
+	s.Board = snapshot.Board
+	s.CurrentPiece = snapshot.CurrentPiece.piece()
+	s.NextPiece = snapshot.NextPiece.piece()
+	s.HeldPiece = snapshot.HeldPiece.piece()
+	s.Score = snapshot.Score
+	s.LinesCleared = snapshot.LinesCleared
+	s.Level = snapshot.Level
+	s.IsGameOver = snapshot.IsGameOver
+	s.topOutKind = snapshot.TopOut
+	s.Deck = snapshot.Deck
+	s.ContributionScores = contributionScores
+	s.CurrentPieceScores = currentPieceScores
+	s.DeckPlacements = deckPlacements
+	s.ConsecutiveClears = snapshot.ConsecutiveClears
+	s.BackToBack = snapshot.BackToBack
+	s.pieceQueue = append([]tetris.PieceType{}, snapshot.PieceQueue...)
+	s.randGenerator = randGenerator
+	s.pieceRand = pieceRand
+	s.hasUsedHold = snapshot.HasUsedHold
+	s.holdDisabled = snapshot.HoldDisabled
+	s.handicap = snapshot.Handicap
+	s.lastMoveWasRotation = snapshot.LastMoveWasRotation
+	s.pendingGarbage = snapshot.PendingGarbage
+	s.lastEvents = nil
+	s.lastClearedRows = nil
+	s.outgoingGarbage = 0
+	s.lastFallTime = time.Now()
+	return nil
+}
@@ -0,0 +1,87 @@
+package tetris
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drawPieceTypes は n 個のピースをキューから取り出し、種類を返します。
+func drawPieceTypes(state *PlayerGameState, n int) []tetris.PieceType {
+	types := make([]tetris.PieceType, 0, n)
+	for i := 0; i < n; i++ {
+		types = append(types, state.GetNextPieceFromQueue().Type)
+	}
+	return types
+}
+
+// TestSnapshot_RestoresPieceOrder はスナップショットから復元した状態で、以降のピース順（新しいバッグの生成を含む）が完全に一致することをテストします。
+func TestSnapshot_RestoresPieceOrder(t *testing.T) {
+	for _, shared := range []bool{false, true} {
+		original := NewPlayerGameState("user-1", nil)
+		if shared {
+			original.reseedPieceQueue(12345)
+		}
+		drawPieceTypes(original, 10) // バッグの途中まで進める
+		original.Score = 1234
+		original.hasUsedHold = true
+		original.pendingGarbage = 3
+		original.HeldPiece = &tetris.Piece{Type: tetris.TypeT, ScoreData: map[string]int{"rot_0_1_0": 250}}
+
+		data, err := original.MarshalSnapshot()
+		require.NoError(t, err)
+
+		restored := &PlayerGameState{}
+		require.NoError(t, restored.RestoreSnapshot(data))
+
+		assert.Equal(t, original.UserID, restored.UserID)
+		assert.Equal(t, original.Board, restored.Board)
+		assert.Equal(t, 1234, restored.Score)
+		assert.True(t, restored.hasUsedHold)
+		assert.Equal(t, 3, restored.pendingGarbage)
+		assert.Equal(t, original.CurrentPiece, restored.CurrentPiece)
+		assert.Equal(t, original.HeldPiece, restored.HeldPiece, "ホールド中のピースのスコア情報も復元されるはず")
+		assert.Equal(t, original.pieceQueue, restored.pieceQueue)
+		assert.Equal(t, shared, restored.pieceRand != nil)
+
+		assert.Equal(t, drawPieceTypes(original, 50), drawPieceTypes(restored, 50), "shared=%v: 復元後のピース順が一致するはず", shared)
+	}
+}
+
+// TestSnapshot_SeparateFromBroadcast はブロードキャスト用のJSONにはピースキューや乱数の状態が含まれないことをテストします。
+func TestSnapshot_SeparateFromBroadcast(t *testing.T) {
+	state := NewPlayerGameState("user-1", nil)
+
+	broadcast, err := json.Marshal(state)
+	require.NoError(t, err)
+	assert.NotContains(t, string(broadcast), "piece_queue")
+	assert.NotContains(t, string(broadcast), "draws")
+
+	snapshot, err := state.MarshalSnapshot()
+	require.NoError(t, err)
+	assert.Contains(t, string(snapshot), "piece_queue")
+	assert.Contains(t, string(snapshot), "draws")
+}
+
+// TestSnapshot_RejectsInvalid は不正なスナップショットを拒否し、状態を変更しないことをテストします。
+func TestSnapshot_RejectsInvalid(t *testing.T) {
+	state := NewPlayerGameState("user-1", nil)
+	queue := append([]tetris.PieceType{}, state.pieceQueue...)
+
+	for _, data := range []string{
+		`not json`,
+		`{"version":999,"user_id":"user-2"}`,
+		`{"version":1}`,
+		`{"version":1,"user_id":"user-2","piece_queue":[9]}`,
+		`{"version":1,"user_id":"user-2","rand":{"seed":1,"draws":99999999999}}`,
+	} {
+		err := state.RestoreSnapshot([]byte(data))
+		assert.True(t, errors.Is(err, ErrInvalidSnapshot), "%s: %v", data, err)
+	}
+	assert.Equal(t, "user-1", state.UserID)
+	assert.Equal(t, queue, state.pieceQueue)
+}
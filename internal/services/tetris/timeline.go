@@ -0,0 +1,68 @@
+package tetris
+
+import (
+	"sync"
+	"time"
+)
+
+// MaxStateTransitions はセッションごとに保持する状態遷移の記録の上限です。超えた場合は古いものから捨てます。
+const MaxStateTransitions = 50
+
+// StateTransition はセッションの状態遷移（タイムライン）の1件分の記録です。
+// ステータスが変わらない出来事（プレイヤー2の参加、開始条件を満たさなかった理由など）は From と To が同じになります。
+type StateTransition struct {
+	From   string    `json:"from"`             // 遷移前のステータス（作成時は空）
+	To     string    `json:"to"`               // 遷移後のステータス
+	At     time.Time `json:"at"`               // 記録した時刻
+	Detail string    `json:"detail,omitempty"` // 遷移のきっかけ・理由
+}
+
+// sessionTimeline は状態遷移の記録です。GetRoomStatus や管理APIから sm.mu を持たずに読めるよう、専用のロックで保護します。
+type sessionTimeline struct {
+	mu          sync.Mutex
+	transitions []StateTransition
+	dropped     int // 上限を超えて捨てた記録の数
+}
+
+// append は記録を追記し、上限を超えた分を古いものから捨てます。
+func (t *sessionTimeline) append(entry StateTransition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.transitions = append(t.transitions, entry)
+	if over := len(t.transitions) - MaxStateTransitions; over > 0 {
+		t.transitions = append(t.transitions[:0:0], t.transitions[over:]...)
+		t.dropped += over
+	}
+}
+
+// setStatus はステータスを変更し、状態遷移として記録します。
+// Status と同じく sm.mu を保持した状態で呼び出してください。
+func (gs *GameSession) setStatus(to, detail string) {
+	from := gs.Status
+	gs.Status = to
+	gs.timeline.append(StateTransition{From: from, To: to, At: time.Now(), Detail: detail})
+}
+
+// recordEvent はステータスが変わらない出来事をタイムラインに記録します。
+// 接続のたびに同じ理由が記録されて古い遷移が押し出されないよう、直前の記録と同じ内容の場合は記録しません。
+// Status を読むため sm.mu を保持した状態で呼び出してください。
+func (gs *GameSession) recordEvent(detail string) {
+	gs.timeline.mu.Lock()
+	if n := len(gs.timeline.transitions); n > 0 {
+		last := gs.timeline.transitions[n-1]
+		if last.From == gs.Status && last.To == gs.Status && last.Detail == detail {
+			gs.timeline.mu.Unlock()
+			return
+		}
+	}
+	gs.timeline.mu.Unlock()
+	gs.timeline.append(StateTransition{From: gs.Status, To: gs.Status, At: time.Now(), Detail: detail})
+}
+
+// Timeline は状態遷移の記録のコピーを古い順で返します。
+// 上限を超えて捨てた記録がある場合は、その件数も返します。
+func (gs *GameSession) Timeline() ([]StateTransition, int) {
+	gs.timeline.mu.Lock()
+	defer gs.timeline.mu.Unlock()
+	return append([]StateTransition{}, gs.timeline.transitions...), gs.timeline.dropped
+}
@@ -0,0 +1,62 @@
+package tetris
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// transitionSummary は比較しやすいよう、状態遷移の記録から時刻を除いた "from->to (detail)" の一覧を返します。
+func transitionSummary(transitions []StateTransition) []string {
+	summary := make([]string, 0, len(transitions))
+	for _, tr := range transitions {
+		summary = append(summary, fmt.Sprintf("%s->%s (%s)", tr.From, tr.To, tr.Detail))
+	}
+	return summary
+}
+
+// TestTimeline_GameFlow は作成から開始条件の待ち・開始・終了までの状態遷移が順に記録され、
+// 同じ理由で開始できなかった記録は接続のたびに重複しないことをテストします。
+func TestTimeline_GameFlow(t *testing.T) {
+	sm := newTestSessionManager()
+	session := newPlayingSession(t, "timeline-room")
+	session.Status = "waiting"
+	sm.sessions["timeline-room"] = session
+	sm.clients["player1"] = &Client{UserID: "player1", RoomID: "timeline-room", Send: make(chan []byte, 8)}
+
+	sm.CheckAndStartGame("timeline-room")
+	sm.CheckAndStartGame("timeline-room")
+	sm.clients["player2"] = &Client{UserID: "player2", RoomID: "timeline-room", Send: make(chan []byte, 8)}
+	sm.CheckAndStartGame("timeline-room")
+	sm.endGameSession("timeline-room", EndReasonGameOver, "player1")
+
+	transitions, dropped := session.Timeline()
+	assert.Equal(t, []string{
+		"->waiting (room_created)",
+		"waiting->waiting (start_conditions_not_met: players=2 connected=1)",
+		"waiting->playing (game_started)",
+		"playing->finished (ended: game_over)",
+	}, transitionSummary(transitions))
+	assert.Equal(t, 0, dropped)
+	for i := 1; i < len(transitions); i++ {
+		assert.False(t, transitions[i].At.Before(transitions[i-1].At), "記録は古い順のはず")
+	}
+}
+
+// TestTimeline_Limit は記録が MaxStateTransitions 件を超えると古いものから捨て、捨てた件数を返すことをテストします。
+func TestTimeline_Limit(t *testing.T) {
+	session := newPlayingSession(t, "timeline-limit")
+	for i := 0; i < MaxStateTransitions+10; i++ {
+		session.recordEvent(fmt.Sprintf("event-%d", i))
+	}
+
+	transitions, dropped := session.Timeline()
+	assert.Len(t, transitions, MaxStateTransitions)
+	assert.Equal(t, 11, dropped, "作成時の記録を含めて11件捨てるはず")
+	assert.Equal(t, fmt.Sprintf("event-%d", MaxStateTransitions+9), transitions[len(transitions)-1].Detail)
+
+	transitions[0].Detail = "changed"
+	copied, _ := session.Timeline()
+	assert.NotEqual(t, "changed", copied[0].Detail, "返す記録はコピーのはず")
+}
@@ -0,0 +1,57 @@
+package tetris
+
+import (
+	"log"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models/tetris"
+)
+
+// トップアウト（積み上がりによるゲームオーバー）の種類です。
+// ゲーム状態の各プレイヤーの top_out と game_result イベントの top_outs で通知し、クライアントはゲームオーバー演出の出し分けに使います。
+const (
+	TopOutBlockOut = "block_out"   // ブロックアウト: 新しいピース（ホールドから出したピースを含む）のスポーン位置が既に埋まっている
+	TopOutLockOut  = "lock_out"    // ロックアウト: スポーンはできたが、表示部分に1マスも入らないまま隠し行の中で固定された
+	TopOutGarbage  = "garbage_out" // せり上げアウト: お邪魔ラインのせり上げで、既存のブロックが表示部分の上端を超えた
+)
+
+// lockedAboveVisible はピースの全ブロックが隠し行の中にある（表示部分に1マスも入っていない）かどうかを返します。
+// 一部でも表示部分に入っていればロックアウトではありません。
+func lockedAboveVisible(piece *tetris.Piece) bool {
+	if piece == nil {
+		return false
+	}
+	for _, block := range piece.Blocks() {
+		if piece.Y+block[1] >= tetris.BoardHiddenHeight {
+			return false
+		}
+	}
+	return true
+}
+
+// applyPendingGarbage は予告中のお邪魔ラインをせり上げます。
+// せり上げで既存のブロックが表示部分の上端を超えた（隠し行に押し上げられた、またはボードの外に押し出された）場合はトップアウトにします。
+// ピースの固定後・次のピースのスポーン前に呼び出してください（次のピースとの衝突は SpawnNewPiece のブロックアウト判定で再判定されます）。
+func (s *PlayerGameState) applyPendingGarbage() {
+	if s.pendingGarbage <= 0 {
+		return
+	}
+	lines := s.pendingGarbage
+	s.pendingGarbage = 0
+	if s.Board.AddGarbageLines(lines) != tetris.GarbageOverflowNone {
+		log.Printf("Player %s was pushed over the top by %d garbage lines", s.UserID, lines)
+		s.topOut(TopOutGarbage)
+	}
+}
+
+// topOut はトップアウトでプレイヤーをゲームオーバーにします。最初のトップアウトの種類だけを記録します。
+func (s *PlayerGameState) topOut(kind string) {
+	s.IsGameOver = true
+	if s.topOutKind == "" {
+		s.topOutKind = kind
+	}
+}
+
+// TopOut はプレイヤーのトップアウトの種類（TopOut* 定数）を返します。トップアウトしていない場合は空です。
+func (s *PlayerGameState) TopOut() string {
+	return s.topOutKind
+}
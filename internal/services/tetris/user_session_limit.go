@@ -0,0 +1,111 @@
+package tetris
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ErrUserSessionLimit はユーザーが参加中のルーム数が上限に達しているため、新しいルームの作成・参加を拒否した場合のエラーです。
+var ErrUserSessionLimit = errors.New("同時に参加できるルーム数の上限に達しています。参加中のルームを解散してから再度お試しください")
+
+// ErrAlreadyPlaying は既に他のルームでプレイ中のため、新しいルームの作成・参加を拒否した場合のエラーです。
+var ErrAlreadyPlaying = errors.New("既に他のルームでプレイ中です")
+
+// DefaultMaxSessionsPerUser は1人のユーザーが作成者・参加者として同時に参加できるセッション数のデフォルトの上限です。
+// 1人が大量のルームを作成してセッション数の上限（MAX_SESSIONS）を占有しないよう制限します。
+const DefaultMaxSessionsPerUser = 3
+
+// loadMaxSessionsPerUser は環境変数 MAX_SESSIONS_PER_USER からユーザーごとのセッション数の上限を読み込みます（0で無制限）。
+func loadMaxSessionsPerUser() int {
+	return loadCapacityLimit("MAX_SESSIONS_PER_USER", DefaultMaxSessionsPerUser)
+}
+
+// userSessionUsage はユーザーが参加中のセッションの集計です。
+type userSessionUsage struct {
+	active  int    // 参加中のセッション数
+	playing string // プレイ中（一時停止中を含む）のセッションの合言葉（無ければ空）
+}
+
+// userSessionUsageLocked は userID が作成者・参加者になっているアクティブなセッションを集計します。
+// 終了済み・終了処理中のセッションと、ユーザーが切断して戻っていないセッションは数えません。
+// exclude のセッション（参加しようとしているルーム自身）も数えません。
+// sm.mu を保持した状態で呼び出してください。
+func (sm *SessionManager) userSessionUsageLocked(userID, exclude string) userSessionUsage {
+	var usage userSessionUsage
+	for passcode, session := range sm.sessions {
+		if passcode == exclude || session.isDeleting || session.Status == "finished" {
+			continue
+		}
+		player := session.playerOf(userID)
+		if player == nil || player.disconnected {
+			continue
+		}
+		usage.active++
+		if session.isInProgress() {
+			usage.playing = passcode
+		}
+	}
+	return usage
+}
+
+// checkUserSessionLimit は集計したユーザーのセッションで、新しいルームの作成・参加を受け付けられるかを判定します。
+//
+// Parameters:
+//   userID   : 作成・参加しようとしているユーザーのID
+//   passcode : 作成・参加しようとしているルームの合言葉（ログ用）
+//   usage    : ユーザーが参加中のセッションの集計
+//   limit    : ユーザーごとのセッション数の上限（0で無制限）
+func checkUserSessionLimit(userID, passcode string, usage userSessionUsage, limit int) error {
+	if usage.playing != "" {
+		log.Printf("[SessionManager] Rejecting %s for user %s: already playing in passcode %s", passcode, userID, usage.playing)
+		return fmt.Errorf("user %s (playing in %s): %w", userID, usage.playing, ErrAlreadyPlaying)
+	}
+	if limit > 0 && usage.active >= limit {
+		log.Printf("[SessionManager] Rejecting %s for user %s: active sessions %d reached the per-user limit %d", passcode, userID, usage.active, limit)
+		return fmt.Errorf("user %s (%d/%d): %w", userID, usage.active, limit, ErrUserSessionLimit)
+	}
+	return nil
+}
+
+// checkUserSessionLimitLocked は userID が passcode のルームを作成・参加できるかを、このマネージャーのセッションで判定します。
+// sm.mu を保持した状態で呼び出してください。
+func (sm *SessionManager) checkUserSessionLimitLocked(userID, passcode string) error {
+	return checkUserSessionLimit(userID, passcode, sm.userSessionUsageLocked(userID, passcode), sm.maxSessionsPerUser)
+}
+
+// markPlayerConnectionLocked はセッションのプレイヤーの接続状態を記録します（切断済みのセッションはユーザーのセッション数に数えません）。
+// sm.mu を保持した状態で呼び出してください。
+func (sm *SessionManager) markPlayerConnectionLocked(passcode, userID string, connected bool) {
+	session, ok := sm.sessions[passcode]
+	if !ok {
+		return
+	}
+	if player := session.playerOf(userID); player != nil {
+		player.disconnected = !connected
+	}
+}
+
+
+// disconnectClient は userID の接続があれば登録を解除して切断し、切断したかどうかを返します。
+// ShardedSessionManager で同じユーザーが別のシャードのルームに接続し直した場合に、古い接続を閉じるために使います。
+// 接続を閉じると readPump が終了して登録解除を送るため、ゲーム中なら通常の切断と同じく再接続猶予が始まります。
+func (sm *SessionManager) disconnectClient(userID string) bool {
+	sm.mu.Lock()
+	client, ok := sm.clients[userID]
+	if ok {
+		delete(sm.clients, userID)
+		sm.syncCapacityLocked()
+		sm.markPlayerConnectionLocked(client.RoomID, userID, false)
+	}
+	sm.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	if client.Conn != nil {
+		client.Conn.Close()
+	}
+	client.SafeClose()
+	return true
+}
@@ -0,0 +1,66 @@
+package tetris
+
+import (
+	"testing"
+
+	"github.com/progate-hackathon-strawberry-flavor/GITRIS-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// newWaitingSession はテスト用に owner が作成した待機中（対戦相手なし）のセッションを作成します。
+func newWaitingSession(t *testing.T, passcode, owner string) *GameSession {
+	session, err := NewGameSession(passcode, owner, &models.Deck{ID: "deck-1"}, nil)
+	assert.NoError(t, err)
+	return session
+}
+
+// TestJoinRoom_UserSessionLimit は参加中のルーム数が上限に達したユーザーの参加を拒否し、
+// 終了済みのセッション・切断済みのセッションは数えないことをテストします。
+func TestJoinRoom_UserSessionLimit(t *testing.T) {
+	sm := newTestSessionManager()
+	sm.maxSessionsPerUser = 2
+	sm.sessions["room-a"] = newWaitingSession(t, "room-a", "player1")
+	sm.sessions["room-b"] = newWaitingSession(t, "room-b", "player1")
+	sm.sessions["target"] = newWaitingSession(t, "target", "player2")
+
+	_, _, err := sm.JoinRoomByPasscode("target", "player1", testDeckID)
+	assert.ErrorIs(t, err, ErrUserSessionLimit)
+
+	sm.sessions["room-a"].Status = "finished"
+	assert.Equal(t, 1, sm.userSessionUsageLocked("player1", "target").active, "終了済みのセッションは数えないはず")
+
+	sm.sessions["room-a"].Status = "waiting"
+	sm.markPlayerConnectionLocked("room-a", "player1", false)
+	assert.Equal(t, 1, sm.userSessionUsageLocked("player1", "target").active, "切断済みのセッションは数えないはず")
+
+	sm.markPlayerConnectionLocked("room-a", "player1", true)
+	assert.Equal(t, 2, sm.userSessionUsageLocked("player1", "target").active, "再接続したセッションは再び数えるはず")
+}
+
+// TestJoinRoom_AlreadyPlaying は他のルームでプレイ中のユーザーの参加を、上限に関係なく拒否することをテストします。
+func TestJoinRoom_AlreadyPlaying(t *testing.T) {
+	sm := newTestSessionManager()
+	sm.sessions["playing-room"] = newPlayingSession(t, "playing-room")
+	sm.sessions["target"] = newWaitingSession(t, "target", "player3")
+
+	_, _, err := sm.JoinRoomByPasscode("target", "player2", testDeckID)
+	assert.ErrorIs(t, err, ErrAlreadyPlaying)
+
+	sm.markPlayerConnectionLocked("playing-room", "player2", false)
+	assert.NoError(t, sm.checkUserSessionLimitLocked("player2", "target"), "切断したプレイ中のセッションは数えないはず")
+}
+
+// TestShardedSessionManager_UserSessionLimit はユーザーのセッションが複数のシャードにまたがっていても、合計で上限を判定することをテストします。
+func TestShardedSessionManager_UserSessionLimit(t *testing.T) {
+	s := newTestShardedSessionManager(4)
+	s.maxSessionsPerUser = 2
+	for _, passcode := range []string{"room-a", "room-b"} {
+		s.shardFor(passcode).sessions[passcode] = newWaitingSession(t, passcode, "player1")
+	}
+	s.shardFor("target").sessions["target"] = newWaitingSession(t, "target", "player2")
+
+	_, _, err := s.JoinRoomByPasscode("target", "player1", testDeckID)
+	assert.ErrorIs(t, err, ErrUserSessionLimit)
+	_, err = s.CreateRoomWithGeneratedPasscode("player1", testDeckID, DefaultPasscodeStyle, DefaultGameRules())
+	assert.ErrorIs(t, err, ErrUserSessionLimit)
+}